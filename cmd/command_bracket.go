@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func init() {
+	registerCommand(&bracket{})
+}
+
+type bracket struct{}
+
+func (bracket) name() string {
+	return "bracket"
+}
+
+func (bracket) alias() []string {
+	return []string{"aeb"}
+}
+
+func (b bracket) execute(c *ip.Client, f []string, asyncOut chan<- string) (res string) {
+	errorFmt := "bracket error: %s\n"
+
+	opts, err := parseBracketOptions(f)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	if opts.by == "" {
+		opts.by = ptpfmt.PRP_ExpBias
+		if v, err := c.GetDevicePropertyValue(ptp.DPC_ExposureProgramMode); err == nil {
+			if m, ok := devicePropValueToInt64(v); ok && ptp.ExposureProgramMode(m) == ptp.EPM_Manual {
+				opts.by = ptpfmt.PRP_Exposure
+			}
+		}
+	}
+
+	code := ptp.DPC_ExposureBiasCompensation
+	stops := func(v int64) float64 { return float64(v) / 1000 }
+	if opts.by == ptpfmt.PRP_Exposure {
+		code = ptp.DPC_ExposureTime
+		// The exposure time doubles with each stop.
+		stops = func(v int64) float64 {
+			if v <= 0 {
+				return math.Inf(1)
+			}
+			return math.Log2(float64(v))
+		}
+	}
+
+	dpd, err := c.GetDevicePropertyDescription(code)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	supported := dpd.SupportedValues(maxRampValues)
+	if len(supported) == 0 {
+		return fmt.Sprintf(errorFmt, fmt.Sprintf("property %s does not list the values it supports", opts.by))
+	}
+	if opts.dir != "" {
+		if err := os.MkdirAll(opts.dir, 0755); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+	}
+
+	vendor := c.ResponderVendor()
+	current := dpd.SignExtend(dpd.CurrentValueAsInt64())
+	display := func(v int64) string {
+		if s := ptpfmt.DevicePropValAsString(vendor, code, v); s != "" {
+			return s
+		}
+		return fmt.Sprintf("%#x", v)
+	}
+
+	// Always restore the original setting, also when a capture fails or the sequence is interrupted.
+	defer func() {
+		if err := c.SetDevicePropertyValue(code, current); err != nil {
+			res += fmt.Sprintf(errorFmt, fmt.Sprintf("restoring %s %s: %s", opts.by, display(current), err))
+			return
+		}
+		res += fmt.Sprintf("%s restored to %s\n", opts.by, display(current))
+	}()
+
+	var (
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	defer onInterrupt(func() { stopOnce.Do(func() { close(stop) }) })()
+
+	values := bracketValues(supported, current, opts.frames, opts.step, stops)
+	for n, v := range values {
+		select {
+		case <-stop:
+			return b.summary(n)
+		case <-quit:
+			return b.summary(n)
+		default:
+		}
+
+		msg := fmt.Sprintf("frame %d/%d %s %s", n+1, len(values), opts.by, display(v))
+		if err := c.SetDevicePropertyValue(code, v); err != nil {
+			return fmt.Sprintf(errorFmt, fmt.Sprintf("%s: %s", msg, err)) + b.summary(n)
+		}
+
+		if f, ok := onCapture.Load().(func()); ok {
+			f()
+		}
+		img, err := c.InitiateCapture()
+		if err != nil {
+			return fmt.Sprintf(errorFmt, fmt.Sprintf("%s: %s", msg, err)) + b.summary(n)
+		}
+		msg += " captured"
+
+		if opts.dir != "" && len(img) > 0 {
+			file := filepath.Join(opts.dir, fmt.Sprintf("bracket_%02d.jpg", n+1))
+			if err := ioutil.WriteFile(file, img, 0644); err != nil {
+				return fmt.Sprintf(errorFmt, err) + b.summary(n+1)
+			}
+			msg += ", preview saved to " + file
+		}
+		asyncOut <- msg
+	}
+
+	return b.summary(len(values))
+}
+
+// summary returns the amount of frames captured.
+func (bracket) summary(n int) string {
+	return fmt.Sprintf("%d frames captured\n", n)
+}
+
+func (b bracket) help() string {
+	help := `"` + b.name() + `" captures a sequence of images stepping the exposure bias compensation, or the exposure time when the camera is in manual mode, around the current setting. The first frame uses the current setting, followed by a darker and a brighter frame for each step. The original setting is restored afterwards. Pressing CTRL+C stops the sequence, except in server mode.` + "\n"
+
+	if args := b.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `=5"` + " is the amount of frames to capture, which defaults to 3\n"
+			case 1:
+				help += "\t- " + `"` + arg + `=2/3"` + " is the difference between two steps in stops, which defaults to 1\n"
+			case 2:
+				help += "\t- " + `"` + arg + `=exposure"` + " steps the exposure time instead of the exposure bias compensation, which is done automatically in manual mode\n"
+			case 3:
+				help += "\t- " + `"` + arg + `=/path"` + " saves the preview of each frame to the given directory if the camera returns it\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (bracket) arguments() []string {
+	return []string{"frames", "step", "by", "dir"}
+}
+
+func (bracket) examples() []string {
+	return []string{
+		"bracket",
+		"bracket frames=5 step=2/3",
+		"bracket frames=7 step=1 by=exposure dir=/tmp/hdr",
+	}
+}
+
+// bracketOptions holds the arguments of the bracket command.
+type bracketOptions struct {
+	frames int
+	// step is the difference between two steps in stops.
+	step float64
+	// by is the property to step, which is empty to pick one depending on the exposure program mode.
+	by  string
+	dir string
+}
+
+func parseBracketOptions(f []string) (bracketOptions, error) {
+	opts := bracketOptions{frames: 3, step: 1}
+	for _, arg := range f {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return opts, fmt.Errorf("invalid argument '%s', use 'name=value'", arg)
+		}
+
+		switch kv[0] {
+		case "frames":
+			n, err := strconv.Atoi(kv[1])
+			if err != nil || n < 2 {
+				return opts, fmt.Errorf("invalid amount of frames '%s', at least 2 frames are required", kv[1])
+			}
+			opts.frames = n
+		case "step":
+			s, err := ptpfmt.ParseExposureBias(kv[1])
+			if err != nil || s <= 0 {
+				return opts, fmt.Errorf("invalid step '%s', use stops such as '1/3' or '1'", kv[1])
+			}
+			opts.step = s
+		case "by":
+			if kv[1] != ptpfmt.PRP_ExpBias && kv[1] != ptpfmt.PRP_Exposure {
+				return opts, fmt.Errorf("invalid property '%s', use %s or %s", kv[1], ptpfmt.PRP_ExpBias, ptpfmt.PRP_Exposure)
+			}
+			opts.by = kv[1]
+		case "dir":
+			opts.dir = kv[1]
+		default:
+			return opts, fmt.Errorf("unknown argument '%s'", kv[0])
+		}
+	}
+
+	return opts, nil
+}
+
+// bracketOffsets returns the offset in steps from the current setting for each frame: 0, -1, 1, -2, 2 and so on.
+func bracketOffsets(frames int) []int {
+	offsets := make([]int, frames)
+	for i := range offsets {
+		offsets[i] = (i + 1) / 2
+		if i%2 == 1 {
+			offsets[i] = -offsets[i]
+		}
+	}
+
+	return offsets
+}
+
+// bracketValues returns the value for each frame, which is the supported value closest to the current value offset by
+// the frame's amount of steps. The stops function converts a value to stops so the closest value can be found for the
+// exposure bias compensation as well as for the exposure time. Values beyond the range of the camera are limited to the
+// lowest or highest supported value.
+func bracketValues(supported []int64, current int64, frames int, step float64, stops func(int64) float64) []int64 {
+	base := stops(current)
+
+	values := make([]int64, frames)
+	for i, off := range bracketOffsets(frames) {
+		target := base + float64(off)*step
+		best := supported[0]
+		for _, s := range supported {
+			if math.Abs(stops(s)-target) < math.Abs(stops(best)-target) {
+				best = s
+			}
+		}
+		values[i] = best
+	}
+
+	return values
+}
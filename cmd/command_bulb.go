@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerCommand(&bulb{})
+}
+
+type bulb struct{}
+
+func (bulb) name() string {
+	return "bulb"
+}
+
+func (bulb) alias() []string {
+	return []string{"longexposure"}
+}
+
+func (b bulb) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "bulb error: %s\n"
+
+	d, err := parseBulbDuration(f)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	var (
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	defer onInterrupt(func() { stopOnce.Do(func() { close(stop) }) })()
+
+	if f, ok := onCapture.Load().(func()); ok {
+		f()
+	}
+	oc, err := c.InitiateOpenCapture()
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	deadline := time.NewTimer(d)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	aborted := false
+exposing:
+	for {
+		select {
+		case <-ticker.C:
+			asyncOut <- fmt.Sprintf("exposing %s of %s", time.Since(oc.Started).Round(time.Second), d)
+		case <-deadline.C:
+			break exposing
+		case <-stop:
+			aborted = true
+			break exposing
+		case <-quit:
+			aborted = true
+			break exposing
+		}
+	}
+
+	res, err := c.TerminateOpenCapture(oc)
+	elapsed := time.Since(oc.Started).Round(100 * time.Millisecond)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, fmt.Sprintf("closing the shutter after %s: %s, check the camera", elapsed, err))
+	}
+
+	msg := fmt.Sprintf("exposure of %s done", elapsed)
+	if aborted {
+		msg = fmt.Sprintf("exposure aborted after %s", elapsed)
+	}
+
+	return msg + b.handles(res) + "\n"
+}
+
+// handles lists the handles of the captured objects, if the camera reported any.
+func (bulb) handles(res *ip.CaptureResult) string {
+	if res == nil || len(res.Handles) == 0 {
+		return ""
+	}
+
+	h := make([]string, len(res.Handles))
+	for i, handle := range res.Handles {
+		h[i] = fmt.Sprintf("%#x", handle)
+	}
+
+	return ", captured object handles: " + strings.Join(h, ", ")
+}
+
+func (b bulb) help() string {
+	help := `"` + b.name() + `" opens the shutter for the given duration, which is meant for exposures longer than the camera allows with the camera set to bulb mode. The elapsed time is printed every second. Pressing CTRL+C closes the shutter early, except in server mode.` + "\n"
+
+	if args := b.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `"` + " is the time to keep the shutter open, either as a duration such as '2m30s' or in seconds, which is required\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (bulb) arguments() []string {
+	return []string{"duration"}
+}
+
+func (bulb) examples() []string {
+	return []string{
+		"bulb 30s",
+		"bulb 2m30s",
+		"bulb 90",
+	}
+}
+
+// parseBulbDuration parses the single duration argument of the bulb command, which is either a Go duration such as
+// '2m30s' or a number of seconds.
+func parseBulbDuration(f []string) (time.Duration, error) {
+	if len(f) != 1 {
+		return 0, errors.New("a single duration is required, e.g. 'bulb 30s'")
+	}
+
+	d, err := parseScriptSleep(f[0])
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration '%s'", f[0])
+	}
+
+	return d, nil
+}
@@ -8,8 +8,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
+// onCapture holds a func() which is called right before the shutter is released, allowing the live view to start the
+// self-timer countdown.
+var onCapture atomic.Value
+
 func init() {
 	registerCommand(&capture{})
 }
@@ -72,6 +77,9 @@ func (cap capture) execute(c *ip.Client, f []string, asyncOut chan<- string) str
 		if amount > 1 {
 			asyncOut <- fmt.Sprintf("  capturing image %d", i+1)
 		}
+		if f, ok := onCapture.Load().(func()); ok {
+			f()
+		}
 		var err error
 		img, err := c.InitiateCapture()
 		if err != nil {
@@ -97,7 +105,6 @@ func (cap capture) execute(c *ip.Client, f []string, asyncOut chan<- string) str
 
 func (cap capture) help() string {
 	help := `"` + cap.name() + `" will make the responder capture a single image.` + "\n"
-	help += helpAddAliases(cap.alias())
 
 	if args := cap.arguments(); len(args) > 0 {
 		help += helpAddArgumentsTitle()
@@ -120,6 +127,15 @@ func (capture) arguments() []string {
 	return []string{"amount", "view", "filepath"}
 }
 
+func (capture) examples() []string {
+	return []string{
+		"capture",
+		"capture 5",
+		"capture view",
+		"capture 3 /tmp/my-preview.jpg",
+	}
+}
+
 func (cap capture) isView(param string) bool {
 	return param == cap.arguments()[1]
 }
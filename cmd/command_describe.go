@@ -62,3 +62,18 @@ func (d describe) help() string {
 func (describe) arguments() []string {
 	return []string{"property", "json", "pretty"}
 }
+
+func (d describe) completions(args []string) []string {
+	if len(args) == 1 {
+		return d.arguments()[1:]
+	}
+
+	return completeProperty(args)
+}
+
+func (describe) examples() []string {
+	return []string{
+		"describe whitebalance",
+		"describe 0x5005 json pretty",
+	}
+}
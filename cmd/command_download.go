@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDownloadTemplate keeps the filename as it is known by the camera.
+const defaultDownloadTemplate = "%f"
+
+func init() {
+	registerCommand(&download{})
+}
+
+type download struct{}
+
+func (download) name() string {
+	return "download"
+}
+
+func (download) alias() []string {
+	return []string{"dl"}
+}
+
+func (d download) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "download error: %s\n"
+
+	var (
+		dir     = "."
+		name    = defaultDownloadTemplate
+		sel     objectSelection
+		history = downloadHistory{path: defaultDownloadHistoryFile(), key: responderKey(c)}
+	)
+	for _, arg := range f {
+		switch {
+		case strings.HasPrefix(arg, "dir="):
+			dir = strings.TrimPrefix(arg, "dir=")
+		case strings.HasPrefix(arg, "name="):
+			name = strings.TrimPrefix(arg, "name=")
+		case arg == "new":
+			since, err := history.last()
+			if err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+			sel.new, sel.since = true, since
+		default:
+			if err := sel.add(arg); err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+		}
+	}
+	if sel.empty() {
+		return fmt.Sprintf(errorFmt, "select the objects to download by handle, filename pattern or 'new'")
+	}
+
+	dl, err := ip.NewDownloader(c, dir, filenameTemplate(name))
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	dl.OnProgress = downloadProgress(asyncOut)
+
+	nodes, err := selectObjects(ip.NewObjectTree(c), sel)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	if len(nodes) == 0 {
+		return "no objects to download\n"
+	}
+
+	var (
+		res    string
+		failed int
+		newest time.Time
+	)
+	for _, n := range nodes {
+		p, err := dl.Download(n.Handle)
+		if err != nil {
+			failed++
+			res += fmt.Sprintf("%s: %s\n", n.Path(), err)
+			continue
+		}
+		res += fmt.Sprintf("%s -> %s\n", n.Path(), p)
+		if n.Info.CaptureDate.After(newest) {
+			newest = n.Info.CaptureDate
+		}
+	}
+
+	if !newest.IsZero() {
+		if err := history.save(newest); err != nil {
+			res += fmt.Sprintf("unable to remember the downloaded objects: %s\n", err)
+		}
+	}
+
+	return res + fmt.Sprintf("%d of %d objects downloaded\n", len(nodes)-failed, len(nodes))
+}
+
+func (d download) help() string {
+	help := `"` + d.name() + `" downloads objects from the camera, showing the progress while downloading.` + "\n"
+
+	if args := d.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- one or more " + arg + "s as listed by the ls command, e.g. '0x00000005'\n"
+			case 1:
+				help += "\t- one or more filename " + arg + "s, e.g. '*.JPG' or '/store_10000001/DCIM/*/DSCF00*'. A pattern holding a slash is matched against the full path of the objects\n"
+			case 2:
+				help += "\t- " + `"` + arg + `" downloads all objects captured after the newest object downloaded before` + "\n"
+			case 3:
+				help += "\t- " + `"` + arg + `=/path"` + " sets the directory to write the objects to, defaults to the current directory\n"
+			case 4:
+				help += "\t- " + `"` + arg + `=%Y/%m/%d/%f"` + " sets the name template for the files which can contain sub directories. The capture date is available as %Y, %y, %m, %d, %H, %M and %S. Use %f for the filename, %n for the filename without extension, %e for the extension, %h for the object handle, %s for a sequence number and %% for a percent sign. Defaults to '" + defaultDownloadTemplate + "'\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (download) arguments() []string {
+	return []string{"handle", "pattern", "new", "dir", "name"}
+}
+
+func (download) examples() []string {
+	return []string{
+		"download 0x00000005",
+		"download *.RAF dir=/tmp/raw",
+		"download new dir=/tmp/camera name=%Y-%m-%d/%f",
+	}
+}
+
+// objectSelection selects objects by handle, by filename pattern or by capture date.
+type objectSelection struct {
+	handles  []ptp.ObjectHandle
+	patterns []string
+	// new selects the objects captured after since.
+	new   bool
+	since time.Time
+}
+
+// add adds a hexadecimal object handle in the form of '0x00000005' or a filename pattern to the selection.
+func (s *objectSelection) add(arg string) error {
+	if strings.HasPrefix(arg, "0x") {
+		h, err := strconv.ParseUint(arg[2:], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid object handle '%s'", arg)
+		}
+		s.handles = append(s.handles, ptp.ObjectHandle(h))
+		return nil
+	}
+
+	if _, err := path.Match(arg, ""); err != nil {
+		return fmt.Errorf("invalid pattern '%s': %s", arg, err)
+	}
+	s.patterns = append(s.patterns, arg)
+
+	return nil
+}
+
+func (s objectSelection) empty() bool {
+	return len(s.handles) == 0 && len(s.patterns) == 0 && !s.new
+}
+
+// match returns true when the node is selected by any of the criteria.
+func (s objectSelection) match(n *ip.ObjectNode) bool {
+	for _, h := range s.handles {
+		if n.Handle == h {
+			return true
+		}
+	}
+
+	for _, p := range s.patterns {
+		name := n.Name()
+		if strings.Contains(p, "/") {
+			name = n.Path()
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return s.new && n.Info != nil && n.Info.CaptureDate.After(s.since)
+}
+
+// selectObjects walks the tree and returns all objects selected by sel. Directories are never selected.
+func selectObjects(t *ip.ObjectTree, sel objectSelection) ([]*ip.ObjectNode, error) {
+	var nodes []*ip.ObjectNode
+	err := t.Walk(func(n *ip.ObjectNode) error {
+		if !n.IsDir() && sel.match(n) {
+			nodes = append(nodes, n)
+		}
+		return nil
+	})
+
+	return nodes, err
+}
+
+// filenameTemplate converts a strftime style name template to the text/template syntax used by ip.Downloader.
+// Unknown directives are kept as they are.
+func filenameTemplate(s string) string {
+	directives := map[byte]string{
+		'Y': `{{.CaptureDate.Format "2006"}}`,
+		'y': `{{.CaptureDate.Format "06"}}`,
+		'm': `{{.CaptureDate.Format "01"}}`,
+		'd': `{{.CaptureDate.Format "02"}}`,
+		'H': `{{.CaptureDate.Format "15"}}`,
+		'M': `{{.CaptureDate.Format "04"}}`,
+		'S': `{{.CaptureDate.Format "05"}}`,
+		'f': `{{.Filename}}`,
+		'n': `{{.Basename}}`,
+		'e': `{{.Extension}}`,
+		'h': `{{printf "%08x" .Handle}}`,
+		's': `{{printf "%04d" .Sequence}}`,
+	}
+
+	var b, lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			// Quote the literal text so it cannot be mistaken for an action.
+			b.WriteString("{{" + strconv.Quote(lit.String()) + "}}")
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i == len(s)-1 {
+			lit.WriteByte(s[i])
+			continue
+		}
+		i++
+		if d, ok := directives[s[i]]; ok {
+			flush()
+			b.WriteString(d)
+			continue
+		}
+		if s[i] != '%' {
+			lit.WriteByte('%')
+		}
+		lit.WriteByte(s[i])
+	}
+	flush()
+
+	return b.String()
+}
+
+// downloadProgress returns a function reporting the progress of each download in steps of 10 percent.
+func downloadProgress(asyncOut chan<- string) func(h ptp.ObjectHandle, transferred, total int64) {
+	var (
+		last ptp.ObjectHandle
+		step int64 = -1
+	)
+
+	return func(h ptp.ObjectHandle, transferred, total int64) {
+		if total <= 0 {
+			return
+		}
+		if h != last {
+			last, step = h, -1
+		}
+		if s := transferred * 10 / total; s > step {
+			step = s
+			asyncOut <- fmt.Sprintf("%0#8x: %d%% of %d bytes", uint32(h), s*10, total)
+		}
+	}
+}
+
+// downloadHistory remembers the capture date of the newest object downloaded from each camera, so the objects that
+// are new since the previous download can be selected.
+type downloadHistory struct {
+	path string
+	key  string
+}
+
+// defaultDownloadHistoryFile returns the path of the download history in the ptp-ip directory of the user's
+// configuration directory, e.g. ~/.config/ptp-ip/downloads.json on Linux.
+func defaultDownloadHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ptp-ip", "downloads.json")
+}
+
+// responderKey identifies the camera in the download history by its GUID, or by its address when it has none.
+func responderKey(c *ip.Client) string {
+	if g := c.ResponderGUID(); g != uuid.Nil {
+		return g.String()
+	}
+
+	return c.CommandDataAddress()
+}
+
+func (dh downloadHistory) read() (map[string]time.Time, error) {
+	h := make(map[string]time.Time)
+	if dh.path == "" {
+		return h, nil
+	}
+
+	b, err := ioutil.ReadFile(dh.path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return h, json.Unmarshal(b, &h)
+}
+
+// last returns the capture date of the newest object downloaded from the camera, the zero time when nothing has been
+// downloaded yet.
+func (dh downloadHistory) last() (time.Time, error) {
+	h, err := dh.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return h[dh.key], nil
+}
+
+// save remembers the capture date of the newest object downloaded from the camera, unless a newer one is known.
+func (dh downloadHistory) save(newest time.Time) error {
+	if dh.path == "" {
+		return nil
+	}
+
+	h, err := dh.read()
+	if err != nil {
+		return err
+	}
+	if !newest.After(h[dh.key]) {
+		return nil
+	}
+	h[dh.key] = newest
+
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dh.path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(dh.path, b, 0600)
+}
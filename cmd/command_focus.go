@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"strconv"
+)
+
+func init() {
+	registerCommand(&focus{})
+}
+
+type focus struct{}
+
+func (focus) name() string {
+	return "focus"
+}
+
+func (focus) alias() []string {
+	return []string{"af"}
+}
+
+func (fc focus) execute(c *ip.Client, f []string, _ chan<- string) string {
+	errorFmt := "focus error: %s\n"
+
+	if len(f) < 1 {
+		return fmt.Sprintf(errorFmt, "the action is missing, use one of near, far, point or reset")
+	}
+
+	switch f[0] {
+	case fc.arguments()[0], fc.arguments()[1]:
+		if len(f) > 2 {
+			return fmt.Sprintf(errorFmt, "too many arguments")
+		}
+		steps := uint64(1)
+		if len(f) == 2 {
+			var err error
+			steps, err = strconv.ParseUint(f[1], 10, 16)
+			if err != nil || steps == 0 {
+				return fmt.Sprintf(errorFmt, fmt.Sprintf("invalid amount of steps '%s'", f[1]))
+			}
+		}
+		dir := ip.FocusNear
+		if f[0] == fc.arguments()[1] {
+			dir = ip.FocusFar
+		}
+		if err := c.DriveFocus(dir, uint16(steps)); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+		return fmt.Sprintf("focus driven %d steps %s\n", steps, f[0])
+	case fc.arguments()[2]:
+		if len(f) != 3 {
+			return fmt.Sprintf(errorFmt, "the point needs an x and a y coordinate, e.g. 'focus point 4 3'")
+		}
+		var xy [2]uint16
+		for i, s := range f[1:] {
+			v, err := strconv.ParseUint(s, 10, 16)
+			if err != nil {
+				return fmt.Sprintf(errorFmt, fmt.Sprintf("invalid coordinate '%s'", s))
+			}
+			xy[i] = uint16(v)
+		}
+		if err := c.SetFocusPoint(xy[0], xy[1]); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+		return fmt.Sprintf("focus point set to %dx%d\n", xy[0], xy[1])
+	case fc.arguments()[3]:
+		if err := c.ResetFocusPoint(); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+		return "focus point reset\n"
+	}
+
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("unknown action '%s', use one of near, far, point or reset", f[0]))
+}
+
+func (fc focus) help() string {
+	help := `"` + fc.name() + `" drives the focus of the lens, which typically requires manual focus mode, or moves the focus area to compose the image remotely.` + "\n"
+
+	if args := fc.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `"` + " drives the focus towards the closest focusing distance, optionally followed by the amount of steps which defaults to 1\n"
+			case 1:
+				help += "\t- " + `"` + arg + `"` + " drives the focus towards infinity, optionally followed by the amount of steps which defaults to 1\n"
+			case 2:
+				help += "\t- " + `"` + arg + `"` + " followed by the x and y coordinate moves the focus area: Fuji cameras use the position in the grid of focus points, Nikon cameras use pixels of the live view image\n"
+			case 3:
+				help += "\t- " + `"` + arg + `"` + " moves the focus area back to its default position\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (focus) arguments() []string {
+	return []string{"near", "far", "point", "reset"}
+}
+
+func (fc focus) completions(args []string) []string {
+	if len(args) == 0 {
+		return fc.arguments()
+	}
+
+	return nil
+}
+
+func (focus) examples() []string {
+	return []string{
+		"focus near 10",
+		"focus far",
+		"focus point 4 3",
+		"focus reset",
+	}
+}
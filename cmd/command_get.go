@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
 	"github.com/malc0mn/ptp-ip/ip"
 )
 
@@ -33,7 +32,7 @@ func (get) execute(c *ip.Client, f []string, _ chan<- string) string {
 		return fmt.Sprintf(errorFmt, err)
 	}
 
-	return ptpfmt.DevicePropValAsString(c.ResponderVendor(), cod, int64(v)) + fmt.Sprintf(" (%#x)", v)
+	return formatDevicePropValue(c.ResponderVendor(), cod, v)
 }
 
 func (g get) help() string {
@@ -55,3 +54,14 @@ func (g get) help() string {
 func (get) arguments() []string {
 	return []string{"property"}
 }
+
+func (get) completions(args []string) []string {
+	return completeProperty(args)
+}
+
+func (get) examples() []string {
+	return []string{
+		"get iso",
+		"get 0x5005",
+	}
+}
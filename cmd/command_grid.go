@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/viewfinder"
+	"strings"
+	"sync/atomic"
+)
+
+// liveViewGrid holds the viewfinder.Grid drawn over the live view window.
+var liveViewGrid int32
+
+func init() {
+	registerCommand(&grid{})
+}
+
+type grid struct{}
+
+func (grid) name() string {
+	return "grid"
+}
+
+func (grid) alias() []string {
+	return []string{}
+}
+
+func (g grid) execute(_ *ip.Client, f []string, _ chan<- string) string {
+	if len(f) < 1 {
+		return fmt.Sprintf("grid: %s\n", viewfinder.Grid(atomic.LoadInt32(&liveViewGrid)))
+	}
+
+	vg, err := viewfinder.ParseGrid(f[0])
+	if err != nil {
+		return fmt.Sprintf("grid error: %s\n", err)
+	}
+	atomic.StoreInt32(&liveViewGrid, int32(vg))
+
+	return fmt.Sprintf("grid set to %s\n", vg)
+}
+
+func (g grid) help() string {
+	help := `"` + g.name() + `" selects the framing grid drawn over the live view window. Without arguments, the current grid is returned.` + "\n"
+
+	if args := g.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		help += "\t- " + strings.Join(args, ", ") + "\n"
+	}
+
+	return help
+}
+
+func (grid) arguments() []string {
+	return []string{
+		viewfinder.GridNone.String(),
+		viewfinder.GridThirds.String(),
+		viewfinder.GridSquare.String(),
+		viewfinder.GridCrop169.String(),
+	}
+}
+
+func (g grid) completions(args []string) []string {
+	if len(args) == 0 {
+		return g.arguments()
+	}
+
+	return nil
+}
+
+func (grid) examples() []string {
+	return []string{
+		"grid",
+		"grid thirds",
+		"grid none",
+	}
+}
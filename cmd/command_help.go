@@ -20,6 +20,9 @@ func (help) alias() []string {
 }
 
 func (help) execute(_ *ip.Client, f []string, _ chan<- string) string {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
 	if len(f) == 0 {
 		names := make([]string, 0, len(commands))
 		for name := range commands {
@@ -29,27 +32,59 @@ func (help) execute(_ *ip.Client, f []string, _ chan<- string) string {
 
 		txt := "\nSupported commands:\n\n"
 		for _, name := range names {
-			txt += commands[name].help() + "\n\n"
+			txt += commandHelp(commands[name]) + "\n"
+		}
+		if len(userAliases) > 0 {
+			txt += userAliasesHelp()
 		}
 		return txt
 	}
 
-	if cmd, exists := commands[f[0]]; exists {
-		return "\n" + cmd.help()
+	n := f[0]
+	if name, exists := aliases[n]; exists {
+		n = name
+	}
+	if cmd, exists := commands[n]; exists {
+		return "\n" + commandHelp(cmd)
+	}
+	if line, exists := userAliases[n]; exists {
+		return "\n" + `"` + n + `" is a user defined alias for "` + line + `"` + "\n"
 	}
 
 	return "\nUnknown command " + f[0] + "!\n"
 }
 
+// userAliasesHelp lists the aliases defined in the config file, sorted alphabetically.
+func userAliasesHelp() string {
+	names := make([]string, 0, len(userAliases))
+	for name := range userAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	txt := "User defined aliases:\n\n"
+	for _, name := range names {
+		txt += `"` + name + `" runs "` + userAliases[name] + `"` + "\n"
+	}
+
+	return txt
+}
+
+// commandHelp returns the help of the command followed by its aliases and examples, so they are listed for all
+// commands alike.
+func commandHelp(cmd command) string {
+	return cmd.help() + helpAddAliases(cmd.alias()) + helpAddExamples(cmd.examples())
+}
+
 func (h help) help() string {
-	help := `"` + h.name() + `" displays help for all commands or for a single one.` + "\n"
+	help := `"` + h.name() + `" displays help for all commands or for a single one, including their aliases, arguments and examples.` + "\n"
 
 	if args := h.arguments(); len(args) > 0 {
 		help += helpAddArgumentsTitle()
 		for i, arg := range args {
 			switch i {
 			case 0:
-				help += "\t- " + arg + " to get help for\n"
+				help += "\t- " + arg + " or alias to get help for\n"
 			}
 		}
 	}
@@ -60,3 +95,10 @@ func (h help) help() string {
 func (help) arguments() []string {
 	return []string{"command"}
 }
+
+func (help) examples() []string {
+	return []string{
+		"help",
+		"help info",
+	}
+}
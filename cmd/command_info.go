@@ -25,7 +25,7 @@ func (info) execute(c *ip.Client, f []string, _ chan<- string) string {
 		res = err.Error()
 	}
 
-	return formatDeviceInfo(c.ResponderVendor(), res, f)
+	return formatDeviceInfo(res, f)
 }
 
 func (i info) help() string {
@@ -49,3 +49,10 @@ func (i info) help() string {
 func (info) arguments() []string {
 	return []string{"json", "pretty"}
 }
+
+func (info) examples() []string {
+	return []string{
+		"info",
+		"info json pretty",
+	}
+}
@@ -11,7 +11,11 @@ import (
 	"github.com/malc0mn/ptp-ip/ptp"
 	"github.com/malc0mn/ptp-ip/viewfinder"
 	"image"
+	"image/color"
 	"image/draw"
+	"log"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,12 +38,35 @@ func (liveview) alias() []string {
 	return []string{}
 }
 
-func (l liveview) execute(c *ip.Client, f []string, _ chan<- string) string {
+func (l liveview) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
 	errorFmt := "liveview error: %s\n"
 
 	if lvState {
 		return "already enabled!\n"
 	}
+	for _, arg := range f {
+		if isLiveViewStreamArg(arg) {
+			return streamLiveView(c, f, asyncOut)
+		}
+	}
+	if lvStream.active() {
+		return "already enabled!\n"
+	}
+
+	withVf := true
+	var peaking color.Color
+	for _, arg := range f {
+		switch {
+		case l.isNoVf(arg):
+			withVf = false
+		case l.isPeaking(arg):
+			col, err := l.peakingColour(arg)
+			if err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+			peaking = col
+		}
+	}
 
 	lvState = true
 
@@ -47,12 +74,7 @@ func (l liveview) execute(c *ip.Client, f []string, _ chan<- string) string {
 		return fmt.Sprintf(errorFmt, err)
 	}
 
-	withVf := true
-	if len(f) >= 1 {
-		withVf = !l.isNoVf(f[0])
-	}
-
-	runOnMain(func() { liveViewUI(c, withVf) })
+	runOnMain(func() { liveViewUI(c, withVf, peaking) })
 
 	return "enabled\n"
 }
@@ -66,6 +88,10 @@ func (l liveview) help() string {
 			switch i {
 			case 0:
 				help += "\t- " + `"` + arg + `" disables the viewfinder overlay which eliminates camera state polling` + "\n"
+			case 1:
+				help += "\t- " + `"` + arg + `" highlights the in-focus areas to aid manual focusing. The highlight colour defaults to red and can be set as a hexadecimal RGB value, e.g. '` + arg + `=00ff00'` + "\n"
+			default:
+				help += liveViewStreamHelp(arg)
 			}
 		}
 	}
@@ -74,13 +100,41 @@ func (l liveview) help() string {
 }
 
 func (liveview) arguments() []string {
-	return []string{"novf"}
+	return append([]string{"novf", "peaking"}, liveViewStreamArguments...)
+}
+
+func (liveview) examples() []string {
+	return []string{
+		"liveview",
+		"liveview novf",
+		"liveview peaking=00ff00",
+		"liveview serve=:8080",
+	}
 }
 
 func (l liveview) isNoVf(param string) bool {
 	return param == l.arguments()[0]
 }
 
+func (l liveview) isPeaking(param string) bool {
+	return param == l.arguments()[1] || strings.HasPrefix(param, l.arguments()[1]+"=")
+}
+
+// peakingColour returns the focus peaking colour given as a hexadecimal RGB value in the form of 'peaking=00ff00'.
+func (l liveview) peakingColour(param string) (color.Color, error) {
+	i := strings.Index(param, "=")
+	if i == -1 {
+		return viewfinder.FocusPeakingColour, nil
+	}
+
+	col, err := viewfinder.ParseColour(param[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid focus peaking colour '%s'", param[i+1:])
+	}
+
+	return col, nil
+}
+
 // mainThread is used to execute on the main thread, which is what OpenGL requires.
 func mainThread() {
 	for {
@@ -98,7 +152,7 @@ func runOnMain(f func()) {
 	mainStack <- f
 }
 
-func liveViewUI(c *ip.Client, withVf bool) error {
+func liveViewUI(c *ip.Client, withVf bool, peaking color.Color) error {
 	if err := gl.Init(); err != nil {
 		return err
 	}
@@ -116,11 +170,16 @@ func liveViewUI(c *ip.Client, withVf bool) error {
 
 	// TODO: add support to allow toggling the viewfinder on or off.
 	var (
-		vf *viewfinder.Viewfinder
-		s  interface{}
+		vf      *viewfinder.Viewfinder
+		overlay *viewfinder.Overlay
+		s       interface{}
+		changes <-chan ip.EventPacket
 	)
-	ticker := time.NewTicker(1 * time.Second)
 	if withVf {
+		// The device state is refreshed each time the camera reports a property change.
+		changes = c.Subscribe(ptp.EC_DevicePropChanged)
+		defer c.Unsubscribe(changes)
+
 		s, err = c.GetDeviceState()
 		if err != nil {
 			s = []*ptp.DevicePropDesc{}
@@ -128,10 +187,26 @@ func liveViewUI(c *ip.Client, withVf bool) error {
 
 		im, _, err := image.Decode(bytes.NewReader(img))
 		if err == nil {
-			vf = viewfinder.NewViewfinder(toRGBA(im), c.ResponderVendor())
+			rgba := toRGBA(im)
+			vf = newViewfinder(c, rgba)
+			if vf != nil {
+				vf.ApplyTheme(conf.vfTheme)
+				if err := vf.ApplyLayout(rgba, conf.vfLayout); err != nil {
+					log.Printf("liveview: %s", err)
+				}
+				vf.Histogram = viewfinder.NewHistogramWidget(rgba, conf.histogramAnchor)
+				vf.Histogram.Enabled = conf.histogram
+				overlay = viewfinder.NewOverlay(vf)
+
+				// Count down the self-timer of captures made while the live view is running.
+				onCapture.Store(func() {
+					if d, err := c.GetCaptureDelay(); err == nil {
+						vf.StartSelfTimer(c, d)
+					}
+				})
+				defer onCapture.Store(func() {})
+			}
 		}
-	} else {
-		ticker.Stop()
 	}
 
 poller:
@@ -141,15 +216,39 @@ poller:
 			im, _, err := image.Decode(bytes.NewReader(img))
 			if err == nil {
 				rgba := toRGBA(im)
+				// The histogram must be computed before any overlay is drawn.
+				var h *ip.Histogram
+				if vf != nil && vf.Histogram.Enabled {
+					h = ip.NewHistogram(rgba)
+				}
+				if peaking != nil {
+					viewfinder.DrawFocusPeaking(rgba, peaking, viewfinder.DefaultFocusPeakingThreshold)
+				}
+				if ire := atomic.LoadInt32(&zebraIRE); ire != 0 {
+					viewfinder.DrawZebra(rgba, int(ire))
+				}
+				if g := atomic.LoadInt32(&liveViewGrid); g != 0 {
+					viewfinder.DrawGrid(rgba, viewfinder.Grid(g))
+				}
 				if vf != nil {
 					if data, ok := s.([]*ptp.DevicePropDesc); ok {
-						viewfinder.DrawViewfinder(vf, rgba, data)
+						overlay.Update(viewfinder.PropertyValues(data))
+						overlay.Draw(rgba)
 					}
+					viewfinder.DrawSelfTimer(vf, rgba)
+					vf.Histogram.Draw(rgba, h)
 				}
 				window.setImage(rgba)
 			}
-		case <-ticker.C:
-			s, _ = c.GetDeviceState()
+		case _, ok := <-changes:
+			if !ok {
+				// The client was closed.
+				changes = nil
+				break
+			}
+			if st, err := c.GetDeviceState(); err == nil {
+				s = st
+			}
 		case <-quit:
 			break poller
 		}
@@ -165,6 +264,23 @@ poller:
 	return nil
 }
 
+// newViewfinder builds the viewfinder using the device info of the camera. When the device info is not available, the
+// vendor specific viewfinder is used if there is one.
+func newViewfinder(c *ip.Client, img *image.RGBA) *viewfinder.Viewfinder {
+	if res, err := c.GetDeviceInfo(); err == nil {
+		switch di := res.(type) {
+		case *ptp.DeviceInfo:
+			return viewfinder.New(di, img)
+		case *ip.FujiDeviceInfo:
+			if di.DeviceInfo != nil {
+				return viewfinder.New(di.DeviceInfo, img)
+			}
+		}
+	}
+
+	return viewfinder.NewViewfinder(img, c.ResponderVendor())
+}
+
 func toRGBA(img image.Image) *image.RGBA {
 	rgba, ok := img.(*image.RGBA)
 	if !ok {
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of the dates accepted by the commands filtering objects by capture date.
+const dateFormat = "2006-01-02"
+
+func init() {
+	registerCommand(&ls{})
+}
+
+type ls struct{}
+
+func (ls) name() string {
+	return "ls"
+}
+
+func (ls) alias() []string {
+	return []string{"dir"}
+}
+
+func (l ls) execute(c *ip.Client, f []string, _ chan<- string) string {
+	errorFmt := "ls error: %s\n"
+
+	var (
+		long, recursive bool
+		p               = "/"
+		filter          objectFilter
+	)
+	for _, arg := range f {
+		switch {
+		case arg == "-l":
+			long = true
+		case arg == "-r":
+			recursive = true
+		case arg == "-lr", arg == "-rl":
+			long, recursive = true, true
+		case strings.Contains(arg, "="):
+			if err := filter.set(arg); err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+		default:
+			p = arg
+		}
+	}
+
+	t := ip.NewObjectTree(c)
+	nodes, err := listObjects(t, p, recursive, filter)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	return formatObjectNodes(nodes, long, recursive)
+}
+
+func (l ls) help() string {
+	help := `"` + l.name() + `" lists the stores and the objects on the camera, such as folders and images. The stores are the top level directories. Without arguments, the stores are listed.` + "\n"
+
+	if args := l.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `" displays the format, size and capture date of the objects` + "\n"
+			case 1:
+				help += "\t- " + `"` + arg + `" lists the objects in all sub directories as well` + "\n"
+			case 2:
+				help += "\t- " + arg + " of the directory to list, starting with the name of the store, e.g. '/store_10000001/DCIM'\n"
+			case 3:
+				help += "\t- " + `"` + arg + `=jpeg" only lists the objects of the given format, which can also be a hexadecimal object format code` + "\n"
+			case 4:
+				help += "\t- " + `"` + arg + `=` + dateFormat + `" only lists the objects captured on or after the given date` + "\n"
+			case 5:
+				help += "\t- " + `"` + arg + `=` + dateFormat + `" only lists the objects captured on or before the given date` + "\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (ls) arguments() []string {
+	return []string{"-l", "-r", "path", "format", "since", "until"}
+}
+
+func (ls) examples() []string {
+	return []string{
+		"ls",
+		"ls -l /store_10000001/DCIM/100_FUJI",
+		"ls -lr format=jpeg since=2021-06-01",
+	}
+}
+
+// objectFilter selects the objects listed by the commands working on objects. The zero value selects all objects.
+type objectFilter struct {
+	format ptp.ObjectFormatCode
+	since  time.Time
+	until  time.Time
+}
+
+// set sets the filter criterion given as 'name=value', see ls.arguments().
+func (of *objectFilter) set(arg string) error {
+	kv := strings.SplitN(arg, "=", 2)
+
+	var err error
+	switch kv[0] {
+	case "format":
+		of.format, err = ptpfmt.ParseObjectFormatCode(kv[1])
+	case "since":
+		of.since, err = time.ParseInLocation(dateFormat, kv[1], time.Local)
+	case "until":
+		of.until, err = time.ParseInLocation(dateFormat, kv[1], time.Local)
+		// Include all objects captured on the given day.
+		of.until = of.until.AddDate(0, 0, 1)
+	default:
+		err = fmt.Errorf("unknown filter '%s'", kv[0])
+	}
+
+	return err
+}
+
+// active returns true when at least one filter criterion has been set.
+func (of objectFilter) active() bool {
+	return of.format != 0 || !of.since.IsZero() || !of.until.IsZero()
+}
+
+// match returns true when the node is selected by the filter. Directories are never selected by an active filter.
+func (of objectFilter) match(n *ip.ObjectNode) bool {
+	if !of.active() {
+		return true
+	}
+	if n.IsDir() {
+		return false
+	}
+
+	return (of.format == 0 || n.Info.ObjectFormat == of.format) &&
+		(of.since.IsZero() || !n.Info.CaptureDate.Before(of.since)) &&
+		(of.until.IsZero() || n.Info.CaptureDate.Before(of.until))
+}
+
+// listObjects returns the nodes found at the given path that match the filter. For a directory, its children are
+// returned, or all its descendants when recursive is true. The stores are returned for the path "/".
+func listObjects(t *ip.ObjectTree, p string, recursive bool, filter objectFilter) ([]*ip.ObjectNode, error) {
+	var dirs []*ip.ObjectNode
+	if strings.Trim(p, "/") == "" {
+		stores, err := t.Stores()
+		if err != nil {
+			return nil, err
+		}
+		dirs = stores
+	} else {
+		n, err := t.Lookup(p)
+		if err != nil {
+			return nil, err
+		}
+		if !n.IsDir() {
+			return []*ip.ObjectNode{n}, nil
+		}
+		if dirs, err = n.Children(); err != nil {
+			return nil, err
+		}
+	}
+
+	var nodes []*ip.ObjectNode
+	var add func(ns []*ip.ObjectNode) error
+	add = func(ns []*ip.ObjectNode) error {
+		for _, n := range ns {
+			if filter.match(n) {
+				nodes = append(nodes, n)
+			}
+			if !recursive || !n.IsDir() {
+				continue
+			}
+			children, err := n.Children()
+			if err != nil {
+				return err
+			}
+			if err := add(children); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return nodes, add(dirs)
+}
@@ -20,16 +20,39 @@ func (liveview) alias() []string {
 	return []string{}
 }
 
-func (liveview) execute(_ *ip.Client, _ []string, _ chan<- string) string {
-	return nolv + "\n"
+func (liveview) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	for _, arg := range f {
+		if isLiveViewStreamArg(arg) {
+			return streamLiveView(c, f, asyncOut)
+		}
+	}
+
+	return nolv + " Use serve, dir or stdout to stream the frames instead.\n"
 }
 
 func (l liveview) help() string {
-	return `"` + l.name() + `" is not supported in this build!`
+	help := `"` + l.name() + `" streams the live view through the camera lens. Displaying the live view in a window is not supported in this build! Not all vendors support this!` + "\n"
+
+	if args := l.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for _, arg := range args {
+			help += liveViewStreamHelp(arg)
+		}
+	}
+
+	return help
 }
 
 func (liveview) arguments() []string {
-	return []string{}
+	return liveViewStreamArguments
+}
+
+func (liveview) examples() []string {
+	return []string{
+		"liveview serve=:8080",
+		"liveview dir=/tmp/frames",
+		"liveview stop",
+	}
 }
 
 func mainThread() {
@@ -77,3 +77,9 @@ func (o opreq) help() string {
 func (opreq) arguments() []string {
 	return []string{"opcode", "param"}
 }
+
+func (opreq) examples() []string {
+	return []string{
+		"opreq 0x1014 0x5003",
+	}
+}
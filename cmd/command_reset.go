@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+)
+
+func init() {
+	registerCommand(&reset{})
+}
+
+type reset struct{}
+
+func (reset) name() string {
+	return "reset"
+}
+
+func (reset) alias() []string {
+	return []string{}
+}
+
+func (reset) execute(c *ip.Client, f []string, _ chan<- string) string {
+	errorFmt := "reset error: %s\n"
+
+	if len(f) != 1 {
+		return fmt.Sprintf(errorFmt, "exactly one property is required, e.g. 'reset whitebalance'")
+	}
+
+	cod, err := formatDeviceProperty(c, f[0])
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	if err := c.ResetDeviceProperty(cod); err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	return fmt.Sprintf("property %s successfully reset to its factory default value\n", f[0])
+}
+
+func (r reset) help() string {
+	help := `"` + r.name() + `" restores the factory default value for the given property.` + "\n"
+
+	if args := r.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + arg + " is a hexadecimal field code in the form of '0x5001' or one of the supported unified field names:\n" + helpAddUnifiedFieldNames()
+			}
+		}
+	}
+
+	return help
+}
+
+func (reset) arguments() []string {
+	return []string{"property"}
+}
+
+func (reset) completions(args []string) []string {
+	return completeProperty(args)
+}
+
+func (reset) examples() []string {
+	return []string{
+		"reset whitebalance",
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"strings"
+)
+
+func init() {
+	registerCommand(&rm{})
+}
+
+type rm struct{}
+
+func (rm) name() string {
+	return "rm"
+}
+
+func (rm) alias() []string {
+	return []string{"del"}
+}
+
+func (r rm) execute(c *ip.Client, f []string, _ chan<- string) string {
+	errorFmt := "rm error: %s\n"
+
+	var (
+		dryRun, yes bool
+		sel         objectSelection
+	)
+	for _, arg := range f {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--yes", "-y":
+			yes = true
+		default:
+			if err := sel.add(arg); err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+		}
+	}
+	if sel.empty() {
+		return fmt.Sprintf(errorFmt, "select the objects to delete by handle or filename pattern")
+	}
+
+	t := ip.NewObjectTree(c)
+	nodes, err := selectObjects(t, sel)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	if len(nodes) == 0 {
+		return "no objects to delete\n"
+	}
+
+	var list strings.Builder
+	for _, n := range nodes {
+		fmt.Fprintf(&list, "%0#8x %s\n", uint32(n.Handle), n.Path())
+	}
+
+	switch {
+	case dryRun:
+		return list.String() + fmt.Sprintf("%d objects would be deleted\n", len(nodes))
+	case yes:
+	case confirm == nil:
+		return list.String() + fmt.Sprintf(errorFmt, fmt.Sprintf("add --yes to delete these %d objects", len(nodes)))
+	case !confirm(fmt.Sprintf("%sDelete these %d objects?", list.String(), len(nodes))):
+		return "nothing deleted\n"
+	}
+
+	var (
+		res     string
+		deleted int
+	)
+	for _, n := range nodes {
+		if err := t.Delete(n); err != nil {
+			res += fmt.Sprintf("%s: %s\n", n.Path(), err)
+			continue
+		}
+		deleted++
+	}
+
+	return res + fmt.Sprintf("%d of %d objects deleted\n", deleted, len(nodes))
+}
+
+func (r rm) help() string {
+	help := `"` + r.name() + `" deletes objects from the camera. The objects to delete are listed first and have to be confirmed, which is not possible in server mode: add "--yes" to delete them without confirmation.` + "\n"
+
+	if args := r.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- one or more " + arg + "s as listed by the ls command, e.g. '0x00000005'\n"
+			case 1:
+				help += "\t- one or more filename " + arg + "s, e.g. '*.JPG' or '/store_10000001/DCIM/*/DSCF00*'. A pattern holding a slash is matched against the full path of the objects\n"
+			case 2:
+				help += "\t- " + `"` + arg + `" or "-n" only lists the objects that would be deleted` + "\n"
+			case 3:
+				help += "\t- " + `"` + arg + `" or "-y" deletes the objects without asking for confirmation` + "\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (rm) arguments() []string {
+	return []string{"handle", "pattern", "--dry-run", "--yes"}
+}
+
+func (rm) examples() []string {
+	return []string{
+		"rm 0x00000005",
+		"rm --dry-run *.RAF",
+		"rm --yes /store_10000001/DCIM/100_FUJI/*",
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
 	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
 )
 
 func init() {
@@ -23,13 +25,17 @@ func (set) alias() []string {
 func (set) execute(c *ip.Client, f []string, _ chan<- string) string {
 	errorFmt := "set error: %s\n"
 
+	if len(f) < 2 {
+		return fmt.Sprintf(errorFmt, "a property and a value are required")
+	}
+
 	cod, err := formatDeviceProperty(c, f[0])
 	if err != nil {
 		return fmt.Sprintf(errorFmt, err)
 	}
 
-	// TODO: add support for "string" values such as "astia" for film simulation.
-	val, err := ptpfmt.HexStringToUint64(f[1], 32)
+	// Values such as "Monochrome + Ye Filter" or "-1 2/3" contain spaces.
+	val, err := ptpfmt.ParseDevicePropValue(c.ResponderVendor(), cod, strings.Join(f[1:], " "))
 	if err != nil {
 		return fmt.Sprintf(errorFmt, err)
 	}
@@ -53,7 +59,7 @@ func (s set) help() string {
 			case 0:
 				help += "\t- " + arg + " is a hexadecimal field code in the form of '0x5001' or one of the supported unified field names:\n" + helpAddUnifiedFieldNames()
 			case 1:
-				help += "\t- " + arg + " is a hexadecimal value to set the field to. E.g. '0x6'\n"
+				help += "\t- " + arg + " is a hexadecimal value to set the field to, e.g. '0x6', or a value as displayed by the get command, e.g. 'f/5.6', '1/250', '-1 2/3' or 'astia'\n"
 			}
 		}
 	}
@@ -64,3 +70,30 @@ func (s set) help() string {
 func (set) arguments() []string {
 	return []string{"property", "value"}
 }
+
+// completions returns the values of the property in the first argument for the vendor in the config, if the property
+// holds one of a list of values.
+func (set) completions(args []string) []string {
+	if len(args) != 1 {
+		return completeProperty(args)
+	}
+
+	vendor := ptp.VendorStringToType(conf.vendor)
+	code, err := ptpfmt.PropNameToDevicePropCode(vendor, args[0])
+	if err != nil {
+		return nil
+	}
+
+	return ptpfmt.DevicePropValueNames(vendor, code)
+}
+
+func (set) examples() []string {
+	return []string{
+		"set iso 0x320",
+		"set iso 1600",
+		"set aperture f/5.6",
+		"set exp-bias -1 2/3",
+		"set whitebalance daylight",
+		"set filmsimulation astia",
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+)
+
+func init() {
+	registerCommand(&source{})
+}
+
+type source struct{}
+
+func (source) name() string {
+	return "source"
+}
+
+func (source) alias() []string {
+	return []string{"run"}
+}
+
+func (source) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "source error: %s\n"
+
+	if len(f) < 1 {
+		return fmt.Sprintf(errorFmt, "the script to run is missing")
+	}
+	vars, err := scriptArguments(f[1:])
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	if err := runScript(c, f[0], vars, asyncOut); err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	return "script done\n"
+}
+
+func (s source) help() string {
+	help := `"` + s.name() + `" runs the commands in a script file one after the other, stopping at the first line that fails. Each line of the script holds a command, a variable assignment such as 'iso=800', a 'sleep 2s' statement or a 'source' statement running another script relative to the current one. Lines starting with '#' are ignored and variables are referenced as '$iso' or '${iso}'. The script stops when pressing CTRL+C, except in server mode.` + "\n"
+
+	if args := s.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + arg + ": the path to the script to run\n"
+			case 1:
+				help += "\t- " + arg + ": optional variables passed to the script in the form of 'name=value'\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (source) arguments() []string {
+	return []string{"file", "variables"}
+}
+
+func (source) examples() []string {
+	return []string{
+		"source hdr.txt",
+		"source timelapse.txt interval=10s",
+	}
+}
@@ -18,14 +18,18 @@ func (state) alias() []string {
 	return []string{}
 }
 
-func (state) execute(c *ip.Client, f []string, _ chan<- string) string {
+func (s state) execute(c *ip.Client, f []string, _ chan<- string) string {
+	if len(f) >= 1 && f[0] == s.arguments()[2] {
+		return formatLiveViewStats(c.LiveViewStats(), f[1:])
+	}
+
 	res, err := c.GetDeviceState()
 
 	if err != nil {
 		res = err.Error()
 	}
 
-	return formatDeviceInfo(c.ResponderVendor(), res, f)
+	return formatDeviceInfo(res, f)
 }
 
 func (i state) help() string {
@@ -39,6 +43,8 @@ func (i state) help() string {
 				help += "\t- " + `"` + arg + `" to output the data in parsable json format` + "\n"
 			case 1:
 				help += "\t- " + `"` + arg + `" to be used together with "` + args[0] + `": format the output in a human readable way` + "\n"
+			case 2:
+				help += "\t- " + `"` + arg + `" displays the live view statistics instead: the frame rate, latency and number of dropped frames. Can be followed by "` + args[0] + `"` + "\n"
 			}
 		}
 	}
@@ -47,5 +53,13 @@ func (i state) help() string {
 }
 
 func (state) arguments() []string {
-	return []string{"json", "pretty"}
+	return []string{"json", "pretty", "liveview"}
+}
+
+func (state) examples() []string {
+	return []string{
+		"state",
+		"state json pretty",
+		"state liveview",
+	}
 }
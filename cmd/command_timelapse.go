@@ -0,0 +1,333 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRampValues limits the amount of values of a Range-Form a property can be ramped through.
+const maxRampValues = 4096
+
+func init() {
+	registerCommand(&timelapse{})
+}
+
+type timelapse struct{}
+
+func (timelapse) name() string {
+	return "timelapse"
+}
+
+func (timelapse) alias() []string {
+	return []string{"intervalometer", "tl"}
+}
+
+func (tl timelapse) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "timelapse error: %s\n"
+
+	opts, err := parseTimelapseOptions(f)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	var ramp *propertyRamp
+	if opts.ramp != "" {
+		if ramp, err = newPropertyRamp(c, opts.ramp, opts.frames()); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+	}
+	if opts.dir != "" {
+		if err := os.MkdirAll(opts.dir, 0755); err != nil {
+			return fmt.Sprintf(errorFmt, err)
+		}
+	}
+
+	var (
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	defer onInterrupt(func() { stopOnce.Do(func() { close(stop) }) })()
+
+	frames := opts.frames()
+	start := time.Now()
+	n := 0
+	for ; frames == 0 || n < frames; n++ {
+		if n > 0 {
+			wait := time.Until(start.Add(time.Duration(n) * opts.interval))
+			if wait < 0 {
+				asyncOut <- fmt.Sprintf("frame %d is %s late, the interval is too short", n+1, -wait.Round(time.Millisecond))
+				wait = 0
+			}
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-stop:
+				t.Stop()
+				return tl.summary(n)
+			case <-quit:
+				t.Stop()
+				return tl.summary(n)
+			}
+		}
+
+		msg := fmt.Sprintf("frame %d", n+1)
+		if frames > 0 {
+			msg += fmt.Sprintf("/%d", frames)
+		}
+		if ramp != nil {
+			desc, err := ramp.apply(c, n)
+			if err != nil {
+				return fmt.Sprintf(errorFmt, fmt.Sprintf("%s: %s", msg, err)) + tl.summary(n)
+			}
+			msg += " " + desc
+		}
+
+		if f, ok := onCapture.Load().(func()); ok {
+			f()
+		}
+		img, err := c.InitiateCapture()
+		if err != nil {
+			return fmt.Sprintf(errorFmt, fmt.Sprintf("%s: %s", msg, err)) + tl.summary(n)
+		}
+		msg += " captured"
+
+		if opts.dir != "" && len(img) > 0 {
+			file := filepath.Join(opts.dir, fmt.Sprintf("frame_%05d.jpg", n+1))
+			if err := ioutil.WriteFile(file, img, 0644); err != nil {
+				return fmt.Sprintf(errorFmt, err) + tl.summary(n+1)
+			}
+			msg += ", preview saved to " + file
+		}
+		asyncOut <- msg
+	}
+
+	return tl.summary(n)
+}
+
+// summary returns the amount of frames captured.
+func (timelapse) summary(n int) string {
+	return fmt.Sprintf("%d frames captured\n", n)
+}
+
+func (tl timelapse) help() string {
+	help := `"` + tl.name() + `" captures an image at a fixed interval, optionally ramping a property such as the exposure time from one value to another over the frames, e.g. for a sunset. Capturing stops after the given amount of frames or duration or when pressing CTRL+C, except in server mode.` + "\n"
+
+	if args := tl.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `=10s"` + " is the time between the start of two captures, which is required\n"
+			case 1:
+				help += "\t- " + `"` + arg + `=100"` + " stops after the given amount of frames\n"
+			case 2:
+				help += "\t- " + `"` + arg + `=1h"` + " stops after the given duration\n"
+			case 3:
+				help += "\t- " + `"` + arg + `=exposure:1/250..1/4"` + " steps the property through the values the camera supports from the first to the second value over the frames, which requires the amount of frames or the duration\n"
+			case 4:
+				help += "\t- " + `"` + arg + `=/path"` + " saves the preview of each frame to the given directory if the camera returns it\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (timelapse) arguments() []string {
+	return []string{"interval", "count", "for", "ramp", "dir"}
+}
+
+func (timelapse) examples() []string {
+	return []string{
+		"timelapse interval=5s count=100",
+		"timelapse interval=30s for=2h dir=/tmp/timelapse",
+		"timelapse interval=10s count=360 ramp=exp-bias:0..-2",
+	}
+}
+
+// timelapseOptions holds the arguments of the timelapse command.
+type timelapseOptions struct {
+	interval time.Duration
+	count    int
+	duration time.Duration
+	// ramp holds the property to ramp and its first and last value in the form of 'property:from..to'.
+	ramp string
+	dir  string
+}
+
+// frames returns the amount of frames to capture, which is 0 when capturing until interrupted.
+func (o timelapseOptions) frames() int {
+	if o.count > 0 {
+		return o.count
+	}
+
+	return int(o.duration / o.interval)
+}
+
+func parseTimelapseOptions(f []string) (timelapseOptions, error) {
+	var opts timelapseOptions
+	for _, arg := range f {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return opts, fmt.Errorf("invalid argument '%s', use 'name=value'", arg)
+		}
+
+		switch kv[0] {
+		case "interval":
+			d, err := time.ParseDuration(kv[1])
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("invalid interval '%s'", kv[1])
+			}
+			opts.interval = d
+		case "count":
+			n, err := strconv.Atoi(kv[1])
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid count '%s'", kv[1])
+			}
+			opts.count = n
+		case "for":
+			d, err := time.ParseDuration(kv[1])
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("invalid duration '%s'", kv[1])
+			}
+			opts.duration = d
+		case "ramp":
+			opts.ramp = kv[1]
+		case "dir":
+			opts.dir = kv[1]
+		default:
+			return opts, fmt.Errorf("unknown argument '%s'", kv[0])
+		}
+	}
+
+	if opts.interval == 0 {
+		return opts, errors.New("the interval is missing, e.g. 'interval=10s'")
+	}
+	if opts.duration > 0 && opts.frames() == 0 {
+		return opts, errors.New("the duration is shorter than the interval")
+	}
+	if opts.ramp != "" && opts.frames() == 0 {
+		return opts, errors.New("ramping requires the amount of frames or the duration")
+	}
+
+	return opts, nil
+}
+
+// propertyRamp holds the value of a property for each frame of a time-lapse.
+type propertyRamp struct {
+	name   string
+	code   ptp.DevicePropCode
+	vendor ptp.VendorExtension
+	values []int64
+}
+
+// newPropertyRamp requests the values the camera supports for the property in the ramp specification, which is in the
+// form of 'property:from..to', and selects the value for each frame.
+func newPropertyRamp(c *ip.Client, spec string, frames int) (*propertyRamp, error) {
+	name, from, to, err := parseRampSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := formatDeviceProperty(c, name)
+	if err != nil {
+		return nil, err
+	}
+	dpd, err := c.GetDevicePropertyDescription(code)
+	if err != nil {
+		return nil, err
+	}
+	supported := dpd.SupportedValues(maxRampValues)
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("property %s does not list the values it supports", name)
+	}
+
+	vendor := c.ResponderVendor()
+	var bounds [2]int64
+	for i, s := range []string{from, to} {
+		v, err := ptpfmt.ParseDevicePropValue(vendor, code, s)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = dpd.SignExtend(v)
+	}
+
+	return &propertyRamp{
+		name:   name,
+		code:   code,
+		vendor: vendor,
+		values: rampValues(supported, bounds[0], bounds[1], frames),
+	}, nil
+}
+
+// apply sets the property to the value of the given frame when it differs from the value of the previous frame. It
+// returns the property and its value for display.
+func (r *propertyRamp) apply(c *ip.Client, frame int) (string, error) {
+	v := r.values[frame]
+	if frame == 0 || v != r.values[frame-1] {
+		if err := c.SetDevicePropertyValue(r.code, v); err != nil {
+			return "", err
+		}
+	}
+
+	val := ptpfmt.DevicePropValAsString(r.vendor, r.code, v)
+	if val == "" {
+		val = fmt.Sprintf("%#x", v)
+	}
+
+	return r.name + " " + val, nil
+}
+
+// parseRampSpec splits a ramp specification in the form of 'property:from..to'.
+func parseRampSpec(spec string) (string, string, string, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) == 2 {
+		if vals := strings.SplitN(kv[1], "..", 2); len(vals) == 2 && kv[0] != "" && vals[0] != "" && vals[1] != "" {
+			return kv[0], vals[0], vals[1], nil
+		}
+	}
+
+	return "", "", "", fmt.Errorf("invalid ramp '%s', use 'property:from..to', e.g. 'exposure:1/250..1/4'", spec)
+}
+
+// rampValues returns the value for each of the given amount of frames, stepping evenly through the supported values
+// from the supported value closest to from to the supported value closest to to. The supported values are sorted so
+// each step changes the property in the same direction.
+func rampValues(supported []int64, from, to int64, frames int) []int64 {
+	sorted := append([]int64(nil), supported...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	closest := func(v int64) int {
+		best := 0
+		for i, s := range sorted {
+			if math.Abs(float64(s-v)) < math.Abs(float64(sorted[best]-v)) {
+				best = i
+			}
+		}
+		return best
+	}
+	first, last := closest(from), closest(to)
+
+	values := make([]int64, frames)
+	for i := range values {
+		idx := first
+		if frames > 1 {
+			idx += int(math.Round(float64((last-first)*i) / float64(frames-1)))
+		}
+		values[i] = sorted[idx]
+	}
+
+	return values
+}
@@ -27,3 +27,7 @@ func (c unknown) help() string {
 func (unknown) arguments() []string {
 	return []string{}
 }
+
+func (unknown) examples() []string {
+	return []string{}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerCommand(&watch{})
+}
+
+type watch struct{}
+
+func (watch) name() string {
+	return "watch"
+}
+
+func (watch) alias() []string {
+	return []string{"events"}
+}
+
+func (w watch) execute(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "watch error: %s\n"
+
+	opts, err := parseWatchOptions(f)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	// Events are queued rather than dropped while the value of a changed property is requested.
+	events := c.SubscribeWithBackpressure(ip.BackpressureBlock, opts.codes...)
+	defer c.Unsubscribe(events)
+
+	var (
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	defer onInterrupt(func() { stopOnce.Do(func() { close(stop) }) })()
+
+	var timeout <-chan time.Time
+	if opts.duration > 0 {
+		t := time.NewTimer(opts.duration)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	vendor := c.ResponderVendor()
+	n := 0
+	for opts.count == 0 || n < opts.count {
+		select {
+		case p, ok := <-events:
+			if !ok {
+				return fmt.Sprintf(errorFmt, "the event connection was closed")
+			}
+			n++
+
+			var value interface{}
+			if e, ok := p.Typed().(ptp.DevicePropChangedEvent); ok {
+				value, _ = c.GetDevicePropertyValue(e.Code)
+			}
+			if !opts.json {
+				asyncOut <- formatEvent(vendor, p, time.Now(), value)
+				continue
+			}
+			line, err := formatEventJSON(vendor, p, time.Now(), value)
+			if err != nil {
+				return fmt.Sprintf(errorFmt, err)
+			}
+			asyncOut <- line
+		case <-timeout:
+			return w.summary(opts, n)
+		case <-stop:
+			return w.summary(opts, n)
+		case <-quit:
+			return w.summary(opts, n)
+		}
+	}
+
+	return w.summary(opts, n)
+}
+
+// summary returns the amount of events received, which is left out when streaming JSON so each line of the output
+// holds a JSON object.
+func (watch) summary(opts watchOptions, n int) string {
+	if opts.json {
+		return ""
+	}
+
+	return fmt.Sprintf("%d events received\n", n)
+}
+
+func (w watch) help() string {
+	help := `"` + w.name() + `" prints the events sent by the camera as they arrive, such as property changes along with the new value of the property. Watching stops when pressing CTRL+C, except in server mode.` + "\n"
+
+	if args := w.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- one or more hexadecimal event " + arg + "s in the form of '0x4006' to only print those events\n"
+			case 1:
+				help += "\t- " + `"` + arg + `" prints each event as a single line of JSON` + "\n"
+			case 2:
+				help += "\t- " + `"` + arg + `=10"` + " stops watching after the given amount of events\n"
+			case 3:
+				help += "\t- " + `"` + arg + `=30s"` + " stops watching after the given duration\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (watch) arguments() []string {
+	return []string{"code", "json", "count", "for"}
+}
+
+func (watch) examples() []string {
+	return []string{
+		"watch",
+		"watch 0x4006 for=1m",
+		"watch json count=1 0x4002",
+	}
+}
+
+// watchOptions holds the arguments of the watch command.
+type watchOptions struct {
+	codes    []ptp.EventCode
+	json     bool
+	count    int
+	duration time.Duration
+}
+
+func parseWatchOptions(f []string) (watchOptions, error) {
+	var opts watchOptions
+	for _, arg := range f {
+		switch {
+		case arg == "json":
+			opts.json = true
+		case strings.HasPrefix(arg, "count="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "count="))
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("invalid count '%s'", strings.TrimPrefix(arg, "count="))
+			}
+			opts.count = n
+		case strings.HasPrefix(arg, "for="):
+			d, err := time.ParseDuration(strings.TrimPrefix(arg, "for="))
+			if err != nil || d <= 0 {
+				return opts, fmt.Errorf("invalid duration '%s'", strings.TrimPrefix(arg, "for="))
+			}
+			opts.duration = d
+		default:
+			code, err := ptpfmt.HexStringToUint64(arg, 16)
+			if err != nil {
+				return opts, fmt.Errorf("invalid event code '%s'", arg)
+			}
+			opts.codes = append(opts.codes, ptp.EventCode(code))
+		}
+	}
+
+	return opts, nil
+}
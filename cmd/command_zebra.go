@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/viewfinder"
+	"strconv"
+	"sync/atomic"
+)
+
+// zebraIRE holds the threshold of the zebra overlay of the live view window. The overlay is disabled when it is 0.
+var zebraIRE int32
+
+func init() {
+	registerCommand(&zebra{})
+}
+
+type zebra struct{}
+
+func (zebra) name() string {
+	return "zebra"
+}
+
+func (zebra) alias() []string {
+	return []string{}
+}
+
+func (z zebra) execute(_ *ip.Client, f []string, _ chan<- string) string {
+	ire := int32(viewfinder.DefaultZebraThreshold)
+	if len(f) >= 1 {
+		switch f[0] {
+		case z.arguments()[0]:
+		case z.arguments()[1]:
+			ire = 0
+		default:
+			val, err := strconv.Atoi(f[0])
+			if err != nil || val < 1 || val > 100 {
+				return fmt.Sprintf("zebra error: invalid threshold '%s'\n", f[0])
+			}
+			ire = int32(val)
+		}
+	} else if atomic.LoadInt32(&zebraIRE) != 0 {
+		// Toggle the overlay when no arguments are given.
+		ire = 0
+	}
+
+	atomic.StoreInt32(&zebraIRE, ire)
+	if ire == 0 {
+		return "zebra disabled\n"
+	}
+
+	return fmt.Sprintf("zebra enabled above %d IRE\n", ire)
+}
+
+func (z zebra) help() string {
+	help := `"` + z.name() + `" toggles the zebra pattern in the live view window which marks the over-exposed areas.` + "\n"
+
+	if args := z.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `" enables the zebra pattern` + "\n"
+			case 1:
+				help += "\t- " + `"` + arg + `" disables the zebra pattern` + "\n"
+			case 2:
+				help += "\t- " + arg + fmt.Sprintf(": enables the zebra pattern for the areas brighter than the given IRE value ranging from 1 to 100. Defaults to %d.", viewfinder.DefaultZebraThreshold) + "\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (zebra) arguments() []string {
+	return []string{"on", "off", "threshold"}
+}
+
+func (z zebra) completions(args []string) []string {
+	if len(args) == 0 {
+		return z.arguments()[:2]
+	}
+
+	return nil
+}
+
+func (zebra) examples() []string {
+	return []string{
+		"zebra on",
+		"zebra 90",
+		"zebra off",
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerCommand(&zoom{})
+}
+
+type zoom struct{}
+
+func (zoom) name() string {
+	return "zoom"
+}
+
+func (zoom) alias() []string {
+	return []string{}
+}
+
+func (z zoom) execute(c *ip.Client, f []string, _ chan<- string) string {
+	errorFmt := "zoom error: %s\n"
+
+	if len(f) > 2 {
+		return fmt.Sprintf(errorFmt, "too many arguments")
+	}
+
+	// Parse the arguments before talking to the camera.
+	var (
+		steps  int
+		target int64
+	)
+	if len(f) >= 1 {
+		switch f[0] {
+		case z.arguments()[0], z.arguments()[1]:
+			steps = 1
+			if len(f) == 2 {
+				n, err := strconv.Atoi(f[1])
+				if err != nil || n < 1 {
+					return fmt.Sprintf(errorFmt, fmt.Sprintf("invalid amount of steps '%s'", f[1]))
+				}
+				steps = n
+			}
+			if f[0] == z.arguments()[1] {
+				steps = -steps
+			}
+		default:
+			factor, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(f[0]), "x"), 64)
+			if err != nil || factor < 0.1 || len(f) == 2 {
+				return fmt.Sprintf(errorFmt, fmt.Sprintf("invalid zoom '%s', use in, out or a zoom factor such as 2x", strings.Join(f, " ")))
+			}
+			// The digital zoom is scaled by 10.
+			target = int64(math.Round(factor * 10))
+		}
+	}
+
+	dpd, err := c.GetDevicePropertyDescription(ptp.DPC_DigitalZoom)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	current := dpd.SignExtend(dpd.CurrentValueAsInt64())
+	if len(f) == 0 {
+		return fmt.Sprintf("zoom: %s\n", zoomFactor(current))
+	}
+
+	supported := dpd.SupportedValues(maxRampValues)
+	if len(supported) == 0 {
+		return fmt.Sprintf(errorFmt, "the camera does not list the zoom factors it supports")
+	}
+
+	v := closestZoom(supported, target)
+	if steps != 0 {
+		v = zoomStep(supported, current, steps)
+	}
+	if v == current {
+		return fmt.Sprintf("zoom already at %s\n", zoomFactor(current))
+	}
+	if err := c.SetDevicePropertyValue(ptp.DPC_DigitalZoom, v); err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	return fmt.Sprintf("zoom set to %s\n", zoomFactor(v))
+}
+
+func (z zoom) help() string {
+	help := `"` + z.name() + `" displays or changes the digital zoom of the camera. The zoom factor is stepped through the values the camera supports.` + "\n"
+
+	if args := z.arguments(); len(args) > 0 {
+		help += helpAddArgumentsTitle()
+		for i, arg := range args {
+			switch i {
+			case 0:
+				help += "\t- " + `"` + arg + `"` + " zooms in, optionally followed by the amount of steps which defaults to 1\n"
+			case 1:
+				help += "\t- " + `"` + arg + `"` + " zooms out, optionally followed by the amount of steps which defaults to 1\n"
+			case 2:
+				help += "\t- " + arg + ": sets the zoom factor closest to the given one, e.g. 2x\n"
+			}
+		}
+	}
+
+	return help
+}
+
+func (zoom) arguments() []string {
+	return []string{"in", "out", "factor"}
+}
+
+func (z zoom) completions(args []string) []string {
+	if len(args) == 0 {
+		return z.arguments()[:2]
+	}
+
+	return nil
+}
+
+func (zoom) examples() []string {
+	return []string{
+		"zoom",
+		"zoom in",
+		"zoom out 2",
+		"zoom 2x",
+	}
+}
+
+// zoomFactor formats a digital zoom value, which is the zoom factor scaled by 10.
+func zoomFactor(v int64) string {
+	return strconv.FormatFloat(float64(v)/10, 'f', -1, 64) + "x"
+}
+
+// closestZoom returns the supported value closest to v.
+func closestZoom(supported []int64, v int64) int64 {
+	best := supported[0]
+	for _, s := range supported {
+		if math.Abs(float64(s-v)) < math.Abs(float64(best-v)) {
+			best = s
+		}
+	}
+
+	return best
+}
+
+// zoomStep returns the supported value the given amount of steps away from the current value, zooming in for a
+// positive amount and out for a negative amount. The result is limited to the lowest and highest supported value.
+func zoomStep(supported []int64, current int64, steps int) int64 {
+	sorted := append([]int64(nil), supported...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	v := closestZoom(sorted, current)
+	idx := sort.Search(len(sorted), func(i int) bool { return sorted[i] >= v }) + steps
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+
+	return sorted[idx]
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
 	"github.com/malc0mn/ptp-ip/ip"
 	"log"
@@ -13,8 +14,14 @@ var (
 	commandsMu sync.RWMutex
 	commands   = make(map[string]command)
 	aliases    = make(map[string]string)
+	// userAliases holds the aliases defined in the config file, mapping the alias to the command line it runs.
+	userAliases = make(map[string]string)
 )
 
+// confirm asks the user to confirm an action, returning true when the user agrees. It is nil when the commands are
+// not entered by a user that can be prompted, such as in server mode.
+var confirm func(question string) bool
+
 type command interface {
 	name() string
 	alias() []string
@@ -22,6 +29,14 @@ type command interface {
 	execute(*ip.Client, []string, chan<- string) string
 	help() string
 	arguments() []string
+	// examples returns complete command lines showing how to use the command, as listed by the help command.
+	examples() []string
+}
+
+// completer is implemented by the commands of which the arguments can be completed, e.g. by the shell completion.
+type completer interface {
+	// completions returns the possible values of the argument following the given arguments.
+	completions(args []string) []string
 }
 
 func registerCommand(cmd command) {
@@ -45,6 +60,43 @@ func registerCommand(cmd command) {
 	}
 }
 
+// registerUserAlias makes the alias run the given command line, to which the arguments following the alias are
+// appended. The alias cannot override a command or one of its aliases and must refer to an existing command.
+func registerUserAlias(alias, line string) error {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+
+	if _, dup := commands[alias]; dup {
+		return fmt.Errorf("alias %s: there is a command with the same name", alias)
+	}
+	if _, dup := aliases[alias]; dup {
+		return fmt.Errorf("alias %s: there is a command alias with the same name", alias)
+	}
+	f := strings.Fields(line)
+	if len(f) == 0 {
+		return fmt.Errorf("alias %s: the command is missing", alias)
+	}
+	if _, ok := commandByName(f[0]).(*unknown); ok {
+		return fmt.Errorf("alias %s: unknown command '%s'", alias, f[0])
+	}
+	userAliases[alias] = line
+
+	return nil
+}
+
+// expandAlias replaces a user defined alias at the start of the command line with the command line it runs.
+func expandAlias(f []string) []string {
+	commandsMu.RLock()
+	line, ok := userAliases[f[0]]
+	commandsMu.RUnlock()
+
+	if !ok {
+		return f
+	}
+
+	return append(strings.Fields(line), f[1:]...)
+}
+
 func helpAddAliases(aliases []string) string {
 	var help string
 
@@ -59,6 +111,19 @@ func helpAddArgumentsTitle() string {
 	return "\tAllowed arguments:\n"
 }
 
+func helpAddExamples(examples []string) string {
+	var help string
+
+	if len(examples) > 0 {
+		help += "\tExamples:\n"
+		for _, e := range examples {
+			help += "\t  " + e + "\n"
+		}
+	}
+
+	return help
+}
+
 func helpAddUnifiedFieldNames() string {
 	return "\t" + `  "` + strings.Join(ptpfmt.UnifiedFieldNames, `", "`) + `"` + "\n"
 }
@@ -81,7 +146,7 @@ func readAndExecuteCommand(rw *bufio.ReadWriter, c *ip.Client, lmp string) {
 
 func executeCommand(msg string, w *bufio.Writer, c *ip.Client, lmp string) {
 	var wg sync.WaitGroup
-	f := strings.Fields(msg)
+	f := expandAlias(strings.Fields(msg))
 	asyncOut := make(chan string)
 
 	// Launch async output routine.
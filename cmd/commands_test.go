@@ -1,25 +1,59 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"text/template"
+	"time"
 )
 
 func TestCommandByName(t *testing.T) {
 	cmds := map[string]command{
-		"capture":  &capture{},
-		"describe": &describe{},
-		"get":      &get{},
-		"help":     &help{},
-		"info":     &info{},
-		"liveview": &liveview{},
-		"opreq":    &opreq{},
-		"shoot":    &capture{},
-		"shutter":  &capture{},
-		"snap":     &capture{},
-		"set":      &set{},
-		"state":    &state{},
+		"aeb":            &bracket{},
+		"af":             &focus{},
+		"bracket":        &bracket{},
+		"bulb":           &bulb{},
+		"capture":        &capture{},
+		"describe":       &describe{},
+		"del":            &rm{},
+		"dir":            &ls{},
+		"dl":             &download{},
+		"download":       &download{},
+		"events":         &watch{},
+		"focus":          &focus{},
+		"get":            &get{},
+		"grid":           &grid{},
+		"help":           &help{},
+		"info":           &info{},
+		"intervalometer": &timelapse{},
+		"liveview":       &liveview{},
+		"longexposure":   &bulb{},
+		"ls":             &ls{},
+		"opreq":          &opreq{},
+		"reset":          &reset{},
+		"rm":             &rm{},
+		"run":            &source{},
+		"shoot":          &capture{},
+		"shutter":        &capture{},
+		"snap":           &capture{},
+		"set":            &set{},
+		"source":         &source{},
+		"state":          &state{},
+		"timelapse":      &timelapse{},
+		"tl":             &timelapse{},
+		"watch":          &watch{},
+		"zebra":          &zebra{},
+		"zoom":           &zoom{},
 	}
 	for name, want := range cmds {
 		got := commandByName(name)
@@ -29,6 +63,37 @@ func TestCommandByName(t *testing.T) {
 	}
 }
 
+func TestRegisterUserAlias(t *testing.T) {
+	defer delete(userAliases, "iso800")
+
+	if err := registerUserAlias("iso800", "set iso 800"); err != nil {
+		t.Fatalf("registerUserAlias() err = %s; want <nil>", err)
+	}
+	check := map[string][]string{
+		"iso800":     {"set", "iso", "800"},
+		"iso800 now": {"set", "iso", "800", "now"},
+		"get iso":    {"get", "iso"},
+	}
+	for line, want := range check {
+		if got := expandAlias(strings.Fields(line)); !reflect.DeepEqual(got, want) {
+			t.Errorf("expandAlias(%s) got = %v; want %v", line, got, want)
+		}
+	}
+
+	errs := map[string]string{
+		"get":   "alias get: there is a command with the same name",
+		"shoot": "alias shoot: there is a command alias with the same name",
+		"empty": "alias empty: the command is missing",
+		"oops":  "alias oops: unknown command 'gte'",
+	}
+	lines := map[string]string{"get": "info", "shoot": "capture", "empty": " ", "oops": "gte iso"}
+	for alias, want := range errs {
+		if err := registerUserAlias(alias, lines[alias]); err == nil || err.Error() != want {
+			t.Errorf("registerUserAlias(%s) err = %v; want %s", alias, err, want)
+		}
+	}
+}
+
 func TestUnknown(t *testing.T) {
 	got := unknown{}.execute(&ip.Client{}, []string{}, make(chan string))
 	want := "unknown command\n"
@@ -36,3 +101,597 @@ func TestUnknown(t *testing.T) {
 		t.Errorf("got = '%s'; want '%s'", got, want)
 	}
 }
+
+func TestHelp(t *testing.T) {
+	h := help{}
+
+	got := h.execute(&ip.Client{}, []string{}, make(chan string))
+	for name, cmd := range commands {
+		if !strings.Contains(got, commandHelp(cmd)) {
+			t.Errorf("help does not list command %s", name)
+		}
+	}
+
+	got = h.execute(&ip.Client{}, []string{"shoot"}, make(chan string))
+	for _, want := range []string{`"capture"`, `Possible aliases: "shoot", "shutter", "snap"`, "Examples:\n\t  capture\n\t  capture 5\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("help shoot got = '%s'; want it to contain '%s'", got, want)
+		}
+	}
+
+	got = h.execute(&ip.Client{}, []string{"iris"}, make(chan string))
+	want := "\nUnknown command iris!\n"
+	if got != want {
+		t.Errorf("got = '%s'; want '%s'", got, want)
+	}
+}
+
+func TestObjectSelection(t *testing.T) {
+	store := &ip.ObjectNode{StorageID: 0x10000001}
+	dcim := &ip.ObjectNode{Parent: store, Info: &ptp.ObjectInfo{Filename: "DCIM", ObjectFormat: ptp.OFC_Association}}
+	img := &ip.ObjectNode{
+		Handle: 5,
+		Parent: dcim,
+		Info: &ptp.ObjectInfo{
+			Filename:    "DSCF0001.JPG",
+			CaptureDate: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	check := []struct {
+		args  []string
+		since time.Time
+		want  bool
+	}{
+		{[]string{"0x00000005"}, time.Time{}, true},
+		{[]string{"0x6"}, time.Time{}, false},
+		{[]string{"*.JPG"}, time.Time{}, true},
+		{[]string{"*.RAF"}, time.Time{}, false},
+		{[]string{"/store_10000001/DCIM/DSCF*"}, time.Time{}, true},
+		{[]string{"/store_10000001/*.JPG"}, time.Time{}, false},
+		{[]string{"*.RAF", "0x00000005"}, time.Time{}, true},
+		{nil, time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC), true},
+		{nil, time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, c := range check {
+		var sel objectSelection
+		for _, arg := range c.args {
+			if err := sel.add(arg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c.args == nil {
+			sel.new, sel.since = true, c.since
+		}
+		if got := sel.match(img); got != c.want {
+			t.Errorf("objectSelection%v.match() = %t; want %t", c.args, got, c.want)
+		}
+	}
+
+	var sel objectSelection
+	if !sel.empty() {
+		t.Error("objectSelection.empty() = false; want true")
+	}
+	for _, arg := range []string{"0xzz", "[a-"} {
+		if err := sel.add(arg); err == nil {
+			t.Errorf("objectSelection.add(%s) error = <nil>; want error", arg)
+		}
+	}
+}
+
+func TestFilenameTemplate(t *testing.T) {
+	data := ip.DownloadTemplateData{
+		ObjectInfo: &ptp.ObjectInfo{
+			Filename:    "DSCF0001.JPG",
+			CaptureDate: time.Date(2021, 6, 1, 13, 4, 5, 0, time.UTC),
+		},
+		Handle:   5,
+		Sequence: 12,
+	}
+
+	check := []struct {
+		in   string
+		want string
+	}{
+		{"%f", "DSCF0001.JPG"},
+		{"%Y/%m/%d/%f", "2021/06/01/DSCF0001.JPG"},
+		{"%y%m%d_%H%M%S.%e", "210601_130405.JPG"},
+		{"%n-%s.%e", "DSCF0001-0012.JPG"},
+		{"%h_%f", "00000005_DSCF0001.JPG"},
+		{"100%% {{.Handle}} %q%", "100% {{.Handle}} %q%"},
+	}
+	for _, c := range check {
+		tmpl, err := template.New("").Parse(filenameTemplate(c.in))
+		if err != nil {
+			t.Fatalf("filenameTemplate(%s) error = %s", c.in, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != c.want {
+			t.Errorf("filenameTemplate(%s) renders '%s'; want '%s'", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDownloadHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptp-ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := downloadHistory{path: filepath.Join(dir, "ptp-ip", "downloads.json"), key: "camera"}
+	got, err := h.last()
+	if err != nil || !got.IsZero() {
+		t.Errorf("last() = %s, %v; want zero time, <nil>", got, err)
+	}
+
+	newest := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, d := range []time.Time{newest, newest.Add(-time.Hour)} {
+		if err := h.save(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err = h.last(); err != nil || !got.Equal(newest) {
+		t.Errorf("last() = %s, %v; want %s, <nil>", got, err, newest)
+	}
+
+	other := downloadHistory{path: h.path, key: "other"}
+	if got, err = other.last(); err != nil || !got.IsZero() {
+		t.Errorf("last() = %s, %v; want zero time, <nil>", got, err)
+	}
+}
+
+func TestObjectFilter(t *testing.T) {
+	img := &ip.ObjectNode{Info: &ptp.ObjectInfo{
+		ObjectFormat: ptp.OFC_EXIF_JPEG,
+		CaptureDate:  time.Date(2021, 6, 1, 23, 59, 0, 0, time.Local),
+	}}
+	dir := &ip.ObjectNode{Info: &ptp.ObjectInfo{ObjectFormat: ptp.OFC_Association}}
+
+	check := []struct {
+		args []string
+		img  bool
+		dir  bool
+	}{
+		{nil, true, true},
+		{[]string{"format=jpeg"}, true, false},
+		{[]string{"format=tiff"}, false, false},
+		{[]string{"since=2021-06-01", "until=2021-06-01"}, true, false},
+		{[]string{"since=2021-06-02"}, false, false},
+		{[]string{"until=2021-05-31"}, false, false},
+	}
+	for _, c := range check {
+		var of objectFilter
+		for _, arg := range c.args {
+			if err := of.set(arg); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := of.match(img); got != c.img {
+			t.Errorf("objectFilter%v.match(img) = %t; want %t", c.args, got, c.img)
+		}
+		if got := of.match(dir); got != c.dir {
+			t.Errorf("objectFilter%v.match(dir) = %t; want %t", c.args, got, c.dir)
+		}
+	}
+
+	var of objectFilter
+	for _, arg := range []string{"size=10", "since=yesterday", "format=raw"} {
+		if err := of.set(arg); err == nil {
+			t.Errorf("objectFilter.set(%s) error = <nil>; want error", arg)
+		}
+	}
+}
+
+func TestGrid(t *testing.T) {
+	defer atomic.StoreInt32(&liveViewGrid, 0)
+
+	check := []struct {
+		args []string
+		want string
+		grid int32
+	}{
+		{[]string{}, "grid: none\n", 0},
+		{[]string{"thirds"}, "grid set to thirds\n", 1},
+		{[]string{"16:9"}, "grid set to 16:9\n", 3},
+		{[]string{"golden"}, "grid error: unknown grid 'golden'\n", 3},
+		{[]string{}, "grid: 16:9\n", 3},
+		{[]string{"none"}, "grid set to none\n", 0},
+	}
+	for _, c := range check {
+		got := grid{}.execute(&ip.Client{}, c.args, make(chan string))
+		if got != c.want {
+			t.Errorf("execute(%v) got = '%s'; want '%s'", c.args, got, c.want)
+		}
+		if g := atomic.LoadInt32(&liveViewGrid); g != c.grid {
+			t.Errorf("execute(%v) liveViewGrid = %d; want %d", c.args, g, c.grid)
+		}
+	}
+}
+
+func TestZebra(t *testing.T) {
+	defer atomic.StoreInt32(&zebraIRE, 0)
+
+	check := []struct {
+		args []string
+		want string
+		ire  int32
+	}{
+		{[]string{}, "zebra enabled above 95 IRE\n", 95},
+		{[]string{}, "zebra disabled\n", 0},
+		{[]string{"90"}, "zebra enabled above 90 IRE\n", 90},
+		{[]string{"on"}, "zebra enabled above 95 IRE\n", 95},
+		{[]string{"101"}, "zebra error: invalid threshold '101'\n", 95},
+		{[]string{"off"}, "zebra disabled\n", 0},
+	}
+	for _, c := range check {
+		got := zebra{}.execute(&ip.Client{}, c.args, make(chan string))
+		if got != c.want {
+			t.Errorf("execute(%v) got = '%s'; want '%s'", c.args, got, c.want)
+		}
+		if ire := atomic.LoadInt32(&zebraIRE); ire != c.ire {
+			t.Errorf("execute(%v) zebraIRE = %d; want %d", c.args, ire, c.ire)
+		}
+	}
+}
+
+func TestRm(t *testing.T) {
+	check := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "rm error: select the objects to delete by handle or filename pattern\n"},
+		{[]string{"--dry-run"}, "rm error: select the objects to delete by handle or filename pattern\n"},
+		{[]string{"0xnope"}, "rm error: invalid object handle '0xnope'\n"},
+	}
+	for _, c := range check {
+		got := rm{}.execute(&ip.Client{}, c.args, make(chan string))
+		if got != c.want {
+			t.Errorf("execute(%v) got = '%s'; want '%s'", c.args, got, c.want)
+		}
+	}
+}
+
+func TestParseWatchOptions(t *testing.T) {
+	got, err := parseWatchOptions([]string{"json", "count=3", "for=1m", "0x4006", "4002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := watchOptions{
+		codes:    []ptp.EventCode{ptp.EC_DevicePropChanged, ptp.EC_ObjectAdded},
+		json:     true,
+		count:    3,
+		duration: time.Minute,
+	}
+	if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", want) {
+		t.Errorf("parseWatchOptions() got = %v; want %v", got, want)
+	}
+
+	for _, arg := range []string{"count=0", "count=x", "for=-1s", "for=1", "0xzz"} {
+		if _, err := parseWatchOptions([]string{arg}); err == nil {
+			t.Errorf("parseWatchOptions(%s) error = <nil>; want error", arg)
+		}
+	}
+}
+
+func TestParseTimelapseOptions(t *testing.T) {
+	got, err := parseTimelapseOptions([]string{"interval=10s", "for=1h", "ramp=exposure:1/250..1/4", "dir=/tmp/tl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := timelapseOptions{
+		interval: 10 * time.Second,
+		duration: time.Hour,
+		ramp:     "exposure:1/250..1/4",
+		dir:      "/tmp/tl",
+	}
+	if got != want {
+		t.Errorf("parseTimelapseOptions() got = %v; want %v", got, want)
+	}
+	if n := got.frames(); n != 360 {
+		t.Errorf("frames() = %d; want 360", n)
+	}
+
+	got, err = parseTimelapseOptions([]string{"interval=1m", "for=1h", "count=10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := got.frames(); n != 10 {
+		t.Errorf("frames() = %d; want 10", n)
+	}
+
+	check := map[string][]string{
+		"the interval is missing, e.g. 'interval=10s'":          {"count=10"},
+		"invalid interval '0s'":                                 {"interval=0s"},
+		"invalid count '-1'":                                    {"interval=1s", "count=-1"},
+		"invalid duration 'long'":                               {"interval=1s", "for=long"},
+		"invalid argument 'view', use 'name=value'":             {"interval=1s", "view"},
+		"unknown argument 'every'":                              {"every=1s"},
+		"the duration is shorter than the interval":             {"interval=1m", "for=30s"},
+		"ramping requires the amount of frames or the duration": {"interval=1s", "ramp=iso:200..800"},
+	}
+	for want, args := range check {
+		if _, err := parseTimelapseOptions(args); err == nil || err.Error() != want {
+			t.Errorf("parseTimelapseOptions(%v) err = %v; want %s", args, err, want)
+		}
+	}
+}
+
+func TestParseRampSpec(t *testing.T) {
+	check := map[string][3]string{
+		"exposure:1/250..1/4": {"exposure", "1/250", "1/4"},
+		"exp-bias:-0.5..-2":   {"exp-bias", "-0.5", "-2"},
+		"0x500f:200..6400":    {"0x500f", "200", "6400"},
+	}
+	for spec, want := range check {
+		name, from, to, err := parseRampSpec(spec)
+		if err != nil || [3]string{name, from, to} != want {
+			t.Errorf("parseRampSpec(%s) got = %s, %s, %s, %v; want %v, <nil>", spec, name, from, to, err, want)
+		}
+	}
+
+	for _, spec := range []string{"exposure", "iso:200", "iso:..800", ":1..2"} {
+		if _, _, _, err := parseRampSpec(spec); err == nil {
+			t.Errorf("parseRampSpec(%s) err = <nil>; want error", spec)
+		}
+	}
+}
+
+func TestRampValues(t *testing.T) {
+	// Exposure bias in thirds of a stop, listed in a random order.
+	supported := []int64{0, -333, -667, -1000, 333, 667, 1000, -1333, -1667, -2000}
+	check := []struct {
+		from, to int64
+		frames   int
+		want     []int64
+	}{
+		{0, -2000, 7, []int64{0, -333, -667, -1000, -1333, -1667, -2000}},
+		{0, -2000, 4, []int64{0, -667, -1333, -2000}},
+		{-2000, 0, 3, []int64{-2000, -1000, 0}},
+		{0, 1000, 7, []int64{0, 333, 333, 667, 667, 1000, 1000}},
+		{-700, 5000, 2, []int64{-667, 1000}},
+		{333, -333, 1, []int64{333}},
+	}
+	for _, c := range check {
+		if got := rampValues(supported, c.from, c.to, c.frames); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("rampValues(%d, %d, %d) = %v; want %v", c.from, c.to, c.frames, got, c.want)
+		}
+	}
+}
+
+func TestTimelapse(t *testing.T) {
+	got := timelapse{}.execute(&ip.Client{}, []string{"count=3"}, make(chan string))
+	want := "timelapse error: the interval is missing, e.g. 'interval=10s'\n"
+	if got != want {
+		t.Errorf("execute() got = '%s'; want '%s'", got, want)
+	}
+}
+
+func TestParseBracketOptions(t *testing.T) {
+	got, err := parseBracketOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (bracketOptions{frames: 3, step: 1}); got != want {
+		t.Errorf("parseBracketOptions() got = %v; want %v", got, want)
+	}
+
+	got, err = parseBracketOptions([]string{"frames=5", "step=2/3", "by=exposure", "dir=/tmp/hdr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (bracketOptions{frames: 5, step: 2.0 / 3, by: "exposure", dir: "/tmp/hdr"}); got != want {
+		t.Errorf("parseBracketOptions() got = %v; want %v", got, want)
+	}
+
+	check := map[string][]string{
+		"invalid amount of frames '1', at least 2 frames are required": {"frames=1"},
+		"invalid step '-1', use stops such as '1/3' or '1'":            {"step=-1"},
+		"invalid step 'half', use stops such as '1/3' or '1'":          {"step=half"},
+		"invalid property 'iso', use exp-bias or exposure":             {"by=iso"},
+		"invalid argument 'hdr', use 'name=value'":                     {"hdr"},
+		"unknown argument 'count'":                                     {"count=3"},
+	}
+	for want, args := range check {
+		if _, err := parseBracketOptions(args); err == nil || err.Error() != want {
+			t.Errorf("parseBracketOptions(%v) err = %v; want %s", args, err, want)
+		}
+	}
+}
+
+func TestBracketOffsets(t *testing.T) {
+	check := map[int][]int{
+		2: {0, -1},
+		3: {0, -1, 1},
+		5: {0, -1, 1, -2, 2},
+		6: {0, -1, 1, -2, 2, -3},
+	}
+	for frames, want := range check {
+		if got := bracketOffsets(frames); !reflect.DeepEqual(got, want) {
+			t.Errorf("bracketOffsets(%d) = %v; want %v", frames, got, want)
+		}
+	}
+}
+
+func TestBracketValues(t *testing.T) {
+	// Exposure bias in thirds of a stop, listed in a random order.
+	bias := []int64{0, -333, -667, -1000, 333, 667, 1000, -1333, -1667, -2000}
+	ev := func(v int64) float64 { return float64(v) / 1000 }
+	// Exposure times in 0.1 milliseconds from 1/1000s to 1s in full stops.
+	times := []int64{10, 20, 40, 80, 156, 313, 625, 1250, 2500, 5000, 10000}
+	log := func(v int64) float64 { return math.Log2(float64(v)) }
+
+	check := []struct {
+		supported []int64
+		stops     func(int64) float64
+		current   int64
+		frames    int
+		step      float64
+		want      []int64
+	}{
+		{bias, ev, 0, 3, 1, []int64{0, -1000, 1000}},
+		{bias, ev, -667, 5, 2.0 / 3, []int64{-667, -1333, 0, -2000, 667}},
+		{bias, ev, 667, 5, 1, []int64{667, -333, 1000, -1333, 1000}},
+		{times, log, 313, 3, 1, []int64{313, 156, 625}},
+		{times, log, 10000, 5, 2, []int64{10000, 2500, 10000, 625, 10000}},
+		{times, log, 80, 2, 1.0 / 3, []int64{80, 80}},
+	}
+	for _, c := range check {
+		if got := bracketValues(c.supported, c.current, c.frames, c.step, c.stops); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("bracketValues(%d, %d, %v) = %v; want %v", c.current, c.frames, c.step, got, c.want)
+		}
+	}
+}
+
+func TestBracket(t *testing.T) {
+	got := bracket{}.execute(&ip.Client{}, []string{"frames=0"}, make(chan string))
+	want := "bracket error: invalid amount of frames '0', at least 2 frames are required\n"
+	if got != want {
+		t.Errorf("execute() got = '%s'; want '%s'", got, want)
+	}
+}
+
+func TestParseBulbDuration(t *testing.T) {
+	check := map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"2m30s": 150 * time.Second,
+		"90":    90 * time.Second,
+	}
+	for in, want := range check {
+		got, err := parseBulbDuration([]string{in})
+		if err != nil || got != want {
+			t.Errorf("parseBulbDuration(%s) got = %s, %v; want %s, <nil>", in, got, err, want)
+		}
+	}
+
+	errs := map[string][]string{
+		"a single duration is required, e.g. 'bulb 30s'": {"30s", "now"},
+		"invalid duration 'long'":                        {"long"},
+		"invalid duration '0'":                           {"0"},
+	}
+	for want, args := range errs {
+		if _, err := parseBulbDuration(args); err == nil || err.Error() != want {
+			t.Errorf("parseBulbDuration(%v) err = %v; want %s", args, err, want)
+		}
+	}
+}
+
+func TestBulb(t *testing.T) {
+	got := bulb{}.execute(&ip.Client{}, nil, make(chan string))
+	want := "bulb error: a single duration is required, e.g. 'bulb 30s'\n"
+	if got != want {
+		t.Errorf("execute() got = '%s'; want '%s'", got, want)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	check := map[string]string{
+		"":              "focus error: the action is missing, use one of near, far, point or reset\n",
+		"near 0":        "focus error: invalid amount of steps '0'\n",
+		"far 70000":     "focus error: invalid amount of steps '70000'\n",
+		"far 1 2":       "focus error: too many arguments\n",
+		"point 4":       "focus error: the point needs an x and a y coordinate, e.g. 'focus point 4 3'\n",
+		"point 4 -3":    "focus error: invalid coordinate '-3'\n",
+		"infinity":      "focus error: unknown action 'infinity', use one of near, far, point or reset\n",
+		"point 4 three": "focus error: invalid coordinate 'three'\n",
+	}
+	for args, want := range check {
+		if got := (focus{}).execute(&ip.Client{}, strings.Fields(args), make(chan string)); got != want {
+			t.Errorf("execute(%s) got = '%s'; want '%s'", args, got, want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	check := map[string]string{
+		"":                 "reset error: exactly one property is required, e.g. 'reset whitebalance'\n",
+		"iso whitebalance": "reset error: exactly one property is required, e.g. 'reset whitebalance'\n",
+	}
+	for args, want := range check {
+		if got := (reset{}).execute(&ip.Client{}, strings.Fields(args), make(chan string)); got != want {
+			t.Errorf("execute(%s) got = '%s'; want '%s'", args, got, want)
+		}
+	}
+}
+
+func TestZoom(t *testing.T) {
+	check := map[string]string{
+		"in 0":   "zoom error: invalid amount of steps '0'\n",
+		"out x":  "zoom error: invalid amount of steps 'x'\n",
+		"in 1 2": "zoom error: too many arguments\n",
+		"close":  "zoom error: invalid zoom 'close', use in, out or a zoom factor such as 2x\n",
+		"2x 3":   "zoom error: invalid zoom '2x 3', use in, out or a zoom factor such as 2x\n",
+		"0x":     "zoom error: invalid zoom '0x', use in, out or a zoom factor such as 2x\n",
+	}
+	for args, want := range check {
+		if got := (zoom{}).execute(&ip.Client{}, strings.Fields(args), make(chan string)); got != want {
+			t.Errorf("execute(%s) got = '%s'; want '%s'", args, got, want)
+		}
+	}
+}
+
+func TestZoomStep(t *testing.T) {
+	// Digital zoom factors from 1x to 4x, listed in a random order.
+	supported := []int64{20, 10, 40, 15, 30}
+	check := []struct {
+		current int64
+		steps   int
+		want    int64
+	}{
+		{10, 1, 15},
+		{15, 2, 30},
+		{30, -1, 20},
+		{40, 1, 40},
+		{15, -5, 10},
+		// A value the camera does not list is replaced by the closest one first.
+		{24, 1, 30},
+	}
+	for _, c := range check {
+		if got := zoomStep(supported, c.current, c.steps); got != c.want {
+			t.Errorf("zoomStep(%d, %d) = %d; want %d", c.current, c.steps, got, c.want)
+		}
+	}
+}
+
+func TestZoomFactor(t *testing.T) {
+	check := map[int64]string{10: "1x", 15: "1.5x", 25: "2.5x", 40: "4x"}
+	for v, want := range check {
+		if got := zoomFactor(v); got != want {
+			t.Errorf("zoomFactor(%d) = %s; want %s", v, got, want)
+		}
+	}
+}
+
+func TestOnInterrupt(t *testing.T) {
+	if interruptCommand() {
+		t.Error("interruptCommand() = true; want false")
+	}
+
+	called := 0
+	done := onInterrupt(func() { called++ })
+	if !interruptCommand() || called != 1 {
+		t.Errorf("interruptCommand() called = %d; want 1", called)
+	}
+	// The function is only called once.
+	if interruptCommand() || called != 1 {
+		t.Errorf("interruptCommand() called = %d; want 1", called)
+	}
+	done()
+
+	onInterrupt(func() { called++ })()
+	if interruptCommand() {
+		t.Error("interruptCommand() = true after done; want false")
+	}
+
+	// The previous function is restored when done.
+	outer := onInterrupt(func() { called += 10 })
+	onInterrupt(func() { called += 100 })()
+	interruptCommand()
+	outer()
+	if called != 11 {
+		t.Errorf("interruptCommand() called = %d; want 11", called)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// completionShells are the shells a completion script can be generated for.
+var completionShells = []string{"bash", "fish", "zsh"}
+
+// completionFileFlags are the flags taking a file name as their value.
+var completionFileFlags = map[string]bool{"f": true, "script": true}
+
+// completionSpec holds the words the shell completion offers, gathered from the registered flags, commands and
+// properties.
+type completionSpec struct {
+	// Exe is the name of the executable to complete.
+	Exe string
+	// Func is the name of the executable usable in a shell function name.
+	Func string
+	// Flags are the flags of the executable, sorted by name.
+	Flags []completionFlag
+	// Commands are the names and aliases of the commands, including the user defined ones, sorted alphabetically.
+	Commands []string
+	// Args are the words completing the first argument of the commands.
+	Args []completionArgs
+	// Values are the words completing the second argument of the commands, e.g. the values of a property.
+	Values []completionArgs
+}
+
+// completionFlag describes a flag of the executable.
+type completionFlag struct {
+	Name  string
+	Usage string
+	// Value is true when the flag takes a value.
+	Value bool
+	// File is true when the value of the flag is a file name.
+	File bool
+	// Words are the possible values of the flag, if known.
+	Words []string
+}
+
+// completionArgs holds the words completing an argument of a command. Patterns holds the command lines preceding the
+// argument, such as "get" or "set iso", one for each alias of the command.
+type completionArgs struct {
+	Patterns []string
+	Words    []string
+}
+
+// newCompletionSpec gathers the words to complete from the given flag set and the registered commands. The values of
+// the properties are those of the vendor in the config.
+func newCompletionSpec(fs *flag.FlagSet) completionSpec {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
+	spec := completionSpec{
+		Exe: exe,
+		Func: strings.Map(func(r rune) rune {
+			if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return '_'
+			}
+			return r
+		}, exe),
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{Name: f.Name, Usage: f.Usage, Value: true, File: completionFileFlags[f.Name]}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.Value = false
+		}
+		if f.Name == "completion" {
+			cf.Words = completionShells
+		}
+		spec.Flags = append(spec.Flags, cf)
+	})
+
+	names := make(map[string][]string, len(commands))
+	for n := range commands {
+		names[n] = []string{n}
+	}
+	for a, n := range aliases {
+		names[n] = append(names[n], a)
+	}
+	cmds := make([]string, 0, len(commands))
+	for n := range commands {
+		cmds = append(cmds, n)
+		sort.Strings(names[n][1:])
+		spec.Commands = append(spec.Commands, names[n]...)
+	}
+	for a := range userAliases {
+		spec.Commands = append(spec.Commands, a)
+	}
+	sort.Strings(cmds)
+	sort.Strings(spec.Commands)
+
+	for _, n := range cmds {
+		c, ok := commands[n].(completer)
+		if !ok {
+			continue
+		}
+		args := completionWords(c.completions(nil))
+		if len(args) == 0 {
+			continue
+		}
+		spec.Args = append(spec.Args, completionArgs{Patterns: names[n], Words: args})
+
+		for _, arg := range args {
+			values := completionWords(c.completions([]string{arg}))
+			if len(values) == 0 {
+				continue
+			}
+			ca := completionArgs{Words: values}
+			for _, name := range names[n] {
+				ca.Patterns = append(ca.Patterns, name+" "+arg)
+			}
+			spec.Values = append(spec.Values, ca)
+		}
+	}
+
+	return spec
+}
+
+// completionWords drops the words containing white space, which cannot be completed as a single word, e.g. the
+// "pro neg. hi" film simulation. The hexadecimal value can be used instead.
+func completionWords(words []string) []string {
+	var res []string
+	for _, w := range words {
+		if w != "" && !strings.ContainsAny(w, " \t'\"") {
+			res = append(res, w)
+		}
+	}
+
+	return res
+}
+
+// completeProperty returns the unified field names when no arguments are given, as accepted by the commands taking a
+// property as their first argument.
+func completeProperty(args []string) []string {
+	if len(args) == 0 {
+		return ptpfmt.UnifiedFieldNames
+	}
+
+	return nil
+}
+
+// completionScript returns the completion script for the given shell, completing the flags and the command passed
+// using the -c flag.
+func completionScript(shell string, fs *flag.FlagSet) (string, error) {
+	var tpl *template.Template
+	switch shell {
+	case "bash":
+		tpl = bashCompletion
+	case "fish":
+		tpl = fishCompletion
+	case "zsh":
+		tpl = zshCompletion
+	default:
+		return "", fmt.Errorf("unsupported shell '%s', use one of %s", shell, strings.Join(completionShells, ", "))
+	}
+
+	var b strings.Builder
+	if err := tpl.Execute(&b, newCompletionSpec(fs)); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+var completionFuncs = template.FuncMap{
+	"join": strings.Join,
+	// fishquote quotes a string for use in a fish script.
+	"fishquote": func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	},
+	// shquote quotes a string for use in a bash script.
+	"shquote": func(s string) string {
+		return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+	},
+}
+
+// bashCompletionBody completes the flags and the words of the command passed using the -c flag, which is also used by
+// the zsh completion through bashcompinit.
+const bashCompletionBody = `_{{.Func}}_command() {
+	local line=$1 cur=${1##* } words=
+	local -a f=($1)
+	local n=${#f[@]}
+	[[ -z $cur ]] && n=$((n + 1))
+
+	case $n in
+	1) words={{shquote (join .Commands " ")}} ;;
+	2)
+		case ${f[0]} in
+{{- range .Args}}
+		{{join .Patterns "|"}}) words={{shquote (join .Words " ")}} ;;
+{{- end}}
+		esac
+		;;
+	3)
+		case "${f[0]} ${f[1]}" in
+{{- range .Values}}
+		{{range $i, $p := .Patterns}}{{if $i}}|{{end}}{{shquote $p}}{{end}}) words={{shquote (join .Words " ")}} ;;
+{{- end}}
+		esac
+		;;
+	esac
+
+	local w
+	for w in $(compgen -W "$words" -- "$cur"); do
+		COMPREPLY+=("${line%"$cur"}$w")
+	done
+}
+
+_{{.Func}}() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	COMPREPLY=()
+
+	case $prev in
+	-c)
+		compopt -o nospace 2>/dev/null
+		_{{.Func}}_command "${cur#[\"\']}"
+		return
+		;;
+{{- range .Flags}}{{if and .Value (ne .Name "c")}}
+	-{{.Name}})
+{{- if .File}}
+		COMPREPLY=($(compgen -f -- "$cur"))
+{{- else if .Words}}
+		COMPREPLY=($(compgen -W {{shquote (join .Words " ")}} -- "$cur"))
+{{- end}}
+		return
+		;;
+{{- end}}{{end}}
+	esac
+
+	COMPREPLY=($(compgen -W '{{range $i, $f := .Flags}}{{if $i}} {{end}}-{{$f.Name}}{{end}}' -- "$cur"))
+}
+
+complete -F _{{.Func}} {{.Exe}}
+`
+
+var (
+	bashCompletion = template.Must(template.New("bash").Funcs(completionFuncs).Parse(
+		`# bash completion for {{.Exe}}, load it by running: source <({{.Exe}} -completion bash)
+` + bashCompletionBody))
+
+	zshCompletion = template.Must(template.New("zsh").Funcs(completionFuncs).Parse(
+		`#compdef {{.Exe}}
+# zsh completion for {{.Exe}}, load it by running: source <({{.Exe}} -completion zsh)
+autoload -U +X bashcompinit && bashcompinit
+
+` + bashCompletionBody))
+
+	fishCompletion = template.Must(template.New("fish").Funcs(completionFuncs).Parse(
+		`# fish completion for {{.Exe}}, load it by running: {{.Exe}} -completion fish | source
+function __{{.Func}}_command
+    set -l line (string replace -r '^[\'"]' '' -- (commandline -ct))
+    set -l f (string split -n ' ' -- $line)
+    set -l n (count $f)
+    if test -z "$line"; or string match -q '* ' -- $line
+        set n (math $n + 1)
+    end
+    set -l pre (string replace -r '[^ ]*$' '' -- $line)
+
+    set -l words
+    switch $n
+        case 1
+            set words {{join .Commands " "}}
+        case 2
+            switch $f[1]
+{{- range .Args}}
+                case {{join .Patterns " "}}
+                    set words {{range $i, $w := .Words}}{{if $i}} {{end}}{{fishquote $w}}{{end}}
+{{- end}}
+            end
+        case 3
+            switch "$f[1] $f[2]"
+{{- range .Values}}
+                case{{range .Patterns}} {{fishquote .}}{{end}}
+                    set words {{range $i, $w := .Words}}{{if $i}} {{end}}{{fishquote $w}}{{end}}
+{{- end}}
+            end
+    end
+
+    for w in $words
+        echo $pre$w
+    end
+end
+
+complete -c {{.Exe}} -f
+{{- range .Flags}}
+complete -c {{$.Exe}} -o {{fishquote .Name}}
+{{- if eq .Name "c"}} -x -a '(__{{$.Func}}_command)'
+{{- else if .File}} -r -F
+{{- else if .Words}} -x -a {{fishquote (join .Words " ")}}
+{{- else if .Value}} -x
+{{- end}} -d {{fishquote .Usage}}
+{{- end}}
+`))
+)
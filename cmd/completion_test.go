@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testCompletionFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("c", "", "The command to send to the responder.")
+	fs.String("completion", "", "Print the shell completion script.")
+	fs.String("f", "", "Read all settings from a config file.")
+	fs.Bool("i", false, "Run with an interactive shell.")
+	fs.String("t", "generic", "The vendor of the responder.")
+
+	return fs
+}
+
+func TestNewCompletionSpec(t *testing.T) {
+	defer func(e string) { exe = e }(exe)
+	exe = "ptp-ip"
+	defer delete(userAliases, "iso800")
+	userAliases["iso800"] = "set iso 800"
+
+	spec := newCompletionSpec(testCompletionFlags())
+
+	if spec.Func != "ptp_ip" {
+		t.Errorf("Func = %s; want ptp_ip", spec.Func)
+	}
+
+	wantFlags := []completionFlag{
+		{Name: "c", Usage: "The command to send to the responder.", Value: true},
+		{Name: "completion", Usage: "Print the shell completion script.", Value: true, Words: completionShells},
+		{Name: "f", Usage: "Read all settings from a config file.", Value: true, File: true},
+		{Name: "i", Usage: "Run with an interactive shell."},
+		{Name: "t", Usage: "The vendor of the responder.", Value: true},
+	}
+	if !reflect.DeepEqual(spec.Flags, wantFlags) {
+		t.Errorf("Flags = %v; want %v", spec.Flags, wantFlags)
+	}
+
+	for _, want := range []string{"capture", "shoot", "iso800"} {
+		found := false
+		for _, c := range spec.Commands {
+			found = found || c == want
+		}
+		if !found {
+			t.Errorf("Commands = %v; want it to contain %s", spec.Commands, want)
+		}
+	}
+
+	wantArgs := completionArgs{Patterns: []string{"zebra"}, Words: []string{"on", "off"}}
+	if got := spec.Args[len(spec.Args)-2]; !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("Args = %v; want %v", got, wantArgs)
+	}
+
+	found := false
+	for _, v := range spec.Values {
+		if reflect.DeepEqual(v.Patterns, []string{"set whitebalance"}) {
+			found = true
+			if want := "automatic"; v.Words[0] != want {
+				t.Errorf("Values set whitebalance = %v; want it to start with %s", v.Words, want)
+			}
+		}
+	}
+	if !found {
+		t.Error("Values does not complete set whitebalance")
+	}
+}
+
+func TestCompletionWords(t *testing.T) {
+	got := completionWords([]string{"provia", "pro neg. hi", "", "16:9", "it's"})
+	if want := []string{"provia", "16:9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completionWords() = %v; want %v", got, want)
+	}
+}
+
+func TestCompletionScript(t *testing.T) {
+	defer func(e string) { exe = e }(exe)
+	exe = "ptpip"
+
+	check := map[string][]string{
+		"bash": {"complete -F _ptpip ptpip", "'set whitebalance') words='automatic daylight"},
+		"zsh":  {"#compdef ptpip", "bashcompinit", "complete -F _ptpip ptpip"},
+		"fish": {"complete -c ptpip -o 'f' -r -F", "complete -c ptpip -o 'c' -x -a '(__ptpip_command)'"},
+	}
+	for shell, wants := range check {
+		got, err := completionScript(shell, testCompletionFlags())
+		if err != nil {
+			t.Fatalf("completionScript(%s) err = %s; want <nil>", shell, err)
+		}
+		for _, want := range wants {
+			if !strings.Contains(got, want) {
+				t.Errorf("completionScript(%s) does not contain '%s'", shell, want)
+			}
+		}
+	}
+
+	wantE := "unsupported shell 'tcsh', use one of bash, fish, zsh"
+	if _, err := completionScript("tcsh", testCompletionFlags()); err == nil || err.Error() != wantE {
+		t.Errorf("completionScript(tcsh) err = %v; want %s", err, wantE)
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	defer func(e string) { exe = e }(exe)
+	exe = "ptpip"
+
+	script, err := completionScript("bash", testCompletionFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "completion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "ptpip.bash")
+	if err := ioutil.WriteFile(file, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[string]string{
+		`ptpip -c '"se'`:       "set",
+		`ptpip -c '"set wh'`:   "set whitebalance",
+		`ptpip -c '"grid 1'`:   "grid 16:9",
+		`ptpip -completion fi`: "fish",
+		`ptpip -com`:           "-completion",
+	}
+	for words, want := range check {
+		out, err := exec.Command(bash, "-c", `source "$0"; COMP_WORDS=(`+words+`); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); _ptpip; printf '%s\n' "${COMPREPLY[@]}"`, file).CombinedOutput()
+		if err != nil {
+			t.Fatalf("bash err = %s: %s", err, out)
+		}
+		if got := strings.TrimSpace(string(out)); got != want {
+			t.Errorf("completing %s got = %q; want %q", words, got, want)
+		}
+	}
+}
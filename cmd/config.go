@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/go-ini/ini"
 	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/viewfinder"
 	"log"
 	"os"
 )
@@ -18,9 +19,18 @@ type config struct {
 	sport  uint16Value
 	fname  string
 	guid   string
+	proxy  string
 
-	srvAddr string
-	srvPort uint16Value
+	srvAddr   string
+	srvPort   uint16Value
+	wsPort    uint16Value
+	wsOrigins stringListValue
+	webUI     bool
+
+	histogram       bool
+	histogramAnchor viewfinder.Anchor
+	vfLayout        map[string]*viewfinder.WidgetLayout
+	vfTheme         viewfinder.Theme
 }
 
 var (
@@ -32,6 +42,9 @@ var (
 		port:    uint16Value(ip.DefaultPort),
 		srvAddr: defaultIp,
 		srvPort: uint16Value(ip.DefaultPort),
+
+		histogramAnchor: viewfinder.AnchorTopRight,
+		vfTheme:         viewfinder.DefaultTheme,
 	}
 )
 
@@ -60,6 +73,9 @@ func loadConfig() {
 		if k, err := i.GetKey("host"); err == nil {
 			conf.host = k.String()
 		}
+		if k, err := i.GetKey("proxy"); err == nil {
+			conf.proxy = k.String()
+		}
 		if k, err := i.GetKey("port"); err == nil {
 			if err := conf.port.Set(k.String()); err != nil {
 				log.Fatal(valueOutOfRange)
@@ -97,6 +113,92 @@ func loadConfig() {
 				log.Fatal(valueOutOfRange)
 			}
 		}
+		if k, err := i.GetKey("websocket_port"); err == nil {
+			if err := conf.wsPort.Set(k.String()); err != nil {
+				log.Fatal(valueOutOfRange)
+			}
+		}
+		if k, err := i.GetKey("websocket_origins"); err == nil {
+			conf.wsOrigins = nil
+			conf.wsOrigins.Set(k.String())
+		}
+		if k, err := i.GetKey("web_ui"); err == nil {
+			if v, err := k.Bool(); err == nil {
+				conf.webUI = v
+			}
+		}
+	}
+
+	// Live view
+	if i, err := f.GetSection("liveview"); err == nil {
+		if k, err := i.GetKey("histogram"); err == nil {
+			if v, err := k.Bool(); err == nil {
+				conf.histogram = v
+			}
+		}
+		if k, err := i.GetKey("histogram_position"); err == nil {
+			a, err := viewfinder.ParseAnchor(k.String())
+			if err != nil {
+				log.Fatal(err)
+			}
+			conf.histogramAnchor = a
+		}
+	}
+
+	// Viewfinder layout
+	if i, err := f.GetSection("viewfinder"); err == nil {
+		conf.vfLayout = make(map[string]*viewfinder.WidgetLayout)
+		for _, k := range i.Keys() {
+			l, err := viewfinder.ParseWidgetLayout(k.String())
+			if err != nil {
+				log.Fatalf("viewfinder widget %s: %s", k.Name(), err)
+			}
+			conf.vfLayout[k.Name()] = l
+		}
+	}
+
+	// User defined command aliases
+	if i, err := f.GetSection("aliases"); err == nil {
+		for _, k := range i.Keys() {
+			if err := registerUserAlias(k.Name(), k.String()); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Viewfinder theme
+	if i, err := f.GetSection("theme"); err == nil {
+		for _, k := range i.Keys() {
+			if k.Name() == "opacity" {
+				v, err := k.Float64()
+				if err != nil || v < 0 || v > 1 {
+					log.Fatalf("viewfinder theme: invalid opacity '%s'", k.String())
+				}
+				conf.vfTheme.Opacity = v
+				continue
+			}
+
+			c, err := viewfinder.ParseColour(k.String())
+			if err != nil {
+				log.Fatalf("viewfinder theme %s: %s", k.Name(), err)
+			}
+			switch k.Name() {
+			case "foreground":
+				conf.vfTheme.Foreground = c
+			case "warning":
+				conf.vfTheme.Warning = c
+			case "highlight":
+				conf.vfTheme.Highlight = c
+			case "inactive":
+				conf.vfTheme.Inactive = c
+			case "confirmation":
+				conf.vfTheme.Confirmation = c
+			case "plate":
+				conf.vfTheme.Plate = c
+			default:
+				log.Fatalf("viewfinder theme: unknown colour '%s'", k.Name())
+			}
+		}
 	}
 }
 
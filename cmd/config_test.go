@@ -2,8 +2,11 @@ package main
 
 import (
 	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/viewfinder"
+	"image/color"
 	"os"
 	"os/exec"
+	"reflect"
 	"testing"
 )
 
@@ -62,6 +65,11 @@ func TestLoadconfigOk1(t *testing.T) {
 		t.Errorf("loadConfig() port = %d; want %d", conf.port, wantPort)
 	}
 
+	want = "socks5://127.0.0.1:1080"
+	if conf.proxy != want {
+		t.Errorf("loadConfig() proxy = %s; want %s", conf.proxy, want)
+	}
+
 	wantEnabled := true
 	if server != wantEnabled {
 		t.Errorf("loadConfig() server = %v; want %v", server, wantEnabled)
@@ -76,6 +84,51 @@ func TestLoadconfigOk1(t *testing.T) {
 	if conf.srvPort != wantPort {
 		t.Errorf("loadConfig() sport = %d; want %d", conf.srvPort, wantPort)
 	}
+
+	wantPort = uint16Value(25741)
+	if conf.wsPort != wantPort {
+		t.Errorf("loadConfig() wsport = %d; want %d", conf.wsPort, wantPort)
+	}
+
+	wantOrigins := stringListValue{"http://camera.lan:8080", "https://remote.lan"}
+	if !reflect.DeepEqual(conf.wsOrigins, wantOrigins) {
+		t.Errorf("loadConfig() wsOrigins = %v; want %v", conf.wsOrigins, wantOrigins)
+	}
+
+	if conf.webUI != wantEnabled {
+		t.Errorf("loadConfig() webUI = %v; want %v", conf.webUI, wantEnabled)
+	}
+
+	if conf.histogram != wantEnabled {
+		t.Errorf("loadConfig() histogram = %v; want %v", conf.histogram, wantEnabled)
+	}
+
+	wantAnchor := viewfinder.AnchorBottomLeft
+	if conf.histogramAnchor != wantAnchor {
+		t.Errorf("loadConfig() histogramAnchor = %d; want %d", conf.histogramAnchor, wantAnchor)
+	}
+
+	wantLayout := map[string]*viewfinder.WidgetLayout{
+		"battery": {
+			Anchored: true,
+			Anchor:   viewfinder.AnchorBottomRight,
+			OffsetX:  60,
+			OffsetY:  8,
+			Colour:   color.RGBA{R: 255, A: 255},
+		},
+		"film-simulation": {Hidden: true},
+	}
+	if !reflect.DeepEqual(conf.vfLayout, wantLayout) {
+		t.Errorf("loadConfig() vfLayout = %v; want %v", conf.vfLayout, wantLayout)
+	}
+
+	wantTheme := viewfinder.DefaultTheme
+	wantTheme.Warning = color.RGBA{R: 255, G: 128, A: 255}
+	wantTheme.Plate = color.RGBA{A: 128}
+	wantTheme.Opacity = 0.8
+	if !reflect.DeepEqual(conf.vfTheme, wantTheme) {
+		t.Errorf("loadConfig() vfTheme = %v; want %v", conf.vfTheme, wantTheme)
+	}
 }
 
 func TestLoadconfigOk2(t *testing.T) {
@@ -144,6 +197,13 @@ func TestLoadconfigOk2(t *testing.T) {
 	if conf.srvPort != wantPort {
 		t.Errorf("loadConfig() sport = %d; want %d", conf.srvPort, wantPort)
 	}
+
+	defer delete(userAliases, "hdr")
+	defer delete(userAliases, "iso800")
+	wantAliases := map[string]string{"hdr": "source hdr.txt", "iso800": "set iso 800"}
+	if !reflect.DeepEqual(userAliases, wantAliases) {
+		t.Errorf("loadConfig() userAliases = %v; want %v", userAliases, wantAliases)
+	}
 }
 
 func TestLoadConfigWrongPath(t *testing.T) {
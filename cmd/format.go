@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 func formatDeviceProperty(c *ip.Client, param string) (ptp.DevicePropCode, error) {
@@ -32,14 +33,181 @@ func formatDeviceProperty(c *ip.Client, param string) (ptp.DevicePropCode, error
 	return cod, nil
 }
 
-func formatDeviceInfo(vendor ptp.VendorExtension, data interface{}, f []string) string {
-	switch vendor {
-	case ptp.VE_FujiPhotoFilmCoLtd:
-		return fujiFormatDeviceInfo(data.([]*ptp.DevicePropDesc), f)
+// formatDevicePropValue formats a value as returned by ip.Client.GetDevicePropertyValue(). Integers are converted to a
+// human readable string followed by their hexadecimal value.
+func formatDevicePropValue(vendor ptp.VendorExtension, code ptp.DevicePropCode, v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	i, ok := devicePropValueToInt64(v)
+	if !ok {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return ptpfmt.DevicePropValAsString(vendor, code, i) + fmt.Sprintf(" (%#x)", v)
+}
+
+// devicePropValueToInt64 converts an integer value as returned by ip.Client.GetDevicePropertyValue() to int64. It
+// returns false for any other type.
+func devicePropValueToInt64(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int8:
+		return int64(val), true
+	case uint8:
+		return int64(val), true
+	case int16:
+		return int64(val), true
+	case uint16:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case uint32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint64:
+		return int64(val), true
+	}
+
+	return 0, false
+}
+
+func formatDeviceInfo(data interface{}, f []string) string {
+	switch di := data.(type) {
+	case string:
+		// The command failed and passed on the error message.
+		return di
+	case []*ptp.DevicePropDesc:
+		return fujiFormatDeviceInfo(di, f)
+	case *ip.FujiDeviceInfo:
+		if di.DeviceInfo == nil || (len(f) >= 1 && f[0] == "json") {
+			return fujiFormatDeviceInfo(di.Properties, f)
+		}
+		return formatGenericDeviceInfo(di.DeviceInfo, f) + fujiFormatDeviceInfo(di.Properties, f)
+	case *ptp.DeviceInfo:
+		return formatGenericDeviceInfo(di, f)
 	default:
-		// TODO: add generic device info formatting.
-		return ""
+		return fmt.Sprintf("unsupported device info type %T", data)
+	}
+}
+
+func formatGenericDeviceInfo(di *ptp.DeviceInfo, f []string) string {
+	if len(f) >= 1 && f[0] == "json" {
+		var opt string
+		if len(f) > 1 {
+			opt = f[1]
+		}
+
+		return fujiFormatJson(di, opt)
+	}
+
+	w, buf := newTabWriter()
+	formatRows(w, [][]string{
+		{"Field", "Value"},
+		{"-----", "-----"},
+		{"Standard version", formatVersion(di.StandardVersion)},
+		{"Vendor extension ID", fmt.Sprintf("%0#8x", di.VendorExtensionID)},
+		{"Vendor extension version", formatVersion(di.VendorExtensionVersion)},
+		{"Vendor extension description", di.VendorExtensionDesc},
+		{"Functional mode", ptpfmt.FunctionalModeAsString(di.FunctionalMode)},
+		{"Operations supported", fmt.Sprintf("%0#4x", di.OperationsSupported)},
+		{"Events supported", fmt.Sprintf("%0#4x", di.EventsSupported)},
+		{"Device properties supported", fmt.Sprintf("%0#4x", di.DevicePropertiesSupported)},
+		{"Capture formats", fmt.Sprintf("%0#4x", di.CaptureFormats)},
+		{"Image formats", fmt.Sprintf("%0#4x", di.ImageFormats)},
+		{"Manufacturer", di.Manufacturer},
+		{"Model", di.Model},
+		{"Device version", di.DeviceVersion},
+		{"Serial number", di.SerialNumber},
+	})
+
+	return "\n" + buf.String()
+}
+
+// formatVersion formats a version expressed in hundredths, e.g. 132 becomes 1.32.
+func formatLiveViewStats(s ip.LiveViewStats, f []string) string {
+	if len(f) >= 1 && f[0] == "json" {
+		var opt string
+		if len(f) > 1 {
+			opt = f[1]
+		}
+
+		return fujiFormatJson(s, opt)
+	}
+
+	w, buf := newTabWriter()
+	formatRows(w, [][]string{
+		{"Frames received:", strconv.FormatUint(s.Received, 10)},
+		{"Frames delivered:", strconv.FormatUint(s.Delivered, 10)},
+		{"Frames dropped:", strconv.FormatUint(s.Dropped, 10)},
+		{"Frame rate:", fmt.Sprintf("%.1f fps", s.FPS)},
+		{"Latency:", s.Latency.Round(time.Millisecond).String()},
+		{"Max latency:", s.MaxLatency.Round(time.Millisecond).String()},
+	})
+
+	return buf.String()
+}
+
+// formatObjectNodes formats the nodes as listed by the ls command: the object handle followed by the name of the
+// object, or the full path when listing recursively. Directories end in a slash. The long format adds the format, size
+// and capture date of the objects.
+func formatObjectNodes(nodes []*ip.ObjectNode, long bool, fullPath bool) string {
+	if len(nodes) == 0 {
+		return "no objects found\n"
+	}
+
+	w, buf := newTabWriter()
+	var rows [][]string
+	if long {
+		rows = append(rows, []string{"Handle", "Format", "Size", "Captured", "Name"})
+		rows = append(rows, []string{"------", "------", "----", "--------", "----"})
 	}
+	for _, n := range nodes {
+		name := n.Name()
+		if fullPath {
+			name = n.Path()
+		}
+		if n.IsDir() {
+			name += "/"
+		}
+
+		// Stores have no handle of their own nor an ObjectInfo dataset.
+		if n.Info == nil {
+			if long {
+				rows = append(rows, []string{"-", "store", "-", "-", name})
+			} else {
+				rows = append(rows, []string{"-", name})
+			}
+			continue
+		}
+
+		handle := fmt.Sprintf("%0#8x", uint32(n.Handle))
+		if !long {
+			rows = append(rows, []string{handle, name})
+			continue
+		}
+
+		format := ptpfmt.ObjectFormatCodeAsString(n.Info.ObjectFormat)
+		if format == "" {
+			format = fmt.Sprintf("%0#4x", uint16(n.Info.ObjectFormat))
+		}
+		size, captured := "-", "-"
+		if !n.IsDir() {
+			size = strconv.FormatUint(uint64(n.Info.ObjectCompressedSize), 10)
+		}
+		if !n.Info.CaptureDate.IsZero() {
+			captured = n.Info.CaptureDate.Format("2006-01-02 15:04:05")
+		}
+		rows = append(rows, []string{handle, format, size, captured, name})
+	}
+	formatRows(w, rows)
+
+	return buf.String()
+}
+
+func formatVersion(v uint16) string {
+	return fmt.Sprintf("%d.%02d", v/100, v%100)
 }
 
 func fujiFormatDeviceProperty(dpd *ptp.DevicePropDesc, f []string) string {
@@ -183,3 +351,54 @@ func formatRows(w *tabwriter.Writer, rows [][]string) {
 	}
 	w.Flush()
 }
+
+// formatEvent formats an event received at the given time as a single line. For a device property change, value holds
+// the new value of the property as returned by ip.Client.GetDevicePropertyValue(), or nil when it is not known.
+func formatEvent(vendor ptp.VendorExtension, p ip.EventPacket, t time.Time, value interface{}) string {
+	code := p.GetEventCode()
+	name := ptpfmt.EventCodeAsString(vendor, code)
+	if name == "" {
+		name = "unknown event"
+	}
+	line := fmt.Sprintf("%s %#04x %s", t.Format("15:04:05.000"), uint16(code), name)
+
+	switch e := p.Typed().(type) {
+	case ptp.DevicePropChangedEvent:
+		line += fmt.Sprintf(": %#04x", uint16(e.Code))
+		if n := ptpfmt.DevicePropCodeAsString(e.Code); n != "" {
+			line += " " + n
+		}
+		if value != nil {
+			line += " = " + formatDevicePropValue(vendor, e.Code, value)
+		}
+	case ptp.UnknownEvent:
+		line += fmt.Sprintf(": %#x %#x %#x", e.Event.Parameter1, e.Event.Parameter2, e.Event.Parameter3)
+	default:
+		if params := strings.Trim(fmt.Sprintf("%+v", e), "{}"); params != "" {
+			line += ": " + params
+		}
+	}
+
+	return line
+}
+
+// formatEventJSON formats an event received at the given time as a single line of JSON, see formatEvent().
+func formatEventJSON(vendor ptp.VendorExtension, p ip.EventPacket, t time.Time, value interface{}) (string, error) {
+	ej := struct {
+		Event *ptpfmt.EventJSON  `json:"event"`
+		Value *ptpfmt.ValueLabel `json:"value,omitempty"`
+	}{
+		Event: &ptpfmt.EventJSON{EventPacket: p, Vendor: vendor, Time: t},
+	}
+	if e, ok := p.Typed().(ptp.DevicePropChangedEvent); ok && value != nil {
+		ej.Value = &ptpfmt.ValueLabel{Value: fmt.Sprintf("%v", value)}
+		if i, ok := devicePropValueToInt64(value); ok {
+			ej.Value.Value = ptpfmt.ConvertToHexString(i)
+			ej.Value.Label = ptpfmt.DevicePropValAsString(vendor, e.Code, i)
+		}
+	}
+
+	b, err := json.Marshal(ej)
+
+	return string(b), err
+}
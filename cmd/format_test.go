@@ -3,11 +3,13 @@ package main
 import (
 	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFormatDeviceProperty(t *testing.T) {
-	c, err := ip.NewClient(ip.DefaultVendor, ip.DefaultIpAddress, ip.DefaultPort, "", "", ip.LevelSilent)
+	c, err := ip.NewClient(ip.DefaultIpAddress)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -40,7 +42,7 @@ func TestFormatDeviceProperty(t *testing.T) {
 		t.Errorf("formatDeviceProperty() got %#x; want %#x", got, want)
 	}
 
-	c, err = ip.NewClient("fuji", ip.DefaultIpAddress, ip.DefaultPort, "", "", ip.LevelSilent)
+	c, err = ip.NewClient(ip.DefaultIpAddress, ip.WithVendor("fuji"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -54,3 +56,157 @@ func TestFormatDeviceProperty(t *testing.T) {
 		t.Errorf("formatDeviceProperty() got %#x; want %#x", got, want)
 	}
 }
+
+func TestFormatDevicePropValue(t *testing.T) {
+	check := []struct {
+		code ptp.DevicePropCode
+		v    interface{}
+		want string
+	}{
+		{ptp.DPC_WhiteBalance, uint16(0x0002), "automatic (0x2)"},
+		{ptp.DPC_ExposureBiasCompensation, int16(-1000), "-1 (-0x3e8)"},
+		{ptp.DPC_Artist, "Ansel Adams", "Ansel Adams"},
+		{ptp.DPC_RGBGain, []uint16{1, 2}, "[1 2]"},
+	}
+
+	for _, c := range check {
+		got := formatDevicePropValue(ptp.VE_EastmanKodakCompany, c.code, c.v)
+		if got != c.want {
+			t.Errorf("formatDevicePropValue() got %s; want %s", got, c.want)
+		}
+	}
+}
+
+func TestFormatDeviceInfo(t *testing.T) {
+	di := &ptp.DeviceInfo{
+		StandardVersion:     132,
+		OperationsSupported: []ptp.OperationCode{ptp.OC_GetDeviceInfo, ptp.OC_OpenSession},
+		Model:               "X-T1",
+	}
+
+	got := formatDeviceInfo(di, nil)
+	for _, want := range []string{"1.32", "[0x1001 0x1002]", "X-T1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatDeviceInfo() return = %s; want it to contain %s", got, want)
+		}
+	}
+
+	got = formatDeviceInfo(di, []string{"json"})
+	if !strings.Contains(got, `"Model":"X-T1"`) {
+		t.Errorf("formatDeviceInfo() return = %s; want json", got)
+	}
+
+	want := "command not supported"
+	got = formatDeviceInfo(want, nil)
+	if got != want {
+		t.Errorf("formatDeviceInfo() return = %s; want %s", got, want)
+	}
+}
+
+func TestFormatLiveViewStats(t *testing.T) {
+	s := ip.LiveViewStats{Received: 10, Delivered: 8, Dropped: 2, FPS: 9.5, Latency: 12 * time.Millisecond}
+
+	got := formatLiveViewStats(s, nil)
+	for _, want := range []string{"Frames dropped:", "9.5 fps", "12ms"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatLiveViewStats() return = %s; want it to contain %s", got, want)
+		}
+	}
+
+	got = formatLiveViewStats(s, []string{"json"})
+	if !strings.Contains(got, `"dropped":2`) {
+		t.Errorf("formatLiveViewStats() return = %s; want json", got)
+	}
+}
+
+func TestFormatObjectNodes(t *testing.T) {
+	store := &ip.ObjectNode{Handle: 0xFFFFFFFF, StorageID: 0x10000001}
+	dir := &ip.ObjectNode{
+		Handle:    1,
+		StorageID: 0x10000001,
+		Info:      &ptp.ObjectInfo{ObjectFormat: ptp.OFC_Association, Filename: "DCIM"},
+		Parent:    store,
+	}
+	img := &ip.ObjectNode{
+		Handle:    2,
+		StorageID: 0x10000001,
+		Info: &ptp.ObjectInfo{
+			ObjectFormat:         ptp.OFC_EXIF_JPEG,
+			ObjectCompressedSize: 4096,
+			Filename:             "DSCF0001.JPG",
+			CaptureDate:          time.Date(2021, 6, 1, 12, 30, 0, 0, time.Local),
+		},
+		Parent: dir,
+	}
+
+	got := formatObjectNodes([]*ip.ObjectNode{store, dir, img}, false, false)
+	want := "-           store_10000001/\n0x00000001  DCIM/\n0x00000002  DSCF0001.JPG\n"
+	if got != want {
+		t.Errorf("formatObjectNodes() return = %q; want %q", got, want)
+	}
+
+	got = formatObjectNodes([]*ip.ObjectNode{img}, true, true)
+	for _, want := range []string{"Captured", "jpeg", "4096", "2021-06-01 12:30:00", "/store_10000001/DCIM/DSCF0001.JPG"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatObjectNodes() return = %s; want it to contain %s", got, want)
+		}
+	}
+
+	if got := formatObjectNodes(nil, true, false); got != "no objects found\n" {
+		t.Errorf("formatObjectNodes() return = %q; want no objects found", got)
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 30, 5, 250e6, time.UTC)
+	check := []struct {
+		event ptp.Event
+		value interface{}
+		want  string
+		json  string
+	}{
+		{
+			ptp.Event{EventCode: ptp.EC_DevicePropChanged, Parameter1: uint32(ptp.DPC_WhiteBalance)},
+			uint16(0x0002),
+			"12:30:05.250 0x4006 device property changed: 0x5005 white balance = automatic (0x2)",
+			`{"event":{"code":{"code":"0x4006","label":"device property changed"},"parameters":{"Code":20485},` +
+				`"timestamp":"2021-06-01T12:30:05.25Z","camera":""},"value":{"value":"0x2","label":"automatic"}}`,
+		},
+		{
+			ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 5},
+			nil,
+			"12:30:05.250 0x4002 object added: Handle:5",
+			`{"event":{"code":{"code":"0x4002","label":"object added"},"parameters":{"Handle":5},` +
+				`"timestamp":"2021-06-01T12:30:05.25Z","camera":""}}`,
+		},
+		{
+			ptp.Event{EventCode: ptp.EC_DeviceInfoChanged},
+			nil,
+			"12:30:05.250 0x4008 device info changed",
+			`{"event":{"code":{"code":"0x4008","label":"device info changed"},"parameters":{},` +
+				`"timestamp":"2021-06-01T12:30:05.25Z","camera":""}}`,
+		},
+		{
+			ptp.Event{EventCode: 0xc0ff, Parameter1: 1, Parameter2: 2},
+			nil,
+			"12:30:05.250 0xc0ff unknown event: 0x1 0x2 0x0",
+			`{"event":{"code":{"code":"0xc0ff","label":""},"parameters":{"EventCode":49407,"SessionID":0,` +
+				`"TransactionID":0,"Parameter1":1,"Parameter2":2,"Parameter3":0},"timestamp":"2021-06-01T12:30:05.25Z",` +
+				`"camera":""}}`,
+		},
+	}
+
+	for _, c := range check {
+		p := &ip.GenericEventPacket{Event: c.event}
+		if got := formatEvent(ptp.VE_EastmanKodakCompany, p, at, c.value); got != c.want {
+			t.Errorf("formatEvent() got = %s; want %s", got, c.want)
+		}
+		got, err := formatEventJSON(ptp.VE_EastmanKodakCompany, p, at, c.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.json {
+			t.Errorf("formatEventJSON() got = %s; want %s", got, c.json)
+		}
+	}
+}
@@ -4,20 +4,48 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/malc0mn/ptp-ip/ip"
+	"io"
+	"log"
 	"os"
-	"time"
+	"strings"
 )
 
+// iShell runs the interactive shell, executing the commands entered by the user until the user enters "exit" or
+// "quit" or presses CTRL+D. The lines entered are kept in the history file, see defaultHistoryFile().
 func iShell(c *ip.Client) {
-	rw := bufio.NewReadWriter(bufio.NewReader(os.Stdin), bufio.NewWriter(os.Stdout))
-	fmt.Print("Interactive shell ready to receive commands.\n")
+	lmp := "[iShell]"
+	e := newLineEditor("> ")
+	e.complete = completeCommand
+	e.historyFile = defaultHistoryFile()
+	confirm = e.confirm
+	if err := e.loadHistory(); err != nil {
+		log.Printf("%s error loading history '%s'", lmp, err)
+	}
+
+	fmt.Print("Interactive shell ready to receive commands, type help to list them or exit to quit.\n")
 	for {
-		// TODO: find a good way (not sleep) to "separate" the outputs so that the '> ' below does not get 'mixed' with
-		//  the Dial() debug output from the client...
-		time.Sleep(1 * time.Second)
+		l, err := e.readLine()
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("%s error reading command '%s'", lmp, err)
+			}
+			break
+		}
 
-		fmt.Print("> ")
-		readAndExecuteCommand(rw, c, "[iShell]")
+		msg := strings.TrimSpace(l)
+		if msg == "" {
+			continue
+		}
+		if err := e.saveHistory(msg); err != nil {
+			log.Printf("%s error saving history '%s'", lmp, err)
+		}
+		if msg == "exit" || msg == "quit" {
+			break
+		}
+
+		executeCommand(msg, bufio.NewWriter(os.Stdout), c, lmp)
 		fmt.Print("\n\n")
 	}
+
+	shutdown()
 }
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxHistory is the amount of lines kept in the history of the interactive shell.
+const maxHistory = 500
+
+var errNoTerminal = errors.New("not a terminal")
+
+// Control characters and keys handled by the lineEditor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// lineEditor reads lines from a terminal, allowing to edit the line using the arrow keys and the usual emacs style
+// control keys, to recall previously entered lines using the up and down keys and to complete words using tab.
+type lineEditor struct {
+	in     *bufio.Reader
+	out    io.Writer
+	prompt string
+	// fd is the file descriptor of the terminal put in raw mode while editing a line.
+	fd int
+	// complete returns the possible completions of the first word of the line when pressing tab.
+	complete func(prefix string) []string
+	history  []string
+	// historyFile is the file the history is kept in, the history is not saved when empty.
+	historyFile string
+
+	// The line being edited, the position of the cursor and the position in the history.
+	line []rune
+	pos  int
+	hpos int
+}
+
+// newLineEditor returns a line editor reading from stdin, falling back to reading plain lines when stdin is not a
+// terminal.
+func newLineEditor(prompt string) *lineEditor {
+	return &lineEditor{
+		in:     bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
+		prompt: prompt,
+		fd:     int(os.Stdin.Fd()),
+	}
+}
+
+// defaultHistoryFile returns the path of the history file in the ptp-ip directory of the user's configuration
+// directory, e.g. ~/.config/ptp-ip/history on Linux.
+func defaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ptp-ip", "history")
+}
+
+// loadHistory reads the history from the history file. A missing file is not an error.
+func (e *lineEditor) loadHistory() error {
+	if e.historyFile == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(e.historyFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, l := range strings.Split(string(b), "\n") {
+		e.addHistory(l)
+	}
+
+	return nil
+}
+
+// addHistory adds the line to the history unless it is empty or equal to the previous line.
+func (e *lineEditor) addHistory(l string) bool {
+	if strings.TrimSpace(l) == "" || len(e.history) > 0 && e.history[len(e.history)-1] == l {
+		return false
+	}
+
+	e.history = append(e.history, l)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	return true
+}
+
+// saveHistory adds the line to the history and appends it to the history file. The file is rewritten once it holds
+// twice the amount of lines kept.
+func (e *lineEditor) saveHistory(l string) error {
+	if !e.addHistory(l) || e.historyFile == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.historyFile), 0700); err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(e.historyFile)
+	if err == nil && strings.Count(string(b), "\n") >= 2*maxHistory {
+		return ioutil.WriteFile(e.historyFile, []byte(strings.Join(e.history, "\n")+"\n"), 0600)
+	}
+
+	f, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(l + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readLine prints the prompt and returns the line entered by the user, without the trailing newline. The terminal is
+// only in raw mode while editing the line, so the output of the commands is not affected. io.EOF is returned when
+// the user presses CTRL+D on an empty line or when the input is closed.
+func (e *lineEditor) readLine() (string, error) {
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		fmt.Fprint(e.out, e.prompt)
+		l, err := e.in.ReadString('\n')
+		if err != nil && (err != io.EOF || l == "") {
+			return "", err
+		}
+		return strings.TrimRight(l, "\r\n"), nil
+	}
+	defer restore()
+
+	return e.edit()
+}
+
+// edit reads keys from the input and edits the line until enter is pressed. The input is expected to come from a
+// terminal in raw mode.
+func (e *lineEditor) edit() (string, error) {
+	e.line, e.pos, e.hpos = e.line[:0], 0, len(e.history)
+	// saved holds the line being edited while browsing the history.
+	var saved []rune
+
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.line), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			e.line, e.pos, e.hpos = e.line[:0], 0, len(e.history)
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos)
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.line)
+		case keyCtrlB:
+			e.move(-1)
+		case keyCtrlF:
+			e.move(1)
+		case keyBackspace, keyDelete:
+			if e.pos > 0 {
+				e.pos--
+				e.delete(e.pos)
+			}
+		case keyCtrlK:
+			e.line = e.line[:e.pos]
+		case keyCtrlU:
+			e.line = append(e.line[:0], e.line[e.pos:]...)
+			e.pos = 0
+		case keyCtrlW:
+			e.deleteWord()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			saved = e.browse(-1, saved)
+		case keyCtrlN:
+			saved = e.browse(1, saved)
+		case keyTab:
+			e.completeWord()
+		case keyEscape:
+			saved = e.escape(saved)
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(r)
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape handles the escape sequences sent by the arrow, home, end and delete keys.
+func (e *lineEditor) escape(saved []rune) []rune {
+	b, err := e.in.ReadByte()
+	if err != nil || b != '[' && b != 'O' {
+		return saved
+	}
+	b, err = e.in.ReadByte()
+	if err != nil {
+		return saved
+	}
+
+	switch b {
+	case 'A':
+		return e.browse(-1, saved)
+	case 'B':
+		return e.browse(1, saved)
+	case 'C':
+		e.move(1)
+	case 'D':
+		e.move(-1)
+	case 'H':
+		e.pos = 0
+	case 'F':
+		e.pos = len(e.line)
+	case '1', '3', '4', '7', '8':
+		// Sequences such as ESC [ 3 ~ for the delete key.
+		if t, err := e.in.ReadByte(); err != nil || t != '~' {
+			return saved
+		}
+		switch b {
+		case '1', '7':
+			e.pos = 0
+		case '3':
+			e.delete(e.pos)
+		case '4', '8':
+			e.pos = len(e.line)
+		}
+	}
+
+	return saved
+}
+
+// browse replaces the line with the previous or next line of the history. The line being edited is saved when leaving
+// it and restored when browsing past the end of the history.
+func (e *lineEditor) browse(dir int, saved []rune) []rune {
+	n := e.hpos + dir
+	if n < 0 || n > len(e.history) {
+		return saved
+	}
+	if e.hpos == len(e.history) {
+		saved = append(saved[:0], e.line...)
+	}
+
+	e.hpos = n
+	if n == len(e.history) {
+		e.line = append(e.line[:0], saved...)
+	} else {
+		e.line = append(e.line[:0], []rune(e.history[n])...)
+	}
+	e.pos = len(e.line)
+
+	return saved
+}
+
+func (e *lineEditor) move(n int) {
+	e.pos += n
+	if e.pos < 0 {
+		e.pos = 0
+	}
+	if e.pos > len(e.line) {
+		e.pos = len(e.line)
+	}
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.line = append(e.line, 0)
+	copy(e.line[e.pos+1:], e.line[e.pos:])
+	e.line[e.pos] = r
+	e.pos++
+}
+
+func (e *lineEditor) delete(pos int) {
+	if pos < len(e.line) {
+		e.line = append(e.line[:pos], e.line[pos+1:]...)
+	}
+}
+
+// deleteWord deletes the word before the cursor, including the spaces following it.
+func (e *lineEditor) deleteWord() {
+	i := e.pos
+	for i > 0 && e.line[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && e.line[i-1] != ' ' {
+		i--
+	}
+	e.line = append(e.line[:i], e.line[e.pos:]...)
+	e.pos = i
+}
+
+// completeWord completes the first word of the line when the cursor is at its end. When there are multiple
+// possibilities, the longest common prefix is completed and all possibilities are listed.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil || strings.ContainsRune(string(e.line[:e.pos]), ' ') {
+		return
+	}
+
+	prefix := string(e.line[:e.pos])
+	matches := e.complete(prefix)
+	if len(matches) == 0 {
+		return
+	}
+
+	common := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(matches) == 1 {
+		common += " "
+	}
+	for _, r := range common[len(prefix):] {
+		e.insert(r)
+	}
+
+	if len(matches) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+	}
+}
+
+// confirm prints the question and returns true when the user answers with yes. All but the last line of the question
+// are printed as they are, the last line is used as the prompt.
+func (e *lineEditor) confirm(question string) bool {
+	i := strings.LastIndex(question, "\n")
+	fmt.Fprint(e.out, question[:i+1])
+
+	prompt := e.prompt
+	e.prompt = question[i+1:] + " [y/N] "
+	defer func() { e.prompt = prompt }()
+
+	l, err := e.readLine()
+	if err != nil {
+		return false
+	}
+	l = strings.ToLower(strings.TrimSpace(l))
+
+	return l == "y" || l == "yes"
+}
+
+// refresh redraws the line and puts the cursor in its place.
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.line))
+	if n := len(e.line) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+// completeCommand returns the names and aliases of the commands, including the user defined aliases, starting with the
+// given prefix, sorted alphabetically.
+func completeCommand(prefix string) []string {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
+	var matches []string
+	for n := range commands {
+		if strings.HasPrefix(n, prefix) {
+			matches = append(matches, n)
+		}
+	}
+	for a := range aliases {
+		if strings.HasPrefix(a, prefix) {
+			matches = append(matches, a)
+		}
+	}
+	for a := range userAliases {
+		if strings.HasPrefix(a, prefix) {
+			matches = append(matches, a)
+		}
+	}
+	sort.Strings(matches)
+
+	return matches
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestLineEditor(input string) *lineEditor {
+	return &lineEditor{
+		in:       bufio.NewReader(strings.NewReader(input)),
+		out:      ioutil.Discard,
+		complete: completeCommand,
+	}
+}
+
+func TestLineEditorEdit(t *testing.T) {
+	check := []struct {
+		input string
+		want  string
+	}{
+		{"info\r", "info"},
+		{"gtt\x1b[D\x7fe\x1b[F\r", "get"},
+		{"et\x01g\x05 x\x08\r", "get "},
+		{"get iso\x17state\r", "get state"},
+		{"abc\x03desc\r", "desc"},
+		{"get iso\x01\x0b\r", ""},
+		{"get iso\x01\x1b[3~\x1b[3~\x1b[3~\x1b[3~set \x05 200\r", "set iso 200"},
+		{"liv\t\r", "liveview "},
+		{"cap\tstop\r", "capture stop"},
+	}
+
+	for _, c := range check {
+		got, err := newTestLineEditor(c.input).edit()
+		if err != nil {
+			t.Errorf("edit(%q) error %s", c.input, err)
+		}
+		if got != c.want {
+			t.Errorf("edit(%q) = %q; want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestLineEditorEditEOF(t *testing.T) {
+	for _, input := range []string{"\x04", "info"} {
+		if _, err := newTestLineEditor(input).edit(); err != io.EOF {
+			t.Errorf("edit(%q) error = %v; want %s", input, err, io.EOF)
+		}
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	e := newTestLineEditor("\x1b[A\x1b[A\r" + "sta\x1b[A\x1b[B\x1b[Bte\r" + "\x10\x10\x10\x10\x0e\r")
+	for _, l := range []string{"info", "info", " ", "get iso"} {
+		e.addHistory(l)
+	}
+	if want := []string{"info", "get iso"}; !reflect.DeepEqual(e.history, want) {
+		t.Errorf("history = %v; want %v", e.history, want)
+	}
+
+	for _, want := range []string{"info", "state", "get iso"} {
+		got, err := e.edit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("edit() = %q; want %q", got, want)
+		}
+	}
+}
+
+func TestLineEditorSaveHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := newTestLineEditor("")
+	e.historyFile = filepath.Join(dir, "ptp-ip", "history")
+	for _, l := range []string{"info", "info", "get iso"} {
+		if err := e.saveHistory(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e = newTestLineEditor("")
+	e.historyFile = filepath.Join(dir, "ptp-ip", "history")
+	if err := e.loadHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"info", "get iso"}; !reflect.DeepEqual(e.history, want) {
+		t.Errorf("history = %v; want %v", e.history, want)
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	got := completeCommand("sh")
+	if want := []string{"shoot", "shutter"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeCommand() = %v; want %v", got, want)
+	}
+
+	defer delete(userAliases, "shot")
+	userAliases["shot"] = "capture"
+	got = completeCommand("sh")
+	if want := []string{"shoot", "shot", "shutter"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeCommand() = %v; want %v", got, want)
+	}
+}
+
+func TestLineEditorConfirm(t *testing.T) {
+	check := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{" YES \n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, c := range check {
+		e := newTestLineEditor(c.input)
+		e.fd, e.prompt = -1, "> "
+		if got := e.confirm("0x00000005 /store_10000001/DCIM/DSCF0001.JPG\nDelete?"); got != c.want {
+			t.Errorf("confirm(%q) = %t; want %t", c.input, got, c.want)
+		}
+		if e.prompt != "> " {
+			t.Errorf("confirm(%q) prompt = %q; want %q", c.input, e.prompt, "> ")
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ip/mjpeg"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultLiveViewAddr is the address the MJPEG stream is served on when the serve argument holds no address.
+const defaultLiveViewAddr = ":8080"
+
+// liveViewStreamArguments are the arguments of the liveview command streaming the frames as they are received from the
+// camera instead of displaying them in a window, which works without live view support compiled in.
+var liveViewStreamArguments = []string{"serve", "dir", "stdout", "stop"}
+
+var lvStream liveViewStream
+
+// liveViewOutput describes where the frames of a live view stream go.
+type liveViewOutput struct {
+	// desc tells the user where to find the frames, e.g. the URL of the MJPEG stream.
+	desc string
+	// wait is true when the command must wait for the stream to end before returning, because its output would
+	// otherwise get mixed with the frames.
+	wait bool
+	// run streams the frames until the frames channel is closed or the context is done.
+	run func(ctx context.Context, frames <-chan *ip.LiveViewFrame) error
+	// close releases the resources of an output that is never run, it can be nil.
+	close func() error
+}
+
+// liveViewStream holds the live view stream running in the background. Only one stream can run at a time.
+type liveViewStream struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// active returns true when a stream is running.
+func (s *liveViewStream) active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cancel != nil
+}
+
+// start enables live view and streams the frames to the output in the background. The returned channel is closed once
+// the stream ended, which happens when it is stopped, when the camera stops sending frames or on shutdown.
+func (s *liveViewStream) start(c *ip.Client, out *liveViewOutput) (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return nil, errors.New("already streaming, use 'liveview stop' first")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames, err := c.LiveView(ctx)
+	if err != nil {
+		cancel()
+		if out.close != nil {
+			out.close()
+		}
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-done:
+		}
+	}()
+	go func() {
+		if err := out.run(ctx, frames); err != nil {
+			log.Printf("[liveview] stream stopped: %s", err)
+		}
+		cancel()
+		// Drain the frames so live view is disabled properly.
+		for range frames {
+		}
+
+		s.mu.Lock()
+		if s.done == done {
+			s.cancel, s.done = nil, nil
+		}
+		s.mu.Unlock()
+		close(done)
+	}()
+
+	return done, nil
+}
+
+// stop stops the stream and waits for it to end. It returns false when no stream was running.
+func (s *liveViewStream) stop() bool {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+
+	return true
+}
+
+// isLiveViewStreamArg returns true when the argument is one of the liveViewStreamArguments, with or without a value.
+func isLiveViewStreamArg(arg string) bool {
+	name := strings.SplitN(arg, "=", 2)[0]
+	for _, a := range liveViewStreamArguments {
+		if name == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newLiveViewOutput returns the output for a stream argument in the form of 'serve=:8080', 'dir=/path' or 'stdout'.
+func newLiveViewOutput(arg string) (*liveViewOutput, error) {
+	kv := strings.SplitN(arg, "=", 2)
+	val := ""
+	if len(kv) == 2 {
+		val = kv[1]
+	}
+
+	switch kv[0] {
+	case "serve":
+		addr := val
+		if addr == "" {
+			addr = defaultLiveViewAddr
+		}
+		if !strings.Contains(addr, ":") {
+			addr = ":" + addr
+		}
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return &liveViewOutput{
+			desc: "serving MJPEG on http://" + l.Addr().String() + "/",
+			run: func(ctx context.Context, frames <-chan *ip.LiveViewFrame) error {
+				srv := &http.Server{Handler: mjpeg.NewHandler(frames)}
+				go func() {
+					<-ctx.Done()
+					srv.Close()
+				}()
+				if err := srv.Serve(l); err != http.ErrServerClosed {
+					return err
+				}
+				return nil
+			},
+			close: l.Close,
+		}, nil
+	case "dir":
+		if val == "" {
+			return nil, errors.New("the directory to write the frames to is missing, e.g. 'dir=/tmp/frames'")
+		}
+		dw, err := mjpeg.NewDirWriter(val)
+		if err != nil {
+			return nil, err
+		}
+		return &liveViewOutput{
+			desc: "writing frames to " + val,
+			run: func(_ context.Context, frames <-chan *ip.LiveViewFrame) error {
+				return mjpeg.Record(dw, frames)
+			},
+		}, nil
+	case "stdout":
+		return &liveViewOutput{
+			wait: true,
+			run: func(_ context.Context, frames <-chan *ip.LiveViewFrame) error {
+				return mjpeg.Record(mjpeg.NewJPEGWriter(os.Stdout), frames)
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output '%s'", kv[0])
+}
+
+// streamLiveView handles the liveViewStreamArguments of the liveview command. When executing a single command, the
+// command waits for the stream to end as the program would exit otherwise.
+func streamLiveView(c *ip.Client, f []string, asyncOut chan<- string) string {
+	errorFmt := "liveview error: %s\n"
+
+	var arg string
+	for _, a := range f {
+		if a == "stop" {
+			if !lvStream.stop() {
+				return "not streaming\n"
+			}
+			return "stopped\n"
+		}
+		if !isLiveViewStreamArg(a) {
+			continue
+		}
+		if arg != "" {
+			return fmt.Sprintf(errorFmt, "only one of serve, dir or stdout can be used at a time")
+		}
+		arg = a
+	}
+
+	out, err := newLiveViewOutput(arg)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+	done, err := lvStream.start(c, out)
+	if err != nil {
+		return fmt.Sprintf(errorFmt, err)
+	}
+
+	switch {
+	case out.wait:
+		<-done
+		return ""
+	case cmd != "":
+		asyncOut <- out.desc
+		<-done
+		return "stopped\n"
+	}
+
+	return out.desc + "\n"
+}
+
+// liveViewStreamHelp returns the help for one of the liveViewStreamArguments.
+func liveViewStreamHelp(arg string) string {
+	switch arg {
+	case "serve":
+		return "\t- " + `"` + arg + `=:8080"` + " serves the frames as an MJPEG stream over HTTP on the given address, which defaults to '" + defaultLiveViewAddr + "'\n"
+	case "dir":
+		return "\t- " + `"` + arg + `=/path"` + " writes each frame to a separate JPEG file in the given directory\n"
+	case "stdout":
+		return "\t- " + `"` + arg + `"` + " writes the JPEG data of the frames back to back to the standard output for use by ffmpeg, e.g. ptpip -c \"liveview stdout\" | ffmpeg -f mjpeg -i - liveview.mp4\n"
+	case "stop":
+		return "\t- " + `"` + arg + `"` + " stops streaming the frames\n"
+	}
+
+	return ""
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"github.com/malc0mn/ptp-ip/ip"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsLiveViewStreamArg(t *testing.T) {
+	check := map[string]bool{
+		"serve":          true,
+		"serve=:8080":    true,
+		"dir=/tmp/x":     true,
+		"stdout":         true,
+		"stop":           true,
+		"novf":           false,
+		"peaking=00ff00": false,
+		"served":         false,
+	}
+	for arg, want := range check {
+		if got := isLiveViewStreamArg(arg); got != want {
+			t.Errorf("isLiveViewStreamArg(%s) = %t; want %t", arg, got, want)
+		}
+	}
+}
+
+func TestStreamLiveView(t *testing.T) {
+	check := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"stop"}, "not streaming\n"},
+		{[]string{"stdout", "serve"}, "liveview error: only one of serve, dir or stdout can be used at a time\n"},
+		{[]string{"dir"}, "liveview error: the directory to write the frames to is missing, e.g. 'dir=/tmp/frames'\n"},
+	}
+	for _, c := range check {
+		got := streamLiveView(&ip.Client{}, c.args, make(chan string))
+		if got != c.want {
+			t.Errorf("streamLiveView(%v) got = '%s'; want '%s'", c.args, got, c.want)
+		}
+	}
+}
+
+func TestNewLiveViewOutputServe(t *testing.T) {
+	out, err := newLiveViewOutput("serve=127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.desc, "serving MJPEG on http://127.0.0.1:") {
+		t.Errorf("desc = %s; want serving MJPEG on http://127.0.0.1:<port>/", out.desc)
+	}
+	if out.wait {
+		t.Error("wait = true; want false")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(chan *ip.LiveViewFrame)
+	res := make(chan error)
+	go func() {
+		res <- out.run(ctx, frames)
+	}()
+	// The headers are only sent along with the first frame.
+	frames <- &ip.LiveViewFrame{Data: []byte{0xff, 0xd8, 0xff, 0xd9}}
+
+	resp, err := http.Get(strings.TrimPrefix(out.desc, "serving MJPEG on "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/x-mixed-replace") {
+		t.Errorf("Content-Type = %s; want multipart/x-mixed-replace", ct)
+	}
+
+	cancel()
+	close(frames)
+	if err := <-res; err != nil {
+		t.Errorf("run() err = %s; want <nil>", err)
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/malc0mn/ptp-ip/ip"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync"
 	"syscall"
 )
 
@@ -17,6 +20,7 @@ const (
 	errOpenConfig       = 102
 	errCreateClient     = 104
 	errResponderConnect = 105
+	errScript           = 106
 )
 
 var (
@@ -24,6 +28,10 @@ var (
 	buildTime = "unknown"
 	exe       string
 	quit      = make(chan struct{}) // Should this be global or do we need to pass it along to all who need it?
+	quitOnce  sync.Once
+
+	interruptMu sync.Mutex
+	interrupt   func()
 )
 
 func main() {
@@ -49,23 +57,57 @@ func main() {
 		loadConfig()
 	}
 
+	// The completion script is generated after loading the config file so it includes the user defined aliases.
+	if completion != "" {
+		s, err := completionScript(completion, flag.CommandLine)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(errInvalidArgs)
+		}
+		fmt.Print(s)
+		os.Exit(ok)
+	}
+
 	checkPorts()
 
-	if cmd != "" && (interactive || server) || (interactive && server) {
-		fmt.Fprintln(os.Stderr, "Too many arguments: either run in server mode OR interactive mode OR execute a single command; not all at once!")
+	modes := 0
+	for _, m := range []bool{cmd != "", script != "", interactive, server} {
+		if m {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintln(os.Stderr, "Too many arguments: either run in server mode OR interactive mode OR execute a single command OR a script; not all at once!")
 		os.Exit(errInvalidArgs)
 	}
 
-	// TODO: finish this implementation so CTRL+C will also abort client.Dial() etc. properly.
+	// The context is cancelled on CTRL+C so we can abort client.Dial() properly.
+	ctx, cancel := context.WithCancel(context.Background())
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		sig := <-sigs
-		fmt.Printf("Received signal %s, shutting down...\n", sig)
-		close(quit)
+		for sig := range sigs {
+			if !server && interruptCommand() {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Received signal %s, shutting down...\n", sig)
+			cancel()
+			shutdown()
+			return
+		}
 	}()
 
-	client, err := ip.NewClient(conf.vendor, conf.host, uint16(conf.port), conf.fname, conf.guid, verbosity)
+	opts := []ip.Option{ip.WithVendor(conf.vendor), ip.WithPort(uint16(conf.port)), ip.WithFriendlyName(conf.fname), ip.WithGUID(conf.guid), ip.WithLogLevel(verbosity)}
+	// Reuse the identity of the previous run so the user is not prompted on the camera again, unless a GUID is given.
+	if conf.guid == "" {
+		if s, err := ip.NewDefaultIdentityStore(); err == nil {
+			opts = append(opts, ip.WithIdentityStore(s))
+		} else {
+			fmt.Fprintf(os.Stderr, "Unable to remember the client identity - %s\n", err)
+		}
+	}
+
+	client, err := ip.NewClient(conf.host, opts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating PTP/IP client - %s\n", err)
 		os.Exit(errCreateClient)
@@ -81,10 +123,21 @@ func main() {
 	if conf.sport != 0 {
 		client.SetStreamerPort(uint16(conf.sport))
 	}
+	if conf.proxy != "" {
+		if err := client.SetProxy(conf.proxy); err != nil {
+			fmt.Fprintf(os.Stderr, "Error configuring proxy - %s\n", err)
+			os.Exit(errCreateClient)
+		}
+	}
 
-	fmt.Printf("Created new client with name '%s' and GUID '%s'.\n", client.InitiatorFriendlyName(), client.InitiatorGUIDAsString())
-	fmt.Printf("Attempting to connect to %s\n", client.CommandDataAddress())
-	err = client.Dial()
+	// The output of a single command or a script can be piped to another program, so keep it free of status messages.
+	status := os.Stdout
+	if cmd != "" || script != "" {
+		status = os.Stderr
+	}
+	fmt.Fprintf(status, "Created new client with name '%s' and GUID '%s'.\n", client.InitiatorFriendlyName(), client.InitiatorGUIDAsString())
+	fmt.Fprintf(status, "Attempting to connect to %s\n", client.CommandDataAddress())
+	err = client.DialContext(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error connecting to responder - %s\n", err)
 		os.Exit(errResponderConnect)
@@ -94,6 +147,13 @@ func main() {
 		executeCommand(cmd, bufio.NewWriter(os.Stdout), client, "cli")
 	}
 
+	if script != "" {
+		if err := runScriptFile(client, script); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running script - %s\n", err)
+			os.Exit(errScript)
+		}
+	}
+
 	if server || interactive {
 		if interactive {
 			go iShell(client)
@@ -101,6 +161,9 @@ func main() {
 
 		if server {
 			go launchServer(client)
+			if conf.wsPort != 0 {
+				go launchWebSocketServer(client)
+			}
 		}
 
 		mainThread()
@@ -111,3 +174,42 @@ func main() {
 
 	os.Exit(ok)
 }
+
+// onInterrupt makes CTRL+C call f instead of shutting down while a command is running, e.g. to stop watching events.
+// Call the returned function once the command is done, which restores the function registered before, if any. This
+// allows a command run by a script to be interrupted without stopping the script. In server mode, CTRL+C always shuts
+// down.
+func onInterrupt(f func()) func() {
+	interruptMu.Lock()
+	prev := interrupt
+	interrupt = f
+	interruptMu.Unlock()
+
+	return func() {
+		interruptMu.Lock()
+		interrupt = prev
+		interruptMu.Unlock()
+	}
+}
+
+// interruptCommand calls the function registered using onInterrupt(), returning false when there is none.
+func interruptCommand() bool {
+	interruptMu.Lock()
+	f := interrupt
+	interrupt = nil
+	interruptMu.Unlock()
+
+	if f == nil {
+		return false
+	}
+	f()
+
+	return true
+}
+
+// shutdown closes the quit channel, making the main thread return. It is safe to call shutdown more than once.
+func shutdown() {
+	quitOnce.Do(func() {
+		close(quit)
+	})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxScriptDepth limits how deep scripts can source other scripts, which stops a script sourcing itself forever.
+const maxScriptDepth = 8
+
+var (
+	scriptVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	scriptVarRef  = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+	scriptInterrupted = errors.New("interrupted")
+)
+
+// scriptRunner executes the lines of a script one after the other. A line is either a comment starting with '#', a
+// variable assignment in the form of 'name=value', a 'sleep' statement, a 'source' statement running another script or
+// a command. Variables are referenced as '$name' or '${name}'.
+type scriptRunner struct {
+	c     *ip.Client
+	out   chan<- string
+	stop  <-chan struct{}
+	depth int
+}
+
+// runScriptFile runs the script passed using the -script flag, writing the output to stdout.
+func runScriptFile(c *ip.Client, path string) error {
+	out := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		for msg := range out {
+			fmt.Println(msg)
+		}
+		close(done)
+	}()
+
+	err := runScript(c, path, nil, out)
+	close(out)
+	<-done
+
+	return err
+}
+
+// runScript runs the script at the given path with the given variables, sending the output of each command to out.
+// Pressing CTRL+C stops the script.
+func runScript(c *ip.Client, path string, vars map[string]string, out chan<- string) error {
+	var (
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+	defer onInterrupt(func() { stopOnce.Do(func() { close(stop) }) })()
+
+	r := &scriptRunner{c: c, out: out, stop: stop}
+
+	return r.run(path, vars)
+}
+
+// run executes the script at the given path, stopping at the first line that fails.
+func (r *scriptRunner) run(path string, vars map[string]string) error {
+	if r.depth >= maxScriptDepth {
+		return fmt.Errorf("%s: scripts are nested too deep, at most %d levels are allowed", path, maxScriptDepth)
+	}
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	// Variables set by a script do not leak into the script sourcing it.
+	local := make(map[string]string, len(vars))
+	for k, v := range vars {
+		local[k] = v
+	}
+
+	s := bufio.NewScanner(fh)
+	for n := 1; s.Scan(); n++ {
+		if err := r.line(path, s.Text(), local); err != nil {
+			if err == scriptInterrupted {
+				return err
+			}
+			return fmt.Errorf("%s:%d: %s", path, n, err)
+		}
+	}
+
+	return s.Err()
+}
+
+// line executes a single line of the script at the given path.
+func (r *scriptRunner) line(path, line string, vars map[string]string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	if name, value, ok := scriptAssignment(line); ok {
+		value, err := expandScriptVars(value, vars)
+		if err != nil {
+			return err
+		}
+		vars[name] = value
+		return nil
+	}
+
+	line, err := expandScriptVars(line, vars)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-r.stop:
+		return scriptInterrupted
+	case <-quit:
+		return scriptInterrupted
+	default:
+	}
+
+	f := expandAlias(strings.Fields(line))
+	switch f[0] {
+	case "sleep":
+		if len(f) != 2 {
+			return errors.New("sleep needs a single duration, e.g. 'sleep 2s'")
+		}
+		d, err := parseScriptSleep(f[1])
+		if err != nil {
+			return err
+		}
+		return r.sleep(d)
+	case (source{}).name():
+		if len(f) < 2 {
+			return errors.New("the script to source is missing")
+		}
+		args, err := scriptArguments(f[2:])
+		if err != nil {
+			return err
+		}
+		for k, v := range vars {
+			if _, set := args[k]; !set {
+				args[k] = v
+			}
+		}
+		p := f[1]
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(path), p)
+		}
+		nested := *r
+		nested.depth++
+		return nested.run(p, args)
+	}
+
+	cmd := commandByName(f[0])
+	if _, ok := cmd.(*unknown); ok {
+		return fmt.Errorf("unknown command '%s'", f[0])
+	}
+
+	r.out <- "> " + line
+	if res := strings.TrimRight(cmd.execute(r.c, f[1:], r.out), "\n"); res != "" {
+		r.out <- res
+	}
+
+	return nil
+}
+
+// sleep waits for the given duration unless the script is stopped.
+func (r *scriptRunner) sleep(d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-r.stop:
+	case <-quit:
+	}
+
+	return scriptInterrupted
+}
+
+// scriptAssignment returns the name and the unexpanded value of a line in the form of 'name=value'.
+func scriptAssignment(line string) (string, string, bool) {
+	kv := strings.SplitN(line, "=", 2)
+	if len(kv) != 2 || !scriptVarName.MatchString(kv[0]) {
+		return "", "", false
+	}
+
+	return kv[0], strings.TrimSpace(kv[1]), true
+}
+
+// scriptArguments returns the variables passed to a script in the form of 'name=value'.
+func scriptArguments(f []string) (map[string]string, error) {
+	vars := make(map[string]string, len(f))
+	for _, arg := range f {
+		name, value, ok := scriptAssignment(arg)
+		if !ok {
+			return nil, fmt.Errorf("invalid variable '%s', use 'name=value'", arg)
+		}
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// expandScriptVars replaces the '$name' and '${name}' references in s with the value of the variable.
+func expandScriptVars(s string, vars map[string]string) (string, error) {
+	var err error
+	res := scriptVarRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := scriptVarRef.FindStringSubmatch(ref)
+		name := m[1] + m[2]
+		v, ok := vars[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable '%s'", name)
+		}
+		return v
+	})
+
+	return res, err
+}
+
+// parseScriptSleep parses the duration of a sleep statement, which is either a Go duration such as '1m30s' or a number
+// of seconds.
+func parseScriptSleep(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		sec, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		d = time.Duration(sec * float64(time.Second))
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+
+	return d, nil
+}
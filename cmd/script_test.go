@@ -0,0 +1,168 @@
+package main
+
+import (
+	"github.com/malc0mn/ptp-ip/ip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collectScript runs the script at the given path and returns its output.
+func collectScript(path string, vars map[string]string) ([]string, error) {
+	out := make(chan string)
+	done := make(chan []string)
+	go func() {
+		var lines []string
+		for l := range out {
+			lines = append(lines, l)
+		}
+		done <- lines
+	}()
+
+	err := runScript(&ip.Client{}, path, vars, out)
+	close(out)
+
+	return <-done, err
+}
+
+func TestRunScript(t *testing.T) {
+	defer atomic.StoreInt32(&liveViewGrid, 0)
+
+	got, err := collectScript("testdata/scripts/grid.txt", nil)
+	if err != nil {
+		t.Fatalf("runScript() err = %s; want <nil>", err)
+	}
+	want := []string{
+		"> grid thirds", "grid set to thirds",
+		"> grid none", "grid set to none",
+		"> grid", "grid: none",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("runScript() got = %q; want %q", got, want)
+	}
+}
+
+func TestRunScriptErrors(t *testing.T) {
+	defer atomic.StoreInt32(&liveViewGrid, 0)
+
+	check := map[string]string{
+		"testdata/scripts/undefined.txt": "testdata/scripts/undefined.txt:2: undefined variable 'unset'",
+		"testdata/scripts/unknown.txt":   "testdata/scripts/unknown.txt:2: unknown command 'gird'",
+		"testdata/scripts/missing.txt":   "open testdata/scripts/missing.txt: no such file or directory",
+	}
+	for path, want := range check {
+		_, err := collectScript(path, nil)
+		if err == nil || err.Error() != want {
+			t.Errorf("runScript(%s) err = %v; want %s", path, err, want)
+		}
+	}
+
+	_, err := collectScript("testdata/scripts/self.txt", nil)
+	if err == nil || !strings.HasSuffix(err.Error(), "scripts are nested too deep, at most 8 levels are allowed") {
+		t.Errorf("runScript(self.txt) err = %v; want scripts are nested too deep", err)
+	}
+}
+
+func TestRunScriptInterrupt(t *testing.T) {
+	res := make(chan error)
+	go func() {
+		_, err := collectScript("testdata/scripts/sleep.txt", nil)
+		res <- err
+	}()
+
+	// Wait for the script to register its interrupt function.
+	for i := 0; i < 100 && !interruptCommand(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-res:
+		if err != scriptInterrupted {
+			t.Errorf("runScript() err = %v; want %s", err, scriptInterrupted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runScript() was not interrupted")
+	}
+}
+
+func TestExpandScriptVars(t *testing.T) {
+	vars := map[string]string{"iso": "800", "dir": "/tmp"}
+	check := map[string]string{
+		"set iso $iso":         "set iso 800",
+		"download dir=${dir}x": "download dir=/tmpx",
+		"no variables":         "no variables",
+		"$iso$iso":             "800800",
+	}
+	for in, want := range check {
+		got, err := expandScriptVars(in, vars)
+		if err != nil || got != want {
+			t.Errorf("expandScriptVars(%s) got = %s, %v; want %s, <nil>", in, got, err, want)
+		}
+	}
+
+	if _, err := expandScriptVars("set iso $isoo", vars); err == nil {
+		t.Error("expandScriptVars() err = <nil>; want undefined variable 'isoo'")
+	}
+}
+
+func TestScriptAssignment(t *testing.T) {
+	check := []struct {
+		line, name, value string
+		ok                bool
+	}{
+		{"iso=800", "iso", "800", true},
+		{"out = ignored", "", "", false},
+		{"dir=/my photos", "dir", "/my photos", true},
+		{"set iso=800", "", "", false},
+		{"1st=x", "", "", false},
+	}
+	for _, c := range check {
+		name, value, ok := scriptAssignment(c.line)
+		if name != c.name || value != c.value || ok != c.ok {
+			t.Errorf("scriptAssignment(%s) got = %s, %s, %t; want %s, %s, %t", c.line, name, value, ok, c.name, c.value, c.ok)
+		}
+	}
+}
+
+func TestParseScriptSleep(t *testing.T) {
+	check := map[string]time.Duration{
+		"2s":   2 * time.Second,
+		"1m5s": 65 * time.Second,
+		"3":    3 * time.Second,
+		"0.5":  500 * time.Millisecond,
+	}
+	for in, want := range check {
+		got, err := parseScriptSleep(in)
+		if err != nil || got != want {
+			t.Errorf("parseScriptSleep(%s) got = %s, %v; want %s, <nil>", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"soon", "-1s"} {
+		if _, err := parseScriptSleep(in); err == nil {
+			t.Errorf("parseScriptSleep(%s) err = <nil>; want invalid duration", in)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	check := map[string]string{
+		"":                                "source error: the script to run is missing\n",
+		"testdata/scripts/off.txt =x":     "source error: invalid variable '=x', use 'name=value'\n",
+		"testdata/scripts/off.txt g=none": "script done\n",
+		"testdata/scripts/off.txt":        "source error: testdata/scripts/off.txt:1: undefined variable 'g'\n",
+	}
+	for args, want := range check {
+		out := make(chan string)
+		go func() {
+			for range out {
+			}
+		}()
+		got := source{}.execute(&ip.Client{}, strings.Fields(args), out)
+		close(out)
+		if got != want {
+			t.Errorf("execute(%s) got = '%s'; want '%s'", args, got, want)
+		}
+	}
+}
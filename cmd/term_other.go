@@ -0,0 +1,8 @@
+// +build !linux,!darwin
+
+package main
+
+// makeRaw is not supported on this platform, the interactive shell reads plain lines instead.
+func makeRaw(_ int) (func() error, error) {
+	return nil, errNoTerminal
+}
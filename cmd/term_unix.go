@@ -0,0 +1,38 @@
+// +build linux darwin
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw puts the terminal in raw mode, so the keys are read as they are pressed without being echoed, and returns
+// a function restoring the previous mode. CTRL+C no longer sends SIGINT while in raw mode.
+func makeRaw(fd int) (func() error, error) {
+	var old syscall.Termios
+	if err := ioctlTermios(fd, ioctlReadTermios, &old); err != nil {
+		return nil, errNoTerminal
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.ICRNL | syscall.INLCR | syscall.IXON | syscall.ISTRIP
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctlTermios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctlTermios(fd, ioctlWriteTermios, &old)
+	}, nil
+}
+
+func ioctlTermios(fd int, req uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	wsUpgradeRequired  = errors.New("websocket upgrade required")
+	wsOriginNotAllowed = errors.New("websocket origin not allowed")
+	wsMaskRequired     = errors.New("websocket frames sent by the client must be masked")
+	wsMessageTooLarge  = errors.New("websocket message too large")
+	wsConnectionClosed = errors.New("websocket connection closed")
+)
+
+const (
+	// wsGUID is used to compute the accept key of the handshake, see RFC 6455.
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// wsMaxMessageSize limits the size of the messages sent by the browser, which are commands.
+	wsMaxMessageSize = 1 << 16
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+// wsConn is a minimal server side WebSocket connection.
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex
+}
+
+// upgradeWebSocket performs the WebSocket handshake and takes over the connection of the request. Upgrades from an
+// origin other than the server itself or one of the given origins are refused.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, origins []string) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, wsUpgradeRequired.Error(), http.StatusBadRequest)
+		return nil, wsUpgradeRequired
+	}
+	if !wsOriginAllowed(r, origins) {
+		http.Error(w, wsOriginNotAllowed.Error(), http.StatusForbidden)
+		return nil, wsOriginNotAllowed
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be upgraded", http.StatusInternalServerError)
+		return nil, wsUpgradeRequired
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// wsOriginAllowed prevents cross-site WebSocket hijacking: any web page can open a WebSocket to the server, which
+// executes every command it receives. Browsers always send the Origin header which must match the host the request is
+// sent to or one of the given origins. Clients other than browsers typically do not send an Origin header and are
+// allowed.
+func wsOriginAllowed(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, o := range origins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// writeMessage sends the data as a single unfragmented frame.
+func (ws *wsConn) writeMessage(op byte, data []byte) error {
+	hdr := []byte{0x80 | op, 0}
+	switch l := len(data); {
+	case l < 126:
+		hdr[1] = byte(l)
+	case l <= 0xffff:
+		hdr[1] = 126
+		hdr = append(hdr, byte(l>>8), byte(l))
+	default:
+		hdr[1] = 127
+		hdr = append(hdr, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(hdr[2:], uint64(l))
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.rw.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(data); err != nil {
+		return err
+	}
+
+	return ws.rw.Flush()
+}
+
+// readMessage returns the next text or binary message. Control frames are handled along the way: pings are answered
+// and a close frame is echoed after which wsConnectionClosed is returned.
+func (ws *wsConn) readMessage() (byte, []byte, error) {
+	var (
+		op  byte
+		msg []byte
+	)
+	for {
+		hdr := make([]byte, 2)
+		if _, err := io.ReadFull(ws.rw, hdr); err != nil {
+			return 0, nil, err
+		}
+		fin, opcode := hdr[0]&0x80 != 0, hdr[0]&0x0f
+		if hdr[1]&0x80 == 0 {
+			return 0, nil, wsMaskRequired
+		}
+
+		l := uint64(hdr[1] & 0x7f)
+		switch l {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(ws.rw, ext); err != nil {
+				return 0, nil, err
+			}
+			l = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(ws.rw, ext); err != nil {
+				return 0, nil, err
+			}
+			l = binary.BigEndian.Uint64(ext)
+		}
+		// The length of a frame can be close to the maximum uint64 value so the addition could overflow.
+		if l > wsMaxMessageSize-uint64(len(msg)) {
+			return 0, nil, wsMessageTooLarge
+		}
+
+		payload := make([]byte, 4+l)
+		if _, err := io.ReadFull(ws.rw, payload); err != nil {
+			return 0, nil, err
+		}
+		mask, payload := payload[:4], payload[4:]
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := ws.writeMessage(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			ws.writeMessage(wsOpClose, payload)
+			return 0, nil, wsConnectionClosed
+		case wsOpText, wsOpBinary:
+			op = opcode
+		}
+
+		msg = append(msg, payload...)
+		if fin {
+			return op, msg, nil
+		}
+	}
+}
+
+func (ws *wsConn) close() error {
+	return ws.conn.Close()
+}
+
+// liveViewHub shares a single live view among all WebSocket connections: live view is enabled when the first
+// connection subscribes and disabled again when the last one unsubscribes.
+type liveViewHub struct {
+	c      *ip.Client
+	mu     sync.Mutex
+	subs   map[chan *ip.LiveViewFrame]struct{}
+	cancel context.CancelFunc
+	// stopped is closed once the live view stopped and its subscribers were closed.
+	stopped chan struct{}
+}
+
+// subscribe returns a channel receiving the live view frames. Frames are dropped for subscribers that are not keeping
+// up.
+func (h *liveViewHub) subscribe() (chan *ip.LiveViewFrame, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The client allows one live view at a time, so wait for the previous one to stop, e.g. when reloading the page.
+	for len(h.subs) == 0 && h.stopped != nil {
+		stopped := h.stopped
+		h.mu.Unlock()
+		<-stopped
+		h.mu.Lock()
+		if h.stopped == stopped {
+			h.stopped = nil
+		}
+	}
+
+	if len(h.subs) == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		frames, err := h.c.LiveView(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		h.subs = make(map[chan *ip.LiveViewFrame]struct{})
+		h.cancel = cancel
+		h.stopped = make(chan struct{})
+		go h.distribute(frames, h.stopped)
+	}
+
+	ch := make(chan *ip.LiveViewFrame, 1)
+	h.subs[ch] = struct{}{}
+
+	return ch, nil
+}
+
+func (h *liveViewHub) unsubscribe(ch chan *ip.LiveViewFrame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[ch]; !ok {
+		return
+	}
+	delete(h.subs, ch)
+	close(ch)
+	if len(h.subs) == 0 {
+		h.cancel()
+	}
+}
+
+func (h *liveViewHub) distribute(frames <-chan *ip.LiveViewFrame, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	for f := range frames {
+		h.mu.Lock()
+		for ch := range h.subs {
+			select {
+			case ch <- f:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	// Live view stopped: the subscribers are closed so they can tell.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// wsResponse is sent to the browser in response to a command.
+type wsResponse struct {
+	Command  string `json:"command"`
+	Response string `json:"response"`
+}
+
+func launchWebSocketServer(c *ip.Client) {
+	validateAddress()
+
+	lmp := "[WebSocket server]"
+	hub := &liveViewHub{c: c}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgradeWebSocket(w, r, conf.wsOrigins)
+		if err != nil {
+			log.Printf("%s error %s...", lmp, err)
+			return
+		}
+		serveWebSocket(ws, c, hub, lmp)
+	})
+
+	addr := net.JoinHostPort(conf.srvAddr, conf.wsPort.String())
+	if conf.webUI {
+		mux.Handle("/", webUIHandler())
+		log.Printf("%s serving the web UI on http://%s/", lmp, addr)
+		if a := net.ParseIP(conf.srvAddr); a == nil || !a.IsLoopback() {
+			log.Printf("%s WARNING: anyone who can reach %s gets full control over the camera, including deleting images!", lmp, conf.srvAddr)
+		}
+	}
+	log.Printf("%s listening on %s...", lmp, addr)
+	log.Printf("%s error %s...", lmp, http.ListenAndServe(addr, mux))
+}
+
+// serveWebSocket sends the live view frames as binary messages and the events as JSON text messages. Text messages
+// received from the browser are executed as commands, the response of which is sent back as a JSON text message.
+func serveWebSocket(ws *wsConn, c *ip.Client, hub *liveViewHub, lmp string) {
+	defer ws.close()
+
+	events := c.Subscribe()
+	defer c.Unsubscribe(events)
+
+	frames, err := hub.subscribe()
+	if err != nil {
+		log.Printf("%s live view not available: %s", lmp, err)
+	} else {
+		defer hub.unsubscribe(frames)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			op, msg, err := ws.readMessage()
+			if err != nil {
+				if err != wsConnectionClosed && err != io.EOF {
+					log.Printf("%s error reading message '%s'", lmp, err)
+				}
+				return
+			}
+			if op != wsOpText || strings.TrimSpace(string(msg)) == "" {
+				continue
+			}
+			log.Printf("%s message received: '%s'", lmp, msg)
+
+			var buf bytes.Buffer
+			executeCommand(string(msg), bufio.NewWriter(&buf), c, lmp)
+			res, _ := json.Marshal(&wsResponse{Command: string(msg), Response: buf.String()})
+			if err := ws.writeMessage(wsOpText, res); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			res, err := json.Marshal(&ptpfmt.EventJSON{EventPacket: e, Vendor: c.ResponderVendor(), Time: time.Now()})
+			if err != nil {
+				log.Printf("%s error marshalling event: %s", lmp, err)
+				continue
+			}
+			if err := ws.writeMessage(wsOpText, res); err != nil {
+				return
+			}
+		case f, ok := <-frames:
+			if !ok {
+				frames = nil
+				continue
+			}
+			if err := ws.writeMessage(wsOpBinary, f.Data); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wsClientFrame returns a masked frame as sent by a browser.
+func wsClientFrame(op byte, payload []byte) []byte {
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame := append([]byte{0x80 | op, 0x80 | byte(len(payload))}, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	return frame
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgradeWebSocket(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.close()
+		for {
+			op, msg, err := ws.readMessage()
+			if err != nil {
+				return
+			}
+			ws.writeMessage(op, append([]byte("echo "), msg...))
+		}
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgradeWebSocket() status = %d; want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	// The example from RFC 6455.
+	if got, want := res.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("upgradeWebSocket() accept = %s; want %s", got, want)
+	}
+
+	// A fragmented message with a ping in between.
+	first := wsClientFrame(wsOpText, []byte("st"))
+	first[0] &^= 0x80
+	conn.Write(first)
+	conn.Write(wsClientFrame(wsOpPing, []byte("hi")))
+	conn.Write(wsClientFrame(wsOpContinuation, []byte("ate")))
+
+	check := []struct {
+		op   byte
+		want []byte
+	}{
+		{wsOpPong, []byte("hi")},
+		{wsOpText, []byte("echo state")},
+	}
+	for _, c := range check {
+		hdr := make([]byte, 2)
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, hdr[1])
+		if _, err := io.ReadFull(r, got); err != nil {
+			t.Fatal(err)
+		}
+		if hdr[0] != 0x80|c.op || !bytes.Equal(got, c.want) {
+			t.Errorf("readMessage() got frame %#x '%s'; want %#x '%s'", hdr[0], got, 0x80|c.op, c.want)
+		}
+	}
+
+	conn.Write(wsClientFrame(wsOpClose, nil))
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0] != 0x80|wsOpClose {
+		t.Errorf("readMessage() got frame %#x; want close frame", hdr[0])
+	}
+}
+
+func TestReadMessageTooLarge(t *testing.T) {
+	first := wsClientFrame(wsOpText, []byte("st"))
+	first[0] &^= 0x80
+	// A continuation frame with a 64-bit length that would overflow when added to the length of the first fragment.
+	huge := []byte{0x80 | wsOpContinuation, 0x80 | 127, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+
+	check := map[string][]byte{
+		"single":       {0x80 | wsOpText, 0x80 | 127, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0},
+		"continuation": append(first, huge...),
+	}
+	for name, frames := range check {
+		ws := &wsConn{rw: bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(frames)), bufio.NewWriter(ioutil.Discard))}
+		if _, _, err := ws.readMessage(); err != wsMessageTooLarge {
+			t.Errorf("readMessage() %s err = %v; want %s", name, err, wsMessageTooLarge)
+		}
+	}
+}
+
+func TestUpgradeWebSocketNoUpgrade(t *testing.T) {
+	w := httptest.NewRecorder()
+	if _, err := upgradeWebSocket(w, httptest.NewRequest("GET", "/ws", nil), nil); err != wsUpgradeRequired {
+		t.Errorf("upgradeWebSocket() err = %v; want %s", err, wsUpgradeRequired)
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("upgradeWebSocket() status = %d; want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUpgradeWebSocketOriginNotAllowed(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://127.0.0.1:15741/ws", nil)
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "https://evil.example")
+
+	w := httptest.NewRecorder()
+	if _, err := upgradeWebSocket(w, r, nil); err != wsOriginNotAllowed {
+		t.Errorf("upgradeWebSocket() err = %v; want %s", err, wsOriginNotAllowed)
+	}
+	if w.Code != http.StatusForbidden {
+		t.Errorf("upgradeWebSocket() status = %d; want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestWSOriginAllowed(t *testing.T) {
+	origins := []string{"http://camera.lan:8080/", "https://remote.lan"}
+	check := map[string]bool{
+		"":                        true,
+		"http://127.0.0.1:15741":  true,
+		"https://127.0.0.1:15741": true,
+		"http://camera.lan:8080":  true,
+		"HTTPS://remote.lan":      true,
+		"http://127.0.0.1":        false,
+		"http://localhost:15741":  false,
+		"https://evil.example":    false,
+		"http://remote.lan":       false,
+		"null":                    false,
+		"%zz":                     false,
+	}
+	for origin, want := range check {
+		r := httptest.NewRequest("GET", "http://127.0.0.1:15741/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := wsOriginAllowed(r, origins); got != want {
+			t.Errorf("wsOriginAllowed(%s) = %v; want %v", origin, got, want)
+		}
+	}
+}
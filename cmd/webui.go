@@ -0,0 +1,32 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"io/fs"
+	"net/http"
+)
+
+// webUIAssets holds the single page remote control UI served by the WebSocket server when enabled using the -sui flag.
+//
+//go:embed webui
+var webUIAssets embed.FS
+
+// webUIHandler serves the web UI. Next to the static assets, the "properties" path lists the unified field names the
+// UI displays the current value of.
+func webUIHandler() http.Handler {
+	assets, err := fs.Sub(webUIAssets, "webui")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/properties", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ptpfmt.UnifiedFieldNames)
+	})
+
+	return mux
+}
@@ -0,0 +1,139 @@
+// The remote control UI talks to the WebSocket endpoint served on the same port: binary messages hold the live view
+// frames, text messages hold events or the response to a command sent by the UI.
+(function () {
+    'use strict';
+
+    const liveview = document.getElementById('liveview');
+    const noLiveview = document.getElementById('no-liveview');
+    const settings = document.getElementById('settings');
+    const status = document.getElementById('status');
+    const log = document.getElementById('log');
+    const buttons = document.querySelectorAll('button[data-command]');
+    const maxLogLines = 200;
+
+    // EC_DevicePropChanged is sent by the camera when a property changes.
+    const propChanged = '0x4006';
+
+    let ws;
+    let frameURL;
+    let properties = [];
+
+    function addLog(line) {
+        const lines = log.textContent.split('\n').filter(l => l !== '');
+        lines.push(new Date().toLocaleTimeString() + ' ' + line);
+        log.textContent = lines.slice(-maxLogLines).join('\n') + '\n';
+        log.scrollTop = log.scrollHeight;
+    }
+
+    function setConnected(connected) {
+        status.textContent = connected ? 'connected' : 'disconnected';
+        status.className = connected ? 'connected' : 'disconnected';
+        buttons.forEach(b => b.disabled = !connected);
+    }
+
+    function send(command) {
+        if (!ws || ws.readyState !== WebSocket.OPEN) {
+            addLog('not connected, cannot send: ' + command);
+            return;
+        }
+        ws.send(command);
+    }
+
+    // refreshSettings requests the value of all properties, once for a burst of property changes.
+    let refreshTimer;
+    function refreshSettings() {
+        clearTimeout(refreshTimer);
+        refreshTimer = setTimeout(() => properties.forEach(p => send('get ' + p)), 250);
+    }
+
+    function showSetting(name, value) {
+        let row = document.getElementById('setting-' + name);
+        if (!row) {
+            row = settings.insertRow();
+            row.id = 'setting-' + name;
+            row.insertCell().textContent = name;
+            row.insertCell();
+        }
+        row.cells[1].textContent = value;
+    }
+
+    function showFrame(blob) {
+        if (frameURL) {
+            URL.revokeObjectURL(frameURL);
+        }
+        frameURL = URL.createObjectURL(blob);
+        liveview.src = frameURL;
+        noLiveview.style.display = 'none';
+    }
+
+    function handleText(data) {
+        let msg;
+        try {
+            msg = JSON.parse(data);
+        } catch (e) {
+            addLog('invalid message: ' + data);
+            return;
+        }
+
+        if ('command' in msg) {
+            const f = msg.command.trim().split(/\s+/);
+            if (f.length === 2 && f[0] === 'get' && properties.includes(f[1])) {
+                showSetting(f[1], msg.response.trim());
+                return;
+            }
+            addLog('> ' + msg.command + '\n' + msg.response.trim());
+            if (f[0] === 'set' || f[0] === 'reset') {
+                refreshSettings();
+            }
+            return;
+        }
+
+        if (msg.code) {
+            addLog('event ' + msg.code.code + ' ' + msg.code.label);
+            if (msg.code.code === propChanged) {
+                refreshSettings();
+            }
+        }
+    }
+
+    function connect() {
+        const scheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+        ws = new WebSocket(scheme + location.host + '/ws');
+        ws.binaryType = 'blob';
+        ws.onopen = () => {
+            setConnected(true);
+            addLog('connected');
+            refreshSettings();
+        };
+        ws.onclose = () => {
+            setConnected(false);
+            addLog('connection closed, reconnecting in 2 seconds');
+            setTimeout(connect, 2000);
+        };
+        ws.onmessage = e => {
+            if (e.data instanceof Blob) {
+                showFrame(e.data);
+            } else {
+                handleText(e.data);
+            }
+        };
+    }
+
+    buttons.forEach(b => b.addEventListener('click', () => send(b.dataset.command)));
+
+    document.getElementById('command').addEventListener('submit', e => {
+        e.preventDefault();
+        const input = document.getElementById('command-line');
+        if (input.value.trim() !== '') {
+            send(input.value.trim());
+            input.value = '';
+        }
+    });
+
+    setConnected(false);
+    fetch('properties')
+        .then(r => r.json())
+        .then(p => properties = p)
+        .catch(e => addLog('cannot load the properties: ' + e))
+        .finally(connect);
+})();
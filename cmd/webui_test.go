@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWebUIHandler(t *testing.T) {
+	srv := httptest.NewServer(webUIHandler())
+	defer srv.Close()
+
+	check := map[string]string{
+		"/":          `<script src="app.js"></script>`,
+		"/app.js":    "new WebSocket(",
+		"/style.css": "#liveview",
+	}
+	for path, want := range check {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s got = %d '%s'; want 200 containing '%s'", path, res.StatusCode, body, want)
+		}
+	}
+
+	res, err := http.Get(srv.URL + "/properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got []string
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, ptpfmt.UnifiedFieldNames) {
+		t.Errorf("GET /properties got = %v; want %v", got, ptpfmt.UnifiedFieldNames)
+	}
+
+	if res, err := http.Get(srv.URL + "/missing.js"); err != nil || res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /missing.js got = %v, %v; want 404", res, err)
+	}
+}
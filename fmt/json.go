@@ -2,7 +2,9 @@ package fmt
 
 import (
 	"encoding/json"
+	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"time"
 )
 
 type DevicePropDescJSON struct {
@@ -96,3 +98,38 @@ func (ef *EnumerationFormJSON) MarshalJSON() ([]byte, error) {
 		SupportedValues: hex,
 	})
 }
+
+// EventJSON allows forwarding an event received from a camera to non-Go consumers. The parameters of the event are
+// those of its typed event, e.g. the handle of a ptp.ObjectAddedEvent.
+type EventJSON struct {
+	ip.EventPacket
+	// Vendor is the vendor of the camera that sent the event, used to name vendor extended event codes.
+	Vendor ptp.VendorExtension
+	// Time is the time the event was received.
+	Time time.Time
+	// CameraID identifies the camera that sent the event, e.g. its serial number or the name of its client in a
+	// ip.ClientPool.
+	CameraID string
+}
+
+func (ej *EventJSON) MarshalJSON() ([]byte, error) {
+	var params interface{} = ej.Typed()
+	if ue, ok := params.(ptp.UnknownEvent); ok {
+		params = ue.Event
+	}
+
+	return json.Marshal(&struct {
+		EventCode  CodeLabel   `json:"code"`
+		Parameters interface{} `json:"parameters"`
+		Time       time.Time   `json:"timestamp"`
+		CameraID   string      `json:"camera"`
+	}{
+		EventCode: CodeLabel{
+			Code:  ConvertToHexString(ej.GetEventCode()),
+			Label: EventCodeAsString(ej.Vendor, ej.GetEventCode()),
+		},
+		Parameters: params,
+		Time:       ej.Time,
+		CameraID:   ej.CameraID,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/malc0mn/ptp-ip/ptp"
 	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func TestMarshalJSON(t *testing.T) {
@@ -170,3 +171,28 @@ func TestMarshalJSON(t *testing.T) {
 		t.Errorf("MarshalJSON() got = %s; want %s", got, want)
 	}
 }
+
+func TestEventJSON_MarshalJSON(t *testing.T) {
+	ej := &EventJSON{
+		EventPacket: &ip.GenericEventPacket{
+			Event: ptp.Event{
+				EventCode:     ptp.EC_ObjectAdded,
+				TransactionID: 7,
+				Parameter1:    3,
+			},
+		},
+		Vendor:   ptp.VE_FujiPhotoFilmCoLtd,
+		Time:     time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC),
+		CameraID: "left",
+	}
+
+	got, err := json.Marshal(ej)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":{"code":"0x4002","label":"object added"},"parameters":{"Handle":3},` +
+		`"timestamp":"2020-05-01T12:30:00Z","camera":"left"}`
+	if string(got) != want {
+		t.Errorf("MarshalJSON() got = %s; want %s", got, want)
+	}
+}
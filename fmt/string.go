@@ -2,12 +2,17 @@ package fmt
 
 import (
 	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
+	PRP_Aperture          string = "aperture"
 	PRP_Delay             string = "delay"
 	PRP_Effect            string = "effect"
 	PRP_Exposure          string = "exposure"
@@ -19,6 +24,7 @@ const (
 )
 
 var UnifiedFieldNames = []string{
+	PRP_Aperture,
 	PRP_Delay,
 	PRP_Effect,
 	PRP_Exposure,
@@ -55,6 +61,26 @@ func DevicePropCodeAsString(code ptp.DevicePropCode) string {
 	return res
 }
 
+// EventCodeAsString returns the EventCode of the given vendor as string. Vendor extended event codes that are not known
+// to this package are named after the name they were registered with using ip.RegisterVendorEvent(). When the
+// EventCode is unknown, it returns an empty string.
+func EventCodeAsString(vendor ptp.VendorExtension, code ptp.EventCode) string {
+	var res string
+	switch vendor {
+	case ptp.VE_FujiPhotoFilmCoLtd:
+		res = FujiEventCodeAsString(code)
+	default:
+		res = GenericEventCodeAsString(code)
+	}
+	if res == "" {
+		if ve, ok := ip.LookupVendorEvent(vendor, code); ok {
+			res = ve.Name
+		}
+	}
+
+	return res
+}
+
 // PropNameToDevicePropCode converts a string to a device property code.
 func PropNameToDevicePropCode(vendor ptp.VendorExtension, param string) (ptp.DevicePropCode, error) {
 	switch vendor {
@@ -65,6 +91,16 @@ func PropNameToDevicePropCode(vendor ptp.VendorExtension, param string) (ptp.Dev
 	}
 }
 
+// ParseWhiteBalance converts a string to a white balance value known by the given vendor.
+func ParseWhiteBalance(vendor ptp.VendorExtension, s string) (ptp.WhiteBalance, error) {
+	switch vendor {
+	case ptp.VE_FujiPhotoFilmCoLtd:
+		return FujiParseWhiteBalance(s)
+	default:
+		return GenericParseWhiteBalance(s)
+	}
+}
+
 func DevicePropValAsString(vendor ptp.VendorExtension, code ptp.DevicePropCode, v int64) string {
 	switch vendor {
 	case ptp.VE_FujiPhotoFilmCoLtd:
@@ -73,3 +109,98 @@ func DevicePropValAsString(vendor ptp.VendorExtension, code ptp.DevicePropCode,
 		return DevicePropValueAsString(code, v)
 	}
 }
+
+// ParseDevicePropValue converts a human readable value, such as "astia" for the Fuji film simulation or "f/5.6" for
+// the aperture, to the value of the device property of the given vendor. Values as returned by DevicePropValAsString()
+// are accepted ignoring case. Hexadecimal values in the form of '0x6' are returned as is, the 0x prefix may be omitted
+// when the value is not a human readable one.
+func ParseDevicePropValue(vendor ptp.VendorExtension, code ptp.DevicePropCode, s string) (int64, error) {
+	val := strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToLower(val), "0x") {
+		v, err := HexStringToUint64(val, 32)
+		return int64(v), err
+	}
+
+	var parse func(ptp.DevicePropCode, string) (int64, error)
+	switch vendor {
+	case ptp.VE_FujiPhotoFilmCoLtd:
+		parse = FujiParseDevicePropValue
+	default:
+		parse = GenericParseDevicePropValue
+	}
+	if v, err := parse(code, val); err == nil {
+		return v, nil
+	}
+
+	if v, ok := lookupDevicePropValue(vendor, code, val); ok {
+		return v, nil
+	}
+
+	if v, err := HexStringToUint64(val, 32); err == nil {
+		return int64(v), nil
+	}
+
+	return 0, fmt.Errorf("unknown value '%s' for property %#x", s, code)
+}
+
+// valueTableKey identifies a reverse lookup table built by lookupDevicePropValue().
+type valueTableKey struct {
+	vendor ptp.VendorExtension
+	code   ptp.DevicePropCode
+}
+
+var (
+	valueTablesMu sync.Mutex
+	valueTables   = make(map[valueTableKey]map[string]int64)
+)
+
+// maxDevicePropValueNames is the amount of values above which a device property is considered to hold a number, such
+// as the aperture, rather than one of a list of values, such as the white balance.
+const maxDevicePropValueNames = 64
+
+// lookupDevicePropValue returns the value DevicePropValAsString() converts to the given string, ignoring case.
+func lookupDevicePropValue(vendor ptp.VendorExtension, code ptp.DevicePropCode, s string) (int64, bool) {
+	v, ok := valueTable(vendor, code)[strings.ToLower(s)]
+
+	return v, ok
+}
+
+// DevicePropValueNames returns the values of the device property as returned by DevicePropValAsString() in lower case
+// and sorted alphabetically, which are accepted by ParseDevicePropValue(). Nil is returned when the property holds a
+// number, e.g. the aperture, as there are too many values to list.
+func DevicePropValueNames(vendor ptp.VendorExtension, code ptp.DevicePropCode) []string {
+	table := valueTable(vendor, code)
+	if len(table) > maxDevicePropValueNames {
+		return nil
+	}
+
+	names := make([]string, 0, len(table))
+	for n := range table {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// valueTable returns the reverse lookup table of DevicePropValAsString() for the given vendor and property, mapping
+// the lower case strings to the values. The table holding the values from 0 to 0xFFFF is built on first use.
+func valueTable(vendor ptp.VendorExtension, code ptp.DevicePropCode) map[string]int64 {
+	valueTablesMu.Lock()
+	defer valueTablesMu.Unlock()
+
+	key := valueTableKey{vendor: vendor, code: code}
+	table, ok := valueTables[key]
+	if !ok {
+		table = make(map[string]int64)
+		for v := int64(math.MaxUint16); v >= 0; v-- {
+			// Iterate backwards so the lowest value wins when several values have the same string.
+			if str := DevicePropValAsString(vendor, code, v); str != "" {
+				table[strings.ToLower(str)] = v
+			}
+		}
+		valueTables[key] = table
+	}
+
+	return table
+}
@@ -5,12 +5,15 @@ import (
 	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
 	"strconv"
+	"strings"
 )
 
 func FujiDevicePropCodeAsString(code ptp.DevicePropCode) string {
 	switch code {
 	case ip.DPC_Fuji_FilmSimulation:
 		return "film simulation"
+	case ip.DPC_Fuji_ColorTemp:
+		return "colour temperature"
 	case ip.DPC_Fuji_ImageQuality:
 		return "image quality"
 	case ip.DPC_Fuji_RecMode:
@@ -46,12 +49,25 @@ func FujiDevicePropCodeAsString(code ptp.DevicePropCode) string {
 	}
 }
 
+// FujiEventCodeAsString returns the EventCode as string, including the Fuji specific event codes. When the EventCode is
+// unknown, it returns an empty string.
+func FujiEventCodeAsString(code ptp.EventCode) string {
+	switch code {
+	case ip.EC_Fuji_PreviewAvailable:
+		return "preview available"
+	case ip.EC_Fuji_ObjectAdded:
+		return "object added"
+	default:
+		return GenericEventCodeAsString(code)
+	}
+}
+
 // FujiPropToDevicePropCode converts a standardised property string to a valid ptp.DevicePropertyCode.
 func FujiPropToDevicePropCode(field string) (ptp.DevicePropCode, error) {
 	switch field {
 	case PRP_ISO:
 		return ip.DPC_Fuji_ExposureIndex, nil
-	case PRP_Effect:
+	case PRP_Effect, "filmsimulation":
 		return ip.DPC_Fuji_FilmSimulation, nil
 	case "recmode":
 		return ip.DPC_Fuji_RecMode, nil
@@ -95,6 +111,18 @@ func FujiDevicePropValueAsString(code ptp.DevicePropCode, v int64) string {
 	}
 }
 
+// FujiParseDevicePropValue converts the numeric values of the properties Fuji cameras display in their own notation,
+// such as "S6400" for the ISO, to the value of the device property. See GenericParseDevicePropValue().
+func FujiParseDevicePropValue(code ptp.DevicePropCode, s string) (int64, error) {
+	switch code {
+	case ip.DPC_Fuji_ExposureIndex:
+		edx, err := FujiParseExposureIndex(s)
+		return int64(edx), err
+	default:
+		return GenericParseDevicePropValue(code, s)
+	}
+}
+
 func FujiBatteryLevelAsString(bat ip.FujiBatteryLevel) string {
 	switch bat {
 	case ip.BAT_Fuji_3bOne:
@@ -169,6 +197,32 @@ func FujiExposureIndexAsString(edx ip.FujiExposureIndex) string {
 	return fmt.Sprintf("%s%d", prefix, val)
 }
 
+// FujiParseExposureIndex converts an ISO string as returned by FujiExposureIndexAsString(), such as "auto", "L100",
+// "H12800" or "S6400", back to an ip.FujiExposureIndex.
+func FujiParseExposureIndex(s string) (ip.FujiExposureIndex, error) {
+	iso := strings.ToUpper(strings.TrimSpace(s))
+	if iso == "AUTO" || iso == "AUTOMATIC" {
+		return ip.EDX_Fuji_Auto, nil
+	}
+
+	var flags uint16
+	switch {
+	case strings.HasPrefix(iso, "L"), strings.HasPrefix(iso, "H"):
+		flags = ip.EDX_Fuji_Extended
+		iso = iso[1:]
+	case strings.HasPrefix(iso, "S"):
+		flags = ip.EDX_Fuji_MaxSensitivity
+		iso = iso[1:]
+	}
+
+	v, err := strconv.ParseUint(iso, 10, 16)
+	if err != nil || v == 0 {
+		return 0, fmt.Errorf("invalid ISO '%s'", s)
+	}
+
+	return ip.FujiExposureIndex(uint32(flags)<<16 | uint32(v)), nil
+}
+
 func FujiFilmSimulationAsString(fs ip.FujiFilmSimulation) string {
 	switch fs {
 	case ip.FS_Fuji_Provia:
@@ -327,6 +381,12 @@ func FujiWhiteBalanceAsString(wb ptp.WhiteBalance) string {
 	}
 }
 
+// FujiParseWhiteBalance converts a white balance string as returned by FujiWhiteBalanceAsString() back to a
+// ptp.WhiteBalance.
+func FujiParseWhiteBalance(s string) (ptp.WhiteBalance, error) {
+	return parseWhiteBalance(s, FujiWhiteBalanceAsString)
+}
+
 // TODO: FujiRecModeAsString(rm ip.FujiRecMode)
 
 func FujiSelfTimerAsString(st ip.FujiSelfTimer) string {
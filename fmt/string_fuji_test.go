@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
 	"testing"
 )
 
 func TestFujiDevicePropCodeAsString(t *testing.T) {
 	check := map[ptp.DevicePropCode]string{
 		ip.DPC_Fuji_FilmSimulation:     "film simulation",
+		ip.DPC_Fuji_ColorTemp:          "colour temperature",
 		ip.DPC_Fuji_ImageQuality:       "image quality",
 		ip.DPC_Fuji_RecMode:            "rec mode",
 		ip.DPC_Fuji_CommandDialMode:    "command dial mode",
@@ -36,9 +38,26 @@ func TestFujiDevicePropCodeAsString(t *testing.T) {
 	}
 }
 
+func TestFujiEventCodeAsString(t *testing.T) {
+	check := map[ptp.EventCode]string{
+		ip.EC_Fuji_PreviewAvailable: "preview available",
+		ip.EC_Fuji_ObjectAdded:      "object added",
+		ptp.EC_CaptureComplete:      "capture complete",
+		ptp.EventCode(0xC005):       "",
+	}
+
+	for code, want := range check {
+		got := FujiEventCodeAsString(code)
+		if got != want {
+			t.Errorf("FujiEventCodeAsString() return = '%s', want '%s'", got, want)
+		}
+	}
+}
+
 func TestFujiPropToDevicePropCode(t *testing.T) {
 	check := map[string]ptp.DevicePropCode{
 		PRP_Effect:            ip.DPC_Fuji_FilmSimulation,
+		"filmsimulation":      ip.DPC_Fuji_FilmSimulation,
 		PRP_FocusMeteringMode: ip.DPC_Fuji_FocusMeteringMode,
 		PRP_ISO:               ip.DPC_Fuji_ExposureIndex,
 		"recmode":             ip.DPC_Fuji_RecMode,
@@ -318,3 +337,54 @@ func TestFujiSelfTimerAsString(t *testing.T) {
 		}
 	}
 }
+
+func TestFujiParseWhiteBalance(t *testing.T) {
+	check := map[string]ptp.WhiteBalance{
+		"fluorescent 2": ip.WB_Fuji_Fluorescent2,
+		"shade":         ip.WB_Fuji_Shade,
+		"Daylight":      ptp.WB_Daylight,
+	}
+
+	for s, want := range check {
+		got, err := FujiParseWhiteBalance(s)
+		if err != nil {
+			t.Errorf("FujiParseWhiteBalance() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("FujiParseWhiteBalance() return = %#x, want %#x", got, want)
+		}
+	}
+
+	if _, err := FujiParseWhiteBalance("sunset"); err == nil {
+		t.Errorf("FujiParseWhiteBalance() error = <nil>, want unknown white balance 'sunset'")
+	}
+}
+
+func TestFujiParseExposureIndex(t *testing.T) {
+	check := map[string]ip.FujiExposureIndex{
+		"auto":   ip.EDX_Fuji_Auto,
+		"400":    400,
+		"L100":   0x40000064,
+		"H25600": 0x40006400,
+		"s6400":  0x80001900,
+	}
+
+	for s, want := range check {
+		got, err := FujiParseExposureIndex(s)
+		if err != nil {
+			t.Errorf("FujiParseExposureIndex() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("FujiParseExposureIndex() return = %#x, want %#x", got, want)
+		}
+		if back := FujiExposureIndexAsString(got); !strings.EqualFold(back, s) {
+			t.Errorf("FujiExposureIndexAsString() return = %s, want %s", back, s)
+		}
+	}
+
+	for _, s := range []string{"", "0", "X100", "high"} {
+		if _, err := FujiParseExposureIndex(s); err == nil {
+			t.Errorf("FujiParseExposureIndex(%s) error = <nil>, want error", s)
+		}
+	}
+}
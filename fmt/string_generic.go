@@ -5,6 +5,8 @@ import (
 	"github.com/malc0mn/ptp-ip/ptp"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // GenericDevicePropCodeAsString returns the DevicePropCode as string. When the DevicePropCode is unknown, it returns an empty
@@ -81,6 +83,8 @@ func GenericDevicePropCodeAsString(code ptp.DevicePropCode) string {
 // GenericPropToDevicePropCode converts a standardised property string to a valid DevicePropertyCode.
 func GenericPropToDevicePropCode(field string) (ptp.DevicePropCode, error) {
 	switch field {
+	case PRP_Aperture:
+		return ptp.DPC_FNumber, nil
 	case PRP_Delay:
 		return ptp.DPC_CaptureDelay, nil
 	case PRP_Effect:
@@ -102,6 +106,44 @@ func GenericPropToDevicePropCode(field string) (ptp.DevicePropCode, error) {
 	}
 }
 
+// GenericEventCodeAsString returns the EventCode as string. When the EventCode is unknown, it returns an empty string.
+func GenericEventCodeAsString(code ptp.EventCode) string {
+	switch code {
+	case ptp.EC_Undefined:
+		return "undefined"
+	case ptp.EC_CancelTransaction:
+		return "cancel transaction"
+	case ptp.EC_ObjectAdded:
+		return "object added"
+	case ptp.EC_ObjectRemoved:
+		return "object removed"
+	case ptp.EC_StoreAdded:
+		return "store added"
+	case ptp.EC_StoreRemoved:
+		return "store removed"
+	case ptp.EC_DevicePropChanged:
+		return "device property changed"
+	case ptp.EC_ObjectInfoChanged:
+		return "object info changed"
+	case ptp.EC_DeviceInfoChanged:
+		return "device info changed"
+	case ptp.EC_RequestObjectTransfer:
+		return "request object transfer"
+	case ptp.EC_StoreFull:
+		return "store full"
+	case ptp.EC_DeviceReset:
+		return "device reset"
+	case ptp.EC_StorageInfoChanged:
+		return "storage info changed"
+	case ptp.EC_CaptureComplete:
+		return "capture complete"
+	case ptp.EC_UnreportedStatus:
+		return "unreported status"
+	default:
+		return ""
+	}
+}
+
 func FormFlagAsString(flag ptp.DevicePropFormFlag) string {
 	switch flag {
 	case ptp.DPF_FormFlag_None:
@@ -174,6 +216,8 @@ func DevicePropValueAsString(code ptp.DevicePropCode, v int64) string {
 		return ExposureBiasCompensationAsString(int16(v))
 	case ptp.DPC_ExposureMeteringMode:
 		return ExposureMeteringModeAsString(ptp.ExposureMeteringMode(v))
+	case ptp.DPC_ExposureTime:
+		return ExposureTimeAsString(uint32(v))
 	case ptp.DPC_ExposureProgramMode:
 		return ExposureProgramModeAsString(ptp.ExposureProgramMode(v))
 	case ptp.DPC_FlashMode:
@@ -203,6 +247,19 @@ func FNumberAsString(fn uint16) string {
 	return fmt.Sprintf("f/%.1f", float32(fn)/100)
 }
 
+// ExposureTimeAsString formats an exposure time in seconds scaled by 10,000 the way cameras display shutter speeds:
+// fractions of a second as "1/250" and whole seconds as "2\"".
+func ExposureTimeAsString(et uint32) string {
+	if et == 0 {
+		return ""
+	}
+	if et >= 10000 {
+		return strconv.FormatFloat(float64(et)/10000, 'f', -1, 64) + `"`
+	}
+
+	return fmt.Sprintf("1/%d", int(math.Round(10000/float64(et))))
+}
+
 func EffectModeAsString(fxm ptp.EffectMode) string {
 	switch fxm {
 	case ptp.FXM_Undefined:
@@ -388,3 +445,225 @@ func WhiteBalanceAsString(wb ptp.WhiteBalance) string {
 		return ""
 	}
 }
+
+// ObjectFormatCodeAsString returns the ObjectFormatCode as a short lowercase string such as "jpeg", which can be
+// converted back using ParseObjectFormatCode(). When the ObjectFormatCode is unknown, it returns an empty string.
+func ObjectFormatCodeAsString(ofc ptp.ObjectFormatCode) string {
+	switch ofc {
+	case ptp.OFC_Undefined:
+		return "undefined"
+	case ptp.OFC_Association:
+		return "association"
+	case ptp.OFC_Script:
+		return "script"
+	case ptp.OFC_Executable:
+		return "executable"
+	case ptp.OFC_Text:
+		return "text"
+	case ptp.OFC_HTML:
+		return "html"
+	case ptp.OFC_DPOF:
+		return "dpof"
+	case ptp.OFC_AIFF:
+		return "aiff"
+	case ptp.OFC_WAV:
+		return "wav"
+	case ptp.OFC_MP3:
+		return "mp3"
+	case ptp.OFC_AVI:
+		return "avi"
+	case ptp.OFC_MPEG:
+		return "mpeg"
+	case ptp.OFC_ASF:
+		return "asf"
+	case ptp.OFC_Unknown:
+		return "unknown image"
+	case ptp.OFC_EXIF_JPEG:
+		return "jpeg"
+	case ptp.OFC_TIFF_EP:
+		return "tiff-ep"
+	case ptp.OFC_FlashPix:
+		return "flashpix"
+	case ptp.OFC_BMP:
+		return "bmp"
+	case ptp.OFC_CIFF:
+		return "ciff"
+	case ptp.OFC_GIF:
+		return "gif"
+	case ptp.OFC_JFIF:
+		return "jfif"
+	case ptp.OFC_PCD:
+		return "pcd"
+	case ptp.OFC_PICT:
+		return "pict"
+	case ptp.OFC_PNG:
+		return "png"
+	case ptp.OFC_TIFF:
+		return "tiff"
+	case ptp.OFC_TIFF_IT:
+		return "tiff-it"
+	case ptp.OFC_JP2:
+		return "jp2"
+	case ptp.OFC_JPX:
+		return "jpx"
+	default:
+		return ""
+	}
+}
+
+// ParseObjectFormatCode converts a string as returned by ObjectFormatCodeAsString() or a hexadecimal ObjectFormatCode
+// in the form of '0x3801' to a ptp.ObjectFormatCode.
+func ParseObjectFormatCode(s string) (ptp.ObjectFormatCode, error) {
+	ofc := strings.ToLower(strings.TrimSpace(s))
+	if ofc == "" {
+		return 0, fmt.Errorf("unknown object format '%s'", s)
+	}
+
+	for v := ptp.OFC_Undefined; v <= ptp.OFC_JPX; v++ {
+		if ObjectFormatCodeAsString(v) == ofc {
+			return v, nil
+		}
+	}
+
+	if strings.HasPrefix(ofc, "0x") {
+		if v, err := HexStringToUint64(ofc, 16); err == nil {
+			return ptp.ObjectFormatCode(v), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown object format '%s'", s)
+}
+
+// ParseFNumber converts an aperture string such as "f/5.6", "F2.8" or "11" to its float value.
+func ParseFNumber(s string) (float64, error) {
+	fn := strings.TrimLeft(strings.TrimSpace(s), "fF/")
+	f, err := strconv.ParseFloat(fn, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid F-number '%s'", s)
+	}
+
+	return f, nil
+}
+
+// ParseShutterSpeed converts a shutter speed string such as "1/250", "2", "2s", "2\"" or "0.5" to a time.Duration.
+func ParseShutterSpeed(s string) (time.Duration, error) {
+	ss := strings.TrimRight(strings.TrimSpace(s), "s\"")
+
+	sec, err := parseFraction(ss)
+	if err != nil || sec <= 0 {
+		return 0, fmt.Errorf("invalid shutter speed '%s'", s)
+	}
+
+	return time.Duration(math.Round(sec * float64(time.Second))), nil
+}
+
+// ParseExposureBias converts an exposure bias compensation string such as "-1 2/3", "+1/3", "2" or "0.7" to its value
+// in EV.
+func ParseExposureBias(s string) (float64, error) {
+	eb := strings.TrimSpace(s)
+
+	neg := strings.HasPrefix(eb, "-")
+	if neg || strings.HasPrefix(eb, "+") {
+		eb = eb[1:]
+	}
+
+	var ev float64
+	for _, part := range strings.Fields(eb) {
+		v, err := parseFraction(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid exposure bias compensation '%s'", s)
+		}
+		ev += v
+	}
+
+	if neg {
+		ev = -ev
+	}
+
+	return ev, nil
+}
+
+// ParseISO converts an ISO string such as "1600" or "auto" to its numeric value. The value 0 is returned for automatic
+// ISO.
+func ParseISO(s string) (uint32, error) {
+	iso := strings.ToLower(strings.TrimSpace(s))
+	if iso == "auto" || iso == "automatic" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(strings.TrimLeft(iso, "lh"), 10, 16)
+	if err != nil || v == 0 {
+		return 0, fmt.Errorf("invalid ISO '%s'", s)
+	}
+
+	return uint32(v), nil
+}
+
+// GenericParseDevicePropValue converts the numeric values of the properties cameras display in their own notation,
+// such as "f/5.6" for the aperture or "1/250" for the exposure time, to the value of the device property. An error is
+// returned for the other properties, see ParseDevicePropValue().
+func GenericParseDevicePropValue(code ptp.DevicePropCode, s string) (int64, error) {
+	switch code {
+	case ptp.DPC_FNumber:
+		fn, err := ParseFNumber(s)
+		return int64(math.Round(fn * 100)), err
+	case ptp.DPC_ExposureTime:
+		d, err := ParseShutterSpeed(s)
+		// The exposure time is expressed in seconds scaled by 10,000.
+		return int64(math.Round(float64(d) / float64(100*time.Microsecond))), err
+	case ptp.DPC_ExposureBiasCompensation:
+		ev, err := ParseExposureBias(s)
+		// The bias is a signed 16 bit value expressed in thousandths of a stop.
+		return int64(uint16(int16(math.Round(ev * 1000)))), err
+	case ptp.DPC_ExposureIndex:
+		iso, err := ParseISO(s)
+		if err == nil && iso == 0 {
+			iso = math.MaxUint16
+		}
+		return int64(iso), err
+	default:
+		return 0, fmt.Errorf("no parser for property %#x", code)
+	}
+}
+
+// GenericParseWhiteBalance converts a white balance string as returned by WhiteBalanceAsString() back to a
+// ptp.WhiteBalance.
+func GenericParseWhiteBalance(s string) (ptp.WhiteBalance, error) {
+	return parseWhiteBalance(s, WhiteBalanceAsString)
+}
+
+func parseWhiteBalance(s string, asString func(ptp.WhiteBalance) string) (ptp.WhiteBalance, error) {
+	wb := strings.ToLower(strings.TrimSpace(s))
+	if wb != "" {
+		for v := 0; v <= math.MaxUint16; v++ {
+			if asString(ptp.WhiteBalance(v)) == wb {
+				return ptp.WhiteBalance(v), nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unknown white balance '%s'", s)
+}
+
+// parseFraction parses strings such as "1/3" or "0.5" to a float.
+func parseFraction(s string) (float64, error) {
+	parts := strings.SplitN(s, "/", 2)
+
+	num, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	if len(parts) == 1 {
+		return num, nil
+	}
+
+	den, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	if den == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return num / den, nil
+}
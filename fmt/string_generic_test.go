@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/malc0mn/ptp-ip/ptp"
 	"testing"
+	"time"
 )
 
 func TestGenericDevicePropCodeAsString(t *testing.T) {
@@ -50,8 +51,37 @@ func TestGenericDevicePropCodeAsString(t *testing.T) {
 	}
 }
 
+func TestGenericEventCodeAsString(t *testing.T) {
+	check := map[ptp.EventCode]string{
+		ptp.EC_Undefined:             "undefined",
+		ptp.EC_CancelTransaction:     "cancel transaction",
+		ptp.EC_ObjectAdded:           "object added",
+		ptp.EC_ObjectRemoved:         "object removed",
+		ptp.EC_StoreAdded:            "store added",
+		ptp.EC_StoreRemoved:          "store removed",
+		ptp.EC_DevicePropChanged:     "device property changed",
+		ptp.EC_ObjectInfoChanged:     "object info changed",
+		ptp.EC_DeviceInfoChanged:     "device info changed",
+		ptp.EC_RequestObjectTransfer: "request object transfer",
+		ptp.EC_StoreFull:             "store full",
+		ptp.EC_DeviceReset:           "device reset",
+		ptp.EC_StorageInfoChanged:    "storage info changed",
+		ptp.EC_CaptureComplete:       "capture complete",
+		ptp.EC_UnreportedStatus:      "unreported status",
+		ptp.EventCode(0xC001):        "",
+	}
+
+	for code, want := range check {
+		got := GenericEventCodeAsString(code)
+		if got != want {
+			t.Errorf("GenericEventCodeAsString() return = '%s', want '%s'", got, want)
+		}
+	}
+}
+
 func TestPropToDevicePropCode(t *testing.T) {
 	check := map[string]ptp.DevicePropCode{
+		PRP_Aperture:          ptp.DPC_FNumber,
 		PRP_Delay:             ptp.DPC_CaptureDelay,
 		PRP_Effect:            ptp.DPC_EffectMode,
 		PRP_Exposure:          ptp.DPC_ExposureTime,
@@ -254,6 +284,23 @@ func TestFNumberAsString(t *testing.T) {
 	}
 }
 
+func TestExposureTimeAsString(t *testing.T) {
+	check := map[uint32]string{
+		0:     "",
+		40:    "1/250",
+		3:     "1/3333",
+		5000:  "1/2",
+		10000: "1\"",
+		25000: "2.5\"",
+	}
+	for et, want := range check {
+		got := ExposureTimeAsString(et)
+		if got != want {
+			t.Errorf("ExposureTimeAsString(%d) return = '%s', want '%s'", et, got, want)
+		}
+	}
+}
+
 func TestEffectModeAsString(t *testing.T) {
 	for code, want := range modes[ptp.DPC_EffectMode] {
 		got := EffectModeAsString(ptp.EffectMode(code))
@@ -357,3 +404,198 @@ func TestWhiteBalanceAsString(t *testing.T) {
 		}
 	}
 }
+
+func TestObjectFormatCodeAsString(t *testing.T) {
+	check := map[ptp.ObjectFormatCode]string{
+		ptp.OFC_Association: "association",
+		ptp.OFC_EXIF_JPEG:   "jpeg",
+		ptp.OFC_TIFF_EP:     "tiff-ep",
+		ptp.OFC_JPX:         "jpx",
+		0xb103:              "",
+	}
+
+	for code, want := range check {
+		got := ObjectFormatCodeAsString(code)
+		if got != want {
+			t.Errorf("ObjectFormatCodeAsString() return = '%s', want '%s'", got, want)
+		}
+	}
+}
+
+func TestParseObjectFormatCode(t *testing.T) {
+	check := map[string]ptp.ObjectFormatCode{
+		"jpeg":   ptp.OFC_EXIF_JPEG,
+		"TIFF":   ptp.OFC_TIFF,
+		"0xb103": 0xb103,
+	}
+
+	for s, want := range check {
+		got, err := ParseObjectFormatCode(s)
+		if err != nil {
+			t.Errorf("ParseObjectFormatCode() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("ParseObjectFormatCode() return = %#x, want %#x", got, want)
+		}
+	}
+
+	for _, s := range []string{"", "raw", "0xzz"} {
+		if _, err := ParseObjectFormatCode(s); err == nil {
+			t.Errorf("ParseObjectFormatCode(%s) error = <nil>, want error", s)
+		}
+	}
+}
+
+func TestParseFNumber(t *testing.T) {
+	check := map[string]float64{
+		"f/5.6": 5.6,
+		"F2.8":  2.8,
+		"11":    11,
+	}
+
+	for s, want := range check {
+		got, err := ParseFNumber(s)
+		if err != nil {
+			t.Errorf("ParseFNumber() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("ParseFNumber() return = %f, want %f", got, want)
+		}
+	}
+
+	for _, s := range []string{"", "f/", "f/0", "five"} {
+		if _, err := ParseFNumber(s); err == nil {
+			t.Errorf("ParseFNumber(%s) error = <nil>, want error", s)
+		}
+	}
+}
+
+func TestParseShutterSpeed(t *testing.T) {
+	check := map[string]time.Duration{
+		"1/250": 4 * time.Millisecond,
+		"1/4":   250 * time.Millisecond,
+		"2":     2 * time.Second,
+		"2s":    2 * time.Second,
+		"30\"":  30 * time.Second,
+		"0.5":   500 * time.Millisecond,
+	}
+
+	for s, want := range check {
+		got, err := ParseShutterSpeed(s)
+		if err != nil {
+			t.Errorf("ParseShutterSpeed() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("ParseShutterSpeed() return = %s, want %s", got, want)
+		}
+	}
+
+	for _, s := range []string{"", "1/0", "0", "-1", "fast"} {
+		if _, err := ParseShutterSpeed(s); err == nil {
+			t.Errorf("ParseShutterSpeed(%s) error = <nil>, want error", s)
+		}
+	}
+}
+
+func TestParseExposureBias(t *testing.T) {
+	check := map[string]float64{
+		"0":      0,
+		"+1/3":   1 / 3.0,
+		"-2/3":   -2 / 3.0,
+		"-1 1/2": -1.5,
+		"2":      2,
+		"0.7":    0.7,
+	}
+
+	for s, want := range check {
+		got, err := ParseExposureBias(s)
+		if err != nil {
+			t.Errorf("ParseExposureBias() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("ParseExposureBias() return = %f, want %f", got, want)
+		}
+	}
+
+	for _, s := range []string{"1/", "+-1", "one"} {
+		if _, err := ParseExposureBias(s); err == nil {
+			t.Errorf("ParseExposureBias(%s) error = <nil>, want error", s)
+		}
+	}
+}
+
+func TestParseISO(t *testing.T) {
+	check := map[string]uint32{
+		"auto":   0,
+		"100":    100,
+		"1600":   1600,
+		"H25600": 25600,
+	}
+
+	for s, want := range check {
+		got, err := ParseISO(s)
+		if err != nil {
+			t.Errorf("ParseISO() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("ParseISO() return = %d, want %d", got, want)
+		}
+	}
+
+	for _, s := range []string{"", "0", "100000", "high"} {
+		if _, err := ParseISO(s); err == nil {
+			t.Errorf("ParseISO(%s) error = <nil>, want error", s)
+		}
+	}
+}
+
+func TestGenericParseDevicePropValue(t *testing.T) {
+	check := []struct {
+		code ptp.DevicePropCode
+		s    string
+		want int64
+	}{
+		{ptp.DPC_FNumber, "f/5.6", 560},
+		{ptp.DPC_ExposureTime, "1/250", 40},
+		{ptp.DPC_ExposureTime, "2\"", 20000},
+		{ptp.DPC_ExposureBiasCompensation, "+1/3", 333},
+		{ptp.DPC_ExposureBiasCompensation, "-1 2/3", 0xf97d},
+		{ptp.DPC_ExposureIndex, "1600", 1600},
+		{ptp.DPC_ExposureIndex, "auto", 0xffff},
+	}
+
+	for _, c := range check {
+		got, err := GenericParseDevicePropValue(c.code, c.s)
+		if err != nil {
+			t.Errorf("GenericParseDevicePropValue(%#x, %s) error = %s, want <nil>", c.code, c.s, err)
+		}
+		if got != c.want {
+			t.Errorf("GenericParseDevicePropValue(%#x, %s) return = %#x, want %#x", c.code, c.s, got, c.want)
+		}
+	}
+
+	if _, err := GenericParseDevicePropValue(ptp.DPC_WhiteBalance, "daylight"); err == nil {
+		t.Errorf("GenericParseDevicePropValue() error = <nil>, want no parser for property 0x5005")
+	}
+}
+
+func TestGenericParseWhiteBalance(t *testing.T) {
+	check := map[string]ptp.WhiteBalance{
+		"automatic": ptp.WB_Automatic,
+		"tungsten":  ptp.WB_Tungsten,
+	}
+
+	for s, want := range check {
+		got, err := GenericParseWhiteBalance(s)
+		if err != nil {
+			t.Errorf("GenericParseWhiteBalance() error = %s, want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("GenericParseWhiteBalance() return = %#x, want %#x", got, want)
+		}
+	}
+
+	if _, err := GenericParseWhiteBalance("shade"); err == nil {
+		t.Errorf("GenericParseWhiteBalance() error = <nil>, want unknown white balance 'shade'")
+	}
+}
@@ -3,6 +3,7 @@ package fmt
 import (
 	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"reflect"
 	"testing"
 )
 
@@ -62,6 +63,28 @@ func TestDevicePropCodeAsString(t *testing.T) {
 	}
 }
 
+func TestEventCodeAsString(t *testing.T) {
+	ip.RegisterVendorEvent(ptp.VE_CanonInc, 0xC181, ip.VendorEvent{Name: "PropValueChanged"})
+
+	check := []struct {
+		vendor ptp.VendorExtension
+		code   ptp.EventCode
+		want   string
+	}{
+		{ptp.VE_FujiPhotoFilmCoLtd, ip.EC_Fuji_PreviewAvailable, "preview available"},
+		{ptp.VE_FujiPhotoFilmCoLtd, ptp.EC_ObjectAdded, "object added"},
+		{ptp.VE_CanonInc, ptp.EC_ObjectAdded, "object added"},
+		{ptp.VE_CanonInc, 0xC181, "PropValueChanged"},
+		{ptp.VE_CanonInc, ip.EC_Fuji_PreviewAvailable, ""},
+	}
+
+	for _, tt := range check {
+		if got := EventCodeAsString(tt.vendor, tt.code); got != tt.want {
+			t.Errorf("EventCodeAsString(%#x, %#x) got = %s; want %s", tt.vendor, tt.code, got, tt.want)
+		}
+	}
+}
+
 func TestPropNameToDevicePropCode(t *testing.T) {
 	want := ip.DPC_Fuji_ExposureIndex
 	got, err := PropNameToDevicePropCode(ptp.VE_FujiPhotoFilmCoLtd, "iso")
@@ -106,3 +129,50 @@ func TestDevicePropValAsString(t *testing.T) {
 		t.Errorf("DevicePropValAsString() got = %s; want %s", got, want)
 	}
 }
+
+func TestParseDevicePropValue(t *testing.T) {
+	check := []struct {
+		vendor ptp.VendorExtension
+		code   ptp.DevicePropCode
+		s      string
+		want   int64
+	}{
+		{ptp.VE_FujiPhotoFilmCoLtd, ip.DPC_Fuji_FilmSimulation, "astia", int64(ip.FS_Fuji_Astia)},
+		{ptp.VE_FujiPhotoFilmCoLtd, ip.DPC_Fuji_FilmSimulation, "Monochrome + Ye Filter", int64(ip.FS_Fuji_MonochromeYeFilter)},
+		{ptp.VE_FujiPhotoFilmCoLtd, ip.DPC_Fuji_ExposureIndex, "S6400", 0x80001900},
+		{ptp.VE_FujiPhotoFilmCoLtd, ptp.DPC_WhiteBalance, "shade", int64(ip.WB_Fuji_Shade)},
+		{ptp.VE_FujiPhotoFilmCoLtd, ptp.DPC_FNumber, "f/5.6", 560},
+		{ptp.VendorExtension(0), ptp.DPC_FNumber, "automatic", 0xffff},
+		{ptp.VendorExtension(0), ptp.DPC_WhiteBalance, "Tungsten", int64(ptp.WB_Tungsten)},
+		{ptp.VendorExtension(0), ptp.DPC_WhiteBalance, "0x6", 6},
+		{ptp.VendorExtension(0), ptp.DPC_WhiteBalance, "6", 6},
+		{ptp.VendorExtension(0), ptp.DPC_ExposureIndex, "0x320", 0x320},
+	}
+
+	for _, c := range check {
+		got, err := ParseDevicePropValue(c.vendor, c.code, c.s)
+		if err != nil {
+			t.Errorf("ParseDevicePropValue(%#x, %#x, %s) error = %s, want <nil>", c.vendor, c.code, c.s, err)
+		}
+		if got != c.want {
+			t.Errorf("ParseDevicePropValue(%#x, %#x, %s) got = %#x; want %#x", c.vendor, c.code, c.s, got, c.want)
+		}
+	}
+
+	wantE := "unknown value 'sunset' for property 0x5005"
+	if _, err := ParseDevicePropValue(ptp.VendorExtension(0), ptp.DPC_WhiteBalance, "sunset"); err == nil || err.Error() != wantE {
+		t.Errorf("ParseDevicePropValue() err = %v; want %s", err, wantE)
+	}
+}
+
+func TestDevicePropValueNames(t *testing.T) {
+	got := DevicePropValueNames(ptp.VendorExtension(0), ptp.DPC_FocusMeteringMode)
+	want := []string{"center spot", "multi spot", "undefined"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DevicePropValueNames() got = %q; want %q", got, want)
+	}
+
+	if got := DevicePropValueNames(ptp.VendorExtension(0), ptp.DPC_FNumber); got != nil {
+		t.Errorf("DevicePropValueNames() got = %q; want <nil>", got)
+	}
+}
@@ -1,11 +1,11 @@
 module github.com/malc0mn/ptp-ip
 
-go 1.14
+go 1.16
 
 require (
 	github.com/go-gl/gl v0.0.0-20190320180904-bf2b1f2f34d7
 	github.com/go-gl/glfw v0.0.0-20200707082815-5321531c36a2
 	github.com/go-ini/ini v1.56.0
 	github.com/google/uuid v1.1.1
-	golang.org/x/image v0.0.0-20200801110659-972c09e46d76
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
 )
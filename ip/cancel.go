@@ -0,0 +1,54 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+)
+
+var (
+	TransactionCancelledError = errors.New("transaction cancelled")
+	UnknownTransactionError   = errors.New("no such transaction in progress")
+)
+
+// CancelTransaction asks the Responder to cancel the transaction with the given ID, e.g. to abort downloading a large
+// object without having to close the connection. Use TransactionId() to obtain the ID of the transaction in progress.
+// The data that is still arriving for the transaction is discarded and the operation that started the transaction
+// returns an error wrapping TransactionCancelledError as soon as the Responder confirms the cancellation.
+func (c *Client) CancelTransaction(tid ptp.TransactionID) error {
+	if !c.markCancelled(tid, false) {
+		return fmt.Errorf("%w: %d", UnknownTransactionError, tid)
+	}
+
+	c.Infof("Cancelling transaction %d...", tid)
+	if err := c.vendorExtensions.cancelTransaction(c, tid); err != nil {
+		return err
+	}
+	c.markCancelled(tid, true)
+
+	return nil
+}
+
+// markCancelled sets the cancelled flag of the transaction so the data phase will no longer keep the data received.
+// The return value indicates if the transaction is in progress.
+func (c *Client) markCancelled(tid ptp.TransactionID, cancelled bool) bool {
+	c.cmdDataSubsMu.Lock()
+	defer c.cmdDataSubsMu.Unlock()
+
+	sub, ok := c.cmdDataSubs[tid]
+	if ok {
+		sub.cancelled = cancelled
+	}
+
+	return ok
+}
+
+// cancelled indicates if CancelTransaction was called for the transaction.
+func (t *transaction) cancelled() bool {
+	t.c.cmdDataSubsMu.Lock()
+	defer t.c.cmdDataSubsMu.Unlock()
+
+	sub, ok := t.c.cmdDataSubs[t.id]
+
+	return ok && sub.cancelled
+}
@@ -0,0 +1,57 @@
+package ip
+
+import (
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_CancelTransaction(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9c8b7a6f-5e4d-4c3b-8a29-1f0e9d8c7b6a"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.CancelTransaction(c.TransactionId() + 1); !errors.Is(err, UnknownTransactionError) {
+		t.Errorf("CancelTransaction() err = %v; want %s", err, UnknownTransactionError)
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		data, err := c.GetObject(mockSlowObject)
+		done <- result{data, err}
+	}()
+
+	// Give the transfer some time to start.
+	time.Sleep(100 * time.Millisecond)
+	if err := c.CancelTransaction(c.TransactionId()); err != nil {
+		t.Fatalf("CancelTransaction() err = %s; want <nil>", err)
+	}
+
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, TransactionCancelledError) {
+			t.Errorf("GetObject() err = %v; want %s", res.err, TransactionCancelledError)
+		}
+		if res.data != nil {
+			t.Errorf("GetObject() data length = %d; want 0", len(res.data))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetObject() did not return after cancelling the transaction")
+	}
+
+	// The connection must still be usable.
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel); err != nil {
+		t.Errorf("GetDevicePropertyValue() err = %s; want <nil>", err)
+	}
+}
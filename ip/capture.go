@@ -0,0 +1,172 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"time"
+)
+
+var StoreFullError = errors.New("store full")
+
+// CaptureResult holds the outcome of a capture performed using InitiateCaptureAndWait().
+type CaptureResult struct {
+	// Handles holds the ObjectHandles of the captured objects in the order they were reported by the Responder. It is
+	// empty for Responders that do not report the handles, as is the case for Fuji devices.
+	Handles []ptp.ObjectHandle
+	// Preview holds a preview of the captured image for Responders returning one.
+	Preview []byte
+}
+
+// InitiateCaptureAndWait releases the shutter and blocks until the Responder reports the capture to be complete, so
+// the captured objects can be downloaded right away. The Capture timeout applies to each event waited for. When the
+// store becomes full, the handles of the objects captured so far are returned together with StoreFullError.
+func (c *Client) InitiateCaptureAndWait() (*CaptureResult, error) {
+	return c.InitiateCaptureAndWaitContext(context.Background())
+}
+
+// InitiateCaptureAndWaitContext does the same as InitiateCaptureAndWait but aborts as soon as the context is done.
+func (c *Client) InitiateCaptureAndWaitContext(ctx context.Context) (*CaptureResult, error) {
+	return c.vendorExtensions.initiateCaptureAndWait(withReadTimeout(ctx, c.timeouts.Capture), c)
+}
+
+// GenericInitiateCapture releases the shutter and waits for the capture to complete. Generic Responders do not return a
+// preview, so the byte array is always nil.
+func GenericInitiateCapture(ctx context.Context, c *Client) ([]byte, error) {
+	_, err := GenericInitiateCaptureAndWait(ctx, c)
+	return nil, err
+}
+
+// GenericInitiateCaptureAndWait releases the shutter and collects the ObjectHandles of the ObjectAdded events carrying
+// the TransactionID of the InitiateCapture operation until the CaptureComplete or StoreFull event is received.
+func GenericInitiateCaptureAndWait(ctx context.Context, c *Client) (*CaptureResult, error) {
+	// Subscribe before releasing the shutter: the events can arrive before the operation response does.
+	events := c.Subscribe(ptp.EC_ObjectAdded, ptp.EC_CaptureComplete, ptp.EC_StoreFull)
+	defer c.Unsubscribe(events)
+
+	c.Infof("Releasing %s shutter...", c.ResponderFriendlyName())
+	res, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.InitiateCapture(0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	return waitForCaptureComplete(ctx, c, events, res.TransactionID)
+}
+
+// waitForCaptureComplete collects the ObjectHandles of the ObjectAdded events carrying the given TransactionID until
+// the CaptureComplete or StoreFull event is received.
+func waitForCaptureComplete(ctx context.Context, c *Client, events <-chan EventPacket, tid ptp.TransactionID) (*CaptureResult, error) {
+	cr := &CaptureResult{}
+	for {
+		select {
+		case msg, ok := <-events:
+			if !ok {
+				return cr, ConnectionLostError
+			}
+			if gep, ok := msg.(*GenericEventPacket); !ok || gep.TransactionID != tid {
+				continue
+			}
+			switch e := msg.Typed().(type) {
+			case ptp.ObjectAddedEvent:
+				c.Debugf("Received object added event for handle %#x.", e.Handle)
+				cr.Handles = append(cr.Handles, e.Handle)
+			case ptp.CaptureCompleteEvent:
+				c.Debugf("Received capture complete event (%#x).", msg.GetEventCode())
+				return cr, nil
+			case ptp.StoreFullEvent:
+				return cr, StoreFullError
+			}
+		case <-after(c.readTimeout(ctx)):
+			return cr, WaitForEventError
+		case <-ctx.Done():
+			return cr, ctx.Err()
+		}
+	}
+}
+
+// FujiInitiateCaptureAndWait releases the shutter and returns the preview of the image taken. FujiInitiateCapture()
+// already waits for the ptp.EC_CaptureComplete event, but Fuji devices do not report the ObjectHandle of the captured
+// image so the handles are always empty.
+func FujiInitiateCaptureAndWait(ctx context.Context, c *Client) (*CaptureResult, error) {
+	img, err := FujiInitiateCapture(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CaptureResult{Preview: img}, nil
+}
+
+// OpenCapture is an exposure started using InitiateOpenCapture(), e.g. a bulb exposure, which lasts until it is ended
+// using TerminateOpenCapture().
+type OpenCapture struct {
+	// TransactionID is the TransactionID of the InitiateOpenCapture operation, which is used to end the capture.
+	TransactionID ptp.TransactionID
+	// Started is the time the Responder acknowledged the start of the capture.
+	Started time.Time
+
+	events <-chan EventPacket
+}
+
+// InitiateOpenCapture opens the shutter and keeps it open until TerminateOpenCapture() is called, allowing exposures
+// of any length to be made such as when the camera is set to bulb mode. The Capture timeout applies to the start of the
+// capture only.
+func (c *Client) InitiateOpenCapture() (*OpenCapture, error) {
+	return c.InitiateOpenCaptureContext(context.Background())
+}
+
+// InitiateOpenCaptureContext does the same as InitiateOpenCapture but aborts as soon as the context is done.
+func (c *Client) InitiateOpenCaptureContext(ctx context.Context) (*OpenCapture, error) {
+	return c.vendorExtensions.initiateOpenCapture(withReadTimeout(ctx, c.timeouts.Capture), c)
+}
+
+// TerminateOpenCapture closes the shutter of a capture started using InitiateOpenCapture() and blocks until the
+// Responder reports the capture to be complete. The Capture timeout applies to each event waited for.
+func (c *Client) TerminateOpenCapture(oc *OpenCapture) (*CaptureResult, error) {
+	return c.TerminateOpenCaptureContext(context.Background(), oc)
+}
+
+// TerminateOpenCaptureContext does the same as TerminateOpenCapture but aborts as soon as the context is done.
+func (c *Client) TerminateOpenCaptureContext(ctx context.Context, oc *OpenCapture) (*CaptureResult, error) {
+	return c.vendorExtensions.terminateOpenCapture(withReadTimeout(ctx, c.timeouts.Capture), c, oc)
+}
+
+// GenericInitiateOpenCapture opens the shutter using the InitiateOpenCapture operation, storing the captured objects in
+// the default store using the default format.
+func GenericInitiateOpenCapture(ctx context.Context, c *Client) (*OpenCapture, error) {
+	// Subscribe before opening the shutter: the events will carry the TransactionID of the InitiateOpenCapture
+	// operation and are only sent once the capture is terminated, but a Responder might report an object early.
+	events := c.Subscribe(ptp.EC_ObjectAdded, ptp.EC_CaptureComplete, ptp.EC_StoreFull)
+
+	c.Infof("Opening %s shutter...", c.ResponderFriendlyName())
+	res, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.InitiateOpenCapture(0, 0))
+	if err != nil {
+		c.Unsubscribe(events)
+		return nil, err
+	}
+
+	return &OpenCapture{TransactionID: res.TransactionID, Started: time.Now(), events: events}, nil
+}
+
+// GenericTerminateOpenCapture closes the shutter using the TerminateOpenCapture operation and collects the
+// ObjectHandles of the captured objects until the CaptureComplete or StoreFull event is received.
+func GenericTerminateOpenCapture(ctx context.Context, c *Client, oc *OpenCapture) (*CaptureResult, error) {
+	defer c.Unsubscribe(oc.events)
+
+	c.Infof("Closing %s shutter...", c.ResponderFriendlyName())
+	if _, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.TerminateOpenCapture(oc.TransactionID)); err != nil {
+		return nil, err
+	}
+
+	return waitForCaptureComplete(ctx, c, oc.events, oc.TransactionID)
+}
+
+// FujiInitiateOpenCapture is not supported: the session with a Fuji device is an open capture in itself, see
+// FujiInitCommandDataConn(), and there is no known way to keep the shutter open.
+func FujiInitiateOpenCapture(_ context.Context, _ *Client) (*OpenCapture, error) {
+	return nil, errors.New("command not YET supported")
+}
+
+// FujiTerminateOpenCapture is not supported, see FujiInitiateOpenCapture().
+func FujiTerminateOpenCapture(_ context.Context, _ *Client, _ *OpenCapture) (*CaptureResult, error) {
+	return nil, errors.New("command not YET supported")
+}
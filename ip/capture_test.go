@@ -0,0 +1,63 @@
+package ip
+
+import "testing"
+
+func TestClient_InitiateCaptureAndWait(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mock responder sends out an ObjectAdded event for handle 3 followed by a CaptureComplete event.
+	got, err := c.InitiateCaptureAndWait()
+	if err != nil {
+		t.Fatalf("InitiateCaptureAndWait() err = %s; want <nil>", err)
+	}
+	if len(got.Handles) != 1 || got.Handles[0] != 3 {
+		t.Errorf("InitiateCaptureAndWait() handles = %v; want [3]", got.Handles)
+	}
+	if got.Preview != nil {
+		t.Errorf("InitiateCaptureAndWait() preview = %v; want <nil>", got.Preview)
+	}
+}
+
+func TestClient_OpenCapture(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	oc, err := c.InitiateOpenCapture()
+	if err != nil {
+		t.Fatalf("InitiateOpenCapture() err = %s; want <nil>", err)
+	}
+	if oc.Started.IsZero() {
+		t.Error("InitiateOpenCapture() started = zero; want the start time")
+	}
+
+	// The mock responder sends out an ObjectAdded event for handle 4 followed by a CaptureComplete event, both carrying
+	// the TransactionID of the InitiateOpenCapture operation.
+	got, err := c.TerminateOpenCapture(oc)
+	if err != nil {
+		t.Fatalf("TerminateOpenCapture() err = %s; want <nil>", err)
+	}
+	if len(got.Handles) != 1 || got.Handles[0] != 4 {
+		t.Errorf("TerminateOpenCapture() handles = %v; want [4]", got.Handles)
+	}
+}
+
+func TestFujiInitiateOpenCapture(t *testing.T) {
+	if _, err := FujiInitiateOpenCapture(nil, nil); err == nil {
+		t.Error("FujiInitiateOpenCapture() err = <nil>; want command not YET supported")
+	}
+}
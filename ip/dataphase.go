@@ -0,0 +1,225 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+)
+
+var (
+	DataLengthMismatchError = errors.New("data length does not match the announced length")
+	MissingDataError        = errors.New("operation completed without the expected data phase")
+	UnexpectedPacketError   = errors.New("unexpected packet received")
+)
+
+const (
+	// unknownDataLength is used in the StartDataPacket when the size of the data is not known up front.
+	unknownDataLength uint64 = 0xFFFFFFFFFFFFFFFF
+	// dataOutChunkSize is the maximum amount of data sent in a single packet during a data-out phase.
+	dataOutChunkSize = 64 * 1024
+)
+
+// transactionState is a state of the data phase state machine. Each transaction starts with an operation request which
+// is optionally followed by a data phase, during which the Responder sends the data, and always ends with an operation
+// response:
+//   stateRequest -> [stateDataIn -> [stateDataEnd] ->] stateDone
+// A data-out phase, where the Initiator sends data, is handled when sending the request and does not change the way
+// the packets coming from the Responder are processed.
+type transactionState int
+
+const (
+	// stateRequest is the state right after sending the request: the Responder can start the data phase or respond.
+	stateRequest transactionState = iota
+	// stateDataIn is the state where the Responder is sending data.
+	stateDataIn
+	// stateDataEnd is the state where all data was received and only the operation response is expected.
+	stateDataEnd
+	// stateDone is the state after receiving the operation response, which ends the transaction.
+	stateDone
+)
+
+// packetKind indicates the role of a packet received during a transaction.
+type packetKind int
+
+const (
+	pkStartData packetKind = iota
+	pkData
+	pkEndData
+	pkResponse
+	pkCancel
+)
+
+// transactionPacket is the vendor independent representation of a packet received during a transaction.
+type transactionPacket struct {
+	kind packetKind
+	// length holds the total data length announced by a StartDataPacket.
+	length uint64
+	// payload holds the data of data packets and the raw parameters of the operation response.
+	payload []byte
+	// code holds the operation response code of the operation response.
+	code ptp.OperationResponseCode
+}
+
+// operationResult holds everything the Responder sent during a transaction.
+type operationResult struct {
+	operation ptp.OperationCode
+	tid       ptp.TransactionID
+	code      ptp.OperationResponseCode
+	params    []byte
+	data      []byte
+	// raw holds all packets as they were received.
+	raw [][]byte
+}
+
+// dataPhase is the data phase state machine of a transaction. It processes the packets sent by the Responder one at a
+// time so the data can either be collected or passed on as it arrives.
+type dataPhase struct {
+	t      *transaction
+	res    *operationResult
+	state  transactionState
+	length uint64
+	// received holds the number of data bytes received so far.
+	received uint64
+	hasData  bool
+	// stream indicates the data is passed on as it arrives, in which case the raw packets are not kept either.
+	stream bool
+}
+
+func (t *transaction) newDataPhase(code ptp.OperationCode) *dataPhase {
+	return &dataPhase{
+		t:      t,
+		res:    &operationResult{operation: code, tid: t.id},
+		state:  stateRequest,
+		length: unknownDataLength,
+	}
+}
+
+// done indicates if the operation response was received.
+func (dp *dataPhase) done() bool {
+	return dp.state == stateDone
+}
+
+// next waits for the next packet of the transaction and returns the data it carries, if any. Packets that do not fit in
+// the current state are reported as an error.
+func (dp *dataPhase) next(ctx context.Context) ([]byte, error) {
+	t := dp.t
+	raw, err := t.c.WaitForRawPacketFromCommandDataSubscriberContext(ctx, t.ch)
+	if err != nil {
+		return nil, err
+	}
+	if !dp.stream {
+		dp.res.raw = append(dp.res.raw, raw)
+	}
+
+	p, err := t.c.vendorExtensions.readTransactionPacket(raw, dp.res.operation)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.kind {
+	case pkStartData:
+		if dp.state != stateRequest {
+			return nil, fmt.Errorf("%w: start of data in state %d", UnexpectedPacketError, dp.state)
+		}
+		dp.length = p.length
+		dp.state = stateDataIn
+		reportProgress(ctx, 0, dp.length)
+	case pkData, pkEndData:
+		// Not all vendors announce the data phase, so data is accepted right after the request as well.
+		if dp.state != stateRequest && dp.state != stateDataIn {
+			return nil, fmt.Errorf("%w: data in state %d", UnexpectedPacketError, dp.state)
+		}
+		dp.received += uint64(len(p.payload))
+		dp.hasData = true
+		dp.state = stateDataIn
+		if p.kind == pkEndData {
+			dp.state = stateDataEnd
+		}
+		// The data that is still arriving after cancelling the transaction is of no use to anyone.
+		if !t.cancelled() {
+			reportProgress(ctx, dp.received, dp.length)
+			return p.payload, nil
+		}
+	case pkCancel:
+		return nil, fmt.Errorf("%w: transaction %d cancelled by responder", TransactionCancelledError, t.id)
+	case pkResponse:
+		dp.res.code = p.code
+		dp.res.params = p.payload
+		dp.state = stateDone
+		t.c.journal.recordResponse(t.id, dp.res.operation, p.code, p.payload)
+	}
+
+	return nil, nil
+}
+
+// finish checks the transaction once the operation response was received: data that does not match the announced
+// length or the operation is reported as an error.
+func (dp *dataPhase) finish() error {
+	t := dp.t
+	if t.cancelled() {
+		return fmt.Errorf("%w: transaction %d completed with response code %#x", TransactionCancelledError, t.id, dp.res.code)
+	}
+	if dp.hasData && dp.length != unknownDataLength && dp.received != dp.length {
+		return fmt.Errorf("%w: announced %d bytes, received %d", DataLengthMismatchError, dp.length, dp.received)
+	}
+	if !dp.hasData && dp.res.code == ptp.RC_OK && expectsDataIn(dp.res.operation) {
+		return fmt.Errorf("%w: operation %#x", MissingDataError, dp.res.operation)
+	}
+
+	return nil
+}
+
+// readResult runs the data phase state machine for the transaction: it collects the data sent by the Responder until
+// the operation response is received. Packets that do not fit in the current state and data that does not match the
+// announced length or the operation are reported as an error.
+// The raw packets received so far are always returned, even when an error occurs.
+func (t *transaction) readResult(ctx context.Context, code ptp.OperationCode) (*operationResult, error) {
+	dp := t.newDataPhase(code)
+	for !dp.done() {
+		data, err := dp.next(ctx)
+		if err != nil {
+			return dp.res, err
+		}
+		dp.res.data = append(dp.res.data, data...)
+	}
+
+	return dp.res, dp.finish()
+}
+
+// sendData runs the data-out phase of the transaction: the data is announced with a StartDataPacket and sent in chunks,
+// the last chunk being carried by the EndDataPacket. Sending no data at all results in an empty EndDataPacket.
+// The progress is reported to the ProgressFunc of the context, if there is one.
+func (t *transaction) sendData(ctx context.Context, data []byte) error {
+	total := uint64(len(data))
+	if err := t.c.SendPacketToCmdDataConn(&StartDataPacket{TransactionId: t.id, TotalDataLength: total}); err != nil {
+		return err
+	}
+
+	for len(data) > dataOutChunkSize {
+		if err := t.c.SendPacketToCmdDataConn(&DataPacket{TransactionId: t.id, DataPayload: data[:dataOutChunkSize]}); err != nil {
+			return err
+		}
+		data = data[dataOutChunkSize:]
+		reportProgress(ctx, total-uint64(len(data)), total)
+	}
+
+	if err := t.c.SendPacketToCmdDataConn(&EndDataPacket{TransactionId: t.id, DataPayload: data}); err != nil {
+		return err
+	}
+	reportProgress(ctx, total, total)
+
+	return nil
+}
+
+// expectsDataIn indicates if the Responder must send data when the operation succeeds.
+func expectsDataIn(code ptp.OperationCode) bool {
+	switch code {
+	case ptp.OC_GetDeviceInfo, ptp.OC_GetStorageIDs, ptp.OC_GetStorageInfo, ptp.OC_GetObjectHandles,
+		ptp.OC_GetObjectInfo, ptp.OC_GetObject, ptp.OC_GetThumb, ptp.OC_GetDevicePropDesc, ptp.OC_GetDevicePropValue,
+		ptp.OC_GetPartialObject, OC_Fuji_GetDeviceInfo, OC_Fuji_GetCapturePreview:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,173 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+)
+
+func genericTransactionPacket(pt PacketType, fields ...[]byte) []byte {
+	p := make([]byte, HeaderSize)
+	binary.LittleEndian.PutUint32(p[4:8], uint32(pt))
+	for _, f := range fields {
+		p = append(p, f...)
+	}
+	binary.LittleEndian.PutUint32(p[0:4], uint32(len(p)))
+
+	return p
+}
+
+func fujiTransactionPacket(dp DataPhase, code uint16, payload []byte) []byte {
+	p := make([]byte, 12)
+	binary.LittleEndian.PutUint16(p[4:6], uint16(dp))
+	binary.LittleEndian.PutUint16(p[6:8], code)
+	p = append(p, payload...)
+	binary.LittleEndian.PutUint32(p[0:4], uint32(len(p)))
+
+	return p
+}
+
+func le16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return b
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+func TestTransaction_readResult(t *testing.T) {
+	tid := le32(1)
+	okResponse := genericTransactionPacket(PKT_OperationResponse, le16(uint16(ptp.RC_OK)), tid)
+	check := []struct {
+		vendor  string
+		code    ptp.OperationCode
+		packets [][]byte
+		data    string
+		rc      ptp.OperationResponseCode
+		err     error
+	}{
+		{
+			vendor: DefaultVendor,
+			code:   ptp.OC_GetObject,
+			packets: [][]byte{
+				genericTransactionPacket(PKT_StartData, tid, le64(6)),
+				genericTransactionPacket(PKT_Data, tid, []byte("foo")),
+				genericTransactionPacket(PKT_EndData, tid, []byte("bar")),
+				okResponse,
+			},
+			data: "foobar",
+			rc:   ptp.RC_OK,
+		},
+		{
+			vendor: DefaultVendor,
+			code:   ptp.OC_GetObject,
+			packets: [][]byte{
+				genericTransactionPacket(PKT_StartData, tid, le64(8)),
+				genericTransactionPacket(PKT_EndData, tid, []byte("foobar")),
+				okResponse,
+			},
+			err: DataLengthMismatchError,
+		},
+		{
+			vendor:  DefaultVendor,
+			code:    ptp.OC_GetObject,
+			packets: [][]byte{okResponse},
+			err:     MissingDataError,
+		},
+		{
+			vendor:  DefaultVendor,
+			code:    ptp.OC_DeleteObject,
+			packets: [][]byte{okResponse},
+			rc:      ptp.RC_OK,
+		},
+		{
+			vendor: DefaultVendor,
+			code:   ptp.OC_GetObject,
+			packets: [][]byte{
+				genericTransactionPacket(PKT_EndData, tid, []byte("foobar")),
+				genericTransactionPacket(PKT_StartData, tid, le64(6)),
+			},
+			err: UnexpectedPacketError,
+		},
+		{
+			vendor: DefaultVendor,
+			code:   ptp.OC_GetObject,
+			packets: [][]byte{
+				genericTransactionPacket(PKT_EndData, tid, []byte("foobar")),
+				genericTransactionPacket(PKT_Data, tid, []byte("foobar")),
+			},
+			err: UnexpectedPacketError,
+		},
+		{
+			vendor: "fuji",
+			code:   ptp.OC_GetDevicePropValue,
+			packets: [][]byte{
+				fujiTransactionPacket(DP_DataOut, uint16(ptp.OC_GetDevicePropValue), []byte{0x02, 0x00}),
+				fujiTransactionPacket(DP_Unknown, uint16(ptp.RC_OK), nil),
+			},
+			data: "\x02\x00",
+			rc:   ptp.RC_OK,
+		},
+		{
+			vendor: "fuji",
+			code:   ptp.OC_GetDevicePropValue,
+			packets: [][]byte{
+				fujiTransactionPacket(DP_DataOut, uint16(ptp.OC_GetDevicePropDesc), []byte{0x02, 0x00}),
+			},
+			err: UnexpectedPacketError,
+		},
+		{
+			vendor: "fuji",
+			code:   ptp.OC_OpenSession,
+			packets: [][]byte{
+				fujiTransactionPacket(DP_Unknown, uint16(ptp.RC_SessionAlreadyOpen), nil),
+			},
+			rc: ptp.RC_SessionAlreadyOpen,
+		},
+	}
+
+	for i, tt := range check {
+		c, err := NewClient(address, WithVendor(tt.vendor), WithPort(okPort), WithFriendlyName("tèster"), WithGUID("4d5e6f7a-8b9c-4d1e-8f2a-3b4c5d6e7f8a"), WithLogLevel(logLevel))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tr, err := c.beginTransaction(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range tt.packets {
+			tr.ch <- p
+		}
+
+		res, err := tr.readResult(context.Background(), tt.code)
+		tr.end()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%d: readResult() err = %v; want %v", i, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if string(res.data) != tt.data {
+			t.Errorf("%d: readResult() data = %q; want %q", i, res.data, tt.data)
+		}
+		if res.code != tt.rc {
+			t.Errorf("%d: readResult() code = %#x; want %#x", i, res.code, tt.rc)
+		}
+		if len(res.raw) != len(tt.packets) {
+			t.Errorf("%d: readResult() raw packets = %d; want %d", i, len(res.raw), len(tt.packets))
+		}
+	}
+}
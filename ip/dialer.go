@@ -0,0 +1,126 @@
+package ip
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip/internal"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	NoConnectionLeftError = errors.New("all connections have been handed out")
+)
+
+// Dialer establishes the connections to the Responder. A custom Dialer allows binding to a specific local address or
+// interface, or routing the connections through a VPN or tunnel. *net.Dialer satisfies this interface.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DialFunc allows using an ordinary function as Dialer.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DialContext calls f(ctx, network, address).
+func (f DialFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// SetDialer allows setting a custom Dialer which will be used for all connections to the Responder. This should be done
+// before calling Dial(). The Dial timeout still applies to each connection attempt. Passing nil restores the default
+// dialer.
+// To bind to a specific local address, e.g. to reach an IPv6 link-local camera address on a given interface:
+//   c.SetDialer(&net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("fe80::1"), Zone: "wlan0"}})
+func (c *Client) SetDialer(d Dialer) {
+	c.dialer = d
+}
+
+// ConnDialer is a Dialer handing out pre-established connections, e.g. connections set up by a tunneling library, in
+// the order they are requested: the command/data connection first, followed by the event connection and the streamer
+// connection for vendors using one. The requested address is ignored. Once all connections have been handed out
+// NoConnectionLeftError is returned, so a new ConnDialer must be set before reconnecting.
+type ConnDialer struct {
+	conns []net.Conn
+	mu    sync.Mutex
+}
+
+// NewConnDialer returns a ConnDialer handing out the given connections.
+func NewConnDialer(conns ...net.Conn) *ConnDialer {
+	return &ConnDialer{conns: conns}
+}
+
+// DialContext returns the next connection.
+func (d *ConnDialer) DialContext(_ context.Context, _, _ string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.conns) == 0 {
+		return nil, NoConnectionLeftError
+	}
+	conn := d.conns[0]
+	d.conns = d.conns[1:]
+
+	return conn, nil
+}
+
+// SetTLSConfig makes the client wrap all connections to the Responder in TLS using the given configuration, which allows
+// talking to a camera behind a secure tunnel such as stunnel. When the configuration does not specify a ServerName, the
+// host of the Responder is used to verify the certificate. This should be done before calling Dial(). Passing nil
+// disables TLS, which is the default.
+func (c *Client) SetTLSConfig(cfg *tls.Config) {
+	c.tlsConfig = cfg
+}
+
+// dialAddress connects to the given address of the Responder using the configured Dialer, retrying when the connection is
+// refused. The connection is wrapped in TLS when a TLS configuration is set.
+func (c *Client) dialAddress(ctx context.Context, address string) (net.Conn, error) {
+	var d Dialer = &net.Dialer{}
+	if c.dialer != nil {
+		d = c.dialer
+	}
+
+	conn, err := internal.RetryDialer(ctx, d.DialContext, c.Network(), address, c.timeouts.Dial)
+	if err != nil || c.tlsConfig == nil {
+		return conn, err
+	}
+
+	return c.tlsHandshake(ctx, conn, address)
+}
+
+// tlsHandshake wraps the connection in TLS and performs the handshake, which is aborted when the Dial timeout is reached
+// or the context is done. The connection is closed when the handshake fails.
+func (c *Client) tlsHandshake(ctx context.Context, conn net.Conn, address string) (net.Conn, error) {
+	cfg := c.tlsConfig
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		cfg.ServerName = c.responder.IpAddress
+	}
+	tc := tls.Client(conn, cfg)
+
+	if c.timeouts.Dial > 0 {
+		tc.SetDeadline(time.Now().Add(c.timeouts.Dial))
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- tc.Handshake()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		conn.Close()
+		<-done
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", address, err)
+	}
+	tc.SetDeadline(time.Time{})
+
+	return tc, nil
+}
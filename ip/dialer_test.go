@@ -0,0 +1,187 @@
+package ip
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_SetDialer(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2f3e4d5c-6b7a-4980-9a1b-2c3d4e5f6a7b"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	c.SetDialer(DialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		mu.Lock()
+		got = append(got, address)
+		mu.Unlock()
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	}))
+
+	if err := c.Dial(); err != nil {
+		t.Fatalf("Dial() err = %s; want <nil>", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{c.CommandDataAddress(), c.EventAddress()}
+	if len(got) != len(want) {
+		t.Fatalf("Dialer called %d times; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Dialer address = %s; want %s", got[i], want[i])
+		}
+	}
+}
+
+func TestClient_SetDialerFail(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3a4b5c6d-7e8f-4091-8a2b-3c4d5e6f7a8b"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := errors.New("no route to camera")
+	c.SetDialer(DialFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, want
+	}))
+
+	if err := c.Dial(); !errors.Is(err, want) {
+		t.Errorf("Dial() err = %v; want %s", err, want)
+	}
+}
+
+// newTLSTunnel starts a TLS server forwarding all connections to the generic responder, like stunnel would. It returns
+// the port it listens on and a certificate pool trusting its self-signed certificate.
+func newTLSTunnel(t *testing.T) (uint16, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tunnel"},
+		IPAddresses:  []net.IP{net.ParseIP(address)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	ln, err := tls.Listen("tcp", address+":0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := net.Dial("tcp", net.JoinHostPort(address, strconv.Itoa(int(okPort))))
+				if err != nil {
+					return
+				}
+				defer target.Close()
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return uint16(ln.Addr().(*net.TCPAddr).Port), pool
+}
+
+func TestClient_SetTLSConfig(t *testing.T) {
+	port, pool := newTLSTunnel(t)
+
+	c, err := NewClient(address, WithPort(port), WithFriendlyName("tèster"), WithGUID("4b5c6d7e-8f9a-4b1c-9d2e-3f4a5b6c7d8e"), WithLogLevel(logLevel), WithTLSConfig(&tls.Config{RootCAs: pool}))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatalf("Dial() err = %s; want <nil>", err)
+	}
+	for _, conn := range []net.Conn{c.commandDataConn, c.eventConn} {
+		if _, ok := conn.(*tls.Conn); !ok {
+			t.Errorf("connection type = %T; want *tls.Conn", conn)
+		}
+	}
+	if _, err := c.GetDeviceInfo(); err != nil {
+		t.Errorf("GetDeviceInfo() err = %s; want <nil>", err)
+	}
+}
+
+func TestClient_SetTLSConfigUntrusted(t *testing.T) {
+	port, _ := newTLSTunnel(t)
+
+	c, err := NewClient(address, WithPort(port), WithFriendlyName("tèster"), WithGUID("5c6d7e8f-9a0b-4c2d-8e3f-4a5b6c7d8e9f"), WithLogLevel(logLevel), WithTLSConfig(&tls.Config{}))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err == nil {
+		t.Error("Dial() err = <nil>; want certificate error")
+	}
+}
+
+func TestConnDialer(t *testing.T) {
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", net.JoinHostPort(address, strconv.Itoa(int(okPort))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("6d7e8f9a-0b1c-4d3e-9f4a-5b6c7d8e9f0a"), WithLogLevel(logLevel), WithDialer(NewConnDialer(conns...)))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatalf("Dial() err = %s; want <nil>", err)
+	}
+	if c.commandDataConn != conns[0] || c.eventConn != conns[1] {
+		t.Error("Dial() did not use the connections in the order given")
+	}
+	if _, err := c.dialer.DialContext(context.Background(), "tcp", c.EventAddress()); err != NoConnectionLeftError {
+		t.Errorf("DialContext() err = %v; want %s", err, NoConnectionLeftError)
+	}
+}
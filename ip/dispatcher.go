@@ -0,0 +1,190 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
+)
+
+// The PTP protocol allows only one transaction at a time within a session: a new operation request may only be sent
+// after the operation response of the previous one was received. The dispatcher allows operations to be called from
+// multiple goroutines by queueing the transactions and routing each packet received on the command/data connection to
+// the transaction it belongs to using the transaction ID.
+
+// transaction is an operation in progress on the command/data connection. All packets received for its ID are sent to
+// its channel.
+type transaction struct {
+	id ptp.TransactionID
+	ch chan []byte
+	c  *Client
+}
+
+// beginTransaction waits until no other transaction is in progress and then starts a new one using the next
+// transaction ID. The transaction must be ended by calling end() to allow the next one to start.
+func (c *Client) beginTransaction(ctx context.Context) (*transaction, error) {
+	return c.beginTransactionWith(ctx, c.incrementTransactionId)
+}
+
+// beginSessionlessTransaction does the same as beginTransaction but uses the transaction ID reserved for operations
+// outside of a session, such as OpenSession, without consuming a transaction ID of the session.
+func (c *Client) beginSessionlessTransaction(ctx context.Context) (*transaction, error) {
+	return c.beginTransactionWith(ctx, func() ptp.TransactionID { return 0 })
+}
+
+// beginTransactionWith starts a new transaction as soon as no other transaction is in progress, using the transaction
+// ID returned by the given function.
+func (c *Client) beginTransactionWith(ctx context.Context, id func() ptp.TransactionID) (*transaction, error) {
+	select {
+	case c.transactionSem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	t := &transaction{
+		id: id(),
+		ch: make(chan []byte, 10),
+		c:  c,
+	}
+	if err := c.subscribe(t.id, t.ch); err != nil {
+		<-c.transactionSem
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// end stops routing packets to the transaction and allows the next transaction to start.
+func (t *transaction) end() {
+	t.c.unsubscribe(t.id)
+	<-t.c.transactionSem
+}
+
+// abortTransactions makes all pending waits for a response fail immediately. Used when the command/data connection is
+// lost since the responses will never arrive. The next dial resets this.
+func (c *Client) abortTransactions() {
+	c.cmdDataSubsMu.Lock()
+	select {
+	case <-c.aborted:
+	default:
+		close(c.aborted)
+	}
+	c.cmdDataSubsMu.Unlock()
+}
+
+// resetTransactions allows waiting for responses again after abortTransactions was called.
+func (c *Client) resetTransactions() {
+	c.cmdDataSubsMu.Lock()
+	select {
+	case <-c.aborted:
+		c.aborted = make(chan struct{})
+	default:
+	}
+	c.cmdDataSubsMu.Unlock()
+}
+
+// abortedChan returns the channel that is closed when the pending transactions are aborted.
+func (c *Client) abortedChan() <-chan struct{} {
+	c.cmdDataSubsMu.Lock()
+	defer c.cmdDataSubsMu.Unlock()
+
+	return c.aborted
+}
+
+// cmdDataSubscription holds the channel subscribed to a transaction ID. The done channel is closed when unsubscribing
+// so that a response arriving after the subscriber gave up does not block the responseListener. The cancelled flag is
+// set when the Initiator asked the Responder to cancel the transaction.
+type cmdDataSubscription struct {
+	ch        chan<- []byte
+	done      chan struct{}
+	cancelled bool
+}
+
+// subscribe registers a channel to receive responses for a specific transaction ID.
+func (c *Client) subscribe(tid ptp.TransactionID, ch chan<- []byte) error {
+	c.cmdDataSubsMu.Lock()
+	defer c.cmdDataSubsMu.Unlock()
+
+	if _, ok := c.cmdDataSubs[tid]; ok {
+		return fmt.Errorf("attempt to double subscribe transaction id %d", tid)
+	}
+	c.cmdDataSubs[tid] = &cmdDataSubscription{ch: ch, done: make(chan struct{})}
+
+	return nil
+}
+
+// unsubscribe removes a subscription for a given transaction ID. The subscribed channel is not closed since the
+// responseListener might still be publishing to it.
+func (c *Client) unsubscribe(tid ptp.TransactionID) {
+	c.cmdDataSubsMu.Lock()
+	if sub, ok := c.cmdDataSubs[tid]; ok {
+		close(sub.done)
+		delete(c.cmdDataSubs, tid)
+	}
+	c.cmdDataSubsMu.Unlock()
+}
+
+// unsubscribeAll removes all subscriptions. Used when starting a new session since the transaction IDs start over.
+func (c *Client) unsubscribeAll() {
+	c.cmdDataSubsMu.Lock()
+	for tid, sub := range c.cmdDataSubs {
+		close(sub.done)
+		delete(c.cmdDataSubs, tid)
+	}
+	c.cmdDataSubsMu.Unlock()
+}
+
+// publishResponse sends the response to the channel subscribed to the transaction ID. Responses for a transaction
+// nobody is waiting for anymore, e.g. because the operation was cancelled, are dropped.
+func (c *Client) publishResponse(tid ptp.TransactionID, p []byte) {
+	c.cmdDataSubsMu.Lock()
+	sub, ok := c.cmdDataSubs[tid]
+	c.cmdDataSubsMu.Unlock()
+
+	if !ok {
+		c.Warnf("[responseListener] no subscriber for transaction ID %d, dropping response", tid)
+		return
+	}
+
+	select {
+	case sub.ch <- p:
+	case <-sub.done:
+		c.Warnf("[responseListener] subscriber for transaction ID %d is gone, dropping response", tid)
+	}
+}
+
+// responseListener listens on the Command/Data connection for incoming packets and publishes them to a registered
+// subscriber based on the transaction ID of the packet.
+func (c *Client) responseListener() {
+	c.cmdDataChan = make(chan []byte, 10)
+	lmp := "[responseListener]"
+	c.Infof("%s subscribing response listener to command/data connection...", lmp)
+	// The listener is bound to the connection it was started for: after reconnecting a new listener takes over.
+	conn := c.conn(cmdDataConnection)
+	for {
+		p, err := c.waitForRawFromCmdDataConn(conn)
+		if conn != c.conn(cmdDataConnection) {
+			c.Infof("%s connection was replaced, message listener stopped", lmp)
+			return
+		}
+		if err == nil {
+			tid, err := c.vendorExtensions.extractTransactionId(p, cmdDataConnection)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+			c.Debugf("%s publishing new response with length '%d' for transaction ID '%d'...", lmp, binary.LittleEndian.Uint32(p[0:4]), tid)
+
+			c.publishResponse(tid, p)
+			continue
+		} else if err == WaitForResponseError || strings.Contains(err.Error(), "i/o timeout") {
+			continue
+		}
+		c.Errorf("%s message listener stopped: %s", lmp, err)
+		// Nothing will be received anymore, so there is no use in letting the pending transactions wait any longer.
+		c.abortTransactions()
+		c.connectionLost(err)
+		return
+	}
+}
@@ -0,0 +1,144 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_beginTransaction(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t1, err := c.beginTransaction(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t1.id != 1 {
+		t.Errorf("beginTransaction() id = %d; want 1", t1.id)
+	}
+
+	// Only one transaction can be in progress at a time.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.beginTransaction(ctx); err != context.DeadlineExceeded {
+		t.Errorf("beginTransaction() err = %v; want %s", err, context.DeadlineExceeded)
+	}
+
+	t1.end()
+	if _, ok := c.cmdDataSubs[t1.id]; ok {
+		t.Errorf("end() subscription for transaction %d still present", t1.id)
+	}
+
+	t2, err := c.beginTransaction(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer t2.end()
+	if t2.id != 2 {
+		t.Errorf("beginTransaction() id = %d; want 2", t2.id)
+	}
+}
+
+func TestClient_abortTransactions(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3c4d5e6f-7a8b-4c9d-8e1f-2a3b4c5d6e7f"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := c.beginTransaction(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.end()
+
+	c.abortTransactions()
+	// Aborting twice must not panic.
+	c.abortTransactions()
+	if _, err := c.WaitForRawPacketFromCommandDataSubscriber(tr.ch); err != ConnectionLostError {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriber() err = %v; want %s", err, ConnectionLostError)
+	}
+
+	c.resetTransactions()
+	ctx := withReadTimeout(context.Background(), 20*time.Millisecond)
+	if _, err := c.WaitForRawPacketFromCommandDataSubscriberContext(ctx, tr.ch); err != WaitForResponseError {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriberContext() err = %v; want %s", err, WaitForResponseError)
+	}
+}
+
+func TestClient_concurrentTransactions(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("4d5e6f7a-8b9c-4d0e-9f2a-3b4c5d6e7f8a"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel)
+			if err == nil && v != uint8(0x32) {
+				t.Errorf("GetDevicePropertyValue() = %v; want %v", v, uint8(0x32))
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			data, err := c.GetObject(4)
+			if err == nil && len(data) != 8192 {
+				t.Errorf("GetObject() length = %d; want %d", len(data), 8192)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent operation err = %s; want <nil>", err)
+		}
+	}
+}
+
+func TestGenericExtractTransactionId(t *testing.T) {
+	cases := []struct {
+		p    []byte
+		want ptp.TransactionID
+		err  error
+	}{
+		{[]byte{0x0e, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00, 0x00}, 42, nil},
+		{[]byte{0x0e, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x06, 0x40, 0x2b, 0x00, 0x00, 0x00}, 43, nil},
+		{[]byte{0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00}, 44, nil},
+		{[]byte{0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x2d, 0x00, 0x00, 0x00}, 45, nil},
+		{[]byte{0x0d, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00}, 0, TruncatedPacketError},
+		{[]byte{0x0a, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x2c, 0x00}, 0, TruncatedPacketError},
+		{[]byte{0x06, 0x00, 0x00, 0x00, 0x07, 0x00}, 0, TruncatedPacketError},
+		{[]byte{0x04, 0x00, 0x00, 0x00}, 0, TruncatedPacketError},
+		{[]byte{0x08, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00}, 0, UnexpectedPacketError},
+		{[]byte{0x0e, 0x00, 0x00, 0x00, 0xff, 0x00, 0x00, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00, 0x00}, 0, UnexpectedPacketError},
+	}
+
+	for _, tc := range cases {
+		got, err := GenericExtractTransactionId(tc.p, cmdDataConnection)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("GenericExtractTransactionId(%#x) err = %v; want %v", tc.p, err, tc.err)
+		}
+		if got != tc.want {
+			t.Errorf("GenericExtractTransactionId(%#x) = %d; want %d", tc.p, got, tc.want)
+		}
+	}
+}
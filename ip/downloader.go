@@ -0,0 +1,270 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultFilenameTemplate simply uses the filename as it is known by the Responder.
+const DefaultFilenameTemplate = "{{.Filename}}"
+
+// DownloadTemplateData is passed to the filename template of a Downloader. All ObjectInfo fields can be used in the
+// template, e.g. "{{.CaptureDate.Format \"2006-01-02\"}}/{{.Handle}}_{{.Filename}}".
+type DownloadTemplateData struct {
+	*ptp.ObjectInfo
+	Handle ptp.ObjectHandle
+	// Sequence is a counter that is incremented for every object downloaded by the Downloader, starting at 1.
+	Sequence int
+}
+
+// Basename returns the filename without its extension, e.g. "DSCF0001" for "DSCF0001.JPG".
+func (d DownloadTemplateData) Basename() string {
+	return strings.TrimSuffix(d.Filename, filepath.Ext(d.Filename))
+}
+
+// Extension returns the extension of the filename without the leading dot, e.g. "JPG" for "DSCF0001.JPG".
+func (d DownloadTemplateData) Extension() string {
+	return strings.TrimPrefix(filepath.Ext(d.Filename), ".")
+}
+
+// DownloadResult is passed to the OnComplete callback of a Downloader once an object has been handled.
+type DownloadResult struct {
+	Handle ptp.ObjectHandle
+	Info   *ptp.ObjectInfo
+	// Path holds the full path of the file that was written.
+	Path string
+	Err  error
+}
+
+// Downloader listens for ObjectAdded events on the event connection and downloads every new object to a directory.
+// This is what is typically called tethered shooting: each image captured by the camera automatically ends up on your
+// computer.
+type Downloader struct {
+	c     *Client
+	dir   string
+	tmpl  *template.Template
+	seq   int
+	seqMu sync.Mutex
+	// known holds the ObjectHandles present on the Responder, to find the objects that were added when the event does
+	// not tell.
+	known map[ptp.ObjectHandle]struct{}
+	// SkipAssociations prevents the creation of a directory for an association that was added, e.g. when the camera
+	// creates a new folder on the memory card. Defaults to true.
+	SkipAssociations bool
+	// OnComplete, when set, is called for every object the Downloader handled, successfully or not.
+	OnComplete func(DownloadResult)
+	// OnProgress, when set, is called while an object is being downloaded. See ProgressFunc.
+	OnProgress func(h ptp.ObjectHandle, transferred, total int64)
+	events     <-chan EventPacket
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewDownloader creates a Downloader that will store the objects in dir using the given filename template. The template
+// uses the text/template syntax and receives DownloadTemplateData. Passing an empty string as template will use the
+// DefaultFilenameTemplate.
+func NewDownloader(c *Client, dir string, tmpl string) (*Downloader, error) {
+	if tmpl == "" {
+		tmpl = DefaultFilenameTemplate
+	}
+
+	t, err := template.New("filename").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Downloader{
+		c:                c,
+		dir:              dir,
+		tmpl:             t,
+		SkipAssociations: true,
+	}, nil
+}
+
+// Start subscribes the Downloader to the client's events and starts downloading every object that is added.
+func (d *Downloader) Start() {
+	if d.events != nil {
+		return
+	}
+
+	// No object must be missed, so the events are queued while an object is being downloaded.
+	d.events = d.c.SubscribeWithBackpressure(BackpressureBlock, ptp.EC_ObjectAdded, EC_Fuji_ObjectAdded)
+	d.stop = make(chan struct{})
+	d.known = nil
+
+	d.wg.Add(1)
+	go d.run()
+}
+
+// Stop unsubscribes the Downloader from the client's events. Any download that is in progress will be finished first.
+func (d *Downloader) Stop() {
+	if d.events == nil {
+		return
+	}
+
+	d.c.Unsubscribe(d.events)
+	close(d.stop)
+	d.wg.Wait()
+	d.events = nil
+}
+
+func (d *Downloader) run() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case evt, ok := <-d.events:
+			if !ok {
+				// The client was closed.
+				return
+			}
+			hs, err := d.addedObjects(evt)
+			if err != nil {
+				d.c.Warnf("[downloader] unable to find the object that was added: %s", err)
+				if d.OnComplete != nil {
+					d.OnComplete(DownloadResult{Err: err})
+				}
+				continue
+			}
+			for _, h := range hs {
+				d.c.Debugf("[downloader] object %#x added", h)
+				p, oi, err := d.download(h)
+				if d.OnComplete != nil {
+					d.OnComplete(DownloadResult{Handle: h, Info: oi, Path: p, Err: err})
+				}
+			}
+		}
+	}
+}
+
+// addedObjects returns the ObjectHandles of the objects an ObjectAdded event announces. Fuji does not send the handle
+// along, so the objects present on the Responder are listed to find the ones that were added.
+func (d *Downloader) addedObjects(evt EventPacket) ([]ptp.ObjectHandle, error) {
+	switch e := evt.Typed().(type) {
+	case ptp.ObjectAddedEvent:
+		return []ptp.ObjectHandle{e.Handle}, nil
+	case FujiObjectAddedEvent:
+		return d.newObjectHandles()
+	}
+
+	return nil, nil
+}
+
+// newObjectHandles returns the ObjectHandles that were not present on the Responder the previous time it was called.
+// The first time, the highest ObjectHandle is considered to be the new one since the handles are assigned in ascending
+// order.
+func (d *Downloader) newObjectHandles() ([]ptp.ObjectHandle, error) {
+	hs, err := d.c.GetObjectHandles(0xFFFFFFFF, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.known == nil {
+		d.known = make(map[ptp.ObjectHandle]struct{}, len(hs))
+		var newest ptp.ObjectHandle
+		for _, h := range hs {
+			d.known[h] = struct{}{}
+			if h > newest {
+				newest = h
+			}
+		}
+		if newest == 0 {
+			return nil, nil
+		}
+		return []ptp.ObjectHandle{newest}, nil
+	}
+
+	var added []ptp.ObjectHandle
+	for _, h := range hs {
+		if _, ok := d.known[h]; !ok {
+			d.known[h] = struct{}{}
+			added = append(added, h)
+		}
+	}
+
+	return added, nil
+}
+
+// Download fetches the object with the given ObjectHandle and stores it in the Downloader's directory. The full path to
+// the file is returned.
+func (d *Downloader) Download(h ptp.ObjectHandle) (string, error) {
+	p, _, err := d.download(h)
+
+	return p, err
+}
+
+func (d *Downloader) download(h ptp.ObjectHandle) (string, *ptp.ObjectInfo, error) {
+	oi, err := d.c.GetObjectInfo(h)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if oi.IsAssociation() && d.SkipAssociations {
+		return "", oi, nil
+	}
+
+	p, err := d.filename(DownloadTemplateData{ObjectInfo: oi, Handle: h, Sequence: d.nextSequence()})
+	if err != nil {
+		return "", oi, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", oi, err
+	}
+
+	if oi.IsAssociation() {
+		return p, oi, os.MkdirAll(p, 0755)
+	}
+
+	ctx := context.Background()
+	if d.OnProgress != nil {
+		ctx = ContextWithProgress(ctx, func(transferred, total int64) {
+			d.OnProgress(h, transferred, total)
+		})
+	}
+	data, err := d.c.GetObjectContext(ctx, h)
+	if err != nil {
+		return "", oi, err
+	}
+
+	if len(data) != int(oi.ObjectCompressedSize) {
+		d.c.Warnf("[downloader] object size mismatch: expected %d, got %d", oi.ObjectCompressedSize, len(data))
+	}
+
+	return p, oi, ioutil.WriteFile(p, data, 0644)
+}
+
+// nextSequence increments the sequence counter. Download() can be called while the Downloader is running.
+func (d *Downloader) nextSequence() int {
+	d.seqMu.Lock()
+	defer d.seqMu.Unlock()
+
+	d.seq++
+
+	return d.seq
+}
+
+// filename renders the filename template and makes sure the result stays inside the download directory.
+func (d *Downloader) filename(data DownloadTemplateData) (string, error) {
+	var b bytes.Buffer
+	if err := d.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	name := filepath.Clean(b.String())
+	if name == "." || name == ".." || filepath.IsAbs(name) || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid filename '%s' rendered for object %#x", b.String(), data.Handle)
+	}
+
+	return filepath.Join(d.dir, name), nil
+}
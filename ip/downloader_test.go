@@ -0,0 +1,214 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloader_Download(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2d1c0b9a-8f7e-4d6c-b5a4-93827160f5e4"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewDownloader(c, dir, "{{.Sequence}}_{{.Handle}}_{{.Filename}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.Download(4)
+	if err != nil {
+		t.Fatalf("Download() err = %s; want <nil>", err)
+	}
+	want := filepath.Join(dir, "1_4_DSCF0002.RAF")
+	if got != want {
+		t.Errorf("Download() got = %s; want %s", got, want)
+	}
+	fi, err := os.Stat(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != 8192 {
+		t.Errorf("Download() file size = %d; want 8192", fi.Size())
+	}
+
+	// Associations are skipped by default.
+	got, err = d.Download(1)
+	if err != nil || got != "" {
+		t.Errorf("Download() got = %s, err = %v; want empty string, <nil>", got, err)
+	}
+}
+
+func TestDownloader_filename(t *testing.T) {
+	d, err := NewDownloader(nil, "/tmp", "../{{.Filename}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.filename(DownloadTemplateData{ObjectInfo: &ptp.ObjectInfo{Filename: "x.jpg"}})
+	if err == nil {
+		t.Errorf("filename() err = %v; want invalid filename", err)
+	}
+
+	d, err = NewDownloader(nil, "/tmp", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.filename(DownloadTemplateData{ObjectInfo: &ptp.ObjectInfo{Filename: "x.jpg"}})
+	if err != nil {
+		t.Errorf("filename() err = %s; want <nil>", err)
+	}
+	if got != filepath.Join("/tmp", "x.jpg") {
+		t.Errorf("filename() got = %s; want /tmp/x.jpg", got)
+	}
+}
+
+func TestDownloader_Start(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("8e7d6c5b-4a39-4281-9f0e-d1c2b3a49586"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewDownloader(c, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := make(chan DownloadResult, 1)
+	d.OnComplete = func(r DownloadResult) {
+		res <- r
+	}
+	d.Start()
+	defer d.Stop()
+
+	// The mock responder sends out an ObjectAdded event for handle 3 when initiating a capture.
+	if _, err := c.OperationRequestRaw(ptp.OC_InitiateCapture, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-res:
+		if r.Err != nil {
+			t.Errorf("OnComplete() err = %s; want <nil>", r.Err)
+		}
+		if r.Handle != 3 {
+			t.Errorf("OnComplete() handle = %d; want 3", r.Handle)
+		}
+		if want := filepath.Join(dir, "DSCF0001.JPG"); r.Path != want {
+			t.Errorf("OnComplete() path = %s; want %s", r.Path, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("OnComplete() not called")
+	}
+}
+
+func TestDownloader_addedObjects(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("4b3a2918-0f7e-46d5-a4c3-b2a190f8e7d6"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDownloader(c, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fuji := &FujiEventPacket{EventCode: EC_Fuji_ObjectAdded, TransactionID: 6, Parameter1: 6}
+	check := []struct {
+		name  string
+		evt   EventPacket
+		known map[ptp.ObjectHandle]struct{}
+		want  []ptp.ObjectHandle
+	}{
+		{"generic", &GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 3}}, nil, []ptp.ObjectHandle{3}},
+		{"other event", &GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_CaptureComplete}}, nil, nil},
+		// Without knowing which objects were present, the highest handle is considered to be the new one.
+		{"fuji first", fuji, nil, []ptp.ObjectHandle{4}},
+		{"fuji", fuji, map[ptp.ObjectHandle]struct{}{1: {}, 2: {}}, []ptp.ObjectHandle{3, 4}},
+		{"fuji nothing new", fuji, map[ptp.ObjectHandle]struct{}{1: {}, 2: {}, 3: {}, 4: {}}, nil},
+	}
+	for _, tt := range check {
+		d.known = tt.known
+		got, err := d.addedObjects(tt.evt)
+		if err != nil {
+			t.Errorf("addedObjects() %s err = %s; want <nil>", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("addedObjects() %s got = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloader_nextSequence(t *testing.T) {
+	d := &Downloader{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.nextSequence()
+		}()
+	}
+	wg.Wait()
+
+	if got := d.nextSequence(); got != 11 {
+		t.Errorf("nextSequence() = %d; want 11", got)
+	}
+}
+
+func TestDownloadTemplateData(t *testing.T) {
+	check := []struct {
+		filename string
+		base     string
+		ext      string
+	}{
+		{"DSCF0001.JPG", "DSCF0001", "JPG"},
+		{"DSCF0001.tar.gz", "DSCF0001.tar", "gz"},
+		{"README", "README", ""},
+	}
+	for _, c := range check {
+		d := DownloadTemplateData{ObjectInfo: &ptp.ObjectInfo{Filename: c.filename}}
+		if got := d.Basename(); got != c.base {
+			t.Errorf("Basename() for %s got = %s; want %s", c.filename, got, c.base)
+		}
+		if got := d.Extension(); got != c.ext {
+			t.Errorf("Extension() for %s got = %s; want %s", c.filename, got, c.ext)
+		}
+	}
+}
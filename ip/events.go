@@ -0,0 +1,290 @@
+package ip
+
+import "github.com/malc0mn/ptp-ip/ptp"
+
+const (
+	// DefaultEventBufferSize is the number of events the channel returned by Events() can hold.
+	DefaultEventBufferSize = 32
+	// DefaultEventHistorySize is the number of events returned by RecentEvents().
+	DefaultEventHistorySize = 64
+)
+
+// Events returns a buffered channel receiving all events the Responder sends on the event connection. When the consumer
+// is not keeping up, the oldest event in the channel is dropped to make room for the new one. The channel is closed
+// when the client is closed: call Events() again after dialing again to receive the events of the new connection.
+func (c *Client) Events() <-chan EventPacket {
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	if c.events == nil {
+		c.events = make(chan EventPacket, DefaultEventBufferSize)
+	}
+
+	return c.events
+}
+
+// deliverEvent sends the event to the channel returned by Events(), if it was requested. The caller must hold
+// eventSubsMu.
+func (c *Client) deliverEvent(p EventPacket) {
+	if c.events == nil {
+		return
+	}
+
+	if sendDropOldest(c.events, p) {
+		c.Warnf("[eventListener] events channel full, dropping oldest event")
+	}
+}
+
+// sendDropOldest sends the event to the channel, dropping the oldest event in the channel when it is full. It returns
+// true when an event was dropped.
+func sendDropOldest(ch chan EventPacket, p EventPacket) bool {
+	dropped := false
+	for {
+		select {
+		case ch <- p:
+			return dropped
+		default:
+			select {
+			case <-ch:
+				dropped = true
+			default:
+			}
+		}
+	}
+}
+
+// closeEvents closes the channel returned by Events() and the channels returned by Subscribe(). Events received
+// afterwards are no longer delivered to them.
+func (c *Client) closeEvents() {
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	if c.events != nil {
+		close(c.events)
+		c.events = nil
+	}
+	for ch, sub := range c.eventSubs {
+		sub.close()
+		delete(c.eventSubs, ch)
+	}
+}
+
+// eventRing holds the last events received, overwriting the oldest event when full.
+type eventRing struct {
+	buf  []EventPacket
+	next int
+	full bool
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{buf: make([]EventPacket, size)}
+}
+
+func (r *eventRing) add(p EventPacket) {
+	if len(r.buf) == 0 {
+		return
+	}
+
+	r.buf[r.next] = p
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the events in the order they were received.
+func (r *eventRing) list() []EventPacket {
+	if !r.full {
+		return append([]EventPacket{}, r.buf[:r.next]...)
+	}
+
+	return append(append([]EventPacket{}, r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+// RecentEvents returns the last events received from the Responder, oldest first, allowing a consumer that subscribed
+// late, e.g. a UI connecting after a capture was started, to catch up on what happened. The history is kept when
+// reconnecting and holds DefaultEventHistorySize events unless changed using SetEventHistorySize().
+func (c *Client) RecentEvents() []EventPacket {
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	return c.recentEvents.list()
+}
+
+// SetEventHistorySize sets the number of events returned by RecentEvents(), keeping the most recent events already
+// recorded. A size of 0 disables the history.
+func (c *Client) SetEventHistorySize(size int) {
+	if size < 0 {
+		size = 0
+	}
+
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	r := newEventRing(size)
+	for _, p := range c.recentEvents.list() {
+		r.add(p)
+	}
+	c.recentEvents = r
+}
+
+// eventFilter holds the event codes a subscriber is interested in. A nil filter matches all events.
+type eventFilter map[ptp.EventCode]struct{}
+
+func (f eventFilter) matches(code ptp.EventCode) bool {
+	if f == nil {
+		return true
+	}
+	_, ok := f[code]
+
+	return ok
+}
+
+// Backpressure defines what happens to an event when the channel of a subscriber that is not keeping up is full. The
+// event listener is never stalled by a subscriber, whatever the policy.
+type Backpressure int
+
+const (
+	// BackpressureDropNewest drops the event that does not fit in the channel. This is what Subscribe() does.
+	BackpressureDropNewest Backpressure = iota
+	// BackpressureDropOldest drops the oldest event in the channel to make room for the new one, like Events() does.
+	BackpressureDropOldest
+	// BackpressureBlock never drops an event: the events that do not fit in the channel are queued in memory by a
+	// goroutine dedicated to the subscriber, which blocks until the subscriber reads them. A subscriber that stops
+	// reading makes the queue grow without bounds, so make sure to call Unsubscribe() when done.
+	BackpressureBlock
+)
+
+// eventSubscription holds the events a subscriber is interested in and how they are delivered.
+type eventSubscription struct {
+	filter eventFilter
+	policy Backpressure
+	// ch is the channel returned by Subscribe().
+	ch chan EventPacket
+	// queue and done are used by the goroutine delivering the events of a BackpressureBlock subscription.
+	queue chan EventPacket
+	done  chan struct{}
+}
+
+// deliver sends the event to the subscriber according to its backpressure policy. The caller must hold eventSubsMu.
+func (s *eventSubscription) deliver(c *Client, p EventPacket) {
+	switch s.policy {
+	case BackpressureBlock:
+		s.queue <- p
+	case BackpressureDropOldest:
+		if sendDropOldest(s.ch, p) {
+			c.Warnf("[eventListener] subscriber not ready, dropping oldest event")
+		}
+	default:
+		select {
+		case s.ch <- p:
+		default:
+			c.Warnf("[eventListener] subscriber not ready, dropping event '%#x'", p.GetEventCode())
+		}
+	}
+}
+
+// close closes the channel returned by Subscribe(). For a BackpressureBlock subscription, the channel is closed by the
+// goroutine delivering the events once it stopped. The caller must hold eventSubsMu.
+func (s *eventSubscription) close() {
+	if s.policy == BackpressureBlock {
+		close(s.done)
+		return
+	}
+	close(s.ch)
+}
+
+// runQueue delivers the queued events of a BackpressureBlock subscription in order until the subscription is closed.
+func (s *eventSubscription) runQueue() {
+	defer close(s.ch)
+
+	var pending []EventPacket
+	for {
+		var (
+			out  chan EventPacket
+			next EventPacket
+		)
+		if len(pending) > 0 {
+			out, next = s.ch, pending[0]
+		}
+		select {
+		case p := <-s.queue:
+			pending = append(pending, p)
+		case out <- next:
+			pending[0] = nil
+			pending = pending[1:]
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Subscribe returns a dedicated buffered channel receiving the events with one of the given codes, or all events when
+// no codes are given. This allows e.g. a viewfinder to watch ptp.EC_DevicePropChanged while a downloader independently
+// watches ptp.EC_ObjectAdded. Events are never blocked on: when the channel is full, the event is dropped. The channel
+// is closed by calling Unsubscribe() or when the client is closed.
+func (c *Client) Subscribe(codes ...ptp.EventCode) <-chan EventPacket {
+	return c.SubscribeWithBackpressure(BackpressureDropNewest, codes...)
+}
+
+// SubscribeWithBackpressure does the same as Subscribe but allows choosing what happens to the events when the channel
+// is full.
+func (c *Client) SubscribeWithBackpressure(bp Backpressure, codes ...ptp.EventCode) <-chan EventPacket {
+	var f eventFilter
+	if len(codes) > 0 {
+		f = make(eventFilter, len(codes))
+		for _, code := range codes {
+			f[code] = struct{}{}
+		}
+	}
+
+	s := &eventSubscription{
+		filter: f,
+		policy: bp,
+		ch:     make(chan EventPacket, DefaultEventBufferSize),
+	}
+	if bp == BackpressureBlock {
+		s.queue = make(chan EventPacket, DefaultEventBufferSize)
+		s.done = make(chan struct{})
+		go s.runQueue()
+	}
+
+	c.eventSubsMu.Lock()
+	c.eventSubs[s.ch] = s
+	c.eventSubsMu.Unlock()
+
+	return s.ch
+}
+
+// Unsubscribe stops delivering events to a channel returned by Subscribe() and closes it.
+func (c *Client) Unsubscribe(ch <-chan EventPacket) {
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	if sub, ok := c.eventSubs[ch]; ok {
+		sub.close()
+		delete(c.eventSubs, ch)
+	}
+}
+
+// OnEvent registers a function that is called for each event with the given code, as an alternative to reading the
+// events from a channel. Handlers are called in the order they were registered from the goroutine reading the event
+// connection, so they should return quickly. A panicking handler is recovered from and logged.
+func (c *Client) OnEvent(code ptp.EventCode, f func(EventPacket)) {
+	c.eventSubsMu.Lock()
+	defer c.eventSubsMu.Unlock()
+
+	c.eventHandlers[code] = append(c.eventHandlers[code], f)
+}
+
+// callEventHandler calls the event handler, recovering from a panic so that a faulty handler does not stop the event
+// listener.
+func (c *Client) callEventHandler(f func(EventPacket), p EventPacket) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.Errorf("[eventListener] handler for event '%#x' panicked: %v", p.GetEventCode(), r)
+		}
+	}()
+
+	f(p)
+}
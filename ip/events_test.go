@@ -0,0 +1,253 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_Events(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("8f9a0b1c-2d3e-4f4a-9b5c-6d7e8f9a0b1c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := c.Events()
+	if got := c.Events(); got != events {
+		t.Error("Events() returned a different channel on the second call")
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 3}}, "[test]")
+	select {
+	case evt := <-events:
+		if got, want := evt.Typed(), (ptp.ObjectAddedEvent{Handle: 3}); got != want {
+			t.Errorf("Typed() = %#v; want %#v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received from Events()")
+	}
+
+	c.Close()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Events() channel received an event; want it closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("Events() channel not closed after Close()")
+	}
+
+	if got := c.Events(); got == events {
+		t.Error("Events() returned the closed channel after Close()")
+	}
+}
+
+func TestClient_deliverEventFull(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9a0b1c2d-3e4f-4a5b-8c6d-7e8f9a0b1c2d"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := c.Events()
+	c.eventSubsMu.Lock()
+	for i := 0; i <= DefaultEventBufferSize; i++ {
+		c.deliverEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: uint32(i)}})
+	}
+	c.eventSubsMu.Unlock()
+
+	if got := len(events); got != DefaultEventBufferSize {
+		t.Fatalf("len(Events()) = %d; want %d", got, DefaultEventBufferSize)
+	}
+	if evt := (<-events).(*GenericEventPacket); evt.Parameter1 != 1 {
+		t.Errorf("oldest event Parameter1 = %d; want 1", evt.Parameter1)
+	}
+}
+
+func TestClient_RecentEvents(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithEventHistorySize(3), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.RecentEvents(); len(got) != 0 {
+		t.Errorf("RecentEvents() = %v; want none", got)
+	}
+
+	c.eventSubsMu.Lock()
+	for i := 0; i < 5; i++ {
+		c.recentEvents.add(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: uint32(i)}})
+	}
+	c.eventSubsMu.Unlock()
+
+	check := func(want ...uint32) {
+		t.Helper()
+		got := c.RecentEvents()
+		if len(got) != len(want) {
+			t.Fatalf("len(RecentEvents()) = %d; want %d", len(got), len(want))
+		}
+		for i, evt := range got {
+			if p := evt.(*GenericEventPacket).Parameter1; p != want[i] {
+				t.Errorf("RecentEvents() event %d Parameter1 = %d; want %d", i, p, want[i])
+			}
+		}
+	}
+
+	check(2, 3, 4)
+	c.SetEventHistorySize(2)
+	check(3, 4)
+	c.SetEventHistorySize(4)
+	check(3, 4)
+	c.SetEventHistorySize(0)
+	check()
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("0b1c2d3e-4f5a-4b6c-9d7e-8f9a0b1c2d3e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	props := c.Subscribe(ptp.EC_DevicePropChanged)
+	objects := c.Subscribe(ptp.EC_ObjectAdded, ptp.EC_ObjectRemoved)
+	all := c.Subscribe()
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 3}}, "[test]")
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_DevicePropChanged, Parameter1: uint32(ptp.DPC_BatteryLevel)}}, "[test]")
+
+	check := []struct {
+		name string
+		ch   <-chan EventPacket
+		want []ptp.EventCode
+	}{
+		{"props", props, []ptp.EventCode{ptp.EC_DevicePropChanged}},
+		{"objects", objects, []ptp.EventCode{ptp.EC_ObjectAdded}},
+		{"all", all, []ptp.EventCode{ptp.EC_ObjectAdded, ptp.EC_DevicePropChanged}},
+	}
+	for _, tt := range check {
+		for _, want := range tt.want {
+			select {
+			case evt := <-tt.ch:
+				if evt.GetEventCode() != want {
+					t.Errorf("%s event code = %#x; want %#x", tt.name, evt.GetEventCode(), want)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s no event received; want %#x", tt.name, want)
+			}
+		}
+	}
+
+	c.Unsubscribe(props)
+	if _, ok := <-props; ok {
+		t.Error("Unsubscribe() did not close the channel")
+	}
+	c.Unsubscribe(props)
+
+	c.Close()
+	for _, ch := range []<-chan EventPacket{objects, all} {
+		if _, ok := <-ch; ok {
+			t.Error("Close() did not close the subscribed channel")
+		}
+	}
+}
+
+func TestClient_SubscribeWithBackpressure(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := DefaultEventBufferSize + 2
+	check := []struct {
+		bp    Backpressure
+		want  int
+		first uint32
+	}{
+		{BackpressureDropNewest, DefaultEventBufferSize, 0},
+		{BackpressureDropOldest, DefaultEventBufferSize, 2},
+		{BackpressureBlock, n, 0},
+	}
+	for _, tt := range check {
+		ch := c.SubscribeWithBackpressure(tt.bp, ptp.EC_ObjectAdded)
+		c.eventSubsMu.Lock()
+		sub := c.eventSubs[ch]
+		for i := 0; i < n; i++ {
+			sub.deliver(c, &GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: uint32(i)}})
+		}
+		c.eventSubsMu.Unlock()
+
+		for i := 0; i < tt.want; i++ {
+			select {
+			case evt := <-ch:
+				if got, want := evt.(*GenericEventPacket).Parameter1, tt.first+uint32(i); got != want {
+					t.Errorf("backpressure %d event %d Parameter1 = %d; want %d", tt.bp, i, got, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("backpressure %d received %d events; want %d", tt.bp, i, tt.want)
+			}
+		}
+		select {
+		case evt := <-ch:
+			t.Errorf("backpressure %d received extra event %#v", tt.bp, evt)
+		default:
+		}
+
+		c.Unsubscribe(ch)
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("backpressure %d channel received an event; want it closed", tt.bp)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("backpressure %d channel not closed after Unsubscribe()", tt.bp)
+		}
+	}
+}
+
+func TestClient_OnEvent(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("1c2d3e4f-5a6b-4c7d-8e8f-9a0b1c2d3e4f"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan ptp.TypedEvent, 2)
+	c.OnEvent(ptp.EC_ObjectAdded, func(p EventPacket) {
+		panic("faulty handler")
+	})
+	c.OnEvent(ptp.EC_ObjectAdded, func(p EventPacket) {
+		got <- p.Typed()
+	})
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_DevicePropChanged, Parameter1: uint32(ptp.DPC_BatteryLevel)}}, "[test]")
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 4}}, "[test]")
+	select {
+	case evt := <-got:
+		if want := (ptp.ObjectAddedEvent{Handle: 4}); evt != want {
+			t.Errorf("handler event = %#v; want %#v", evt, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+
+	// The event listener survived the panicking handler.
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 3}}, "[test]")
+	select {
+	case evt := <-got:
+		if want := (ptp.ObjectAddedEvent{Handle: 3}); evt != want {
+			t.Errorf("handler event = %#v; want %#v", evt, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called after a handler panicked")
+	}
+}
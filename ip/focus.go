@@ -0,0 +1,152 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"math"
+)
+
+// FocusDirection is the direction to drive the focus of the lens in.
+type FocusDirection uint8
+
+const (
+	// FocusNear drives the focus towards the closest focusing distance.
+	FocusNear FocusDirection = iota + 1
+	// FocusFar drives the focus towards infinity.
+	FocusFar
+)
+
+const (
+	// OC_Nikon_MfDrive drives the focus of the lens. Parameter1 holds the direction: 1 for near and 2 for infinity.
+	// Parameter2 holds the amount of steps to drive, from 1 to 32767. Nikon devices only accept it while the live view
+	// is active.
+	OC_Nikon_MfDrive ptp.OperationCode = 0x9204
+	// OC_Nikon_ChangeAfArea moves the focus area to the coordinates in Parameter1 and Parameter2, expressed in pixels
+	// of the live view image.
+	OC_Nikon_ChangeAfArea ptp.OperationCode = 0x9205
+	// OC_Canon_EOS_DriveLens drives the focus of the lens a single step. Parameter1 holds the direction and the size of
+	// the step: 0x0001 to 0x0003 for small to large steps towards near and 0x8001 to 0x8003 towards infinity.
+	OC_Canon_EOS_DriveLens ptp.OperationCode = 0x9155
+)
+
+// DriveFocus drives the focus of the lens the given amount of steps in the given direction, which requires the lens
+// to be in manual focus mode for most devices. The size of a step depends on the device.
+func (c *Client) DriveFocus(dir FocusDirection, steps uint16) error {
+	return c.DriveFocusContext(context.Background(), dir, steps)
+}
+
+// DriveFocusContext does the same as DriveFocus but aborts as soon as the context is done.
+func (c *Client) DriveFocusContext(ctx context.Context, dir FocusDirection, steps uint16) error {
+	if dir != FocusNear && dir != FocusFar {
+		return fmt.Errorf("invalid focus direction %d", dir)
+	}
+	if steps == 0 {
+		return nil
+	}
+
+	return c.vendorExtensions.driveFocus(ctx, c, dir, steps)
+}
+
+// SetFocusPoint moves the focus area to the given coordinates. The coordinates depend on the device: Fuji devices use
+// the position in the grid of focus points, Nikon devices use pixels of the live view image.
+func (c *Client) SetFocusPoint(x, y uint16) error {
+	return c.SetFocusPointContext(context.Background(), x, y)
+}
+
+// SetFocusPointContext does the same as SetFocusPoint but aborts as soon as the context is done.
+func (c *Client) SetFocusPointContext(ctx context.Context, x, y uint16) error {
+	return c.vendorExtensions.setFocusPoint(ctx, c, x, y)
+}
+
+// ResetFocusPoint moves the focus area back to the default position of the device.
+func (c *Client) ResetFocusPoint() error {
+	return c.ResetFocusPointContext(context.Background())
+}
+
+// ResetFocusPointContext does the same as ResetFocusPoint but aborts as soon as the context is done.
+func (c *Client) ResetFocusPointContext(ctx context.Context) error {
+	return c.vendorExtensions.resetFocusPoint(ctx, c)
+}
+
+// GenericDriveFocus drives the focus using the vendor operation of Nikon and Canon devices. PTP itself does not define
+// an operation to drive the focus.
+func GenericDriveFocus(ctx context.Context, c *Client, dir FocusDirection, steps uint16) error {
+	switch c.ResponderVendor() {
+	case ptp.VE_NikonCorporation:
+		if steps > math.MaxInt16 {
+			steps = math.MaxInt16
+		}
+		_, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.OperationRequest{
+			OperationCode: OC_Nikon_MfDrive,
+			Parameter1:    uint32(dir),
+			Parameter2:    uint32(steps),
+		})
+		return err
+	case ptp.VE_CanonInc:
+		param := uint32(0x0001)
+		if dir == FocusFar {
+			param = 0x8001
+		}
+		for i := uint16(0); i < steps; i++ {
+			if _, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.OperationRequest{
+				OperationCode: OC_Canon_EOS_DriveLens,
+				Parameter1:    param,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return errors.New("command not YET supported")
+}
+
+// GenericSetFocusPoint moves the focus area using the vendor operation of Nikon devices. PTP itself does not define a
+// property or an operation to set the focus area.
+func GenericSetFocusPoint(ctx context.Context, c *Client, x, y uint16) error {
+	if c.ResponderVendor() != ptp.VE_NikonCorporation {
+		return errors.New("command not YET supported")
+	}
+
+	_, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.OperationRequest{
+		OperationCode: OC_Nikon_ChangeAfArea,
+		Parameter1:    uint32(x),
+		Parameter2:    uint32(y),
+	})
+
+	return err
+}
+
+// GenericResetFocusPoint is not supported: there is no known operation to reset the focus area of a generic device.
+func GenericResetFocusPoint(_ context.Context, _ *Client) error {
+	return errors.New("command not YET supported")
+}
+
+// FujiDriveFocus is not supported: there is no known way to drive the focus of a Fuji device.
+func FujiDriveFocus(_ context.Context, _ *Client, _ FocusDirection, _ uint16) error {
+	return errors.New("command not YET supported")
+}
+
+// FujiSetFocusPoint sets the position of the focus point in DPC_Fuji_FocusMeteringMode. The second byte of the value
+// holds the x coordinate and the first byte holds the y coordinate. The meaning of the upper two bytes is unknown, so
+// they are kept as they are.
+func FujiSetFocusPoint(ctx context.Context, c *Client, x, y uint16) error {
+	if x > math.MaxUint8 || y > math.MaxUint8 {
+		return fmt.Errorf("focus point %dx%d out of range", x, y)
+	}
+
+	dpd, err := FujiGetDevicePropertyDesc(ctx, c, DPC_Fuji_FocusMeteringMode)
+	if err != nil {
+		return err
+	}
+	v := uint32(dpd.CurrentValueAsInt64())&0xFFFF0000 | uint32(x)<<8 | uint32(y)
+
+	return FujiSetDeviceProperty(ctx, c, DPC_Fuji_FocusMeteringMode, v)
+}
+
+// FujiResetFocusPoint moves the focus point back to the default position using the OC_Fuji_ResetFocusPoint operation.
+func FujiResetFocusPoint(ctx context.Context, c *Client) error {
+	return FujiSendOperationRequestIgnoreResponse(ctx, c, OC_Fuji_ResetFocusPoint, PM_Fuji_NoParam, 0)
+}
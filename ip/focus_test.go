@@ -0,0 +1,56 @@
+package ip
+
+import (
+	"testing"
+)
+
+func TestClient_SetFocusPoint(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetFocusPoint(4, 3); err != nil {
+		t.Errorf("SetFocusPoint(4, 3) error = %s; want <nil>", err)
+	}
+	if err := c.SetFocusPoint(256, 3); err == nil {
+		t.Error("SetFocusPoint(256, 3) error = <nil>; want out of range")
+	}
+	if err := c.ResetFocusPoint(); err != nil {
+		t.Errorf("ResetFocusPoint() error = %s; want <nil>", err)
+	}
+	if err := c.DriveFocus(FocusNear, 10); err == nil {
+		t.Error("DriveFocus() error = <nil>; want command not YET supported")
+	}
+}
+
+func TestClient_DriveFocus(t *testing.T) {
+	c, err := NewClient(address, WithVendor("nikon"), WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DriveFocus(FocusFar, 100); err != nil {
+		t.Errorf("DriveFocus(FocusFar, 100) error = %s; want <nil>", err)
+	}
+	if err := c.DriveFocus(FocusDirection(3), 1); err == nil {
+		t.Error("DriveFocus(3, 1) error = <nil>; want invalid focus direction")
+	}
+	if err := c.SetFocusPoint(320, 240); err != nil {
+		t.Errorf("SetFocusPoint(320, 240) error = %s; want <nil>", err)
+	}
+	if err := c.ResetFocusPoint(); err == nil {
+		t.Error("ResetFocusPoint() error = <nil>; want command not YET supported")
+	}
+}
@@ -0,0 +1,141 @@
+package ip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// MaxPacketLength is the largest packet length accepted from the Responder. Anything larger is considered to be a
+// corrupt length field.
+const MaxPacketLength uint32 = 256 << 20
+
+var (
+	PacketLengthError    = errors.New("invalid packet length")
+	TruncatedPacketError = errors.New("connection closed in the middle of a packet")
+)
+
+// FramingError is returned when the data received does not form a valid packet. The start of the next packet can no
+// longer be determined after a framing error, so the connection cannot be used anymore.
+type FramingError struct {
+	// Length holds the packet length as it was received.
+	Length uint32
+	// Read holds the number of bytes of the packet that were read.
+	Read int
+	Err  error
+}
+
+func (e *FramingError) Error() string {
+	return fmt.Sprintf("framing error: %s (packet length %d, %d bytes read)", e.Err, e.Length, e.Read)
+}
+
+func (e *FramingError) Unwrap() error {
+	return e.Err
+}
+
+// packetReader reads length prefixed packets from a stream. Packets can be split over several reads and a single read
+// can hold several packets: the reader only ever consumes the number of bytes the length field announces.
+// When a read fails, e.g. because the read deadline was reached, the bytes read so far are kept so the next call to
+// readPacket continues where the previous one stopped instead of reading the remainder of a packet as a new one.
+type packetReader struct {
+	r io.Reader
+	// l holds the length field of the packet being read.
+	l [4]byte
+	// buf holds the packet being read once the length field is complete.
+	buf []byte
+	// n is the number of bytes of the packet read so far, including the length field.
+	n int
+}
+
+func newPacketReader(r io.Reader) *packetReader {
+	return &packetReader{r: r}
+}
+
+// readPacket returns the next complete packet, length field included. When io.EOF is returned, the stream ended neatly
+// in between two packets; a stream ending in the middle of a packet results in a TruncatedPacketError.
+func (pr *packetReader) readPacket() ([]byte, error) {
+	for pr.n < len(pr.l) {
+		n, err := pr.r.Read(pr.l[pr.n:])
+		pr.n += n
+		if err != nil && pr.n < len(pr.l) {
+			return nil, pr.readError(err)
+		}
+	}
+
+	if pr.buf == nil {
+		l := binary.LittleEndian.Uint32(pr.l[:])
+		// The length includes the length field itself, so anything below 4 is invalid.
+		if l < uint32(len(pr.l)) || l > MaxPacketLength {
+			err := &FramingError{Length: l, Read: pr.n, Err: PacketLengthError}
+			pr.reset()
+			return nil, err
+		}
+		pr.buf = make([]byte, l)
+		copy(pr.buf, pr.l[:])
+	}
+
+	for pr.n < len(pr.buf) {
+		n, err := pr.r.Read(pr.buf[pr.n:])
+		pr.n += n
+		if err != nil && pr.n < len(pr.buf) {
+			return nil, pr.readError(err)
+		}
+	}
+
+	p := pr.buf
+	pr.reset()
+
+	return p, nil
+}
+
+// readError converts io.EOF to a TruncatedPacketError when part of a packet was read already.
+func (pr *packetReader) readError(err error) error {
+	if pr.n == 0 || (err != io.EOF && err != io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	ferr := &FramingError{Length: binary.LittleEndian.Uint32(pr.l[:]), Read: pr.n, Err: TruncatedPacketError}
+	if pr.n < len(pr.l) {
+		ferr.Length = 0
+	}
+	pr.reset()
+
+	return ferr
+}
+
+func (pr *packetReader) reset() {
+	pr.buf = nil
+	pr.n = 0
+}
+
+// readPacket reads a single packet from r. Use a packetReader to read from a connection so no data is lost when a read
+// times out.
+func readPacket(r io.Reader) ([]byte, error) {
+	return newPacketReader(r).readPacket()
+}
+
+// packetReaderFor returns the packetReader for the given connection. A new packetReader is created when the connection
+// was replaced.
+func (c *Client) packetReaderFor(conn net.Conn) *packetReader {
+	c.packetReadersMu.Lock()
+	defer c.packetReadersMu.Unlock()
+
+	if pr, ok := c.packetReaders[conn]; ok {
+		return pr
+	}
+
+	// Drop the readers of connections that were closed.
+	cmd, evt, str := c.conn(cmdDataConnection), c.conn(eventConnection), c.conn(streamConnection)
+	for rc := range c.packetReaders {
+		if rc != cmd && rc != evt && rc != str {
+			delete(c.packetReaders, rc)
+		}
+	}
+
+	pr := newPacketReader(conn)
+	c.packetReaders[conn] = pr
+
+	return pr
+}
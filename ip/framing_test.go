@@ -0,0 +1,104 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// stutterReader returns the chunks one by one, returning err in between each chunk.
+type stutterReader struct {
+	chunks [][]byte
+	err    error
+	fail   bool
+}
+
+func (sr *stutterReader) Read(p []byte) (int, error) {
+	if len(sr.chunks) == 0 {
+		return 0, io.EOF
+	}
+	if sr.fail = !sr.fail; sr.fail {
+		return 0, sr.err
+	}
+
+	n := copy(p, sr.chunks[0])
+	if sr.chunks[0] = sr.chunks[0][n:]; len(sr.chunks[0]) == 0 {
+		sr.chunks = sr.chunks[1:]
+	}
+
+	return n, nil
+}
+
+func TestPacketReader_readPacket(t *testing.T) {
+	p1 := []byte{0x0a, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+	p2 := []byte{0x04, 0x00, 0x00, 0x00}
+
+	// Packets split in single bytes and coalesced in one read.
+	pr := newPacketReader(iotest.OneByteReader(bytes.NewReader(append(append([]byte{}, p1...), p2...))))
+	for _, want := range [][]byte{p1, p2} {
+		got, err := pr.readPacket()
+		if err != nil {
+			t.Fatalf("readPacket() err = %s; want <nil>", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("readPacket() = %v; want %v", got, want)
+		}
+	}
+	if _, err := pr.readPacket(); err != io.EOF {
+		t.Errorf("readPacket() err = %v; want %s", err, io.EOF)
+	}
+
+	// A failing read must not lose the part of the packet that was read already.
+	timeout := errors.New("i/o timeout")
+	pr = newPacketReader(&stutterReader{chunks: [][]byte{p1[:2], p1[2:7], append(p1[7:], p2...)}, err: timeout})
+	for _, want := range [][]byte{p1, p2} {
+		var got []byte
+		var err error
+		for i := 0; i < 10; i++ {
+			if got, err = pr.readPacket(); err != timeout {
+				break
+			}
+		}
+		if err != nil {
+			t.Fatalf("readPacket() err = %s; want <nil>", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("readPacket() = %v; want %v", got, want)
+		}
+	}
+}
+
+func TestPacketReader_readPacketFramingError(t *testing.T) {
+	check := []struct {
+		raw    []byte
+		err    error
+		length uint32
+	}{
+		{[]byte{0x02, 0x00, 0x00, 0x00}, PacketLengthError, 2},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x01}, PacketLengthError, 0xffffffff},
+		{[]byte{0x0a, 0x00, 0x00, 0x00, 0x01, 0x02}, TruncatedPacketError, 10},
+		{[]byte{0x0a, 0x00}, TruncatedPacketError, 0},
+	}
+
+	for _, tt := range check {
+		_, err := newPacketReader(bytes.NewReader(tt.raw)).readPacket()
+		var ferr *FramingError
+		if !errors.As(err, &ferr) {
+			t.Fatalf("readPacket() err = %v; want *FramingError", err)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("readPacket() err = %s; want %s", err, tt.err)
+		}
+		if ferr.Length != tt.length {
+			t.Errorf("readPacket() FramingError.Length = %d; want %d", ferr.Length, tt.length)
+		}
+	}
+}
+
+func TestParsePacketTooShort(t *testing.T) {
+	if _, _, err := parsePacket([]byte{0x06, 0x00, 0x00, 0x00, 0x01, 0x00}, nil); !errors.Is(err, PacketLengthError) {
+		t.Errorf("parsePacket() err = %v; want %s", err, PacketLengthError)
+	}
+}
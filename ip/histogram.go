@@ -0,0 +1,95 @@
+package ip
+
+import (
+	"image"
+	"image/color"
+)
+
+// Histogram holds the number of pixels per luminance level and per level of each of the RGB channels of an image,
+// useful for checking the exposure over a remote link.
+type Histogram struct {
+	Luminance [256]uint32 `json:"luminance"`
+	Red       [256]uint32 `json:"red"`
+	Green     [256]uint32 `json:"green"`
+	Blue      [256]uint32 `json:"blue"`
+}
+
+// NewHistogram computes the histogram of the image. The luminance is computed as defined by ITU-R BT.601, which is what
+// JPEG images use.
+func NewHistogram(img image.Image) *Histogram {
+	h := &Histogram{}
+	b := img.Bounds()
+
+	switch img := img.(type) {
+	case *image.YCbCr:
+		// Live view frames are JPEG images which decode to YCbCr: the luminance is right there.
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				yy := img.Y[img.YOffset(x, y)]
+				ci := img.COffset(x, y)
+				r, g, bl := color.YCbCrToRGB(yy, img.Cb[ci], img.Cr[ci])
+				h.add(yy, r, g, bl)
+			}
+		}
+	case *image.RGBA:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				p := img.Pix[img.PixOffset(x, y):]
+				h.add(luminance(p[0], p[1], p[2]), p[0], p[1], p[2])
+			}
+		}
+	default:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				h.add(luminance(c.R, c.G, c.B), c.R, c.G, c.B)
+			}
+		}
+	}
+
+	return h
+}
+
+func (h *Histogram) add(y, r, g, b uint8) {
+	h.Luminance[y]++
+	h.Red[r]++
+	h.Green[g]++
+	h.Blue[b]++
+}
+
+// Max returns the highest pixel count of all levels of all channels, which is useful to scale the histogram when
+// drawing it.
+func (h *Histogram) Max() uint32 {
+	var max uint32
+	for _, ch := range [][256]uint32{h.Luminance, h.Red, h.Green, h.Blue} {
+		for _, n := range ch {
+			if n > max {
+				max = n
+			}
+		}
+	}
+
+	return max
+}
+
+// luminance returns the luma of the RGB colour as defined by ITU-R BT.601.
+func luminance(r, g, b uint8) uint8 {
+	return uint8((299*uint32(r) + 587*uint32(g) + 114*uint32(b) + 500) / 1000)
+}
+
+// HistogramLiveView computes the histogram of each frame and stores it in the Histogram field of the frame before
+// passing the frame on. The returned channel is closed when the frames channel is closed.
+func HistogramLiveView(frames <-chan *LiveViewFrame) <-chan *LiveViewFrame {
+	out := make(chan *LiveViewFrame)
+	go func() {
+		defer close(out)
+		for f := range frames {
+			if f.Image != nil {
+				f.Histogram = NewHistogram(f.Image)
+			}
+			out <- f
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,70 @@
+package ip
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestNewHistogram(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+		img.Set(x, 1, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+
+	got := NewHistogram(img)
+	check := []struct {
+		name string
+		got  uint32
+		want uint32
+	}{
+		{"red 255", got.Red[255], 8},
+		{"green 0", got.Green[0], 4},
+		{"green 255", got.Green[255], 4},
+		{"luminance 76", got.Luminance[76], 4},
+		{"luminance 255", got.Luminance[255], 4},
+		{"max", got.Max(), 8},
+	}
+	for _, c := range check {
+		if c.got != c.want {
+			t.Errorf("NewHistogram() %s = %d; want %d", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestNewHistogramYCbCr(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	dec, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dec.(*image.YCbCr); !ok {
+		t.Fatalf("jpeg.Decode() = %T; want *image.YCbCr", dec)
+	}
+
+	got := NewHistogram(dec)
+	if got.Luminance[0] != 256 || got.Red[0] != 256 {
+		t.Errorf("NewHistogram() luminance[0] = %d, red[0] = %d; want 256", got.Luminance[0], got.Red[0])
+	}
+}
+
+func TestHistogramLiveView(t *testing.T) {
+	frames := make(chan *LiveViewFrame, 1)
+	frames <- &LiveViewFrame{Sequence: 1, Image: image.NewRGBA(image.Rect(0, 0, 2, 2))}
+	close(frames)
+
+	out := HistogramLiveView(frames)
+	f := <-out
+	if f.Histogram == nil || f.Histogram.Luminance[0] != 4 {
+		t.Errorf("HistogramLiveView() Histogram = %v; want 4 black pixels", f.Histogram)
+	}
+	if _, ok := <-out; ok {
+		t.Error("HistogramLiveView() channel not closed after closing the frames channel")
+	}
+}
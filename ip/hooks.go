@@ -0,0 +1,94 @@
+package ip
+
+import (
+	"io"
+	"net"
+)
+
+// SendHook is called for each packet before it is sent to the Responder. It receives the name of the connection the
+// packet is sent on ("cmd", "event" or "stream"), the packet itself and the raw packet, length field included. The
+// bytes returned are sent instead of the raw packet, which allows altering packets for experiments: simply return raw
+// to send the packet as is. The packet is nil for raw packets sent using RawConn.SendRaw().
+type SendHook func(conn string, p PacketOut, raw []byte) []byte
+
+// ReceiveHook is called for each packet received from the Responder, before the client processes it. It receives the
+// name of the connection the packet was received on ("cmd", "event" or "stream"), the decoded packet and the raw packet,
+// length field included. The decoded packet is nil when the packet cannot be decoded without knowing the context, as is
+// the case for vendor specific packets lacking a packet type field. The bytes returned are processed instead of the raw
+// packet: simply return raw to leave the packet untouched.
+type ReceiveHook func(conn string, p PacketIn, raw []byte) []byte
+
+// OnSend registers a hook which is called for each packet sent, e.g. to log or count the packets or to alter them when
+// reverse engineering the protocol. Hooks are called in the order they were registered and must not send packets
+// themselves.
+func (c *Client) OnSend(h SendHook) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	c.sendHooks = append(c.sendHooks, h)
+}
+
+// OnReceive registers a hook which is called for each packet received. Hooks are called in the order they were
+// registered from the goroutine reading the connection, so they should return quickly.
+func (c *Client) OnReceive(h ReceiveHook) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+
+	c.receiveHooks = append(c.receiveHooks, h)
+}
+
+func (c *Client) runSendHooks(t connectionType, p PacketOut, raw []byte) []byte {
+	c.hooksMu.Lock()
+	hooks := c.sendHooks
+	c.hooksMu.Unlock()
+
+	for _, h := range hooks {
+		raw = h(string(t), p, raw)
+	}
+
+	return raw
+}
+
+func (c *Client) runReceiveHooks(t connectionType, raw []byte) []byte {
+	c.hooksMu.Lock()
+	hooks := c.receiveHooks
+	c.hooksMu.Unlock()
+
+	for _, h := range hooks {
+		// Each hook gets to see the packet as altered by the previous hooks.
+		p, _, err := parsePacket(raw, nil)
+		if err != nil {
+			p = nil
+		}
+		raw = h(string(t), p, raw)
+	}
+
+	return raw
+}
+
+// receivePacket reads the next packet from the connection and passes it through the receive hooks.
+func (c *Client) receivePacket(conn net.Conn) ([]byte, error) {
+	raw, err := c.packetReaderFor(conn).readPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.runReceiveHooks(c.connectionTypeOf(conn), raw), nil
+}
+
+// connectionTypeOf returns the type of the given connection or an empty string when w is not one of the connections
+// to the Responder.
+func (c *Client) connectionTypeOf(w io.Writer) connectionType {
+	switch {
+	case w == nil:
+		return ""
+	case w == c.conn(cmdDataConnection):
+		return cmdDataConnection
+	case w == c.conn(eventConnection):
+		return eventConnection
+	case w == c.conn(streamConnection):
+		return streamConnection
+	}
+
+	return ""
+}
@@ -0,0 +1,101 @@
+package ip
+
+import (
+	"encoding/binary"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sync"
+	"testing"
+)
+
+func TestClient_OnSendOnReceive(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("7b8c9d0e-1f2a-4b3c-8d4e-5f6a7b8c9d0e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	sent := make(map[string][]PacketType)
+	received := make(map[string][]PacketType)
+	c.OnSend(func(conn string, p PacketOut, raw []byte) []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		if pt := PacketType(binary.LittleEndian.Uint32(raw[4:8])); pt != p.PacketType() {
+			t.Errorf("OnSend() raw packet type = %#x; want %#x", pt, p.PacketType())
+		}
+		sent[conn] = append(sent[conn], p.PacketType())
+		return raw
+	})
+	c.OnReceive(func(conn string, p PacketIn, raw []byte) []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		if p == nil {
+			t.Errorf("OnReceive() packet = <nil>; want decoded packet for %v", raw)
+			return raw
+		}
+		received[conn] = append(received[conn], p.PacketType())
+		return raw
+	})
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	// The Responder is free to send events right after the handshake, so only the first event packet is checked.
+	if len(received["event"]) > 1 {
+		received["event"] = received["event"][:1]
+	}
+	check := []struct {
+		name string
+		got  []PacketType
+		want []PacketType
+	}{
+		{"sent cmd", sent["cmd"], []PacketType{PKT_InitCommandRequest, PKT_OperationRequest}},
+		{"sent event", sent["event"], []PacketType{PKT_InitEventRequest}},
+		{"received cmd", received["cmd"], []PacketType{PKT_InitCommandAck, PKT_OperationResponse}},
+		{"received event", received["event"], []PacketType{PKT_InitEventAck}},
+	}
+	mu.Unlock()
+
+	for _, tt := range check {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s packets = %#x; want %#x", tt.name, tt.got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if tt.got[i] != tt.want[i] {
+				t.Errorf("%s packet %d = %#x; want %#x", tt.name, i, tt.got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestClient_OnSendAlterPacket(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("8c9d0e1f-2a3b-4c4d-9e5f-6a7b8c9d0e1f"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Request the battery level whenever the focus mode, which the mock does not support, is requested.
+	c.OnSend(func(conn string, p PacketOut, raw []byte) []byte {
+		orp, ok := p.(*OperationRequestPacket)
+		if !ok || orp.Parameter1 != uint32(ptp.DPC_FocusMode) ||
+			(orp.OperationCode != ptp.OC_GetDevicePropDesc && orp.OperationCode != ptp.OC_GetDevicePropValue) {
+			return raw
+		}
+		alt := append([]byte{}, raw...)
+		// Header (8), data phase (4), operation code (2) and transaction ID (4) come before the first parameter.
+		binary.LittleEndian.PutUint32(alt[18:22], uint32(ptp.DPC_BatteryLevel))
+		return alt
+	})
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_FocusMode); err != nil {
+		t.Errorf("GetDevicePropertyValue() err = %s; want <nil>", err)
+	}
+}
@@ -0,0 +1,131 @@
+package ip
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/malc0mn/ptp-ip/ip/internal"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// IdentityStore persists the identity the client uses per Responder. Responders such as Fuji devices remember the
+// clients they were paired with by GUID and friendly name: reusing the same identity prevents the user from having to
+// accept the connection on the camera each time.
+type IdentityStore interface {
+	// LoadIdentity returns the identity saved for the Responder at the given address, nil when there is none.
+	LoadIdentity(responder string) (*Initiator, error)
+	// SaveIdentity saves the identity used for the Responder at the given address.
+	SaveIdentity(responder string, i *Initiator) error
+}
+
+// storedIdentity is the representation of an Initiator in the file of a FileIdentityStore.
+type storedIdentity struct {
+	GUID         uuid.UUID `json:"guid"`
+	FriendlyName string    `json:"friendly_name"`
+}
+
+// FileIdentityStore is an IdentityStore keeping the identities of all Responders in a single JSON file.
+type FileIdentityStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileIdentityStore returns an IdentityStore keeping the identities in the file at the given path.
+func NewFileIdentityStore(path string) *FileIdentityStore {
+	return &FileIdentityStore{Path: path}
+}
+
+// NewDefaultIdentityStore returns an IdentityStore keeping the identities in the ptp-ip directory of the user's
+// configuration directory, e.g. ~/.config/ptp-ip/identities.json on Linux.
+func NewDefaultIdentityStore() (*FileIdentityStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFileIdentityStore(filepath.Join(dir, "ptp-ip", "identities.json")), nil
+}
+
+// LoadIdentity reads the identity for the given Responder from the file. A missing file is not an error.
+func (s *FileIdentityStore) LoadIdentity(responder string) (*Initiator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := ids[responder]
+	if !ok {
+		return nil, nil
+	}
+
+	return &Initiator{GUID: id.GUID, FriendlyName: id.FriendlyName}, nil
+}
+
+// SaveIdentity adds the identity for the given Responder to the file, creating the file and its directory when needed.
+func (s *FileIdentityStore) SaveIdentity(responder string, i *Initiator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.read()
+	if err != nil {
+		return err
+	}
+	ids[responder] = storedIdentity{GUID: i.GUID, FriendlyName: i.FriendlyName}
+
+	b, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+
+	return internal.WriteFileAtomic(s.Path, append(b, '\n'), 0600)
+}
+
+func (s *FileIdentityStore) read() (map[string]storedIdentity, error) {
+	ids := make(map[string]storedIdentity)
+
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &ids); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// SetIdentityStore makes the client reuse the identity saved for the Responder it connects to. When no identity was
+// saved yet, the current identity of the client is saved so it will be reused from now on. The stored identity takes
+// precedence over the GUID and friendly name the client was created with. The Responder is identified by its address,
+// since that is all there is to go by before connecting. This should be done before calling Dial().
+func (c *Client) SetIdentityStore(s IdentityStore) error {
+	c.identities = s
+	if s == nil {
+		return nil
+	}
+
+	id, err := s.LoadIdentity(c.responder.IpAddress)
+	if err != nil {
+		return err
+	}
+	if id == nil {
+		return s.SaveIdentity(c.responder.IpAddress, c.initiator)
+	}
+
+	c.Infof("Reusing identity '%s' with GUID '%s'", id.FriendlyName, id.GUID)
+	c.initiator = id
+
+	return nil
+}
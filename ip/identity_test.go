@@ -0,0 +1,80 @@
+package ip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileIdentityStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewFileIdentityStore(filepath.Join(dir, "ptp-ip", "identities.json"))
+	got, err := s.LoadIdentity("192.168.0.1")
+	if err != nil {
+		t.Errorf("LoadIdentity() err = %s; want <nil>", err)
+	}
+	if got != nil {
+		t.Errorf("LoadIdentity() = %v; want <nil>", got)
+	}
+
+	want, _ := NewInitiator("tèster", "8a9b0c1d-2e3f-4a4b-9c5d-6e7f8a9b0c1d")
+	if err := s.SaveIdentity("192.168.0.1", want); err != nil {
+		t.Fatalf("SaveIdentity() err = %s; want <nil>", err)
+	}
+	other, _ := NewInitiator("", "")
+	if err := s.SaveIdentity("192.168.0.2", other); err != nil {
+		t.Fatalf("SaveIdentity() err = %s; want <nil>", err)
+	}
+
+	got, err = s.LoadIdentity("192.168.0.1")
+	if err != nil {
+		t.Fatalf("LoadIdentity() err = %s; want <nil>", err)
+	}
+	if *got != *want {
+		t.Errorf("LoadIdentity() = %v; want %v", got, want)
+	}
+	if got, _ = s.LoadIdentity("192.168.0.2"); got == nil || *got != *other {
+		t.Errorf("LoadIdentity() = %v; want %v", got, other)
+	}
+}
+
+func TestClient_SetIdentityStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := NewFileIdentityStore(filepath.Join(dir, "identities.json"))
+
+	c1, err := NewClient(address, WithFriendlyName("tèster"), WithIdentityStore(s))
+	if err != nil {
+		t.Fatalf("NewClient() err = %s; want <nil>", err)
+	}
+
+	// The identity generated for the first client must be reused, even when asking for another one.
+	c2, err := NewClient(address, WithFriendlyName("other"), WithGUID("9b0c1d2e-3f4a-4b5c-8d6e-7f8a9b0c1d2e"), WithIdentityStore(s))
+	if err != nil {
+		t.Fatalf("NewClient() err = %s; want <nil>", err)
+	}
+	if c2.InitiatorGUID() != c1.InitiatorGUID() {
+		t.Errorf("InitiatorGUID() = %s; want %s", c2.InitiatorGUID(), c1.InitiatorGUID())
+	}
+	if c2.InitiatorFriendlyName() != "tèster" {
+		t.Errorf("InitiatorFriendlyName() = %s; want tèster", c2.InitiatorFriendlyName())
+	}
+
+	// Another Responder gets its own identity.
+	c3, err := NewClient("192.168.0.1", WithIdentityStore(s))
+	if err != nil {
+		t.Fatalf("NewClient() err = %s; want <nil>", err)
+	}
+	if c3.InitiatorGUID() == c1.InitiatorGUID() {
+		t.Errorf("InitiatorGUID() = %s; want a new GUID", c3.InitiatorGUID())
+	}
+}
@@ -2,17 +2,30 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"fmt"
 	"github.com/malc0mn/ptp-ip/ptp"
 	"io"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
 	"unicode/utf16"
 )
 
+// ptpStringType is used to detect PTP strings which are encoded differently from the strings used in PTP/IP packets.
+var ptpStringType = reflect.TypeOf(ptp.String(""))
+
 func marshal(s interface{}, bo binary.ByteOrder, b *bytes.Buffer) {
+	if v := reflect.Indirect(reflect.ValueOf(s)); v.Kind() == reflect.Slice {
+		marshalArray(v, bo, b)
+		return
+	}
+
 	// binary.Write() can only cope with fixed length values so we'll need to handle anything else ourselves.
 	if _, hasSession := s.(ptp.Session); binary.Size(s) < 0 || hasSession {
 		v := reflect.Indirect(reflect.ValueOf(s))
@@ -24,10 +37,13 @@ func marshal(s interface{}, bo binary.ByteOrder, b *bytes.Buffer) {
 			}
 
 			f := v.Field(i)
-			switch f.Kind() {
-			case reflect.Struct:
+			switch {
+			case f.Type() == ptpStringType:
+				// PTP strings, as opposed to PTP/IP strings, are prefixed with their length.
+				ptp.WriteString(b, f.String())
+			case f.Kind() == reflect.Struct:
 				marshal(f.Addr().Interface(), bo, b)
-			case reflect.String:
+			case f.Kind() == reflect.String:
 				// TODO: the PTP protocol sets a limit of 255 characters per string including the terminating null
 				//  character. We must still enforce this limit here.
 				// A rune in Go is an alias for uint32 but the PTP protocol expects 2 byte Unicode characters according
@@ -35,6 +51,8 @@ func marshal(s interface{}, bo binary.ByteOrder, b *bytes.Buffer) {
 				binary.Write(b, bo, utf16.Encode([]rune(f.String())))
 				// Strings must be null terminated.
 				binary.Write(b, bo, uint16(0))
+			case f.Kind() == reflect.Slice:
+				marshalArray(f, bo, b)
 			default:
 				binary.Write(b, bo, f.Addr().Interface())
 			}
@@ -44,6 +62,13 @@ func marshal(s interface{}, bo binary.ByteOrder, b *bytes.Buffer) {
 	}
 }
 
+// marshalArray writes a slice as a PTP array: a uint32 holding the number of elements followed by the elements. The 128
+// bit data types are supported by using ptp.Int128 or ptp.Uint128 as element type.
+func marshalArray(v reflect.Value, bo binary.ByteOrder, b *bytes.Buffer) {
+	binary.Write(b, bo, uint32(v.Len()))
+	binary.Write(b, bo, v.Interface())
+}
+
 // Marshal data to a byte array, Little Endian formant, for transport.
 func MarshalLittleEndian(s interface{}) []byte {
 	var b bytes.Buffer
@@ -61,6 +86,11 @@ func MarshalLittleEndian(s interface{}) []byte {
 func unmarshal(r io.Reader, s interface{}, l int, vs int, bo binary.ByteOrder) (int, error) {
 	v := reflect.Indirect(reflect.ValueOf(s))
 
+	if v.Kind() == reflect.Slice {
+		n, err := unmarshalArray(r, v, l, bo)
+		return l - n, err
+	}
+
 	for i := 0; i < v.NumField(); i++ {
 		// When a dataset has a SessionID, we must skip it since the PTP/IP protocol does not send it.
 		if v.Type().Field(i).Name == "SessionID" {
@@ -68,14 +98,23 @@ func unmarshal(r io.Reader, s interface{}, l int, vs int, bo binary.ByteOrder) (
 		}
 
 		f := v.Field(i)
-		switch f.Kind() {
-		case reflect.Struct:
+		switch {
+		case f.Type() == ptpStringType:
+			// Count the bytes actually read: the string could lack the terminator or be too long to marshal again.
+			cr := &countingReader{r: r}
+			s, err := ptp.ReadString(cr)
+			if err != nil {
+				return 0, err
+			}
+			f.SetString(s)
+			l -= cr.n
+		case f.Kind() == reflect.Struct:
 			var err error
 			l, err = unmarshal(r, f.Addr().Interface(), l, vs, bo)
 			if err != nil {
 				return 0, err
 			}
-		case reflect.String:
+		case f.Kind() == reflect.String:
 			// The PTP protocol expects 2 byte Unicode characters according to the ISO10646 standard, so we convert
 			// them to string here.
 			b := make([]uint16, vs / 2)
@@ -85,6 +124,12 @@ func unmarshal(r io.Reader, s interface{}, l int, vs int, bo binary.ByteOrder) (
 			// The slice operation happening here is to drop the null terminator.
 			f.SetString(string(utf16.Decode(b[:len(b) - 1])))
 			l -= vs
+		case f.Kind() == reflect.Slice:
+			n, err := unmarshalArray(r, f, l, bo)
+			if err != nil {
+				return 0, err
+			}
+			l -= n
 		default:
 			if err := binary.Read(r, bo, f.Addr().Interface()); err != nil {
 				return 0, err
@@ -100,6 +145,44 @@ func unmarshal(r io.Reader, s interface{}, l int, vs int, bo binary.ByteOrder) (
 	return l, nil
 }
 
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+
+	return n, err
+}
+
+// unmarshalArray reads a PTP array into the slice v. The number of bytes read is returned. The element count is checked
+// against the remaining length l so a corrupt count cannot cause a huge allocation.
+func unmarshalArray(r io.Reader, v reflect.Value, l int, bo binary.ByteOrder) (int, error) {
+	var n uint32
+	if err := binary.Read(r, bo, &n); err != nil {
+		return 0, err
+	}
+
+	es := binary.Size(reflect.Zero(v.Type().Elem()).Interface())
+	if es <= 0 {
+		return 0, fmt.Errorf("unsupported array element type %s", v.Type().Elem())
+	}
+	if size := 4 + int64(n)*int64(es); size > int64(l) {
+		return 0, fmt.Errorf("array of %d bytes exceeds remaining length of %d bytes", size, l)
+	}
+
+	a := reflect.MakeSlice(v.Type(), int(n), int(n))
+	if err := binary.Read(r, bo, a.Interface()); err != nil {
+		return 0, err
+	}
+	v.Set(a)
+
+	return 4 + int(n)*es, nil
+}
+
 // Unmarshal a byte array, Little Endian formant, upon reception.
 // We need a reader, a destination container, the total expected length and a "variable size" integer indicating the
 // variable sized portion of the packet.
@@ -130,8 +213,8 @@ func TotalSizeOfFixedFields(s interface{}) int {
 		for i := 0; i < v.NumField(); i++ {
 			f := v.Field(i)
 			switch f.Kind() {
-			case reflect.String:
-				// Skip string fields, we do not calculate their size.
+			case reflect.String, reflect.Slice:
+				// Skip string and array fields, we do not calculate their size.
 				continue
 			default:
 				tfs += binary.Size(f.Addr().Interface())
@@ -142,22 +225,26 @@ func TotalSizeOfFixedFields(s interface{}) int {
 	return tfs
 }
 
-// A wrapper around net.Dial() that will retry dialing 10 times on a "connection refused" error with a 500ms delay
-// between retries.
-// TODO: make this loop cancelable!
-func RetryDialer(network, address string, timeout time.Duration) (net.Conn, error) {
+// A wrapper around a dial function, such as net.Dialer.DialContext(), that will retry dialing 10 times on a
+// "connection refused" error with a 500ms delay between retries. Each attempt is limited to the given timeout, unless
+// the timeout is 0, and dialing is aborted as soon as the context is done.
+func RetryDialer(ctx context.Context, dial func(ctx context.Context, network, address string) (net.Conn, error), network, address string, timeout time.Duration) (net.Conn, error) {
 	var err error
 	var retries = 10
 	var wait = 500 * time.Millisecond
 	var conn net.Conn
 
 	for {
-		conn, err = net.DialTimeout(network, address, timeout)
+		conn, err = dialWithTimeout(ctx, dial, network, address, timeout)
 		// Insane isn't it? No sentinel errors from net.Dial()!
 		if err != nil && strings.Contains(err.Error(), "connection refused") && retries > 0 {
 			retries--
-			time.Sleep(wait)
-			continue
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
 		}
 		break
 	}
@@ -167,3 +254,37 @@ func RetryDialer(network, address string, timeout time.Duration) (net.Conn, erro
 
 	return conn, nil
 }
+
+func dialWithTimeout(ctx context.Context, dial func(ctx context.Context, network, address string) (net.Conn, error), network, address string, timeout time.Duration) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return dial(ctx, network, address)
+}
+
+// WriteFileAtomic writes the data to a temporary file which then replaces the file at the given path, so a crash can
+// never leave a truncated file behind.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
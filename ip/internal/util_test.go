@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type arrays struct {
+	Code    uint16
+	Values  []uint16
+	Signed  []int32
+	Big     ptp.Int128
+	BigList []ptp.Uint128
+}
+
+func TestMarshalLittleEndian_Arrays(t *testing.T) {
+	in := &arrays{
+		Code:    0x5005,
+		Values:  []uint16{0x0002, 0x8001},
+		Signed:  []int32{},
+		Big:     ptp.Int128{Lo: 0xffffffffffffffff, Hi: 0xffffffffffffffff},
+		BigList: []ptp.Uint128{{Lo: 1, Hi: 2}},
+	}
+
+	want := []byte{
+		0x05, 0x50,
+		0x02, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x80,
+		0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x01, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	got := MarshalLittleEndian(in)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("MarshalLittleEndian() return = %#x, want %#x", got, want)
+	}
+
+	out := new(arrays)
+	xs, err := UnmarshalLittleEndian(bytes.NewReader(got), out, len(got), 0)
+	if err != nil {
+		t.Errorf("UnmarshalLittleEndian() error = %s, want <nil>", err)
+	}
+	if xs != nil {
+		t.Errorf("UnmarshalLittleEndian() left over = %#x, want <nil>", xs)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("UnmarshalLittleEndian() result = %#v, want %#v", out, in)
+	}
+}
+
+func TestMarshalLittleEndian_TopLevelArray(t *testing.T) {
+	in := []ptp.Int128{{Lo: 1}, {Hi: 0x8000000000000000}}
+
+	got := MarshalLittleEndian(in)
+	if len(got) != 4+2*16 {
+		t.Fatalf("MarshalLittleEndian() length = %d, want %d", len(got), 4+2*16)
+	}
+
+	var out []ptp.Int128
+	if _, err := UnmarshalLittleEndian(bytes.NewReader(got), &out, len(got), 0); err != nil {
+		t.Errorf("UnmarshalLittleEndian() error = %s, want <nil>", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("UnmarshalLittleEndian() result = %#v, want %#v", out, in)
+	}
+}
+
+func TestUnmarshalLittleEndian_ArrayTooLong(t *testing.T) {
+	// The element count claims 0xffff elements while only one is present.
+	b := []byte{0xff, 0xff, 0x00, 0x00, 0x01, 0x00}
+
+	var out []uint16
+	if _, err := UnmarshalLittleEndian(bytes.NewReader(b), &out, len(b), 0); err == nil {
+		t.Errorf("UnmarshalLittleEndian() error = <nil>, want error")
+	}
+}
+
+type ptpStrings struct {
+	Name    ptp.String
+	Empty   ptp.String
+	Trailer uint8
+}
+
+func TestMarshalLittleEndian_PTPString(t *testing.T) {
+	in := &ptpStrings{Name: "X-T3", Empty: "", Trailer: 0xff}
+
+	want := []byte{0x05, 0x58, 0x00, 0x2d, 0x00, 0x54, 0x00, 0x33, 0x00, 0x00, 0x00, 0x00, 0xff}
+
+	got := MarshalLittleEndian(in)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("MarshalLittleEndian() return = %#x, want %#x", got, want)
+	}
+
+	out := new(ptpStrings)
+	if _, err := UnmarshalLittleEndian(bytes.NewReader(got), out, len(got), 0); err != nil {
+		t.Errorf("UnmarshalLittleEndian() error = %s, want <nil>", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("UnmarshalLittleEndian() result = %#v, want %#v", out, in)
+	}
+}
+
+func TestUnmarshalLittleEndian_PTPStringLength(t *testing.T) {
+	long := make([]byte, 0, 1+255*2+1)
+	long = append(long, 0xff)
+	for i := 0; i < 255; i++ {
+		long = append(long, 0x41, 0x00)
+	}
+
+	check := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		// The Responder forgot the null terminator.
+		{"unterminated", []byte{0x02, 0x58, 0x00, 0x31, 0x00, 0x00, 0xff}, "X1"},
+		// 255 characters without a terminator exceeds the maximum length a ptp.String can be marshalled to.
+		{"too long", append(long, 0x00, 0xff), strings.Repeat("A", 255)},
+	}
+	for _, c := range check {
+		out := new(ptpStrings)
+		left, err := UnmarshalLittleEndian(bytes.NewReader(c.in), out, len(c.in), 0)
+		if err != nil {
+			t.Errorf("UnmarshalLittleEndian() %s error = %s, want <nil>", c.name, err)
+		}
+		if out.Name != ptp.String(c.want) || out.Trailer != 0xff || len(left) != 0 {
+			t.Errorf("UnmarshalLittleEndian() %s result = %#v, %#x; want Name %s and Trailer 0xff", c.name, out, left, c.want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package ip
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -12,6 +14,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +24,7 @@ const (
 	DefaultVendor         string         = "generic"
 	DefaultDialTimeout                   = 10 * time.Second
 	DefaultReadTimeout                   = 30 * time.Second
+	DefaultWriteTimeout                  = 10 * time.Second
 	DefaultPort           uint16         = 15740
 	DefaultIpAddress      string         = "192.168.0.1"
 	InitiatorFriendlyName string         = "Golang PTP/IP client"
@@ -33,10 +37,12 @@ var (
 	BytesWrittenMismatch = "bytes written mismatch: written %d wanted %d"
 	ConnectionLostError  = errors.New("connection lost")
 	ReadResponseError    = errors.New("unable to read response packet")
-	WaitForResponseError = errors.New("timeout reached when waiting for response")
-	WaitForEventError    = errors.New("timeout reached when waiting for event")
+	WaitForResponseError = fmt.Errorf("%w when waiting for response", ptp.ErrTimeout)
+	WaitForEventError    = fmt.Errorf("%w when waiting for event", ptp.ErrTimeout)
+	WaitForProbeError    = fmt.Errorf("%w when waiting for probe response", ptp.ErrTimeout)
 	InvalidPacketError   = errors.New("invalid packet")
 	NotConnectedError    = errors.New("not connected")
+	ObjectNotFoundError  = errors.New("object not found")
 )
 
 type connectionType string
@@ -106,17 +112,23 @@ func (r Responder) Network() string {
 
 // CommandDataAddress returns the address of the command/data channel as string in the form of host:port.
 func (r Responder) CommandDataAddress() string {
-	return fmt.Sprintf("%s:%d", r.IpAddress, r.CommandDataPort)
+	return r.address(r.CommandDataPort)
 }
 
 // EventAddress returns the address of the event channel as string in the form of host:port.
 func (r Responder) EventAddress() string {
-	return fmt.Sprintf("%s:%d", r.IpAddress, r.EventPort)
+	return r.address(r.EventPort)
 }
 
 // StreamerAddress returns the address streamer channel as string in the form of host:port.
 func (r Responder) StreamerAddress() string {
-	return fmt.Sprintf("%s:%d", r.IpAddress, r.StreamerPort)
+	return r.address(r.StreamerPort)
+}
+
+// address joins the IP address and the given port. IPv6 addresses, including link-local addresses holding a zone such as
+// fe80::1%wlan0, are enclosed in square brackets.
+func (r Responder) address(port uint16) string {
+	return net.JoinHostPort(strings.Trim(r.IpAddress, "[]"), strconv.Itoa(int(port)))
 }
 
 // NewResponder creates a new responder struct.
@@ -132,14 +144,26 @@ func NewResponder(vendor string, ip string, cport uint16, eport uint16, sport ui
 
 // Client holds all parts needed to build our PTP/IP client:
 //   - the connection number
-//   - the current transaction ID
+//   - the current transaction ID, the store persisting it and the block of transaction IDs reserved in the store
 //   - the command/data channel connection
 //   - the event channel connection
 //   - the streamer channel connection
-//   - the initiator info, i.e. us
+//   - a packet reader per connection keeping the partially read packets
+//   - the hooks called for each packet sent or received
+//   - the initiator info, i.e. us, and the store persisting it per responder
 //   - the responder info, i.e. camera
+//   - the dialer used to connect to the responder and the TLS configuration wrapping the connections
 //   - the loaded vendor extensions
+//   - the subscriptions of the transactions in progress and a semaphore allowing only one transaction at a time
 //   - an async event channel receiving events from the Responder's event connection
+//   - the channels subscribed to receive a copy of the events, the channel returned by Events() and the event handlers
+//   - the journal correlating the operation requests and responses and the events
+//   - the timeouts per class of operation and the policy for retrying operations failing for a transient reason
+//   - the reconnect policy and the property values to restore after reconnecting
+//   - the cache holding the last known property values
+//   - the connection state and the callbacks to call when it changes
+//   - the keep alive settings and a channel receiving the probe responses
+//   - the settings for polling the Responder for changes
 //   - an async streamer channel receiving raw image data from the Responder's streaming connection if there is one
 //   - a channel to request the streamer to close down
 //   - a logger
@@ -147,18 +171,68 @@ type Client struct {
 	connectionNumber uint32
 	transactionId    ptp.TransactionID
 	transactionIdMu  sync.Mutex
+	transactionIds   TransactionIDStore
+	reservedTids     int
+	reservedTidMax   ptp.TransactionID
+	tidStoreMu       sync.Mutex
 	commandDataConn  net.Conn
 	eventConn        net.Conn
 	streamConn       net.Conn
+	connMu           sync.RWMutex
+	packetReaders    map[net.Conn]*packetReader
+	packetReadersMu  sync.Mutex
+	writeMu          sync.Mutex
+	sendHooks        []SendHook
+	receiveHooks     []ReceiveHook
+	hooksMu          sync.Mutex
 	initiator        *Initiator
+	identities       IdentityStore
 	responder        *Responder
+	dialer           Dialer
+	tlsConfig        *tls.Config
 	vendorExtensions *VendorExtensions
 	cmdDataChan      chan []byte
-	cmdDataSubs      map[ptp.TransactionID]chan<- []byte
+	cmdDataSubs      map[ptp.TransactionID]*cmdDataSubscription
 	cmdDataSubsMu    sync.Mutex
+	transactionSem   chan struct{}
+	aborted          chan struct{}
 	eventChan        chan EventPacket
+	eventSubs        map[<-chan EventPacket]*eventSubscription
+	events           chan EventPacket
+	eventHandlers    map[ptp.EventCode][]func(EventPacket)
+	recentEvents     *eventRing
+	journal          *Journal
+	eventSubsMu      sync.Mutex
+	timeouts         Timeouts
+	retryPolicy      *RetryPolicy
+	reconnectPolicy  *ReconnectPolicy
+	keepAlive        *KeepAlive
+	eventActivity    time.Time
+	activityMu       sync.Mutex
+	eventPoller      *EventPoller
+	probeChan        chan struct{}
+	reconnecting     bool
+	closed           bool
+	reconnectMu      sync.Mutex
+	state            LifecycleState
+	onStateChange    []func(LifecycleState)
+	onConnect        []func()
+	onDisconnect     []func(error)
+	onError          []func(error)
+	stateMu          sync.Mutex
+	deviceProps      map[ptp.DevicePropCode]uint32
+	devicePropsMu    sync.Mutex
+	propCache        map[ptp.DevicePropCode]interface{}
+	propCacheMu      sync.Mutex
+	storageInfo      map[ptp.StorageID]*ptp.StorageInfo
+	onStorageChange  []func(StorageChange)
+	storageMu        sync.Mutex
 	StreamChan       chan []byte
+	streamFrames     chan *streamFrame
 	closeStreamChan  chan struct{}
+	liveView         bool
+	streamMu         sync.Mutex
+	lvStats          liveViewStats
 	Logger
 }
 
@@ -168,25 +242,6 @@ func (c *Client) ConnectionNumber() uint32 {
 	return c.connectionNumber
 }
 
-// TransactionId returns the current transaction ID.
-func (c *Client) TransactionId() ptp.TransactionID {
-	return c.transactionId
-}
-
-// incrementTransactionId increments the transaction ID in a thread safe way.
-func (c *Client) incrementTransactionId() ptp.TransactionID {
-	c.transactionIdMu.Lock()
-	c.transactionId++
-	// The min and max values are considered 'invalid', so we roll over to 1 when we reach the max value.
-	if c.transactionId == 0xFFFFFFFF {
-		c.transactionId = 0x00000001
-	}
-	tid := c.transactionId // must copy the value before releasing the lock to reliably return it!
-	c.transactionIdMu.Unlock()
-
-	return tid
-}
-
 // Network returns a fixed value: "tcp".
 func (c *Client) Network() string {
 	return c.responder.Network()
@@ -264,32 +319,85 @@ func (c *Client) SetLogger(log Logger) {
 
 // Dial will initialise the command/data and Event connections.
 func (c *Client) Dial() error {
+	return c.DialContext(context.Background())
+}
+
+// DialContext does the same as Dial but aborts dialing and the init sequence as soon as the context is done. This is
+// particularly useful with Fuji devices where the init sequence waits for the user to confirm the connection on the
+// camera.
+func (c *Client) DialContext(ctx context.Context) error {
+	c.reconnectMu.Lock()
+	c.closed = false
+	c.reconnectMu.Unlock()
+
+	err := c.retry(ctx, func(attempt int) error {
+		// Clean up what remains of the previous attempt.
+		if attempt > 1 {
+			c.closeConnections()
+		}
+		return c.dial(ctx)
+	})
+	if err != nil {
+		c.setState(ConnectionIdle, err)
+		return err
+	}
+
+	return nil
+}
+
+// dial initialises the command/data and Event connections without touching the closed state of the client so that it
+// can be used to reconnect.
+func (c *Client) dial(ctx context.Context) error {
 	var err error
 
-	err = c.initCommandDataConn()
+	ctx = withReadTimeout(ctx, c.timeouts.Init)
+	c.resetTransactions()
+	c.clearPropertyCache()
+	c.clearStorageCache()
+	c.setState(ConnectionDialing, nil)
+
+	err = c.initCommandDataConn(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = c.initEventConn()
+	err = c.initEventConn(ctx)
 	if err != nil {
 		return err
 	}
 
+	err = c.vendorExtensions.openSession(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if c.keepAlive != nil {
+		go c.runKeepAlive(c.conn(eventConnection), *c.keepAlive)
+	}
+	if c.eventPoller != nil {
+		go c.runEventPoller(c.conn(cmdDataConnection), *c.eventPoller)
+	}
+	c.setState(ConnectionReady, nil)
+
 	return nil
 }
 
 // DialWithStreamer will call Dial and also attempt to open the steamer channel used for live preview. Not all devices
 // have such a channel.
 func (c *Client) DialWithStreamer() error {
+	return c.DialWithStreamerContext(context.Background())
+}
+
+// DialWithStreamerContext does the same as DialWithStreamer but aborts as soon as the context is done.
+func (c *Client) DialWithStreamerContext(ctx context.Context) error {
 	var err error
 
-	err = c.Dial()
+	err = c.DialContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = c.initStreamConn()
+	err = c.initStreamConn(ctx)
 	if err != nil {
 		return err
 	}
@@ -297,47 +405,75 @@ func (c *Client) DialWithStreamer() error {
 	return nil
 }
 
-// Close closes all open connections for the client.
+// Close ends the session and closes all open connections for the client. Ending the session properly makes the
+// Responder return to its normal operation instead of showing it is still connected. The client will not attempt to
+// reconnect after being closed.
 func (c *Client) Close() error {
+	ready := c.State() == ConnectionReady
+	c.reconnectMu.Lock()
+	c.closed = true
+	c.reconnectMu.Unlock()
+
+	if ready {
+		c.closeSession()
+	}
+
+	err := c.closeConnections()
+	c.closeEvents()
+	c.releaseTransactionIds()
+	c.setState(ConnectionIdle, nil)
+
+	return err
+}
+
+// closeSession performs the vendor specific sequence ending the session. The Operation timeout limits the time spent on
+// it, also when another transaction is still in progress. Closing the connections ends the session anyway, so a
+// failure is merely logged.
+func (c *Client) closeSession() {
+	ctx := context.Background()
+	if c.timeouts.Operation > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeouts.Operation)
+		defer cancel()
+	}
+
+	if err := c.vendorExtensions.closeSession(ctx, c); err != nil {
+		c.Warnf("Unable to close the session: %s", err)
+	}
+}
+
+// closeConnections closes the streamer, event and command/data connections, in that order. All connections are closed
+// even when closing one of them fails, in which case the first error is returned.
+func (c *Client) closeConnections() error {
 	var err error
 
+	c.abortTransactions()
+
 	// streamConn must be closed first so we can do it cleanly, otherwise the camera might terminate it for us causing
 	// any possible listeners to panic.
-	if c.streamConn != nil {
-		err = c.closeStreamConn()
-		if err != nil {
-			return err
-		}
-	}
+	err = c.closeStreamConn()
 
-	// TODO: add a closeEventConn() method so we can properly shut down the event channel like we do with the streamer.
-	if c.eventConn != nil {
-		err = c.eventConn.Close()
-		c.eventConn = nil
-		if err != nil {
-			return err
-		}
+	if cerr := c.closeEventConn(); err == nil {
+		err = cerr
 	}
 
-	if c.commandDataConn != nil {
-		err = c.commandDataConn.Close()
-		c.commandDataConn = nil
-		if err != nil {
-			return err
+	if conn := c.setConn(cmdDataConnection, nil); conn != nil {
+		if cerr := conn.Close(); err == nil {
+			err = cerr
 		}
 	}
 
-	return nil
+	return err
 }
 
 // SendPacketToCmdDataConn sends a packet to the command/data connection.
 func (c *Client) SendPacketToCmdDataConn(p PacketOut) error {
-	return c.sendPacket(c.commandDataConn, p)
+	return c.sendPacket(c.conn(cmdDataConnection), p)
 }
 
 // SendPacketToEventConn sends a packet to the Event connection.
 func (c *Client) SendPacketToEventConn(p PacketOut) error {
-	return c.sendPacket(c.eventConn, p)
+	return c.sendPacket(c.conn(eventConnection), p)
 }
 
 // We write directly to the connection here without using bufio. The Payload() method and marshaling functions are
@@ -350,60 +486,46 @@ func (c *Client) sendPacket(w io.Writer, p PacketOut) error {
 		return InvalidPacketError
 	}
 	c.Debugf("[sendPacket] sending %T", p)
+	c.journal.recordRequest(p)
 
-	pl := p.Payload()
-	pll := len(pl)
-
-	// An invalid packet type means it does not adhere to the PTP/IP standard, so we only send the length field here.
-	if p.PacketType() == PKT_Invalid {
-		// Send length only. The length must include the size of the length field, so we add 4 bytes for that!
-		if _, err := w.Write(internal.MarshalLittleEndian(uint32(pll + 4))); err != nil {
-			return err
-		}
-	} else {
-		// The packet length MUST include the header, so we add 8 bytes for that!
-		h := internal.MarshalLittleEndian(Header{uint32(pll + HeaderSize), p.PacketType()})
+	// The header and payload are sent in one go: the packet length includes the size of the header.
+	return c.writePacket(w, c.runSendHooks(c.connectionTypeOf(w), p, marshalPacket(p)))
+}
 
-		// Send header.
-		n, err := w.Write(h)
-		if err != nil {
-			return err
-		}
-		if n != HeaderSize {
-			return fmt.Errorf(BytesWrittenMismatch, n, HeaderSize)
-		}
-		c.Debugf("[sendPacket] header bytes written %d", n)
-	}
+// writePacket writes the raw packet, length field included, to the connection.
+func (c *Client) writePacket(w io.Writer, raw []byte) error {
+	// Packets sent from different goroutines must not interleave.
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 
-	// Send payload.
-	if pll == 0 {
-		c.Debugf("[sendPacket] packet has no payload")
-		return nil
+	if conn, ok := w.(net.Conn); ok {
+		conn.SetWriteDeadline(deadline(c.timeouts.Write))
 	}
 
-	n, err := w.Write(pl)
+	n, err := w.Write(raw)
 	if err != nil {
 		return err
 	}
-	if n != pll {
-		return fmt.Errorf(BytesWrittenMismatch, n, pll)
+	if n != len(raw) {
+		return fmt.Errorf(BytesWrittenMismatch, n, len(raw))
 	}
-	c.Debugf("[sendPacket] payload bytes written %d", n)
+	c.Debugf("[sendPacket] bytes written %d", n)
 
 	return nil
 }
 
-// readRawFromCmdDataConn reads raw data from the command/data connection with a read timout of 30 seconds.
-func (c *Client) readRawFromCmdDataConn() ([]byte, error) {
-	if c.commandDataConn == nil {
-		return nil, fmt.Errorf("connection lost")
+// readRawFromCmdDataConn reads raw data from the given command/data connection with a read timout of 30 seconds.
+func (c *Client) readRawFromCmdDataConn(conn net.Conn) ([]byte, error) {
+	if conn == nil {
+		return nil, ConnectionLostError
 	}
-	c.commandDataConn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
-	return c.readRawResponse(c.commandDataConn)
+	conn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
+	return c.receivePacket(conn)
 }
 
-// waitForRawFromCmdDataConn waits 30 seconds for a packet on the command/data connection.
-func (c *Client) waitForRawFromCmdDataConn() ([]byte, error) {
+// waitForRawFromCmdDataConn waits 30 seconds for a packet on the given command/data connection. Contrary to the other
+// wait functions, io.EOF is returned immediately since it means the connection was dropped.
+func (c *Client) waitForRawFromCmdDataConn(conn net.Conn) ([]byte, error) {
 	var (
 		res []byte
 		err error
@@ -415,11 +537,8 @@ func (c *Client) waitForRawFromCmdDataConn() ([]byte, error) {
 			wait = false
 			err = WaitForResponseError
 		default:
-			res, err = c.readRawFromCmdDataConn()
-			if err != io.EOF || res != nil {
-				wait = false
-			}
-			time.Sleep(20 * time.Millisecond)
+			res, err = c.readRawFromCmdDataConn(conn)
+			wait = false
 		}
 	}
 	if err != nil {
@@ -429,40 +548,52 @@ func (c *Client) waitForRawFromCmdDataConn() ([]byte, error) {
 	return res, nil
 }
 
-// readPacketFromCmdDataConn reads a packet from the command/data connection with a read timout of 30 seconds.
+// readPacketFromCmdDataConn reads a packet from the command/data connection with the given read deadline.
 // When expecting a specific packet, you can pass it in, otherwise pass nil.
 // The byte array that is returned will contain any excess data that was not unmarshalled, empty otherwise.
-func (c *Client) readPacketFromCmdDataConn(p PacketIn) (PacketIn, []byte, error) {
-	if c.commandDataConn == nil {
+func (c *Client) readPacketFromCmdDataConn(p PacketIn, dl time.Time) (PacketIn, []byte, error) {
+	conn := c.conn(cmdDataConnection)
+	if conn == nil {
 		return nil, nil, ConnectionLostError
 	}
-	c.commandDataConn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
-	return c.readResponse(c.commandDataConn, p)
+	conn.SetReadDeadline(dl)
+	return c.readPacket(conn, p)
 }
 
-// waitForPacketFromCmdDataConn waits 30 seconds for a packet on the command/data connection.
+// waitForPacketFromCmdDataConn waits for a packet on the command/data connection until the read timeout for the context
+// is reached or until the context is done.
 // This function will return a packet satisfying PacketIn together with any excess data that was not unmarshalled as a
 // byte array. The excess data will be empty if there was none.
-func (c *Client) waitForPacketFromCmdDataConn(p PacketIn) (PacketIn, []byte, error) {
+func (c *Client) waitForPacketFromCmdDataConn(ctx context.Context, p PacketIn) (PacketIn, []byte, error) {
 	var (
 		res PacketIn
 		xs  []byte
 		err error
 	)
 
-	for wait, timeout := true, time.After(DefaultReadTimeout); wait; {
+	t := c.readTimeout(ctx)
+	dl := deadline(t)
+	defer interruptReadOnDone(ctx, c.conn(cmdDataConnection))()
+	for wait, timeout := true, after(t); wait; {
 		select {
 		case <-timeout:
 			wait = false
 			err = WaitForResponseError
+		case <-ctx.Done():
+			wait = false
+			err = ctx.Err()
 		default:
-			res, xs, err = c.readPacketFromCmdDataConn(p)
+			res, xs, err = c.readPacketFromCmdDataConn(p, dl)
 			if err != io.EOF || res != nil {
 				wait = false
 			}
 			time.Sleep(20 * time.Millisecond)
 		}
 	}
+	// A read interrupted because the context is done fails with a timeout error, report the actual reason instead.
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -470,39 +601,51 @@ func (c *Client) waitForPacketFromCmdDataConn(p PacketIn) (PacketIn, []byte, err
 	return res, xs, nil
 }
 
-// readPacketFromEventConn reads a packet from the Event connection.
+// readPacketFromEventConn reads a packet from the Event connection with the given read deadline.
 // The byte array that is returned will contain any excess data that was not unmarshalled, empty otherwise.
-func (c *Client) readPacketFromEventConn(p PacketIn) (PacketIn, []byte, error) {
-	if c.eventConn == nil {
+func (c *Client) readPacketFromEventConn(p PacketIn, dl time.Time) (PacketIn, []byte, error) {
+	conn := c.conn(eventConnection)
+	if conn == nil {
 		return nil, nil, ConnectionLostError
 	}
-	c.eventConn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
-	return c.readResponse(c.eventConn, p)
+	conn.SetReadDeadline(dl)
+	return c.readPacket(conn, p)
 }
 
-// waitForPacketFromEventConn waits for a packet on the Event connection.
+// waitForPacketFromEventConn waits for a packet on the Event connection until the read timeout for the context is
+// reached or until the context is done.
 // This function will return a packet satisfying EventPacket together with any excess data that was not unmarshalled as
 // a byte array. The excess data will be empty if there was none.
-func (c *Client) waitForPacketFromEventConn(p EventPacket) (PacketIn, []byte, error) {
+func (c *Client) waitForPacketFromEventConn(ctx context.Context, p EventPacket) (PacketIn, []byte, error) {
 	var (
 		res PacketIn
 		xs  []byte
 		err error
 	)
 
-	for wait, timeout := true, time.After(DefaultReadTimeout); wait; {
+	t := c.readTimeout(ctx)
+	dl := deadline(t)
+	defer interruptReadOnDone(ctx, c.conn(eventConnection))()
+	for wait, timeout := true, after(t); wait; {
 		select {
 		case <-timeout:
 			wait = false
 			err = WaitForEventError
+		case <-ctx.Done():
+			wait = false
+			err = ctx.Err()
 		default:
-			res, xs, err = c.readPacketFromEventConn(p)
+			res, xs, err = c.readPacketFromEventConn(p, dl)
 			if err != io.EOF || res != nil {
 				wait = false
 			}
 			time.Sleep(20 * time.Millisecond)
 		}
 	}
+	// A read interrupted because the context is done fails with a timeout error, report the actual reason instead.
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -510,35 +653,82 @@ func (c *Client) waitForPacketFromEventConn(p EventPacket) (PacketIn, []byte, er
 	return res, xs, nil
 }
 
+// interruptReadOnDone makes any blocking read on the connection return as soon as the context is done. The function
+// returned must be called to stop watching the context.
+func interruptReadOnDone(ctx context.Context, conn net.Conn) func() {
+	if ctx.Done() == nil || conn == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Every read sets its own deadline, so this will not affect reads done after we stopped watching.
+			conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
 // ReadRawFromStreamConn reads raw data from the streamer connection with a read timout of 30 seconds.
 func (c *Client) ReadRawFromStreamConn() ([]byte, error) {
-	c.commandDataConn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
-	return c.readRawResponse(c.streamConn)
+	return c.readRawFromStreamConn(c.conn(streamConnection))
 }
 
-// TODO: this must be refactored to work like the events: continuously read and push to a channel in such a way that we
-//  do not mix up packets (use transaction ID properly) like what's happening now with liveview polling the camera state
-//  every second.
+// readRawFromStreamConn reads raw data from the given streamer connection with a read timout of 30 seconds.
+func (c *Client) readRawFromStreamConn(conn net.Conn) ([]byte, error) {
+	if conn == nil {
+		return nil, NotConnectedError
+	}
+	conn.SetReadDeadline(time.Now().Add(DefaultReadTimeout))
+	return c.receivePacket(conn)
+}
+
+// readPacket reads the next packet from the connection using the connection's packetReader, so a packet that was
+// partially read when the read deadline was reached is completed by the next read.
+func (c *Client) readPacket(conn net.Conn, p PacketIn) (PacketIn, []byte, error) {
+	raw, err := c.receivePacket(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parsePacket(raw, p)
+}
+
+// readResponse reads a single packet from r and unmarshals it.
+// When expecting a specific packet, you can pass it in, otherwise pass nil.
+// The byte array that is returned will contain any excess data that was not unmarshalled, empty otherwise.
 func (c *Client) readResponse(r io.Reader, p PacketIn) (PacketIn, []byte, error) {
+	raw, err := readPacket(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parsePacket(raw, p)
+}
+
+// parsePacket unmarshals a complete raw packet, length field included. When p is nil, the packet type in the header
+// determines the packet that is returned.
+func parsePacket(raw []byte, p PacketIn) (PacketIn, []byte, error) {
 	var err error
 	var h Header
 	var hl int
 
+	r := bytes.NewReader(raw)
 	// An invalid packet type means it does not adhere to the PTP/IP standard, so we only read the length field here.
 	if p != nil && p.PacketType() == PKT_Invalid {
-		var l uint32
-		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
-			return nil, nil, err
-		}
-		hl = int(l) - 4
+		hl = len(raw) - 4
+		r.Seek(4, io.SeekStart)
 	} else {
+		if len(raw) < HeaderSize {
+			return nil, nil, &FramingError{Length: uint32(len(raw)), Read: len(raw), Err: PacketLengthError}
+		}
 		if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
 			return nil, nil, err
 		}
-
-		if h.Length == 0 {
-			return nil, nil, ReadResponseError
-		}
 		hl = int(h.Length) - HeaderSize
 	}
 
@@ -548,11 +738,16 @@ func (c *Client) readResponse(r io.Reader, p PacketIn) (PacketIn, []byte, error)
 		}
 	}
 
+	if rp, ok := p.(rawPayloadPacket); ok {
+		return p, nil, rp.setRawPayload(raw[len(raw)-hl:])
+	}
+
 	// TODO: this variable string calculation works for now, but there MUST be a better way to handle this!
 	// We calculate the size of the variable portion of the packet here!
 	// If there is no variable portion, vs will be 0.
 	vs := hl - p.TotalFixedFieldSize()
 	xs, err := internal.UnmarshalLittleEndian(r, p, hl, vs)
+	// Not all vendors send all fields, so running out of data is not an error.
 	if err != nil && err != io.EOF {
 		return nil, nil, err
 	}
@@ -560,108 +755,54 @@ func (c *Client) readResponse(r io.Reader, p PacketIn) (PacketIn, []byte, error)
 	return p, xs, nil
 }
 
-// TODO: this must be refactored to work like the events: continuously read and push to a channel in such a way that we
-//  do not mix up packets (use transaction ID properly) like what's happening now with liveview polling the camera state
-//  every second.
-// The reading approach taken here is so that we can return the full raw data but still reliably read the complete
-// expected data length.
+// readRawResponse reads a single packet from r and returns it as is, length field included.
 func (c *Client) readRawResponse(r io.Reader) ([]byte, error) {
-	l := make([]byte, 4)
-	if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
-		return nil, err
-	}
-
-	len := binary.LittleEndian.Uint32(l)
-	b := make([]byte, int(len)-4)
-	if err := binary.Read(r, binary.LittleEndian, &b); err != nil {
-		return nil, err
-	}
-
-	return append(l, b...), nil
-}
-
-// subscribe registers a channel to receive responses for a specific transaction ID.
-func (c *Client) subscribe(tid ptp.TransactionID, ch chan<- []byte) error {
-	c.cmdDataSubsMu.Lock()
-	defer c.cmdDataSubsMu.Unlock()
-
-	if _, ok := c.cmdDataSubs[tid]; ok {
-		return fmt.Errorf("attempt to double subscribe transaction id %d", tid)
-	}
-	c.cmdDataSubs[tid] = ch
-
-	return nil
+	return readPacket(r)
 }
 
-// unsubscribe removes a subscription for a given transaction ID and closes the corresponding channel.
-func (c *Client) unsubscribe(tid ptp.TransactionID) {
-	c.cmdDataSubsMu.Lock()
-	if ch, ok := c.cmdDataSubs[tid]; ok {
-		close(ch)
-		delete(c.cmdDataSubs, tid)
-	}
-	c.cmdDataSubsMu.Unlock()
-}
-
-// responseListener listens on the Command/Data connection for incoming packets and publishes them to a registered
-// subscriber based on the transaction ID of the packet.
-func (c *Client) responseListener() {
-	c.cmdDataChan = make(chan []byte, 10)
-	lmp := "[responseListener]"
-	c.Infof("%s subscribing response listener to command/data connection...", lmp)
-	for {
-		p, err := c.waitForRawFromCmdDataConn()
-		if err == nil {
-			tid, err := c.vendorExtensions.extractTransactionId(p, cmdDataConnection)
-			if err != nil {
-				c.Error(err)
-				continue
-			}
-			c.Debugf("%s publishing new response with length '%d' for transaction ID '%d'...", lmp, binary.LittleEndian.Uint32(p[0:4]), tid)
-
-			if _, ok := c.cmdDataSubs[tid]; !ok {
-				panic(fmt.Sprintf("No subscriber for transaction ID %d!", tid))
-			}
-			c.cmdDataSubs[tid] <- p
-			continue
-		} else if err == WaitForResponseError || strings.Contains(err.Error(), "i/o timeout") {
-			continue
-		}
-		c.Errorf("%s message listener stopped: %s", lmp, err)
-		return
-	}
-}
-
-func (c *Client) initCommandDataConn() error {
-	var err error
-
-	c.commandDataConn, err = internal.RetryDialer(c.Network(), c.CommandDataAddress(), DefaultDialTimeout)
+func (c *Client) initCommandDataConn(ctx context.Context) error {
+	conn, err := c.dialAddress(ctx, c.CommandDataAddress())
+	c.setConn(cmdDataConnection, conn)
 	if err != nil {
 		return err
 	}
 
 	c.configureTcpConn(cmdDataConnection)
+	c.setState(ConnectionHandshaking, nil)
 
-	if err := c.vendorExtensions.cmdDataInit(c); err != nil {
-		return fmt.Errorf("command data connection: %s", err)
+	if err := c.vendorExtensions.cmdDataInit(ctx, c); err != nil {
+		return fmt.Errorf("command data connection: %w", err)
 	}
 
 	return nil
 }
 
-// WaitForRawPacketFromCommandDataSubscriber waits 30 seconds for a packet to be sent to a command/data channel
-// subscriber registered using the subscribe method.
+// WaitForRawPacketFromCommandDataSubscriber waits for a packet to be sent to a command/data channel subscriber
+// registered using the subscribe method. It gives up when the Operation timeout is reached.
 func (c *Client) WaitForRawPacketFromCommandDataSubscriber(ch <-chan []byte) ([]byte, error) {
+	return c.WaitForRawPacketFromCommandDataSubscriberContext(context.Background(), ch)
+}
+
+// WaitForRawPacketFromCommandDataSubscriberContext does the same as WaitForRawPacketFromCommandDataSubscriber but stops
+// waiting as soon as the context is done. The timeout depends on the class of operation the context was created for.
+func (c *Client) WaitForRawPacketFromCommandDataSubscriberContext(ctx context.Context, ch <-chan []byte) ([]byte, error) {
 	var (
 		res []byte
 		err error
 	)
 
-	for wait, timeout := true, time.After(DefaultReadTimeout); wait; {
+	aborted := c.abortedChan()
+	for wait, timeout := true, after(c.readTimeout(ctx)); wait; {
 		select {
 		case <-timeout:
 			wait = false
 			err = WaitForResponseError
+		case <-ctx.Done():
+			wait = false
+			err = ctx.Err()
+		case <-aborted:
+			wait = false
+			err = ConnectionLostError
 		case res = <-ch:
 			wait = false
 		}
@@ -673,12 +814,18 @@ func (c *Client) WaitForRawPacketFromCommandDataSubscriber(ch <-chan []byte) ([]
 	return res, nil
 }
 
-// WaitForPacketFromCommandDataSubscriber waits 30 seconds for a packet to be sent to a command/data channel subscriber
-// registered using the subscribe method.
+// WaitForPacketFromCommandDataSubscriber waits for a packet to be sent to a command/data channel subscriber registered
+// using the subscribe method. It gives up when the Operation timeout is reached.
 // This function will return a packet satisfying PacketIn together with any excess data that was not unmarshalled as a
 // byte array. The excess data will be empty if there was none.
 func (c *Client) WaitForPacketFromCommandDataSubscriber(ch <-chan []byte, p PacketIn) (PacketIn, []byte, error) {
-	res, err := c.WaitForRawPacketFromCommandDataSubscriber(ch)
+	return c.WaitForPacketFromCommandDataSubscriberContext(context.Background(), ch, p)
+}
+
+// WaitForPacketFromCommandDataSubscriberContext does the same as WaitForPacketFromCommandDataSubscriber but stops
+// waiting as soon as the context is done.
+func (c *Client) WaitForPacketFromCommandDataSubscriberContext(ctx context.Context, ch <-chan []byte, p PacketIn) (PacketIn, []byte, error) {
+	res, err := c.WaitForRawPacketFromCommandDataSubscriberContext(ctx, ch)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -691,26 +838,40 @@ func (c *Client) newCmdDataInitPacket() InitCommandRequestPacket {
 }
 
 // TODO: refactor this one to work exactly like the responseListener!
-func (c *Client) initEventConn() error {
-	if err := c.vendorExtensions.eventInit(c); err != nil {
-		return fmt.Errorf("event connection error: %s", err)
+func (c *Client) initEventConn(ctx context.Context) error {
+	if err := c.vendorExtensions.eventInit(ctx, c); err != nil {
+		return fmt.Errorf("event connection error: %w", err)
 	}
 
 	lmp := "[eventListener]"
 	c.eventChan = make(chan EventPacket, 10)
+	conn := c.conn(eventConnection)
+	c.touchEventConn()
 	go func() {
 		c.Infof("%s subscribing event listener to event connection...", lmp)
 		for {
-			p := c.vendorExtensions.newEventPacket()
-			_, _, err := c.waitForPacketFromEventConn(p)
+			if conn != c.conn(eventConnection) {
+				c.Infof("%s connection was replaced, message listener stopped", lmp)
+				return
+			}
+			// Standard event packets carry a packet type so we can let the header decide what packet we are reading: the
+			// Responder can also send probe packets over the event connection.
+			var ep EventPacket
+			if p := c.vendorExtensions.newEventPacket(); p.PacketType() == PKT_Invalid {
+				ep = p
+			}
+			res, _, err := c.waitForPacketFromEventConn(context.Background(), ep)
 			if err == nil {
-				c.Debugf("%s publishing new event '%#x' to event channel...", lmp, p.GetEventCode())
-				c.eventChan <- p
+				c.touchEventConn()
+				c.handleEventConnPacket(res)
 				continue
 			} else if err == WaitForEventError || strings.Contains(err.Error(), "i/o timeout") {
 				continue
 			}
 			c.Errorf("%s message listener stopped: %s", lmp, err)
+			if conn == c.conn(eventConnection) {
+				c.connectionLost(err)
+			}
 			return
 		}
 	}()
@@ -718,15 +879,103 @@ func (c *Client) initEventConn() error {
 	return nil
 }
 
+// handleEventConnPacket publishes the events received on the event connection and handles the probe packets.
+func (c *Client) handleEventConnPacket(p PacketIn) {
+	lmp := "[eventListener]"
+	switch pkt := p.(type) {
+	case EventPacket:
+		c.logUnknownVendorEvent(lmp, pkt)
+		c.Debugf("%s publishing new event '%#x' to event channel...", lmp, pkt.GetEventCode())
+		c.publishEvent(pkt)
+	case *ProbeRequestPacket:
+		c.Debugf("%s responding to probe request...", lmp)
+		if err := c.SendPacketToEventConn(&ProbeResponsePacket{}); err != nil {
+			c.Warnf("%s unable to respond to probe request: %s", lmp, err)
+		}
+	case *CancelPacket:
+		// The Responder confirms the cancellation of a transaction, which ends the data phase of the transaction.
+		c.Debugf("%s received cancel for transaction %d", lmp, pkt.TransactionId)
+		c.publishResponse(pkt.TransactionId, marshalPacket(pkt))
+	case *ProbeResponsePacket:
+		c.Debugf("%s received probe response", lmp)
+		select {
+		case c.probeChan <- struct{}{}:
+		default:
+		}
+	default:
+		c.Warnf("%s ignoring unexpected packet %T", lmp, p)
+	}
+}
+
+// publishEvent sends the event to all subscribers, to the channel returned by Events() and to the internal event
+// channel and calls the handlers registered for its code. Subscribers that are not keeping up are dealt with according
+// to their Backpressure policy. When the internal event channel is full, the oldest event in it is dropped to make
+// room.
+func (c *Client) publishEvent(p EventPacket) {
+	// The caches must be up to date by the time the subscribers learn about the change.
+	e := p.Typed()
+	if dpc, ok := e.(ptp.DevicePropChangedEvent); ok {
+		c.invalidateProperty(dpc.Code)
+	}
+	c.handleStorageEvent(e)
+
+	c.journal.recordEvent(p)
+
+	c.eventSubsMu.Lock()
+	c.recentEvents.add(p)
+	for _, sub := range c.eventSubs {
+		if sub.filter.matches(p.GetEventCode()) {
+			sub.deliver(c, p)
+		}
+	}
+	c.deliverEvent(p)
+	handlers := c.eventHandlers[p.GetEventCode()]
+	c.eventSubsMu.Unlock()
+
+	for _, f := range handlers {
+		c.callEventHandler(f, p)
+	}
+
+	for {
+		select {
+		case c.eventChan <- p:
+			return
+		default:
+			select {
+			case <-c.eventChan:
+			default:
+			}
+		}
+	}
+}
+
 func (c *Client) newEventInitPacket() InitEventRequestPacket {
 	return c.vendorExtensions.newEventInitPacket(c.connectionNumber)
 }
 
-func (c *Client) initStreamConn() error {
-	if c.streamConn == nil {
-		var err error
+// closeEventConn closes the event connection. The event listener stops as soon as it notices the connection is gone.
+func (c *Client) closeEventConn() error {
+	conn := c.setConn(eventConnection, nil)
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close()
+}
+
+func (c *Client) initStreamConn(ctx context.Context) error {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	return c.openStreamConn(ctx)
+}
 
-		c.streamConn, err = internal.RetryDialer(c.Network(), c.StreamerAddress(), DefaultDialTimeout)
+// openStreamConn opens the streamer connection and starts the stream listener, unless the connection is open already.
+// The caller must hold streamMu, which guards the channels of the stream listener.
+func (c *Client) openStreamConn(ctx context.Context) error {
+	if c.conn(streamConnection) == nil {
+		conn, err := c.dialAddress(ctx, c.StreamerAddress())
+		c.setConn(streamConnection, conn)
 		if err != nil {
 			return err
 		}
@@ -735,6 +984,7 @@ func (c *Client) initStreamConn() error {
 
 		c.StreamChan = make(chan []byte, 50)
 		c.closeStreamChan = make(chan struct{})
+		c.lvStats.reset()
 
 		return c.vendorExtensions.processStreamData(c)
 	}
@@ -743,30 +993,76 @@ func (c *Client) initStreamConn() error {
 }
 
 func (c *Client) closeStreamConn() error {
-	if c.StreamChan != nil {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	conn := c.setConn(streamConnection, nil)
+	if conn == nil {
+		return nil
+	}
+	// The stream listener closes the streamer channel when it stops.
+	if c.closeStreamChan != nil {
 		close(c.closeStreamChan)
+		c.closeStreamChan = nil
 	}
+	c.StreamChan = nil
+	c.streamFrames = nil
 
-	err := c.streamConn.Close()
-	c.streamConn = nil
+	return conn.Close()
+}
 
-	return err
+// conn returns the connection of the given type or nil when it is not open. The connections are replaced while
+// reconnecting, so listeners compare the connection they were started for with the current one to know when to stop.
+func (c *Client) conn(t connectionType) net.Conn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+
+	switch t {
+	case cmdDataConnection:
+		return c.commandDataConn
+	case eventConnection:
+		return c.eventConn
+	case streamConnection:
+		return c.streamConn
+	}
+
+	return nil
 }
 
-func (c *Client) configureTcpConn(t connectionType) {
-	var conn net.Conn
+// setConn replaces the connection of the given type and returns the connection it replaced.
+func (c *Client) setConn(t connectionType, conn net.Conn) net.Conn {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
 
+	var old net.Conn
 	switch t {
 	case cmdDataConnection:
-		conn = c.commandDataConn
+		old, c.commandDataConn = c.commandDataConn, conn
 	case eventConnection:
-		conn = c.eventConn
+		old, c.eventConn = c.eventConn, conn
 	case streamConnection:
-		conn = c.streamConn
+		old, c.streamConn = c.streamConn, conn
+	}
+
+	return old
+}
+
+func (c *Client) configureTcpConn(t connectionType) {
+	conn := c.conn(t)
+
+	// A TLS connection wraps the TCP connection, which is what we want to configure.
+	if nc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = nc.NetConn()
+	}
+	// A custom Dialer does not necessarily return a TCP connection, e.g. when tunneling.
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		c.Infof("%s connection is not a TCP connection: not configuring TCP options", t)
+		return
 	}
 
 	// The PTP/IP protocol specifically asks to enable keep alive.
-	if err := conn.(*net.TCPConn).SetKeepAlive(true); err != nil {
+	if err := tc.SetKeepAlive(true); err != nil {
 		c.Warnf("TCP_KEEPALIVE not enabled for %s connection: %s", t, err)
 	} else {
 		c.Infof("TCP_KEEPALIVE enabled for %s connection", t)
@@ -774,29 +1070,55 @@ func (c *Client) configureTcpConn(t connectionType) {
 
 	// The PTP/IP protocol specifically asks to disable Nagle's algorithm. TCP_NODELAY SHOULD be enabled by default in
 	// golang but there's no harm in making sure since performance here is negligible.
-	if err := conn.(*net.TCPConn).SetNoDelay(true); err != nil {
+	if err := tc.SetNoDelay(true); err != nil {
 		c.Warnf("TCP_NODELAY not enabled for %s connection: %s", t, err)
 	} else {
 		c.Infof("TCP_NODELAY enabled for %s connection", t)
 	}
 }
 
-// NewClient creates a new PTP/IP client.
-// Passing an empty string to friendlyName will use the default friendly name.
-// Passing an empty string as guid will generate a random V4 UUID upon initialisation.
-func NewClient(vendor string, ip string, port uint16, friendlyName string, guid string, logLevel LogLevel) (*Client, error) {
-	i, err := NewInitiator(friendlyName, guid)
+// NewClient creates a new PTP/IP client for the Responder at the given host, configured using the given options. By
+// default, the client connects to DefaultPort of a DefaultVendor Responder, identifies itself as InitiatorFriendlyName
+// using a random V4 UUID and does not log anything:
+//   c, err := NewClient("192.168.0.1", WithVendor("fuji"), WithGUID("cca455de-79ac-4b12-9731-91e433a899cf"))
+func NewClient(host string, opts ...Option) (*Client, error) {
+	i, err := NewDefaultInitiator()
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Client{
-		initiator:   i,
-		responder:   NewResponder(vendor, ip, port, port, port),
-		cmdDataSubs: make(map[ptp.TransactionID]chan<- []byte),
-		Logger:      NewLogger(logLevel, os.Stderr, "", log.LstdFlags),
+		initiator:      i,
+		responder:      NewResponder(DefaultVendor, host, DefaultPort, DefaultPort, DefaultPort),
+		packetReaders:  make(map[net.Conn]*packetReader),
+		cmdDataSubs:    make(map[ptp.TransactionID]*cmdDataSubscription),
+		transactionSem: make(chan struct{}, 1),
+		aborted:        make(chan struct{}),
+		eventSubs:      make(map[<-chan EventPacket]*eventSubscription),
+		eventHandlers:  make(map[ptp.EventCode][]func(EventPacket)),
+		recentEvents:   newEventRing(DefaultEventHistorySize),
+		journal:        NewJournal(DefaultJournalSize, nil),
+		deviceProps:    make(map[ptp.DevicePropCode]uint32),
+		propCache:      make(map[ptp.DevicePropCode]interface{}),
+		storageInfo:    make(map[ptp.StorageID]*ptp.StorageInfo),
+		probeChan:      make(chan struct{}, 1),
+		timeouts:       DefaultTimeouts(),
+		state:          ConnectionIdle,
+		Logger:         NewLogger(LevelSilent, os.Stderr, "", log.LstdFlags),
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
 	}
 
+	// The stored identity is loaded last so it takes precedence over the WithGUID and WithFriendlyName options.
+	if err := c.SetIdentityStore(c.identities); err != nil {
+		return nil, err
+	}
+
+	// The vendor extensions can only be loaded once the vendor is known.
 	c.loadVendorExtensions()
 
 	return c, nil
@@ -805,48 +1127,226 @@ func NewClient(vendor string, ip string, port uint16, friendlyName string, guid
 // GetDeviceInfo requests the Responder's device information. The data that should be returned is clearly specified by
 // the PTP/IP protocol but will, alas, greatly differ from vendor to vendor.
 func (c *Client) GetDeviceInfo() (interface{}, error) {
-	return c.vendorExtensions.getDeviceInfo(c)
+	return c.GetDeviceInfoContext(context.Background())
+}
+
+// GetDeviceInfoContext does the same as GetDeviceInfo but aborts as soon as the context is done.
+func (c *Client) GetDeviceInfoContext(ctx context.Context) (interface{}, error) {
+	return c.vendorExtensions.getDeviceInfo(ctx, c)
 }
 
 // GetDeviceState requests the Responder's device status. This is not part of the PTP/IP specification but is
 // implemented by Fuji as a means to display the current camera settings in their mobile app.
 func (c *Client) GetDeviceState() (interface{}, error) {
-	return c.vendorExtensions.getDeviceState(c)
+	return c.GetDeviceStateContext(context.Background())
+}
+
+// GetDeviceStateContext does the same as GetDeviceState but aborts as soon as the context is done.
+func (c *Client) GetDeviceStateContext(ctx context.Context) (interface{}, error) {
+	s, err := c.vendorExtensions.getDeviceState(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheDeviceState(s)
+
+	return s, nil
 }
 
 // GetDevicePropertyDescription gets the description of the given device property.
 func (c *Client) GetDevicePropertyDescription(code ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
-	return c.vendorExtensions.getDevicePropertyDesc(c, code)
+	return c.GetDevicePropertyDescriptionContext(context.Background(), code)
+}
+
+// GetDevicePropertyDescriptionContext does the same as GetDevicePropertyDescription but aborts as soon as the context is done.
+func (c *Client) GetDevicePropertyDescriptionContext(ctx context.Context, code ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
+	return c.vendorExtensions.getDevicePropertyDesc(ctx, c, code)
 }
 
-// GetDevicePropertyValue gets the value of the given device property.
-func (c *Client) GetDevicePropertyValue(code ptp.DevicePropCode) (uint32, error) {
-	return c.vendorExtensions.getDevicePropertyValue(c, code)
+// GetDevicePropertyValue gets the value of the given device property. The property description is requested first to
+// find out the data type of the property so the value can be returned as the matching Go type, e.g. a uint16 for
+// ptp.DTC_UINT16, a []int32 for ptp.DTC_AINT32 or a string for ptp.DTC_STR. See ptp.DecodeValue() for details.
+func (c *Client) GetDevicePropertyValue(code ptp.DevicePropCode) (interface{}, error) {
+	return c.GetDevicePropertyValueContext(context.Background(), code)
 }
 
-// SetDeviceProperty sets the given device property to the specified value.
+// GetDevicePropertyValueContext does the same as GetDevicePropertyValue but aborts as soon as the context is done.
+func (c *Client) GetDevicePropertyValueContext(ctx context.Context, code ptp.DevicePropCode) (interface{}, error) {
+	dpd, err := c.GetDevicePropertyDescriptionContext(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if dpd == nil {
+		return nil, fmt.Errorf("property %#x cannot be described", code)
+	}
+
+	raw, err := c.vendorExtensions.getDevicePropertyValue(ctx, c, code)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := ptp.DecodeValue(raw, dpd.DataType)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheProperty(code, val)
+
+	return val, nil
+}
+
+// SetDeviceProperty sets the given device property to the specified value. The value is remembered so it can be
+// restored after reconnecting.
 func (c *Client) SetDeviceProperty(code ptp.DevicePropCode, val uint32) error {
-	return c.vendorExtensions.setDeviceProperty(c, code, val)
+	return c.SetDevicePropertyContext(context.Background(), code, val)
+}
+
+// SetDevicePropertyContext does the same as SetDeviceProperty but aborts as soon as the context is done.
+func (c *Client) SetDevicePropertyContext(ctx context.Context, code ptp.DevicePropCode, val uint32) error {
+	c.invalidateProperty(code)
+	if err := c.vendorExtensions.setDeviceProperty(ctx, c, code, val); err != nil {
+		return err
+	}
+	c.rememberDeviceProperty(code, val)
+
+	return nil
+}
+
+// ResetDeviceProperty restores the factory default value of the given device property.
+func (c *Client) ResetDeviceProperty(code ptp.DevicePropCode) error {
+	return c.ResetDevicePropertyContext(context.Background(), code)
+}
+
+// ResetDevicePropertyContext does the same as ResetDeviceProperty but aborts as soon as the context is done.
+func (c *Client) ResetDevicePropertyContext(ctx context.Context, code ptp.DevicePropCode) error {
+	c.invalidateProperty(code)
+	if err := c.vendorExtensions.resetDeviceProperty(ctx, c, code); err != nil {
+		return err
+	}
+	c.forgetDeviceProperty(code)
+
+	return nil
 }
 
 // OperationRequestRaw allows to perform any operation request and returns the raw result intended for reverse
 // engineering purposes.
 func (c *Client) OperationRequestRaw(code ptp.OperationCode, params []uint32) ([][]byte, error) {
-	return c.vendorExtensions.operationRequestRaw(c, code, params)
+	return c.OperationRequestRawContext(context.Background(), code, params)
+}
+
+// OperationRequestRawContext does the same as OperationRequestRaw but aborts as soon as the context is done.
+func (c *Client) OperationRequestRawContext(ctx context.Context, code ptp.OperationCode, params []uint32) ([][]byte, error) {
+	return c.vendorExtensions.operationRequestRaw(ctx, c, code, params)
+}
+
+// OperationRequestStream performs any operation request and returns an io.ReadCloser over the data sent by the
+// Responder during the data-in phase. Contrary to OperationRequestRaw, the data is not buffered which allows piping
+// large objects such as video files to disk or over the network. The Transfer timeout applies to each packet read.
+// Read returns io.EOF once the Responder reported success and an error when the operation failed. No other operation
+// can be performed until the stream is closed, so always call Close: closing the stream before all data was read
+// cancels the transaction.
+func (c *Client) OperationRequestStream(code ptp.OperationCode, params []uint32) (io.ReadCloser, error) {
+	return c.OperationRequestStreamContext(context.Background(), code, params)
+}
+
+// OperationRequestStreamContext does the same as OperationRequestStream but aborts as soon as the context is done. The
+// context applies to reading the stream as well: once it is done, Read returns the context's error.
+func (c *Client) OperationRequestStreamContext(ctx context.Context, code ptp.OperationCode, params []uint32) (io.ReadCloser, error) {
+	return c.vendorExtensions.operationRequestStream(withReadTimeout(ctx, c.timeouts.Transfer), c, code, params)
 }
 
 // InitiateCapture releases the shutter and captures an image. If the responder supports it, a preview of the captured
-// image is returned as a byte array.
+// image is returned as a byte array. Use InitiateCaptureAndWait() to get the ObjectHandles of the captured objects.
 func (c *Client) InitiateCapture() ([]byte, error) {
-	return c.vendorExtensions.initiateCapture(c)
+	return c.InitiateCaptureContext(context.Background())
+}
+
+// InitiateCaptureContext does the same as InitiateCapture but aborts as soon as the context is done.
+func (c *Client) InitiateCaptureContext(ctx context.Context) ([]byte, error) {
+	return c.vendorExtensions.initiateCapture(withReadTimeout(ctx, c.timeouts.Capture), c)
+}
+
+// GetStorageIDs returns the list of StorageIDs, one for each logical store present on the Responder.
+func (c *Client) GetStorageIDs() ([]ptp.StorageID, error) {
+	return c.GetStorageIDsContext(context.Background())
+}
+
+// GetStorageIDsContext does the same as GetStorageIDs but aborts as soon as the context is done.
+func (c *Client) GetStorageIDsContext(ctx context.Context) ([]ptp.StorageID, error) {
+	return c.vendorExtensions.getStorageIDs(ctx, c)
+}
+
+// GetStorageInfo returns the StorageInfo dataset of the store with the given StorageID. The dataset is cached, see
+// CachedStorageInfo().
+func (c *Client) GetStorageInfo(sid ptp.StorageID) (*ptp.StorageInfo, error) {
+	return c.GetStorageInfoContext(context.Background(), sid)
+}
+
+// GetStorageInfoContext does the same as GetStorageInfo but aborts as soon as the context is done.
+func (c *Client) GetStorageInfoContext(ctx context.Context, sid ptp.StorageID) (*ptp.StorageInfo, error) {
+	si, err := c.vendorExtensions.getStorageInfo(ctx, c, sid)
+	if err == nil {
+		c.cacheStorageInfo(sid, si)
+	}
+
+	return si, err
+}
+
+// GetObjectHandles returns the list of ObjectHandles present on the given store. Use 0xFFFFFFFF as StorageID to query
+// all stores. Pass a non-zero ObjectFormatCode to only list objects of that format. Pass the ObjectHandle of an
+// association as parent to only list its direct children, 0xFFFFFFFF to list the root of the store or 0 to list all
+// objects ignoring any hierarchy.
+func (c *Client) GetObjectHandles(sid ptp.StorageID, ofc ptp.ObjectFormatCode, parent ptp.ObjectHandle) ([]ptp.ObjectHandle, error) {
+	return c.GetObjectHandlesContext(context.Background(), sid, ofc, parent)
+}
+
+// GetObjectHandlesContext does the same as GetObjectHandles but aborts as soon as the context is done.
+func (c *Client) GetObjectHandlesContext(ctx context.Context, sid ptp.StorageID, ofc ptp.ObjectFormatCode, parent ptp.ObjectHandle) ([]ptp.ObjectHandle, error) {
+	return c.vendorExtensions.getObjectHandles(ctx, c, sid, ofc, parent)
+}
+
+// GetObjectInfo returns the ObjectInfo dataset for the given ObjectHandle.
+func (c *Client) GetObjectInfo(h ptp.ObjectHandle) (*ptp.ObjectInfo, error) {
+	return c.GetObjectInfoContext(context.Background(), h)
+}
+
+// GetObjectInfoContext does the same as GetObjectInfo but aborts as soon as the context is done.
+func (c *Client) GetObjectInfoContext(ctx context.Context, h ptp.ObjectHandle) (*ptp.ObjectInfo, error) {
+	return c.vendorExtensions.getObjectInfo(ctx, c, h)
+}
+
+// GetObject retrieves the object with the given ObjectHandle from the Responder.
+func (c *Client) GetObject(h ptp.ObjectHandle) ([]byte, error) {
+	return c.GetObjectContext(context.Background(), h)
+}
+
+// GetObjectContext does the same as GetObject but aborts as soon as the context is done.
+func (c *Client) GetObjectContext(ctx context.Context, h ptp.ObjectHandle) ([]byte, error) {
+	return c.vendorExtensions.getObject(withReadTimeout(ctx, c.timeouts.Transfer), c, h)
+}
+
+// DeleteObject deletes the object with the given ObjectHandle from the Responder. Deleting an association also deletes
+// all objects it holds. Use ObjectTree.Delete() to keep a cached tree up to date.
+func (c *Client) DeleteObject(h ptp.ObjectHandle) error {
+	return c.DeleteObjectContext(context.Background(), h)
+}
+
+// DeleteObjectContext does the same as DeleteObject but aborts as soon as the context is done.
+func (c *Client) DeleteObjectContext(ctx context.Context, h ptp.ObjectHandle) error {
+	return c.vendorExtensions.deleteObject(ctx, c, h)
 }
 
 // ToggleLiveView opens or closes the streamer connection on the camera, if it has one, and initiates or closes the
 // StreamChan on the client.
-// StreamChan will receive raw image data that can be processed by the client.
+// StreamChan will receive raw image data that can be processed by the client. Use LiveView() to receive decoded frames
+// instead.
 func (c *Client) ToggleLiveView(en bool) error {
+	return c.ToggleLiveViewContext(context.Background(), en)
+}
+
+// ToggleLiveViewContext does the same as ToggleLiveView but aborts opening the streamer connection as soon as the
+// context is done.
+func (c *Client) ToggleLiveViewContext(ctx context.Context, en bool) error {
 	if en {
-		return c.initStreamConn()
+		return c.initStreamConn(ctx)
 	}
 
 	return c.closeStreamConn()
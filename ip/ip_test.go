@@ -2,10 +2,15 @@ package ip
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"net"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewDefaultInitiator(t *testing.T) {
@@ -64,9 +69,25 @@ func TestNewResponder(t *testing.T) {
 	}
 }
 
+func TestResponder_AddressIPv6(t *testing.T) {
+	check := map[string]string{
+		"fe80::1%wlan0":   "[fe80::1%wlan0]:15740",
+		"[fe80::1%wlan0]": "[fe80::1%wlan0]:15740",
+		"::1":             "[::1]:15740",
+		"192.168.0.1":     "192.168.0.1:15740",
+	}
+
+	for ip, want := range check {
+		got := NewResponder(DefaultVendor, ip, 15740, 15740, 15740).CommandDataAddress()
+		if got != want {
+			t.Errorf("CommandDataAddress() = %s; want %s", got, want)
+		}
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	guid := "cf2407bc-4b4c-4525-9622-afb30db356df"
-	got, err := NewClient(DefaultVendor, DefaultIpAddress, 26831, "", guid, logLevel)
+	got, err := NewClient(DefaultIpAddress, WithPort(26831), WithGUID(guid), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("NewClient() err = %s; want <nil>", err)
 	}
@@ -133,7 +154,7 @@ func TestNewClient(t *testing.T) {
 }
 
 func TestClient_SetCommandDataPort(t *testing.T) {
-	got, err := NewClient(DefaultVendor, DefaultIpAddress, 55286, "", "5d5069bd-57a5-46e2-83cc-63c897ace234", logLevel)
+	got, err := NewClient(DefaultIpAddress, WithPort(55286), WithGUID("5d5069bd-57a5-46e2-83cc-63c897ace234"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -151,7 +172,7 @@ func TestClient_SetCommandDataPort(t *testing.T) {
 }
 
 func TestClient_SetEventPort(t *testing.T) {
-	got, err := NewClient(DefaultVendor, DefaultIpAddress, 55348, "", "5d5069bd-57a5-46e2-83cc-63c897ace234", logLevel)
+	got, err := NewClient(DefaultIpAddress, WithPort(55348), WithGUID("5d5069bd-57a5-46e2-83cc-63c897ace234"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -169,7 +190,7 @@ func TestClient_SetEventPort(t *testing.T) {
 }
 
 func TestClient_SetStreamerPort(t *testing.T) {
-	got, err := NewClient(DefaultVendor, DefaultIpAddress, 51986, "", "5d5069bd-57a5-46e2-83cc-63c897ace234", logLevel)
+	got, err := NewClient(DefaultIpAddress, WithPort(51986), WithGUID("5d5069bd-57a5-46e2-83cc-63c897ace234"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -218,7 +239,7 @@ func TestClient_incrementTransactionId(t *testing.T) {
 }
 
 func TestClient_sendPacket(t *testing.T) {
-	c, err := NewClient(DefaultVendor, DefaultIpAddress, DefaultPort, "writèr", "e462b590-b516-474a-9db8-a465b370fabd", logLevel)
+	c, err := NewClient(DefaultIpAddress, WithFriendlyName("writèr"), WithGUID("e462b590-b516-474a-9db8-a465b370fabd"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("sendPacket() err = %s; want <nil>", err)
 	}
@@ -237,7 +258,7 @@ func TestClient_sendPacket(t *testing.T) {
 }
 
 func TestClient_readResponse(t *testing.T) {
-	c, err := NewClient(DefaultVendor, DefaultIpAddress, DefaultPort, "writèr", "d6555687-a599-44b8-a4af-279d599a92f6", logLevel)
+	c, err := NewClient(DefaultIpAddress, WithFriendlyName("writèr"), WithGUID("d6555687-a599-44b8-a4af-279d599a92f6"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("readResponse() err = %s; want <nil>", err)
 	}
@@ -297,7 +318,7 @@ func TestClient_readResponse(t *testing.T) {
 }
 
 func TestClient_readRawResponse(t *testing.T) {
-	c, err := NewClient(DefaultVendor, DefaultIpAddress, DefaultPort, "wrîter", "617b38ef-b6e6-4ef6-b2ad-ea51cecdbbd3", logLevel)
+	c, err := NewClient(DefaultIpAddress, WithFriendlyName("wrîter"), WithGUID("617b38ef-b6e6-4ef6-b2ad-ea51cecdbbd3"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("readRawResponse() err = %s; want <nil>", err)
 	}
@@ -324,12 +345,12 @@ func TestClient_readRawResponse(t *testing.T) {
 }
 
 func TestClient_initCommandDataConn(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, okPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = c.initCommandDataConn()
+	err = c.initCommandDataConn(context.Background())
 	if err != nil {
 		t.Errorf("initCommandDataConn() error = %s; want <nil>", err)
 	}
@@ -341,12 +362,12 @@ func TestClient_initCommandDataConn(t *testing.T) {
 	}
 }
 func TestClient_initCommandDataConnFail(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, failPort, "testér", "b3ca53e9-bb61-4c85-9fcd-3b446a9e81e6", logLevel)
+	c, err := NewClient(address, WithPort(failPort), WithFriendlyName("testér"), WithGUID("b3ca53e9-bb61-4c85-9fcd-3b446a9e81e6"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = c.initCommandDataConn()
+	err = c.initCommandDataConn(context.Background())
 	if err == nil {
 		t.Errorf("initCommandDataConn() error = %s; want rejected: device not allowed", err)
 	}
@@ -359,7 +380,7 @@ func TestClient_initCommandDataConnFail(t *testing.T) {
 }
 
 func TestClient_subscribe(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, failPort, "testér", "b3ca53e9-bb61-4c85-9fcd-3b446a9e81e6", logLevel)
+	c, err := NewClient(address, WithPort(failPort), WithFriendlyName("testér"), WithGUID("b3ca53e9-bb61-4c85-9fcd-3b446a9e81e6"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -374,20 +395,51 @@ func TestClient_subscribe(t *testing.T) {
 
 	got, ok := c.cmdDataSubs[tid]
 	if !ok {
-		t.Errorf("subscribe() got = %#v; want true", got)
+		t.Fatalf("subscribe() got = %#v; want true", got)
 	}
-	if got != ch {
-		t.Errorf("subscribe() got = %#v; want %#v", got, ch)
+	if got.ch != ch {
+		t.Errorf("subscribe() got = %#v; want %#v", got.ch, ch)
 	}
 }
 
+func TestClient_publishResponse(t *testing.T) {
+	c, err := NewClient(address, WithPort(failPort), WithFriendlyName("testér"), WithGUID("b3ca53e9-bb61-4c85-9fcd-3b446a9e81e6"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tid := ptp.TransactionID(56)
+	ch := make(chan []byte)
+	if err := c.subscribe(tid, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		// Nobody is reading from the unbuffered channel so this blocks until we unsubscribe.
+		c.publishResponse(tid, []byte{0x01})
+		close(done)
+	}()
+	c.unsubscribe(tid)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishResponse() is still blocking after unsubscribe()")
+	}
+
+	// Publishing to a transaction without subscriber must not panic.
+	c.publishResponse(tid, []byte{0x01})
+}
+
 func TestClient_initEventConn(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, okPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = c.initEventConn()
+	err = c.initEventConn(context.Background())
 	if err != nil {
 		t.Errorf("initEventConn() error = %s; want <nil>", err)
 	}
@@ -400,12 +452,12 @@ func TestClient_initEventConn(t *testing.T) {
 }
 
 func TestClient_initEventConnFail(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, failPort, "testér", "733e8d71-0f05-4aba-9745-ea9294dd2278", logLevel)
+	c, err := NewClient(address, WithPort(failPort), WithFriendlyName("testér"), WithGUID("733e8d71-0f05-4aba-9745-ea9294dd2278"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = c.initEventConn()
+	err = c.initEventConn(context.Background())
 	if err == nil {
 		t.Errorf("initEventConn() error = %s; want rejected: device not allowed", err)
 	}
@@ -418,7 +470,7 @@ func TestClient_initEventConnFail(t *testing.T) {
 }
 
 func TestClient_Dial(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, okPort, "testèr", "7e5ac7d3-46b7-4c50-b0d9-ba56c0e599f0", logLevel)
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("7e5ac7d3-46b7-4c50-b0d9-ba56c0e599f0"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -429,7 +481,7 @@ func TestClient_Dial(t *testing.T) {
 		t.Errorf("Dial() err = %s; want <nil>", err)
 	}
 
-	c, err = NewClient(DefaultVendor, address, failPort, "testér", "f62b41f8-a094-4dab-b537-99afd04c6024", logLevel)
+	c, err = NewClient(address, WithPort(failPort), WithFriendlyName("testér"), WithGUID("f62b41f8-a094-4dab-b537-99afd04c6024"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -441,8 +493,167 @@ func TestClient_Dial(t *testing.T) {
 	}
 }
 
+func TestClient_DialGeneric(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("8f9a0b1c-2d3e-4f5a-8b6c-7d8e9f0a1b2c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genericEventConnMu.Lock()
+	genericEventConnNumber, genericSessionID, genericSessionTID = 0, 0, 0xFFFFFFFF
+	genericEventConnMu.Unlock()
+
+	events := c.Subscribe()
+	if err := c.Dial(); err != nil {
+		t.Fatalf("Dial() err = %s; want <nil>", err)
+	}
+
+	genericEventConnMu.Lock()
+	if genericEventConnNumber != c.ConnectionNumber() {
+		t.Errorf("InitEventRequest ConnectionNumber = %d; want %d", genericEventConnNumber, c.ConnectionNumber())
+	}
+	if genericSessionID != 1 {
+		t.Errorf("OpenSession SessionID = %d; want 1", genericSessionID)
+	}
+	if genericSessionTID != 0 {
+		t.Errorf("OpenSession TransactionID = %d; want 0", genericSessionTID)
+	}
+	// Only initialising the event connection consumes a transaction ID, OpenSession must not.
+	if got := c.TransactionId(); got != 1 {
+		t.Errorf("TransactionId() after Dial() = %d; want 1", got)
+	}
+	genericEventConnMu.Unlock()
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_DevicePropChanged}}, "[test]")
+	select {
+	case evt := <-events:
+		if evt.GetEventCode() != ptp.EC_DevicePropChanged {
+			t.Errorf("event code = %#x; want %#x", evt.GetEventCode(), ptp.EC_DevicePropChanged)
+		}
+	case <-time.After(time.Second):
+		t.Error("no event received on the event connection")
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	check := []struct {
+		vendor string
+		port   uint16
+		guid   string
+		want   []ptp.OperationCode
+	}{
+		{DefaultVendor, okPort, "6e7f8a9b-0c1d-4e2f-9a3b-4c5d6e7f8a9b", []ptp.OperationCode{ptp.OC_CloseSession}},
+		{"fuji", fujiCmdPort, "7f8a9b0c-1d2e-4f3a-8b4c-5d6e7f8a9b0c", []ptp.OperationCode{ptp.OC_TerminateOpenCapture, ptp.OC_CloseSession}},
+	}
+
+	for _, tt := range check {
+		c, err := NewClient(address, WithVendor(tt.vendor), WithPort(tt.port), WithFriendlyName("testèr"), WithGUID(tt.guid), WithLogLevel(logLevel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Dial(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []ptp.OperationCode
+		c.OnSend(func(_ string, p PacketOut, raw []byte) []byte {
+			switch orp := p.(type) {
+			case *OperationRequestPacket:
+				got = append(got, orp.OperationCode)
+			case *FujiOperationRequestPacket:
+				got = append(got, orp.OperationCode)
+			}
+			return raw
+		})
+
+		if err := c.Close(); err != nil {
+			t.Errorf("%s: Close() err = %s; want <nil>", tt.vendor, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Close() operations = %#x; want %#x", tt.vendor, got, tt.want)
+		}
+		if c.commandDataConn != nil || c.eventConn != nil {
+			t.Errorf("%s: Close() left connections open", tt.vendor)
+		}
+
+		// Closing again must not attempt to end the session again.
+		got = nil
+		if err := c.Close(); err != nil {
+			t.Errorf("%s: Close() err = %s; want <nil>", tt.vendor, err)
+		}
+		if len(got) != 0 {
+			t.Errorf("%s: Close() operations = %#x; want none", tt.vendor, got)
+		}
+	}
+}
+
+func TestClient_DialContext(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("7e5ac7d3-46b7-4c50-b0d9-ba56c0e599f0"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = c.DialContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DialContext() err = %v; want %s", err, context.Canceled)
+	}
+}
+
+func TestClient_waitForPacketFromCmdDataConnContext(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("7e5ac7d3-46b7-4c50-b0d9-ba56c0e599f0"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is ever written to the other end of the pipe so reading blocks until the read deadline is reached.
+	var other net.Conn
+	c.commandDataConn, other = net.Pipe()
+	defer other.Close()
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err = c.waitForPacketFromCmdDataConn(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitForPacketFromCmdDataConn() err = %v; want %s", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("waitForPacketFromCmdDataConn() returned after %s; want it to return right after cancellation", d)
+	}
+}
+
+func TestClient_WaitForRawPacketFromCommandDataSubscriberContext(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithGUID("7e5ac7d3-46b7-4c50-b0d9-ba56c0e599f0"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.WaitForRawPacketFromCommandDataSubscriberContext(ctx, make(chan []byte))
+	if err != context.Canceled {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriberContext() err = %v; want %s", err, context.Canceled)
+	}
+
+	ch := make(chan []byte, 1)
+	ch <- []byte{0x01}
+	got, err := c.WaitForRawPacketFromCommandDataSubscriberContext(context.Background(), ch)
+	if err != nil || !bytes.Equal(got, []byte{0x01}) {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriberContext() got = %v, %v; want [1], <nil>", got, err)
+	}
+}
+
 func TestClient_GetDeviceInfo(t *testing.T) {
-	c, err := NewClient(DefaultVendor, address, okPort, "tèster", "558acd44-f794-4b26-9129-d460b2a29e8d", logLevel)
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("558acd44-f794-4b26-9129-d460b2a29e8d"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -457,7 +668,241 @@ func TestClient_GetDeviceInfo(t *testing.T) {
 	if err != nil {
 		t.Errorf("GetDeviceInfo() err = %s; want <nil>", err)
 	}
-	if got == nil {
-		t.Errorf("GetDeviceInfo() got = %v; want *ip.OperationResponsePacket", got)
+	if !reflect.DeepEqual(got, &mockDeviceInfo) {
+		t.Errorf("GetDeviceInfo() got = %#v; want %#v", got, &mockDeviceInfo)
+	}
+}
+
+func TestClient_GetStorageIDs(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5e61ab36-1a4f-4f1c-ae0a-0a1bd4b6c1a3"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetStorageIDs()
+	if err != nil {
+		t.Errorf("GetStorageIDs() err = %s; want <nil>", err)
+	}
+	want := []ptp.StorageID{0x00010001, 0x00020000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStorageIDs() got = %#x; want %#x", got, want)
+	}
+}
+
+func TestClient_GetObjectHandles(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("a4b1e5a2-2cf8-4b0d-9e53-3ff6a2ef0c64"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetObjectHandles(0x00010001, 0, 2)
+	if err != nil {
+		t.Errorf("GetObjectHandles() err = %s; want <nil>", err)
+	}
+	want := []ptp.ObjectHandle{3, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetObjectHandles() got = %#x; want %#x", got, want)
+	}
+}
+
+func TestClient_GetObjectInfo(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("0c0d8a6f-7a53-4d3e-a51c-d7a3a0a2f2d4"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetObjectInfo(3)
+	if err != nil {
+		t.Fatalf("GetObjectInfo() err = %s; want <nil>", err)
+	}
+	if got.Filename != "DSCF0001.JPG" {
+		t.Errorf("GetObjectInfo() Filename = %s; want DSCF0001.JPG", got.Filename)
+	}
+	if got.ObjectCompressedSize != 4096 {
+		t.Errorf("GetObjectInfo() ObjectCompressedSize = %d; want 4096", got.ObjectCompressedSize)
+	}
+	if got.ParentObject != 2 {
+		t.Errorf("GetObjectInfo() ParentObject = %d; want 2", got.ParentObject)
+	}
+
+	_, err = c.GetObjectInfo(99)
+	if err == nil {
+		t.Errorf("GetObjectInfo() err = %s; want %s", err, ptp.OperationResponseCodeAsError(ptp.RC_InvalidObjectHandle))
+	}
+}
+
+func TestClient_DeleteObject(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5a7c1e3d-2b4f-4c6a-9e8d-0f1a2b3c4d5e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteObject(3); err != nil {
+		t.Errorf("DeleteObject() err = %s; want <nil>", err)
+	}
+
+	err = c.DeleteObject(99)
+	if !errors.Is(err, ptp.NewResponseError(ptp.RC_InvalidObjectHandle, ptp.OC_DeleteObject, 0)) {
+		t.Errorf("DeleteObject() err = %v; want %s", err, ptp.OperationResponseCodeAsError(ptp.RC_InvalidObjectHandle))
+	}
+}
+
+func TestClient_GetDevicePropertyDescription(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9b2d6e1f-3c4a-4e5b-8f7a-6d5c4b3a2f1e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetDevicePropertyDescription(ptp.DPC_BatteryLevel)
+	if err != nil {
+		t.Fatalf("GetDevicePropertyDescription() err = %s; want <nil>", err)
+	}
+	if got.CurrentValueAsInt64() != 50 {
+		t.Errorf("GetDevicePropertyDescription() CurrentValue = %d; want 50", got.CurrentValueAsInt64())
+	}
+	rf, ok := got.Form.(*ptp.RangeForm)
+	if !ok {
+		t.Fatalf("GetDevicePropertyDescription() Form = %T; want *ptp.RangeForm", got.Form)
+	}
+	if rf.MaximumValueAsInt64() != 100 {
+		t.Errorf("GetDevicePropertyDescription() MaximumValue = %d; want 100", rf.MaximumValueAsInt64())
+	}
+
+	_, err = c.GetDevicePropertyDescription(ptp.DPC_FocusMode)
+	if err == nil {
+		t.Errorf("GetDevicePropertyDescription() err = %s; want %s", err, ptp.OperationResponseCodeAsError(ptp.RC_DevicePropNotSupported))
+	}
+}
+
+func TestClient_GetDevicePropertyValue(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9b2d6e1f-3c4a-4e5b-8f7a-6d5c4b3a2f1e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[ptp.DevicePropCode]interface{}{
+		ptp.DPC_BatteryLevel: uint8(50),
+		ptp.DPC_Artist:       mockArtist,
+	}
+
+	for code, want := range check {
+		got, err := c.GetDevicePropertyValue(code)
+		if err != nil {
+			t.Errorf("GetDevicePropertyValue() err = %s; want <nil>", err)
+		}
+		if got != want {
+			t.Errorf("GetDevicePropertyValue() got = %#v; want %#v", got, want)
+		}
+	}
+
+	_, err = c.GetDevicePropertyValue(ptp.DPC_FocusMode)
+	if err == nil {
+		t.Errorf("GetDevicePropertyValue() err = %s; want %s", err, ptp.OperationResponseCodeAsError(ptp.RC_DevicePropNotSupported))
+	}
+}
+
+func TestClient_ResetDeviceProperty(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9b2d6e1f-3c4a-4e5b-8f7a-6d5c4b3a2f1e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.ResetDeviceProperty(ptp.DPC_Artist)
+	if err != nil {
+		t.Errorf("ResetDeviceProperty() err = %s; want <nil>", err)
+	}
+
+	check := map[ptp.DevicePropCode]ptp.OperationResponseCode{
+		ptp.DPC_BatteryLevel: ptp.RC_AccessDenied,
+		ptp.DPC_FocusMode:    ptp.RC_DevicePropNotSupported,
+	}
+
+	for code, rc := range check {
+		err = c.ResetDeviceProperty(code)
+		want := ptp.OperationResponseCodeAsError(rc)
+		if !errors.Is(err, want) {
+			t.Errorf("ResetDeviceProperty() err = %s; want %s", err, want)
+		}
+	}
+}
+
+func TestClient_SetDeviceProperty(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("6a7b8c9d-0e1f-4a2b-9c3d-4e5f6a7b8c9d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetDeviceProperty(ptp.DPC_ExposureIndex, 400)
+	if err != nil {
+		t.Errorf("SetDeviceProperty() err = %s; want <nil>", err)
+	}
+
+	got, err := c.GetISO()
+	if err != nil {
+		t.Errorf("GetISO() err = %s; want <nil>", err)
+	}
+	if got != 400 {
+		t.Errorf("GetISO() = %d; want 400", got)
+	}
+
+	check := map[ptp.DevicePropCode]ptp.OperationResponseCode{
+		ptp.DPC_BatteryLevel: ptp.RC_AccessDenied,
+		ptp.DPC_FocusMode:    ptp.RC_DevicePropNotSupported,
+	}
+
+	for code, rc := range check {
+		err = c.SetDeviceProperty(code, 1)
+		want := ptp.OperationResponseCodeAsError(rc)
+		if !errors.Is(err, want) {
+			t.Errorf("SetDeviceProperty() err = %s; want %s", err, want)
+		}
 	}
 }
@@ -0,0 +1,207 @@
+package ip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultJournalSize is the number of entries kept by the journal of a new client.
+const DefaultJournalSize = 1024
+
+// JournalEntryKind indicates what a JournalEntry records.
+type JournalEntryKind int
+
+const (
+	// JournalRequest records an operation request sent to the Responder.
+	JournalRequest JournalEntryKind = iota
+	// JournalResponse records an operation response received from the Responder.
+	JournalResponse
+	// JournalEvent records an event received from the Responder.
+	JournalEvent
+)
+
+func (k JournalEntryKind) String() string {
+	switch k {
+	case JournalRequest:
+		return "request"
+	case JournalResponse:
+		return "response"
+	case JournalEvent:
+		return "event"
+	}
+
+	return fmt.Sprintf("JournalEntryKind(%d)", int(k))
+}
+
+// JournalEntry is a single operation request, operation response or event recorded by a Journal.
+type JournalEntry struct {
+	Time          time.Time
+	Kind          JournalEntryKind
+	TransactionID ptp.TransactionID
+	// OperationCode holds the operation of requests and responses.
+	OperationCode ptp.OperationCode
+	// ResponseCode holds the response code of responses.
+	ResponseCode ptp.OperationResponseCode
+	// EventCode holds the event code of events.
+	EventCode  ptp.EventCode
+	Parameters []uint32
+}
+
+func (e JournalEntry) String() string {
+	var code string
+	switch e.Kind {
+	case JournalRequest:
+		code = fmt.Sprintf("operation=%#x", e.OperationCode)
+	case JournalResponse:
+		code = fmt.Sprintf("operation=%#x response=%#x", e.OperationCode, e.ResponseCode)
+	case JournalEvent:
+		code = fmt.Sprintf("event=%#x", e.EventCode)
+	}
+
+	return fmt.Sprintf("%s %-8s tid=%d %s params=%#x", e.Time.Format(time.RFC3339Nano), e.Kind, e.TransactionID, code,
+		e.Parameters)
+}
+
+// Journal records the operation requests and responses and the events of a session, allowing to correlate them by
+// transaction ID. This is invaluable when debugging why e.g. a capture did not result in an ObjectAdded event. Only
+// the last entries are kept in memory, but all entries can be written to a Writer as well to persist them.
+type Journal struct {
+	entries []JournalEntry
+	size    int
+	w       io.Writer
+	mu      sync.Mutex
+}
+
+// NewJournal returns a Journal keeping the given number of entries in memory. When w is not nil, each entry is also
+// written to it as a line of text, e.g. to keep a log file of the complete session.
+func NewJournal(size int, w io.Writer) *Journal {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Journal{size: size, w: w}
+}
+
+// Entries returns the entries in memory, oldest first.
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]JournalEntry(nil), j.entries...)
+}
+
+// Transaction returns the entries recorded for the given transaction ID: the operation request, the events carrying
+// the transaction ID and the operation response. Since transaction IDs start over in a new session, only the entries
+// recorded since the last request using the transaction ID are returned.
+func (j *Journal) Transaction(tid ptp.TransactionID) []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	start := 0
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		if j.entries[i].Kind == JournalRequest && j.entries[i].TransactionID == tid {
+			start = i
+			break
+		}
+	}
+
+	var entries []JournalEntry
+	for _, e := range j.entries[start:] {
+		if e.TransactionID == tid {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+func (j *Journal) add(e JournalEntry) {
+	if j == nil {
+		return
+	}
+	e.Time = time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.entries) == j.size {
+		copy(j.entries, j.entries[1:])
+		j.entries = j.entries[:len(j.entries)-1]
+	}
+	j.entries = append(j.entries, e)
+
+	if j.w != nil {
+		fmt.Fprintln(j.w, e)
+	}
+}
+
+// recordRequest records the operation request packets, other packets are ignored.
+func (j *Journal) recordRequest(p PacketOut) {
+	switch p := p.(type) {
+	case *OperationRequestPacket:
+		j.add(JournalEntry{
+			Kind:          JournalRequest,
+			TransactionID: p.TransactionID,
+			OperationCode: p.OperationCode,
+			Parameters:    []uint32{p.Parameter1, p.Parameter2, p.Parameter3, p.Parameter4, p.Parameter5},
+		})
+	case *FujiOperationRequestPacket:
+		// Fuji devices send a second request packet carrying the data in a data-out phase.
+		if DataPhase(p.DataPhaseInfo) == DP_DataOut {
+			return
+		}
+		j.add(JournalEntry{
+			Kind:          JournalRequest,
+			TransactionID: p.TransactionID,
+			OperationCode: p.OperationCode,
+			Parameters:    []uint32{p.Parameter1, p.Parameter2, p.Parameter3, p.Parameter4, p.Parameter5},
+		})
+	}
+}
+
+// recordResponse records the operation response of a transaction, decoding the raw parameters.
+func (j *Journal) recordResponse(tid ptp.TransactionID, oc ptp.OperationCode, rc ptp.OperationResponseCode, params []byte) {
+	var ps []uint32
+	for ; len(params) >= 4; params = params[4:] {
+		ps = append(ps, binary.LittleEndian.Uint32(params))
+	}
+
+	j.add(JournalEntry{
+		Kind:          JournalResponse,
+		TransactionID: tid,
+		OperationCode: oc,
+		ResponseCode:  rc,
+		Parameters:    ps,
+	})
+}
+
+// recordEvent records the event.
+func (j *Journal) recordEvent(p EventPacket) {
+	e := JournalEntry{Kind: JournalEvent, EventCode: p.GetEventCode()}
+	switch p := p.(type) {
+	case *GenericEventPacket:
+		e.TransactionID = p.TransactionID
+		e.Parameters = []uint32{p.Parameter1, p.Parameter2, p.Parameter3}
+	case *FujiEventPacket:
+		e.TransactionID = p.TransactionID
+		e.Parameters = []uint32{p.Parameter1, p.Parameter2, p.Parameter3}
+	}
+
+	j.add(e)
+}
+
+// Journal returns the journal recording the operation requests and responses and the events, or nil when the journal
+// was disabled.
+func (c *Client) Journal() *Journal {
+	return c.journal
+}
+
+// SetJournal replaces the journal of the client, e.g. by one persisting the entries. This should be done before calling
+// Dial(). Passing nil disables the journal.
+func (c *Client) SetJournal(j *Journal) {
+	c.journal = j
+}
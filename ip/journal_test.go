@@ -0,0 +1,69 @@
+package ip
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
+	"testing"
+)
+
+func TestJournal_add(t *testing.T) {
+	var buf bytes.Buffer
+	j := NewJournal(2, &buf)
+
+	j.recordRequest(&OperationRequestPacket{OperationRequest: ptp.OperationRequest{
+		OperationCode: ptp.OC_GetObjectInfo,
+		TransactionID: 4,
+		Parameter1:    3,
+	}})
+	j.recordResponse(4, ptp.OC_GetObjectInfo, ptp.RC_OK, []byte{0x01, 0x00, 0x00, 0x00})
+	j.recordEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_DeviceInfoChanged, TransactionID: 0xFFFFFFFF}})
+
+	got := j.Entries()
+	if len(got) != 2 {
+		t.Fatalf("Entries() len = %d; want 2", len(got))
+	}
+	if got[0].Kind != JournalResponse || got[0].ResponseCode != ptp.RC_OK || got[0].Parameters[0] != 1 {
+		t.Errorf("Entries()[0] = %v; want response RC_OK with parameter 1", got[0])
+	}
+	if got[1].Kind != JournalEvent || got[1].EventCode != ptp.EC_DeviceInfoChanged {
+		t.Errorf("Entries()[1] = %v; want event EC_DeviceInfoChanged", got[1])
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("persisted lines = %d; want 3", len(lines))
+	}
+	want := "request  tid=4 operation=0x1008 params=[0x3 0x0 0x0 0x0 0x0]"
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("persisted line = %s; want suffix %s", lines[0], want)
+	}
+}
+
+func TestClient_Journal(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9c0d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.InitiateCaptureAndWait(); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make(map[JournalEntryKind]int)
+	for _, e := range c.Journal().Transaction(c.TransactionId()) {
+		kinds[e.Kind]++
+		if e.Kind == JournalRequest && e.OperationCode != ptp.OC_InitiateCapture {
+			t.Errorf("Transaction() request operation = %#x; want %#x", e.OperationCode, ptp.OC_InitiateCapture)
+		}
+	}
+	// The mock responder sends out an ObjectAdded event followed by a CaptureComplete event.
+	if kinds[JournalRequest] != 1 || kinds[JournalResponse] != 1 || kinds[JournalEvent] != 2 {
+		t.Errorf("Transaction() entries = %v; want 1 request, 1 response and 2 events", kinds)
+	}
+}
@@ -0,0 +1,127 @@
+package ip
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DefaultProbeTimeout is the time to wait for the Responder to answer a probe as recommended by the PTP/IP
+// specification.
+const DefaultProbeTimeout = 10 * time.Second
+
+// ConnectionState indicates if the Responder is still responding to the probes sent over the event connection.
+type ConnectionState int
+
+const (
+	ConnectionAlive ConnectionState = iota
+	ConnectionUnresponsive
+)
+
+// String returns the name of the connection state.
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionAlive:
+		return "alive"
+	case ConnectionUnresponsive:
+		return "unresponsive"
+	}
+
+	return "unknown"
+}
+
+// KeepAlive defines the probes sent to the Responder to keep an idle connection alive. NAT routers and Wi-Fi access
+// points tend to silently drop connections that are idle for too long, which is a common situation when tethering.
+type KeepAlive struct {
+	// Interval is the time between two probes.
+	Interval time.Duration
+	// Timeout is the time to wait for the Responder to answer a probe. When zero, DefaultProbeTimeout is used.
+	Timeout time.Duration
+	// SilenceWindow, when set, makes the client only probe the Responder when nothing was received on the event
+	// connection for at least this long, so Responders that keep sending events are not probed needlessly. The
+	// Interval then is the time between two checks of the silence window.
+	SilenceWindow time.Duration
+	// OnStateChange, when set, is called when the Responder stops answering the probes, with the error that occurred,
+	// and when it starts answering them again.
+	OnStateChange func(ConnectionState, error)
+}
+
+// SetKeepAlive enables sending probes to the Responder. This must be done before calling Dial(). Passing nil disables
+// them, which is the default.
+// When the Responder fails to answer a probe and a ReconnectPolicy is set, the client will attempt to reconnect.
+func (c *Client) SetKeepAlive(ka *KeepAlive) {
+	c.keepAlive = ka
+}
+
+// LastEventConnActivity returns the time the last packet was received on the event connection, which includes events
+// and probe responses, or the time the event connection was established when nothing was received yet. A zero time is
+// returned when the client was never connected.
+func (c *Client) LastEventConnActivity() time.Time {
+	c.activityMu.Lock()
+	defer c.activityMu.Unlock()
+
+	return c.eventActivity
+}
+
+// touchEventConn records activity on the event connection.
+func (c *Client) touchEventConn() {
+	c.activityMu.Lock()
+	c.eventActivity = time.Now()
+	c.activityMu.Unlock()
+}
+
+// Probe checks if the Responder is still active. Standard Responders are sent a ProbeRequestPacket on the event
+// connection, other vendors might use a cheap operation request instead.
+func (c *Client) Probe() error {
+	return c.ProbeContext(context.Background())
+}
+
+// ProbeContext does the same as Probe but aborts as soon as the context is done.
+func (c *Client) ProbeContext(ctx context.Context) error {
+	return c.vendorExtensions.probe(withReadTimeout(ctx, DefaultProbeTimeout), c)
+}
+
+// runKeepAlive probes the Responder on every interval, or once the silence window has passed without any activity on
+// the event connection, until the event connection it was started for is closed or replaced.
+func (c *Client) runKeepAlive(conn net.Conn, ka KeepAlive) {
+	if ka.Interval <= 0 {
+		return
+	}
+	if ka.Timeout <= 0 {
+		ka.Timeout = DefaultProbeTimeout
+	}
+
+	lmp := "[keepAlive]"
+	t := time.NewTicker(ka.Interval)
+	defer t.Stop()
+
+	state := ConnectionAlive
+	for range t.C {
+		if conn != c.conn(eventConnection) {
+			return
+		}
+		if ka.SilenceWindow > 0 && time.Since(c.LastEventConnActivity()) < ka.SilenceWindow {
+			continue
+		}
+
+		err := c.vendorExtensions.probe(withReadTimeout(context.Background(), ka.Timeout), c)
+		if conn != c.conn(eventConnection) {
+			return
+		}
+
+		s := ConnectionAlive
+		if err != nil {
+			s = ConnectionUnresponsive
+			c.Warnf("%s probe failed: %s", lmp, err)
+		}
+		if s != state {
+			state = s
+			if ka.OnStateChange != nil {
+				ka.OnStateChange(s, err)
+			}
+		}
+		if err != nil {
+			c.connectionLost(err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package ip
+
+import (
+	"context"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClient_Probe(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("c4b3a291-8f7e-4d6c-85b4-a39281706f5e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Probe()
+	if err != nil {
+		t.Errorf("Probe() err = %s; want <nil>", err)
+	}
+}
+
+func TestGenericProbeTimeout(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("e5d4c3b2-a190-4f8e-9d7c-6b5a49382716"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The other end of the pipe reads the probe request but never responds.
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+	go readRawResponses(other)
+	c.eventConn = conn
+
+	err = GenericProbe(withReadTimeout(context.Background(), 20*time.Millisecond), c)
+	if err != WaitForProbeError {
+		t.Errorf("GenericProbe() err = %v; want %s", err, WaitForProbeError)
+	}
+}
+
+func TestClient_handleEventConnPacket(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("7a6b5c4d-3e2f-4a1b-8c9d-0e1f2a3b4c5d"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+	c.eventConn = conn
+
+	go c.handleEventConnPacket(&ProbeRequestPacket{})
+	h, _, err := readMessage(other, "[test]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.PacketType != PKT_ProbeResponse {
+		t.Errorf("handleEventConnPacket() responded with packet type %#x; want %#x", h.PacketType, PKT_ProbeResponse)
+	}
+
+	c.handleEventConnPacket(&ProbeResponsePacket{})
+	select {
+	case <-c.probeChan:
+	default:
+		t.Error("handleEventConnPacket() did not signal the probe response")
+	}
+}
+
+func TestClient_runKeepAlive(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("1f2e3d4c-5b6a-4798-8a7b-6c5d4e3f2a1b"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+	go readRawResponses(other)
+	c.eventConn = conn
+
+	states := make(chan ConnectionState, 1)
+	go c.runKeepAlive(conn, KeepAlive{
+		Interval: 10 * time.Millisecond,
+		Timeout:  10 * time.Millisecond,
+		OnStateChange: func(s ConnectionState, _ error) {
+			states <- s
+		},
+	})
+
+	select {
+	case got := <-states:
+		if got != ConnectionUnresponsive {
+			t.Errorf("OnStateChange() state = %d; want %d", got, ConnectionUnresponsive)
+		}
+	case <-time.After(time.Second):
+		t.Error("OnStateChange() was not called")
+	}
+}
+
+func TestClient_runKeepAliveSilenceWindow(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("6d7e8f9a-0b1c-4d2e-8f3a-4b5c6d7e8f9a"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The other end of the pipe reads the probe requests but never responds.
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+	probes := make(chan struct{}, 10)
+	go func() {
+		for {
+			if _, _, err := readMessageRaw(other, "[test]"); err != nil {
+				return
+			}
+			probes <- struct{}{}
+		}
+	}()
+	c.eventConn = conn
+	c.touchEventConn()
+
+	states := make(chan ConnectionState, 1)
+	go c.runKeepAlive(conn, KeepAlive{
+		Interval:      10 * time.Millisecond,
+		Timeout:       10 * time.Millisecond,
+		SilenceWindow: 200 * time.Millisecond,
+		OnStateChange: func(s ConnectionState, _ error) {
+			states <- s
+		},
+	})
+
+	select {
+	case <-probes:
+		t.Fatal("probe sent within the silence window")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	select {
+	case got := <-states:
+		if got != ConnectionUnresponsive {
+			t.Errorf("OnStateChange() state = %d; want %d", got, ConnectionUnresponsive)
+		}
+	case <-time.After(time.Second):
+		t.Error("OnStateChange() was not called after the silence window")
+	}
+}
+
+func TestClient_LastEventConnActivity(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3b4c5d6e-7f8a-4b9c-8d1e-2f3a4b5c6d7f"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.LastEventConnActivity(); !got.IsZero() {
+		t.Errorf("LastEventConnActivity() = %s; want zero time before dialing", got)
+	}
+	events := c.Events()
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	dialed := c.LastEventConnActivity()
+	if dialed.IsZero() {
+		t.Fatal("LastEventConnActivity() = zero time; want the time the event connection was established")
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_ObjectAdded, Parameter1: 3}}, "[test]")
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	if got := c.LastEventConnActivity(); !got.After(dialed) {
+		t.Errorf("LastEventConnActivity() = %s; want after %s", got, dialed)
+	}
+}
+
+// readRawResponses keeps on reading from r, discarding everything, until it fails.
+func readRawResponses(r net.Conn) {
+	for {
+		if _, _, err := readMessageRaw(r, "[test]"); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"time"
+)
+
+var LiveViewInUseError = errors.New("live view already in use")
+
+// LiveViewFrame is a single decoded live view frame.
+type LiveViewFrame struct {
+	// Sequence numbers the frames received since live view was enabled, starting at 1. Frames that cannot be decoded
+	// are skipped, which shows as a gap in the sequence.
+	Sequence uint64
+	// Time is the time the frame was received.
+	Time time.Time
+	// Image holds the decoded frame.
+	Image image.Image
+	// Data holds the JPEG data as it was received.
+	Data []byte
+	// Histogram holds the histogram of the frame when the frames are passed through HistogramLiveView().
+	Histogram *Histogram
+	// Metadata holds the camera state sent along with the frame. It is nil when the vendor sends no metadata.
+	Metadata *LiveViewMetadata
+}
+
+// FocusState is the focus state of the camera as reported along with a live view frame.
+type FocusState uint8
+
+const (
+	FS_Unknown FocusState = iota
+	FS_Searching
+	FS_Focused
+	FS_Failed
+)
+
+func (fs FocusState) String() string {
+	switch fs {
+	case FS_Searching:
+		return "searching"
+	case FS_Focused:
+		return "focused"
+	case FS_Failed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
+// LiveViewMetadata holds the camera state some vendors send along with the live view frames, allowing overlays to show
+// the real camera state.
+type LiveViewMetadata struct {
+	// FrameNumber is the frame counter of the camera, which wraps around.
+	FrameNumber uint8
+	FocusState  FocusState
+	// Faces holds the bounding boxes of the detected faces in image coordinates.
+	Faces []image.Rectangle
+}
+
+// streamFrame holds the image data received on the streamer connection along with the metadata, if any.
+type streamFrame struct {
+	data     []byte
+	metadata *LiveViewMetadata
+	received time.Time
+}
+
+// LiveView enables live view and returns a channel receiving the decoded frames, decoupling the transport from the
+// rendering of the frames. Live view is disabled and the channel is closed as soon as the context is done or the
+// streamer connection is lost. Only one LiveView can be in use at a time: LiveViewInUseError is returned until the
+// channel of the previous one is closed. Share the frames among several consumers instead. Not all vendors support
+// this!
+func (c *Client) LiveView(ctx context.Context) (<-chan *LiveViewFrame, error) {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	if c.liveView {
+		return nil, LiveViewInUseError
+	}
+	// Vendors sending metadata along with the frames hand both of them to LiveView, unless the streamer connection was
+	// already opened using ToggleLiveView().
+	if c.conn(streamConnection) == nil {
+		c.streamFrames = make(chan *streamFrame, 50)
+	}
+	if err := c.openStreamConn(ctx); err != nil {
+		c.streamFrames = nil
+		return nil, err
+	}
+	c.liveView = true
+
+	frames := make(chan *LiveViewFrame)
+	go c.decodeLiveView(ctx, c.StreamChan, c.streamFrames, frames)
+
+	return frames, nil
+}
+
+// decodeLiveView decodes the JPEG data received from src or, along with the metadata, from srcMeta and sends the frames
+// to dst until the context is done or the stream listener stops.
+func (c *Client) decodeLiveView(ctx context.Context, src <-chan []byte, srcMeta <-chan *streamFrame,
+	dst chan<- *LiveViewFrame) {
+	lmp := "[liveView]"
+	defer close(dst)
+	// A new LiveView can start as soon as the consumer notices the channel is closed.
+	defer func() {
+		c.streamMu.Lock()
+		c.liveView = false
+		c.streamMu.Unlock()
+	}()
+
+	var seq uint64
+	for {
+		var (
+			sf *streamFrame
+			ok bool
+		)
+		select {
+		case sf, ok = <-srcMeta:
+		case data, okd := <-src:
+			sf, ok = &streamFrame{data: data}, okd
+		case <-ctx.Done():
+			c.stopLiveView(lmp)
+			return
+		}
+		if !ok {
+			c.Infof("%s streamer channel closed", lmp)
+			return
+		}
+
+		seq++
+		img, err := jpeg.Decode(bytes.NewReader(sf.data))
+		if err != nil {
+			c.Warnf("%s unable to decode frame %d: %s", lmp, seq, err)
+			c.lvStats.dropped()
+			continue
+		}
+		// Frames taken from StreamChan were counted as delivered by the stream listener already.
+		received := sf.received
+		if received.IsZero() {
+			received = time.Now()
+		}
+		select {
+		case dst <- &LiveViewFrame{Sequence: seq, Time: received, Image: img, Data: sf.data, Metadata: sf.metadata}:
+			if !sf.received.IsZero() {
+				c.lvStats.delivered(sf.received)
+			}
+		case <-ctx.Done():
+			c.stopLiveView(lmp)
+			return
+		}
+	}
+}
+
+// LiveViewSnapshot enables live view, grabs the next frame and disables live view again, returning the frame as JPEG
+// data for a quick preview without triggering the shutter. When overlay is not nil, it is called to draw on the frame
+// before encoding it, e.g. to composite the viewfinder widgets using viewfinder.DrawViewfinder(). LiveViewInUseError is
+// returned when live view is already enabled. Not all vendors support this!
+func (c *Client) LiveViewSnapshot(ctx context.Context, overlay func(*image.RGBA)) ([]byte, error) {
+	if c.conn(streamConnection) != nil {
+		return nil, LiveViewInUseError
+	}
+
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	frames, err := c.LiveView(lctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *LiveViewFrame
+	select {
+	case f = <-frames:
+	case <-ctx.Done():
+	}
+	// Live view has been disabled once the frames channel is closed.
+	cancel()
+	for range frames {
+	}
+
+	if f == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: streamer connection closed before receiving a frame", ConnectionLostError)
+	}
+	if overlay == nil {
+		return f.Data, nil
+	}
+
+	b := f.Image.Bounds()
+	img := image.NewRGBA(b)
+	draw.Draw(img, b, f.Image, b.Min, draw.Src)
+	overlay(img)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Client) stopLiveView(lmp string) {
+	if err := c.ToggleLiveView(false); err != nil {
+		c.Warnf("%s unable to disable live view: %s", lmp, err)
+	}
+}
+
+// ThrottleLiveView caps the rate at which the frames are delivered to one frame per interval, e.g. time.Second / 10
+// for a web UI showing 10 frames per second. The latest frame wins: frames arriving while waiting for the interval to
+// pass or for the consumer to receive the previous frame replace the pending frame, so a slow consumer always gets a
+// fresh frame rather than an ever-growing backlog. An interval of 0 only drops the frames a slow consumer cannot keep up
+// with. The returned channel is closed when the frames channel is closed.
+func ThrottleLiveView(frames <-chan *LiveViewFrame, interval time.Duration) <-chan *LiveViewFrame {
+	out := make(chan *LiveViewFrame)
+	go func() {
+		defer close(out)
+
+		var (
+			pending *LiveViewFrame
+			next    time.Time
+		)
+		for {
+			var (
+				send chan<- *LiveViewFrame
+				wait <-chan time.Time
+			)
+			if pending != nil {
+				if d := time.Until(next); d > 0 {
+					wait = after(d)
+				} else {
+					send = out
+				}
+			}
+
+			select {
+			case f, ok := <-frames:
+				if !ok {
+					return
+				}
+				pending = f
+			case send <- pending:
+				pending = nil
+				next = time.Now().Add(interval)
+			case <-wait:
+			}
+		}
+	}()
+
+	return out
+}
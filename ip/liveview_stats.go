@@ -0,0 +1,117 @@
+package ip
+
+import (
+	"sync"
+	"time"
+)
+
+// LiveViewStats holds the live view statistics, which help tuning e.g. a Wi-Fi setup.
+type LiveViewStats struct {
+	// Received is the number of frames received on the streamer connection.
+	Received uint64 `json:"received"`
+	// Delivered is the number of frames handed to the consumer: the receiver of the frames returned by LiveView() or,
+	// when live view is consumed using StreamChan, StreamChan itself.
+	Delivered uint64 `json:"delivered"`
+	// Dropped is the number of frames lost along the way: frames the camera sent but never arrived, for vendors that
+	// number their frames, frames that could not be parsed and frames that could not be decoded.
+	Dropped uint64 `json:"dropped"`
+	// FPS is the number of frames delivered per second, measured over the last second.
+	FPS float64 `json:"fps"`
+	// Latency is the moving average of the time between receiving a frame and delivering it.
+	Latency time.Duration `json:"latency_ns"`
+	// MaxLatency is the highest latency seen.
+	MaxLatency time.Duration `json:"max_latency_ns"`
+}
+
+// liveViewStats collects the live view statistics.
+type liveViewStats struct {
+	mu    sync.Mutex
+	stats LiveViewStats
+	// number holds the number of the last frame received and numbered whether it is set.
+	number   uint8
+	numbered bool
+	// windowStart and windowCount are used to compute the frame rate.
+	windowStart   time.Time
+	windowCount   int
+	lastDelivered time.Time
+}
+
+func (s *liveViewStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats = LiveViewStats{}
+	s.numbered = false
+	s.windowStart = time.Time{}
+	s.windowCount = 0
+	s.lastDelivered = time.Time{}
+}
+
+// received counts a frame numbered by the camera, the counter of which wraps around after 0xff.
+func (s *liveViewStats) received(number uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Received++
+	if s.numbered {
+		s.stats.Dropped += uint64(number - s.number - 1)
+	}
+	s.number = number
+	s.numbered = true
+}
+
+func (s *liveViewStats) dropped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Dropped++
+}
+
+// delivered counts a frame handed to the consumer, which was received at the given time.
+func (s *liveViewStats) delivered(received time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.stats.Delivered++
+	s.lastDelivered = now
+
+	l := now.Sub(received)
+	if s.stats.Latency == 0 {
+		s.stats.Latency = l
+	} else {
+		s.stats.Latency += (l - s.stats.Latency) / 8
+	}
+	if l > s.stats.MaxLatency {
+		s.stats.MaxLatency = l
+	}
+
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+		return
+	}
+	s.windowCount++
+	if d := now.Sub(s.windowStart); d >= time.Second {
+		s.stats.FPS = float64(s.windowCount) / d.Seconds()
+		s.windowStart = now
+		s.windowCount = 0
+	}
+}
+
+func (s *liveViewStats) snapshot() LiveViewStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stats
+	// The frame rate is stale when no frames are coming in.
+	if time.Since(s.lastDelivered) > 2*time.Second {
+		st.FPS = 0
+	}
+
+	return st
+}
+
+// LiveViewStats returns the statistics of the live view since it was last enabled.
+func (c *Client) LiveViewStats() LiveViewStats {
+	return c.lvStats.snapshot()
+}
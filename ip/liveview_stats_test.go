@@ -0,0 +1,45 @@
+package ip
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLiveViewStats(t *testing.T) {
+	var s liveViewStats
+
+	// The counter wraps around: frames 0x00 and 0x01 got lost.
+	for _, n := range []uint8{0xfd, 0xfe, 0xff, 0x02} {
+		s.received(n)
+	}
+	s.dropped()
+	s.delivered(time.Now().Add(-40 * time.Millisecond))
+	s.delivered(time.Now().Add(-20 * time.Millisecond))
+
+	got := s.snapshot()
+	if got.Received != 4 {
+		t.Errorf("snapshot() Received = %d; want 4", got.Received)
+	}
+	if got.Dropped != 3 {
+		t.Errorf("snapshot() Dropped = %d; want 3", got.Dropped)
+	}
+	if got.Delivered != 2 {
+		t.Errorf("snapshot() Delivered = %d; want 2", got.Delivered)
+	}
+	if got.MaxLatency < 40*time.Millisecond {
+		t.Errorf("snapshot() MaxLatency = %s; want at least 40ms", got.MaxLatency)
+	}
+	if got.Latency < 20*time.Millisecond || got.Latency >= got.MaxLatency {
+		t.Errorf("snapshot() Latency = %s; want between 20ms and %s", got.Latency, got.MaxLatency)
+	}
+
+	s.reset()
+	if got := s.snapshot(); got != (LiveViewStats{}) {
+		t.Errorf("snapshot() after reset = %+v; want zero stats", got)
+	}
+	// The counter starts over after a reset.
+	s.received(0x10)
+	if got := s.snapshot(); got.Dropped != 0 {
+		t.Errorf("snapshot() Dropped = %d; want 0", got.Dropped)
+	}
+}
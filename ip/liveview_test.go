@@ -0,0 +1,147 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"net"
+	"testing"
+	"time"
+)
+
+// fujiStreamFrame returns a Fuji stream frame holding a JPEG image of the given size.
+func fujiStreamFrame(t *testing.T, number uint8, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := make([]byte, fujiStreamFrameHeaderSize, fujiStreamFrameHeaderSize+buf.Len())
+	raw[8] = number
+	raw = append(raw, buf.Bytes()...)
+	binary.LittleEndian.PutUint32(raw[0:4], uint32(len(raw)))
+
+	return raw
+}
+
+func TestClient_LiveView(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+
+	c, err := NewClient(address, WithVendor("fuji"), WithFriendlyName("testèr"), WithGUID("1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9"), WithDialer(NewConnDialer(conn)), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frames, err := c.LiveView(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LiveView(ctx); err != LiveViewInUseError {
+		t.Errorf("LiveView() err = %v; want %s while the previous one is in use", err, LiveViewInUseError)
+	}
+
+	go other.Write(fujiStreamFrame(t, 1, 16, 8))
+
+	select {
+	case f := <-frames:
+		if f.Sequence != 1 {
+			t.Errorf("LiveView() Sequence = %d; want 1", f.Sequence)
+		}
+		if b := f.Image.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+			t.Errorf("LiveView() image bounds = %v; want 16x8", b)
+		}
+		if f.Time.IsZero() {
+			t.Error("LiveView() Time is not set")
+		}
+		if f.Metadata == nil || f.Metadata.FrameNumber != 1 {
+			t.Errorf("LiveView() Metadata = %+v; want frame number 1", f.Metadata)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("LiveView() no frame received")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-frames:
+		if ok {
+			t.Error("LiveView() received frame after the context was cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("LiveView() channel not closed after the context was cancelled")
+	}
+
+	// The only connection of the dialer was used, but live view is no longer in use.
+	if _, err := c.LiveView(context.Background()); err == nil || err == LiveViewInUseError {
+		t.Errorf("LiveView() err = %v; want a dial error after the previous one stopped", err)
+	}
+}
+
+func TestClient_LiveViewSnapshot(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+
+	c, err := NewClient(address, WithVendor("fuji"), WithFriendlyName("testèr"), WithGUID("5c4b3a29-1807-4f6e-9d5c-4b3a29180706"), WithDialer(NewConnDialer(conn)), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go other.Write(fujiStreamFrame(t, 1, 16, 8))
+
+	var called bool
+	data, err := c.LiveViewSnapshot(context.Background(), func(img *image.RGBA) {
+		called = img.Bounds().Dx() == 16 && img.Bounds().Dy() == 8
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("LiveViewSnapshot() overlay not called with the frame")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("LiveViewSnapshot() JPEG size = %dx%d; want 16x8", cfg.Width, cfg.Height)
+	}
+	if c.streamConn != nil {
+		t.Error("LiveViewSnapshot() live view not disabled")
+	}
+
+	c.streamConn = conn
+	if _, err := c.LiveViewSnapshot(context.Background(), nil); err != LiveViewInUseError {
+		t.Errorf("LiveViewSnapshot() err = %v; want %s", err, LiveViewInUseError)
+	}
+}
+
+func TestThrottleLiveView(t *testing.T) {
+	frames := make(chan *LiveViewFrame)
+	out := ThrottleLiveView(frames, time.Hour)
+
+	// Nobody receives the frames, so each frame replaces the previous one.
+	for i := uint64(1); i <= 3; i++ {
+		frames <- &LiveViewFrame{Sequence: i}
+	}
+	if got := <-out; got.Sequence != 3 {
+		t.Errorf("ThrottleLiveView() Sequence = %d; want 3", got.Sequence)
+	}
+
+	// The next frame is held back until the interval has passed.
+	frames <- &LiveViewFrame{Sequence: 4}
+	select {
+	case got := <-out:
+		t.Errorf("ThrottleLiveView() got frame %d; want none before the interval has passed", got.Sequence)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(frames)
+	if _, ok := <-out; ok {
+		t.Error("ThrottleLiveView() channel not closed after closing the frames channel")
+	}
+}
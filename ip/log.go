@@ -77,73 +77,73 @@ type StdLogger struct {
 
 func (sl *StdLogger) Debug(v ...interface{}) {
 	if sl.level >= LevelDebug {
-		log.Print(v...)
+		sl.Print(v...)
 	}
 }
 
 func (sl *StdLogger) Debugf(format string, v ...interface{}) {
 	if sl.level >= LevelDebug {
-		log.Printf(format, v...)
+		sl.Printf(format, v...)
 	}
 }
 
 func (sl *StdLogger) Debugln(v ...interface{}) {
 	if sl.level >= LevelDebug {
-		log.Println(v...)
+		sl.Println(v...)
 	}
 }
 
 func (sl *StdLogger) Error(v ...interface{}) {
 	if sl.level > LevelSilent {
-		log.Print(v...)
+		sl.Print(v...)
 	}
 }
 
 func (sl *StdLogger) Errorf(format string, v ...interface{}) {
 	if sl.level > LevelSilent {
-		log.Printf(format, v...)
+		sl.Printf(format, v...)
 	}
 }
 
 func (sl *StdLogger) Errorln(v ...interface{}) {
 	if sl.level > LevelSilent {
-		log.Println(v...)
+		sl.Println(v...)
 	}
 }
 
 func (sl *StdLogger) Info(v ...interface{}) {
 	if sl.level >= LevelVeryVerbose {
-		log.Print(v...)
+		sl.Print(v...)
 	}
 }
 
 func (sl *StdLogger) Infof(format string, v ...interface{}) {
 	if sl.level >= LevelVeryVerbose {
-		log.Printf(format, v...)
+		sl.Printf(format, v...)
 	}
 }
 
 func (sl *StdLogger) Infoln(v ...interface{}) {
 	if sl.level >= LevelVeryVerbose {
-		log.Println(v...)
+		sl.Println(v...)
 	}
 }
 
 func (sl *StdLogger) Warn(v ...interface{}) {
 	if sl.level >= LevelVerbose {
-		log.Print(v...)
+		sl.Print(v...)
 	}
 }
 
 func (sl *StdLogger) Warnf(format string, v ...interface{}) {
 	if sl.level >= LevelVerbose {
-		log.Printf(format, v...)
+		sl.Printf(format, v...)
 	}
 }
 
 func (sl *StdLogger) Warnln(v ...interface{}) {
 	if sl.level >= LevelVerbose {
-		log.Println(v...)
+		sl.Println(v...)
 	}
 }
 
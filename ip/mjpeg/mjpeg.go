@@ -0,0 +1,107 @@
+package mjpeg
+
+import (
+	"github.com/malc0mn/ptp-ip/ip"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// broadcaster hands the latest frame received from a channel to any number of consumers.
+type broadcaster struct {
+	frame *ip.LiveViewFrame
+	// update is closed and replaced each time a new frame arrives.
+	update chan struct{}
+	done   bool
+	mu     sync.Mutex
+}
+
+func newBroadcaster(frames <-chan *ip.LiveViewFrame) *broadcaster {
+	b := &broadcaster{update: make(chan struct{})}
+	go b.run(frames)
+
+	return b
+}
+
+func (b *broadcaster) run(frames <-chan *ip.LiveViewFrame) {
+	for f := range frames {
+		b.mu.Lock()
+		b.frame = f
+		close(b.update)
+		b.update = make(chan struct{})
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	b.done = true
+	close(b.update)
+	b.mu.Unlock()
+}
+
+// next returns the latest frame and the channel that is closed when the next frame arrives. The boolean is false when
+// no more frames will arrive.
+func (b *broadcaster) next() (*ip.LiveViewFrame, <-chan struct{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.frame, b.update, !b.done
+}
+
+// Handler is an http.Handler serving the live view frames as a multipart/x-mixed-replace stream. Any number of clients
+// can watch the stream: each of them is sent the latest frame as soon as it arrives, so a slow client skips frames
+// instead of falling behind.
+type Handler struct {
+	*broadcaster
+}
+
+// NewHandler returns a Handler serving the frames received from the given channel, e.g. the channel returned by
+// ip.Client.LiveView(). The streams end when the channel is closed.
+func NewHandler(frames <-chan *ip.LiveViewFrame) *Handler {
+	return &Handler{newBroadcaster(frames)}
+}
+
+// ServeHTTP streams the frames to the client until the client goes away or no more frames will arrive.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		frame, update, ok := h.next()
+		if !ok {
+			mw.Close()
+			return
+		}
+		if frame != nil {
+			if err := writeFrame(mw, frame.Data); err != nil {
+				return
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+
+		select {
+		case <-update:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeFrame writes the JPEG data as the next part of the stream.
+func writeFrame(mw *multipart.Writer, frame []byte) error {
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":   {"image/jpeg"},
+		"Content-Length": {strconv.Itoa(len(frame))},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(frame)
+
+	return err
+}
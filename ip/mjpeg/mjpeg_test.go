@@ -0,0 +1,69 @@
+package mjpeg
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ip"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	frames := make(chan *ip.LiveViewFrame)
+	h := NewHandler(frames)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	// The handler sends the latest frame to clients connecting later on.
+	want := [][]byte{{0xff, 0xd8, 0x01, 0xff, 0xd9}, {0xff, 0xd8, 0x02, 0xff, 0xd9}}
+	frames <- &ip.LiveViewFrame{Sequence: 1, Data: want[0]}
+	for frame, _, _ := h.next(); frame == nil; frame, _, _ = h.next() {
+		time.Sleep(time.Millisecond)
+	}
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	mt, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt != "multipart/x-mixed-replace" {
+		t.Errorf("ServeHTTP() Content-Type = %s; want multipart/x-mixed-replace", mt)
+	}
+
+	// A part only ends when the boundary of the next part is read, so the next frame must be sent before reading one.
+	mr := multipart.NewReader(res.Body, params["boundary"])
+	frames <- &ip.LiveViewFrame{Sequence: 2, Data: want[1]}
+	for i, w := range want {
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := p.Header.Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("ServeHTTP() part Content-Type = %s; want image/jpeg", ct)
+		}
+		if i == 1 {
+			close(frames)
+		}
+		got, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, w) {
+			t.Errorf("ServeHTTP() part %d = % x; want % x", i, got, w)
+		}
+	}
+
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("ServeHTTP() err = %v; want %s", err, io.EOF)
+	}
+}
@@ -0,0 +1,324 @@
+package mjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+var RecorderClosedError = errors.New("recorder closed")
+
+// FrameWriter writes live view frames, e.g. to a file.
+type FrameWriter interface {
+	WriteFrame(f *ip.LiveViewFrame) error
+	// Close finishes the recording.
+	Close() error
+}
+
+// Record writes the frames to the FrameWriter until the frames channel is closed, after which the FrameWriter is
+// closed. Recording stops at the first error, but the FrameWriter is closed nonetheless so the frames written so far
+// are kept.
+func Record(fw FrameWriter, frames <-chan *ip.LiveViewFrame) error {
+	var err error
+	for f := range frames {
+		if err = fw.WriteFrame(f); err != nil {
+			break
+		}
+	}
+
+	if cerr := fw.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+const (
+	// aviHeaderSize is the size of everything preceding the first frame in the AVI file.
+	aviHeaderSize = 224
+	// aviDefaultFrameRate is used when the frame rate cannot be determined from the frames.
+	aviDefaultFrameRate = 10
+	avifHasIndex        = 0x00000010
+	aviifKeyFrame       = 0x00000010
+)
+
+// AVIWriter is a FrameWriter writing the frames as-is to an AVI file using the MJPEG codec, which most video players
+// can play. The frame rate is derived from the time the frames were received, since the live view of most cameras does
+// not run at a fixed frame rate.
+type AVIWriter struct {
+	w io.WriteSeeker
+	// index holds the offset and size of each frame, relative to the start of the movi list.
+	index  []uint32
+	offset uint32
+	width  uint32
+	height uint32
+	// maxSize holds the size of the largest frame.
+	maxSize uint32
+	first   time.Time
+	last    time.Time
+	closed  bool
+}
+
+// NewAVIWriter returns an AVIWriter writing to w. The header is written right away and completed when the writer is
+// closed, which is why w must be seekable.
+func NewAVIWriter(w io.WriteSeeker) (*AVIWriter, error) {
+	aw := &AVIWriter{w: w, offset: 4}
+	if _, err := w.Write(aw.header()); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// WriteFrame appends the JPEG data of the frame to the file. The size of the video is taken from the first frame.
+func (aw *AVIWriter) WriteFrame(f *ip.LiveViewFrame) error {
+	if aw.closed {
+		return RecorderClosedError
+	}
+	if len(aw.index) == 0 {
+		if err := aw.setSize(f); err != nil {
+			return err
+		}
+		aw.first = f.Time
+	}
+	aw.last = f.Time
+
+	size := uint32(len(f.Data))
+	chunk := make([]byte, 8, 8+len(f.Data)+1)
+	copy(chunk, "00dc")
+	binary.LittleEndian.PutUint32(chunk[4:], size)
+	chunk = append(chunk, f.Data...)
+	// Chunks are aligned on a word boundary.
+	if size%2 != 0 {
+		chunk = append(chunk, 0)
+	}
+	if _, err := aw.w.Write(chunk); err != nil {
+		return err
+	}
+
+	aw.index = append(aw.index, aw.offset, size)
+	aw.offset += uint32(len(chunk))
+	if size > aw.maxSize {
+		aw.maxSize = size
+	}
+
+	return nil
+}
+
+func (aw *AVIWriter) setSize(f *ip.LiveViewFrame) error {
+	if f.Image != nil {
+		aw.width, aw.height = uint32(f.Image.Bounds().Dx()), uint32(f.Image.Bounds().Dy())
+		return nil
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(f.Data))
+	if err != nil {
+		return err
+	}
+	aw.width, aw.height = uint32(cfg.Width), uint32(cfg.Height)
+
+	return nil
+}
+
+// Close writes the index and completes the header. The underlying writer is not closed.
+func (aw *AVIWriter) Close() error {
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+
+	idx := make([]byte, 8+len(aw.index)*8)
+	copy(idx, "idx1")
+	binary.LittleEndian.PutUint32(idx[4:], uint32(len(aw.index)*8))
+	for i := 0; i < len(aw.index); i += 2 {
+		e := idx[8+i*8:]
+		copy(e, "00dc")
+		binary.LittleEndian.PutUint32(e[4:], aviifKeyFrame)
+		binary.LittleEndian.PutUint32(e[8:], aw.index[i])
+		binary.LittleEndian.PutUint32(e[12:], aw.index[i+1])
+	}
+	if _, err := aw.w.Write(idx); err != nil {
+		return err
+	}
+
+	if _, err := aw.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := aw.w.Write(aw.header()); err != nil {
+		return err
+	}
+	_, err := aw.w.Seek(0, io.SeekEnd)
+
+	return err
+}
+
+// frameRate returns the average number of frames per second.
+func (aw *AVIWriter) frameRate() uint32 {
+	frames := len(aw.index) / 2
+	d := aw.last.Sub(aw.first)
+	if frames < 2 || d <= 0 {
+		return aviDefaultFrameRate
+	}
+	if fps := uint32(time.Duration(frames-1) * time.Second / d); fps > 0 {
+		return fps
+	}
+
+	return 1
+}
+
+// header returns the RIFF header, the hdrl list and the start of the movi list.
+func (aw *AVIWriter) header() []byte {
+	frames := uint32(len(aw.index) / 2)
+	fps := aw.frameRate()
+	moviSize := aw.offset
+	idxSize := 8 + frames*16
+
+	h := make([]byte, aviHeaderSize)
+	le := binary.LittleEndian
+	copy(h[0:], "RIFF")
+	le.PutUint32(h[4:], aviHeaderSize-8+moviSize-4+idxSize)
+	copy(h[8:], "AVI ")
+	copy(h[12:], "LIST")
+	le.PutUint32(h[16:], 192)
+	copy(h[20:], "hdrl")
+
+	// The main AVI header.
+	copy(h[24:], "avih")
+	le.PutUint32(h[28:], 56)
+	le.PutUint32(h[32:], 1000000/fps)
+	le.PutUint32(h[36:], aw.maxSize*fps)
+	le.PutUint32(h[44:], avifHasIndex)
+	le.PutUint32(h[48:], frames)
+	le.PutUint32(h[56:], 1)
+	le.PutUint32(h[60:], aw.maxSize)
+	le.PutUint32(h[64:], aw.width)
+	le.PutUint32(h[68:], aw.height)
+
+	// The stream header of the video stream.
+	copy(h[88:], "LIST")
+	le.PutUint32(h[92:], 116)
+	copy(h[96:], "strl")
+	copy(h[100:], "strh")
+	le.PutUint32(h[104:], 56)
+	copy(h[108:], "vids")
+	copy(h[112:], "MJPG")
+	le.PutUint32(h[128:], 1)
+	le.PutUint32(h[132:], fps)
+	le.PutUint32(h[140:], frames)
+	le.PutUint32(h[144:], aw.maxSize)
+	le.PutUint32(h[148:], 0xFFFFFFFF)
+	le.PutUint16(h[160:], uint16(aw.width))
+	le.PutUint16(h[162:], uint16(aw.height))
+
+	// The stream format: a BITMAPINFOHEADER.
+	copy(h[164:], "strf")
+	le.PutUint32(h[168:], 40)
+	le.PutUint32(h[172:], 40)
+	le.PutUint32(h[176:], aw.width)
+	le.PutUint32(h[180:], aw.height)
+	le.PutUint16(h[184:], 1)
+	le.PutUint16(h[186:], 24)
+	copy(h[188:], "MJPG")
+	le.PutUint32(h[192:], aw.width*aw.height*3)
+
+	copy(h[212:], "LIST")
+	le.PutUint32(h[216:], moviSize)
+	copy(h[220:], "movi")
+
+	return h
+}
+
+// CommandWriter is a FrameWriter piping the JPEG data of the frames to the standard input of an external command, e.g.
+// to encode the live view to MP4 using:
+//   ffmpeg -f mjpeg -i - -pix_fmt yuv420p liveview.mp4
+type CommandWriter struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// NewCommandWriter starts the command which is sent the frames.
+func NewCommandWriter(cmd *exec.Cmd) (*CommandWriter, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &CommandWriter{cmd: cmd, stdin: stdin}, nil
+}
+
+// WriteFrame writes the JPEG data of the frame to the standard input of the command.
+func (cw *CommandWriter) WriteFrame(f *ip.LiveViewFrame) error {
+	_, err := cw.stdin.Write(f.Data)
+
+	return err
+}
+
+// Close closes the standard input of the command and waits for the command to finish.
+func (cw *CommandWriter) Close() error {
+	if err := cw.stdin.Close(); err != nil {
+		return err
+	}
+
+	return cw.cmd.Wait()
+}
+
+// JPEGWriter is a FrameWriter writing the JPEG data of the frames back to back, which is what ffmpeg reads using the
+// mjpeg input format, e.g. when writing to the standard output:
+//   ptpip -c "liveview stdout" | ffmpeg -f mjpeg -i - -pix_fmt yuv420p liveview.mp4
+type JPEGWriter struct {
+	w io.Writer
+}
+
+// NewJPEGWriter returns a JPEGWriter writing to w.
+func NewJPEGWriter(w io.Writer) *JPEGWriter {
+	return &JPEGWriter{w: w}
+}
+
+// WriteFrame writes the JPEG data of the frame.
+func (jw *JPEGWriter) WriteFrame(f *ip.LiveViewFrame) error {
+	_, err := jw.w.Write(f.Data)
+
+	return err
+}
+
+// Close does nothing: the underlying writer is not closed.
+func (jw *JPEGWriter) Close() error {
+	return nil
+}
+
+// DirWriter is a FrameWriter writing each frame to a separate JPEG file in a directory. The files are named after the
+// sequence number of the frame, e.g. frame_00000001.jpg.
+type DirWriter struct {
+	dir string
+}
+
+// NewDirWriter returns a DirWriter writing to the given directory, which is created when it does not exist.
+func NewDirWriter(dir string) (*DirWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DirWriter{dir: dir}, nil
+}
+
+// WriteFrame writes the JPEG data of the frame to a new file.
+func (dw *DirWriter) WriteFrame(f *ip.LiveViewFrame) error {
+	return ioutil.WriteFile(filepath.Join(dw.dir, fmt.Sprintf("frame_%08d.jpg", f.Sequence)), f.Data, 0644)
+}
+
+// Close does nothing as each file is closed as soon as the frame has been written.
+func (dw *DirWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,180 @@
+package mjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestAVIWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mjpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "liveview.avi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	aw, err := NewAVIWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := make(chan *ip.LiveViewFrame, 5)
+	start := time.Now()
+	data := testJPEG(t, 32, 16)
+	for i := 0; i < 5; i++ {
+		ts := start.Add(time.Duration(i) * time.Second / 5)
+		frames <- &ip.LiveViewFrame{Sequence: uint64(i + 1), Time: ts, Data: data}
+	}
+	close(frames)
+
+	if err := Record(aw, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got[0:4]) != "RIFF" || string(got[8:12]) != "AVI " {
+		t.Fatalf("AVIWriter header = % x; want RIFF AVI", got[0:12])
+	}
+	check := []struct {
+		name   string
+		offset int
+		want   uint32
+	}{
+		{"RIFF size", 4, uint32(len(got) - 8)},
+		{"microseconds per frame", 32, 200000},
+		{"total frames", 48, 5},
+		{"width", 64, 32},
+		{"height", 68, 16},
+		{"rate", 132, 5},
+		{"length", 140, 5},
+	}
+	for _, c := range check {
+		if v := binary.LittleEndian.Uint32(got[c.offset:]); v != c.want {
+			t.Errorf("AVIWriter %s = %d; want %d", c.name, v, c.want)
+		}
+	}
+
+	idx := bytes.LastIndex(got, []byte("idx1"))
+	if idx == -1 {
+		t.Fatal("AVIWriter index not found")
+	}
+	if size := binary.LittleEndian.Uint32(got[idx+4:]); size != 5*16 {
+		t.Errorf("AVIWriter index size = %d; want %d", size, 5*16)
+	}
+	// The index offsets are relative to the movi list type.
+	off := binary.LittleEndian.Uint32(got[idx+8+16+8:])
+	if chunk := string(got[220+off : 220+off+4]); chunk != "00dc" {
+		t.Errorf("AVIWriter second index entry points to %s; want 00dc", chunk)
+	}
+
+	if err := aw.WriteFrame(&ip.LiveViewFrame{Data: data}); err != RecorderClosedError {
+		t.Errorf("WriteFrame() err = %v; want %s", err, RecorderClosedError)
+	}
+}
+
+func TestCommandWriter(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat command not available")
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("cat")
+	cmd.Stdout = &out
+
+	cw, err := NewCommandWriter(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := make(chan *ip.LiveViewFrame, 2)
+	frames <- &ip.LiveViewFrame{Data: []byte{0xff, 0xd8, 0x01, 0xff, 0xd9}}
+	frames <- &ip.LiveViewFrame{Data: []byte{0xff, 0xd8, 0x02, 0xff, 0xd9}}
+	close(frames)
+
+	if err := Record(cw, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{0xff, 0xd8, 0x01, 0xff, 0xd9, 0xff, 0xd8, 0x02, 0xff, 0xd9}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("CommandWriter output = % x; want % x", out.Bytes(), want)
+	}
+}
+
+func TestJPEGWriter(t *testing.T) {
+	var out bytes.Buffer
+	frames := make(chan *ip.LiveViewFrame, 2)
+	frames <- &ip.LiveViewFrame{Data: []byte{0xff, 0xd8, 0x01, 0xff, 0xd9}}
+	frames <- &ip.LiveViewFrame{Data: []byte{0xff, 0xd8, 0x02, 0xff, 0xd9}}
+	close(frames)
+
+	if err := Record(NewJPEGWriter(&out), frames); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{0xff, 0xd8, 0x01, 0xff, 0xd9, 0xff, 0xd8, 0x02, 0xff, 0xd9}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("JPEGWriter output = % x; want % x", out.Bytes(), want)
+	}
+}
+
+func TestDirWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mjpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dw, err := NewDirWriter(filepath.Join(dir, "frames"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := make(chan *ip.LiveViewFrame, 2)
+	frames <- &ip.LiveViewFrame{Sequence: 1, Data: []byte{0xff, 0xd8, 0x01, 0xff, 0xd9}}
+	frames <- &ip.LiveViewFrame{Sequence: 3, Data: []byte{0xff, 0xd8, 0x03, 0xff, 0xd9}}
+	close(frames)
+
+	if err := Record(dw, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, seq := range []byte{1, 3} {
+		name := filepath.Join(dir, "frames", fmt.Sprintf("frame_%08d.jpg", seq))
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []byte{0xff, 0xd8, seq, 0xff, 0xd9}; !bytes.Equal(got, want) {
+			t.Errorf("%s = % x; want % x", name, got, want)
+		}
+	}
+}
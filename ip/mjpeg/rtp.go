@@ -0,0 +1,160 @@
+package mjpeg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var UnsupportedJPEGError = errors.New("unsupported JPEG")
+
+const (
+	// rtpPayloadTypeJPEG is the static RTP payload type for JPEG, see RFC 3551.
+	rtpPayloadTypeJPEG = 26
+	// rtpClockRate is the clock rate used for the timestamps of video streams.
+	rtpClockRate = 90000
+	// rtpMaxPayloadSize keeps the RTP packets within a typical MTU.
+	rtpMaxPayloadSize = 1400
+)
+
+// rtpJPEG holds the parts of a baseline JPEG image needed to send it using the RTP payload format for JPEG, see RFC
+// 2435.
+type rtpJPEG struct {
+	// typ is 0 for 4:2:2 and 1 for 4:2:0 chroma subsampling, 64 is added when restart markers are used.
+	typ             byte
+	width           int
+	height          int
+	restartInterval uint16
+	// qtables holds the luminance and chrominance quantization tables.
+	qtables []byte
+	// scan holds the entropy coded scan data.
+	scan []byte
+}
+
+// parseRTPJPEG extracts the quantization tables, image properties and scan data of a baseline JPEG image. Only three
+// component images using 4:2:2 or 4:2:0 chroma subsampling, as produced by cameras, can be sent using RTP.
+func parseRTPJPEG(data []byte) (*rtpJPEG, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("%w: missing start of image marker", UnsupportedJPEGError)
+	}
+
+	j := &rtpJPEG{}
+	qtables := make(map[byte][]byte)
+	var sof bool
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: expected marker at offset %d", UnsupportedJPEGError, i)
+		}
+		marker := data[i+1]
+		l := int(binary.BigEndian.Uint16(data[i+2:]))
+		// The segment length includes the length field itself.
+		if l < 2 {
+			return nil, fmt.Errorf("%w: segment %#x has invalid length %d", UnsupportedJPEGError, marker, l)
+		}
+		if i+2+l > len(data) {
+			return nil, fmt.Errorf("%w: segment %#x exceeds the image data", UnsupportedJPEGError, marker)
+		}
+		seg := data[i+4 : i+2+l]
+
+		switch marker {
+		case 0xDB: // Define quantization tables.
+			for len(seg) >= 65 {
+				if seg[0]>>4 != 0 {
+					return nil, fmt.Errorf("%w: 16-bit quantization tables", UnsupportedJPEGError)
+				}
+				qtables[seg[0]&0x0F] = seg[1:65]
+				seg = seg[65:]
+			}
+		case 0xC0: // Start of frame, baseline.
+			if len(seg) < 15 || seg[5] != 3 {
+				return nil, fmt.Errorf("%w: only three component images are supported", UnsupportedJPEGError)
+			}
+			j.height = int(binary.BigEndian.Uint16(seg[1:]))
+			j.width = int(binary.BigEndian.Uint16(seg[3:]))
+			switch seg[7] {
+			case 0x21:
+				j.typ = 0
+			case 0x22:
+				j.typ = 1
+			default:
+				return nil, fmt.Errorf("%w: sampling factors %#x", UnsupportedJPEGError, seg[7])
+			}
+			sof = true
+		case 0xC1, 0xC2, 0xC3, 0xC5, 0xC6, 0xC7, 0xC9, 0xCA, 0xCB, 0xCD, 0xCE, 0xCF:
+			return nil, fmt.Errorf("%w: only baseline images are supported", UnsupportedJPEGError)
+		case 0xDD: // Define restart interval.
+			if len(seg) >= 2 {
+				j.restartInterval = binary.BigEndian.Uint16(seg)
+			}
+		case 0xDA: // Start of scan: the entropy coded data follows up to the end of image marker.
+			if !sof {
+				return nil, fmt.Errorf("%w: missing start of frame", UnsupportedJPEGError)
+			}
+			end := len(data)
+			if data[end-2] == 0xFF && data[end-1] == 0xD9 {
+				end -= 2
+			}
+			j.scan = data[i+2+l : end]
+			if j.width > 2040 || j.height > 2040 {
+				return nil, fmt.Errorf("%w: images larger than 2040x2040 pixels", UnsupportedJPEGError)
+			}
+			if j.restartInterval > 0 {
+				j.typ += 64
+			}
+			for q := byte(0); q < 2; q++ {
+				if qtables[q] == nil {
+					return nil, fmt.Errorf("%w: missing quantization table %d", UnsupportedJPEGError, q)
+				}
+				j.qtables = append(j.qtables, qtables[q]...)
+			}
+			return j, nil
+		}
+		i += 2 + l
+	}
+
+	return nil, fmt.Errorf("%w: missing start of scan", UnsupportedJPEGError)
+}
+
+// rtpPacketizer turns JPEG images into RTP packets.
+type rtpPacketizer struct {
+	seq  uint16
+	ssrc uint32
+}
+
+// packetize splits the image into RTP packets carrying the given timestamp. The quantization tables are sent in-band
+// with the first packet, the last packet has the marker bit set.
+func (p *rtpPacketizer) packetize(j *rtpJPEG, ts uint32) [][]byte {
+	var packets [][]byte
+	for off := 0; off < len(j.scan); {
+		hdr := make([]byte, 12, rtpMaxPayloadSize+12)
+		hdr[0] = 0x80
+		hdr[1] = rtpPayloadTypeJPEG
+		binary.BigEndian.PutUint16(hdr[2:], p.seq)
+		binary.BigEndian.PutUint32(hdr[4:], ts)
+		binary.BigEndian.PutUint32(hdr[8:], p.ssrc)
+		p.seq++
+
+		// The main JPEG header: the quality value of 255 indicates the tables are sent in-band.
+		pkt := append(hdr, 0, byte(off>>16), byte(off>>8), byte(off), j.typ, 255, byte(j.width/8), byte(j.height/8))
+		if j.typ >= 64 {
+			pkt = append(pkt, byte(j.restartInterval>>8), byte(j.restartInterval), 0xFF, 0xFF)
+		}
+		if off == 0 {
+			pkt = append(pkt, 0, 0, byte(len(j.qtables)>>8), byte(len(j.qtables)))
+			pkt = append(pkt, j.qtables...)
+		}
+
+		n := rtpMaxPayloadSize + 12 - len(pkt)
+		if n > len(j.scan)-off {
+			n = len(j.scan) - off
+		}
+		pkt = append(pkt, j.scan[off:off+n]...)
+		off += n
+		if off == len(j.scan) {
+			pkt[1] |= 0x80
+		}
+		packets = append(packets, pkt)
+	}
+
+	return packets
+}
@@ -0,0 +1,246 @@
+package mjpeg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RTSPServer is a minimal RTSP server streaming the live view frames using the RTP payload format for JPEG, so
+// surveillance style consumers such as VLC or an NVR can subscribe to the camera feed. The RTP packets are interleaved
+// with the RTSP connection, which keeps the server simple and works through NAT. Any number of clients can watch the
+// stream: each of them is sent the latest frame as soon as it arrives.
+type RTSPServer struct {
+	*broadcaster
+	// Name is announced to the clients as the session name.
+	Name string
+	// Logger logs the problems streaming to the clients. Nothing is logged by default.
+	ip.Logger
+}
+
+// NewRTSPServer returns an RTSPServer streaming the frames received from the given channel, e.g. the channel returned
+// by ip.Client.LiveView(). The streams end when the channel is closed.
+func NewRTSPServer(frames <-chan *ip.LiveViewFrame) *RTSPServer {
+	return &RTSPServer{
+		broadcaster: newBroadcaster(frames),
+		Name:        "ptp-ip live view",
+		Logger:      ip.NewLogger(ip.LevelSilent, ioutil.Discard, "", 0),
+	}
+}
+
+// ListenAndServe listens on the TCP network address addr, e.g. ":8554", and then calls Serve.
+func (s *RTSPServer) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts RTSP connections on the listener until it is closed.
+func (s *RTSPServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// rtspConn is a single RTSP connection.
+type rtspConn struct {
+	s       *RTSPServer
+	conn    net.Conn
+	session string
+	channel byte
+	// stop is closed to stop streaming.
+	stop    chan struct{}
+	writeMu sync.Mutex
+}
+
+func (s *RTSPServer) serveConn(conn net.Conn) {
+	c := &rtspConn{s: s, conn: conn}
+	defer c.close()
+
+	r := textproto.NewReader(bufio.NewReader(conn))
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		if line == "" {
+			continue
+		}
+		req := strings.Fields(line)
+		if len(req) != 3 {
+			s.Warnf("[rtsp] malformed request line %q", line)
+			return
+		}
+		hdr, err := r.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return
+		}
+		// Request bodies are of no use to us.
+		if l, _ := strconv.Atoi(hdr.Get("Content-Length")); l > 0 {
+			if _, err := io.CopyN(ioutil.Discard, r.R, int64(l)); err != nil {
+				return
+			}
+		}
+
+		if !c.handle(req[0], req[1], hdr) {
+			return
+		}
+	}
+}
+
+// handle responds to a single request. It returns false when the connection must be closed.
+func (c *rtspConn) handle(method, url string, hdr textproto.MIMEHeader) bool {
+	cseq := hdr.Get("CSeq")
+	switch method {
+	case "OPTIONS":
+		c.respond(200, "OK", cseq, []string{"Public: OPTIONS, DESCRIBE, SETUP, PLAY, TEARDOWN, GET_PARAMETER"}, "")
+	case "DESCRIBE":
+		sdp := "v=0\r\n" +
+			"o=- 0 0 IN IP4 127.0.0.1\r\n" +
+			"s=" + c.s.Name + "\r\n" +
+			"c=IN IP4 0.0.0.0\r\n" +
+			"t=0 0\r\n" +
+			"m=video 0 RTP/AVP " + strconv.Itoa(rtpPayloadTypeJPEG) + "\r\n" +
+			"a=control:track0\r\n"
+		c.respond(200, "OK", cseq, []string{
+			"Content-Base: " + strings.TrimSuffix(url, "/") + "/",
+			"Content-Type: application/sdp",
+		}, sdp)
+	case "SETUP":
+		transport := hdr.Get("Transport")
+		if !strings.Contains(transport, "RTP/AVP/TCP") {
+			// Only interleaved transport is supported: the client should retry using TCP.
+			c.respond(461, "Unsupported Transport", cseq, nil, "")
+			return true
+		}
+		c.channel = 0
+		if i := strings.Index(transport, "interleaved="); i != -1 {
+			if ch, err := strconv.Atoi(strings.SplitN(transport[i+len("interleaved="):], "-", 2)[0]); err == nil {
+				c.channel = byte(ch)
+			}
+		}
+		c.session = strconv.FormatUint(uint64(rand.Uint32()), 16)
+		c.respond(200, "OK", cseq, []string{
+			fmt.Sprintf("Transport: RTP/AVP/TCP;unicast;interleaved=%d-%d", c.channel, c.channel+1),
+			"Session: " + c.session,
+		}, "")
+	case "PLAY":
+		if c.session == "" {
+			c.respond(455, "Method Not Valid in This State", cseq, nil, "")
+			return true
+		}
+		c.respond(200, "OK", cseq, []string{"Session: " + c.session}, "")
+		if c.stop == nil {
+			c.stop = make(chan struct{})
+			go c.stream()
+		}
+	case "GET_PARAMETER":
+		// Used by clients to keep the session alive.
+		c.respond(200, "OK", cseq, []string{"Session: " + c.session}, "")
+	case "TEARDOWN":
+		c.respond(200, "OK", cseq, []string{"Session: " + c.session}, "")
+		return false
+	default:
+		c.respond(501, "Not Implemented", cseq, nil, "")
+	}
+
+	return true
+}
+
+func (c *rtspConn) respond(code int, status, cseq string, headers []string, body string) {
+	res := fmt.Sprintf("RTSP/1.0 %d %s\r\nCSeq: %s\r\n", code, status, cseq)
+	for _, h := range headers {
+		res += h + "\r\n"
+	}
+	if body != "" {
+		res += "Content-Length: " + strconv.Itoa(len(body)) + "\r\n"
+	}
+	res += "\r\n" + body
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.Write([]byte(res))
+}
+
+// stream sends the frames as interleaved RTP packets until streaming is stopped or no more frames will arrive.
+func (c *rtspConn) stream() {
+	p := &rtpPacketizer{seq: uint16(rand.Uint32()), ssrc: rand.Uint32()}
+	var start time.Time
+	for {
+		frame, update, ok := c.s.next()
+		if !ok {
+			c.conn.Close()
+			return
+		}
+		if frame != nil {
+			if start.IsZero() {
+				start = frame.Time
+			}
+			if err := c.sendFrame(p, frame, start); err != nil {
+				c.s.Warnf("[rtsp] unable to send frame %d: %s", frame.Sequence, err)
+				if _, ok := err.(net.Error); ok {
+					return
+				}
+			}
+		}
+
+		select {
+		case <-update:
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *rtspConn) sendFrame(p *rtpPacketizer, f *ip.LiveViewFrame, start time.Time) error {
+	j, err := parseRTPJPEG(f.Data)
+	if err != nil {
+		return err
+	}
+
+	ts := uint32(uint64(f.Time.Sub(start)/time.Microsecond) * rtpClockRate / 1000000)
+	for _, pkt := range p.packetize(j, ts) {
+		buf := make([]byte, 4, 4+len(pkt))
+		buf[0] = '$'
+		buf[1] = c.channel
+		binary.BigEndian.PutUint16(buf[2:], uint16(len(pkt)))
+		if err := c.write(append(buf, pkt...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *rtspConn) write(b []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.conn.Write(b)
+
+	return err
+}
+
+func (c *rtspConn) close() {
+	if c.stop != nil {
+		close(c.stop)
+	}
+	c.conn.Close()
+}
@@ -0,0 +1,179 @@
+package mjpeg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"image"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRGBJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseRTPJPEG(t *testing.T) {
+	got, err := parseRTPJPEG(testRGBJPEG(t, 64, 48))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.typ != 1 {
+		t.Errorf("parseRTPJPEG() type = %d; want 1", got.typ)
+	}
+	if got.width != 64 || got.height != 48 {
+		t.Errorf("parseRTPJPEG() size = %dx%d; want 64x48", got.width, got.height)
+	}
+	if len(got.qtables) != 128 {
+		t.Errorf("parseRTPJPEG() quantization tables length = %d; want 128", len(got.qtables))
+	}
+	if len(got.scan) == 0 {
+		t.Error("parseRTPJPEG() scan data is empty")
+	}
+
+	if _, err := parseRTPJPEG(testJPEG(t, 64, 48)); err == nil {
+		t.Error("parseRTPJPEG() err = <nil>; want an error for a single component image")
+	}
+
+	for _, data := range [][]byte{
+		{0xFF, 0xD8, 0xFF, 0xDB, 0x00, 0x00, 0xFF, 0xD9},
+		{0xFF, 0xD8, 0xFF, 0xDB, 0x00, 0x01, 0xFF, 0xD9},
+		{0xFF, 0xD8, 0xFF, 0xDB, 0x00, 0x43, 0x00, 0x01},
+	} {
+		if _, err := parseRTPJPEG(data); !errors.Is(err, UnsupportedJPEGError) {
+			t.Errorf("parseRTPJPEG(%#x) err = %v; want %s", data, err, UnsupportedJPEGError)
+		}
+	}
+}
+
+func TestRTPPacketizer_packetize(t *testing.T) {
+	j := &rtpJPEG{typ: 1, width: 64, height: 48, qtables: make([]byte, 128), scan: make([]byte, 3000)}
+	p := &rtpPacketizer{seq: 0xFFFF, ssrc: 0x01020304}
+
+	got := p.packetize(j, 90000)
+	if len(got) != 3 {
+		t.Fatalf("packetize() packets = %d; want 3", len(got))
+	}
+
+	var scan int
+	for i, pkt := range got {
+		if pt := pkt[1] & 0x7F; pt != rtpPayloadTypeJPEG {
+			t.Errorf("packetize() packet %d payload type = %d; want %d", i, pt, rtpPayloadTypeJPEG)
+		}
+		if marker := pkt[1]&0x80 != 0; marker != (i == len(got)-1) {
+			t.Errorf("packetize() packet %d marker = %t; want %t", i, marker, i == len(got)-1)
+		}
+		if seq := binary.BigEndian.Uint16(pkt[2:]); seq != uint16(0xFFFF+i) {
+			t.Errorf("packetize() packet %d sequence = %d; want %d", i, seq, uint16(0xFFFF+i))
+		}
+		if off := int(pkt[13])<<16 | int(pkt[14])<<8 | int(pkt[15]); off != scan {
+			t.Errorf("packetize() packet %d fragment offset = %d; want %d", i, off, scan)
+		}
+		hdr := 20
+		if i == 0 {
+			hdr += 4 + 128
+		}
+		scan += len(pkt) - hdr
+	}
+	if scan != len(j.scan) {
+		t.Errorf("packetize() scan data sent = %d; want %d", scan, len(j.scan))
+	}
+}
+
+func TestRTSPServer(t *testing.T) {
+	frames := make(chan *ip.LiveViewFrame)
+	s := NewRTSPServer(frames)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go s.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	url := "rtsp://" + l.Addr().String() + "/liveview"
+	request := func(cseq int, method, url string, headers ...string) textproto.MIMEHeader {
+		hs := strings.Join(append(headers, ""), "\r\n")
+		fmt.Fprintf(conn, "%s %s RTSP/1.0\r\nCSeq: %d\r\n%s\r\n", method, url, cseq, hs)
+		tp := textproto.NewReader(r)
+		line, err := tp.ReadLine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "RTSP/1.0 200 OK" {
+			t.Fatalf("%s status = %s; want RTSP/1.0 200 OK", method, line)
+		}
+		hdr, err := tp.ReadMIMEHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Get("CSeq") != fmt.Sprint(cseq) {
+			t.Errorf("%s CSeq = %s; want %d", method, hdr.Get("CSeq"), cseq)
+		}
+		if l := hdr.Get("Content-Length"); l != "" {
+			var n int
+			fmt.Sscan(l, &n)
+			body := make([]byte, n)
+			if _, err := io.ReadFull(r, body); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(body), "m=video 0 RTP/AVP 26") {
+				t.Errorf("%s body = %s; want a JPEG video stream", method, body)
+			}
+		}
+
+		return hdr
+	}
+
+	request(1, "OPTIONS", url)
+	request(2, "DESCRIBE", url, "Accept: application/sdp")
+	hdr := request(3, "SETUP", url+"/track0", "Transport: RTP/AVP/TCP;unicast;interleaved=0-1")
+	request(4, "PLAY", url, "Session: "+hdr.Get("Session"))
+
+	frames <- &ip.LiveViewFrame{Sequence: 1, Time: time.Now(), Data: testRGBJPEG(t, 64, 48)}
+
+	// Read interleaved packets until the packet holding the end of the frame.
+	for {
+		head := make([]byte, 4)
+		if _, err := io.ReadFull(r, head); err != nil {
+			t.Fatal(err)
+		}
+		if head[0] != '$' || head[1] != 0 {
+			t.Fatalf("interleaved header = % x; want channel 0", head)
+		}
+		pkt := make([]byte, binary.BigEndian.Uint16(head[2:]))
+		if _, err := io.ReadFull(r, pkt); err != nil {
+			t.Fatal(err)
+		}
+		if w, h := int(pkt[18])*8, int(pkt[19])*8; w != 64 || h != 48 {
+			t.Errorf("RTP JPEG size = %dx%d; want 64x48", w, h)
+		}
+		if pkt[1]&0x80 != 0 {
+			break
+		}
+	}
+
+	request(5, "TEARDOWN", url, "Session: "+hdr.Get("Session"))
+	close(frames)
+}
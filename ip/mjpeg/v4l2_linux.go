@@ -0,0 +1,124 @@
+package mjpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
+	"image/jpeg"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var FrameSizeChangedError = errors.New("frame size changed")
+
+const (
+	v4l2BufTypeVideoOutput = 2
+	v4l2FieldNone          = 1
+	v4l2ColorspaceJPEG     = 7
+	v4l2PixFmtYUYV         = 'Y' | 'U'<<8 | 'Y'<<16 | 'V'<<24
+)
+
+// v4l2PixFormat mirrors struct v4l2_pix_format of the Linux kernel.
+type v4l2PixFormat struct {
+	width        uint32
+	height       uint32
+	pixelFormat  uint32
+	field        uint32
+	bytesPerLine uint32
+	sizeImage    uint32
+	colorspace   uint32
+	priv         uint32
+	flags        uint32
+	ycbcrEnc     uint32
+	quantization uint32
+	xferFunc     uint32
+}
+
+// v4l2Format mirrors struct v4l2_format of the Linux kernel: the format union is 200 bytes and pointer aligned.
+type v4l2Format struct {
+	typ uint32
+	fmt struct {
+		_   [0]uintptr
+		pix v4l2PixFormat
+		_   [200 - unsafe.Sizeof(v4l2PixFormat{})]byte
+	}
+}
+
+// vidiocSFmt is the VIDIOC_S_FMT ioctl request: _IOWR('V', 5, struct v4l2_format).
+var vidiocSFmt = uintptr(3<<30 | unsafe.Sizeof(v4l2Format{})<<16 | 'V'<<8 | 5)
+
+// V4L2Writer is a FrameWriter pushing the frames to a v4l2loopback device, making the live view available as a webcam
+// to any Linux application. The frames are converted to YUYV, which is the format most applications accept. Load the
+// v4l2loopback module before opening the device, e.g.:
+//   modprobe v4l2loopback video_nr=10 exclusive_caps=1 card_label="ptp-ip"
+type V4L2Writer struct {
+	f      *os.File
+	width  int
+	height int
+}
+
+// NewV4L2Writer opens the video device, e.g. "/dev/video10". The format of the device is set when the first frame is
+// written, since only then the size of the frames is known.
+func NewV4L2Writer(device string) (*V4L2Writer, error) {
+	f, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &V4L2Writer{f: f}, nil
+}
+
+// WriteFrame converts the frame to YUYV and writes it to the device. All frames must be of the same size.
+func (vw *V4L2Writer) WriteFrame(f *ip.LiveViewFrame) error {
+	img := f.Image
+	if img == nil {
+		var err error
+		if img, err = jpeg.Decode(bytes.NewReader(f.Data)); err != nil {
+			return err
+		}
+	}
+
+	w, h := img.Bounds().Dx()&^1, img.Bounds().Dy()
+	if vw.width == 0 {
+		if err := vw.setFormat(w, h); err != nil {
+			return err
+		}
+	} else if w != vw.width || h != vw.height {
+		return fmt.Errorf("%w: %dx%d instead of %dx%d", FrameSizeChangedError, w, h, vw.width, vw.height)
+	}
+
+	_, err := vw.f.Write(toYUYV(img))
+
+	return err
+}
+
+func (vw *V4L2Writer) setFormat(w, h int) error {
+	if w == 0 || h == 0 {
+		return fmt.Errorf("invalid frame size %dx%d", w, h)
+	}
+
+	f := v4l2Format{typ: v4l2BufTypeVideoOutput}
+	f.fmt.pix = v4l2PixFormat{
+		width:        uint32(w),
+		height:       uint32(h),
+		pixelFormat:  v4l2PixFmtYUYV,
+		field:        v4l2FieldNone,
+		bytesPerLine: uint32(w * 2),
+		sizeImage:    uint32(w * h * 2),
+		colorspace:   v4l2ColorspaceJPEG,
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, vw.f.Fd(), vidiocSFmt, uintptr(unsafe.Pointer(&f)))
+	if errno != 0 {
+		return fmt.Errorf("unable to set the video format: %w", errno)
+	}
+	vw.width, vw.height = w, h
+
+	return nil
+}
+
+// Close closes the device.
+func (vw *V4L2Writer) Close() error {
+	return vw.f.Close()
+}
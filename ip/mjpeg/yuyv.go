@@ -0,0 +1,42 @@
+package mjpeg
+
+import (
+	"image"
+	"image/color"
+)
+
+// toYUYV converts the image to packed YUYV 4:2:2, where each pair of pixels is stored as four bytes: the luma of both
+// pixels interleaved with the chroma they share. An odd width is rounded down, since YUYV cannot hold half a pair.
+func toYUYV(img image.Image) []byte {
+	b := img.Bounds()
+	w, h := b.Dx()&^1, b.Dy()
+	buf := make([]byte, 0, w*h*2)
+
+	if ycc, ok := img.(*image.YCbCr); ok {
+		// Live view frames are JPEG images which decode to YCbCr, so no colour conversion is needed.
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Min.X+w; x += 2 {
+				ci := ycc.COffset(x, y)
+				buf = append(buf, ycc.Y[ycc.YOffset(x, y)], ycc.Cb[ci], ycc.Y[ycc.YOffset(x+1, y)], ycc.Cr[ci])
+			}
+		}
+
+		return buf
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Min.X+w; x += 2 {
+			y0, cb0, cr0 := toYCbCr(img.At(x, y))
+			y1, cb1, cr1 := toYCbCr(img.At(x+1, y))
+			buf = append(buf, y0, uint8((uint16(cb0)+uint16(cb1))/2), y1, uint8((uint16(cr0)+uint16(cr1))/2))
+		}
+	}
+
+	return buf
+}
+
+func toYCbCr(c color.Color) (uint8, uint8, uint8) {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+
+	return color.RGBToYCbCr(rgba.R, rgba.G, rgba.B)
+}
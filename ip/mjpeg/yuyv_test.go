@@ -0,0 +1,33 @@
+package mjpeg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestToYUYV(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(0, 0, color.White)
+	img.Set(1, 0, color.Black)
+	img.Set(2, 0, color.White)
+
+	// The odd pixel is dropped.
+	got := toYUYV(img)
+	if want := []byte{0xff, 0x80, 0x00, 0x80}; !bytes.Equal(got, want) {
+		t.Errorf("toYUYV() = % x; want % x", got, want)
+	}
+}
+
+func TestToYUYVYCbCr(t *testing.T) {
+	img := image.NewYCbCr(image.Rect(0, 0, 4, 1), image.YCbCrSubsampleRatio422)
+	copy(img.Y, []byte{0x10, 0x20, 0x30, 0x40})
+	copy(img.Cb, []byte{0x50, 0x60})
+	copy(img.Cr, []byte{0x70, 0x80})
+
+	got := toYUYV(img)
+	if want := []byte{0x10, 0x50, 0x20, 0x70, 0x30, 0x60, 0x40, 0x80}; !bytes.Equal(got, want) {
+		t.Errorf("toYUYV() = % x; want % x", got, want)
+	}
+}
@@ -43,6 +43,9 @@ func handleFujiMessages(conn net.Conn, evtChan chan uint32, lmp string) {
 			msg, resp, data = fujiGetCapturePreview(raw[4:8])
 			evt = constructEventData(OC_Fuji_GetCapturePreview, raw[4:8])
 			eodp = true
+		case constructPacketType(ptp.OC_GetDeviceInfo):
+			msg, resp, data = fujiGetStandardDeviceInfo(raw[4:8])
+			eodp = true
 		case constructPacketType(OC_Fuji_GetDeviceInfo):
 			msg, resp, data = fujiGetDeviceInfo(raw[4:8])
 			eodp = true
@@ -59,6 +62,14 @@ func handleFujiMessages(conn net.Conn, evtChan chan uint32, lmp string) {
 			msg, resp = fujiInitiateOpenCaptureResponse(raw[4:8])
 		case constructPacketType(ptp.OC_OpenSession):
 			msg, resp = fujiOpenSessionResponse(raw[4:8])
+		case constructPacketType(ptp.OC_CloseSession):
+			msg, resp = fujiCloseSessionResponse(raw[4:8])
+		case constructPacketType(ptp.OC_TerminateOpenCapture):
+			msg, resp = fujiTerminateOpenCaptureResponse(raw[4:8])
+		case constructPacketType(OC_Fuji_ResetFocusPoint):
+			msg, resp = fujiResetFocusPoint(raw[4:8])
+		case constructPacketType(ptp.OC_ResetDevicePropValue):
+			msg, resp = fujiResetDevicePropValue(raw[4:8])
 		case constructPacketTypeWithDataPhase(ptp.OC_SetDevicePropValue, DP_DataOut):
 			// SetDevicePropValue involves two messages, only the second one needs a response from us!
 			msg, resp = fujiSetDevicePropValue(raw[4:8])
@@ -128,6 +139,21 @@ func handleFujiEvents(conn net.Conn, evtChan chan uint32, lmp string) {
 	}
 }
 
+// mockFujiDeviceInfo is the standard DeviceInfo dataset returned by the Fuji responder.
+var mockFujiDeviceInfo = ptp.DeviceInfo{
+	StandardVersion:           100,
+	VendorExtensionID:         uint32(ptp.VE_FujiPhotoFilmCoLtd),
+	VendorExtensionVersion:    100,
+	OperationsSupported:       []ptp.OperationCode{ptp.OC_GetDeviceInfo, OC_Fuji_GetDeviceInfo},
+	EventsSupported:           []ptp.EventCode{},
+	DevicePropertiesSupported: []ptp.DevicePropCode{ptp.DPC_WhiteBalance, DPC_Fuji_FilmSimulation},
+	CaptureFormats:            []ptp.ObjectFormatCode{},
+	ImageFormats:              []ptp.ObjectFormatCode{ptp.OFC_EXIF_JPEG},
+	Manufacturer:              "FUJIFILM",
+	Model:                     "X-T1",
+	DeviceVersion:             "5.51",
+}
+
 func constructPacketType(code ptp.OperationCode) uint32 {
 	return constructPacketTypeWithDataPhase(code, DP_NoDataOrDataIn)
 }
@@ -148,6 +174,12 @@ func fujiGetCapturePreview(tid []byte) (string, *FujiOperationResponsePacket, []
 		dat
 }
 
+func fujiGetStandardDeviceInfo(tid []byte) (string, *FujiOperationResponsePacket, []byte) {
+	return "GetStandardDeviceInfo",
+		fujiOperationResponsePacket(DP_DataOut, ptp.OperationResponseCode(ptp.OC_GetDeviceInfo), tid),
+		genericDeviceInfo(mockFujiDeviceInfo)
+}
+
 func fujiGetDeviceInfo(tid []byte) (string, *FujiOperationResponsePacket, []byte) {
 	return "GetDeviceInfo",
 		fujiOperationResponsePacket(DP_DataOut, RC_Fuji_GetDeviceInfo, tid),
@@ -187,6 +219,20 @@ func fujiGetDevicePropDescResponse(tid []byte, prop []byte) (string, *FujiOperat
 		p = []byte{0x05, 0x50, 0x04, 0x00, 0x01, 0x02, 0x00, 0x02, 0x00, 0x02, 0x0a, 0x00, 0x02, 0x00, 0x04, 0x00, 0x06,
 			0x80, 0x01, 0x80, 0x02, 0x80, 0x03, 0x80, 0x06, 0x00, 0x0a, 0x80, 0x0b, 0x80, 0x0c, 0x80,
 		}
+	case uint16(ptp.DPC_ExposureBiasCompensation):
+		p = []byte{0x10, 0x50, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x02, 0x13, 0x00, 0x48, 0xf4, 0x95, 0xf5, 0xe3,
+			0xf6, 0x30, 0xf8, 0x7d, 0xf9, 0xcb, 0xfa, 0x18, 0xfc, 0x65, 0xfd, 0xb3, 0xfe, 0x00, 0x00, 0x4d, 0x01, 0x9b,
+			0x02, 0xe8, 0x03, 0x35, 0x05, 0x83, 0x06, 0xd0, 0x07, 0x1d, 0x09, 0x6b, 0x0a, 0xb8, 0x0b,
+		}
+	case uint16(DPC_Fuji_ExposureIndex):
+		p = []byte{0x2a, 0xd0, 0x06, 0x00, 0x01, 0xff, 0xff, 0xff, 0xff, 0x00, 0x19, 0x00, 0x80, 0x02, 0x19, 0x00, 0x90,
+			0x01, 0x00, 0x80, 0x20, 0x03, 0x00, 0x80, 0x40, 0x06, 0x00, 0x80, 0x80, 0x0c, 0x00, 0x80, 0x00, 0x19, 0x00,
+			0x80, 0x64, 0x00, 0x00, 0x40, 0xc8, 0x00, 0x00, 0x00, 0xfa, 0x00, 0x00, 0x00, 0x40, 0x01, 0x00, 0x00, 0x90,
+			0x01, 0x00, 0x00, 0xf4, 0x01, 0x00, 0x00, 0x80, 0x02, 0x00, 0x00, 0x20, 0x03, 0x00, 0x00, 0xe8, 0x03, 0x00,
+			0x00, 0xe2, 0x04, 0x00, 0x00, 0x40, 0x06, 0x00, 0x00, 0xd0, 0x07, 0x00, 0x00, 0xc4, 0x09, 0x00, 0x00, 0x80,
+			0x0c, 0x00, 0x00, 0xa0, 0x0f, 0x00, 0x00, 0x88, 0x13, 0x00, 0x00, 0x00, 0x19, 0x00, 0x00, 0x00, 0x32, 0x00,
+			0x40, 0x00, 0x64, 0x00, 0x40, 0x00, 0xc8, 0x00, 0x40,
+		}
 	case uint16(DPC_Fuji_FilmSimulation):
 		p = []byte{0x01, 0xd0, 0x04, 0x00, 0x01, 0x01, 0x00, 0x01, 0x00, 0x02, 0x0b, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03,
 			0x00, 0x04, 0x00, 0x05, 0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00,
@@ -202,6 +248,8 @@ func fujiGetDevicePropValueResponse(tid []byte, prop []byte) (string, *FujiOpera
 	var p []byte
 
 	switch binary.LittleEndian.Uint16(prop) {
+	case uint16(ptp.DPC_CaptureDelay):
+		p = []byte{0x04, 0x00}
 	case uint16(DPC_Fuji_AppVersion):
 		p = make([]byte, 4)
 		binary.LittleEndian.PutUint32(p, PM_Fuji_AppVersion)
@@ -235,6 +283,26 @@ func fujiOpenSessionResponse(tid []byte) (string, *FujiOperationResponsePacket)
 		fujiEndOfDataPacket(tid)
 }
 
+func fujiCloseSessionResponse(tid []byte) (string, *FujiOperationResponsePacket) {
+	return "CloseSession",
+		fujiEndOfDataPacket(tid)
+}
+
+func fujiTerminateOpenCaptureResponse(tid []byte) (string, *FujiOperationResponsePacket) {
+	return "TerminateOpenCapture",
+		fujiEndOfDataPacket(tid)
+}
+
+func fujiResetDevicePropValue(tid []byte) (string, *FujiOperationResponsePacket) {
+	return "ResetDevicePropValue",
+		fujiEndOfDataPacket(tid)
+}
+
+func fujiResetFocusPoint(tid []byte) (string, *FujiOperationResponsePacket) {
+	return "ResetFocusPoint",
+		fujiEndOfDataPacket(tid)
+}
+
 func fujiSetDevicePropValue(tid []byte) (string, *FujiOperationResponsePacket) {
 	return "SetDevicePropValue",
 		fujiEndOfDataPacket(tid)
@@ -1,10 +1,17 @@
 package ip
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/malc0mn/ptp-ip/ip/internal"
 	"github.com/malc0mn/ptp-ip/ptp"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func handleGenericMessages(conn net.Conn, _ chan uint32, lmp string) {
@@ -27,8 +34,18 @@ func handleGenericMessages(conn net.Conn, _ chan uint32, lmp string) {
 			msg, res = genericInitCommandRequestResponse(lmp, PV_VersionOnePointZero)
 		case PKT_InitEventRequest:
 			msg, res = genericInitEventRequestResponse()
+			genericEventConnMu.Lock()
+			genericEventConn = conn
+			genericEventConnNumber = pkt.(*GenericInitEventRequestPacket).ConnectionNumber
+			genericEventConnMu.Unlock()
 		case PKT_OperationRequest:
-			msg, res = genericOperationRequestResponse()
+			msg, res = genericOperationRequestResponse(conn, pkt.(*OperationRequestPacket), lmp)
+		case PKT_ProbeRequest:
+			msg, res = "ProbeRequest", &ProbeResponsePacket{}
+		case PKT_Cancel:
+			lgr.Infof("%s cancel requested for transaction %d", lmp, pkt.(*CancelPacket).TransactionId)
+			genericCancelled.Store(pkt.(*CancelPacket).TransactionId, true)
+			continue
 		default:
 			lgr.Errorf("%s unknown packet type %#x", lmp, h.PacketType)
 			continue
@@ -58,10 +75,339 @@ func genericInitEventRequestResponse() (string, PacketIn) {
 	return "InitEventRequest", &InitEventAckPacket{}
 }
 
-func genericOperationRequestResponse() (string, PacketIn) {
-	return "OperationRequest", &OperationResponsePacket{
+func genericOperationRequestResponse(conn net.Conn, pkt *OperationRequestPacket, lmp string) (string, PacketIn) {
+	var data []byte
+	rc := ptp.RC_OK
+
+	switch pkt.OperationCode {
+	case ptp.OC_OpenSession:
+		genericEventConnMu.Lock()
+		genericSessionID = ptp.SessionID(pkt.Parameter1)
+		genericSessionTID = pkt.TransactionID
+		genericEventConnMu.Unlock()
+	case ptp.OC_GetDeviceInfo:
+		data = genericDeviceInfo(mockDeviceInfo)
+	case ptp.OC_GetStorageIDs:
+		data = genericArray([]uint32{0x00010001, 0x00020000})
+	case ptp.OC_GetStorageInfo:
+		if si, ok := mockStorageInfo[ptp.StorageID(pkt.Parameter1)]; ok {
+			data = genericStorageInfo(si)
+		} else {
+			rc = ptp.RC_InvalidStorageID
+		}
+	case ptp.OC_GetObjectHandles:
+		data = genericArray(mockObjectHandles[ptp.ObjectHandle(pkt.Parameter3)])
+	case ptp.OC_GetDevicePropDesc:
+		switch ptp.DevicePropCode(pkt.Parameter1) {
+		case ptp.DPC_BatteryLevel:
+			data = []byte{0x01, 0x50, 0x02, 0x00, 0x00, 0x64, 0x32, 0x01, 0x00, 0x64, 0x0a}
+		case ptp.DPC_Artist:
+			data = append([]byte{0x1e, 0x50, 0xff, 0xff, 0x01}, genericString("")...)
+			data = append(data, genericString(mockArtist)...)
+			data = append(data, byte(ptp.DPF_FormFlag_None))
+		case ptp.DPC_ExposureIndex:
+			data = []byte{0x0f, 0x50, 0x04, 0x00, 0x01, 0xff, 0xff}
+			data = append(data, internal.MarshalLittleEndian(mockExposureIndex)...)
+			data = append(data, byte(ptp.DPF_FormFlag_Enum), 0x04, 0x00, 0xff, 0xff, 0xc8, 0x00, 0x90, 0x01, 0x20, 0x03)
+		default:
+			rc = ptp.RC_DevicePropNotSupported
+		}
+	case ptp.OC_GetDevicePropValue:
+		switch ptp.DevicePropCode(pkt.Parameter1) {
+		case ptp.DPC_BatteryLevel:
+			data = []byte{0x32}
+		case ptp.DPC_Artist:
+			data = genericString(mockArtist)
+		case ptp.DPC_ExposureIndex:
+			data = internal.MarshalLittleEndian(mockExposureIndex)
+		case ptp.DPC_WhiteBalance:
+			if atomic.AddInt32(&genericBusy, -1) >= 0 {
+				rc = ptp.RC_DeviceBusy
+			} else {
+				data = []byte{0x02, 0x00}
+			}
+		default:
+			rc = ptp.RC_DevicePropNotSupported
+		}
+	case ptp.OC_SetDevicePropValue:
+		val, err := genericReadDataOut(conn, lmp)
+		switch {
+		case err != nil:
+			rc = ptp.RC_IncompleteTransfer
+		case ptp.DevicePropCode(pkt.Parameter1) == ptp.DPC_ExposureIndex && len(val) == 2:
+			mockExposureIndex = binary.LittleEndian.Uint16(val)
+		case ptp.DevicePropCode(pkt.Parameter1) == ptp.DPC_ExposureIndex:
+			rc = ptp.RC_InvalidDevicePropFormat
+		case ptp.DevicePropCode(pkt.Parameter1) == ptp.DPC_BatteryLevel:
+			rc = ptp.RC_AccessDenied
+		default:
+			rc = ptp.RC_DevicePropNotSupported
+		}
+	case ptp.OC_ResetDevicePropValue:
+		switch ptp.DevicePropCode(pkt.Parameter1) {
+		case ptp.DPC_Artist:
+		case ptp.DPC_BatteryLevel:
+			rc = ptp.RC_AccessDenied
+		default:
+			rc = ptp.RC_DevicePropNotSupported
+		}
+	case ptp.OC_GetObject:
+		if ptp.ObjectHandle(pkt.Parameter1) == mockSlowObject {
+			genericSendSlowly(conn, pkt.TransactionID, lmp)
+			return "", nil
+		}
+		if oi, ok := mockObjects[ptp.ObjectHandle(pkt.Parameter1)]; ok && !oi.IsAssociation() {
+			data = bytes.Repeat([]byte{0xff}, int(oi.ObjectCompressedSize))
+		} else {
+			rc = ptp.RC_InvalidObjectHandle
+		}
+	case ptp.OC_DeleteObject:
+		oi, ok := mockObjects[ptp.ObjectHandle(pkt.Parameter1)]
+		switch {
+		case !ok:
+			rc = ptp.RC_InvalidObjectHandle
+		case oi.ProtectionStatus == ptp.PS_ReadOnly:
+			rc = ptp.RC_ObjectWriteProtected
+		}
+	case ptp.OC_InitiateCapture:
+		genericSendEvent(&GenericEventPacket{
+			Event: ptp.Event{
+				EventCode:     ptp.EC_ObjectAdded,
+				TransactionID: pkt.TransactionID,
+				Parameter1:    3,
+			},
+		}, lmp)
+		genericSendEvent(&GenericEventPacket{
+			Event: ptp.Event{
+				EventCode:     ptp.EC_CaptureComplete,
+				TransactionID: pkt.TransactionID,
+			},
+		}, lmp)
+	case ptp.OC_TerminateOpenCapture:
+		// The events of an open capture carry the TransactionID of the InitiateOpenCapture operation.
+		genericSendEvent(&GenericEventPacket{
+			Event: ptp.Event{
+				EventCode:     ptp.EC_ObjectAdded,
+				TransactionID: ptp.TransactionID(pkt.Parameter1),
+				Parameter1:    4,
+			},
+		}, lmp)
+		genericSendEvent(&GenericEventPacket{
+			Event: ptp.Event{
+				EventCode:     ptp.EC_CaptureComplete,
+				TransactionID: ptp.TransactionID(pkt.Parameter1),
+			},
+		}, lmp)
+	case ptp.OC_GetObjectInfo:
+		if oi, ok := mockObjects[ptp.ObjectHandle(pkt.Parameter1)]; ok {
+			data = genericObjectInfo(oi)
+		} else {
+			rc = ptp.RC_InvalidObjectHandle
+		}
+	}
+
+	if data != nil {
+		sendMessage(conn, &StartDataPacket{TransactionId: pkt.TransactionID, TotalDataLength: uint64(len(data))}, nil, lmp)
+		sendMessage(conn, &EndDataPacket{TransactionId: pkt.TransactionID}, data, lmp)
+	}
+
+	return fmt.Sprintf("OperationRequest %#x", pkt.OperationCode), &OperationResponsePacket{
 		OperationResponse: ptp.OperationResponse{
-			TransactionID: 2,
+			ResponseCode:  rc,
+			TransactionID: pkt.TransactionID,
 		},
 	}
 }
+
+// genericReadDataOut reads the data-out phase sent by the Initiator.
+func genericReadDataOut(conn net.Conn, lmp string) ([]byte, error) {
+	var data []byte
+	for {
+		_, pkt, err := readMessage(conn, lmp)
+		if err != nil {
+			return nil, err
+		}
+		switch p := pkt.(type) {
+		case *StartDataPacket:
+		case *DataPacket:
+			data = append(data, p.DataPayload...)
+		case *EndDataPacket:
+			return append(data, p.DataPayload...), nil
+		default:
+			return nil, fmt.Errorf("unexpected packet %T during data phase", pkt)
+		}
+	}
+}
+
+// genericSendSlowly sends the data in small chunks, giving the Initiator time to cancel the transaction. The cancel is
+// acknowledged with a CancelPacket on the event connection and no operation response is sent.
+func genericSendSlowly(conn net.Conn, tid ptp.TransactionID, lmp string) {
+	// Transaction IDs start over for each client, so forget about a cancellation requested by a previous one.
+	genericCancelled.Delete(tid)
+	sendMessage(conn, &StartDataPacket{TransactionId: tid, TotalDataLength: mockSlowObjectSize}, nil, lmp)
+	for sent := 0; sent < mockSlowObjectSize; sent += 1024 {
+		if _, ok := genericCancelled.Load(tid); ok {
+			genericSendEvent(&CancelPacket{TransactionId: tid}, lmp)
+			return
+		}
+		sendMessage(conn, &DataPacket{TransactionId: tid, DataPayload: bytes.Repeat([]byte{0xff}, 1024)}, nil, lmp)
+		time.Sleep(10 * time.Millisecond)
+	}
+	sendMessage(conn, &EndDataPacket{TransactionId: tid}, nil, lmp)
+	sendMessage(conn, &OperationResponsePacket{OperationResponse: ptp.OperationResponse{ResponseCode: ptp.RC_OK, TransactionID: tid}}, nil, lmp)
+}
+
+// genericBusy holds the number of times the responder answers RC_DeviceBusy when the white balance is requested.
+var genericBusy int32
+
+// genericCancelled holds the IDs of the transactions the Initiator requested to cancel.
+var genericCancelled sync.Map
+
+// genericEventConn holds the most recently initialised event connection together with the connection number the
+// Initiator sent and the ID of the last session that was opened along with the transaction ID used to open it. Tests run
+// sequentially so this will always be the event connection of the client under test.
+var (
+	genericEventConn       net.Conn
+	genericEventConnNumber uint32
+	genericSessionID       ptp.SessionID
+	genericSessionTID      ptp.TransactionID
+	genericEventConnMu     sync.Mutex
+)
+
+func genericSendEvent(evt Packet, lmp string) {
+	genericEventConnMu.Lock()
+	defer genericEventConnMu.Unlock()
+
+	if genericEventConn != nil {
+		sendMessage(genericEventConn, evt, nil, lmp)
+	}
+}
+
+// mockDeviceInfo is the DeviceInfo dataset returned by the generic responder.
+var mockDeviceInfo = ptp.DeviceInfo{
+	StandardVersion:           100,
+	VendorExtensionDesc:       "Mock",
+	OperationsSupported:       []ptp.OperationCode{ptp.OC_GetDeviceInfo, ptp.OC_OpenSession, ptp.OC_GetStorageIDs},
+	EventsSupported:           []ptp.EventCode{ptp.EC_ObjectAdded},
+	DevicePropertiesSupported: []ptp.DevicePropCode{ptp.DPC_BatteryLevel, ptp.DPC_Artist},
+	CaptureFormats:            []ptp.ObjectFormatCode{ptp.OFC_EXIF_JPEG},
+	ImageFormats:              []ptp.ObjectFormatCode{ptp.OFC_EXIF_JPEG},
+	Manufacturer:              "Mocking Bird",
+	Model:                     "Responder",
+	DeviceVersion:             "1.0",
+	SerialNumber:              "1234",
+}
+
+// mockStorageInfo holds the StorageInfo datasets of the stores returned by the generic responder.
+var mockStorageInfo = map[ptp.StorageID]ptp.StorageInfo{
+	0x00010001: {
+		StorageType:        ptp.ST_RemovableRAM,
+		FilesystemType:     ptp.FT_DCF,
+		MaxCapacity:        32 * 1024 * 1024 * 1024,
+		FreeSpaceInBytes:   16 * 1024 * 1024 * 1024,
+		FreeSpaceInImages:  1234,
+		StorageDescription: "SD card",
+		VolumeLabel:        "MOCK",
+	},
+	0x00020000: {
+		StorageType:       ptp.ST_FixedRAM,
+		FilesystemType:    ptp.FT_GenericFlat,
+		FreeSpaceInImages: 0xFFFFFFFF,
+	},
+}
+
+const (
+	// mockSlowObject is the handle of an object that is sent very slowly so its transfer can be cancelled.
+	mockSlowObject ptp.ObjectHandle = 0xFFFF
+	// mockSlowObjectSize is the size of the mockSlowObject.
+	mockSlowObjectSize = 1024 * 1024
+)
+
+// mockArtist is the value of the ptp.DPC_Artist property.
+const mockArtist = "Ansel Adams"
+
+// mockExposureIndex is the value of the ptp.DPC_ExposureIndex property which can be changed by the Initiator.
+var mockExposureIndex uint16 = 200
+
+// mockObjectHandles maps a parent object to its children. The 0xFFFFFFFF handle holds the objects in the root of the
+// store and the 0 handle holds all objects.
+var mockObjectHandles = map[ptp.ObjectHandle][]uint32{
+	0:          {1, 2, 3, 4},
+	0xFFFFFFFF: {1},
+	1:          {2},
+	2:          {3, 4},
+}
+
+var mockObjects = map[ptp.ObjectHandle]ptp.ObjectInfo{
+	1: {StorageID: 0x00010001, ObjectFormat: ptp.OFC_Association, AssociationType: ptp.AT_GenericFolder, Filename: "DCIM"},
+	2: {StorageID: 0x00010001, ObjectFormat: ptp.OFC_Association, AssociationType: ptp.AT_GenericFolder, ParentObject: 1, Filename: "100_FUJI"},
+	3: {StorageID: 0x00010001, ObjectFormat: ptp.OFC_EXIF_JPEG, ObjectCompressedSize: 4096, ParentObject: 2, Filename: "DSCF0001.JPG"},
+	4: {StorageID: 0x00010001, ObjectFormat: ptp.OFC_Undefined, ObjectCompressedSize: 8192, ParentObject: 2, Filename: "DSCF0002.RAF"},
+}
+
+func genericArray(vals []uint32) []byte {
+	b := make([]byte, 4+4*len(vals))
+	binary.LittleEndian.PutUint32(b, uint32(len(vals)))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(b[4+4*i:], v)
+	}
+
+	return b
+}
+
+func genericString(s string) []byte {
+	b, _ := ptp.String(s).MarshalBinary()
+
+	return b
+}
+
+func genericDeviceInfo(di ptp.DeviceInfo) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, di.StandardVersion)
+	binary.Write(&b, binary.LittleEndian, di.VendorExtensionID)
+	binary.Write(&b, binary.LittleEndian, di.VendorExtensionVersion)
+	b.Write(genericString(di.VendorExtensionDesc))
+	binary.Write(&b, binary.LittleEndian, di.FunctionalMode)
+	for _, a := range []interface{}{
+		di.OperationsSupported, di.EventsSupported, di.DevicePropertiesSupported, di.CaptureFormats, di.ImageFormats,
+	} {
+		binary.Write(&b, binary.LittleEndian, uint32(binary.Size(a)/2))
+		binary.Write(&b, binary.LittleEndian, a)
+	}
+	for _, s := range []string{di.Manufacturer, di.Model, di.DeviceVersion, di.SerialNumber} {
+		b.Write(genericString(s))
+	}
+
+	return b.Bytes()
+}
+
+func genericStorageInfo(si ptp.StorageInfo) []byte {
+	var b bytes.Buffer
+	for _, f := range []interface{}{
+		si.StorageType, si.FilesystemType, si.AccessCapability, si.MaxCapacity, si.FreeSpaceInBytes,
+		si.FreeSpaceInImages,
+	} {
+		binary.Write(&b, binary.LittleEndian, f)
+	}
+	b.Write(genericString(si.StorageDescription))
+	b.Write(genericString(si.VolumeLabel))
+
+	return b.Bytes()
+}
+
+func genericObjectInfo(oi ptp.ObjectInfo) []byte {
+	var b bytes.Buffer
+	for _, f := range []interface{}{
+		oi.StorageID, oi.ObjectFormat, oi.ProtectionStatus, oi.ObjectCompressedSize, oi.ThumbFormat,
+		oi.ThumbCompressedSize, oi.ThumbPixWidth, oi.ThumbPixHeight, oi.ImagePixWidth, oi.ImagePixHeight,
+		oi.ImageBitDepth, oi.ParentObject, oi.AssociationType, oi.AssociationDesc, oi.SequenceNumber,
+	} {
+		binary.Write(&b, binary.LittleEndian, f)
+	}
+	b.Write(genericString(oi.Filename))
+	b.Write(genericString("20201015T134500"))
+	b.Write(genericString(""))
+	b.Write(genericString(oi.Keywords))
+
+	return b.Bytes()
+}
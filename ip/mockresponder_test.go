@@ -121,6 +121,15 @@ func readMessage(r io.Reader, lmp string) (Header, PacketOut, error) {
 		return h, nil, err
 	}
 
+	if rp, ok := pkt.(rawPayloadPacket); ok {
+		pl := make([]byte, int(h.Length)-HeaderSize)
+		if _, err := io.ReadFull(r, pl); err != nil {
+			lgr.Errorf("%s error reading packet %T data %s", lmp, pkt, err)
+			return h, nil, err
+		}
+		return h, pkt, rp.setRawPayload(pl)
+	}
+
 	vs := int(h.Length) - HeaderSize - internal.TotalSizeOfFixedFields(pkt)
 	_, err = internal.UnmarshalLittleEndian(r, pkt, int(h.Length)-HeaderSize, vs)
 	// TODO: handle byte array being returned?
@@ -178,7 +187,12 @@ func alwaysFailMessage(conn net.Conn, _ chan uint32, lmp string) {
 func sendAnyPacket(w io.Writer, p Packet, extra []byte, lmp string) error {
 	lgr.Infof("%s sendAnyPacket() %T", lmp, p)
 
-	pl := internal.MarshalLittleEndian(p)
+	var pl []byte
+	if po, ok := p.(PacketOut); ok {
+		pl = po.Payload()
+	} else {
+		pl = internal.MarshalLittleEndian(p)
+	}
 	pll := len(pl)
 	if extra != nil {
 		pll += len(extra)
@@ -0,0 +1,234 @@
+package ip
+
+import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"path"
+	"strings"
+	"sync"
+)
+
+// rootObjectHandle is passed as parent to GetObjectHandles to only list the objects in the root of a store.
+const rootObjectHandle ptp.ObjectHandle = 0xFFFFFFFF
+
+// ObjectNode represents an object on the Responder as part of an ObjectTree. Nodes representing a store or an
+// association, i.e. a folder, can hold child nodes. These are only requested from the Responder when they are accessed
+// for the first time.
+type ObjectNode struct {
+	Handle    ptp.ObjectHandle
+	StorageID ptp.StorageID
+	// Info holds the ObjectInfo dataset of the object. It is nil for nodes representing a store.
+	Info     *ptp.ObjectInfo
+	Parent   *ObjectNode
+	tree     *ObjectTree
+	children []*ObjectNode
+	loaded   bool
+}
+
+// Name returns the filename of the object. Stores do not have a filename so they are named after their StorageID.
+func (n *ObjectNode) Name() string {
+	if n.Info == nil {
+		return fmt.Sprintf("store_%08x", uint32(n.StorageID))
+	}
+
+	return n.Info.Filename
+}
+
+// Path returns the full path to the object starting from the store it resides in, e.g.
+// /store_00010001/DCIM/100_FUJI/DSCF0001.JPG.
+func (n *ObjectNode) Path() string {
+	if n.Parent == nil {
+		return "/" + n.Name()
+	}
+
+	return path.Join(n.Parent.Path(), n.Name())
+}
+
+// IsDir indicates if the object can hold other objects, which is the case for stores and associations.
+func (n *ObjectNode) IsDir() bool {
+	return n.Info == nil || n.Info.IsAssociation()
+}
+
+// Children returns the direct children of the node. The Responder is only queried the first time this method is called,
+// subsequent calls will return the cached result until ObjectTree.Invalidate() is called.
+func (n *ObjectNode) Children() ([]*ObjectNode, error) {
+	if !n.IsDir() {
+		return nil, nil
+	}
+
+	return n.tree.children(n)
+}
+
+// ObjectTree allows navigating the objects on a Responder as if it were a directory hierarchy: each store is a top level
+// directory and associations are sub directories. The tree is built lazily and cached as it is being traversed.
+type ObjectTree struct {
+	c      *Client
+	mu     sync.Mutex
+	stores []*ObjectNode
+}
+
+// NewObjectTree creates an ObjectTree for the given client. The client must be connected before traversing the tree.
+func NewObjectTree(c *Client) *ObjectTree {
+	return &ObjectTree{c: c}
+}
+
+// Stores returns a node for each logical store present on the Responder.
+func (t *ObjectTree) Stores() ([]*ObjectNode, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stores != nil {
+		return t.stores, nil
+	}
+
+	sids, err := t.c.GetStorageIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]*ObjectNode, 0, len(sids))
+	for _, sid := range sids {
+		// A LogicalStorageID of 0x0000 indicates removable media that is not inserted.
+		if sid&0x0000FFFF == 0 {
+			continue
+		}
+		stores = append(stores, &ObjectNode{
+			Handle:    rootObjectHandle,
+			StorageID: sid,
+			tree:      t,
+		})
+	}
+	t.stores = stores
+
+	return t.stores, nil
+}
+
+// Lookup returns the node found at the given path. The path must start with the name of the store, e.g.
+// /store_00010001/DCIM.
+func (t *ObjectTree) Lookup(p string) (*ObjectNode, error) {
+	stores, err := t.Stores()
+	if err != nil {
+		return nil, err
+	}
+
+	elems := strings.Split(strings.Trim(path.Clean("/"+p), "/"), "/")
+	nodes := stores
+	var found *ObjectNode
+	for _, elem := range elems {
+		found = nil
+		for _, n := range nodes {
+			if n.Name() == elem {
+				found = n
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%w: %s", ObjectNotFoundError, p)
+		}
+		if nodes, err = found.Children(); err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
+
+// Walk traverses the full tree depth first calling fn for each node. Returning an error from fn stops the walk and
+// returns that error.
+func (t *ObjectTree) Walk(fn func(*ObjectNode) error) error {
+	stores, err := t.Stores()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stores {
+		if err := walkObjectNode(s, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes the object held by the node from the Responder and removes the node from the tree. Stores cannot be
+// deleted.
+func (t *ObjectTree) Delete(n *ObjectNode) error {
+	if n.Info == nil {
+		return fmt.Errorf("cannot delete store %s", n.Name())
+	}
+	if err := t.c.DeleteObject(n.Handle); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p := n.Parent; p != nil && p.loaded {
+		for i, child := range p.children {
+			if child == n {
+				p.children = append(p.children[:i:i], p.children[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Invalidate drops all cached nodes so the Responder will be queried again when traversing the tree.
+func (t *ObjectTree) Invalidate() {
+	t.mu.Lock()
+	t.stores = nil
+	t.mu.Unlock()
+}
+
+func (t *ObjectTree) children(n *ObjectNode) ([]*ObjectNode, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n.loaded {
+		return n.children, nil
+	}
+
+	handles, err := t.c.GetObjectHandles(n.StorageID, 0, n.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*ObjectNode, 0, len(handles))
+	for _, h := range handles {
+		oi, err := t.c.GetObjectInfo(h)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, &ObjectNode{
+			Handle:    h,
+			StorageID: oi.StorageID,
+			Info:      oi,
+			Parent:    n,
+			tree:      t,
+		})
+	}
+	n.children = children
+	n.loaded = true
+
+	return n.children, nil
+}
+
+func walkObjectNode(n *ObjectNode, fn func(*ObjectNode) error) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+
+	children, err := n.Children()
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := walkObjectNode(child, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package ip
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestObjectTree_Stores(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("6f3b4a0e-8d1c-4c55-93a1-2b0e0f1d7c11"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ot := NewObjectTree(c)
+	got, err := ot.Stores()
+	if err != nil {
+		t.Fatalf("Stores() err = %s; want <nil>", err)
+	}
+	// The second store in the mock has no media inserted and must be skipped.
+	if len(got) != 1 {
+		t.Fatalf("Stores() len = %d; want 1", len(got))
+	}
+	if got[0].Name() != "store_00010001" {
+		t.Errorf("Stores() Name() = %s; want store_00010001", got[0].Name())
+	}
+	if !got[0].IsDir() {
+		t.Errorf("Stores() IsDir() = %v; want true", got[0].IsDir())
+	}
+}
+
+func TestObjectTree_Lookup(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("e1d2c3b4-a596-4877-8899-aabbccddeeff"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ot := NewObjectTree(c)
+	want := "/store_00010001/DCIM/100_FUJI/DSCF0002.RAF"
+	got, err := ot.Lookup(want)
+	if err != nil {
+		t.Fatalf("Lookup() err = %s; want <nil>", err)
+	}
+	if got.Handle != 4 {
+		t.Errorf("Lookup() Handle = %d; want 4", got.Handle)
+	}
+	if got.Path() != want {
+		t.Errorf("Lookup() Path() = %s; want %s", got.Path(), want)
+	}
+	if got.IsDir() {
+		t.Errorf("Lookup() IsDir() = %v; want false", got.IsDir())
+	}
+
+	// Cached nodes must be returned on subsequent lookups.
+	dir, err := ot.Lookup("store_00010001/DCIM/100_FUJI/")
+	if err != nil {
+		t.Fatalf("Lookup() err = %s; want <nil>", err)
+	}
+	if got.Parent != dir {
+		t.Errorf("Lookup() got = %p; want %p", dir, got.Parent)
+	}
+
+	_, err = ot.Lookup("/store_00010001/DCIM/nope")
+	if !errors.Is(err, ObjectNotFoundError) {
+		t.Errorf("Lookup() err = %s; want %s", err, ObjectNotFoundError)
+	}
+}
+
+func TestObjectTree_Walk(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("1a2b3c4d-5e6f-4a8b-9c0d-1e2f3a4b5c6d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err = NewObjectTree(c).Walk(func(n *ObjectNode) error {
+		got = append(got, n.Path())
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Walk() err = %s; want <nil>", err)
+	}
+
+	want := []string{
+		"/store_00010001",
+		"/store_00010001/DCIM",
+		"/store_00010001/DCIM/100_FUJI",
+		"/store_00010001/DCIM/100_FUJI/DSCF0001.JPG",
+		"/store_00010001/DCIM/100_FUJI/DSCF0002.RAF",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Walk() visited %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Walk() node %d = %s; want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestObjectTree_Delete(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3e9f0a1b-6c2d-4e8f-a7b6-c5d4e3f2a1b0"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ot := NewObjectTree(c)
+	n, err := ot.Lookup("/store_00010001/DCIM/100_FUJI/DSCF0001.JPG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ot.Delete(n); err != nil {
+		t.Fatalf("Delete() err = %s; want <nil>", err)
+	}
+	children, err := n.Parent.Children()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 1 || children[0].Name() != "DSCF0002.RAF" {
+		t.Errorf("Delete() did not remove the node from the tree, children = %v", children)
+	}
+
+	stores, err := ot.Stores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ot.Delete(stores[0]); err == nil {
+		t.Error("Delete() of a store err = <nil>; want error")
+	}
+}
@@ -0,0 +1,133 @@
+package ip
+
+import (
+	"crypto/tls"
+	"github.com/google/uuid"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"log"
+	"os"
+)
+
+// Option configures a Client created by NewClient.
+type Option func(c *Client) error
+
+// WithVendor sets the vendor of the Responder, which determines the vendor extensions used to talk to it. Defaults to
+// DefaultVendor.
+func WithVendor(vendor string) Option {
+	return func(c *Client) error {
+		c.responder.Vendor = ptp.VendorStringToType(vendor)
+		return nil
+	}
+}
+
+// WithPort sets the port used for all connections to the Responder. Defaults to DefaultPort. Use SetCommandDataPort(),
+// SetEventPort() and SetStreamerPort() for Responders using a separate port per connection.
+func WithPort(port uint16) Option {
+	return func(c *Client) error {
+		c.responder.CommandDataPort = port
+		c.responder.EventPort = port
+		c.responder.StreamerPort = port
+		return nil
+	}
+}
+
+// WithFriendlyName sets the friendly name the client identifies itself with. Passing an empty string will use
+// InitiatorFriendlyName, which is the default.
+func WithFriendlyName(name string) Option {
+	return func(c *Client) error {
+		if name == "" {
+			name = InitiatorFriendlyName
+		}
+		c.initiator.FriendlyName = name
+		return nil
+	}
+}
+
+// WithGUID sets the GUID the client identifies itself with. Passing an empty string keeps the random V4 UUID generated
+// by default. Some cameras only accept clients they were paired with, so use a fixed GUID to reconnect to them.
+func WithGUID(guid string) Option {
+	return func(c *Client) error {
+		if guid == "" {
+			return nil
+		}
+		id, err := uuid.Parse(guid)
+		if err != nil {
+			return err
+		}
+		c.initiator.GUID = id
+		return nil
+	}
+}
+
+// WithTimeouts sets the time the client waits for the Responder. Defaults to DefaultTimeouts().
+func WithTimeouts(t Timeouts) Option {
+	return func(c *Client) error {
+		c.SetTimeouts(t)
+		return nil
+	}
+}
+
+// WithRetryPolicy enables retrying operations that fail for a transient reason. See SetRetryPolicy().
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(c *Client) error {
+		c.SetRetryPolicy(p)
+		return nil
+	}
+}
+
+// WithEventHistorySize sets the number of events returned by RecentEvents(). Defaults to DefaultEventHistorySize.
+func WithEventHistorySize(size int) Option {
+	return func(c *Client) error {
+		c.SetEventHistorySize(size)
+		return nil
+	}
+}
+
+// WithJournal replaces the journal recording the operation requests and responses and the events. See SetJournal().
+func WithJournal(j *Journal) Option {
+	return func(c *Client) error {
+		c.SetJournal(j)
+		return nil
+	}
+}
+
+// WithLogger sets a custom logger.
+func WithLogger(l Logger) Option {
+	return func(c *Client) error {
+		c.SetLogger(l)
+		return nil
+	}
+}
+
+// WithLogLevel makes the client log to stderr using the Go log package at the given level. Defaults to LevelSilent.
+func WithLogLevel(level LogLevel) Option {
+	return func(c *Client) error {
+		c.SetLogger(NewLogger(level, os.Stderr, "", log.LstdFlags))
+		return nil
+	}
+}
+
+// WithDialer sets a custom Dialer used for all connections to the Responder. See SetDialer().
+func WithDialer(d Dialer) Option {
+	return func(c *Client) error {
+		c.SetDialer(d)
+		return nil
+	}
+}
+
+// WithTLSConfig wraps all connections to the Responder in TLS. See SetTLSConfig().
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) error {
+		c.SetTLSConfig(cfg)
+		return nil
+	}
+}
+
+// WithIdentityStore makes the client reuse the identity saved for the Responder, overriding the WithGUID and
+// WithFriendlyName options. See SetIdentityStore().
+func WithIdentityStore(s IdentityStore) Option {
+	return func(c *Client) error {
+		c.identities = s
+		return nil
+	}
+}
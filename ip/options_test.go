@@ -0,0 +1,79 @@
+package ip
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestNewClientDefaults(t *testing.T) {
+	c, err := NewClient(DefaultIpAddress)
+	if err != nil {
+		t.Fatalf("NewClient() err = %s; want <nil>", err)
+	}
+
+	if got := c.ResponderVendor(); got != ptp.VendorStringToType(DefaultVendor) {
+		t.Errorf("NewClient() ResponderVendor() = %#x; want %#x", got, ptp.VendorStringToType(DefaultVendor))
+	}
+	want := DefaultIpAddress + ":15740"
+	if got := c.CommandDataAddress(); got != want {
+		t.Errorf("NewClient() CommandDataAddress() = %s; want %s", got, want)
+	}
+	if got := c.InitiatorFriendlyName(); got != InitiatorFriendlyName {
+		t.Errorf("NewClient() InitiatorFriendlyName() = %s; want %s", got, InitiatorFriendlyName)
+	}
+	if got := c.Timeouts(); got != DefaultTimeouts() {
+		t.Errorf("NewClient() Timeouts() = %v; want %v", got, DefaultTimeouts())
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(LevelDebug, &buf, "", 0)
+	d := DialFunc(nil)
+	to := Timeouts{Operation: time.Minute}
+	c, err := NewClient(DefaultIpAddress,
+		WithVendor("fuji"),
+		WithPort(55740),
+		WithFriendlyName("optiöns"),
+		WithGUID("3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e"),
+		WithTimeouts(to),
+		WithLogger(l),
+		WithDialer(d),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() err = %s; want <nil>", err)
+	}
+
+	if got := c.ResponderVendor(); got != ptp.VE_FujiPhotoFilmCoLtd {
+		t.Errorf("NewClient() ResponderVendor() = %#x; want %#x", got, ptp.VE_FujiPhotoFilmCoLtd)
+	}
+	want := DefaultIpAddress + ":55740"
+	if got := c.EventAddress(); got != want {
+		t.Errorf("NewClient() EventAddress() = %s; want %s", got, want)
+	}
+	if got := c.InitiatorFriendlyName(); got != "optiöns" {
+		t.Errorf("NewClient() InitiatorFriendlyName() = %s; want optiöns", got)
+	}
+	if got := c.InitiatorGUIDAsString(); got != "3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e" {
+		t.Errorf("NewClient() InitiatorGUIDAsString() = %s; want 3b4c5d6e-7f8a-4b9c-8d0e-1f2a3b4c5d6e", got)
+	}
+	if got := c.Timeouts(); got != to {
+		t.Errorf("NewClient() Timeouts() = %v; want %v", got, to)
+	}
+	if c.Logger != l {
+		t.Errorf("NewClient() Logger = %v; want %v", c.Logger, l)
+	}
+	if c.dialer == nil {
+		t.Error("NewClient() dialer = <nil>; want DialFunc")
+	}
+	// The Fuji vendor extensions must be loaded since the vendor is only known after applying the options.
+	if c.vendorExtensions.openSession == nil {
+		t.Error("NewClient() vendor extensions not loaded")
+	}
+
+	if _, err := NewClient(DefaultIpAddress, WithGUID("not a guid")); err == nil {
+		t.Error("NewClient() err = <nil>; want invalid UUID error")
+	}
+}
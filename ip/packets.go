@@ -1,6 +1,7 @@
 package ip
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
@@ -75,6 +76,13 @@ type PacketIn interface {
 	TotalFixedFieldSize() int
 }
 
+// rawPayloadPacket is implemented by the packets transporting data: the data has no structure that can be unmarshalled
+// so the payload of the packet is handed over as is.
+type rawPayloadPacket interface {
+	PacketIn
+	setRawPayload(pl []byte) error
+}
+
 type Header struct {
 	Length     uint32
 	PacketType PacketType
@@ -223,25 +231,38 @@ func (ifp *InitFailPacket) TotalFixedFieldSize() int {
 	return internal.TotalSizeOfFixedFields(ifp)
 }
 
+// ReasonAsError returns the failure reason as an *InitFailError.
 func (ifp *InitFailPacket) ReasonAsError() error {
-	var msg string
-	switch ifp.Reason {
+	return &InitFailError{Reason: ifp.Reason}
+}
+
+// InitFailError is returned when the Responder refuses the connection by sending an InitFailPacket.
+type InitFailError struct {
+	Reason FailReason
+}
+
+func (e *InitFailError) Error() string {
+	switch e.Reason {
 	case FR_FailBusy:
-		msg = "busy: too many active connections"
+		return "busy: too many active connections"
 	case FR_FailRejectedInitiator:
-		msg = "rejected: device not allowed"
+		return "rejected: device not allowed"
 	case FR_FailUnspecified:
-		msg = "reason unspecified"
+		return "reason unspecified"
 	// TODO: should we not split off the vendor related errors somehow, to prevent this from becoming a very long list?
 	case FR_Fuji_DeviceBusy:
-		msg = "fuji: invalid friendly name or camera state: allow to 'change' client or 'reset' connection"
+		return "fuji: invalid friendly name or camera state: allow to 'change' client or 'reset' connection"
 	case FR_Fuji_InvalidParameter:
-		msg = "fuji: unknown protocol version"
-	default:
-		msg = fmt.Sprintf("unknown failure reason returned %#x", ifp.Reason)
+		return "fuji: unknown protocol version"
 	}
 
-	return errors.New(msg)
+	return fmt.Sprintf("unknown failure reason returned %#x", e.Reason)
+}
+
+// Is makes errors.Is() match ptp.ErrDeviceBusy when the Responder is busy. FR_Fuji_DeviceBusy is not matched: it
+// requires the user to act on the camera, so trying again will not help.
+func (e *InitFailError) Is(target error) bool {
+	return target == ptp.ErrDeviceBusy && e.Reason == FR_FailBusy
 }
 
 // OperationRequestPacket is used to transport operation requests. PTP-IP Operation Request Packets are issued by the
@@ -285,6 +306,9 @@ func (orp *OperationResponsePacket) TotalFixedFieldSize() int {
 type EventPacket interface {
 	PacketIn
 	GetEventCode() ptp.EventCode
+	// Typed returns the event as one of the concrete ptp event types, e.g. ptp.ObjectAddedEvent, allowing to use a
+	// type switch.
+	Typed() ptp.TypedEvent
 }
 
 // GenericEventPacket is used to send PTP Events on the Event TCP connection. The events are used to inform the
@@ -339,7 +363,7 @@ func (sdp *StartDataPacket) TotalFixedFieldSize() int {
 // mechanism MAY be utilized to allow for a simple data transfer cancelling mechanism. No error checking is required.
 type DataPacket struct {
 	TransactionId ptp.TransactionID
-	DataPayload   interface{}
+	DataPayload   []byte
 }
 
 func (dp *DataPacket) PacketType() PacketType {
@@ -347,11 +371,18 @@ func (dp *DataPacket) PacketType() PacketType {
 }
 
 func (dp *DataPacket) Payload() []byte {
-	return internal.MarshalLittleEndian(dp)
+	return append(internal.MarshalLittleEndian(dp.TransactionId), dp.DataPayload...)
 }
 
 func (dp *DataPacket) TotalFixedFieldSize() int {
-	return internal.TotalSizeOfFixedFields(dp)
+	return internal.TotalSizeOfFixedFields(dp.TransactionId)
+}
+
+func (dp *DataPacket) setRawPayload(pl []byte) error {
+	var err error
+	dp.TransactionId, dp.DataPayload, err = splitDataPayload(pl)
+
+	return err
 }
 
 // EndDataPacket is used to indicate the end of the data phase. The EndDataPacket can also carry useful data. This
@@ -360,7 +391,7 @@ func (dp *DataPacket) TotalFixedFieldSize() int {
 // from the Initiator to the Responder.
 type EndDataPacket struct {
 	TransactionId ptp.TransactionID
-	DataPayload   interface{}
+	DataPayload   []byte
 }
 
 func (edp *EndDataPacket) PacketType() PacketType {
@@ -368,11 +399,27 @@ func (edp *EndDataPacket) PacketType() PacketType {
 }
 
 func (edp *EndDataPacket) Payload() []byte {
-	return internal.MarshalLittleEndian(edp)
+	return append(internal.MarshalLittleEndian(edp.TransactionId), edp.DataPayload...)
 }
 
 func (edp *EndDataPacket) TotalFixedFieldSize() int {
-	return internal.TotalSizeOfFixedFields(edp)
+	return internal.TotalSizeOfFixedFields(edp.TransactionId)
+}
+
+func (edp *EndDataPacket) setRawPayload(pl []byte) error {
+	var err error
+	edp.TransactionId, edp.DataPayload, err = splitDataPayload(pl)
+
+	return err
+}
+
+// splitDataPayload splits the payload of a data packet in the transaction ID and the data.
+func splitDataPayload(pl []byte) (ptp.TransactionID, []byte, error) {
+	if len(pl) < 4 {
+		return 0, nil, InvalidPacketError
+	}
+
+	return ptp.TransactionID(binary.LittleEndian.Uint32(pl[0:4])), pl[4:], nil
 }
 
 // CancelPacket is used to cancel a transaction.
@@ -438,6 +485,16 @@ func (prsp *ProbeResponsePacket) TotalFixedFieldSize() int {
 	return internal.TotalSizeOfFixedFields(prsp)
 }
 
+// marshalPacket returns the packet as it is sent out, header included.
+func marshalPacket(p PacketOut) []byte {
+	pl := p.Payload()
+	if p.PacketType() == PKT_Invalid {
+		return append(internal.MarshalLittleEndian(uint32(len(pl)+4)), pl...)
+	}
+
+	return append(internal.MarshalLittleEndian(Header{uint32(len(pl) + HeaderSize), p.PacketType()}), pl...)
+}
+
 // NewPacketOutFromPacketType creates an new packet struct based on the given packet type. All fields will be left
 // uninitialised.
 func NewPacketOutFromPacketType(pt PacketType) (PacketOut, error) {
@@ -2,13 +2,16 @@ package ip
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/malc0mn/ptp-ip/ip/internal"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -125,6 +128,7 @@ const (
 	WB_Fuji_Custom       ptp.WhiteBalance = 0x800C
 
 	DPC_Fuji_FilmSimulation  ptp.DevicePropCode = 0xD001
+	DPC_Fuji_ColorTemp       ptp.DevicePropCode = 0xD017
 	DPC_Fuji_ImageQuality    ptp.DevicePropCode = 0xD018
 	DPC_Fuji_RecMode         ptp.DevicePropCode = 0xD019
 	DPC_Fuji_CommandDialMode ptp.DevicePropCode = 0xD028
@@ -345,14 +349,10 @@ func (forp *FujiOperationResponsePacket) TotalFixedFieldSize() int {
 	return internal.TotalSizeOfFixedFields(forp)
 }
 
+// operationCodeToOKResponseCode returns the response code, other than ptp.RC_OK, that indicates success for the given
+// operation.
 func operationCodeToOKResponseCode(oc ptp.OperationCode) ptp.OperationResponseCode {
 	switch oc {
-	case OC_Fuji_GetDeviceInfo:
-		return RC_Fuji_GetDeviceInfo
-	case ptp.OC_GetDevicePropDesc:
-		return RC_Fuji_GetDevicePropDesc
-	case ptp.OC_GetDevicePropValue:
-		return RC_Fuji_GetDevicePropValue
 	case ptp.OC_OpenSession:
 		return ptp.RC_SessionAlreadyOpen
 	default:
@@ -366,9 +366,9 @@ func (forp *FujiOperationResponsePacket) WasSuccessful(rc ptp.OperationResponseC
 	return forp.OperationResponseCode == ptp.RC_OK || (rc != 0 && forp.OperationResponseCode == rc)
 }
 
-// ReasonAsError returns an error based on the operation response code.
+// ReasonAsError returns a *ptp.ResponseError based on the operation response code.
 func (forp *FujiOperationResponsePacket) ReasonAsError() error {
-	return ptp.OperationResponseCodeAsError(forp.OperationResponseCode)
+	return ptp.NewResponseError(forp.OperationResponseCode, 0, forp.TransactionID)
 }
 
 // FujiEventPacket is the Fuji version of the PTP/IP EventPacket which again deviates from the standard. 'Over the wire'
@@ -416,6 +416,46 @@ func (fep *FujiEventPacket) GetEventCode() ptp.EventCode {
 	return fep.EventCode
 }
 
+// FujiPreviewAvailableEvent is the typed version of EC_Fuji_PreviewAvailable.
+type FujiPreviewAvailableEvent struct {
+	TransactionID ptp.TransactionID
+	// Size holds the size in bytes of the image preview data.
+	Size uint32
+}
+
+func (FujiPreviewAvailableEvent) EventCode() ptp.EventCode {
+	return EC_Fuji_PreviewAvailable
+}
+
+// FujiObjectAddedEvent is the typed version of EC_Fuji_ObjectAdded. It holds the transaction ID of the capture adding
+// the object since the event does not hold the object handle.
+type FujiObjectAddedEvent struct {
+	TransactionID ptp.TransactionID
+}
+
+func (FujiObjectAddedEvent) EventCode() ptp.EventCode {
+	return EC_Fuji_ObjectAdded
+}
+
+// Typed maps the Fuji event onto the typed events. The vendor specific events are decoded using the decoders registered
+// with RegisterVendorEvent(). Apart from those, Fuji uses the standard event codes with the standard parameters: e.g.
+// the property change notifications sent during remote shooting are ptp.EC_DevicePropChanged events holding the
+// property code in Parameter1, resulting in a ptp.DevicePropChangedEvent.
+func (fep *FujiEventPacket) Typed() ptp.TypedEvent {
+	e := ptp.Event{
+		EventCode:     fep.EventCode,
+		TransactionID: fep.TransactionID,
+		Parameter1:    fep.Parameter1,
+		Parameter2:    fep.Parameter2,
+		Parameter3:    fep.Parameter3,
+	}
+	if te, ok := decodeVendorEvent(ptp.VE_FujiPhotoFilmCoLtd, e); ok {
+		return te
+	}
+
+	return e.Typed()
+}
+
 func (fep *FujiEventPacket) PacketType() PacketType {
 	return PKT_Invalid
 }
@@ -429,27 +469,37 @@ func NewFujiEventPacket() EventPacket {
 }
 
 // FujiExtractTransactionId extracts the transaction ID from a full raw inbound packet. This packet must include the
-// full header containing length and packet type.
+// full header containing length and packet type. A TruncatedPacketError is returned when the packet is too short to
+// hold the transaction ID.
 func FujiExtractTransactionId(p []byte, ct connectionType) (ptp.TransactionID, error) {
-	errFmt := "packet too small: got length %d"
-
-	var data []byte
 	switch ct {
 	case cmdDataConnection:
-		if len(p) < 8 {
-			return 0, fmt.Errorf(errFmt, len(p))
-		}
-
-		data = p[8:12]
+		return readTransactionId(p, 8)
 	case eventConnection:
-		if len(p) < 12 {
-			return 0, fmt.Errorf(errFmt, len(p))
-		}
+		return readTransactionId(p, 12)
+	}
+
+	return 0, fmt.Errorf("%w: no transaction ID on the %s connection", UnexpectedPacketError, ct)
+}
+
+// fujiReadTransactionPacket interprets a packet received during a transaction. Fuji does not send a packet type, so
+// the DataPhase field is used instead: DP_DataOut indicates a data packet, anything else is the operation response.
+// Data packets carry the operation code where the response code is expected, which is verified to make sure the data
+// belongs to the operation.
+func fujiReadTransactionPacket(raw []byte, code ptp.OperationCode) (*transactionPacket, error) {
+	if len(raw) < 12 {
+		return nil, InvalidPacketError
+	}
 
-		data = p[12:16]
+	rc := binary.LittleEndian.Uint16(raw[6:8])
+	if DataPhase(binary.LittleEndian.Uint16(raw[4:6])) == DP_DataOut {
+		if rc != uint16(code) {
+			return nil, fmt.Errorf("%w: data for operation %#x while expecting %#x", UnexpectedPacketError, rc, code)
+		}
+		return &transactionPacket{kind: pkData, payload: raw[12:]}, nil
 	}
 
-	return ptp.TransactionID(binary.LittleEndian.Uint32(data)), nil
+	return &transactionPacket{kind: pkResponse, code: ptp.OperationResponseCode(rc), payload: raw[12:]}, nil
 }
 
 // FujiInitCommandDataConn initialises the Fuji command/data connection. It expects an open TCP connection to the
@@ -473,68 +523,203 @@ func FujiExtractTransactionId(p []byte, ct connectionType) (ptp.TransactionID, e
 //   6. Finally, we send the operation request OC_InitiateOpenCapture which makes the Responder hand over control to the
 //      Initiator. This also opens up the event connection port 55741 used by Fuji so we can connect to it and complete
 //      the init sequence there.
-func FujiInitCommandDataConn(c *Client) error {
+func FujiInitCommandDataConn(ctx context.Context, c *Client) error {
 	// The first part of the sequence is according to the PTP/IP standard, save for the different packet format.
-	if err := GenericInitCommandDataConn(c); err != nil {
+	if err := GenericInitCommandDataConn(ctx, c); err != nil {
 		return err
 	}
 
 	c.Info("Opening a session...")
-	if err := FujiSendOperationRequestIgnoreResponse(c, ptp.OC_OpenSession, 0x00000001, 0); err != nil {
+	if err := FujiSendOperationRequestIgnoreResponse(ctx, c, ptp.OC_OpenSession, 0x00000001, 0); err != nil {
 		return err
 	}
 
 	c.Info("Setting correct init sequence number...")
 	c.Infof("Should you be prompted, please accept the new connection request on the %s.", c.ResponderFriendlyName())
-	if err := FujiSetDeviceProperty(c, DPC_Fuji_InitSequence, PM_Fuji_InitSequence); err != nil {
+	if err := FujiSetDeviceProperty(ctx, c, DPC_Fuji_InitSequence, PM_Fuji_InitSequence); err != nil {
 		return err
 	}
 
 	c.Info("Getting current minimum application version...")
-	val, err := FujiGetDevicePropertyValue(c, DPC_Fuji_AppVersion)
+	raw, err := FujiGetDevicePropertyValue(ctx, c, DPC_Fuji_AppVersion)
 	if err != nil {
 		return err
 	}
+	val := rawValueToUint32(raw)
 	c.Infof("Acknowledging current minimal application version as communicated by the %s: %#x", c.ResponderFriendlyName(), val)
-	if err := FujiSetDeviceProperty(c, DPC_Fuji_AppVersion, val); err != nil {
+	if err := FujiSetDeviceProperty(ctx, c, DPC_Fuji_AppVersion, val); err != nil {
 		return err
 	}
 
 	c.Info("Initiating open capture...")
-	if err := FujiSendOperationRequestIgnoreResponse(c, ptp.OC_InitiateOpenCapture, PM_Fuji_NoParam, 0); err != nil {
+	if err := FujiSendOperationRequestIgnoreResponse(ctx, c, ptp.OC_InitiateOpenCapture, PM_Fuji_NoParam, 0); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// FujiProcessStreamData reads raw image data from the incoming stream and sends them to the streamer channel.
+// FujiOpenSession does nothing since the session is opened by FujiInitCommandDataConn already: Fuji expects it before
+// the event connection can be established.
+func FujiOpenSession(_ context.Context, _ *Client) error {
+	return nil
+}
+
+// FujiCloseSession terminates the open capture initiated by FujiInitCommandDataConn, which hands control back to the
+// camera, and then closes the session. Without this, the camera keeps showing it is connected after the client is gone.
+func FujiCloseSession(ctx context.Context, c *Client) error {
+	c.Info("Terminating open capture...")
+	if err := FujiSendOperationRequestIgnoreResponse(ctx, c, ptp.OC_TerminateOpenCapture, PM_Fuji_NoParam, 0); err != nil {
+		return err
+	}
+
+	c.Info("Closing the session...")
+	return FujiSendOperationRequestIgnoreResponse(ctx, c, ptp.OC_CloseSession, PM_Fuji_NoParam, 0)
+}
+
+// FujiCancelTransaction is not supported: there is no known way to cancel a transaction on a Fuji device.
+func FujiCancelTransaction(_ *Client, _ ptp.TransactionID) error {
+	return errors.New("command not YET supported")
+}
+
+// FujiStreamerPort is the port Fuji devices listen on for the streamer connection. Fuji devices use 55740 for the
+// command/data connection and 55741 for the event connection.
+const FujiStreamerPort uint16 = 55742
+
+// fujiStreamFrameHeaderSize is the size of the header preceding the image data of a stream frame, the length field
+// included.
+const fujiStreamFrameHeaderSize = 18
+
+// FujiStreamFrame is a single live view frame received on the streamer connection of Fuji devices.
+type FujiStreamFrame struct {
+	// Number is a counter which wraps around after 0xff, allowing to detect dropped frames.
+	Number uint8
+	// Header holds the bytes between the counter and the image data. Their meaning is unknown, but they always end in
+	// two bytes with unknown significance (seen 0xff, 0xff as well as 0x5e, 0x49 and 0x4b, 0xbf).
+	Header []byte
+	// Image holds the JPEG image data.
+	Image []byte
+	// Trailer holds the bytes following the end of image marker of the JPEG image data, if any. When present, it holds
+	// the focus state followed by the number of detected faces and their bounding boxes.
+	Trailer []byte
+}
+
+// fujiStreamFaceSize is the size of a face bounding box in the trailer of a stream frame: four uint16 values holding
+// the x and y coordinates of the top left corner, the width and the height.
+const fujiStreamFaceSize = 8
+
+// Metadata returns the metadata carried by the frame. Frames without a trailer only have their frame number set. A
+// trailer too short to hold all of the announced faces only has the focus state decoded.
+func (f *FujiStreamFrame) Metadata() *LiveViewMetadata {
+	m := &LiveViewMetadata{FrameNumber: f.Number}
+	if len(f.Trailer) < 2 {
+		return m
+	}
+
+	m.FocusState = FocusState(f.Trailer[0])
+	faces := f.Trailer[2:]
+	if len(faces) < int(f.Trailer[1])*fujiStreamFaceSize {
+		return m
+	}
+	for i := 0; i < int(f.Trailer[1]); i++ {
+		b := faces[i*fujiStreamFaceSize:]
+		x, y := int(binary.LittleEndian.Uint16(b[0:2])), int(binary.LittleEndian.Uint16(b[2:4]))
+		w, h := int(binary.LittleEndian.Uint16(b[4:6])), int(binary.LittleEndian.Uint16(b[6:8]))
+		m.Faces = append(m.Faces, image.Rect(x, y, x+w, y+h))
+	}
+
+	return m
+}
+
+// ParseFujiStreamFrame parses a raw packet received on the streamer connection, length field included. The length
+// field is followed by four bytes which are always zero, a one byte counter and nine bytes of unknown meaning after
+// which the image data fills the rest of the packet. Any bytes following the end of image marker are split off into the
+// trailer.
+func ParseFujiStreamFrame(raw []byte) (*FujiStreamFrame, error) {
+	if len(raw) < fujiStreamFrameHeaderSize {
+		return nil, fmt.Errorf("%w: stream frame of %d bytes is too short", InvalidPacketError, len(raw))
+	}
+	if l := binary.LittleEndian.Uint32(raw[0:4]); l != uint32(len(raw)) {
+		return nil, fmt.Errorf("%w: stream frame length %d does not match the packet length %d", InvalidPacketError, l,
+			len(raw))
+	}
+
+	f := &FujiStreamFrame{
+		Number: raw[8],
+		Header: raw[9:fujiStreamFrameHeaderSize],
+		Image:  raw[fujiStreamFrameHeaderSize:],
+	}
+	if i := bytes.LastIndex(f.Image, []byte{0xff, 0xd9}); i != -1 && i+2 < len(f.Image) {
+		f.Image, f.Trailer = f.Image[:i+2], f.Image[i+2:]
+	}
+
+	return f, nil
+}
+
+// FujiProcessStreamData reads the live view frames from the streamer connection and sends their image data to the
+// streamer channel, or the image data along with the frame metadata to LiveView() when it is in use. The listener stops
+// when the streamer connection is closed using ToggleLiveView() or fails, in which case the streamer channel is closed.
 func FujiProcessStreamData(c *Client) error {
+	lmp := "[fujiStreamListener]"
+	// The listener is bound to the connection it was started for: closing the streamer connection resets the fields.
+	conn := c.conn(streamConnection)
+	ch := c.StreamChan
+	frames := c.streamFrames
+	done := c.closeStreamChan
 	go func() {
-		c.Info("[fujiStreamListener] subscribing stream listener to streamer connection...")
+		c.Infof("%s subscribing stream listener to streamer connection...", lmp)
+		defer close(ch)
+		if frames != nil {
+			defer close(frames)
+		}
 		for {
 			select {
-			case <-c.closeStreamChan:
-				c.Info("[fujiStreamListener] stopping stream listener.")
-				close(c.StreamChan)
-				c.StreamChan = nil
+			case <-done:
+				c.Infof("%s stopping stream listener.", lmp)
 				return
 			default:
-				if data, err := c.ReadRawFromStreamConn(); err == nil {
-					// As always, packet length first.
-					l := binary.LittleEndian.Uint32(data[:4])
-					c.Debugf("[fujiStreamListener] Packet length %d", l)
-
-					// Four bytes always zero followed by what is clearly a counter which resets on 0xff, so one byte
-					// only.
-					count := binary.LittleEndian.Uint16(append(data[8:9], 0))
-					c.Debugf("[fujiStreamListener] Image number %d", count)
-
-					// Unknown what the next 9 bytes are, but they always END in two bytes with unknown significance
-					// (seen 0xff, 0xff as well as 0x5e, 0x49 and 0x4b, 0xbf) after which the image data begins, filling
-					// the rest of the packet.
-					c.StreamChan <- data[18:]
+			}
+
+			raw, err := c.readRawFromStreamConn(conn)
+			received := time.Now()
+			if err != nil {
+				if strings.Contains(err.Error(), "i/o timeout") {
+					continue
+				}
+				select {
+				case <-done:
+					c.Infof("%s stopping stream listener.", lmp)
+				default:
+					c.Errorf("%s stream listener stopped: %s", lmp, err)
+				}
+				return
+			}
+
+			f, err := ParseFujiStreamFrame(raw)
+			if err != nil {
+				c.Warnf("%s dropping frame: %s", lmp, err)
+				c.lvStats.dropped()
+				continue
+			}
+			c.Debugf("%s received frame %d of %d bytes", lmp, f.Number, len(raw))
+			c.lvStats.received(f.Number)
+
+			if frames != nil {
+				select {
+				case frames <- &streamFrame{data: f.Image, metadata: f.Metadata(), received: received}:
+				case <-done:
+					c.Infof("%s stopping stream listener.", lmp)
+					return
 				}
+				continue
+			}
+
+			select {
+			case ch <- f.Image:
+				c.lvStats.delivered(received)
+			case <-done:
+				c.Infof("%s stopping stream listener.", lmp)
+				return
 			}
 		}
 	}()
@@ -543,19 +728,23 @@ func FujiProcessStreamData(c *Client) error {
 }
 
 // FujiSetDeviceProperty sets a device property to the given value.
-func FujiSetDeviceProperty(c *Client, code ptp.DevicePropCode, val uint32) error {
-	tid := c.incrementTransactionId()
+func FujiSetDeviceProperty(ctx context.Context, c *Client, code ptp.DevicePropCode, val uint32) error {
+	return c.retry(ctx, func(_ int) error {
+		return fujiSetDevicePropertyOnce(ctx, c, code, val)
+	})
+}
 
-	resCh := make(chan []byte, 2)
-	if err := c.subscribe(tid, resCh); err != nil {
+func fujiSetDevicePropertyOnce(ctx context.Context, c *Client, code ptp.DevicePropCode, val uint32) error {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
 		return err
 	}
-	defer c.unsubscribe(tid)
+	defer t.end()
 
 	if err := c.SendPacketToCmdDataConn(&FujiOperationRequestPacket{
 		DataPhaseInfo: uint16(DP_NoDataOrDataIn),
 		OperationCode: ptp.OC_SetDevicePropValue,
-		TransactionID: tid,
+		TransactionID: t.id,
 		Parameter1:    uint32(code),
 	}); err != nil {
 		return err
@@ -564,36 +753,109 @@ func FujiSetDeviceProperty(c *Client, code ptp.DevicePropCode, val uint32) error
 	if err := c.SendPacketToCmdDataConn(&FujiOperationRequestPacket{
 		DataPhaseInfo: uint16(DP_DataOut),
 		OperationCode: ptp.OC_SetDevicePropValue,
-		TransactionID: tid,
+		TransactionID: t.id,
 		Parameter1:    val,
 	}); err != nil {
 		return err
 	}
 
-	p := new(FujiOperationResponsePacket)
-	if _, _, err := c.WaitForPacketFromCommandDataSubscriber(resCh, p); err != nil {
+	res, err := t.readResult(ctx, ptp.OC_SetDevicePropValue)
+	if err != nil {
 		return err
 	}
 
-	if !p.WasSuccessful(0) {
-		return p.ReasonAsError()
+	return fujiResultAsError(res)
+}
+
+// FujiResetDeviceProperty restores the factory default value for the given device property.
+func FujiResetDeviceProperty(ctx context.Context, c *Client, code ptp.DevicePropCode) error {
+	_, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_ResetDevicePropValue, []uint32{uint32(code)})
+
+	return err
+}
+
+// FujiGetDevicePropertyValue gets the raw value for the given device property.
+func FujiGetDevicePropertyValue(ctx context.Context, c *Client, dpc ptp.DevicePropCode) ([]byte, error) {
+	_, xs, err := FujiSendOperationRequestAndGetResponse(ctx, c, ptp.OC_GetDevicePropValue, uint32(dpc), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if xs == nil {
+		return nil, errors.New("expected additional value but none was returned")
 	}
 
-	return nil
+	return xs, nil
 }
 
-// FujiGetDevicePropertyValue gets the value for the given device property.
-// TODO: add third parameter to indicate how many parameters from the response object are expected?
-func FujiGetDevicePropertyValue(c *Client, dpc ptp.DevicePropCode) (uint32, error) {
-	var val uint32
-	var err error
+// FujiSetISO sets DPC_Fuji_ExposureIndex to the given ISO value. The plain ISO setting is preferred over an extended one
+// when the Responder supports both.
+func FujiSetISO(ctx context.Context, c *Client, iso uint32) error {
+	dpd, err := c.GetDevicePropertyDescriptionContext(ctx, DPC_Fuji_ExposureIndex)
+	if err != nil {
+		return err
+	}
 
-	// First we get the actual value from the Responder.
-	if val, _, err = FujiSendOperationRequestAndGetResponse(c, ptp.OC_GetDevicePropValue, uint32(dpc), 4); err != nil {
+	v := uint32(EDX_Fuji_Auto)
+	if iso != 0 {
+		v = iso
+		if form, ok := dpd.Form.(*ptp.EnumerationForm); ok {
+			for _, sv := range form.SupportedValues {
+				edx := binary.LittleEndian.Uint32(sv)
+				if edx&0x0000FFFF != iso || uint16(edx>>16) == EDX_Fuji_MaxSensitivity {
+					continue
+				}
+				v = edx
+				if edx>>16 == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	return c.setValidatedDevicePropertyWithDesc(ctx, dpd, int64(v))
+}
+
+// FujiGetISO returns the ISO value using DPC_Fuji_ExposureIndex. The extended and maximum sensitivity flags are
+// dropped.
+func FujiGetISO(ctx context.Context, c *Client) (uint32, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, DPC_Fuji_ExposureIndex)
+	if err != nil || FujiExposureIndex(v) == EDX_Fuji_Auto {
+		return 0, err
+	}
+
+	return v & 0x0000FFFF, nil
+}
+
+// FujiGetCaptureDelay returns the self-timer delay: Fuji devices use ptp.DPC_CaptureDelay to select one of the
+// FujiSelfTimer values instead of holding the delay in milliseconds.
+func FujiGetCaptureDelay(ctx context.Context, c *Client) (time.Duration, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_CaptureDelay)
+	if err != nil {
 		return 0, err
 	}
 
-	return val, nil
+	switch FujiSelfTimer(v) {
+	case ST_Fuji_1Sec:
+		return time.Second, nil
+	case ST_Fuji_2Sec:
+		return 2 * time.Second, nil
+	case ST_Fuji_5Sec:
+		return 5 * time.Second, nil
+	case ST_Fuji_10Sec:
+		return 10 * time.Second, nil
+	}
+
+	return 0, nil
+}
+
+// FujiProbe checks if the Responder is still active. Fuji does not support the ProbeRequestPacket: since its events do
+// not carry a packet type, there is no way to tell a probe apart from an event. Requesting the application version is
+// used instead as it is the smallest property the camera returns.
+func FujiProbe(ctx context.Context, c *Client) error {
+	_, err := FujiGetDevicePropertyValue(ctx, c, DPC_Fuji_AppVersion)
+
+	return err
 }
 
 // FujiSendOperationRequest sends an operation request to the camera and returns a channel that will receive the
@@ -610,6 +872,8 @@ func FujiSendOperationRequest(c *Client, code ptp.OperationCode, param uint32) (
 // FujiSendOperationRequestWithChan sends an operation request to the camera and returns the current transaction ID
 // the given response channel has been subscribed to.
 // If a parameter is not required, simply pass in PM_Fuji_NoParam!
+// The request is sent right away, without waiting for the transaction in progress to end, and the caller is
+// responsible for unsubscribing the channel. Use FujiSendOperationRequestAndGetResponse() instead when possible.
 func FujiSendOperationRequestWithChan(c *Client, code ptp.OperationCode, param uint32, resCh chan []byte) (ptp.TransactionID, error) {
 	tid := c.incrementTransactionId()
 
@@ -617,20 +881,40 @@ func FujiSendOperationRequestWithChan(c *Client, code ptp.OperationCode, param u
 		return 0, err
 	}
 
-	return tid, c.SendPacketToCmdDataConn(&FujiOperationRequestPacket{
+	return tid, fujiSendOperationRequestWithParams(c, tid, code, []uint32{param})
+}
+
+// fujiSendOperationRequestWithParams sends an operation request with up to five parameters for the given transaction.
+func fujiSendOperationRequestWithParams(c *Client, tid ptp.TransactionID, code ptp.OperationCode, params []uint32) error {
+	p := &FujiOperationRequestPacket{
 		DataPhaseInfo: uint16(DP_NoDataOrDataIn),
 		OperationCode: code,
 		TransactionID: tid,
-		Parameter1:    param,
-	})
+	}
+	for i, param := range params {
+		switch i {
+		case 0:
+			p.Parameter1 = param
+		case 1:
+			p.Parameter2 = param
+		case 2:
+			p.Parameter3 = param
+		case 3:
+			p.Parameter4 = param
+		case 4:
+			p.Parameter5 = param
+		}
+	}
+
+	return c.SendPacketToCmdDataConn(p)
 }
 
 // FujiSendOperationRequestIgnoreResponse sends an operation request to the camera. If a parameter is not required,
 // simply pass in PM_Fuji_NoParam!
 // Use this wrapper function if you do not care about the actual response value but just want to know if it was
 // successful.
-func FujiSendOperationRequestIgnoreResponse(c *Client, code ptp.OperationCode, param uint32, pSize int) error {
-	_, _, err := FujiSendOperationRequestAndGetResponse(c, code, param, pSize)
+func FujiSendOperationRequestIgnoreResponse(ctx context.Context, c *Client, code ptp.OperationCode, param uint32, pSize int) error {
+	_, _, err := FujiSendOperationRequestAndGetResponse(ctx, c, code, param, pSize)
 
 	return err
 }
@@ -641,31 +925,23 @@ func FujiSendOperationRequestIgnoreResponse(c *Client, code ptp.OperationCode, p
 // by passing the size in bytes of the expected data. Pass 0 when not expecting anything.
 // The byte array being returned may contain excess dat that could not be unmarshalled. This will often be the case so
 // check this data to see if it is not nil and handle it accordingly.
-func FujiSendOperationRequestAndGetResponse(c *Client, code ptp.OperationCode, param uint32, pSize int) (uint32, []byte, error) {
-	resCh, err := FujiSendOperationRequest(c, code, param)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	p := new(FujiOperationResponsePacket)
-	_, xs, err := c.WaitForPacketFromCommandDataSubscriber(resCh, p)
+func FujiSendOperationRequestAndGetResponse(ctx context.Context, c *Client, code ptp.OperationCode, param uint32, pSize int) (uint32, []byte, error) {
+	var res *operationResult
+	err := c.retry(ctx, func(_ int) error {
+		var err error
+		res, err = fujiTransaction(ctx, c, code, []uint32{param})
+		return err
+	})
 	if err != nil {
 		return 0, nil, err
 	}
 
-	// Make sure we also grab the end of data packet should it be there...
-	if p.DataPhase == uint16(DP_DataOut) {
-		eodp := new(FujiOperationResponsePacket)
-		if _, _, err := c.WaitForPacketFromCommandDataSubscriber(resCh, eodp); err != nil {
-			return 0, nil, err
-		}
-
-		if eodp != nil && !eodp.WasSuccessful(0) {
-			return 0, nil, eodp.ReasonAsError()
-		}
+	// Without a data phase, the additional value is part of the operation response.
+	xs := res.data
+	if xs == nil {
+		xs = res.params
 	}
-
-	if xs == nil && pSize > 0 {
+	if len(xs) == 0 && pSize > 0 {
 		return 0, nil, errors.New("expected additional value but none was returned")
 	}
 
@@ -694,47 +970,89 @@ func FujiSendOperationRequestAndGetResponse(c *Client, code ptp.OperationCode, p
 		xs = xs[pSize:]
 	}
 
-	if !p.WasSuccessful(operationCodeToOKResponseCode(code)) {
-		return 0, nil, p.ReasonAsError()
+	return parameter, xs, nil
+}
+
+// FujiSendOperationRequestAndGetRawResponse sends an operation request with up to five parameters and returns the raw
+// camera response data.
+func FujiSendOperationRequestAndGetRawResponse(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) ([][]byte, error) {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer t.end()
+
+	if err := fujiSendOperationRequestWithParams(c, t.id, code, params); err != nil {
+		return nil, err
 	}
 
-	return parameter, xs, nil
+	// TODO: check if there is data on the event connection and read that as well!
+	res, err := t.readResult(ctx, code)
+
+	return res.raw, err
 }
 
-// FujiSendOperationRequestAndGetRawResponse wraps FujiSendOperationRequest and returns the raw camera response data.
-func FujiSendOperationRequestAndGetRawResponse(c *Client, code ptp.OperationCode, params []uint32) ([][]byte, error) {
-	var err error
+// FujiOperationRequestStream sends an operation request with up to five parameters and returns an io.ReadCloser over
+// the data sent by the camera during the data phase.
+func FujiOperationRequestStream(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) (io.ReadCloser, error) {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	field := uint32(PM_Fuji_NoParam)
-	if len(params) != 0 {
-		field = params[0]
+	if err := fujiSendOperationRequestWithParams(c, t.id, code, params); err != nil {
+		t.end()
+		return nil, err
 	}
 
-	resCh := make(chan []byte, 2)
-	tid, err := FujiSendOperationRequestWithChan(c, code, field, resCh)
+	return newDataPhaseReader(ctx, t, code, fujiResultAsError), nil
+}
+
+// fujiSendOperationRequestAndGetData sends an operation request with up to five parameters and returns the data
+// received during the data phase stripped from all packet headers.
+// The transaction is retried according to the RetryPolicy of the client.
+func fujiSendOperationRequestAndGetData(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) ([]byte, error) {
+	var res *operationResult
+	err := c.retry(ctx, func(_ int) error {
+		var err error
+		res, err = fujiTransaction(ctx, c, code, params)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer c.unsubscribe(tid)
 
-	var raw [][]byte
-	for {
-		r, err := c.WaitForRawPacketFromCommandDataSubscriber(resCh)
-		if err == nil {
-			raw = append(raw, r)
-			// Keep reading as long as the Responder tells us there is more data.
-			if len(r) > 7 && binary.LittleEndian.Uint16(r[4:6]) == uint16(DP_DataOut) {
-				continue
-			}
-		}
-		break
+	return res.data, nil
+}
+
+// fujiTransaction runs a complete transaction for an operation request with up to five parameters. An error is returned
+// when the response code does not indicate success.
+func fujiTransaction(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) (*operationResult, error) {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer t.end()
 
-	// TODO: find out why no error is returned when receiving a timeout!
+	if err := fujiSendOperationRequestWithParams(c, t.id, code, params); err != nil {
+		return nil, err
+	}
 
-	// TODO: check if there is data on the event connection and read that as well!
+	res, err := t.readResult(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, fujiResultAsError(res)
+}
+
+// fujiResultAsError returns an error when the response code of the operation does not indicate success.
+func fujiResultAsError(res *operationResult) error {
+	if res.code == ptp.RC_OK || res.code == operationCodeToOKResponseCode(res.operation) {
+		return nil
+	}
 
-	return raw, err
+	return ptp.NewResponseError(res.code, res.operation, res.tid)
 }
 
 // FujiGetDevicePropDesc retrieves the description for the given device property code. Beware that this method can
@@ -742,16 +1060,16 @@ func FujiSendOperationRequestAndGetRawResponse(c *Client, code ptp.OperationCode
 // property cannot be described: the camera gave a response but returned no property data.
 // With the Fuji implementation one cannot be sure if the property does not exist or cannot be described as there is no
 // clear error being returned.
-func FujiGetDevicePropertyDesc(c *Client, code ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
+func FujiGetDevicePropertyDesc(ctx context.Context, c *Client, code ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
 	c.Infof("Requesting %s device property description for %#x...", c.ResponderFriendlyName(), code)
-	_, xs, err := FujiSendOperationRequestAndGetResponse(c, ptp.OC_GetDevicePropDesc, uint32(code), 0)
+	_, xs, err := FujiSendOperationRequestAndGetResponse(ctx, c, ptp.OC_GetDevicePropDesc, uint32(code), 0)
 	if err != nil {
 		return nil, err
 	}
 
 	r := bytes.NewReader(xs)
 
-	dpd, err := fujiReadDevicePropDesc(c, r)
+	dpd, err := ptp.ReadDevicePropDesc(r)
 	// When requesting the description of a non-existing device property, the camera does not return an error code, it
 	// just does not return any data. Another annoying complexity we need to handle here...
 	if err != nil && err != io.EOF {
@@ -761,12 +1079,43 @@ func FujiGetDevicePropertyDesc(c *Client, code ptp.DevicePropCode) (*ptp.DeviceP
 	return dpd, nil
 }
 
-// FujiGetDeviceInfo retrieves the current settings of a Fuji device. It is not at all a GetDeviceInfo call as specified
-// in the PTP/IP specification, but it is more of a GetDevicePropDescList call that simply does not exist in the PTP/IP
-// specification.
-func FujiGetDeviceInfo(c *Client) (interface{}, error) {
+// FujiDeviceInfo holds the device information of a Fuji device. The standard DeviceInfo dataset does not tell us much
+// about a Fuji device, the interesting part is the list of property descriptions returned by OC_Fuji_GetDeviceInfo.
+type FujiDeviceInfo struct {
+	// DeviceInfo holds the standard DeviceInfo dataset. It is nil when the device refused to return it.
+	DeviceInfo *ptp.DeviceInfo
+	// Properties holds the descriptions of all properties the device exposes including their current values.
+	Properties []*ptp.DevicePropDesc
+}
+
+// FujiGetDeviceInfo retrieves the device information of a Fuji device and returns it as a *FujiDeviceInfo. The standard
+// DeviceInfo dataset is requested first, followed by OC_Fuji_GetDeviceInfo which is not at all a GetDeviceInfo call as
+// specified in the PTP/IP specification, but it is more of a GetDevicePropDescList call that simply does not exist in
+// the PTP/IP specification.
+func FujiGetDeviceInfo(ctx context.Context, c *Client) (interface{}, error) {
 	c.Infof("Requesting %s device info...", c.ResponderFriendlyName())
-	numProps, xs, err := FujiSendOperationRequestAndGetResponse(c, OC_Fuji_GetDeviceInfo, PM_Fuji_NoParam, 4)
+	fdi := new(FujiDeviceInfo)
+
+	// Not getting the standard dataset is not fatal: the property list is what matters most for a Fuji device.
+	data, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetDeviceInfo, nil)
+	if err == nil {
+		fdi.DeviceInfo, err = ptp.ReadDeviceInfo(bytes.NewReader(data))
+	}
+	if err != nil {
+		c.Warnf("Unable to get standard device info: %s", err)
+	}
+
+	fdi.Properties, err = fujiGetDevicePropDescList(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return fdi, nil
+}
+
+// fujiGetDevicePropDescList requests the descriptions of all properties using OC_Fuji_GetDeviceInfo.
+func fujiGetDevicePropDescList(ctx context.Context, c *Client) ([]*ptp.DevicePropDesc, error) {
+	numProps, xs, err := FujiSendOperationRequestAndGetResponse(ctx, c, OC_Fuji_GetDeviceInfo, PM_Fuji_NoParam, 4)
 	if err != nil {
 		return nil, err
 	}
@@ -784,7 +1133,7 @@ func FujiGetDeviceInfo(c *Client) (interface{}, error) {
 
 		c.Debugf("Property length: %d", l)
 
-		dpd, err := fujiReadDevicePropDesc(c, r)
+		dpd, err := ptp.ReadDevicePropDesc(r)
 		if err != nil {
 			return nil, err
 		}
@@ -795,95 +1144,12 @@ func FujiGetDeviceInfo(c *Client) (interface{}, error) {
 	return list, nil
 }
 
-func fujiReadDevicePropDesc(c *Client, r io.Reader) (*ptp.DevicePropDesc, error) {
-	dpd := new(ptp.DevicePropDesc)
-	if err := binary.Read(r, binary.LittleEndian, &dpd.DevicePropertyCode); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(r, binary.LittleEndian, &dpd.DataType); err != nil {
-		return nil, err
-	}
-	if err := binary.Read(r, binary.LittleEndian, &dpd.GetSet); err != nil {
-		return nil, err
-	}
-
-	c.Debugf("Size of property values in bytes: %d", dpd.SizeOfValueInBytes())
-
-	// We now know the DataTypeCode so we know what to expect next.
-	dpd.FactoryDefaultValue = make([]byte, dpd.SizeOfValueInBytes())
-	if err := binary.Read(r, binary.LittleEndian, dpd.FactoryDefaultValue); err != nil {
-		return nil, err
-	}
-
-	dpd.CurrentValue = make([]byte, dpd.SizeOfValueInBytes())
-	if err := binary.Read(r, binary.LittleEndian, dpd.CurrentValue); err != nil {
-		return nil, err
-	}
-
-	// Read the type of form that will follow.
-	if err := binary.Read(r, binary.LittleEndian, &dpd.FormFlag); err != nil {
-		return nil, err
-	}
-
-	switch dpd.FormFlag {
-	case ptp.DPF_FormFlag_Range:
-		form := new(ptp.RangeForm)
-		c.Debug("Property is a range type, filling range form...")
-
-		form.SetDevicePropDesc(dpd)
-
-		// Minimum possible value.
-		form.MinimumValue = make([]byte, dpd.SizeOfValueInBytes())
-		if err := binary.Read(r, binary.LittleEndian, form.MinimumValue); err != nil {
-			return nil, err
-		}
-
-		// Maximum possible value.
-		form.MaximumValue = make([]byte, dpd.SizeOfValueInBytes())
-		if err := binary.Read(r, binary.LittleEndian, form.MaximumValue); err != nil {
-			return nil, err
-		}
-
-		// Stepper value.
-		form.StepSize = make([]byte, dpd.SizeOfValueInBytes())
-		if err := binary.Read(r, binary.LittleEndian, form.StepSize); err != nil {
-			return nil, err
-		}
-
-		dpd.Form = form
-	case ptp.DPF_FormFlag_Enum:
-		form := new(ptp.EnumerationForm)
-		c.Debug("Property is an enum type, filling enum form...")
-
-		form.SetDevicePropDesc(dpd)
-
-		// First read the number of values that will follow.
-		var num uint16
-		if err := binary.Read(r, binary.LittleEndian, &num); err != nil {
-			return nil, err
-		}
-		form.NumberOfValues = int(num)
-
-		// Now fill the enumeration form with the actual values.
-		for i := 0; i < form.NumberOfValues; i++ {
-			v := make([]byte, dpd.SizeOfValueInBytes())
-			if err := binary.Read(r, binary.LittleEndian, v); err != nil {
-				return nil, err
-			}
-			form.SupportedValues = append(form.SupportedValues, v)
-		}
-		dpd.Form = form
-	}
-
-	return dpd, nil
-}
-
 // FujiGetDeviceState returns a list of properties with their current values. The values being returned will depend on
 // the exposure program mode of the camera: it will change if the camera is in aperture priority, shutter priority,
 // manual or auto.
-func FujiGetDeviceState(c *Client) (interface{}, error) {
+func FujiGetDeviceState(ctx context.Context, c *Client) (interface{}, error) {
 	c.Infof("Requesting %s device state...", c.ResponderFriendlyName())
-	numProps, xs, err := FujiSendOperationRequestAndGetResponse(c, ptp.OC_GetDevicePropValue, uint32(DPC_Fuji_CurrentState), 2)
+	numProps, xs, err := FujiSendOperationRequestAndGetResponse(ctx, c, ptp.OC_GetDevicePropValue, uint32(DPC_Fuji_CurrentState), 2)
 	if err != nil {
 		return nil, err
 	}
@@ -919,9 +1185,9 @@ func FujiGetDeviceState(c *Client) (interface{}, error) {
 // from the camera in order for the ptp.EC_CaptureComplete to be sent out.
 // Failing to do this, will not allow the client to release the shutter again. The operation request will be accepted
 // but no further actions will be taken by the camera.
-func FujiInitiateCapture(c *Client) ([]byte, error) {
+func FujiInitiateCapture(ctx context.Context, c *Client) ([]byte, error) {
 	c.Infof("Releasing %s shutter...", c.ResponderFriendlyName())
-	if err := FujiSendOperationRequestIgnoreResponse(c, ptp.OC_InitiateCapture, PM_Fuji_NoParam, 0); err != nil {
+	if err := FujiSendOperationRequestIgnoreResponse(ctx, c, ptp.OC_InitiateCapture, PM_Fuji_NoParam, 0); err != nil {
 		return nil, err
 	}
 
@@ -944,12 +1210,14 @@ func FujiInitiateCapture(c *Client) ([]byte, error) {
 				extra = fmt.Sprintf(": preview size is %d bytes", pvSize)
 			}
 			c.Debugf("Received %s event (%#x)%s.", txt, msg.GetEventCode(), extra)
-		case <-time.After(DefaultReadTimeout):
+		case <-after(c.readTimeout(ctx)):
 			return nil, WaitForEventError
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
 
-	raw, err := FujiSendOperationRequestAndGetRawResponse(c, OC_Fuji_GetCapturePreview, nil)
+	img, err := fujiSendOperationRequestAndGetData(ctx, c, OC_Fuji_GetCapturePreview, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -960,20 +1228,10 @@ func FujiInitiateCapture(c *Client) ([]byte, error) {
 			return nil, fmt.Errorf("invalid event received, expected '%#x' got '%#x'", ptp.EC_CaptureComplete, msg.GetEventCode())
 		}
 		c.Debugf("Received capture complete event (%#x).", msg.GetEventCode())
-	case <-time.After(DefaultReadTimeout):
+	case <-after(c.readTimeout(ctx)):
 		return nil, WaitForEventError
-	}
-
-	var img []byte
-	for _, pkt := range raw {
-		code := binary.LittleEndian.Uint16(pkt[6:8])
-		switch {
-		case code == uint16(ptp.RC_OK):
-			break
-		case code != uint16(OC_Fuji_GetCapturePreview):
-			return nil, errors.New("failed reading image data")
-		}
-		img = append(img, pkt[12:]...)
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
 	if len(img) != pvSize {
@@ -982,3 +1240,55 @@ func FujiInitiateCapture(c *Client) ([]byte, error) {
 
 	return img, nil
 }
+
+// FujiGetStorageIDs requests the list of StorageIDs from the Fuji device.
+func FujiGetStorageIDs(ctx context.Context, c *Client) ([]ptp.StorageID, error) {
+	data, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetStorageIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadStorageIDs(bytes.NewReader(data))
+}
+
+// FujiGetStorageInfo requests the StorageInfo dataset for the given StorageID from the Fuji device.
+func FujiGetStorageInfo(ctx context.Context, c *Client, sid ptp.StorageID) (*ptp.StorageInfo, error) {
+	data, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetStorageInfo, []uint32{uint32(sid)})
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadStorageInfo(bytes.NewReader(data))
+}
+
+// FujiGetObjectHandles requests the list of ObjectHandles from the Fuji device.
+func FujiGetObjectHandles(ctx context.Context, c *Client, sid ptp.StorageID, ofc ptp.ObjectFormatCode, parent ptp.ObjectHandle) ([]ptp.ObjectHandle, error) {
+	data, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetObjectHandles, []uint32{uint32(sid), uint32(ofc), uint32(parent)})
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadObjectHandles(bytes.NewReader(data))
+}
+
+// FujiGetObjectInfo requests the ObjectInfo dataset for the given ObjectHandle from the Fuji device.
+func FujiGetObjectInfo(ctx context.Context, c *Client, h ptp.ObjectHandle) (*ptp.ObjectInfo, error) {
+	data, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetObjectInfo, []uint32{uint32(h)})
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadObjectInfo(bytes.NewReader(data))
+}
+
+// FujiGetObject retrieves the object with the given ObjectHandle from the Fuji device.
+func FujiGetObject(ctx context.Context, c *Client, h ptp.ObjectHandle) ([]byte, error) {
+	return fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_GetObject, []uint32{uint32(h)})
+}
+
+// FujiDeleteObject deletes the object with the given ObjectHandle from the Fuji device.
+func FujiDeleteObject(ctx context.Context, c *Client, h ptp.ObjectHandle) error {
+	_, err := fujiSendOperationRequestAndGetData(ctx, c, ptp.OC_DeleteObject, []uint32{uint32(h)})
+
+	return err
+}
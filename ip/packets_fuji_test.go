@@ -2,12 +2,17 @@ package ip
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
 	"io/ioutil"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewFujiInitCommandRequestPacket(t *testing.T) {
@@ -24,7 +29,7 @@ func TestNewFujiInitCommandRequestPacket(t *testing.T) {
 }
 
 func TestNewFujiInitCommandRequestPacketForClient(t *testing.T) {
-	c, err := NewClient("fuji", DefaultIpAddress, DefaultPort, "test", "", logLevel)
+	c, err := NewClient(DefaultIpAddress, WithVendor("fuji"), WithFriendlyName("test"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("NewClient() err = %s; want <nil>", err)
 	}
@@ -70,13 +75,110 @@ func TestFujiOperationRequestPacket_Payload(t *testing.T) {
 	}
 }
 
+func TestFujiEventPacket_Typed(t *testing.T) {
+	check := []struct {
+		raw  []byte
+		want ptp.TypedEvent
+	}{
+		{
+			[]byte{
+				0x1c, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x04, 0xc0,
+				0x01, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			FujiObjectAddedEvent{TransactionID: 6},
+		},
+		{
+			[]byte{
+				0x1c, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x01, 0xc0,
+				0x01, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x29, 0xf1, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			FujiPreviewAvailableEvent{TransactionID: 6, Size: 0xf129},
+		},
+		{
+			[]byte{
+				0x1c, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x06, 0x40,
+				0x01, 0x00, 0x00, 0x00,
+				0xff, 0xff, 0xff, 0xff,
+				0x01, 0xd0, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			ptp.DevicePropChangedEvent{Code: DPC_Fuji_FilmSimulation},
+		},
+		{
+			[]byte{
+				0x1c, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x0d, 0x40,
+				0x01, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			ptp.CaptureCompleteEvent{TransactionID: 6},
+		},
+	}
+
+	for _, tt := range check {
+		p, _, err := parsePacket(tt.raw, NewFujiEventPacket())
+		if err != nil {
+			t.Fatalf("parsePacket() err = %s; want <nil>", err)
+		}
+		got := p.(EventPacket).Typed()
+		if got != tt.want {
+			t.Errorf("Typed() = %#v; want %#v", got, tt.want)
+		}
+		if got.EventCode() != p.(EventPacket).GetEventCode() {
+			t.Errorf("EventCode() = %#x; want %#x", got.EventCode(), p.(EventPacket).GetEventCode())
+		}
+	}
+}
+
+func TestFujiExtractTransactionId(t *testing.T) {
+	cases := []struct {
+		p    []byte
+		ct   connectionType
+		want ptp.TransactionID
+		err  error
+	}{
+		{[]byte{0x0c, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00, 0x00}, cmdDataConnection, 42, nil},
+		{[]byte{0x10, 0x00, 0x00, 0x00, 0x04, 0x00, 0x06, 0x40, 0x00, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x00, 0x00}, eventConnection, 43, nil},
+		{[]byte{0x0b, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00}, cmdDataConnection, 0, TruncatedPacketError},
+		{[]byte{0x08, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x20}, cmdDataConnection, 0, TruncatedPacketError},
+		{[]byte{0x0c, 0x00, 0x00, 0x00, 0x04, 0x00, 0x06, 0x40, 0x00, 0x00, 0x00, 0x00}, eventConnection, 0, TruncatedPacketError},
+		{[]byte{0x04, 0x00, 0x00, 0x00}, eventConnection, 0, TruncatedPacketError},
+		{[]byte{0x0c, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x20, 0x2a, 0x00, 0x00, 0x00}, streamConnection, 0, UnexpectedPacketError},
+	}
+
+	for _, tc := range cases {
+		got, err := FujiExtractTransactionId(tc.p, tc.ct)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("FujiExtractTransactionId(%#x, %s) err = %v; want %v", tc.p, tc.ct, err, tc.err)
+		}
+		if got != tc.want {
+			t.Errorf("FujiExtractTransactionId(%#x, %s) = %d; want %d", tc.p, tc.ct, got, tc.want)
+		}
+	}
+}
+
 func TestFujiInitCommandDataConn(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = c.initCommandDataConn()
+	err = c.initCommandDataConn(context.Background())
 	if err != nil {
 		t.Errorf("FujiInitCommandDataConn() error = %s; want <nil>", err)
 	}
@@ -89,7 +191,7 @@ func TestFujiInitCommandDataConn(t *testing.T) {
 }
 
 func TestFujiSetDeviceProperty(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -100,7 +202,7 @@ func TestFujiSetDeviceProperty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = FujiSetDeviceProperty(c, DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
+	err = FujiSetDeviceProperty(context.Background(), c, DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
 	if err != nil {
 		t.Errorf("FujiSetDeviceProperty() error = %s; want <nil>", err)
 	}
@@ -113,20 +215,38 @@ func TestFujiSetDeviceProperty(t *testing.T) {
 }
 
 func TestFujiSetDevicePropertyFail(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = FujiSetDeviceProperty(c, DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
+	err = FujiSetDeviceProperty(context.Background(), c, DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
 	want := "not connected"
 	if err.Error() != want {
 		t.Errorf("FujiSetDeviceProperty() error = %s; want %s", err, want)
 	}
 }
 
+func TestFujiResetDeviceProperty(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = FujiResetDeviceProperty(context.Background(), c, DPC_Fuji_FilmSimulation)
+	if err != nil {
+		t.Errorf("FujiResetDeviceProperty() error = %s; want <nil>", err)
+	}
+}
+
 func TestFujiGetDevicePropertyValue(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -137,19 +257,19 @@ func TestFujiGetDevicePropertyValue(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := FujiGetDevicePropertyValue(c, DPC_Fuji_AppVersion)
+	got, err := FujiGetDevicePropertyValue(context.Background(), c, DPC_Fuji_AppVersion)
 	if err != nil {
 		t.Errorf("FujiGetDevicePropertyValue() error = %s; want <nil>", err)
 	}
 
-	want := uint32(PM_Fuji_AppVersion)
-	if got != want {
+	want := []byte{0x01, 0x00, 0x02, 0x00}
+	if !bytes.Equal(got, want) {
 		t.Errorf("FujiGetDevicePropertyValue() got = %#x; want %#x", got, want)
 	}
 }
 
 func TestFujiSendOperationRequest(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -160,8 +280,8 @@ func TestFujiSendOperationRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// We use close session here because our fuji mock will not respond to it.
-	resCh, err := FujiSendOperationRequest(c, ptp.OC_CloseSession, PM_Fuji_NoParam)
+	// We use get num objects here because our fuji mock will not respond to it.
+	resCh, err := FujiSendOperationRequest(c, ptp.OC_GetNumObjects, PM_Fuji_NoParam)
 	defer close(resCh)
 	if err != nil {
 		t.Errorf("FujiSendOperationRequest() error = %s; want <nil>", err)
@@ -169,7 +289,7 @@ func TestFujiSendOperationRequest(t *testing.T) {
 }
 
 func TestFujiSendOperationRequestAndGetResponse(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -180,7 +300,7 @@ func TestFujiSendOperationRequestAndGetResponse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	gotPar, xs, err := FujiSendOperationRequestAndGetResponse(c, ptp.OC_GetDevicePropValue, uint32(DPC_Fuji_AppVersion), 4)
+	gotPar, xs, err := FujiSendOperationRequestAndGetResponse(context.Background(), c, ptp.OC_GetDevicePropValue, uint32(DPC_Fuji_AppVersion), 4)
 	if len(xs) > 0 {
 		t.Errorf("FujiSendOperationRequestAndGetResponse() excess bytes = %d; want <nil>", len(xs))
 	}
@@ -195,7 +315,7 @@ func TestFujiSendOperationRequestAndGetResponse(t *testing.T) {
 }
 
 func TestFujiSendOperationRequestAndGetRawResponse(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -206,7 +326,7 @@ func TestFujiSendOperationRequestAndGetRawResponse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := FujiSendOperationRequestAndGetRawResponse(c, ptp.OC_GetDevicePropDesc, []uint32{uint32(DPC_Fuji_FilmSimulation)})
+	got, err := FujiSendOperationRequestAndGetRawResponse(context.Background(), c, ptp.OC_GetDevicePropDesc, []uint32{uint32(DPC_Fuji_FilmSimulation)})
 	if err != nil {
 		t.Errorf("FujiSendOperationRequestAndGetRawResponse() error = %s; want <nil>", err)
 	}
@@ -226,7 +346,7 @@ func TestFujiSendOperationRequestAndGetRawResponse(t *testing.T) {
 }
 
 func TestFujiGetDevicePropertyDesc(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -237,7 +357,7 @@ func TestFujiGetDevicePropertyDesc(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := FujiGetDevicePropertyDesc(c, ptp.DPC_WhiteBalance)
+	got, err := FujiGetDevicePropertyDesc(context.Background(), c, ptp.DPC_WhiteBalance)
 	if err != nil {
 		t.Errorf("FujiGetDevicePropertyDesc() error = %s; want <nil>", err)
 	}
@@ -263,7 +383,7 @@ func TestFujiGetDevicePropertyDesc(t *testing.T) {
 		t.Errorf("FujiGetDevicePropertyDesc() got = %#v; want %#v", got, want)
 	}
 
-	got, err = FujiGetDevicePropertyDesc(c, DPC_Fuji_FocusMeteringMode)
+	got, err = FujiGetDevicePropertyDesc(context.Background(), c, DPC_Fuji_FocusMeteringMode)
 	if err != nil {
 		t.Errorf("FujiGetDevicePropertyDesc() error = %s; want <nil>", err)
 	}
@@ -289,7 +409,7 @@ func TestFujiGetDevicePropertyDesc(t *testing.T) {
 }
 
 func TestFujiGetDeviceInfo(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -300,7 +420,7 @@ func TestFujiGetDeviceInfo(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := FujiGetDeviceInfo(c)
+	got, err := FujiGetDeviceInfo(context.Background(), c)
 	if err != nil {
 		t.Errorf("FujiGetDeviceInfo() error = %s; want <nil>", err)
 	}
@@ -433,16 +553,26 @@ func TestFujiGetDeviceInfo(t *testing.T) {
 		f.Form.SetDevicePropDesc(f)
 	}
 
-	for i, g := range got.([]*ptp.DevicePropDesc) {
+	fdi, ok := got.(*FujiDeviceInfo)
+	if !ok {
+		t.Fatalf("FujiGetDeviceInfo() got = %T; want *ip.FujiDeviceInfo", got)
+	}
+	if !reflect.DeepEqual(fdi.DeviceInfo, &mockFujiDeviceInfo) {
+		t.Errorf("FujiGetDeviceInfo() DeviceInfo = %#v; want %#v", fdi.DeviceInfo, &mockFujiDeviceInfo)
+	}
+	if len(fdi.Properties) != len(want) {
+		t.Errorf("FujiGetDeviceInfo() len(Properties) = %d; want %d", len(fdi.Properties), len(want))
+	}
+	for i, g := range fdi.Properties {
 		if !reflect.DeepEqual(g, want[i]) {
-			t.Errorf("FujiGetDeviceInfo() got = %#v; want %#v", got, want)
+			t.Errorf("FujiGetDeviceInfo() Properties = %#v; want %#v", fdi.Properties, want)
 			break
 		}
 	}
 }
 
 func TestFujiGetDeviceState(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	defer c.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -453,7 +583,7 @@ func TestFujiGetDeviceState(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, err := FujiGetDeviceState(c)
+	got, err := FujiGetDeviceState(context.Background(), c)
 	if err != nil {
 		t.Errorf("FujiGetDeviceState() error = %s; want <nil>", err)
 	}
@@ -487,7 +617,7 @@ func TestFujiGetDeviceState(t *testing.T) {
 }
 
 func TestFujiInitiateCapture(t *testing.T) {
-	c, err := NewClient("fuji", address, fujiCmdPort, "testèr", "67bace55-e7a4-4fbc-8e31-5122ee73a17c", logLevel)
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
 	c.SetEventPort(fujiEvtPort)
 
 	defer c.Close()
@@ -504,7 +634,7 @@ func TestFujiInitiateCapture(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	got, err := FujiInitiateCapture(c)
+	got, err := FujiInitiateCapture(context.Background(), c)
 	if err != nil {
 		t.Errorf("FujiInitiateCapture() error = %s; want <nil>", err)
 	}
@@ -513,3 +643,126 @@ func TestFujiInitiateCapture(t *testing.T) {
 		t.Errorf("FujiInitiateCapture() imgdata = %#v; want %#v", got, want)
 	}
 }
+
+func TestFujiProbe(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = FujiProbe(context.Background(), c)
+	if err != nil {
+		t.Errorf("FujiProbe() error = %s; want <nil>", err)
+	}
+}
+
+func TestParseFujiStreamFrame(t *testing.T) {
+	raw := []byte{0x16, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2a, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x5e, 0x49,
+		0xff, 0xd8, 0xff, 0xd9}
+	got, err := ParseFujiStreamFrame(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Number != 0x2a {
+		t.Errorf("ParseFujiStreamFrame() Number = %#x; want 0x2a", got.Number)
+	}
+	want := []byte{0xff, 0xd8, 0xff, 0xd9}
+	if !bytes.Equal(got.Image, want) {
+		t.Errorf("ParseFujiStreamFrame() Image = % x; want % x", got.Image, want)
+	}
+
+	if got.Trailer != nil {
+		t.Errorf("ParseFujiStreamFrame() Trailer = % x; want <nil>", got.Trailer)
+	}
+
+	if _, err := ParseFujiStreamFrame(raw[:10]); !errors.Is(err, InvalidPacketError) {
+		t.Errorf("ParseFujiStreamFrame() err = %v; want %s", err, InvalidPacketError)
+	}
+
+	raw = append(raw, 0x02, 0x00)
+	raw[0] = 0x18
+	got, err = ParseFujiStreamFrame(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Image, want) {
+		t.Errorf("ParseFujiStreamFrame() Image = % x; want % x", got.Image, want)
+	}
+	if want := []byte{0x02, 0x00}; !bytes.Equal(got.Trailer, want) {
+		t.Errorf("ParseFujiStreamFrame() Trailer = % x; want % x", got.Trailer, want)
+	}
+}
+
+func TestFujiStreamFrame_Metadata(t *testing.T) {
+	f := &FujiStreamFrame{Number: 0x07}
+	got := f.Metadata()
+	if got.FrameNumber != 0x07 || got.FocusState != FS_Unknown || got.Faces != nil {
+		t.Errorf("Metadata() = %+v; want frame number 7 only", got)
+	}
+
+	f.Trailer = []byte{0x02, 0x02, 0x10, 0x00, 0x20, 0x00, 0x30, 0x00, 0x40, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00,
+		0x04, 0x00}
+	got = f.Metadata()
+	if got.FocusState != FS_Focused {
+		t.Errorf("Metadata() FocusState = %s; want %s", got.FocusState, FS_Focused)
+	}
+	want := []image.Rectangle{image.Rect(16, 32, 64, 96), image.Rect(1, 2, 4, 6)}
+	if !reflect.DeepEqual(got.Faces, want) {
+		t.Errorf("Metadata() Faces = %v; want %v", got.Faces, want)
+	}
+
+	// A truncated face list is ignored.
+	f.Trailer = f.Trailer[:12]
+	got = f.Metadata()
+	if got.FocusState != FS_Focused || got.Faces != nil {
+		t.Errorf("Metadata() = %+v; want focus state only", got)
+	}
+}
+
+func TestFujiProcessStreamData(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithFriendlyName("testèr"), WithGUID("0d1e2f3a-4b5c-4d6e-8f70-8192a3b4c5d6"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other net.Conn
+	c.streamConn, other = net.Pipe()
+	defer other.Close()
+	c.StreamChan = make(chan []byte, 1)
+	c.closeStreamChan = make(chan struct{})
+	ch := c.StreamChan
+
+	if err := FujiProcessStreamData(c); err != nil {
+		t.Fatal(err)
+	}
+
+	go other.Write([]byte{0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0xd8})
+
+	select {
+	case got := <-ch:
+		if want := []byte{0xff, 0xd8}; !bytes.Equal(got, want) {
+			t.Errorf("FujiProcessStreamData() image = % x; want % x", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FujiProcessStreamData() no image received")
+	}
+
+	if err := c.ToggleLiveView(false); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("FujiProcessStreamData() received image after closing the streamer connection")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("FujiProcessStreamData() streamer channel not closed after closing the streamer connection")
+	}
+}
@@ -1,9 +1,13 @@
 package ip
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/malc0mn/ptp-ip/ip/internal"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"reflect"
 	"testing"
 )
 
@@ -21,7 +25,7 @@ func TestNewInitCommandRequestPacket(t *testing.T) {
 }
 
 func TestNewInitCommandRequestPacketForClient(t *testing.T) {
-	c, err := NewClient(DefaultVendor, DefaultIpAddress, DefaultPort, "test", "", logLevel)
+	c, err := NewClient(DefaultIpAddress, WithFriendlyName("test"), WithLogLevel(logLevel))
 	if err != nil {
 		t.Errorf("NewClient() err = %s; want <nil>", err)
 	}
@@ -80,6 +84,26 @@ func TestInitFailPacket_ReasonAsError(t *testing.T) {
 	}
 }
 
+func TestInitFailError_Is(t *testing.T) {
+	errs := map[FailReason]bool{
+		FR_FailBusy:              true,
+		FR_FailRejectedInitiator: false,
+		FR_FailUnspecified:       false,
+		FR_Fuji_DeviceBusy:       false,
+		FR_Fuji_InvalidParameter: false,
+	}
+
+	for reason, want := range errs {
+		ifp := InitFailPacket{
+			Reason: reason,
+		}
+		got := errors.Is(ifp.ReasonAsError(), ptp.ErrDeviceBusy)
+		if got != want {
+			t.Errorf("errors.Is(ReasonAsError(), ptp.ErrDeviceBusy) Reason = %#x; got %v, want %v", reason, got, want)
+		}
+	}
+}
+
 func TestNewPacketOutFromPacketType(t *testing.T) {
 	types := map[PacketType]string{
 		PKT_InitCommandRequest: "GenericInitCommandRequest",
@@ -193,3 +217,30 @@ func TestOperationRequestPacket_Payload(t *testing.T) {
 		t.Errorf("payload() buffer = %s; want %s", got, want)
 	}
 }
+
+func TestDataPacket_Payload(t *testing.T) {
+	check := []rawPayloadPacket{
+		&DataPacket{TransactionId: 7, DataPayload: []byte{0x01, 0x02, 0x03}},
+		&EndDataPacket{TransactionId: 7, DataPayload: []byte{0x01, 0x02, 0x03}},
+	}
+
+	for _, p := range check {
+		pl := p.(PacketOut).Payload()
+		want := []byte{0x07, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03}
+		if !bytes.Equal(pl, want) {
+			t.Errorf("%T Payload() = %v; want %v", p, pl, want)
+		}
+
+		raw := append(internal.MarshalLittleEndian(Header{uint32(HeaderSize + len(pl)), p.PacketType()}), pl...)
+		got, xs, err := parsePacket(raw, nil)
+		if err != nil {
+			t.Fatalf("parsePacket() err = %s; want <nil>", err)
+		}
+		if xs != nil {
+			t.Errorf("parsePacket() excess bytes = %d; want 0", len(xs))
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("parsePacket() = %#v; want %#v", got, p)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ip
+
+import (
+	"context"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"net"
+	"time"
+)
+
+// EventPoller defines how the Responder is polled for changes, as a fallback for Responders that never deliver events.
+// On every interval the device state and the list of objects are requested and compared to the previous ones: a
+// ptp.EC_DevicePropChanged event is published for each property that changed and a ptp.EC_ObjectAdded event for each
+// new object. The events are delivered the same way as the events received on the event connection, so downstream code
+// works either way. Polling the device state requires a vendor supporting GetDeviceState().
+type EventPoller struct {
+	// Interval is the time between two polls.
+	Interval time.Duration
+}
+
+// SetEventPoller enables polling the Responder for changes. This must be done before calling Dial(). Passing nil
+// disables polling, which is the default.
+func (c *Client) SetEventPoller(p *EventPoller) {
+	c.eventPoller = p
+}
+
+// eventPollState holds the results of the previous poll. A nil map means nothing was polled yet, in which case no events
+// are published.
+type eventPollState struct {
+	props   map[ptp.DevicePropCode]string
+	handles map[ptp.ObjectHandle]struct{}
+}
+
+// runEventPoller polls the Responder on every interval until the command/data connection it was started for is closed
+// or replaced.
+func (c *Client) runEventPoller(conn net.Conn, p EventPoller) {
+	if p.Interval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+
+	st := &eventPollState{}
+	for {
+		if conn != c.conn(cmdDataConnection) {
+			return
+		}
+		c.pollEvents(context.Background(), st)
+		<-t.C
+	}
+}
+
+// pollEvents requests the device state and the list of objects and publishes an event for each change compared to the
+// previous poll.
+func (c *Client) pollEvents(ctx context.Context, st *eventPollState) {
+	lmp := "[eventPoller]"
+
+	if s, err := c.vendorExtensions.getDeviceState(ctx, c); err != nil {
+		c.Debugf("%s unable to poll the device state: %s", lmp, err)
+	} else if list, ok := s.([]*ptp.DevicePropDesc); ok {
+		props := make(map[ptp.DevicePropCode]string, len(list))
+		for _, dpd := range list {
+			props[dpd.DevicePropertyCode] = string(dpd.CurrentValue)
+			if prev, ok := st.props[dpd.DevicePropertyCode]; st.props != nil && (!ok || prev != props[dpd.DevicePropertyCode]) {
+				c.Debugf("%s property %#x changed", lmp, dpd.DevicePropertyCode)
+				c.publishEvent(newPolledEvent(ptp.EC_DevicePropChanged, uint32(dpd.DevicePropertyCode)))
+			}
+		}
+		st.props = props
+		c.cacheDeviceState(list)
+	}
+
+	if hs, err := c.vendorExtensions.getObjectHandles(ctx, c, 0xFFFFFFFF, 0, 0); err != nil {
+		c.Debugf("%s unable to poll the object handles: %s", lmp, err)
+	} else {
+		handles := make(map[ptp.ObjectHandle]struct{}, len(hs))
+		for _, h := range hs {
+			handles[h] = struct{}{}
+			if _, ok := st.handles[h]; st.handles != nil && !ok {
+				c.Debugf("%s object %#x added", lmp, h)
+				c.publishEvent(newPolledEvent(ptp.EC_ObjectAdded, uint32(h)))
+			}
+		}
+		st.handles = handles
+	}
+}
+
+// newPolledEvent returns an event for a change detected by polling. It is not related to any transaction.
+func newPolledEvent(code ptp.EventCode, param uint32) EventPacket {
+	return &GenericEventPacket{
+		Event: ptp.Event{
+			EventCode:     code,
+			SessionID:     0xFFFFFFFF,
+			TransactionID: 0xFFFFFFFF,
+			Parameter1:    param,
+		},
+	}
+}
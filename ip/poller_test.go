@@ -0,0 +1,81 @@
+package ip
+
+import (
+	"context"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+// expectEvents checks the events received on the channel without waiting for more.
+func expectEvents(t *testing.T, ch <-chan EventPacket, want ...ptp.TypedEvent) {
+	t.Helper()
+
+	for _, w := range want {
+		select {
+		case evt := <-ch:
+			if got := evt.Typed(); got != w {
+				t.Errorf("event = %#v; want %#v", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event received; want %#v", w)
+		}
+	}
+	select {
+	case evt := <-ch:
+		t.Errorf("unexpected event %#v", evt.Typed())
+	default:
+	}
+}
+
+func TestClient_pollEventsObjects(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3e4f5a6b-7c8d-4e9f-8a0b-1c2d3e4f5a6b"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	events := c.Subscribe()
+
+	st := &eventPollState{}
+	c.pollEvents(context.Background(), st)
+	expectEvents(t, events)
+	if len(st.handles) != 4 {
+		t.Fatalf("polled %d handles; want 4", len(st.handles))
+	}
+
+	delete(st.handles, 4)
+	c.pollEvents(context.Background(), st)
+	expectEvents(t, events, ptp.ObjectAddedEvent{Handle: 4})
+}
+
+func TestClient_pollEventsProperties(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	events := c.Subscribe(ptp.EC_DevicePropChanged)
+
+	// The Fuji responder does not list the objects, so the poll is kept short.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	st := &eventPollState{}
+	c.pollEvents(ctx, st)
+	expectEvents(t, events)
+
+	st.props[ptp.DPC_BatteryLevel] = "\x03\x00\x00\x00"
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c.pollEvents(ctx, st)
+	expectEvents(t, events, ptp.DevicePropChangedEvent{Code: ptp.DPC_BatteryLevel})
+
+	if got, ok := c.CachedProperty(ptp.DPC_BatteryLevel); !ok || got != uint32(2) {
+		t.Errorf("CachedProperty() = %v, %v; want %v, true", got, ok, uint32(2))
+	}
+}
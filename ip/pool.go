@@ -0,0 +1,146 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	DuplicateClientError = errors.New("a client with that name already exists")
+	UnknownClientError   = errors.New("no client with that name")
+)
+
+// PoolError holds the errors that occurred when performing an action on the clients in a ClientPool by client name.
+type PoolError map[string]error
+
+func (pe PoolError) Error() string {
+	names := make([]string, 0, len(pe))
+	for name := range pe {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %s", name, pe[name])
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ClientPool manages the clients of several cameras, e.g. to control a multi-camera rig. Clients do not share any state,
+// so each camera can be configured independently: give each client its own logger with a distinct prefix to tell the
+// log messages of the cameras apart.
+type ClientPool struct {
+	clients map[string]*Client
+	mu      sync.Mutex
+}
+
+// NewClientPool creates an empty ClientPool.
+func NewClientPool() *ClientPool {
+	return &ClientPool{
+		clients: make(map[string]*Client),
+	}
+}
+
+// Add adds a client to the pool under the given name, which must be unique within the pool.
+func (p *ClientPool) Add(name string, c *Client) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.clients[name]; ok {
+		return fmt.Errorf("%w: %s", DuplicateClientError, name)
+	}
+	p.clients[name] = c
+
+	return nil
+}
+
+// Remove removes the client with the given name from the pool and returns it. The client is not closed.
+func (p *ClientPool) Remove(name string) (*Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", UnknownClientError, name)
+	}
+	delete(p.clients, name)
+
+	return c, nil
+}
+
+// Get returns the client with the given name or nil when there is no such client.
+func (p *ClientPool) Get(name string) *Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.clients[name]
+}
+
+// Names returns the names of all clients in the pool in alphabetical order.
+func (p *ClientPool) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.clients))
+	for name := range p.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Do calls f for all clients in the pool concurrently and waits for all calls to return, e.g. to release the shutter of
+// all cameras at the same time. The errors returned by f are collected in a PoolError.
+func (p *ClientPool) Do(f func(name string, c *Client) error) error {
+	p.mu.Lock()
+	clients := make(map[string]*Client, len(p.clients))
+	for name, c := range p.clients {
+		clients[name] = c
+	}
+	p.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(PoolError)
+	)
+	for name, c := range clients {
+		wg.Add(1)
+		go func(name string, c *Client) {
+			defer wg.Done()
+			if err := f(name, c); err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name, c)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// DialContext connects all clients in the pool concurrently. See Client.DialContext().
+func (p *ClientPool) DialContext(ctx context.Context) error {
+	return p.Do(func(_ string, c *Client) error {
+		return c.DialContext(ctx)
+	})
+}
+
+// Close closes all clients in the pool. See Client.Close().
+func (p *ClientPool) Close() error {
+	return p.Do(func(_ string, c *Client) error {
+		return c.Close()
+	})
+}
@@ -0,0 +1,74 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClientPool(t *testing.T) {
+	var logs [2]bytes.Buffer
+	p := NewClientPool()
+	for i, name := range []string{"right", "left"} {
+		c, err := NewClient(address, WithPort(okPort), WithLogger(NewLogger(LevelVeryVerbose, &logs[i], "["+name+"] ", 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Add(name, c); err != nil {
+			t.Errorf("Add() err = %s; want <nil>", err)
+		}
+	}
+	defer p.Close()
+
+	if err := p.Add("left", p.Get("right")); !errors.Is(err, DuplicateClientError) {
+		t.Errorf("Add() err = %v; want %s", err, DuplicateClientError)
+	}
+	if got, want := p.Names(), []string{"left", "right"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v; want %v", got, want)
+	}
+
+	if err := p.DialContext(context.Background()); err != nil {
+		t.Fatalf("DialContext() err = %s; want <nil>", err)
+	}
+
+	err := p.Do(func(name string, c *Client) error {
+		_, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel)
+		return err
+	})
+	if err != nil {
+		t.Errorf("Do() err = %s; want <nil>", err)
+	}
+
+	err = p.Do(func(name string, c *Client) error {
+		if name == "left" {
+			return errors.New("out of film")
+		}
+		return nil
+	})
+	if pe, ok := err.(PoolError); !ok || len(pe) != 1 || pe.Error() != "left: out of film" {
+		t.Errorf("Do() err = %v; want left: out of film", err)
+	}
+
+	// Each client must log using its own logger.
+	for i, names := range [][2]string{{"right", "left"}, {"left", "right"}} {
+		if l := logs[i].String(); !strings.HasPrefix(l, "["+names[0]+"] ") || strings.Contains(l, "["+names[1]+"] ") {
+			t.Errorf("%s log = %q; want only lines prefixed with [%s]", names[0], l, names[0])
+		}
+	}
+
+	c, err := p.Remove("left")
+	if err != nil || c == nil {
+		t.Errorf("Remove() = %v, %v; want client, <nil>", c, err)
+	}
+	c.Close()
+	if _, err := p.Remove("left"); !errors.Is(err, UnknownClientError) {
+		t.Errorf("Remove() err = %v; want %s", err, UnknownClientError)
+	}
+	if p.Get("left") != nil {
+		t.Error("Get() = client; want <nil>")
+	}
+}
@@ -0,0 +1,60 @@
+package ip
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressFunc is called during the data phase of a transaction with the number of bytes transferred so far and the
+// total number of bytes to transfer. The total is -1 when the Responder did not announce the size of the data.
+type ProgressFunc func(transferred, total int64)
+
+// progressKey is the context key holding the ProgressFunc of the operation the context was created for.
+type progressKey struct{}
+
+// ContextWithProgress returns a context that makes the operation performed using it report the progress of its data
+// phase to f, e.g. to render a progress bar when downloading a large RAW file:
+//   data, err := c.GetObjectContext(ip.ContextWithProgress(ctx, f), h)
+// The function is called from the goroutine performing the operation, so it should return quickly.
+func ContextWithProgress(ctx context.Context, f ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, f)
+}
+
+// reportProgress calls the ProgressFunc of the given context, if there is one.
+func reportProgress(ctx context.Context, transferred, total uint64) {
+	f, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok || f == nil {
+		return
+	}
+
+	t := int64(-1)
+	if total != unknownDataLength {
+		t = int64(total)
+	}
+	f(int64(transferred), t)
+}
+
+// ProgressWithRate turns f into a ProgressFunc that also passes the average transfer rate in bytes per second since
+// the start of the transfer, which allows calculating the remaining time. The rate is 0 until some time has passed. The
+// returned ProgressFunc can be reused for consecutive transfers but not for concurrent ones.
+func ProgressWithRate(f func(transferred, total int64, rate float64)) ProgressFunc {
+	var (
+		start time.Time
+		last  int64
+	)
+
+	return func(transferred, total int64) {
+		now := time.Now()
+		// A new transfer started when the byte count goes down.
+		if start.IsZero() || transferred < last {
+			start = now
+		}
+		last = transferred
+
+		var rate float64
+		if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+			rate = float64(transferred) / elapsed
+		}
+		f(transferred, total, rate)
+	}
+}
@@ -0,0 +1,117 @@
+package ip
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContextWithProgress(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("4c5d6e7f-8a9b-4c0d-9e1f-2a3b4c5d6e7f"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][2]int64
+	ctx := ContextWithProgress(context.Background(), func(transferred, total int64) {
+		got = append(got, [2]int64{transferred, total})
+	})
+	if _, err := c.GetObjectContext(ctx, 4); err != nil {
+		t.Fatalf("GetObjectContext() err = %s; want <nil>", err)
+	}
+
+	want := [][2]int64{{0, 8192}, {8192, 8192}}
+	if len(got) != len(want) {
+		t.Fatalf("progress = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress %d = %v; want %v", i, got[i], want[i])
+		}
+	}
+
+	// Operations using a context without progress must not report anything.
+	got = nil
+	if _, err := c.GetObject(3); err != nil {
+		t.Fatalf("GetObject() err = %s; want <nil>", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("progress = %v; want none", got)
+	}
+}
+
+func TestTransaction_sendDataProgress(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5d6e7f8a-9b0c-4d1e-8f2a-3b4c5d6e7f8a"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	var last [2]int64
+	calls := 0
+	ctx := ContextWithProgress(context.Background(), func(transferred, total int64) {
+		last = [2]int64{transferred, total}
+		calls++
+	})
+	tr, err := c.beginTransaction(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.end()
+
+	if err := tr.sendData(ctx, make([]byte, 2*dataOutChunkSize+1)); err != nil {
+		t.Fatalf("sendData() err = %s; want <nil>", err)
+	}
+	if calls != 3 {
+		t.Errorf("progress calls = %d; want 3", calls)
+	}
+	if want := [2]int64{2*dataOutChunkSize + 1, 2*dataOutChunkSize + 1}; last != want {
+		t.Errorf("progress = %v; want %v", last, want)
+	}
+}
+
+func TestProgressWithRate(t *testing.T) {
+	var rates []float64
+	f := ProgressWithRate(func(transferred, total int64, rate float64) {
+		rates = append(rates, rate)
+	})
+
+	f(0, 1000)
+	time.Sleep(50 * time.Millisecond)
+	f(500, 1000)
+	// A new transfer starts over.
+	f(0, 1000)
+
+	if rates[0] != 0 {
+		t.Errorf("rate = %f; want 0", rates[0])
+	}
+	if rates[1] <= 0 || rates[1] > 500/0.05 {
+		t.Errorf("rate = %f; want between 0 and %f", rates[1], 500/0.05)
+	}
+	if rates[2] != 0 {
+		t.Errorf("rate = %f; want 0", rates[2])
+	}
+}
+
+func TestReportProgressUnknownTotal(t *testing.T) {
+	var total int64
+	ctx := ContextWithProgress(context.Background(), func(_, t int64) {
+		total = t
+	})
+	reportProgress(ctx, 10, unknownDataLength)
+	if total != -1 {
+		t.Errorf("total = %d; want -1", total)
+	}
+
+	// A nil ProgressFunc must be ignored.
+	reportProgress(ContextWithProgress(context.Background(), nil), 10, 20)
+}
@@ -0,0 +1,53 @@
+package ip
+
+import "github.com/malc0mn/ptp-ip/ptp"
+
+// CachedProperty returns the last known value of the given device property without contacting the Responder, which
+// allows UIs to read the camera settings as often as they like. The cache is filled by GetDevicePropertyValue() and
+// GetDeviceState() and a value is dropped as soon as the Responder reports it changed, or when it is set or reset by
+// the client. The second return value is false when the value is not known.
+func (c *Client) CachedProperty(code ptp.DevicePropCode) (interface{}, bool) {
+	c.propCacheMu.Lock()
+	defer c.propCacheMu.Unlock()
+
+	val, ok := c.propCache[code]
+
+	return val, ok
+}
+
+// cacheProperty stores the value of the device property.
+func (c *Client) cacheProperty(code ptp.DevicePropCode, val interface{}) {
+	c.propCacheMu.Lock()
+	c.propCache[code] = val
+	c.propCacheMu.Unlock()
+}
+
+// cacheDeviceState stores the property values of the device state. Only device states in the form of a list of device
+// property descriptions, as returned by Fuji, are supported.
+func (c *Client) cacheDeviceState(state interface{}) {
+	list, ok := state.([]*ptp.DevicePropDesc)
+	if !ok {
+		return
+	}
+
+	for _, dpd := range list {
+		if val, err := ptp.DecodeValue(dpd.CurrentValue, dpd.DataType); err == nil {
+			c.cacheProperty(dpd.DevicePropertyCode, val)
+		}
+	}
+}
+
+// invalidateProperty drops the value of the device property from the cache.
+func (c *Client) invalidateProperty(code ptp.DevicePropCode) {
+	c.propCacheMu.Lock()
+	delete(c.propCache, code)
+	c.propCacheMu.Unlock()
+}
+
+// clearPropertyCache drops all values from the cache. Used when dialing since the values might have changed while the
+// client was not connected.
+func (c *Client) clearPropertyCache() {
+	c.propCacheMu.Lock()
+	c.propCache = make(map[ptp.DevicePropCode]interface{})
+	c.propCacheMu.Unlock()
+}
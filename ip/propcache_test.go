@@ -0,0 +1,71 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_CachedProperty(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2d3e4f5a-6b7c-4d8e-9f0a-1b2c3d4e5f6a"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.CachedProperty(ptp.DPC_BatteryLevel); ok {
+		t.Error("CachedProperty() ok = true; want false before requesting the value")
+	}
+
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.CachedProperty(ptp.DPC_BatteryLevel)
+	if !ok || got != uint8(0x32) {
+		t.Errorf("CachedProperty() = %v, %v; want %v, true", got, ok, uint8(0x32))
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_DevicePropChanged, Parameter1: uint32(ptp.DPC_BatteryLevel)}}, "[test]")
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := c.CachedProperty(ptp.DPC_BatteryLevel); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("CachedProperty() still holds the value after a DevicePropChanged event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ResetDeviceProperty(ptp.DPC_BatteryLevel); err == nil {
+		t.Fatal("ResetDeviceProperty() err = <nil>; want access denied")
+	}
+	if _, ok := c.CachedProperty(ptp.DPC_BatteryLevel); ok {
+		t.Error("CachedProperty() ok = true; want false after resetting the property")
+	}
+}
+
+func TestClient_CachedPropertyDeviceState(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetDeviceState(); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.CachedProperty(DPC_Fuji_CapturesRemaining)
+	if !ok || got != uint32(0x05d6) {
+		t.Errorf("CachedProperty() = %v, %v; want %v, true", got, ok, uint32(0x05d6))
+	}
+}
@@ -0,0 +1,220 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"math"
+	"time"
+)
+
+const (
+	// autoExposureIndex is the ptp.DPC_ExposureIndex value indicating the Responder selects the ISO automatically.
+	autoExposureIndex uint16 = 0xFFFF
+	// autoFNumber is the ptp.DPC_FNumber value indicating the Responder selects the aperture automatically.
+	autoFNumber uint16 = 0xFFFF
+)
+
+// SetISO sets the ISO on the Responder. Pass 0 to let the Responder select the ISO automatically.
+func (c *Client) SetISO(iso uint32) error {
+	return c.SetISOContext(context.Background(), iso)
+}
+
+// SetISOContext does the same as SetISO but aborts as soon as the context is done.
+func (c *Client) SetISOContext(ctx context.Context, iso uint32) error {
+	return c.vendorExtensions.setISO(ctx, c, iso)
+}
+
+// GetISO returns the ISO currently set on the Responder. The value 0 indicates automatic ISO.
+func (c *Client) GetISO() (uint32, error) {
+	return c.GetISOContext(context.Background())
+}
+
+// GetISOContext does the same as GetISO but aborts as soon as the context is done.
+func (c *Client) GetISOContext(ctx context.Context) (uint32, error) {
+	return c.vendorExtensions.getISO(ctx, c)
+}
+
+// SetFNumber sets the aperture on the Responder, e.g. 5.6 for f/5.6.
+func (c *Client) SetFNumber(fn float64) error {
+	return c.SetFNumberContext(context.Background(), fn)
+}
+
+// SetFNumberContext does the same as SetFNumber but aborts as soon as the context is done.
+func (c *Client) SetFNumberContext(ctx context.Context, fn float64) error {
+	return c.setValidatedDeviceProperty(ctx, ptp.DPC_FNumber, int64(math.Round(fn*100)))
+}
+
+// GetFNumber returns the aperture currently set on the Responder. The value 0 indicates automatic aperture.
+func (c *Client) GetFNumber() (float64, error) {
+	return c.GetFNumberContext(context.Background())
+}
+
+// GetFNumberContext does the same as GetFNumber but aborts as soon as the context is done.
+func (c *Client) GetFNumberContext(ctx context.Context) (float64, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_FNumber)
+	if err != nil || uint16(v) == autoFNumber {
+		return 0, err
+	}
+
+	return float64(uint16(v)) / 100, nil
+}
+
+// SetShutterSpeed sets the exposure time on the Responder, e.g. time.Second/250 for 1/250s. The PTP resolution is 0.1
+// milliseconds so the duration will be rounded accordingly.
+func (c *Client) SetShutterSpeed(d time.Duration) error {
+	return c.SetShutterSpeedContext(context.Background(), d)
+}
+
+// SetShutterSpeedContext does the same as SetShutterSpeed but aborts as soon as the context is done.
+func (c *Client) SetShutterSpeedContext(ctx context.Context, d time.Duration) error {
+	return c.setValidatedDeviceProperty(ctx, ptp.DPC_ExposureTime, int64(math.Round(float64(d)/float64(100*time.Microsecond))))
+}
+
+// GetShutterSpeed returns the exposure time currently set on the Responder.
+func (c *Client) GetShutterSpeed() (time.Duration, error) {
+	return c.GetShutterSpeedContext(context.Background())
+}
+
+// GetShutterSpeedContext does the same as GetShutterSpeed but aborts as soon as the context is done.
+func (c *Client) GetShutterSpeedContext(ctx context.Context) (time.Duration, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_ExposureTime)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(v) * 100 * time.Microsecond, nil
+}
+
+// SetExposureBias sets the exposure bias compensation on the Responder in EV, e.g. -0.7 or -2/3.0 for -2/3 EV.
+func (c *Client) SetExposureBias(ev float64) error {
+	return c.SetExposureBiasContext(context.Background(), ev)
+}
+
+// SetExposureBiasContext does the same as SetExposureBias but aborts as soon as the context is done.
+func (c *Client) SetExposureBiasContext(ctx context.Context, ev float64) error {
+	dpd, err := c.GetDevicePropertyDescriptionContext(ctx, ptp.DPC_ExposureBiasCompensation)
+	if err != nil {
+		return err
+	}
+
+	v := int64(math.Round(ev * 1000))
+	// Thirds of a stop cannot be represented exactly: a Responder might list 2/3 EV as 666 or 667 and 0.7 EV as 700.
+	// Snap to the nearest supported value when we are close enough.
+	if form, ok := dpd.Form.(*ptp.EnumerationForm); ok {
+		best, diff := v, int64(35)
+		for _, sv := range form.SupportedValues {
+			s := int64(int16(binary.LittleEndian.Uint16(sv)))
+			if d := s - v; d*d <= diff*diff {
+				best, diff = s, d
+			}
+		}
+		v = best
+	}
+
+	return c.setValidatedDevicePropertyWithDesc(ctx, dpd, v)
+}
+
+// GetExposureBias returns the exposure bias compensation currently set on the Responder in EV.
+func (c *Client) GetExposureBias() (float64, error) {
+	return c.GetExposureBiasContext(context.Background())
+}
+
+// GetExposureBiasContext does the same as GetExposureBias but aborts as soon as the context is done.
+func (c *Client) GetExposureBiasContext(ctx context.Context) (float64, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_ExposureBiasCompensation)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(int16(v)) / 1000, nil
+}
+
+// SetWhiteBalance sets the white balance on the Responder.
+func (c *Client) SetWhiteBalance(wb ptp.WhiteBalance) error {
+	return c.SetWhiteBalanceContext(context.Background(), wb)
+}
+
+// SetWhiteBalanceContext does the same as SetWhiteBalance but aborts as soon as the context is done.
+func (c *Client) SetWhiteBalanceContext(ctx context.Context, wb ptp.WhiteBalance) error {
+	return c.setValidatedDeviceProperty(ctx, ptp.DPC_WhiteBalance, int64(wb))
+}
+
+// GetWhiteBalance returns the white balance currently set on the Responder.
+func (c *Client) GetWhiteBalance() (ptp.WhiteBalance, error) {
+	return c.GetWhiteBalanceContext(context.Background())
+}
+
+// GetWhiteBalanceContext does the same as GetWhiteBalance but aborts as soon as the context is done.
+func (c *Client) GetWhiteBalanceContext(ctx context.Context) (ptp.WhiteBalance, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_WhiteBalance)
+
+	return ptp.WhiteBalance(v), err
+}
+
+// GetCaptureDelay returns the delay between releasing the shutter and the actual capture, as set for the self-timer of
+// the Responder. The value 0 indicates the self-timer is off.
+func (c *Client) GetCaptureDelay() (time.Duration, error) {
+	return c.GetCaptureDelayContext(context.Background())
+}
+
+// GetCaptureDelayContext does the same as GetCaptureDelay but aborts as soon as the context is done.
+func (c *Client) GetCaptureDelayContext(ctx context.Context) (time.Duration, error) {
+	return c.vendorExtensions.getCaptureDelay(ctx, c)
+}
+
+// setValidatedDeviceProperty requests the description of the given property and checks the value against it before
+// setting it on the Responder.
+// SetDevicePropertyValue sets an integer device property after checking the value is supported according to the
+// property's description. Signed values are passed as is, e.g. -667 for an exposure bias of -2/3 EV.
+func (c *Client) SetDevicePropertyValue(code ptp.DevicePropCode, v int64) error {
+	return c.SetDevicePropertyValueContext(context.Background(), code, v)
+}
+
+// SetDevicePropertyValueContext does the same as SetDevicePropertyValue but aborts as soon as the context is done.
+func (c *Client) SetDevicePropertyValueContext(ctx context.Context, code ptp.DevicePropCode, v int64) error {
+	return c.setValidatedDeviceProperty(ctx, code, v)
+}
+
+func (c *Client) setValidatedDeviceProperty(ctx context.Context, code ptp.DevicePropCode, v int64) error {
+	dpd, err := c.GetDevicePropertyDescriptionContext(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	return c.setValidatedDevicePropertyWithDesc(ctx, dpd, v)
+}
+
+func (c *Client) setValidatedDevicePropertyWithDesc(ctx context.Context, dpd *ptp.DevicePropDesc, v int64) error {
+	if err := dpd.ValidateValue(v); err != nil {
+		return err
+	}
+
+	size := dpd.SizeOfValueInBytes()
+	if size < 1 || size > 4 {
+		return fmt.Errorf("unsupported data type %#x for property %#x", dpd.DataType, dpd.DevicePropertyCode)
+	}
+
+	// Negative values must not be sign extended beyond the size of the data type.
+	return c.SetDevicePropertyContext(ctx, dpd.DevicePropertyCode, uint32(uint64(v)&(1<<(8*uint(size))-1)))
+}
+
+// getDevicePropertyValueAsUint32 requests the raw value of an integer property and converts it to a uint32 without
+// requesting the property's description first.
+func (c *Client) getDevicePropertyValueAsUint32(ctx context.Context, code ptp.DevicePropCode) (uint32, error) {
+	raw, err := c.vendorExtensions.getDevicePropertyValue(ctx, c, code)
+	if err != nil {
+		return 0, err
+	}
+
+	return rawValueToUint32(raw), nil
+}
+
+// rawValueToUint32 converts a little endian value of up to 4 bytes to a uint32.
+func rawValueToUint32(raw []byte) uint32 {
+	b := make([]byte, 4)
+	copy(b, raw)
+
+	return binary.LittleEndian.Uint32(b)
+}
@@ -0,0 +1,157 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_SetISO(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[uint32]bool{
+		// The camera uses the S-values for automatic ISO.
+		0:     false,
+		100:   true,
+		200:   true,
+		6400:  true,
+		12800: true,
+		123:   false,
+	}
+
+	for iso, ok := range check {
+		err := c.SetISO(iso)
+		if ok && err != nil {
+			t.Errorf("SetISO(%d) error = %s; want <nil>", iso, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("SetISO(%d) error = <nil>; want error", iso)
+		}
+	}
+}
+
+func TestClient_SetExposureBias(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[float64]bool{
+		-3:       true,
+		-2 / 3.0: true,
+		0:        true,
+		0.7:      true,
+		1.5:      false,
+		5:        false,
+	}
+
+	for ev, ok := range check {
+		err := c.SetExposureBias(ev)
+		if ok && err != nil {
+			t.Errorf("SetExposureBias(%f) error = %s; want <nil>", ev, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("SetExposureBias(%f) error = <nil>; want error", ev)
+		}
+	}
+}
+
+func TestClient_SetDevicePropertyValue(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[int64]bool{
+		-3000:  true,
+		-667:   true,
+		0:      true,
+		3000:   true,
+		500:    false,
+		0xfd65: false,
+	}
+
+	for v, ok := range check {
+		err := c.SetDevicePropertyValue(ptp.DPC_ExposureBiasCompensation, v)
+		if ok && err != nil {
+			t.Errorf("SetDevicePropertyValue(%d) error = %s; want <nil>", v, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("SetDevicePropertyValue(%d) error = <nil>; want error", v)
+		}
+	}
+}
+
+func TestClient_SetWhiteBalance(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := map[ptp.WhiteBalance]bool{
+		ptp.WB_Automatic:   true,
+		WB_Fuji_Shade:      true,
+		ptp.WB_Tungsten:    true,
+		ptp.WB_Flash:       false,
+		WB_Fuji_Underwater: true,
+	}
+
+	for wb, ok := range check {
+		err := c.SetWhiteBalance(wb)
+		if ok && err != nil {
+			t.Errorf("SetWhiteBalance(%#x) error = %s; want <nil>", wb, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("SetWhiteBalance(%#x) error = <nil>; want error", wb)
+		}
+	}
+}
+
+func TestClient_GetCaptureDelay(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("testèr"), WithGUID("67bace55-e7a4-4fbc-8e31-5122ee73a17c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetCaptureDelay()
+	if err != nil {
+		t.Errorf("GetCaptureDelay() error = %s; want <nil>", err)
+	}
+	want := 10 * time.Second
+	if got != want {
+		t.Errorf("GetCaptureDelay() = %s; want %s", got, want)
+	}
+}
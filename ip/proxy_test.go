@@ -0,0 +1,234 @@
+package ip
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// proxyServer is a minimal proxy forwarding the connections it accepts to the requested address. The handshake
+// function returns the address requested by the client.
+type proxyServer struct {
+	ln        net.Listener
+	handshake func(conn net.Conn) (string, bool)
+	mu        sync.Mutex
+	targets   []string
+}
+
+func newProxyServer(t *testing.T, handshake func(conn net.Conn) (string, bool)) *proxyServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps := &proxyServer{ln: ln, handshake: handshake}
+	go ps.serve()
+
+	return ps
+}
+
+func (ps *proxyServer) serve() {
+	for {
+		conn, err := ps.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			address, ok := ps.handshake(conn)
+			if !ok {
+				return
+			}
+			ps.mu.Lock()
+			ps.targets = append(ps.targets, address)
+			ps.mu.Unlock()
+
+			target, err := net.Dial("tcp", address)
+			if err != nil {
+				return
+			}
+			defer target.Close()
+			go io.Copy(target, conn)
+			io.Copy(conn, target)
+		}()
+	}
+}
+
+func (ps *proxyServer) address() string {
+	return ps.ln.Addr().String()
+}
+
+func socks5Handshake(user, pass string) func(conn net.Conn) (string, bool) {
+	return func(conn net.Conn) (string, bool) {
+		b := make([]byte, 2)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return "", false
+		}
+		methods := make([]byte, b[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return "", false
+		}
+
+		if user == "" {
+			conn.Write([]byte{socks5Version, socks5AuthNone})
+		} else {
+			if bytes.IndexByte(methods, socks5AuthPassword) < 0 {
+				conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+				return "", false
+			}
+			conn.Write([]byte{socks5Version, socks5AuthPassword})
+			r := bufio.NewReader(conn)
+			r.ReadByte()
+			ul, _ := r.ReadByte()
+			u := make([]byte, ul)
+			io.ReadFull(r, u)
+			pl, _ := r.ReadByte()
+			p := make([]byte, pl)
+			io.ReadFull(r, p)
+			if string(u) != user || string(p) != pass {
+				conn.Write([]byte{socks5AuthVersion, 0x01})
+				return "", false
+			}
+			conn.Write([]byte{socks5AuthVersion, 0x00})
+		}
+
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return "", false
+		}
+		var host string
+		switch req[3] {
+		case socks5AddrIPv4:
+			ip := make([]byte, net.IPv4len)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socks5AddrDomain:
+			l := make([]byte, 1)
+			io.ReadFull(conn, l)
+			h := make([]byte, l[0])
+			io.ReadFull(conn, h)
+			host = string(h)
+		default:
+			conn.Write([]byte{socks5Version, 0x08, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+			return "", false
+		}
+		port := make([]byte, 2)
+		io.ReadFull(conn, port)
+
+		conn.Write([]byte{socks5Version, 0x00, 0x00, socks5AddrIPv4, 127, 0, 0, 1, port[0], port[1]})
+
+		return net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))), true
+	}
+}
+
+func httpConnectHandshake(auth string) func(conn net.Conn) (string, bool) {
+	return func(conn net.Conn) (string, bool) {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return "", false
+		}
+		if req.Method != http.MethodConnect {
+			io.WriteString(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			return "", false
+		}
+		if auth != "" && req.Header.Get("Proxy-Authorization") != auth {
+			io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return "", false
+		}
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+		return req.Host, true
+	}
+}
+
+func TestClient_SetProxy(t *testing.T) {
+	check := []struct {
+		scheme    string
+		user      string
+		handshake func(conn net.Conn) (string, bool)
+	}{
+		{"socks5", "", socks5Handshake("", "")},
+		{"socks5h", "tester:secret@", socks5Handshake("tester", "secret")},
+		{"http", "", httpConnectHandshake("")},
+		// base64("tester:secret")
+		{"http", "tester:secret@", httpConnectHandshake("Basic dGVzdGVyOnNlY3JldA==")},
+	}
+
+	for _, tt := range check {
+		ps := newProxyServer(t, tt.handshake)
+		c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5c6d7e8f-9a0b-4c1d-8e2f-3a4b5c6d7e8f"), WithLogLevel(logLevel))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.SetProxy(tt.scheme + "://" + tt.user + ps.address()); err != nil {
+			t.Fatalf("SetProxy() err = %s; want <nil>", err)
+		}
+		if err := c.Dial(); err != nil {
+			t.Errorf("%s: Dial() err = %s; want <nil>", tt.scheme, err)
+		}
+		c.Close()
+		ps.ln.Close()
+
+		ps.mu.Lock()
+		want := []string{c.CommandDataAddress(), c.EventAddress()}
+		if len(ps.targets) != len(want) {
+			t.Errorf("%s: proxy connections = %v; want %v", tt.scheme, ps.targets, want)
+		}
+		ps.mu.Unlock()
+	}
+}
+
+func TestClient_SetProxyFail(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("6d7e8f9a-0b1c-4d2e-9f3a-4b5c6d7e8f9a"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetProxy("ftp://localhost"); !errors.Is(err, UnsupportedProxyError) {
+		t.Errorf("SetProxy() err = %v; want %s", err, UnsupportedProxyError)
+	}
+
+	for _, proxyURL := range []string{"socks5://wrong:password@", "http://wrong:password@"} {
+		var ps *proxyServer
+		if proxyURL[0] == 's' {
+			ps = newProxyServer(t, socks5Handshake("tester", "secret"))
+		} else {
+			ps = newProxyServer(t, httpConnectHandshake("Basic dGVzdGVyOnNlY3JldA=="))
+		}
+
+		if err := c.SetProxy(proxyURL + ps.address()); err != nil {
+			t.Fatalf("SetProxy() err = %s; want <nil>", err)
+		}
+		if err := c.Dial(); !errors.Is(err, ProxyConnectError) {
+			t.Errorf("Dial() err = %v; want %s", err, ProxyConnectError)
+		}
+		ps.ln.Close()
+		c.SetDialer(nil)
+	}
+}
+
+func TestSocks5ConnectRequest(t *testing.T) {
+	check := map[string][]byte{
+		"192.168.0.1:15740":     {0x05, 0x01, 0x00, 0x01, 192, 168, 0, 1, 0x3d, 0x7c},
+		"camera:80":             {0x05, 0x01, 0x00, 0x03, 6, 'c', 'a', 'm', 'e', 'r', 'a', 0x00, 0x50},
+		"[fe80::1%wlan0]:15740": {0x05, 0x01, 0x00, 0x04, 0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x3d, 0x7c},
+	}
+
+	for address, want := range check {
+		got, err := socks5ConnectRequest(address)
+		if err != nil {
+			t.Errorf("socks5ConnectRequest(%s) err = %s; want <nil>", address, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("socks5ConnectRequest(%s) = %v; want %v", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sync"
+)
+
+// RawConn gives exclusive access to the command/data connection for sending and receiving arbitrary packets, e.g. to
+// experiment with undocumented vendor operations without patching the library. No other transaction is started while
+// the RawConn is open: operations called from other goroutines wait until it is closed.
+// The Responder's packets are routed by transaction ID, so only the packets for the transaction ID reserved by the
+// RawConn are received. Use it in the packets sent, see TransactionID().
+type RawConn struct {
+	c    *Client
+	t    *transaction
+	once sync.Once
+}
+
+// RawConn waits until no transaction is in progress and hands over the command/data connection. The RawConn must be
+// closed to allow other operations to run again.
+func (c *Client) RawConn(ctx context.Context) (*RawConn, error) {
+	if c.conn(cmdDataConnection) == nil {
+		return nil, NotConnectedError
+	}
+
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawConn{c: c, t: t}, nil
+}
+
+// TransactionID returns the transaction ID reserved for the packets sent.
+func (rc *RawConn) TransactionID() ptp.TransactionID {
+	return rc.t.id
+}
+
+// Send sends a packet to the command/data connection.
+func (rc *RawConn) Send(p PacketOut) error {
+	return rc.c.SendPacketToCmdDataConn(p)
+}
+
+// SendRaw sends the raw packet to the command/data connection as is. The packet must be framed, i.e. start with the
+// length field holding the length of the packet, length field included.
+func (rc *RawConn) SendRaw(raw []byte) error {
+	if len(raw) < 4 || binary.LittleEndian.Uint32(raw[0:4]) != uint32(len(raw)) {
+		return fmt.Errorf("%w: length field does not match the packet length %d", InvalidPacketError, len(raw))
+	}
+
+	return rc.c.writePacket(rc.c.conn(cmdDataConnection), rc.c.runSendHooks(cmdDataConnection, nil, raw))
+}
+
+// Receive waits for the next packet the Responder sends for the reserved transaction ID and returns it as is, length
+// field included. It gives up when the Operation timeout is reached or the context is done.
+func (rc *RawConn) Receive(ctx context.Context) ([]byte, error) {
+	return rc.c.WaitForRawPacketFromCommandDataSubscriberContext(ctx, rc.t.ch)
+}
+
+// Close hands the command/data connection back to the client. Packets the Responder still sends for the reserved
+// transaction ID are dropped.
+func (rc *RawConn) Close() error {
+	rc.once.Do(rc.t.end)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_RawConn(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("7e8f9a0b-1c2d-4e4f-8a5b-6c7d8e9f0a1b"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.RawConn(context.Background()); err != NotConnectedError {
+		t.Errorf("RawConn() err = %v; want %s", err, NotConnectedError)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := c.RawConn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No other transaction can start while the RawConn is open.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetDeviceInfoContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetDeviceInfoContext() err = %v; want %s", err, context.DeadlineExceeded)
+	}
+
+	err = rc.Send(&OperationRequestPacket{
+		DataPhaseInfo:    DP_NoDataOrDataIn,
+		OperationRequest: ptp.OperationRequest{OperationCode: ptp.OC_GetStorageIDs, TransactionID: rc.TransactionID()},
+	})
+	if err != nil {
+		t.Fatalf("Send() err = %s; want <nil>", err)
+	}
+	want := []PacketType{PKT_StartData, PKT_EndData, PKT_OperationResponse}
+	for _, pt := range want {
+		raw, err := rc.Receive(context.Background())
+		if err != nil {
+			t.Fatalf("Receive() err = %s; want <nil>", err)
+		}
+		p, _, err := parsePacket(raw, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.PacketType() != pt {
+			t.Errorf("Receive() packet type = %#x; want %#x", p.PacketType(), pt)
+		}
+	}
+
+	raw := marshalPacket(&OperationRequestPacket{
+		DataPhaseInfo:    DP_NoDataOrDataIn,
+		OperationRequest: ptp.OperationRequest{OperationCode: ptp.OC_ResetDevicePropValue, TransactionID: rc.TransactionID(), Parameter1: uint32(ptp.DPC_BatteryLevel)},
+	})
+	if err := rc.SendRaw(raw[:len(raw)-1]); !errors.Is(err, InvalidPacketError) {
+		t.Errorf("SendRaw() err = %v; want %s", err, InvalidPacketError)
+	}
+	if err := rc.SendRaw(raw); err != nil {
+		t.Fatalf("SendRaw() err = %s; want <nil>", err)
+	}
+	res, err := rc.Receive(context.Background())
+	if err != nil {
+		t.Fatalf("Receive() err = %s; want <nil>", err)
+	}
+	p, _, err := parsePacket(res, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orp, ok := p.(*OperationResponsePacket); !ok || orp.ResponseCode != ptp.RC_AccessDenied {
+		t.Errorf("Receive() packet = %#v; want operation response %#x", p, ptp.RC_AccessDenied)
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() err = %s; want <nil>", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() err = %s; want <nil>", err)
+	}
+
+	if _, err := c.GetDeviceInfo(); err != nil {
+		t.Errorf("GetDeviceInfo() err = %s; want <nil>", err)
+	}
+}
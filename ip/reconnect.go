@@ -0,0 +1,179 @@
+package ip
+
+import (
+	"context"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sort"
+	"time"
+)
+
+// DefaultReconnectInterval is the time waited between two reconnect attempts when the ReconnectPolicy does not specify
+// an interval.
+const DefaultReconnectInterval = 2 * time.Second
+
+// ReconnectPolicy defines how the client re-establishes the connection when it detects the Responder dropped it, e.g.
+// because the camera went out of Wi-Fi range. Reconnecting re-dials all connections that were open, replays the vendor
+// specific init sequence, which opens a new session when required, and restores all property values that were set
+// using SetDeviceProperty().
+// Operations in progress while the connection is dropped will fail, they are not retried.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of times to try reconnecting before giving up. Use 0 to keep on trying until the client
+	// is closed.
+	MaxAttempts int
+	// Interval is the time to wait between two attempts. When zero, DefaultReconnectInterval is used.
+	Interval time.Duration
+}
+
+// ReconnectPolicy returns the reconnect policy of the client, nil when reconnecting is disabled.
+func (c *Client) ReconnectPolicy() *ReconnectPolicy {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	return c.reconnectPolicy
+}
+
+// SetReconnectPolicy enables reconnecting automatically when the connection is dropped. Passing nil disables it, which
+// is the default.
+func (c *Client) SetReconnectPolicy(p *ReconnectPolicy) {
+	c.reconnectMu.Lock()
+	c.reconnectPolicy = p
+	c.reconnectMu.Unlock()
+}
+
+// Reconnecting indicates if the client is currently trying to re-establish a dropped connection.
+func (c *Client) Reconnecting() bool {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	return c.reconnecting
+}
+
+// connectionLost is called by the listeners when their connection fails. It starts reconnecting in the background
+// unless the client was closed, reconnecting is disabled or another listener already triggered it.
+func (c *Client) connectionLost(err error) {
+	c.reconnectMu.Lock()
+	if c.closed || c.reconnecting {
+		c.reconnectMu.Unlock()
+		return
+	}
+	p := c.reconnectPolicy
+	c.reconnecting = p != nil
+	c.reconnectMu.Unlock()
+
+	// Each listener notices the connection was dropped, but it is only reported once.
+	if p == nil && c.State() == ConnectionLost {
+		return
+	}
+	c.setState(ConnectionLost, err)
+	if p == nil {
+		return
+	}
+
+	c.Warnf("[reconnect] connection to the Responder lost: %s", err)
+	go c.reconnect(*p)
+}
+
+// reconnect closes what remains of the connections and keeps dialing according to the policy until it succeeds.
+func (c *Client) reconnect(p ReconnectPolicy) {
+	defer func() {
+		c.reconnectMu.Lock()
+		c.reconnecting = false
+		c.reconnectMu.Unlock()
+	}()
+
+	if p.Interval <= 0 {
+		p.Interval = DefaultReconnectInterval
+	}
+
+	withStreamer := c.conn(streamConnection) != nil
+	for attempt := 1; p.MaxAttempts == 0 || attempt <= p.MaxAttempts; attempt++ {
+		c.closeConnections()
+		if c.isClosed() {
+			return
+		}
+
+		// A new connection starts a new session so the transaction IDs start over as well, unless they are persisted.
+		c.transactionIdMu.Lock()
+		if c.transactionIds == nil {
+			c.transactionId = 0
+		}
+		c.transactionIdMu.Unlock()
+		c.unsubscribeAll()
+
+		c.Infof("[reconnect] attempt %d...", attempt)
+		if err := c.redial(withStreamer); err != nil {
+			c.Warnf("[reconnect] attempt %d failed: %s", attempt, err)
+			c.setState(ConnectionLost, err)
+			time.Sleep(p.Interval)
+			continue
+		}
+
+		// The client might have been closed while we were dialing.
+		if c.isClosed() {
+			c.closeConnections()
+			return
+		}
+
+		c.restoreDeviceProperties()
+		c.Info("[reconnect] connection restored")
+		return
+	}
+
+	c.Errorf("[reconnect] giving up after %d attempts", p.MaxAttempts)
+}
+
+func (c *Client) redial(withStreamer bool) error {
+	ctx := context.Background()
+
+	if err := c.dial(ctx); err != nil {
+		return err
+	}
+
+	if withStreamer {
+		return c.initStreamConn(ctx)
+	}
+
+	return nil
+}
+
+func (c *Client) isClosed() bool {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	return c.closed
+}
+
+// rememberDeviceProperty stores the value set for a property so it can be restored after reconnecting.
+func (c *Client) rememberDeviceProperty(code ptp.DevicePropCode, val uint32) {
+	c.devicePropsMu.Lock()
+	c.deviceProps[code] = val
+	c.devicePropsMu.Unlock()
+}
+
+// forgetDeviceProperty removes a property that was reset to its factory default value from the values to restore.
+func (c *Client) forgetDeviceProperty(code ptp.DevicePropCode) {
+	c.devicePropsMu.Lock()
+	delete(c.deviceProps, code)
+	c.devicePropsMu.Unlock()
+}
+
+// restoreDeviceProperties sets all remembered property values on the Responder again, in order of their property code.
+// Failures are logged but do not abort restoring the other values.
+func (c *Client) restoreDeviceProperties() {
+	c.devicePropsMu.Lock()
+	codes := make([]ptp.DevicePropCode, 0, len(c.deviceProps))
+	vals := make(map[ptp.DevicePropCode]uint32, len(c.deviceProps))
+	for code, val := range c.deviceProps {
+		codes = append(codes, code)
+		vals[code] = val
+	}
+	c.devicePropsMu.Unlock()
+
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, code := range codes {
+		c.Debugf("[reconnect] restoring property %#x to %#x", code, vals[code])
+		if err := c.vendorExtensions.setDeviceProperty(context.Background(), c, code, vals[code]); err != nil {
+			c.Warnf("[reconnect] unable to restore property %#x: %s", code, err)
+		}
+	}
+}
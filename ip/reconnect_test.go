@@ -0,0 +1,115 @@
+package ip
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_SetReconnectPolicy(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5f4e3d2c-1b0a-4998-8877-665544332211"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.ReconnectPolicy(); got != nil {
+		t.Errorf("ReconnectPolicy() = %v; want <nil>", got)
+	}
+
+	want := &ReconnectPolicy{MaxAttempts: 3, Interval: time.Second}
+	c.SetReconnectPolicy(want)
+	if got := c.ReconnectPolicy(); got != want {
+		t.Errorf("ReconnectPolicy() = %v; want %v", got, want)
+	}
+}
+
+func TestClient_connectionLostWithoutPolicy(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.connectionLost(ConnectionLostError)
+	if c.Reconnecting() {
+		t.Error("Reconnecting() = true; want false")
+	}
+
+	c.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 1})
+	c.Close()
+	c.connectionLost(ConnectionLostError)
+	if c.Reconnecting() {
+		t.Error("Reconnecting() = true; want false")
+	}
+}
+
+func TestClient_rememberDeviceProperty(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("tèster"), WithGUID("9e8d7c6b-5a49-4382-a716-f5e4d3c2b1a0"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetDeviceProperty(DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.deviceProps[DPC_Fuji_FilmSimulation], uint32(FS_Fuji_Astia); got != want {
+		t.Errorf("deviceProps[%#x] = %#x; want %#x", DPC_Fuji_FilmSimulation, got, want)
+	}
+
+	err = c.ResetDeviceProperty(DPC_Fuji_FilmSimulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.deviceProps[DPC_Fuji_FilmSimulation]; ok {
+		t.Errorf("deviceProps[%#x] still present after ResetDeviceProperty()", DPC_Fuji_FilmSimulation)
+	}
+}
+
+func TestClient_reconnect(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("tèster"), WithGUID("0b1c2d3e-4f5a-4b6c-9d7e-8f9a0b1c2d3e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, Interval: 100 * time.Millisecond})
+
+	err = c.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetDeviceProperty(DPC_Fuji_FilmSimulation, uint32(FS_Fuji_Astia))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := c.TransactionId()
+
+	// Simulate the Responder dropping the connection.
+	old := c.conn(cmdDataConnection)
+	old.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for c.Reconnecting() || c.conn(cmdDataConnection) == old {
+		select {
+		case <-ctx.Done():
+			t.Fatal("client did not reconnect in time")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// A new session was opened and the film simulation was restored using the same amount of transactions.
+	if got := c.TransactionId(); got != want {
+		t.Errorf("TransactionId() = %d; want %d", got, want)
+	}
+
+	if _, err := c.GetDeviceState(); err != nil {
+		t.Errorf("GetDeviceState() err = %s; want <nil>", err)
+	}
+}
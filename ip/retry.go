@@ -0,0 +1,108 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultRetryBackoff is the time waited before the first retry when the RetryPolicy does not specify a backoff.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultRetryMultiplier is the factor the backoff is multiplied with after each retry when the RetryPolicy does
+	// not specify a multiplier.
+	DefaultRetryMultiplier = 2
+)
+
+// RetryPolicy defines how operations failing for a transient reason, such as a busy Responder, are retried. Each
+// retry waits longer than the previous one: the backoff starts at InitialBackoff and is multiplied by Multiplier after
+// each attempt, up to MaxBackoff. The jitter randomly shortens each backoff so multiple clients do not retry in
+// lockstep.
+// Operations are retried as a whole, so this applies to the init sequence when dialing as well.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, the first one included. Values below 2 disable retrying.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first retry. When zero, DefaultRetryBackoff is used.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time to wait between two attempts. When zero, the backoff is not capped.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the backoff grows with after each attempt. When zero, DefaultRetryMultiplier is used.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, by which each backoff is randomly shortened.
+	Jitter float64
+	// Retryable decides if the operation failing with the given error is retried. It receives a *ptp.ResponseError
+	// when the Responder returned a response code other than RC_OK, allowing to decide per response code. When nil,
+	// IsTransientError is used.
+	Retryable func(error) bool
+}
+
+// IsTransientError indicates if the error is caused by a busy Responder, in which case trying again later might
+// succeed: ptp.RC_DeviceBusy and FR_FailBusy are considered to be transient.
+func IsTransientError(err error) bool {
+	return errors.Is(err, ptp.ErrDeviceBusy)
+}
+
+// backoff returns the time to wait after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	if d <= 0 {
+		d = DefaultRetryBackoff
+	}
+	m := p.Multiplier
+	if m <= 0 {
+		m = DefaultRetryMultiplier
+	}
+
+	for i := 1; i < attempt; i++ {
+		d = time.Duration(float64(d) * m)
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			d = p.MaxBackoff
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// RetryPolicy returns the retry policy of the client, nil when retrying is disabled.
+func (c *Client) RetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+// SetRetryPolicy enables retrying operations that fail for a transient reason. Passing nil disables it, which is the
+// default. This should be done before calling Dial().
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.retryPolicy = p
+}
+
+// retry calls f until it succeeds, fails with an error the retry policy does not consider retryable or the maximum
+// number of attempts is reached. The attempt number, starting at 1, is passed to f. Waiting between two attempts is
+// aborted as soon as the context is done.
+func (c *Client) retry(ctx context.Context, f func(attempt int) error) error {
+	p := c.retryPolicy
+	retryable := IsTransientError
+	if p != nil && p.Retryable != nil {
+		retryable = p.Retryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := f(attempt)
+		if err == nil || p == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		d := p.backoff(attempt)
+		c.Warnf("[retry] attempt %d of %d failed, retrying in %s: %s", attempt, p.MaxAttempts, d, err)
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 3}
+	check := map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 30 * time.Millisecond,
+		3: 50 * time.Millisecond,
+		4: 50 * time.Millisecond,
+	}
+	for attempt, want := range check {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s; want %s", attempt, got, want)
+		}
+	}
+
+	p = &RetryPolicy{}
+	if got := p.backoff(2); got != DefaultRetryBackoff*DefaultRetryMultiplier {
+		t.Errorf("backoff(2) = %s; want %s", got, DefaultRetryBackoff*DefaultRetryMultiplier)
+	}
+
+	p = &RetryPolicy{InitialBackoff: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 10; i++ {
+		if got := p.backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Errorf("backoff(1) = %s; want between 50ms and 100ms", got)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	check := map[error]bool{
+		&ptp.ResponseError{Code: ptp.RC_DeviceBusy}:      true,
+		&ptp.ResponseError{Code: ptp.RC_GeneralError}:    false,
+		&InitFailError{Reason: FR_FailBusy}:              true,
+		&InitFailError{Reason: FR_Fuji_DeviceBusy}:       false,
+		&InitFailError{Reason: FR_FailRejectedInitiator}: false,
+		errors.New("device busy"):                        false,
+	}
+	for err, want := range check {
+		if got := IsTransientError(err); got != want {
+			t.Errorf("IsTransientError(%s) = %v; want %v", err, got, want)
+		}
+	}
+}
+
+func TestClient_retry(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&genericBusy, 2)
+	_, err = GenericGetDevicePropertyValue(context.Background(), c, ptp.DPC_WhiteBalance)
+	if !errors.Is(err, ptp.ErrDeviceBusy) {
+		t.Errorf("GenericGetDevicePropertyValue() err = %v; want %s", err, ptp.ErrDeviceBusy)
+	}
+
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond})
+	got, err := GenericGetDevicePropertyValue(context.Background(), c, ptp.DPC_WhiteBalance)
+	if err != nil {
+		t.Fatalf("GenericGetDevicePropertyValue() err = %s; want <nil>", err)
+	}
+	if want := []byte{0x02, 0x00}; string(got) != string(want) {
+		t.Errorf("GenericGetDevicePropertyValue() = %#x; want %#x", got, want)
+	}
+
+	// The attempts are exhausted before the responder stops being busy.
+	atomic.StoreInt32(&genericBusy, 3)
+	_, err = GenericGetDevicePropertyValue(context.Background(), c, ptp.DPC_WhiteBalance)
+	if !errors.Is(err, ptp.ErrDeviceBusy) {
+		t.Errorf("GenericGetDevicePropertyValue() err = %v; want %s", err, ptp.ErrDeviceBusy)
+	}
+
+	// Errors the policy does not consider retryable are returned right away.
+	atomic.StoreInt32(&genericBusy, 1)
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, Retryable: func(error) bool {
+		return false
+	}})
+	_, err = GenericGetDevicePropertyValue(context.Background(), c, ptp.DPC_WhiteBalance)
+	if !errors.Is(err, ptp.ErrDeviceBusy) {
+		t.Errorf("GenericGetDevicePropertyValue() err = %v; want %s", err, ptp.ErrDeviceBusy)
+	}
+	if n := atomic.LoadInt32(&genericBusy); n != 0 {
+		t.Errorf("responder still busy %d times; want 0", n)
+	}
+
+	// Waiting for the next attempt stops when the context is done.
+	atomic.StoreInt32(&genericBusy, 2)
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = GenericGetDevicePropertyValue(ctx, c, ptp.DPC_WhiteBalance)
+	if !errors.Is(err, ptp.ErrDeviceBusy) {
+		t.Errorf("GenericGetDevicePropertyValue() err = %v; want %s", err, ptp.ErrDeviceBusy)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("GenericGetDevicePropertyValue() took %s; want it to return when the context is done", d)
+	}
+	atomic.StoreInt32(&genericBusy, 0)
+}
@@ -0,0 +1,114 @@
+package ip
+
+// LifecycleState indicates where the connection to the Responder is in its lifecycle, as returned by Client.State().
+type LifecycleState int
+
+const (
+	ConnectionIdle LifecycleState = iota
+	ConnectionDialing
+	ConnectionHandshaking
+	ConnectionReady
+	ConnectionLost
+)
+
+// String returns the name of the lifecycle state.
+func (s LifecycleState) String() string {
+	switch s {
+	case ConnectionIdle:
+		return "idle"
+	case ConnectionDialing:
+		return "dialing"
+	case ConnectionHandshaking:
+		return "handshaking"
+	case ConnectionReady:
+		return "ready"
+	case ConnectionLost:
+		return "lost"
+	}
+
+	return "unknown"
+}
+
+// State returns the current state of the connection to the Responder: ConnectionIdle, ConnectionDialing,
+// ConnectionHandshaking, ConnectionReady or ConnectionLost.
+func (c *Client) State() LifecycleState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	return c.state
+}
+
+// OnStateChange registers a function that is called each time the connection state changes. The connection goes from
+// ConnectionIdle to ConnectionDialing while the connections are being established, to ConnectionHandshaking during the
+// init sequence and to ConnectionReady once the client can be used. A dropped connection results in ConnectionLost, which
+// is followed by ConnectionDialing again when a ReconnectPolicy is set. Closing the client returns it to ConnectionIdle.
+// The callbacks are called from the goroutine causing the state change, so they should return quickly.
+func (c *Client) OnStateChange(f func(LifecycleState)) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	c.onStateChange = append(c.onStateChange, f)
+}
+
+// OnConnect registers a function that is called each time the connection becomes ready for use, including after
+// reconnecting.
+func (c *Client) OnConnect(f func()) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	c.onConnect = append(c.onConnect, f)
+}
+
+// OnDisconnect registers a function that is called each time a ready connection is dropped or closed. The error is nil
+// when the client was closed.
+func (c *Client) OnDisconnect(f func(error)) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	c.onDisconnect = append(c.onDisconnect, f)
+}
+
+// OnError registers a function that is called with the error causing a failed dial or reconnect attempt or a dropped
+// connection.
+func (c *Client) OnError(f func(error)) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	c.onError = append(c.onError, f)
+}
+
+// setState moves the connection to the given state and calls the callbacks. Once the client is closed, only the move to
+// ConnectionIdle is accepted so listeners noticing the closed connections cannot report it as lost.
+func (c *Client) setState(s LifecycleState, err error) {
+	c.stateMu.Lock()
+	prev := c.state
+	if (prev == s && err == nil) || (s != ConnectionIdle && c.isClosed()) {
+		c.stateMu.Unlock()
+		return
+	}
+	c.state = s
+	onStateChange, onConnect, onDisconnect, onError := c.onStateChange, c.onConnect, c.onDisconnect, c.onError
+	c.stateMu.Unlock()
+
+	if prev != s {
+		c.Debugf("[state] %s -> %s", prev, s)
+		for _, f := range onStateChange {
+			f(s)
+		}
+	}
+	if s == ConnectionReady && prev != ConnectionReady {
+		for _, f := range onConnect {
+			f()
+		}
+	}
+	if prev == ConnectionReady && s != ConnectionReady {
+		for _, f := range onDisconnect {
+			f(err)
+		}
+	}
+	if err != nil {
+		for _, f := range onError {
+			f(err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package ip
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// stateRecorder records the connection states and callbacks of a client.
+type stateRecorder struct {
+	mu         sync.Mutex
+	states     []LifecycleState
+	connects   int
+	disconnect []error
+	errors     []error
+}
+
+func recordStates(c *Client) *stateRecorder {
+	sr := &stateRecorder{}
+	c.OnStateChange(func(s LifecycleState) {
+		sr.mu.Lock()
+		sr.states = append(sr.states, s)
+		sr.mu.Unlock()
+	})
+	c.OnConnect(func() {
+		sr.mu.Lock()
+		sr.connects++
+		sr.mu.Unlock()
+	})
+	c.OnDisconnect(func(err error) {
+		sr.mu.Lock()
+		sr.disconnect = append(sr.disconnect, err)
+		sr.mu.Unlock()
+	})
+	c.OnError(func(err error) {
+		sr.mu.Lock()
+		sr.errors = append(sr.errors, err)
+		sr.mu.Unlock()
+	})
+
+	return sr
+}
+
+func (sr *stateRecorder) checkStates(t *testing.T, want ...LifecycleState) {
+	t.Helper()
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if len(sr.states) != len(want) {
+		t.Fatalf("states = %v; want %v", sr.states, want)
+	}
+	for i := range want {
+		if sr.states[i] != want[i] {
+			t.Errorf("states = %v; want %v", sr.states, want)
+			return
+		}
+	}
+}
+
+func TestConnectionState_String(t *testing.T) {
+	check := map[ConnectionState]string{
+		ConnectionAlive:        "alive",
+		ConnectionUnresponsive: "unresponsive",
+		ConnectionState(99):    "unknown",
+	}
+
+	for s, want := range check {
+		if got := s.String(); got != want {
+			t.Errorf("String() = %s; want %s", got, want)
+		}
+	}
+}
+
+func TestLifecycleState_String(t *testing.T) {
+	check := map[LifecycleState]string{
+		ConnectionIdle:        "idle",
+		ConnectionDialing:     "dialing",
+		ConnectionHandshaking: "handshaking",
+		ConnectionReady:       "ready",
+		ConnectionLost:        "lost",
+		LifecycleState(99):    "unknown",
+	}
+
+	for s, want := range check {
+		if got := s.String(); got != want {
+			t.Errorf("String() = %s; want %s", got, want)
+		}
+	}
+
+	var zero LifecycleState
+	if zero != ConnectionIdle {
+		t.Errorf("zero value = %s; want %s", zero, ConnectionIdle)
+	}
+}
+
+func TestClient_State(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("9d0e1f2a-3b4c-4d5e-8f6a-7b8c9d0e1f2a"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := recordStates(c)
+
+	if got := c.State(); got != ConnectionIdle {
+		t.Errorf("State() = %s; want %s", got, ConnectionIdle)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.State(); got != ConnectionReady {
+		t.Errorf("State() = %s; want %s", got, ConnectionReady)
+	}
+
+	c.Close()
+	sr.checkStates(t, ConnectionDialing, ConnectionHandshaking, ConnectionReady, ConnectionIdle)
+	if sr.connects != 1 {
+		t.Errorf("OnConnect() calls = %d; want 1", sr.connects)
+	}
+	if len(sr.disconnect) != 1 || sr.disconnect[0] != nil {
+		t.Errorf("OnDisconnect() errors = %v; want [<nil>]", sr.disconnect)
+	}
+	if len(sr.errors) != 0 {
+		t.Errorf("OnError() errors = %v; want none", sr.errors)
+	}
+}
+
+func TestClient_StateDialFail(t *testing.T) {
+	c, err := NewClient(address, WithPort(failPort), WithFriendlyName("tèster"), WithGUID("0e1f2a3b-4c5d-4e6f-9a7b-8c9d0e1f2a3b"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := recordStates(c)
+
+	if err := c.Dial(); err == nil {
+		t.Fatal("Dial() err = <nil>; want error")
+	}
+
+	sr.checkStates(t, ConnectionDialing, ConnectionHandshaking, ConnectionIdle)
+	if sr.connects != 0 || len(sr.disconnect) != 0 {
+		t.Errorf("OnConnect() calls = %d, OnDisconnect() calls = %d; want 0", sr.connects, len(sr.disconnect))
+	}
+	if len(sr.errors) != 1 {
+		t.Errorf("OnError() errors = %v; want 1 error", sr.errors)
+	}
+}
+
+func TestClient_StateLost(t *testing.T) {
+	c, err := NewClient(address, WithVendor("fuji"), WithPort(fujiCmdPort), WithFriendlyName("tèster"), WithGUID("1f2a3b4c-5d6e-4f7a-8b8c-9d0e1f2a3b4c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, Interval: 100 * time.Millisecond})
+	sr := recordStates(c)
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate the Responder dropping the connection.
+	c.commandDataConn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sr.mu.Lock()
+		connects := sr.connects
+		sr.mu.Unlock()
+		if connects == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client did not reconnect in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	sr.checkStates(t,
+		ConnectionDialing, ConnectionHandshaking, ConnectionReady,
+		ConnectionLost,
+		ConnectionDialing, ConnectionHandshaking, ConnectionReady,
+	)
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if len(sr.disconnect) != 1 || sr.disconnect[0] == nil {
+		t.Errorf("OnDisconnect() errors = %v; want 1 error", sr.disconnect)
+	}
+	if len(sr.errors) == 0 {
+		t.Error("OnError() not called; want connection lost error")
+	}
+}
@@ -0,0 +1,128 @@
+package ip
+
+import "github.com/malc0mn/ptp-ip/ptp"
+
+// StorageChange describes a store that was added or removed or of which the StorageInfo dataset changed, e.g. because
+// a memory card was inserted or pulled.
+type StorageChange struct {
+	StorageID ptp.StorageID
+	// Removed is true when the store is no longer available.
+	Removed bool
+	// Info holds the refreshed StorageInfo dataset. It is nil when the store was removed or when refreshing failed.
+	Info *ptp.StorageInfo
+	// Err holds the error that occurred while refreshing the StorageInfo dataset.
+	Err error
+}
+
+// CachedStorageInfo returns the last known StorageInfo dataset of the given store without contacting the Responder.
+// The cache is filled by GetStorageInfo() and kept up to date using the StoreAdded, StoreRemoved and
+// StorageInfoChanged events. The second return value is false when the dataset is not known.
+func (c *Client) CachedStorageInfo(sid ptp.StorageID) (*ptp.StorageInfo, bool) {
+	c.storageMu.Lock()
+	defer c.storageMu.Unlock()
+
+	si, ok := c.storageInfo[sid]
+
+	return si, ok
+}
+
+// OnStorageChange registers a function that is called each time a store is added or removed or its StorageInfo dataset
+// changes, allowing e.g. tethering software to warn the user when the memory card is pulled mid-session. The client
+// requests the StorageInfo dataset of added and changed stores before calling the function, which is done from a
+// separate goroutine so the event listener is not held up.
+func (c *Client) OnStorageChange(f func(StorageChange)) {
+	c.storageMu.Lock()
+	defer c.storageMu.Unlock()
+
+	c.onStorageChange = append(c.onStorageChange, f)
+}
+
+// cacheStorageInfo stores the StorageInfo dataset of the store.
+func (c *Client) cacheStorageInfo(sid ptp.StorageID, si *ptp.StorageInfo) {
+	c.storageMu.Lock()
+	c.storageInfo[sid] = si
+	c.storageMu.Unlock()
+}
+
+// clearStorageCache drops all StorageInfo datasets from the cache. Used when dialing since the stores might have
+// changed while the client was not connected.
+func (c *Client) clearStorageCache() {
+	c.storageMu.Lock()
+	c.storageInfo = make(map[ptp.StorageID]*ptp.StorageInfo)
+	c.storageMu.Unlock()
+}
+
+// handleStorageEvent keeps the storage cache up to date and informs the OnStorageChange() functions.
+func (c *Client) handleStorageEvent(e ptp.TypedEvent) {
+	switch e := e.(type) {
+	case ptp.StoreAddedEvent:
+		go c.refreshStorageInfo(e.StorageID)
+	case ptp.StorageInfoChangedEvent:
+		go c.refreshStorageInfo(e.StorageID)
+	case ptp.StoreRemovedEvent:
+		for _, sid := range c.dropStorageInfo(e.StorageID) {
+			c.notifyStorageChange(StorageChange{StorageID: sid, Removed: true})
+		}
+	}
+}
+
+// refreshStorageInfo requests the StorageInfo dataset of the store. A StorageID of 0 indicates a physical store holding
+// several logical stores was added, in which case the list of StorageIDs is requested first.
+func (c *Client) refreshStorageInfo(sid ptp.StorageID) {
+	sids := []ptp.StorageID{sid}
+	if sid == 0 {
+		var err error
+		if sids, err = c.GetStorageIDs(); err != nil {
+			c.Warnf("[storage] unable to refresh the list of StorageIDs: %s", err)
+			c.notifyStorageChange(StorageChange{StorageID: sid, Err: err})
+			return
+		}
+	}
+
+	for _, sid := range sids {
+		si, err := c.GetStorageInfo(sid)
+		if err != nil {
+			c.Warnf("[storage] unable to refresh StorageInfo of store %#x: %s", sid, err)
+		}
+		c.notifyStorageChange(StorageChange{StorageID: sid, Info: si, Err: err})
+	}
+}
+
+// dropStorageInfo drops the StorageInfo datasets of the removed stores from the cache and returns their StorageIDs.
+// When the least significant sixteen bits of the StorageID are set to 0xFFFF, all logical stores on the physical store
+// were removed.
+func (c *Client) dropStorageInfo(sid ptp.StorageID) []ptp.StorageID {
+	if sid&0xFFFF != 0xFFFF {
+		c.storageMu.Lock()
+		delete(c.storageInfo, sid)
+		c.storageMu.Unlock()
+
+		return []ptp.StorageID{sid}
+	}
+
+	c.storageMu.Lock()
+	defer c.storageMu.Unlock()
+
+	var removed []ptp.StorageID
+	for id := range c.storageInfo {
+		if id>>16 == sid>>16 {
+			delete(c.storageInfo, id)
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) == 0 {
+		removed = append(removed, sid)
+	}
+
+	return removed
+}
+
+func (c *Client) notifyStorageChange(sc StorageChange) {
+	c.storageMu.Lock()
+	onStorageChange := c.onStorageChange
+	c.storageMu.Unlock()
+
+	for _, f := range onStorageChange {
+		f(sc)
+	}
+}
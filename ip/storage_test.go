@@ -0,0 +1,88 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"testing"
+	"time"
+)
+
+func TestClient_GetStorageInfo(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("4f5a6b7c-8d9e-4f0a-9b1c-2d3e4f5a6b7c"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	si, err := c.GetStorageInfo(0x00010001)
+	if err != nil {
+		t.Fatalf("GetStorageInfo() err = %s; want <nil>", err)
+	}
+	if want := mockStorageInfo[0x00010001]; *si != want {
+		t.Errorf("GetStorageInfo() = %#v; want %#v", *si, want)
+	}
+	if got, ok := c.CachedStorageInfo(0x00010001); !ok || got != si {
+		t.Errorf("CachedStorageInfo() = %v, %t; want %v, true", got, ok, si)
+	}
+
+	if _, err := c.GetStorageInfo(0x00030001); err == nil {
+		t.Error("GetStorageInfo() err = <nil>; want error for unknown store")
+	}
+	if _, ok := c.CachedStorageInfo(0x00030001); ok {
+		t.Error("CachedStorageInfo() ok = true; want false for unknown store")
+	}
+}
+
+func TestClient_OnStorageChange(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan StorageChange, 2)
+	c.OnStorageChange(func(sc StorageChange) {
+		changes <- sc
+	})
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetStorageInfo(0x00010001); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() StorageChange {
+		t.Helper()
+		select {
+		case sc := <-changes:
+			return sc
+		case <-time.After(time.Second):
+			t.Fatal("OnStorageChange() function not called")
+		}
+		return StorageChange{}
+	}
+
+	// The card holding store 0x00010001 is pulled.
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_StoreRemoved, Parameter1: 0x0001FFFF}}, "[test]")
+	if sc := next(); sc.StorageID != 0x00010001 || !sc.Removed || sc.Info != nil {
+		t.Errorf("StorageChange = %+v; want store 0x00010001 removed", sc)
+	}
+	if _, ok := c.CachedStorageInfo(0x00010001); ok {
+		t.Error("CachedStorageInfo() ok = true; want false after the store was removed")
+	}
+
+	genericSendEvent(&GenericEventPacket{Event: ptp.Event{EventCode: ptp.EC_StorageInfoChanged, Parameter1: 0x00020000}}, "[test]")
+	sc := next()
+	if sc.StorageID != 0x00020000 || sc.Removed || sc.Err != nil || sc.Info == nil {
+		t.Fatalf("StorageChange = %+v; want refreshed store 0x00020000", sc)
+	}
+	if want := mockStorageInfo[0x00020000]; *sc.Info != want {
+		t.Errorf("StorageChange Info = %#v; want %#v", *sc.Info, want)
+	}
+	if got, ok := c.CachedStorageInfo(0x00020000); !ok || got != sc.Info {
+		t.Errorf("CachedStorageInfo() = %v, %t; want %v, true", got, ok, sc.Info)
+	}
+}
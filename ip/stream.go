@@ -0,0 +1,108 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io"
+)
+
+var StreamClosedError = errors.New("read from closed stream")
+
+// dataPhaseReader is an io.ReadCloser over the data-in phase of a transaction. The data is handed to the reader as it
+// arrives, so nothing but the packet being read is kept in memory. The transaction ends once the operation response was
+// read or the reader is closed.
+type dataPhaseReader struct {
+	ctx context.Context
+	dp  *dataPhase
+	// check returns an error when the response code of the operation does not indicate success.
+	check func(*operationResult) error
+	buf   []byte
+	err   error
+	ended bool
+}
+
+func newDataPhaseReader(ctx context.Context, t *transaction, code ptp.OperationCode, check func(*operationResult) error) *dataPhaseReader {
+	dp := t.newDataPhase(code)
+	dp.stream = true
+
+	return &dataPhaseReader{
+		ctx:   ctx,
+		dp:    dp,
+		check: check,
+	}
+}
+
+// Read reads the data sent by the Responder. It returns io.EOF once the Responder sent a successful operation response
+// and an error when the operation failed or the transfer was interrupted.
+func (r *dataPhaseReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.buf, r.err = r.advance()
+		if r.err != nil {
+			r.end()
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+// advance processes the next packet of the transaction and returns the data it carries, if any.
+func (r *dataPhaseReader) advance() ([]byte, error) {
+	if !r.dp.done() {
+		return r.dp.next(r.ctx)
+	}
+
+	if err := r.dp.finish(); err != nil {
+		return nil, err
+	}
+	if err := r.check(r.dp.res); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// Close ends the transaction. When the data phase is still in progress, the Responder is asked to cancel the transaction
+// and the data that is still arriving is discarded until the transaction is done, so the client can be used again when
+// Close returns.
+func (r *dataPhaseReader) Close() error {
+	r.buf = nil
+	r.err = StreamClosedError
+	if r.ended {
+		return nil
+	}
+
+	if !r.dp.done() {
+		t := r.dp.t
+		if err := t.c.CancelTransaction(t.id); err != nil {
+			t.c.Warnf("[stream] unable to cancel transaction %d: %s", t.id, err)
+		}
+		for !r.dp.done() {
+			if _, err := r.dp.next(r.ctx); err != nil {
+				break
+			}
+		}
+	}
+	r.end()
+
+	return nil
+}
+
+// end ends the transaction allowing the next one to start.
+func (r *dataPhaseReader) end() {
+	if !r.ended {
+		r.dp.t.end()
+		r.ended = true
+	}
+}
+
+// genericResultAsError returns an error when the response code of the operation does not indicate success.
+func genericResultAsError(res *operationResult) error {
+	return ptp.NewResponseError(res.code, res.operation, res.tid)
+}
@@ -0,0 +1,79 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestClient_OperationRequestStream(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2a3b4c5d-6e7f-4a8b-9c0d-1e2f3a4b5c6d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := c.OperationRequestStream(ptp.OC_GetObject, []uint32{4})
+	if err != nil {
+		t.Fatalf("OperationRequestStream() err = %s; want <nil>", err)
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, rc)
+	if err != nil {
+		t.Errorf("io.Copy() err = %s; want <nil>", err)
+	}
+	if n != 8192 || !bytes.Equal(buf.Bytes(), bytes.Repeat([]byte{0xff}, 8192)) {
+		t.Errorf("io.Copy() n = %d; want 8192 bytes of 0xff", n)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() err = %s; want <nil>", err)
+	}
+	if _, err := rc.Read(make([]byte, 1)); err != StreamClosedError {
+		t.Errorf("Read() err = %v; want %s", err, StreamClosedError)
+	}
+
+	rc, err = c.OperationRequestStream(ptp.OC_GetObject, []uint32{99})
+	if err != nil {
+		t.Fatalf("OperationRequestStream() err = %s; want <nil>", err)
+	}
+	_, err = ioutil.ReadAll(rc)
+	rc.Close()
+	if !errors.Is(err, ptp.NewResponseError(ptp.RC_InvalidObjectHandle, ptp.OC_GetObject, 0)) {
+		t.Errorf("ReadAll() err = %v; want %#x", err, ptp.RC_InvalidObjectHandle)
+	}
+}
+
+func TestClient_OperationRequestStreamClose(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("3b4c5d6e-7f8a-4b9c-8d1e-2f3a4b5c6d7e"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := c.OperationRequestStream(ptp.OC_GetObject, []uint32{uint32(mockSlowObject)})
+	if err != nil {
+		t.Fatalf("OperationRequestStream() err = %s; want <nil>", err)
+	}
+	if _, err := io.ReadFull(rc, make([]byte, 4096)); err != nil {
+		t.Fatalf("ReadFull() err = %s; want <nil>", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() err = %s; want <nil>", err)
+	}
+
+	// The transaction must be ended so the connection can be used again.
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel); err != nil {
+		t.Errorf("GetDevicePropertyValue() err = %s; want <nil>", err)
+	}
+}
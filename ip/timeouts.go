@@ -0,0 +1,83 @@
+package ip
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts holds the time the client will wait for the Responder, configurable per class of operation. Sleeping
+// cameras tend to keep the connection open without ever responding, so waiting indefinitely is not a good idea. A zero
+// value does mean waiting indefinitely nonetheless.
+type Timeouts struct {
+	// Dial is the maximum time to wait for a connection to be established.
+	Dial time.Duration
+	// Write is the maximum time allowed to send a single packet.
+	Write time.Duration
+	// Init is the maximum time to wait for each response during the init handshake. Fuji devices wait for the user to
+	// accept the connection on the camera, so do not make this too short.
+	Init time.Duration
+	// Operation is the maximum time to wait for each response to an operation request.
+	Operation time.Duration
+	// Capture is the maximum time to wait for each response or event when capturing an image. Make sure this is
+	// longer than the exposure time when shooting long exposures.
+	Capture time.Duration
+	// Transfer is the maximum time to wait for each data packet when downloading an object.
+	Transfer time.Duration
+}
+
+// DefaultTimeouts returns the timeouts used by a new client.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Dial:      DefaultDialTimeout,
+		Write:     DefaultWriteTimeout,
+		Init:      DefaultReadTimeout,
+		Operation: DefaultReadTimeout,
+		Capture:   DefaultReadTimeout,
+		Transfer:  DefaultReadTimeout,
+	}
+}
+
+// Timeouts returns the timeouts currently used by the client.
+func (c *Client) Timeouts() Timeouts {
+	return c.timeouts
+}
+
+// SetTimeouts allows setting custom timeouts. This should be done before calling Dial().
+func (c *Client) SetTimeouts(t Timeouts) {
+	c.timeouts = t
+}
+
+// readTimeoutKey is the context key holding the read timeout of the operation class the context was created for.
+type readTimeoutKey struct{}
+
+// withReadTimeout returns a context that makes the client wait d for each packet instead of the Operation timeout.
+func withReadTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, readTimeoutKey{}, d)
+}
+
+// readTimeout returns the time to wait for each packet when performing an operation using the given context.
+func (c *Client) readTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(readTimeoutKey{}).(time.Duration); ok {
+		return d
+	}
+
+	return c.timeouts.Operation
+}
+
+// after does the same as time.After but returns a nil channel, which blocks forever, when d is zero.
+func after(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+
+	return time.After(d)
+}
+
+// deadline returns the point in time d from now or the zero time, meaning no deadline, when d is zero.
+func deadline(d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(d)
+}
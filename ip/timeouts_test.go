@@ -0,0 +1,94 @@
+package ip
+
+import (
+	"context"
+	"errors"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClient_SetTimeouts(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Timeouts(); got != DefaultTimeouts() {
+		t.Errorf("Timeouts() got = %#v; want %#v", got, DefaultTimeouts())
+	}
+
+	want := Timeouts{Dial: time.Second, Capture: time.Minute}
+	c.SetTimeouts(want)
+	if got := c.Timeouts(); got != want {
+		t.Errorf("Timeouts() got = %#v; want %#v", got, want)
+	}
+}
+
+func TestClient_readTimeout(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTimeouts(Timeouts{Operation: time.Second, Transfer: time.Minute})
+
+	if got := c.readTimeout(context.Background()); got != time.Second {
+		t.Errorf("readTimeout() got = %s; want %s", got, time.Second)
+	}
+	if got := c.readTimeout(withReadTimeout(context.Background(), c.Timeouts().Transfer)); got != time.Minute {
+		t.Errorf("readTimeout() got = %s; want %s", got, time.Minute)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	if got := after(0); got != nil {
+		t.Errorf("after(0) got = %v; want <nil>", got)
+	}
+	if got := after(time.Millisecond); got == nil {
+		t.Error("after(1ms) got = <nil>; want channel")
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	if got := deadline(0); !got.IsZero() {
+		t.Errorf("deadline(0) got = %s; want zero time", got)
+	}
+	if got := deadline(time.Minute); got.Before(time.Now()) {
+		t.Errorf("deadline(1m) got = %s; want a time in the future", got)
+	}
+}
+
+func TestClient_WaitForRawPacketFromCommandDataSubscriberTimeout(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTimeouts(Timeouts{Operation: 20 * time.Millisecond})
+
+	_, err = c.WaitForRawPacketFromCommandDataSubscriber(make(chan []byte))
+	if err != WaitForResponseError {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriber() err = %v; want %s", err, WaitForResponseError)
+	}
+	if !errors.Is(err, ptp.ErrTimeout) {
+		t.Errorf("WaitForRawPacketFromCommandDataSubscriber() err = %v; want %s", err, ptp.ErrTimeout)
+	}
+}
+
+func TestClient_sendPacketWriteTimeout(t *testing.T) {
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("testèr"), WithLogLevel(logLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTimeouts(Timeouts{Write: 20 * time.Millisecond})
+
+	// Nobody reads from the other end of the pipe so writing blocks until the write deadline is reached.
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+
+	err = c.sendPacket(conn, c.newCmdDataInitPacket())
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("sendPacket() err = %v; want timeout error", err)
+	}
+}
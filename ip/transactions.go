@@ -0,0 +1,186 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ip/internal"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The PTP specification reserves transaction ID 0x00000000 for operations outside of a session, such as OpenSession,
+// and considers 0xFFFFFFFF to be invalid. Valid transaction IDs range from 0x00000001 up to and including 0xFFFFFFFE.
+const (
+	minTransactionId ptp.TransactionID = 0x00000001
+	maxTransactionId ptp.TransactionID = 0xFFFFFFFE
+
+	// transactionIdBlock is the number of transaction IDs reserved at once when persisting them, so the store is not
+	// written to for every single transaction.
+	transactionIdBlock = 64
+)
+
+// TransactionIDStore persists the last transaction ID used so a new client can continue where the previous one left
+// off. Some Responders, mostly the ones lacking proper session handling, refuse to process a transaction ID they have
+// seen before until they are power cycled.
+type TransactionIDStore interface {
+	// LoadTransactionID returns the last transaction ID saved, 0 when there is none.
+	LoadTransactionID() (ptp.TransactionID, error)
+	// SaveTransactionID saves the transaction ID to continue counting from. The client reserves the transaction IDs in
+	// blocks, so this can be higher than the last transaction ID that was actually used.
+	SaveTransactionID(ptp.TransactionID) error
+}
+
+// FileTransactionIDStore is a TransactionIDStore keeping the last transaction ID in a file.
+type FileTransactionIDStore struct {
+	Path string
+}
+
+// NewFileTransactionIDStore returns a TransactionIDStore keeping the last transaction ID in the file at the given path.
+func NewFileTransactionIDStore(path string) *FileTransactionIDStore {
+	return &FileTransactionIDStore{Path: path}
+}
+
+// LoadTransactionID reads the transaction ID from the file. A missing file is not an error, 0 is returned instead.
+func (s *FileTransactionIDStore) LoadTransactionID() (ptp.TransactionID, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	tid, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return ptp.TransactionID(tid), nil
+}
+
+// SaveTransactionID writes the transaction ID to a temporary file which then replaces the file, so a crash can never
+// leave a truncated file behind.
+func (s *FileTransactionIDStore) SaveTransactionID(tid ptp.TransactionID) error {
+	return internal.WriteFileAtomic(s.Path, []byte(strconv.FormatUint(uint64(tid), 10)+"\n"), 0644)
+}
+
+// SetTransactionIDStore makes the client persist the transaction IDs it uses and continue counting from the last
+// transaction ID saved, also when reconnecting. To spare the store, the transaction IDs are reserved in blocks: the
+// last one of the block is saved when the block is started and the transaction ID actually used last is saved when
+// closing the client. This should be done before calling Dial(). Passing nil disables persisting the transaction IDs,
+// which is the default.
+func (c *Client) SetTransactionIDStore(s TransactionIDStore) error {
+	c.transactionIdMu.Lock()
+	defer c.transactionIdMu.Unlock()
+
+	c.transactionIds = s
+	if s == nil {
+		return nil
+	}
+
+	tid, err := s.LoadTransactionID()
+	if err != nil {
+		return err
+	}
+	if tid > maxTransactionId {
+		tid = 0
+	}
+	c.transactionId = tid
+	c.reservedTids = 0
+
+	return nil
+}
+
+// TransactionId returns the current transaction ID.
+func (c *Client) TransactionId() ptp.TransactionID {
+	c.transactionIdMu.Lock()
+	defer c.transactionIdMu.Unlock()
+
+	return c.transactionId
+}
+
+// incrementTransactionId increments the transaction ID in a thread safe way. After reaching the maximum value, the
+// transaction ID wraps around to 1, skipping the reserved values. The IDs of the transactions still in progress are
+// skipped as well so a wrap around cannot make the responses of two transactions collide.
+func (c *Client) incrementTransactionId() ptp.TransactionID {
+	c.transactionIdMu.Lock()
+
+	for {
+		c.transactionId = nextTransactionId(c.transactionId, 1)
+		c.reservedTids--
+		if !c.transactionInProgress(c.transactionId) {
+			break
+		}
+	}
+	tid := c.transactionId // must copy the value before releasing the lock to reliably return it!
+
+	reserve := c.transactionIds != nil && c.reservedTids < 0
+	if reserve {
+		c.reservedTids = transactionIdBlock - 1
+		c.reservedTidMax = nextTransactionId(tid, transactionIdBlock-1)
+	}
+	c.transactionIdMu.Unlock()
+
+	// The store is written to without holding the lock so other transactions do not have to wait for it.
+	if reserve {
+		c.saveTransactionId()
+	}
+
+	return tid
+}
+
+// nextTransactionId returns the transaction ID n steps after tid, n being at least 1, wrapping around to 1 after
+// reaching the maximum value.
+func nextTransactionId(tid ptp.TransactionID, n uint32) ptp.TransactionID {
+	// The position of tid in the range of valid transaction IDs, 0 being the position right before the first one.
+	var pos uint64
+	if tid >= minTransactionId && tid <= maxTransactionId {
+		pos = uint64(tid-minTransactionId) + 1
+	}
+
+	return minTransactionId + ptp.TransactionID((pos+uint64(n)-1)%uint64(maxTransactionId-minTransactionId+1))
+}
+
+// saveTransactionId persists the transaction ID reserved last. The saves are serialised so a slow save cannot
+// overwrite a more recent reservation.
+func (c *Client) saveTransactionId() {
+	c.tidStoreMu.Lock()
+	defer c.tidStoreMu.Unlock()
+
+	c.transactionIdMu.Lock()
+	s, tid := c.transactionIds, c.reservedTidMax
+	c.transactionIdMu.Unlock()
+
+	if s == nil {
+		return
+	}
+	if err := s.SaveTransactionID(tid); err != nil {
+		c.Warnf("unable to save transaction ID %d: %s", tid, err)
+	}
+}
+
+// releaseTransactionIds saves the transaction ID used last, instead of the last one of the reserved block, so the next
+// client continues right after it.
+func (c *Client) releaseTransactionIds() {
+	c.transactionIdMu.Lock()
+	if c.transactionIds == nil || c.reservedTids == 0 {
+		c.transactionIdMu.Unlock()
+		return
+	}
+	c.reservedTidMax = c.transactionId
+	c.reservedTids = 0
+	c.transactionIdMu.Unlock()
+
+	c.saveTransactionId()
+}
+
+// transactionInProgress indicates if a subscription exists for the given transaction ID.
+func (c *Client) transactionInProgress(tid ptp.TransactionID) bool {
+	c.cmdDataSubsMu.Lock()
+	defer c.cmdDataSubsMu.Unlock()
+
+	_, ok := c.cmdDataSubs[tid]
+
+	return ok
+}
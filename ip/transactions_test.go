@@ -0,0 +1,164 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClient_incrementTransactionIdSkipsInProgress(t *testing.T) {
+	c := Client{cmdDataSubs: make(map[ptp.TransactionID]*cmdDataSubscription)}
+	c.cmdDataSubs[1] = &cmdDataSubscription{}
+	c.cmdDataSubs[2] = &cmdDataSubscription{}
+
+	c.transactionId = maxTransactionId
+	got := c.incrementTransactionId()
+	want := ptp.TransactionID(3)
+	if got != want {
+		t.Errorf("incrementTransactionId() = %#x; want %#x", got, want)
+	}
+}
+
+func TestFileTransactionIDStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewFileTransactionIDStore(filepath.Join(dir, "tid"))
+	got, err := s.LoadTransactionID()
+	if err != nil {
+		t.Errorf("LoadTransactionID() err = %s; want <nil>", err)
+	}
+	if got != 0 {
+		t.Errorf("LoadTransactionID() = %d; want 0", got)
+	}
+
+	want := ptp.TransactionID(0xFFFFFFFE)
+	if err := s.SaveTransactionID(want); err != nil {
+		t.Fatalf("SaveTransactionID() err = %s; want <nil>", err)
+	}
+	if got, err = s.LoadTransactionID(); err != nil || got != want {
+		t.Errorf("LoadTransactionID() = %#x, %v; want %#x, <nil>", got, err, want)
+	}
+
+	// Only the file itself must remain, the temporary files must be gone.
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("files in store directory = %d; want 1", len(files))
+	}
+}
+
+func TestClient_SetTransactionIDStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptpip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := NewFileTransactionIDStore(filepath.Join(dir, "tid"))
+	if err := s.SaveTransactionID(41); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(address, WithPort(okPort), WithFriendlyName("tèster"), WithGUID("2a3b4c5d-6e7f-4a8b-9c9d-0e1f2a3b4c5d"), WithLogLevel(logLevel))
+	defer c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetTransactionIDStore(s); err != nil {
+		t.Fatalf("SetTransactionIDStore() err = %s; want <nil>", err)
+	}
+	if got := c.TransactionId(); got != 41 {
+		t.Errorf("TransactionId() = %d; want 41", got)
+	}
+
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetDevicePropertyValue(ptp.DPC_BatteryLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	want := c.TransactionId()
+	if want <= 41 {
+		t.Errorf("TransactionId() = %d; want more than 41", want)
+	}
+	// A block of transaction IDs is reserved starting right after the one loaded.
+	if got, err := s.LoadTransactionID(); err != nil || got != 41+transactionIdBlock {
+		t.Errorf("LoadTransactionID() = %d, %v; want %d, <nil>", got, err, 41+transactionIdBlock)
+	}
+
+	// Closing the client saves the transaction ID used last.
+	c.Close()
+	want = c.TransactionId()
+	if got, err := s.LoadTransactionID(); err != nil || got != want {
+		t.Errorf("LoadTransactionID() after Close() = %d, %v; want %d, <nil>", got, err, want)
+	}
+}
+
+// countingTransactionIDStore keeps the transaction ID in memory and counts the number of saves.
+type countingTransactionIDStore struct {
+	tid   ptp.TransactionID
+	saves int
+}
+
+func (s *countingTransactionIDStore) LoadTransactionID() (ptp.TransactionID, error) {
+	return s.tid, nil
+}
+
+func (s *countingTransactionIDStore) SaveTransactionID(tid ptp.TransactionID) error {
+	s.tid = tid
+	s.saves++
+	return nil
+}
+
+func TestClient_incrementTransactionIdReservesBlocks(t *testing.T) {
+	s := &countingTransactionIDStore{tid: maxTransactionId - 10}
+	c := Client{cmdDataSubs: make(map[ptp.TransactionID]*cmdDataSubscription)}
+	if err := c.SetTransactionIDStore(s); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2*transactionIdBlock+1; i++ {
+		tid := c.incrementTransactionId()
+		// The saved transaction ID must be the one used or one of the transaction IDs reserved after it.
+		ok := s.tid == tid
+		for n := uint32(1); n < transactionIdBlock && !ok; n++ {
+			ok = nextTransactionId(tid, n) == s.tid
+		}
+		if !ok {
+			t.Fatalf("incrementTransactionId() = %#x; saved transaction ID %#x is not reserved", tid, s.tid)
+		}
+	}
+	if want := 3; s.saves != want {
+		t.Errorf("SaveTransactionID() called %d times; want %d", s.saves, want)
+	}
+
+	c.releaseTransactionIds()
+	if want := c.TransactionId(); s.tid != want {
+		t.Errorf("releaseTransactionIds() saved %#x; want %#x", s.tid, want)
+	}
+}
+
+func TestNextTransactionId(t *testing.T) {
+	check := []struct {
+		tid  ptp.TransactionID
+		n    uint32
+		want ptp.TransactionID
+	}{
+		{0, 1, 1},
+		{1, 1, 2},
+		{41, 64, 105},
+		{maxTransactionId, 1, minTransactionId},
+		{maxTransactionId - 1, 3, 2},
+		{0xFFFFFFFF, 1, 1},
+	}
+	for _, c := range check {
+		if got := nextTransactionId(c.tid, c.n); got != c.want {
+			t.Errorf("nextTransactionId(%#x, %d) = %#x; want %#x", c.tid, c.n, got, c.want)
+		}
+	}
+}
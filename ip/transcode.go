@@ -0,0 +1,78 @@
+package ip
+
+import (
+	"bytes"
+	"golang.org/x/image/draw"
+	"image"
+	"image/jpeg"
+)
+
+// TranscodeLiveView scales the frames down to fit within the given width and height, keeping the aspect ratio, and
+// re-encodes them using the given JPEG quality ranging from 1 to 100, which keeps the bandwidth in check when relaying
+// the live view over the internet. A width or height of 0 leaves that dimension unbounded and a quality of 0 uses the
+// default quality of the image/jpeg package. Frames are never scaled up. The frames passed on are copies, so frames
+// received from the frames channel are left untouched. The returned channel is closed when the frames channel is
+// closed.
+func TranscodeLiveView(frames <-chan *LiveViewFrame, width, height, quality int) <-chan *LiveViewFrame {
+	if quality == 0 {
+		quality = jpeg.DefaultQuality
+	}
+
+	out := make(chan *LiveViewFrame)
+	go func() {
+		defer close(out)
+		for f := range frames {
+			if f.Image != nil {
+				f = transcodeFrame(f, width, height, quality)
+			}
+			out <- f
+		}
+	}()
+
+	return out
+}
+
+func transcodeFrame(f *LiveViewFrame, width, height, quality int) *LiveViewFrame {
+	img := f.Image
+	if r := scaledRect(img.Bounds(), width, height); r != img.Bounds() {
+		dst := image.NewRGBA(r)
+		draw.ApproxBiLinear.Scale(dst, r, img, img.Bounds(), draw.Src, nil)
+		img = dst
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		// Better to pass on the original frame than none at all.
+		return f
+	}
+
+	tf := *f
+	tf.Image = img
+	tf.Data = buf.Bytes()
+
+	return &tf
+}
+
+// scaledRect returns the rectangle, starting at the origin, fitting the bounds within the given width and height.
+func scaledRect(b image.Rectangle, width, height int) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if width > 0 && w > width {
+		h = h * width / w
+		w = width
+	}
+	if height > 0 && h > height {
+		w = w * height / h
+		h = height
+	}
+	if w == b.Dx() && h == b.Dy() {
+		return b
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	return image.Rect(0, 0, w, h)
+}
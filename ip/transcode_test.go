@@ -0,0 +1,53 @@
+package ip
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestScaledRect(t *testing.T) {
+	b := image.Rect(0, 0, 640, 480)
+	check := []struct {
+		width  int
+		height int
+		want   image.Rectangle
+	}{
+		{320, 0, image.Rect(0, 0, 320, 240)},
+		{0, 120, image.Rect(0, 0, 160, 120)},
+		{320, 120, image.Rect(0, 0, 160, 120)},
+		{1280, 960, b},
+		{0, 0, b},
+	}
+	for _, c := range check {
+		if got := scaledRect(b, c.width, c.height); got != c.want {
+			t.Errorf("scaledRect(%d, %d) = %v; want %v", c.width, c.height, got, c.want)
+		}
+	}
+}
+
+func TestTranscodeLiveView(t *testing.T) {
+	frames := make(chan *LiveViewFrame, 1)
+	orig := &LiveViewFrame{Sequence: 1, Image: image.NewGray(image.Rect(0, 0, 64, 32)), Data: []byte{0xff, 0xd8}}
+	frames <- orig
+	close(frames)
+
+	got := <-TranscodeLiveView(frames, 16, 0, 50)
+	if got.Sequence != 1 {
+		t.Errorf("TranscodeLiveView() Sequence = %d; want 1", got.Sequence)
+	}
+	if b := got.Image.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+		t.Errorf("TranscodeLiveView() image bounds = %v; want 16x8", b)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("TranscodeLiveView() JPEG size = %dx%d; want 16x8", cfg.Width, cfg.Height)
+	}
+	if len(orig.Data) != 2 || orig.Image.Bounds().Dx() != 64 {
+		t.Error("TranscodeLiveView() altered the original frame")
+	}
+}
@@ -0,0 +1,88 @@
+package ip
+
+import (
+	"github.com/malc0mn/ptp-ip/ip/internal"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"sync"
+)
+
+// VendorEvent describes a vendor extended event code.
+type VendorEvent struct {
+	// Name is a human readable name for the event, e.g. for logging.
+	Name string
+	// Decode turns the event into one of the typed events. When nil, the event is returned as a ptp.UnknownEvent.
+	Decode func(e ptp.Event) ptp.TypedEvent
+}
+
+var (
+	vendorEvents   = make(map[ptp.VendorExtension]map[ptp.EventCode]VendorEvent)
+	vendorEventsMu sync.RWMutex
+)
+
+func init() {
+	RegisterVendorEvent(ptp.VE_FujiPhotoFilmCoLtd, EC_Fuji_PreviewAvailable, VendorEvent{
+		Name: "PreviewAvailable",
+		Decode: func(e ptp.Event) ptp.TypedEvent {
+			return FujiPreviewAvailableEvent{TransactionID: e.TransactionID, Size: e.Parameter2}
+		},
+	})
+	RegisterVendorEvent(ptp.VE_FujiPhotoFilmCoLtd, EC_Fuji_ObjectAdded, VendorEvent{
+		Name: "ObjectAdded",
+		Decode: func(e ptp.Event) ptp.TypedEvent {
+			return FujiObjectAddedEvent{TransactionID: e.TransactionID}
+		},
+	})
+}
+
+// RegisterVendorEvent registers a vendor extended event code for the given vendor, replacing any previous registration
+// of the code. The decoder is used by the event packets of the vendor to return a typed event. Vendor extended events
+// that are not registered are logged together with their payload, so new events can be reverse engineered.
+func RegisterVendorEvent(vendor ptp.VendorExtension, code ptp.EventCode, ve VendorEvent) {
+	vendorEventsMu.Lock()
+	defer vendorEventsMu.Unlock()
+
+	if vendorEvents[vendor] == nil {
+		vendorEvents[vendor] = make(map[ptp.EventCode]VendorEvent)
+	}
+	vendorEvents[vendor][code] = ve
+}
+
+// LookupVendorEvent returns the VendorEvent registered for the given vendor and event code. The second return value is
+// false when the code was not registered.
+func LookupVendorEvent(vendor ptp.VendorExtension, code ptp.EventCode) (VendorEvent, bool) {
+	vendorEventsMu.RLock()
+	defer vendorEventsMu.RUnlock()
+
+	ve, ok := vendorEvents[vendor][code]
+
+	return ve, ok
+}
+
+// decodeVendorEvent returns the typed event for a vendor extended event using the registered decoder. The second
+// return value is false when no decoder was registered for the event code.
+func decodeVendorEvent(vendor ptp.VendorExtension, e ptp.Event) (ptp.TypedEvent, bool) {
+	ve, ok := LookupVendorEvent(vendor, e.EventCode)
+	if !ok || ve.Decode == nil {
+		return nil, false
+	}
+
+	return ve.Decode(e), true
+}
+
+// isVendorEventCode returns true when the most significant nibble of the code indicates a vendor extended event.
+func isVendorEventCode(code ptp.EventCode) bool {
+	return code&0xF000 == 0xC000
+}
+
+// logUnknownVendorEvent logs the payload of vendor extended events that were not registered.
+func (c *Client) logUnknownVendorEvent(lmp string, p EventPacket) {
+	code := p.GetEventCode()
+	if !isVendorEventCode(code) {
+		return
+	}
+	if _, ok := LookupVendorEvent(c.ResponderVendor(), code); ok {
+		return
+	}
+
+	c.Infof("%s unknown vendor event '%#x' received with payload % x", lmp, code, internal.MarshalLittleEndian(p))
+}
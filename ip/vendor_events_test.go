@@ -0,0 +1,60 @@
+package ip
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"strings"
+	"testing"
+)
+
+func TestRegisterVendorEvent(t *testing.T) {
+	const code ptp.EventCode = 0xC101
+	if _, ok := LookupVendorEvent(ptp.VE_NikonCorporation, code); ok {
+		t.Fatalf("LookupVendorEvent() ok = true; want false for unregistered code")
+	}
+
+	RegisterVendorEvent(ptp.VE_NikonCorporation, code, VendorEvent{
+		Name: "ObjectAddedInSdram",
+		Decode: func(e ptp.Event) ptp.TypedEvent {
+			return ptp.ObjectAddedEvent{Handle: ptp.ObjectHandle(e.Parameter1)}
+		},
+	})
+	defer func() {
+		vendorEventsMu.Lock()
+		delete(vendorEvents, ptp.VE_NikonCorporation)
+		vendorEventsMu.Unlock()
+	}()
+
+	ve, ok := LookupVendorEvent(ptp.VE_NikonCorporation, code)
+	if !ok || ve.Name != "ObjectAddedInSdram" {
+		t.Errorf("LookupVendorEvent() = %+v, %t; want ObjectAddedInSdram, true", ve, ok)
+	}
+	if _, ok := LookupVendorEvent(ptp.VE_FujiPhotoFilmCoLtd, code); ok {
+		t.Error("LookupVendorEvent() ok = true; want false for another vendor")
+	}
+
+	got, ok := decodeVendorEvent(ptp.VE_NikonCorporation, ptp.Event{EventCode: code, Parameter1: 7})
+	if want := (ptp.ObjectAddedEvent{Handle: 7}); !ok || got != want {
+		t.Errorf("decodeVendorEvent() = %#v, %t; want %#v, true", got, ok, want)
+	}
+}
+
+func TestClient_logUnknownVendorEvent(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := NewClient(address, WithVendor("fuji"), WithLogger(NewLogger(LevelVeryVerbose, &buf, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.logUnknownVendorEvent("[test]", &FujiEventPacket{EventCode: EC_Fuji_ObjectAdded, TransactionID: 1})
+	c.logUnknownVendorEvent("[test]", &FujiEventPacket{EventCode: ptp.EC_DevicePropChanged, TransactionID: 2})
+	if buf.Len() != 0 {
+		t.Errorf("logUnknownVendorEvent() logged %q; want nothing for known events", buf.String())
+	}
+
+	c.logUnknownVendorEvent("[test]", &FujiEventPacket{DataPhase: 0x0004, EventCode: 0xC005, Amount: 1, TransactionID: 3})
+	want := "[test] unknown vendor event '0xc005' received with payload 04 00 05 c0 01 00 00 00 03 00 00 00"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("logUnknownVendorEvent() logged %q; want %q", got, want)
+	}
+}
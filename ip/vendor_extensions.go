@@ -1,12 +1,15 @@
 package ip
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/malc0mn/ptp-ip/ip/internal"
 	"github.com/malc0mn/ptp-ip/ptp"
+	"io"
+	"time"
 )
 
 // TODO: This solution is not OK, vendors can differ massively so it seems. Should this become an interface that all
@@ -15,67 +18,133 @@ import (
 //  calls the initCommandDataConn() and initEventConn() methods but when using embedding the methods on ip.Client get
 //  called and not the ones on ip.FujiClient so you would also have to "override" the Dial() as well.
 type VendorExtensions struct {
-	cmdDataInit            func(*Client) error
-	eventInit              func(*Client) error
+	cmdDataInit            func(context.Context, *Client) error
+	eventInit              func(context.Context, *Client) error
+	openSession            func(context.Context, *Client) error
+	closeSession           func(context.Context, *Client) error
 	processStreamData      func(*Client) error
 	newCmdDataInitPacket   func(uuid.UUID, string) InitCommandRequestPacket
 	newEventInitPacket     func(uint32) InitEventRequestPacket
 	newEventPacket         func() EventPacket
 	extractTransactionId   func([]byte, connectionType) (ptp.TransactionID, error)
-	getDeviceInfo          func(*Client) (interface{}, error)
-	getDeviceState         func(*Client) (interface{}, error)
-	getDevicePropertyDesc  func(*Client, ptp.DevicePropCode) (*ptp.DevicePropDesc, error)
-	getDevicePropertyValue func(*Client, ptp.DevicePropCode) (uint32, error)
-	setDeviceProperty      func(*Client, ptp.DevicePropCode, uint32) error
-	operationRequestRaw    func(*Client, ptp.OperationCode, []uint32) ([][]byte, error)
-	initiateCapture        func(*Client) ([]byte, error)
+	readTransactionPacket  func([]byte, ptp.OperationCode) (*transactionPacket, error)
+	getDeviceInfo          func(context.Context, *Client) (interface{}, error)
+	getDeviceState         func(context.Context, *Client) (interface{}, error)
+	getDevicePropertyDesc  func(context.Context, *Client, ptp.DevicePropCode) (*ptp.DevicePropDesc, error)
+	getDevicePropertyValue func(context.Context, *Client, ptp.DevicePropCode) ([]byte, error)
+	setDeviceProperty      func(context.Context, *Client, ptp.DevicePropCode, uint32) error
+	resetDeviceProperty    func(context.Context, *Client, ptp.DevicePropCode) error
+	operationRequestRaw    func(context.Context, *Client, ptp.OperationCode, []uint32) ([][]byte, error)
+	operationRequestStream func(context.Context, *Client, ptp.OperationCode, []uint32) (io.ReadCloser, error)
+	initiateCapture        func(context.Context, *Client) ([]byte, error)
+	initiateCaptureAndWait func(context.Context, *Client) (*CaptureResult, error)
+	initiateOpenCapture    func(context.Context, *Client) (*OpenCapture, error)
+	terminateOpenCapture   func(context.Context, *Client, *OpenCapture) (*CaptureResult, error)
+	getStorageIDs          func(context.Context, *Client) ([]ptp.StorageID, error)
+	getStorageInfo         func(context.Context, *Client, ptp.StorageID) (*ptp.StorageInfo, error)
+	getObjectHandles       func(context.Context, *Client, ptp.StorageID, ptp.ObjectFormatCode, ptp.ObjectHandle) ([]ptp.ObjectHandle, error)
+	getObjectInfo          func(context.Context, *Client, ptp.ObjectHandle) (*ptp.ObjectInfo, error)
+	getObject              func(context.Context, *Client, ptp.ObjectHandle) ([]byte, error)
+	deleteObject           func(context.Context, *Client, ptp.ObjectHandle) error
+	setISO                 func(context.Context, *Client, uint32) error
+	getISO                 func(context.Context, *Client) (uint32, error)
+	getCaptureDelay        func(context.Context, *Client) (time.Duration, error)
+	driveFocus             func(context.Context, *Client, FocusDirection, uint16) error
+	setFocusPoint          func(context.Context, *Client, uint16, uint16) error
+	resetFocusPoint        func(context.Context, *Client) error
+	probe                  func(context.Context, *Client) error
+	cancelTransaction      func(*Client, ptp.TransactionID) error
 }
 
 func (c *Client) loadVendorExtensions() {
 	c.vendorExtensions = &VendorExtensions{
 		cmdDataInit:            GenericInitCommandDataConn,
 		eventInit:              GenericInitEventConn,
+		openSession:            GenericOpenSession,
+		closeSession:           GenericCloseSession,
 		processStreamData:      GenericProcessStreamData,
 		newCmdDataInitPacket:   NewInitCommandRequestPacket,
 		newEventInitPacket:     NewInitEventRequestPacket,
 		newEventPacket:         NewEventPacket,
 		extractTransactionId:   GenericExtractTransactionId,
+		readTransactionPacket:  genericReadTransactionPacket,
 		getDeviceInfo:          GenericGetDeviceInfo,
 		getDeviceState:         GenericGetDeviceState,
 		getDevicePropertyDesc:  GenericGetDevicePropertyDesc,
 		getDevicePropertyValue: GenericGetDevicePropertyValue,
 		setDeviceProperty:      GenericSetDeviceProperty,
+		resetDeviceProperty:    GenericResetDeviceProperty,
 		operationRequestRaw:    GenericOperationRequestRaw,
+		operationRequestStream: GenericOperationRequestStream,
 		initiateCapture:        GenericInitiateCapture,
+		initiateCaptureAndWait: GenericInitiateCaptureAndWait,
+		initiateOpenCapture:    GenericInitiateOpenCapture,
+		terminateOpenCapture:   GenericTerminateOpenCapture,
+		getStorageIDs:          GenericGetStorageIDs,
+		getStorageInfo:         GenericGetStorageInfo,
+		getObjectHandles:       GenericGetObjectHandles,
+		getObjectInfo:          GenericGetObjectInfo,
+		getObject:              GenericGetObject,
+		deleteObject:           GenericDeleteObject,
+		setISO:                 GenericSetISO,
+		getISO:                 GenericGetISO,
+		getCaptureDelay:        GenericGetCaptureDelay,
+		driveFocus:             GenericDriveFocus,
+		setFocusPoint:          GenericSetFocusPoint,
+		resetFocusPoint:        GenericResetFocusPoint,
+		probe:                  GenericProbe,
+		cancelTransaction:      GenericCancelTransaction,
 	}
 
 	switch c.ResponderVendor() {
 	case ptp.VE_FujiPhotoFilmCoLtd:
 		c.vendorExtensions.cmdDataInit = FujiInitCommandDataConn
+		c.vendorExtensions.openSession = FujiOpenSession
+		c.vendorExtensions.closeSession = FujiCloseSession
 		c.vendorExtensions.processStreamData = FujiProcessStreamData
 		c.vendorExtensions.newCmdDataInitPacket = NewFujiInitCommandRequestPacket
 		c.vendorExtensions.newEventInitPacket = NewFujiInitEventRequestPacket
 		c.vendorExtensions.newEventPacket = NewFujiEventPacket
 		c.vendorExtensions.extractTransactionId = FujiExtractTransactionId
+		c.vendorExtensions.readTransactionPacket = fujiReadTransactionPacket
 		c.vendorExtensions.getDeviceInfo = FujiGetDeviceInfo
 		c.vendorExtensions.getDeviceState = FujiGetDeviceState
 		c.vendorExtensions.getDevicePropertyDesc = FujiGetDevicePropertyDesc
 		c.vendorExtensions.getDevicePropertyValue = FujiGetDevicePropertyValue
 		c.vendorExtensions.setDeviceProperty = FujiSetDeviceProperty
+		c.vendorExtensions.resetDeviceProperty = FujiResetDeviceProperty
 		c.vendorExtensions.operationRequestRaw = FujiSendOperationRequestAndGetRawResponse
+		c.vendorExtensions.operationRequestStream = FujiOperationRequestStream
 		c.vendorExtensions.initiateCapture = FujiInitiateCapture
+		c.vendorExtensions.initiateCaptureAndWait = FujiInitiateCaptureAndWait
+		c.vendorExtensions.initiateOpenCapture = FujiInitiateOpenCapture
+		c.vendorExtensions.terminateOpenCapture = FujiTerminateOpenCapture
+		c.vendorExtensions.getStorageIDs = FujiGetStorageIDs
+		c.vendorExtensions.getStorageInfo = FujiGetStorageInfo
+		c.vendorExtensions.getObjectHandles = FujiGetObjectHandles
+		c.vendorExtensions.getObjectInfo = FujiGetObjectInfo
+		c.vendorExtensions.getObject = FujiGetObject
+		c.vendorExtensions.deleteObject = FujiDeleteObject
+		c.vendorExtensions.setISO = FujiSetISO
+		c.vendorExtensions.getISO = FujiGetISO
+		c.vendorExtensions.getCaptureDelay = FujiGetCaptureDelay
+		c.vendorExtensions.driveFocus = FujiDriveFocus
+		c.vendorExtensions.setFocusPoint = FujiSetFocusPoint
+		c.vendorExtensions.resetFocusPoint = FujiResetFocusPoint
+		c.vendorExtensions.probe = FujiProbe
+		c.vendorExtensions.cancelTransaction = FujiCancelTransaction
 	}
 }
 
 // GenericInitCommandDataConn initiates the command/data connection. It expects an open TCP connection to the
 // command/data port to be present.
-func GenericInitCommandDataConn(c *Client) error {
+func GenericInitCommandDataConn(ctx context.Context, c *Client) error {
 	err := c.SendPacketToCmdDataConn(c.newCmdDataInitPacket())
 	if err != nil {
 		return err
 	}
 
-	res, _, err := c.waitForPacketFromCmdDataConn(nil)
+	res, _, err := c.waitForPacketFromCmdDataConn(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -95,15 +164,17 @@ func GenericInitCommandDataConn(c *Client) error {
 	}
 
 	c.Infoln("Closing Command/Data connection!")
-	c.commandDataConn.Close()
+	c.conn(cmdDataConnection).Close()
 	return err
 }
 
-// GenericInitEventConn initiates the event connection.
-func GenericInitEventConn(c *Client) error {
-	var err error
-
-	c.eventConn, err = internal.RetryDialer(c.Network(), c.EventAddress(), DefaultDialTimeout)
+// GenericInitEventConn initiates the event connection. The Initiator identifies itself on the event connection by
+// sending an InitEventRequestPacket holding the connection number received in the InitCommandAckPacket, which allows the
+// Responder to link both connections. The Responder accepts by sending an InitEventAckPacket.
+// Vendors that do not return an InitEventRequestPacket skip this part and only connect to the event port.
+func GenericInitEventConn(ctx context.Context, c *Client) error {
+	conn, err := c.dialAddress(ctx, c.EventAddress())
+	c.setConn(eventConnection, conn)
 	if err != nil {
 		return err
 	}
@@ -120,7 +191,7 @@ func GenericInitEventConn(c *Client) error {
 		return err
 	}
 
-	res, _, err := c.waitForPacketFromEventConn(nil)
+	res, _, err := c.waitForPacketFromEventConn(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -136,10 +207,55 @@ func GenericInitEventConn(c *Client) error {
 	}
 
 	c.Infoln("Closing Event connection!")
-	c.eventConn.Close()
+	conn.Close()
 	return err
 }
 
+// GenericOpenSession opens a session on the Responder which is the next step in the PTP/IP standard after the event
+// connection was established: apart from GetDeviceInfo, no operation is allowed outside of a session. A session that is
+// already open is accepted as well. OpenSession is sent using transaction ID 0 since it runs outside of a session.
+func GenericOpenSession(ctx context.Context, c *Client) error {
+	c.Info("Opening a session...")
+	p, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.OpenSession(1))
+	if p != nil && p.ResponseCode == ptp.RC_SessionAlreadyOpen {
+		return nil
+	}
+
+	return err
+}
+
+// GenericCloseSession closes the session, which makes the Responder perform any session specific cleanup and return to
+// its normal operation.
+func GenericCloseSession(ctx context.Context, c *Client) error {
+	c.Info("Closing the session...")
+	_, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.CloseSession())
+
+	return err
+}
+
+// GenericProbe sends a ProbeRequestPacket over the event connection and waits for the Responder to answer it with a
+// ProbeResponsePacket.
+func GenericProbe(ctx context.Context, c *Client) error {
+	// Drop a late response to a previous probe so it cannot be mistaken for the response to this one.
+	select {
+	case <-c.probeChan:
+	default:
+	}
+
+	if err := c.SendPacketToEventConn(&ProbeRequestPacket{}); err != nil {
+		return err
+	}
+
+	select {
+	case <-c.probeChan:
+		return nil
+	case <-after(c.readTimeout(ctx)):
+		return WaitForProbeError
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // GenericProcessStreamData does absolutely nothing since the standard PTP/IP protocol does not have a streamer
 // connection.
 func GenericProcessStreamData(_ *Client) error {
@@ -147,82 +263,183 @@ func GenericProcessStreamData(_ *Client) error {
 }
 
 // GenericExtractTransactionId extracts the transaction ID from a full raw inbound packet. This packet must include the
-// full header containing length and packet type.
+// full header containing length and packet type. A TruncatedPacketError is returned when the packet is too short to
+// hold the transaction ID and an UnexpectedPacketError when the packet type does not carry one.
 func GenericExtractTransactionId(p []byte, _ connectionType) (ptp.TransactionID, error) {
-	if len(p) < 13 {
-		return 0, fmt.Errorf("packet too small: got length %d", len(p))
+	if len(p) < HeaderSize {
+		return 0, fmt.Errorf("%w: got length %d, need at least %d", TruncatedPacketError, len(p), HeaderSize)
 	}
 
-	var data []byte
+	var offset int
 	pt := PacketType(binary.LittleEndian.Uint32(p[4:8]))
 	switch pt {
 	case PKT_OperationResponse, PKT_Event:
-		data = p[10:14]
+		offset = 10
 	case PKT_StartData, PKT_Data, PKT_EndData, PKT_Cancel:
-		data = p[8:12]
-		// TODO: PKT_ProbeRequest and PKT_ProbeResponse do not have a transaction ID, how to handle those?
+		offset = 8
+	default:
+		return 0, fmt.Errorf("%w: packet type %#x has no transaction ID", UnexpectedPacketError, pt)
 	}
 
-	return ptp.TransactionID(binary.LittleEndian.Uint32(data)), nil
+	return readTransactionId(p, offset)
 }
 
-// Request the Responder's device information.
-func GenericGetDeviceInfo(c *Client) (interface{}, error) {
-	tid := c.incrementTransactionId()
+// readTransactionId reads the transaction ID found at the given offset of the raw packet.
+func readTransactionId(p []byte, offset int) (ptp.TransactionID, error) {
+	if len(p) < offset+4 {
+		return 0, fmt.Errorf("%w: got length %d, need at least %d", TruncatedPacketError, len(p), offset+4)
+	}
+
+	return ptp.TransactionID(binary.LittleEndian.Uint32(p[offset : offset+4])), nil
+}
 
-	resCh := make(chan []byte, 2)
-	defer close(resCh)
-	if err := c.subscribe(tid, resCh); err != nil {
+// genericReadTransactionPacket interprets a packet received during a transaction using the packet type in its header.
+func genericReadTransactionPacket(raw []byte, _ ptp.OperationCode) (*transactionPacket, error) {
+	p, _, err := parsePacket(raw, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	err := c.SendPacketToCmdDataConn(&OperationRequestPacket{
-		DataPhaseInfo:    DP_NoDataOrDataIn,
-		OperationRequest: ptp.GetDeviceInfo(tid),
-	})
+	switch pkt := p.(type) {
+	case *StartDataPacket:
+		return &transactionPacket{kind: pkStartData, length: pkt.TotalDataLength}, nil
+	case *DataPacket:
+		return &transactionPacket{kind: pkData, payload: pkt.DataPayload}, nil
+	case *EndDataPacket:
+		return &transactionPacket{kind: pkEndData, payload: pkt.DataPayload}, nil
+	case *CancelPacket:
+		return &transactionPacket{kind: pkCancel}, nil
+	case *OperationResponsePacket:
+		if len(raw) < HeaderSize+6 {
+			return nil, InvalidPacketError
+		}
+		// The parameters are kept as they were received: the response code and the transaction ID precede them.
+		return &transactionPacket{kind: pkResponse, code: pkt.ResponseCode, payload: raw[HeaderSize+6:]}, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", UnexpectedPacketError, p)
+	}
+}
 
+// GenericGetDeviceInfo requests the Responder's device information and returns it as a *ptp.DeviceInfo.
+func GenericGetDeviceInfo(ctx context.Context, c *Client) (interface{}, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetDeviceInfo(0))
 	if err != nil {
 		return nil, err
 	}
 
-	res, _, err := c.WaitForPacketFromCommandDataSubscriber(resCh, nil)
+	return ptp.ReadDeviceInfo(bytes.NewReader(data))
+}
+
+// GenericGetDeviceState requests the Responder's device status.
+func GenericGetDeviceState(_ context.Context, _ *Client) (interface{}, error) {
+	return nil, errors.New("command not supported")
+}
+
+// GenericGetDevicePropertyDesc requests the description of the given property from the Responder.
+func GenericGetDevicePropertyDesc(ctx context.Context, c *Client, dpc ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetDevicePropDesc(dpc))
 	if err != nil {
 		return nil, err
 	}
 
-	switch pkt := res.(type) {
-	case *OperationResponsePacket:
-		return pkt, nil
-	default:
-		err = fmt.Errorf("unexpected packet received %T", res)
+	return ptp.ReadDevicePropDesc(bytes.NewReader(data))
+}
+
+// GenericGetDevicePropertyValue requests the raw value for the given property from the Responder.
+func GenericGetDevicePropertyValue(ctx context.Context, c *Client, dpc ptp.DevicePropCode) ([]byte, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetDevicePropValue(dpc))
+
+	return data, err
+}
+
+// GenericSetDeviceProperty sets the value for the given property on the Responder.
+// The property's description is requested first to determine the size of the value to send during the data phase.
+func GenericSetDeviceProperty(ctx context.Context, c *Client, dpc ptp.DevicePropCode, val uint32) error {
+	dpd, err := GenericGetDevicePropertyDesc(ctx, c, dpc)
+	if err != nil {
+		return err
 	}
 
-	return nil, err
+	size := dpd.SizeOfValueInBytes()
+	if size < 1 || size > 4 {
+		return fmt.Errorf("unsupported data type %#x for property %#x", dpd.DataType, dpc)
+	}
+
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, val)
+	_, err = GenericSendOperationRequestWithData(ctx, c, ptp.SetDevicePropValue(dpc, val), b[:size])
+
+	return err
 }
 
-// GenericGetDeviceState requests the Responder's device status.
-func GenericGetDeviceState(_ *Client) (interface{}, error) {
-	return nil, errors.New("command not supported")
+// GenericResetDeviceProperty restores the factory default value for the given property on the Responder.
+func GenericResetDeviceProperty(ctx context.Context, c *Client, dpc ptp.DevicePropCode) error {
+	_, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.ResetDevicePropValue(dpc))
+
+	return err
+}
+
+// GenericSetISO sets ptp.DPC_ExposureIndex to the given ISO value.
+func GenericSetISO(ctx context.Context, c *Client, iso uint32) error {
+	v := int64(iso)
+	if iso == 0 {
+		v = int64(autoExposureIndex)
+	}
+
+	return c.setValidatedDeviceProperty(ctx, ptp.DPC_ExposureIndex, v)
 }
 
-// GenericGetDevicePropertyValue requests the value for the given property from the Responder's.
-func GenericGetDevicePropertyDesc(c *Client, dpc ptp.DevicePropCode) (*ptp.DevicePropDesc, error) {
-	return nil, errors.New("command not YET supported")
+// GenericGetISO returns the ISO value using ptp.DPC_ExposureIndex.
+func GenericGetISO(ctx context.Context, c *Client) (uint32, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_ExposureIndex)
+	if err != nil || uint16(v) == autoExposureIndex {
+		return 0, err
+	}
+
+	return v, nil
 }
 
-// GenericGetDevicePropertyValue requests the value for the given property from the Responder's.
-func GenericGetDevicePropertyValue(c *Client, dpc ptp.DevicePropCode) (uint32, error) {
-	return 0, errors.New("command not YET supported")
+// GenericGetCaptureDelay returns ptp.DPC_CaptureDelay which holds the delay in milliseconds.
+func GenericGetCaptureDelay(ctx context.Context, c *Client) (time.Duration, error) {
+	v, err := c.getDevicePropertyValueAsUint32(ctx, ptp.DPC_CaptureDelay)
+
+	return time.Duration(v) * time.Millisecond, err
 }
 
-// GenericSetDeviceProperty sets the value for the given property on the Responder.
-func GenericSetDeviceProperty(c *Client, dpc ptp.DevicePropCode, val uint32) error {
-	return errors.New("command not YET supported")
+func GenericOperationRequestRaw(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) ([][]byte, error) {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer t.end()
+
+	if err := c.SendPacketToCmdDataConn(genericOperationRequestPacket(t.id, code, params)); err != nil {
+		return nil, err
+	}
+
+	res, err := t.readResult(ctx, code)
+
+	return res.raw, err
 }
 
-func GenericOperationRequestRaw(c *Client, code ptp.OperationCode, params []uint32) ([][]byte, error) {
-	tid := c.incrementTransactionId()
+// GenericOperationRequestStream sends an operation request with up to five parameters and returns an io.ReadCloser over
+// the data sent by the Responder during the data-in phase.
+func GenericOperationRequestStream(ctx context.Context, c *Client, code ptp.OperationCode, params []uint32) (io.ReadCloser, error) {
+	t, err := c.beginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.SendPacketToCmdDataConn(genericOperationRequestPacket(t.id, code, params)); err != nil {
+		t.end()
+		return nil, err
+	}
 
+	return newDataPhaseReader(ctx, t, code, genericResultAsError), nil
+}
+
+// genericOperationRequestPacket creates an operation request packet with up to five parameters without data-out phase.
+func genericOperationRequestPacket(tid ptp.TransactionID, code ptp.OperationCode, params []uint32) *OperationRequestPacket {
 	or := ptp.OperationRequest{
 		OperationCode: code,
 		TransactionID: tid,
@@ -245,32 +462,153 @@ func GenericOperationRequestRaw(c *Client, code ptp.OperationCode, params []uint
 	if len(params) == 5 {
 		or.Parameter5 = params[4]
 	}
-	resCh := make(chan []byte, 2)
-	if err := c.subscribe(tid, resCh); err != nil {
-		return nil, err
-	}
-	defer c.unsubscribe(tid)
 
-	err := c.SendPacketToCmdDataConn(&OperationRequestPacket{
+	return &OperationRequestPacket{
 		DataPhaseInfo:    DP_NoDataOrDataIn,
 		OperationRequest: or,
+	}
+}
+
+// GenericCancelTransaction sends a CancelPacket for the given transaction over the event connection. The command/data
+// connection can be busy transferring the data of the transaction, so the event connection is used to make sure the
+// Responder gets the request right away.
+func GenericCancelTransaction(c *Client, tid ptp.TransactionID) error {
+	return c.SendPacketToEventConn(&CancelPacket{TransactionId: tid})
+}
+
+// GenericSendOperationRequestAndGetResponse sends an operation request to the Responder and collects the data of the
+// data-in phase, if there is one, until the operation response packet is received. The transaction ID of the request
+// will be set for you, which is 0 for OpenSession. When the Responder does not return ptp.RC_OK, the response packet is returned together with an
+// error.
+func GenericSendOperationRequestAndGetResponse(ctx context.Context, c *Client, or ptp.OperationRequest) (*OperationResponsePacket, []byte, error) {
+	return genericTransaction(ctx, c, or, nil)
+}
+
+// GenericSendOperationRequestWithData sends an operation request to the Responder followed by a data-out phase
+// transferring the given data. The transaction ID of the request will be set for you. When the Responder does not
+// return ptp.RC_OK, the response packet is returned together with an error.
+func GenericSendOperationRequestWithData(ctx context.Context, c *Client, or ptp.OperationRequest, data []byte) (*OperationResponsePacket, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	p, _, err := genericTransaction(ctx, c, or, data)
+
+	return p, err
+}
+
+// genericTransaction runs a complete transaction: the operation request, the data-out phase when dataOut is not nil, the
+// data-in phase when the Responder has data to send and finally the operation response. The transaction is retried
+// according to the RetryPolicy of the client.
+func genericTransaction(ctx context.Context, c *Client, or ptp.OperationRequest, dataOut []byte) (*OperationResponsePacket, []byte, error) {
+	var (
+		p    *OperationResponsePacket
+		data []byte
+	)
+	err := c.retry(ctx, func(_ int) error {
+		var err error
+		p, data, err = genericTransactionOnce(ctx, c, or, dataOut)
+		return err
 	})
 
+	return p, data, err
+}
+
+func genericTransactionOnce(ctx context.Context, c *Client, or ptp.OperationRequest, dataOut []byte) (*OperationResponsePacket, []byte, error) {
+	begin := c.beginTransaction
+	if or.OperationCode == ptp.OC_OpenSession {
+		begin = c.beginSessionlessTransaction
+	}
+	t, err := begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer t.end()
+	or.TransactionID = t.id
+
+	dp := DP_NoDataOrDataIn
+	if dataOut != nil {
+		dp = DP_DataOut
+	}
+	if err := c.SendPacketToCmdDataConn(&OperationRequestPacket{
+		DataPhaseInfo:    dp,
+		OperationRequest: or,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if dataOut != nil {
+		if err := t.sendData(ctx, dataOut); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res, err := t.readResult(ctx, or.OperationCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := new(OperationResponsePacket)
+	if _, _, err := parsePacket(res.raw[len(res.raw)-1], p); err != nil {
+		return nil, nil, err
+	}
+	if p.ResponseCode != ptp.RC_OK {
+		return p, nil, ptp.NewResponseError(p.ResponseCode, or.OperationCode, t.id)
+	}
+
+	return p, res.data, nil
+}
+
+// GenericGetStorageIDs requests the list of StorageIDs from the Responder.
+func GenericGetStorageIDs(ctx context.Context, c *Client) ([]ptp.StorageID, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetStorageIDs())
 	if err != nil {
 		return nil, err
 	}
 
-	var raw [][]byte
-	raw[0], err = c.WaitForRawPacketFromCommandDataSubscriber(resCh)
+	return ptp.ReadStorageIDs(bytes.NewReader(data))
+}
+
+// GenericGetStorageInfo requests the StorageInfo dataset for the given StorageID from the Responder.
+func GenericGetStorageInfo(ctx context.Context, c *Client, sid ptp.StorageID) (*ptp.StorageInfo, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetStorageInfo(sid))
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: handle possible followup packets depending on the data phase returned.
+	return ptp.ReadStorageInfo(bytes.NewReader(data))
+}
+
+// GenericGetObjectHandles requests the list of ObjectHandles from the Responder. See ptp.GetObjectHandles() for the
+// meaning of the parameters.
+func GenericGetObjectHandles(ctx context.Context, c *Client, sid ptp.StorageID, ofc ptp.ObjectFormatCode, parent ptp.ObjectHandle) ([]ptp.ObjectHandle, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetObjectHandles(sid, ofc, parent))
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadObjectHandles(bytes.NewReader(data))
+}
+
+// GenericGetObjectInfo requests the ObjectInfo dataset for the given ObjectHandle from the Responder.
+func GenericGetObjectInfo(ctx context.Context, c *Client, h ptp.ObjectHandle) (*ptp.ObjectInfo, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetObjectInfo(h))
+	if err != nil {
+		return nil, err
+	}
+
+	return ptp.ReadObjectInfo(bytes.NewReader(data))
+}
+
+// GenericGetObject retrieves the object with the given ObjectHandle from the Responder.
+func GenericGetObject(ctx context.Context, c *Client, h ptp.ObjectHandle) ([]byte, error) {
+	_, data, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.GetObject(h))
 
-	return raw, err
+	return data, err
 }
 
-func GenericInitiateCapture(c *Client) ([]byte, error) {
-	return nil, errors.New("command not YET supported")
+// GenericDeleteObject deletes the object with the given ObjectHandle from the Responder.
+func GenericDeleteObject(ctx context.Context, c *Client, h ptp.ObjectHandle) error {
+	_, _, err := GenericSendOperationRequestAndGetResponse(ctx, c, ptp.DeleteObject(h, 0))
+
+	return err
 }
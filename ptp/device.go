@@ -1,5 +1,14 @@
 package ptp
 
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+)
+
 type DataTypeCode uint16
 
 // The most significant nibble (4 bits) is used to indicate the category of the code and whether the code value is
@@ -224,6 +233,45 @@ const (
 	DTC_STR DataTypeCode = 0xFFFF
 )
 
+// Size returns the size in bytes of a single value of the data type. Arrays and strings have a variable size in which
+// case 0 is returned.
+func (dtc DataTypeCode) Size() int {
+	switch dtc {
+	case DTC_INT8, DTC_UINT8:
+		return 1
+	case DTC_INT16, DTC_UINT16:
+		return 2
+	case DTC_INT32, DTC_UINT32:
+		return 4
+	case DTC_INT64, DTC_UINT64:
+		return 8
+	case DTC_INT128, DTC_UINT128:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// IsArray indicates if the data type is an array of integers.
+func (dtc DataTypeCode) IsArray() bool {
+	return dtc >= DTC_AINT8 && dtc <= DTC_AUINT128
+}
+
+// ElementType returns the data type of the elements of an array type. For any other type, the type itself is returned.
+func (dtc DataTypeCode) ElementType() DataTypeCode {
+	if dtc.IsArray() {
+		return dtc &^ 0x4000
+	}
+
+	return dtc
+}
+
+// IsSigned indicates if the data type is a signed integer or an array of signed integers.
+func (dtc DataTypeCode) IsSigned() bool {
+	e := dtc.ElementType()
+	return e >= DTC_INT8 && e <= DTC_UINT128 && e%2 == 1
+}
+
 type DevicePropDesc struct {
 	// DevicePropertyCode is a specific DevicePropCode
 	DevicePropertyCode DevicePropCode
@@ -241,19 +289,10 @@ type DevicePropDesc struct {
 	Form Form
 }
 
+// SizeOfValueInBytes returns the size of a single value of the property. Arrays and strings have a variable size in
+// which case 0 is returned.
 func (dpd *DevicePropDesc) SizeOfValueInBytes() int {
-	switch dpd.DataType {
-	case DTC_INT8, DTC_UINT8:
-		return 1
-	case DTC_INT16, DTC_UINT16:
-		return 2
-	case DTC_INT32, DTC_UINT32:
-		return 4
-	case DTC_INT64, DTC_UINT64:
-		return 8
-	default:
-		return 0
-	}
+	return dpd.DataType.Size()
 }
 
 func (dpd *DevicePropDesc) FactoryDefaultValueAsInt64() int64 {
@@ -264,6 +303,82 @@ func (dpd *DevicePropDesc) CurrentValueAsInt64() int64 {
 	return byteArrayToInt64(dpd.CurrentValue, dpd.SizeOfValueInBytes())
 }
 
+// ValidateValue checks if the given value is allowed by the property's form. Read-only properties never accept a new
+// value.
+func (dpd *DevicePropDesc) ValidateValue(v int64) error {
+	if dpd.GetSet != DPD_GetSet {
+		return fmt.Errorf("property %#x is read-only", dpd.DevicePropertyCode)
+	}
+
+	switch form := dpd.Form.(type) {
+	case *RangeForm:
+		min := dpd.valueAsInt64(form.MinimumValue)
+		max := dpd.valueAsInt64(form.MaximumValue)
+		step := dpd.valueAsInt64(form.StepSize)
+		if v < min || v > max || (step > 0 && (v-min)%step != 0) {
+			return fmt.Errorf("value %d for property %#x not in range %d to %d with step %d", v, dpd.DevicePropertyCode, min, max, step)
+		}
+	case *EnumerationForm:
+		for _, sv := range form.SupportedValues {
+			if dpd.valueAsInt64(sv) == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %d for property %#x is not supported", v, dpd.DevicePropertyCode)
+	}
+
+	return nil
+}
+
+// SupportedValues returns the values listed by the Enumeration-Form, or the values from the minimum to the maximum
+// value of the Range-Form, taking the sign of the data type into account. Nil is returned when the property has no
+// form or when the range holds more than limit values.
+func (dpd *DevicePropDesc) SupportedValues(limit int) []int64 {
+	switch form := dpd.Form.(type) {
+	case *RangeForm:
+		min := dpd.valueAsInt64(form.MinimumValue)
+		max := dpd.valueAsInt64(form.MaximumValue)
+		step := dpd.valueAsInt64(form.StepSize)
+		if step < 1 || max < min || (max-min)/step >= int64(limit) {
+			return nil
+		}
+		var values []int64
+		for v := min; v <= max; v += step {
+			values = append(values, v)
+		}
+		return values
+	case *EnumerationForm:
+		values := make([]int64, len(form.SupportedValues))
+		for i, sv := range form.SupportedValues {
+			values[i] = dpd.valueAsInt64(sv)
+		}
+		return values
+	}
+
+	return nil
+}
+
+// SignExtend interprets v as a value of the property's data type, e.g. 0xfd2d becomes -723 for a DTC_INT16 property.
+// Unsigned values are returned as is.
+func (dpd *DevicePropDesc) SignExtend(v int64) int64 {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(v))
+
+	return dpd.valueAsInt64(b)
+}
+
+// valueAsInt64 converts a raw value to an int64 taking the sign of the data type into account.
+func (dpd *DevicePropDesc) valueAsInt64(b []byte) int64 {
+	size := dpd.SizeOfValueInBytes()
+	v := byteArrayToInt64(b, size)
+	if dpd.DataType.IsSigned() && size > 0 && size < 8 {
+		shift := uint(64 - size*8)
+		v = v << shift >> shift
+	}
+
+	return v
+}
+
 type Form interface {
 	SetDevicePropDesc(*DevicePropDesc)
 }
@@ -318,6 +433,182 @@ func (ef *EnumerationForm) SupportedValuesAsInt64Array() []int64 {
 	return a
 }
 
+// ReadDevicePropDesc reads a DevicePropDesc dataset as it is sent by the Responder during the data phase of a
+// GetDevicePropDesc operation. The values are read according to the DataTypeCode of the property. Values of a variable
+// sized type, i.e. arrays and strings, hold their complete wire representation including the length prefix so they can
+// be sent back to the Responder as is.
+func ReadDevicePropDesc(r io.Reader) (*DevicePropDesc, error) {
+	dpd := new(DevicePropDesc)
+	for _, f := range []interface{}{&dpd.DevicePropertyCode, &dpd.DataType, &dpd.GetSet} {
+		if err := binary.Read(r, binary.LittleEndian, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if dpd.FactoryDefaultValue, err = ReadValue(r, dpd.DataType); err != nil {
+		return nil, err
+	}
+	if dpd.CurrentValue, err = ReadValue(r, dpd.DataType); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &dpd.FormFlag); err != nil {
+		return nil, err
+	}
+
+	switch dpd.FormFlag {
+	case DPF_FormFlag_Range:
+		form := new(RangeForm)
+		form.SetDevicePropDesc(dpd)
+		for _, v := range []*[]byte{&form.MinimumValue, &form.MaximumValue, &form.StepSize} {
+			if *v, err = ReadValue(r, dpd.DataType); err != nil {
+				return nil, err
+			}
+		}
+		dpd.Form = form
+	case DPF_FormFlag_Enum:
+		form := new(EnumerationForm)
+		form.SetDevicePropDesc(dpd)
+
+		var num uint16
+		if err := binary.Read(r, binary.LittleEndian, &num); err != nil {
+			return nil, err
+		}
+		form.NumberOfValues = int(num)
+
+		form.SupportedValues = make([][]byte, form.NumberOfValues)
+		for i := range form.SupportedValues {
+			if form.SupportedValues[i], err = ReadValue(r, dpd.DataType); err != nil {
+				return nil, err
+			}
+		}
+		dpd.Form = form
+	}
+
+	return dpd, nil
+}
+
+// ReadValue reads a single value of the given data type and returns its raw wire representation. For arrays, this
+// includes the uint32 element count, for strings the uint8 character count.
+func ReadValue(r io.Reader, dtc DataTypeCode) ([]byte, error) {
+	var (
+		prefix []byte
+		size   uint64
+	)
+
+	switch {
+	case dtc == DTC_STR:
+		prefix = make([]byte, 1)
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			return nil, err
+		}
+		size = uint64(prefix[0]) * 2
+	case dtc.IsArray():
+		prefix = make([]byte, 4)
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			return nil, err
+		}
+		size = uint64(binary.LittleEndian.Uint32(prefix)) * uint64(dtc.ElementType().Size())
+	case dtc.Size() > 0:
+		size = uint64(dtc.Size())
+	default:
+		return nil, fmt.Errorf("unsupported data type %#x", dtc)
+	}
+
+	// The element count is sent by the Responder so the size is only known to be correct once the data has been read.
+	b, err := readBytes(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(prefix, b...), nil
+}
+
+// Int128 holds a DTC_INT128 value. The least significant 64 bits come first so the type can be read and written using
+// encoding/binary in little endian byte order.
+type Int128 struct {
+	Lo uint64
+	Hi uint64
+}
+
+// BigInt converts the value to a big.Int.
+func (i Int128) BigInt() *big.Int {
+	v := Uint128(i).BigInt()
+	if int64(i.Hi) < 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+
+	return v
+}
+
+// Uint128 holds a DTC_UINT128 value. The least significant 64 bits come first so the type can be read and written
+// using encoding/binary in little endian byte order.
+type Uint128 struct {
+	Lo uint64
+	Hi uint64
+}
+
+// BigInt converts the value to a big.Int.
+func (u Uint128) BigInt() *big.Int {
+	v := new(big.Int).SetUint64(u.Hi)
+
+	return v.Lsh(v, 64).Or(v, new(big.Int).SetUint64(u.Lo))
+}
+
+// goTypes maps the integer data types to their Go counterpart.
+var goTypes = map[DataTypeCode]reflect.Type{
+	DTC_INT8:    reflect.TypeOf(int8(0)),
+	DTC_UINT8:   reflect.TypeOf(uint8(0)),
+	DTC_INT16:   reflect.TypeOf(int16(0)),
+	DTC_UINT16:  reflect.TypeOf(uint16(0)),
+	DTC_INT32:   reflect.TypeOf(int32(0)),
+	DTC_UINT32:  reflect.TypeOf(uint32(0)),
+	DTC_INT64:   reflect.TypeOf(int64(0)),
+	DTC_UINT64:  reflect.TypeOf(uint64(0)),
+	DTC_INT128:  reflect.TypeOf(Int128{}),
+	DTC_UINT128: reflect.TypeOf(Uint128{}),
+}
+
+// DecodeValue converts a raw value, as returned by ReadValue(), to a Go value of the matching type: DTC_UINT16 becomes a
+// uint16, DTC_AINT32 a []int32, DTC_UINT128 a Uint128 and DTC_STR a string.
+func DecodeValue(b []byte, dtc DataTypeCode) (interface{}, error) {
+	r := bytes.NewReader(b)
+
+	if dtc == DTC_STR {
+		return ReadString(r)
+	}
+
+	t, ok := goTypes[dtc.ElementType()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported data type %#x", dtc)
+	}
+
+	if !dtc.IsArray() {
+		v := reflect.New(t)
+		if err := binary.Read(r, binary.LittleEndian, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	// Do not trust the element count blindly: make sure the data is actually there before allocating anything.
+	if uint64(n)*uint64(t.Size()) > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	v := reflect.MakeSlice(reflect.SliceOf(t), int(n), int(n))
+	if err := binary.Read(r, binary.LittleEndian, v.Interface()); err != nil {
+		return nil, err
+	}
+
+	return v.Interface(), nil
+}
+
 // DeviceInfo is used to hold the description information for a device. The Initiator can obtain this dataset from the
 // Responder without opening a session with the device. This dataset holds data that describes the device and its
 // capabilities. This information is only static if the device capabilities cannot change during a session, which would
@@ -397,3 +688,73 @@ type DeviceInfo struct {
 	// field for one device infers that this field is non-zero and unique among all devices of that model and version.
 	SerialNumber string
 }
+
+// SupportsOperation indicates if the given operation is listed in OperationsSupported.
+func (di *DeviceInfo) SupportsOperation(oc OperationCode) bool {
+	for _, v := range di.OperationsSupported {
+		if v == oc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsEvent indicates if the given event is listed in EventsSupported.
+func (di *DeviceInfo) SupportsEvent(ec EventCode) bool {
+	for _, v := range di.EventsSupported {
+		if v == ec {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportsDeviceProperty indicates if the given device property is listed in DevicePropertiesSupported.
+func (di *DeviceInfo) SupportsDeviceProperty(dpc DevicePropCode) bool {
+	for _, v := range di.DevicePropertiesSupported {
+		if v == dpc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadDeviceInfo reads a DeviceInfo dataset as it is returned in the data phase of a GetDeviceInfo operation.
+func ReadDeviceInfo(r io.Reader) (*DeviceInfo, error) {
+	di := new(DeviceInfo)
+
+	for _, f := range []interface{}{&di.StandardVersion, &di.VendorExtensionID, &di.VendorExtensionVersion} {
+		if err := binary.Read(r, binary.LittleEndian, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if di.VendorExtensionDesc, err = ReadString(r); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &di.FunctionalMode); err != nil {
+		return nil, err
+	}
+
+	for _, a := range []interface{}{
+		&di.OperationsSupported, &di.EventsSupported, &di.DevicePropertiesSupported, &di.CaptureFormats,
+		&di.ImageFormats,
+	} {
+		if err := readArray(r, a); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range []*string{&di.Manufacturer, &di.Model, &di.DeviceVersion, &di.SerialNumber} {
+		if *s, err = ReadString(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return di, nil
+}
@@ -1,20 +1,27 @@
 package ptp
 
 import (
+	"bytes"
 	"encoding/binary"
+	"io"
+	"reflect"
 	"testing"
 )
 
 func TestDevicePropDesc_SizeOfValueInBytes(t *testing.T) {
 	check := map[DataTypeCode]int{
-		DTC_INT8:   1,
-		DTC_UINT8:  1,
-		DTC_INT16:  2,
-		DTC_UINT16: 2,
-		DTC_INT32:  4,
-		DTC_UINT32: 4,
-		DTC_INT64:  8,
-		DTC_UINT64: 8,
+		DTC_INT8:    1,
+		DTC_UINT8:   1,
+		DTC_INT16:   2,
+		DTC_UINT16:  2,
+		DTC_INT32:   4,
+		DTC_UINT32:  4,
+		DTC_INT64:   8,
+		DTC_UINT64:  8,
+		DTC_INT128:  16,
+		DTC_UINT128: 16,
+		DTC_AUINT8:  0,
+		DTC_STR:     0,
 	}
 
 	for code, want := range check {
@@ -124,3 +131,396 @@ func TestEnumerationForm_SupportedValuesAsInt64Array(t *testing.T) {
 		}
 	}
 }
+
+func TestDataTypeCode_ElementType(t *testing.T) {
+	check := map[DataTypeCode]DataTypeCode{
+		DTC_AINT8:    DTC_INT8,
+		DTC_AUINT16:  DTC_UINT16,
+		DTC_AUINT128: DTC_UINT128,
+		DTC_UINT32:   DTC_UINT32,
+		DTC_STR:      DTC_STR,
+	}
+
+	for code, want := range check {
+		got := code.ElementType()
+		if got != want {
+			t.Errorf("ElementType() return = %#x, want %#x", got, want)
+		}
+	}
+}
+
+func TestReadDevicePropDesc(t *testing.T) {
+	// Fuji X-T1 white balance: an enumeration of uint16 values.
+	b := []byte{0x05, 0x50, 0x04, 0x00, 0x01, 0x02, 0x00, 0x02, 0x00, 0x02, 0x03, 0x00, 0x02, 0x00, 0x04, 0x00, 0x06,
+		0x80}
+
+	got, err := ReadDevicePropDesc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDevicePropDesc() err = %s; want <nil>", err)
+	}
+	if got.DevicePropertyCode != DPC_WhiteBalance {
+		t.Errorf("ReadDevicePropDesc() DevicePropertyCode = %#x; want %#x", got.DevicePropertyCode, DPC_WhiteBalance)
+	}
+	if got.CurrentValueAsInt64() != 0x0002 {
+		t.Errorf("ReadDevicePropDesc() CurrentValue = %#x; want 0x0002", got.CurrentValueAsInt64())
+	}
+	form, ok := got.Form.(*EnumerationForm)
+	if !ok {
+		t.Fatalf("ReadDevicePropDesc() Form = %T; want *EnumerationForm", got.Form)
+	}
+	want := []int64{0x0002, 0x0004, 0x8006}
+	for i, v := range form.SupportedValuesAsInt64Array() {
+		if v != want[i] {
+			t.Errorf("ReadDevicePropDesc() SupportedValues[%d] = %#x; want %#x", i, v, want[i])
+		}
+	}
+	if form.DevicePropDesc != got {
+		t.Errorf("ReadDevicePropDesc() Form.DevicePropDesc = %p; want %p", form.DevicePropDesc, got)
+	}
+
+	// Exposure bias: a range of int16 values.
+	b = []byte{0x10, 0x50, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x48, 0xf4, 0xb8, 0x0b, 0x4d, 0x01}
+
+	got, err = ReadDevicePropDesc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDevicePropDesc() err = %s; want <nil>", err)
+	}
+	rf, ok := got.Form.(*RangeForm)
+	if !ok {
+		t.Fatalf("ReadDevicePropDesc() Form = %T; want *RangeForm", got.Form)
+	}
+	if !bytes.Equal(rf.MinimumValue, []byte{0x48, 0xf4}) || !bytes.Equal(rf.StepSize, []byte{0x4d, 0x01}) {
+		t.Errorf("ReadDevicePropDesc() range = %#x - %#x step %#x", rf.MinimumValue, rf.MaximumValue, rf.StepSize)
+	}
+
+	// Artist: a string without a form.
+	b = []byte{0x1e, 0x50, 0xff, 0xff, 0x01, 0x00, 0x02, 0x41, 0x00, 0x00, 0x00, 0x00}
+
+	got, err = ReadDevicePropDesc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDevicePropDesc() err = %s; want <nil>", err)
+	}
+	if !bytes.Equal(got.FactoryDefaultValue, []byte{0x00}) {
+		t.Errorf("ReadDevicePropDesc() FactoryDefaultValue = %#x; want 0x00", got.FactoryDefaultValue)
+	}
+	if !bytes.Equal(got.CurrentValue, []byte{0x02, 0x41, 0x00, 0x00, 0x00}) {
+		t.Errorf("ReadDevicePropDesc() CurrentValue = %#x; want 0x0241000000", got.CurrentValue)
+	}
+	if got.Form != nil {
+		t.Errorf("ReadDevicePropDesc() Form = %T; want <nil>", got.Form)
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	cases := []struct {
+		dtc  DataTypeCode
+		in   []byte
+		want []byte
+	}{
+		{DTC_UINT8, []byte{0x01, 0xff}, []byte{0x01}},
+		{DTC_UINT128, bytes.Repeat([]byte{0x01}, 17), bytes.Repeat([]byte{0x01}, 16)},
+		{DTC_AUINT16, []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0xff}, []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}},
+		{DTC_AINT128, []byte{0x00, 0x00, 0x00, 0x00, 0xff}, []byte{0x00, 0x00, 0x00, 0x00}},
+		{DTC_STR, []byte{0x00, 0xff}, []byte{0x00}},
+	}
+
+	for _, c := range cases {
+		got, err := ReadValue(bytes.NewReader(c.in), c.dtc)
+		if err != nil {
+			t.Errorf("ReadValue() err = %s; want <nil>", err)
+		}
+		if !bytes.Equal(got, c.want) {
+			t.Errorf("ReadValue() return = %#x, want %#x", got, c.want)
+		}
+	}
+
+	if _, err := ReadValue(bytes.NewReader([]byte{0x01, 0x02}), DTC_UINT32); err == nil {
+		t.Errorf("ReadValue() err = %s; want %s", err, "unexpected EOF")
+	}
+	if _, err := ReadValue(bytes.NewReader([]byte{0x01}), DTC_UNDEF); err == nil {
+		t.Errorf("ReadValue() err = %s; want unsupported data type 0x0", err)
+	}
+	// An element count which is way too high must not be trusted.
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 0x01, 0x02}
+	for _, r := range []io.Reader{bytes.NewReader(huge), io.MultiReader(bytes.NewReader(huge))} {
+		if _, err := ReadValue(r, DTC_AUINT128); err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadValue() err = %v; want %s", err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestDataTypeCode_IsSigned(t *testing.T) {
+	check := map[DataTypeCode]bool{
+		DTC_INT8:    true,
+		DTC_UINT8:   false,
+		DTC_INT16:   true,
+		DTC_UINT32:  false,
+		DTC_AINT64:  true,
+		DTC_AUINT16: false,
+		DTC_STR:     false,
+		DTC_UNDEF:   false,
+	}
+
+	for code, want := range check {
+		got := code.IsSigned()
+		if got != want {
+			t.Errorf("IsSigned() return = %v for %#x, want %v", got, code, want)
+		}
+	}
+}
+
+func TestDevicePropDesc_ValidateValue(t *testing.T) {
+	rng := &DevicePropDesc{
+		DataType: DTC_INT16,
+		GetSet:   DPD_GetSet,
+		FormFlag: DPF_FormFlag_Range,
+		Form: &RangeForm{
+			MinimumValue: []byte{0x48, 0xf4}, // -3000
+			MaximumValue: []byte{0xb8, 0x0b}, // 3000
+			StepSize:     []byte{0xe8, 0x03}, // 1000
+		},
+	}
+	enum := &DevicePropDesc{
+		DataType: DTC_UINT16,
+		GetSet:   DPD_GetSet,
+		FormFlag: DPF_FormFlag_Enum,
+		Form: &EnumerationForm{
+			NumberOfValues:  2,
+			SupportedValues: [][]byte{{0x02, 0x00}, {0x06, 0x80}},
+		},
+	}
+	ro := &DevicePropDesc{
+		DataType: DTC_UINT8,
+		GetSet:   DPD_Get,
+		FormFlag: DPF_FormFlag_None,
+	}
+
+	cases := []struct {
+		dpd *DevicePropDesc
+		v   int64
+		ok  bool
+	}{
+		{rng, -3000, true},
+		{rng, 0, true},
+		{rng, 2000, true},
+		{rng, 500, false},
+		{rng, 4000, false},
+		{rng, -4000, false},
+		{enum, 0x0002, true},
+		{enum, 0x8006, true},
+		{enum, 0x0004, false},
+		{ro, 1, false},
+	}
+
+	for _, c := range cases {
+		err := c.dpd.ValidateValue(c.v)
+		if c.ok && err != nil {
+			t.Errorf("ValidateValue(%d) error = %s; want <nil>", c.v, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("ValidateValue(%d) error = <nil>; want error", c.v)
+		}
+	}
+}
+
+func TestDevicePropDesc_SupportedValues(t *testing.T) {
+	rng := &DevicePropDesc{
+		DataType: DTC_INT16,
+		Form: &RangeForm{
+			MinimumValue: []byte{0x48, 0xf4}, // -3000
+			MaximumValue: []byte{0xb8, 0x0b}, // 3000
+			StepSize:     []byte{0xe8, 0x03}, // 1000
+		},
+	}
+	enum := &DevicePropDesc{
+		DataType: DTC_INT16,
+		Form: &EnumerationForm{
+			NumberOfValues:  3,
+			SupportedValues: [][]byte{{0x2d, 0xfd}, {0x00, 0x00}, {0xd3, 0x02}},
+		},
+	}
+
+	cases := []struct {
+		dpd   *DevicePropDesc
+		limit int
+		want  []int64
+	}{
+		{rng, 10, []int64{-3000, -2000, -1000, 0, 1000, 2000, 3000}},
+		{rng, 6, nil},
+		{enum, 1, []int64{-723, 0, 723}},
+		{&DevicePropDesc{DataType: DTC_UINT8}, 10, nil},
+	}
+
+	for _, c := range cases {
+		if got := c.dpd.SupportedValues(c.limit); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SupportedValues(%d) = %v; want %v", c.limit, got, c.want)
+		}
+	}
+}
+
+func TestDevicePropDesc_SignExtend(t *testing.T) {
+	cases := []struct {
+		dtc  DataTypeCode
+		v    int64
+		want int64
+	}{
+		{DTC_INT16, 0xfd2d, -723},
+		{DTC_INT16, 723, 723},
+		{DTC_UINT16, 0xfd2d, 0xfd2d},
+		{DTC_INT8, 0xff, -1},
+	}
+
+	for _, c := range cases {
+		dpd := &DevicePropDesc{DataType: c.dtc}
+		if got := dpd.SignExtend(c.v); got != c.want {
+			t.Errorf("SignExtend(%#x) = %d; want %d", c.v, got, c.want)
+		}
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	cases := []struct {
+		dtc  DataTypeCode
+		in   []byte
+		want interface{}
+	}{
+		{DTC_INT8, []byte{0xff}, int8(-1)},
+		{DTC_UINT8, []byte{0xff}, uint8(0xff)},
+		{DTC_INT16, []byte{0x48, 0xf4}, int16(-3000)},
+		{DTC_UINT16, []byte{0x02, 0x80}, uint16(0x8002)},
+		{DTC_INT32, []byte{0xfe, 0xff, 0xff, 0xff}, int32(-2)},
+		{DTC_UINT32, []byte{0x01, 0x00, 0x02, 0x00}, uint32(0x00020001)},
+		{DTC_INT64, bytes.Repeat([]byte{0xff}, 8), int64(-1)},
+		{DTC_UINT64, []byte{0x01, 0, 0, 0, 0, 0, 0, 0x01}, uint64(0x0100000000000001)},
+		{DTC_INT128, bytes.Repeat([]byte{0xff}, 16), Int128{Lo: 0xffffffffffffffff, Hi: 0xffffffffffffffff}},
+		{DTC_UINT128, append([]byte{0x02}, make([]byte, 15)...), Uint128{Lo: 2}},
+		{DTC_AUINT16, []byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}, []uint16{1, 2}},
+		{DTC_AINT8, []byte{0x01, 0x00, 0x00, 0x00, 0xff}, []int8{-1}},
+		{DTC_AINT32, []byte{0x00, 0x00, 0x00, 0x00}, []int32{}},
+		{DTC_AINT128, append([]byte{0x01, 0x00, 0x00, 0x00}, bytes.Repeat([]byte{0xff}, 16)...), []Int128{{Lo: 0xffffffffffffffff, Hi: 0xffffffffffffffff}}},
+		{DTC_STR, []byte{0x00}, ""},
+		{DTC_STR, []byte{0x03, 0x46, 0x00, 0x75, 0x00, 0x00, 0x00}, "Fu"},
+	}
+
+	for _, c := range cases {
+		got, err := DecodeValue(c.in, c.dtc)
+		if err != nil {
+			t.Errorf("DecodeValue() err = %s for %#x; want <nil>", err, c.dtc)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("DecodeValue() return = %#v for %#x, want %#v", got, c.dtc, c.want)
+		}
+	}
+
+	errs := []struct {
+		dtc DataTypeCode
+		in  []byte
+	}{
+		{DTC_UINT32, []byte{0x01, 0x02}},
+		{DTC_AUINT64, []byte{0xff, 0xff, 0xff, 0xff, 0x01}},
+		{DTC_UNDEF, []byte{0x01}},
+	}
+
+	for _, c := range errs {
+		if _, err := DecodeValue(c.in, c.dtc); err == nil {
+			t.Errorf("DecodeValue() err = <nil> for %#x; want error", c.dtc)
+		}
+	}
+}
+
+func TestInt128_BigInt(t *testing.T) {
+	check := map[Int128]string{
+		{}:             "0",
+		{Lo: 1}:        "1",
+		{Lo: 0, Hi: 1}: "18446744073709551616",
+		{Lo: 0xffffffffffffffff, Hi: 0xffffffffffffffff}: "-1",
+		{Lo: 0, Hi: 0x8000000000000000}:                  "-170141183460469231731687303715884105728",
+	}
+
+	for i, want := range check {
+		got := i.BigInt().String()
+		if got != want {
+			t.Errorf("BigInt() return = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestUint128_BigInt(t *testing.T) {
+	check := map[Uint128]string{
+		{}:             "0",
+		{Lo: 1}:        "1",
+		{Lo: 0, Hi: 1}: "18446744073709551616",
+		{Lo: 0xffffffffffffffff, Hi: 0xffffffffffffffff}: "340282366920938463463374607431768211455",
+	}
+
+	for u, want := range check {
+		got := u.BigInt().String()
+		if got != want {
+			t.Errorf("BigInt() return = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestReadDeviceInfo(t *testing.T) {
+	b := []byte{
+		0x64, 0x00, // StandardVersion
+		0x0e, 0x00, 0x00, 0x00, // VendorExtensionID
+		0x64, 0x00, // VendorExtensionVersion
+		0x03, 0x46, 0x00, 0x46, 0x00, 0x00, 0x00, // VendorExtensionDesc
+		0x00, 0x00, // FunctionalMode
+		0x03, 0x00, 0x00, 0x00, 0x01, 0x10, 0x02, 0x10, 0x0e, 0x10, // OperationsSupported
+		0x01, 0x00, 0x00, 0x00, 0x02, 0x40, // EventsSupported
+		0x02, 0x00, 0x00, 0x00, 0x01, 0x50, 0x05, 0x50, // DevicePropertiesSupported
+		0x01, 0x00, 0x00, 0x00, 0x01, 0x38, // CaptureFormats
+		0x00, 0x00, 0x00, 0x00, // ImageFormats
+		0x05, 0x46, 0x00, 0x55, 0x00, 0x4a, 0x00, 0x49, 0x00, 0x00, 0x00, // Manufacturer
+		0x05, 0x58, 0x00, 0x2d, 0x00, 0x54, 0x00, 0x31, 0x00, 0x00, 0x00, // Model
+		0x04, 0x35, 0x00, 0x2e, 0x00, 0x31, 0x00, 0x00, 0x00, // DeviceVersion
+		0x00, // SerialNumber
+	}
+
+	got, err := ReadDeviceInfo(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDeviceInfo() err = %s; want <nil>", err)
+	}
+	want := &DeviceInfo{
+		StandardVersion:           100,
+		VendorExtensionID:         uint32(VE_FujiPhotoFilmCoLtd),
+		VendorExtensionVersion:    100,
+		VendorExtensionDesc:       "FF",
+		FunctionalMode:            FUM_StandardMode,
+		OperationsSupported:       []OperationCode{OC_GetDeviceInfo, OC_OpenSession, OC_InitiateCapture},
+		EventsSupported:           []EventCode{EC_ObjectAdded},
+		DevicePropertiesSupported: []DevicePropCode{DPC_BatteryLevel, DPC_WhiteBalance},
+		CaptureFormats:            []ObjectFormatCode{OFC_EXIF_JPEG},
+		ImageFormats:              []ObjectFormatCode{},
+		Manufacturer:              "FUJI",
+		Model:                     "X-T1",
+		DeviceVersion:             "5.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDeviceInfo() got = %#v; want %#v", got, want)
+	}
+
+	if _, err := ReadDeviceInfo(bytes.NewReader(b[:20])); err == nil {
+		t.Error("ReadDeviceInfo() err = <nil>; want error for truncated dataset")
+	}
+}
+
+func TestDeviceInfo_Supports(t *testing.T) {
+	di := &DeviceInfo{
+		OperationsSupported:       []OperationCode{OC_GetDeviceInfo, OC_OpenSession},
+		EventsSupported:           []EventCode{EC_ObjectAdded},
+		DevicePropertiesSupported: []DevicePropCode{DPC_BatteryLevel},
+	}
+
+	if !di.SupportsOperation(OC_OpenSession) || di.SupportsOperation(OC_InitiateCapture) {
+		t.Error("SupportsOperation() does not match OperationsSupported")
+	}
+	if !di.SupportsEvent(EC_ObjectAdded) || di.SupportsEvent(EC_CaptureComplete) {
+		t.Error("SupportsEvent() does not match EventsSupported")
+	}
+	if !di.SupportsDeviceProperty(DPC_BatteryLevel) || di.SupportsDeviceProperty(DPC_WhiteBalance) {
+		t.Error("SupportsDeviceProperty() does not match DevicePropertiesSupported")
+	}
+}
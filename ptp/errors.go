@@ -0,0 +1,59 @@
+package ptp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors allowing callers to branch on the cause of a failure using errors.Is(), whatever the operation or
+// transport was.
+var (
+	// ErrDeviceBusy matches a ResponseError holding RC_DeviceBusy: retrying the operation later might succeed.
+	ErrDeviceBusy = errors.New("device busy")
+	// ErrInvalidParameter matches a ResponseError holding RC_InvalidParameter or RC_ParameterNotSupported.
+	ErrInvalidParameter = errors.New("invalid parameter")
+	// ErrTimeout is wrapped by all errors indicating the Responder did not respond in time.
+	ErrTimeout = errors.New("timeout reached")
+)
+
+// ResponseError is returned when the Responder completes an operation with a response code other than RC_OK. The
+// operation and transaction ID are zero when they are not known.
+type ResponseError struct {
+	Code          OperationResponseCode
+	Operation     OperationCode
+	TransactionID TransactionID
+}
+
+// NewResponseError returns a *ResponseError for the operation response code or nil for RC_OK.
+func NewResponseError(code OperationResponseCode, oc OperationCode, tid TransactionID) error {
+	if code == RC_OK {
+		return nil
+	}
+
+	return &ResponseError{Code: code, Operation: oc, TransactionID: tid}
+}
+
+func (e *ResponseError) Error() string {
+	if e.Operation == 0 {
+		return OperationResponseCodeAsString(e.Code)
+	}
+
+	return fmt.Sprintf("operation %#x in transaction %d failed: %s", e.Operation, e.TransactionID, OperationResponseCodeAsString(e.Code))
+}
+
+// Is makes errors.Is() match the sentinel errors corresponding to the response code. Another *ResponseError matches
+// when the codes are equal and its operation and transaction ID are either zero or equal as well.
+func (e *ResponseError) Is(target error) bool {
+	switch target {
+	case ErrDeviceBusy:
+		return e.Code == RC_DeviceBusy
+	case ErrInvalidParameter:
+		return e.Code == RC_InvalidParameter || e.Code == RC_ParameterNotSupported
+	}
+
+	t, ok := target.(*ResponseError)
+
+	return ok && t.Code == e.Code &&
+		(t.Operation == 0 || t.Operation == e.Operation) &&
+		(t.TransactionID == 0 || t.TransactionID == e.TransactionID)
+}
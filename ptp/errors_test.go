@@ -0,0 +1,56 @@
+package ptp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewResponseError(t *testing.T) {
+	if err := NewResponseError(RC_OK, OC_GetDevicePropValue, 3); err != nil {
+		t.Errorf("NewResponseError() return = %v; want <nil>", err)
+	}
+
+	err := fmt.Errorf("wrapped: %w", NewResponseError(RC_DeviceBusy, OC_GetDevicePropValue, 3))
+	var rerr *ResponseError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("errors.As() return = false; want true")
+	}
+	if rerr.Code != RC_DeviceBusy || rerr.Operation != OC_GetDevicePropValue || rerr.TransactionID != 3 {
+		t.Errorf("errors.As() ResponseError = %#v; want code %#x, operation %#x, transaction 3", rerr, RC_DeviceBusy, OC_GetDevicePropValue)
+	}
+
+	want := "operation 0x1015 in transaction 3 failed: device busy"
+	if err.Error() != "wrapped: "+want {
+		t.Errorf("Error() return = '%s'; want 'wrapped: %s'", err, want)
+	}
+}
+
+func TestResponseError_Is(t *testing.T) {
+	err := NewResponseError(RC_DeviceBusy, OC_GetDevicePropValue, 3)
+	check := []struct {
+		target error
+		want   bool
+	}{
+		{ErrDeviceBusy, true},
+		{ErrInvalidParameter, false},
+		{ErrTimeout, false},
+		{OperationResponseCodeAsError(RC_DeviceBusy), true},
+		{OperationResponseCodeAsError(RC_AccessDenied), false},
+		{&ResponseError{Code: RC_DeviceBusy, Operation: OC_GetDevicePropValue, TransactionID: 3}, true},
+		{&ResponseError{Code: RC_DeviceBusy, Operation: OC_GetDevicePropDesc}, false},
+		{&ResponseError{Code: RC_DeviceBusy, TransactionID: 4}, false},
+	}
+
+	for i, tt := range check {
+		if got := errors.Is(err, tt.target); got != tt.want {
+			t.Errorf("%d: errors.Is(%s) return = %t; want %t", i, tt.target, got, tt.want)
+		}
+	}
+
+	for _, code := range []OperationResponseCode{RC_InvalidParameter, RC_ParameterNotSupported} {
+		if !errors.Is(OperationResponseCodeAsError(code), ErrInvalidParameter) {
+			t.Errorf("errors.Is(%#x, ErrInvalidParameter) return = false; want true", code)
+		}
+	}
+}
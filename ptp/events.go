@@ -116,11 +116,142 @@ type Event struct {
 	// of any parameter is dependent upon the EventCode. Any unused parameter fields should be set to 0x00000000. If a
 	// parameter holds a value that is less than 32 bits, the lowest significant bits shall be used to store the value,
 	// with the most significant bits being set to zeros.
-	Parameter1 []byte
-	Parameter2 []byte
-	Parameter3 []byte
+	Parameter1 uint32
+	Parameter2 uint32
+	Parameter3 uint32
 }
 
 func (e *Event) Session() SessionID {
 	return e.SessionID
 }
+
+// TypedEvent is implemented by the concrete event types returned by Event.Typed(), which allows consumers to use a
+// type switch instead of interpreting the event parameters themselves.
+type TypedEvent interface {
+	EventCode() EventCode
+}
+
+// CancelTransactionEvent asks to abort the transaction with the given ID.
+type CancelTransactionEvent struct {
+	TransactionID TransactionID
+}
+
+// ObjectAddedEvent indicates a new object was added to the device.
+type ObjectAddedEvent struct {
+	Handle ObjectHandle
+}
+
+// ObjectRemovedEvent indicates an object was removed from the device due to something external to the session.
+type ObjectRemovedEvent struct {
+	Handle ObjectHandle
+}
+
+// StoreAddedEvent indicates a new store was added to the device. The StorageID is 0x00000000 when the store holds more
+// than one logical store, in which case the StorageIDs must be requested again.
+type StoreAddedEvent struct {
+	StorageID StorageID
+}
+
+// StoreRemovedEvent indicates a store is no longer available.
+type StoreRemovedEvent struct {
+	StorageID StorageID
+}
+
+// DevicePropChangedEvent indicates a property changed on the device due to something external to the session.
+type DevicePropChangedEvent struct {
+	Code DevicePropCode
+}
+
+// ObjectInfoChangedEvent indicates the ObjectInfo dataset of the object changed.
+type ObjectInfoChangedEvent struct {
+	Handle ObjectHandle
+}
+
+// DeviceInfoChangedEvent indicates the capabilities of the device changed.
+type DeviceInfoChangedEvent struct{}
+
+// RequestObjectTransferEvent asks the Initiator to get the object.
+type RequestObjectTransferEvent struct {
+	Handle ObjectHandle
+}
+
+// StoreFullEvent indicates the store became full.
+type StoreFullEvent struct {
+	StorageID StorageID
+}
+
+// DeviceResetEvent indicates the sessions are about to be closed.
+type DeviceResetEvent struct{}
+
+// StorageInfoChangedEvent indicates the StorageInfo dataset of the store changed.
+type StorageInfoChangedEvent struct {
+	StorageID StorageID
+}
+
+// CaptureCompleteEvent indicates the capture initiated by the transaction with the given ID is complete: no more
+// ObjectAdded events will follow for it.
+type CaptureCompleteEvent struct {
+	TransactionID TransactionID
+}
+
+// UnreportedStatusEvent indicates the Responder was unable to report events, so the Initiator should refresh what it
+// knows about the Responder.
+type UnreportedStatusEvent struct{}
+
+// UnknownEvent holds an event without a concrete type, such as a vendor extended event.
+type UnknownEvent struct {
+	Event Event
+}
+
+func (CancelTransactionEvent) EventCode() EventCode     { return EC_CancelTransaction }
+func (ObjectAddedEvent) EventCode() EventCode           { return EC_ObjectAdded }
+func (ObjectRemovedEvent) EventCode() EventCode         { return EC_ObjectRemoved }
+func (StoreAddedEvent) EventCode() EventCode            { return EC_StoreAdded }
+func (StoreRemovedEvent) EventCode() EventCode          { return EC_StoreRemoved }
+func (DevicePropChangedEvent) EventCode() EventCode     { return EC_DevicePropChanged }
+func (ObjectInfoChangedEvent) EventCode() EventCode     { return EC_ObjectInfoChanged }
+func (DeviceInfoChangedEvent) EventCode() EventCode     { return EC_DeviceInfoChanged }
+func (RequestObjectTransferEvent) EventCode() EventCode { return EC_RequestObjectTransfer }
+func (StoreFullEvent) EventCode() EventCode             { return EC_StoreFull }
+func (DeviceResetEvent) EventCode() EventCode           { return EC_DeviceReset }
+func (StorageInfoChangedEvent) EventCode() EventCode    { return EC_StorageInfoChanged }
+func (CaptureCompleteEvent) EventCode() EventCode       { return EC_CaptureComplete }
+func (UnreportedStatusEvent) EventCode() EventCode      { return EC_UnreportedStatus }
+func (e UnknownEvent) EventCode() EventCode             { return e.Event.EventCode }
+
+// Typed returns the event as one of the concrete event types, interpreting the parameters according to the event code.
+// Events without a concrete type, such as vendor extended events, are returned as an UnknownEvent.
+func (e *Event) Typed() TypedEvent {
+	switch e.EventCode {
+	case EC_CancelTransaction:
+		return CancelTransactionEvent{TransactionID: e.TransactionID}
+	case EC_ObjectAdded:
+		return ObjectAddedEvent{Handle: ObjectHandle(e.Parameter1)}
+	case EC_ObjectRemoved:
+		return ObjectRemovedEvent{Handle: ObjectHandle(e.Parameter1)}
+	case EC_StoreAdded:
+		return StoreAddedEvent{StorageID: StorageID(e.Parameter1)}
+	case EC_StoreRemoved:
+		return StoreRemovedEvent{StorageID: StorageID(e.Parameter1)}
+	case EC_DevicePropChanged:
+		return DevicePropChangedEvent{Code: DevicePropCode(e.Parameter1)}
+	case EC_ObjectInfoChanged:
+		return ObjectInfoChangedEvent{Handle: ObjectHandle(e.Parameter1)}
+	case EC_DeviceInfoChanged:
+		return DeviceInfoChangedEvent{}
+	case EC_RequestObjectTransfer:
+		return RequestObjectTransferEvent{Handle: ObjectHandle(e.Parameter1)}
+	case EC_StoreFull:
+		return StoreFullEvent{StorageID: StorageID(e.Parameter1)}
+	case EC_DeviceReset:
+		return DeviceResetEvent{}
+	case EC_StorageInfoChanged:
+		return StorageInfoChangedEvent{StorageID: StorageID(e.Parameter1)}
+	case EC_CaptureComplete:
+		return CaptureCompleteEvent{TransactionID: e.TransactionID}
+	case EC_UnreportedStatus:
+		return UnreportedStatusEvent{}
+	}
+
+	return UnknownEvent{Event: *e}
+}
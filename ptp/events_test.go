@@ -13,3 +13,36 @@ func TestEvent_Session(t *testing.T) {
 		t.Errorf("Session() return = %d, want %d", got, want)
 	}
 }
+
+func TestEvent_Typed(t *testing.T) {
+	check := []struct {
+		event Event
+		want  TypedEvent
+	}{
+		{Event{EventCode: EC_CancelTransaction, TransactionID: 7}, CancelTransactionEvent{TransactionID: 7}},
+		{Event{EventCode: EC_ObjectAdded, Parameter1: 3}, ObjectAddedEvent{Handle: 3}},
+		{Event{EventCode: EC_ObjectRemoved, Parameter1: 4}, ObjectRemovedEvent{Handle: 4}},
+		{Event{EventCode: EC_StoreAdded, Parameter1: 0x00010001}, StoreAddedEvent{StorageID: 0x00010001}},
+		{Event{EventCode: EC_StoreRemoved, Parameter1: 0x0001FFFF}, StoreRemovedEvent{StorageID: 0x0001FFFF}},
+		{Event{EventCode: EC_DevicePropChanged, Parameter1: uint32(DPC_BatteryLevel)}, DevicePropChangedEvent{Code: DPC_BatteryLevel}},
+		{Event{EventCode: EC_ObjectInfoChanged, Parameter1: 5}, ObjectInfoChangedEvent{Handle: 5}},
+		{Event{EventCode: EC_DeviceInfoChanged}, DeviceInfoChangedEvent{}},
+		{Event{EventCode: EC_RequestObjectTransfer, Parameter1: 6}, RequestObjectTransferEvent{Handle: 6}},
+		{Event{EventCode: EC_StoreFull, Parameter1: 0x00010001}, StoreFullEvent{StorageID: 0x00010001}},
+		{Event{EventCode: EC_DeviceReset}, DeviceResetEvent{}},
+		{Event{EventCode: EC_StorageInfoChanged, Parameter1: 0x00020001}, StorageInfoChangedEvent{StorageID: 0x00020001}},
+		{Event{EventCode: EC_CaptureComplete, TransactionID: 8}, CaptureCompleteEvent{TransactionID: 8}},
+		{Event{EventCode: EC_UnreportedStatus}, UnreportedStatusEvent{}},
+		{Event{EventCode: 0xC001, Parameter1: 1}, UnknownEvent{Event: Event{EventCode: 0xC001, Parameter1: 1}}},
+	}
+
+	for _, tt := range check {
+		got := tt.event.Typed()
+		if got != tt.want {
+			t.Errorf("Typed() = %#v; want %#v", got, tt.want)
+		}
+		if got.EventCode() != tt.event.EventCode {
+			t.Errorf("EventCode() = %#x; want %#x", got.EventCode(), tt.event.EventCode)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package ptp
 
-import "time"
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
 
-type AssociationDesc uint16
+type AssociationDesc uint32
 type AssociationType uint16
 
 // The most significant nibble (4 bits) is used to indicate the category of the code and whether the code value is
@@ -240,3 +245,73 @@ type ObjectInfo struct {
 	// within one keyword.
 	Keywords string
 }
+
+// IsAssociation indicates if the object is an association, i.e. a folder like object that can hold other objects.
+func (oi *ObjectInfo) IsAssociation() bool {
+	return oi.ObjectFormat == OFC_Association
+}
+
+// ReadObjectInfo reads an ObjectInfo dataset as it is sent by the Responder during the data phase of a GetObjectInfo
+// operation.
+func ReadObjectInfo(r io.Reader) (*ObjectInfo, error) {
+	oi := new(ObjectInfo)
+
+	for _, f := range []interface{}{
+		&oi.StorageID,
+		&oi.ObjectFormat,
+		&oi.ProtectionStatus,
+		&oi.ObjectCompressedSize,
+		&oi.ThumbFormat,
+		&oi.ThumbCompressedSize,
+		&oi.ThumbPixWidth,
+		&oi.ThumbPixHeight,
+		&oi.ImagePixWidth,
+		&oi.ImagePixHeight,
+		&oi.ImageBitDepth,
+		&oi.ParentObject,
+		&oi.AssociationType,
+		&oi.AssociationDesc,
+		&oi.SequenceNumber,
+	} {
+		if err := binary.Read(r, binary.LittleEndian, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if oi.Filename, err = ReadString(r); err != nil {
+		return nil, err
+	}
+	if oi.CaptureDate, err = readDateTime(r); err != nil {
+		return nil, err
+	}
+	if oi.ModificationDate, err = readDateTime(r); err != nil {
+		return nil, err
+	}
+	if oi.Keywords, err = ReadString(r); err != nil {
+		return nil, err
+	}
+
+	return oi, nil
+}
+
+// ReadObjectHandles reads an array of ObjectHandles as it is sent by the Responder during the data phase of a
+// GetObjectHandles operation.
+func ReadObjectHandles(r io.Reader) ([]ObjectHandle, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+
+	b, err := readBytes(r, uint64(n)*4)
+	if err != nil {
+		return nil, err
+	}
+
+	handles := make([]ObjectHandle, n)
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, handles); err != nil {
+		return nil, err
+	}
+
+	return handles, nil
+}
@@ -0,0 +1,86 @@
+package ptp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadObjectInfo(t *testing.T) {
+	b := []byte{
+		0x01, 0x00, 0x01, 0x00, // StorageID
+		0x01, 0x38, // ObjectFormat
+		0x00, 0x00, // ProtectionStatus
+		0x00, 0x10, 0x00, 0x00, // ObjectCompressedSize
+		0x08, 0x38, // ThumbFormat
+		0x00, 0x02, 0x00, 0x00, // ThumbCompressedSize
+		0xa0, 0x00, 0x00, 0x00, // ThumbPixWidth
+		0x78, 0x00, 0x00, 0x00, // ThumbPixHeight
+		0x80, 0x07, 0x00, 0x00, // ImagePixWidth
+		0x38, 0x04, 0x00, 0x00, // ImagePixHeight
+		0x18, 0x00, 0x00, 0x00, // ImageBitDepth
+		0x02, 0x00, 0x00, 0x00, // ParentObject
+		0x00, 0x00, // AssociationType
+		0x00, 0x00, 0x00, 0x00, // AssociationDesc
+		0x00, 0x00, 0x00, 0x00, // SequenceNumber
+		0x05, 0x41, 0x00, 0x2e, 0x00, 0x4a, 0x00, 0x50, 0x00, 0x00, 0x00, // Filename
+		0x10, 0x32, 0x00, 0x30, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x30, 0x00, 0x31, 0x00, 0x35, 0x00, 0x54,
+		0x00, 0x31, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35, 0x00, 0x30, 0x00, 0x30, 0x00, 0x5a, 0x00, 0x00, 0x00, // CaptureDate
+		0x00, // ModificationDate
+		0x00, // Keywords
+	}
+
+	got, err := ReadObjectInfo(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadObjectInfo() err = %s; want <nil>", err)
+	}
+	if got.StorageID != 0x00010001 {
+		t.Errorf("ReadObjectInfo() StorageID = %#x; want 0x00010001", got.StorageID)
+	}
+	if got.ObjectFormat != OFC_EXIF_JPEG {
+		t.Errorf("ReadObjectInfo() ObjectFormat = %#x; want %#x", got.ObjectFormat, OFC_EXIF_JPEG)
+	}
+	if got.ImagePixWidth != 1920 {
+		t.Errorf("ReadObjectInfo() ImagePixWidth = %d; want 1920", got.ImagePixWidth)
+	}
+	if got.ParentObject != 2 {
+		t.Errorf("ReadObjectInfo() ParentObject = %d; want 2", got.ParentObject)
+	}
+	if got.Filename != "A.JP" {
+		t.Errorf("ReadObjectInfo() Filename = %s; want A.JP", got.Filename)
+	}
+	want := time.Date(2020, 10, 15, 13, 45, 0, 0, time.UTC)
+	if !got.CaptureDate.Equal(want) {
+		t.Errorf("ReadObjectInfo() CaptureDate = %s; want %s", got.CaptureDate, want)
+	}
+	if !got.ModificationDate.IsZero() {
+		t.Errorf("ReadObjectInfo() ModificationDate = %s; want zero time", got.ModificationDate)
+	}
+	if got.IsAssociation() {
+		t.Errorf("IsAssociation() = %v; want false", got.IsAssociation())
+	}
+}
+
+func TestReadObjectHandles(t *testing.T) {
+	b := []byte{0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00}
+
+	got, err := ReadObjectHandles(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadObjectHandles() err = %s; want <nil>", err)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("ReadObjectHandles() got = %v; want [3 4]", got)
+	}
+
+	// An element count which is way too high must not be trusted.
+	check := []io.Reader{
+		bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x03, 0x00, 0x00, 0x00}),
+		io.MultiReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x03, 0x00, 0x00, 0x00})),
+	}
+	for _, r := range check {
+		if _, err := ReadObjectHandles(r); err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadObjectHandles() err = %v; want %s", err, io.ErrUnexpectedEOF)
+		}
+	}
+}
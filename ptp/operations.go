@@ -95,7 +95,9 @@ const (
 	RC_SpecificationofDestinationUnsupported OperationResponseCode = 0x2020
 )
 
-func OperationResponseCodeAsError(code OperationResponseCode) error {
+// OperationResponseCodeAsString returns a description of the operation response code. An empty string is returned for
+// RC_OK.
+func OperationResponseCodeAsString(code OperationResponseCode) string {
 	var err string
 
 	switch code {
@@ -169,11 +171,13 @@ func OperationResponseCodeAsError(code OperationResponseCode) error {
 		err = fmt.Sprintf("unknown operation response code: %#x", code)
 	}
 
-	if err != "" {
-		return fmt.Errorf(err)
-	}
+	return err
+}
 
-	return nil
+// OperationResponseCodeAsError returns a *ResponseError for the operation response code or nil for RC_OK. Use
+// NewResponseError when the operation and transaction are known.
+func OperationResponseCodeAsError(code OperationResponseCode) error {
+	return NewResponseError(code, 0, 0)
 }
 
 // OperationRequest consists of the ip-specific transmission of a 30-byte operation dataset from the Initiator to the
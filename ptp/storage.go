@@ -1,5 +1,10 @@
 package ptp
 
+import (
+	"encoding/binary"
+	"io"
+)
+
 type StorageType uint16
 type FilesystemType uint16
 type AccessCapability uint16
@@ -68,3 +73,48 @@ type StorageInfo struct {
 	// known. If unused, this field should be set to the empty string.
 	VolumeLabel string
 }
+
+// ReadStorageIDs reads an array of StorageIDs as it is sent by the Responder during the data phase of a GetStorageIDs
+// operation.
+func ReadStorageIDs(r io.Reader) ([]StorageID, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+
+	ids := make([]StorageID, n)
+	if err := binary.Read(r, binary.LittleEndian, ids); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ReadStorageInfo reads a StorageInfo dataset as it is sent by the Responder during the data phase of a GetStorageInfo
+// operation.
+func ReadStorageInfo(r io.Reader) (*StorageInfo, error) {
+	si := new(StorageInfo)
+
+	for _, f := range []interface{}{
+		&si.StorageType,
+		&si.FilesystemType,
+		&si.AccessCapability,
+		&si.MaxCapacity,
+		&si.FreeSpaceInBytes,
+		&si.FreeSpaceInImages,
+	} {
+		if err := binary.Read(r, binary.LittleEndian, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if si.StorageDescription, err = ReadString(r); err != nil {
+		return nil, err
+	}
+	if si.VolumeLabel, err = ReadString(r); err != nil {
+		return nil, err
+	}
+
+	return si, nil
+}
@@ -0,0 +1,40 @@
+package ptp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadStorageInfo(t *testing.T) {
+	b := []byte{
+		0x04, 0x00, // StorageType
+		0x03, 0x00, // FilesystemType
+		0x00, 0x00, // AccessCapability
+		0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, // MaxCapacity
+		0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, // FreeSpaceInBytes
+		0xd2, 0x04, 0x00, 0x00, // FreeSpaceInImages
+		0x03, 0x53, 0x00, 0x44, 0x00, 0x00, 0x00, // StorageDescription
+		0x00, // VolumeLabel
+	}
+
+	got, err := ReadStorageInfo(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadStorageInfo() err = %s; want <nil>", err)
+	}
+	want := StorageInfo{
+		StorageType:        ST_RemovableRAM,
+		FilesystemType:     FT_DCF,
+		AccessCapability:   AC_ReadWrite,
+		MaxCapacity:        32 * 1024 * 1024 * 1024,
+		FreeSpaceInBytes:   16 * 1024 * 1024 * 1024,
+		FreeSpaceInImages:  1234,
+		StorageDescription: "SD",
+	}
+	if *got != want {
+		t.Errorf("ReadStorageInfo() = %#v; want %#v", *got, want)
+	}
+
+	if _, err := ReadStorageInfo(bytes.NewReader(b[:10])); err == nil {
+		t.Error("ReadStorageInfo() err = <nil>; want error for truncated dataset")
+	}
+}
@@ -0,0 +1,93 @@
+package ptp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// MaxStringLength is the maximum number of UCS-2 characters a PTP string can hold, excluding the terminating null
+// character.
+const MaxStringLength = 254
+
+var (
+	StringTooLongError      = errors.New("string exceeds the maximum PTP string length")
+	StringTrailingDataError = errors.New("trailing data after PTP string")
+)
+
+// String is the PTP string data type (DTC_STR). On the wire it is a single byte holding the number of characters,
+// including the terminating null character, followed by the UCS-2 encoded characters in little endian byte order. An
+// empty string is sent as a single zero byte.
+// Characters outside of the Basic Multilingual Plane are sent as UTF-16 surrogate pairs, each half counting as a
+// character.
+type String string
+
+// MarshalBinary converts the string to its PTP wire format.
+func (s String) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	if err := WriteString(&b, string(s)); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary reads a string in PTP wire format. The data must hold exactly one string.
+func (s *String) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	str, err := ReadString(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return StringTrailingDataError
+	}
+
+	*s = String(str)
+
+	return nil
+}
+
+// ReadString reads a string in PTP wire format from r.
+func ReadString(r io.Reader) (string, error) {
+	var n uint8
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+
+	b := make([]uint16, n)
+	if err := binary.Read(r, binary.LittleEndian, b); err != nil {
+		return "", err
+	}
+
+	// Drop the null terminator. Be lenient towards Responders forgetting to send one.
+	if b[n-1] == 0 {
+		b = b[:n-1]
+	}
+
+	return string(utf16.Decode(b)), nil
+}
+
+// WriteString writes s to w in PTP wire format.
+func WriteString(w io.Writer, s string) error {
+	if s == "" {
+		_, err := w.Write([]byte{0x00})
+		return err
+	}
+
+	chars := utf16.Encode([]rune(s))
+	if len(chars) > MaxStringLength {
+		return StringTooLongError
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint8(len(chars)+1)); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, append(chars, 0))
+}
@@ -0,0 +1,114 @@
+package ptp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadString(t *testing.T) {
+	cases := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte{0x00}, ""},
+		// A string holding nothing but the null terminator.
+		{[]byte{0x01, 0x00, 0x00}, ""},
+		{[]byte{0x03, 0x58, 0x00, 0x2d, 0x00, 0x00, 0x00}, "X-"},
+		// An odd number of characters.
+		{[]byte{0x04, 0x58, 0x00, 0x2d, 0x00, 0x54, 0x00, 0x00, 0x00}, "X-T"},
+		{[]byte{0x03, 0xe9, 0x00, 0x74, 0x00, 0x00, 0x00}, "ét"},
+		{[]byte{0x03, 0xe5, 0x65, 0x2c, 0x67, 0x00, 0x00}, "日本"},
+		// A character outside of the Basic Multilingual Plane is sent as a surrogate pair.
+		{[]byte{0x03, 0x3d, 0xd8, 0xf7, 0xdc, 0x00, 0x00}, "📷"},
+		// Missing null terminator.
+		{[]byte{0x02, 0x58, 0x00, 0x2d, 0x00}, "X-"},
+	}
+
+	for _, c := range cases {
+		got, err := ReadString(bytes.NewReader(c.in))
+		if err != nil {
+			t.Errorf("ReadString(%#x) err = %s; want <nil>", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("ReadString(%#x) return = '%s', want '%s'", c.in, got, c.want)
+		}
+	}
+
+	errs := map[string][]byte{
+		"no data":                 {},
+		"odd number of bytes":     {0x02, 0x58, 0x00, 0x2d},
+		"character count too big": {0x05, 0x58, 0x00, 0x2d, 0x00, 0x00, 0x00},
+	}
+
+	for name, in := range errs {
+		if _, err := ReadString(bytes.NewReader(in)); err != io.EOF && err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadString() with %s err = %v; want EOF", name, err)
+		}
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []byte
+	}{
+		{"", []byte{0x00}},
+		{"X-", []byte{0x03, 0x58, 0x00, 0x2d, 0x00, 0x00, 0x00}},
+		{"X-T", []byte{0x04, 0x58, 0x00, 0x2d, 0x00, 0x54, 0x00, 0x00, 0x00}},
+		{"日本", []byte{0x03, 0xe5, 0x65, 0x2c, 0x67, 0x00, 0x00}},
+		{"📷", []byte{0x03, 0x3d, 0xd8, 0xf7, 0xdc, 0x00, 0x00}},
+	}
+
+	for _, c := range cases {
+		var b bytes.Buffer
+		if err := WriteString(&b, c.in); err != nil {
+			t.Errorf("WriteString(%s) err = %s; want <nil>", c.in, err)
+		}
+		if !bytes.Equal(b.Bytes(), c.want) {
+			t.Errorf("WriteString(%s) wrote %#x, want %#x", c.in, b.Bytes(), c.want)
+		}
+	}
+
+	var b bytes.Buffer
+	max := strings.Repeat("a", MaxStringLength)
+	if err := WriteString(&b, max); err != nil {
+		t.Errorf("WriteString() with %d characters err = %s; want <nil>", MaxStringLength, err)
+	}
+	if b.Len() != 1+2*(MaxStringLength+1) || b.Bytes()[0] != 0xff {
+		t.Errorf("WriteString() with %d characters wrote %d bytes, count %#x", MaxStringLength, b.Len(), b.Bytes()[0])
+	}
+
+	for _, s := range []string{max + "a", strings.Repeat("📷", MaxStringLength/2) + "a"} {
+		if err := WriteString(&b, s); err != StringTooLongError {
+			t.Errorf("WriteString() with %d runes err = %v; want %s", len([]rune(s)), err, StringTooLongError)
+		}
+	}
+}
+
+func TestString_MarshalBinary(t *testing.T) {
+	for _, s := range []String{"", "a", "Fujifilm X-T3", "Ansel Adams", "日本", "📷 camera"} {
+		b, err := s.MarshalBinary()
+		if err != nil {
+			t.Errorf("MarshalBinary(%s) err = %s; want <nil>", s, err)
+		}
+
+		var got String
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Errorf("UnmarshalBinary(%#x) err = %s; want <nil>", b, err)
+		}
+		if got != s {
+			t.Errorf("UnmarshalBinary(%#x) = '%s'; want '%s'", b, got, s)
+		}
+	}
+
+	if _, err := String(strings.Repeat("a", MaxStringLength+1)).MarshalBinary(); err != StringTooLongError {
+		t.Errorf("MarshalBinary() err = %v; want %s", err, StringTooLongError)
+	}
+
+	var s String
+	if err := s.UnmarshalBinary([]byte{0x01, 0x00, 0x00, 0xff}); err != StringTrailingDataError {
+		t.Errorf("UnmarshalBinary() err = %v; want %s", err, StringTrailingDataError)
+	}
+}
@@ -1,6 +1,13 @@
 package ptp
 
-import "encoding/binary"
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
 
 // byteArrayToInt64 converts a byte array to an int64 where l is the number of significant bytes in the byte array.
 // Setting l to 0 will cause l to be set to the length of the byte array passed in.
@@ -17,3 +24,63 @@ func byteArrayToInt64(b []byte, l int) int64 {
 	// Converting between uint64 and int64 does not change the sign bit, only the way it is interpreted.
 	return int64(binary.LittleEndian.Uint64(b))
 }
+
+// readBytes reads exactly n bytes. The lengths sent by the Responder cannot be trusted blindly, so instead of allocating
+// n bytes up front the buffer grows as the data comes in: a malformed length fails with io.ErrUnexpectedEOF instead
+// of allocating gigabytes.
+func readBytes(r io.Reader, n uint64) ([]byte, error) {
+	if l, ok := r.(interface{ Len() int }); ok && n > uint64(l.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var b bytes.Buffer
+	if _, err := io.CopyN(&b, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// readDateTime reads a PTP DateTime string which is formatted as YYYYMMDDThhmmss.s with an optional UTC indicator
+// 'Z' or a relative time zone offset in the form of +hhmm or -hhmm. When no time zone information is present, the time
+// is considered to be local time.
+func readDateTime(r io.Reader) (time.Time, error) {
+	s, err := ReadString(r)
+	if err != nil || s == "" {
+		return time.Time{}, err
+	}
+
+	// Fractional seconds are accepted when parsing even if the layout does not mention them.
+	if strings.ContainsAny(s, "Z+-") {
+		return time.Parse("20060102T150405Z0700", s)
+	}
+
+	return time.ParseInLocation("20060102T150405", s, time.Local)
+}
+
+// readArray reads a PTP array, which is a uint32 holding the number of elements followed by the elements themselves,
+// into the slice pointed to by a.
+func readArray(r io.Reader, a interface{}) error {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(a).Elem()
+	size := binary.Size(reflect.Zero(v.Type().Elem()).Interface())
+	b, err := readBytes(r, uint64(n)*uint64(size))
+	if err != nil {
+		return err
+	}
+
+	s := reflect.MakeSlice(v.Type(), int(n), int(n))
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, s.Interface()); err != nil {
+		return err
+	}
+	v.Set(s)
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package ptp
 
 import (
+	"bytes"
 	"encoding/binary"
+	"io"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestByteArrayToInt64(t *testing.T) {
@@ -21,3 +25,46 @@ func TestByteArrayToInt64(t *testing.T) {
 		t.Errorf("byteArrayToInt64() return = %d, want %d", got, want)
 	}
 }
+
+func TestReadDateTime(t *testing.T) {
+	dt := func(s string) []byte {
+		b, _ := String(s).MarshalBinary()
+		return b
+	}
+
+	cases := []struct {
+		in   string
+		want time.Time
+	}{
+		{"20201015T134500", time.Date(2020, 10, 15, 13, 45, 0, 0, time.Local)},
+		{"20201015T134500.5Z", time.Date(2020, 10, 15, 13, 45, 0, 500000000, time.UTC)},
+		{"20201015T134500+0200", time.Date(2020, 10, 15, 11, 45, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		got, err := readDateTime(bytes.NewReader(dt(c.in)))
+		if err != nil {
+			t.Errorf("readDateTime() err = %s; want <nil>", err)
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("readDateTime() return = %s, want %s", got, c.want)
+		}
+	}
+}
+
+func TestReadArray(t *testing.T) {
+	var got []uint16
+	if err := readArray(bytes.NewReader([]byte{0x02, 0x00, 0x00, 0x00, 0x01, 0x50, 0x02, 0x50}), &got); err != nil {
+		t.Fatalf("readArray() err = %s; want <nil>", err)
+	}
+	if want := []uint16{0x5001, 0x5002}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readArray() got = %#x; want %#x", got, want)
+	}
+
+	// An element count which is way too high must not be trusted.
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 0x01, 0x50}
+	for _, r := range []io.Reader{bytes.NewReader(huge), io.MultiReader(bytes.NewReader(huge))} {
+		if err := readArray(r, &got); err != io.ErrUnexpectedEOF {
+			t.Errorf("readArray() err = %v; want %s", err, io.ErrUnexpectedEOF)
+		}
+	}
+}
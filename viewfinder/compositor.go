@@ -0,0 +1,145 @@
+package viewfinder
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// Compositor draws the viewfinder on top of live view frames using the device property values cached by the client,
+// turning the live view into a preview showing the current camera settings.
+type Compositor struct {
+	// Viewfinder holds the widgets to draw. When nil, the viewfinder is created from the first frame using
+	// NewViewfinder() for the vendor of the client.
+	Viewfinder *Viewfinder
+	// Overlay is called for each frame before the widgets are drawn when set, e.g. to draw a grid or focus peaking.
+	Overlay func(img *image.RGBA)
+	// Quality is the JPEG quality ranging from 1 to 100 used to re-encode the frames. A quality of 0 uses the default
+	// quality of the image/jpeg package.
+	Quality int
+
+	client *ip.Client
+	// layer caches the widgets drawn for the previous frame, so only the widgets that changed are drawn again.
+	layer *Overlay
+	// initialised is true once the Viewfinder has been created, which is only attempted once.
+	initialised bool
+}
+
+// NewCompositor returns a compositor drawing the given viewfinder using the property values cached by the client. The
+// viewfinder can be nil, see Compositor.Viewfinder.
+func NewCompositor(c *ip.Client, vf *Viewfinder) *Compositor {
+	return &Compositor{
+		Viewfinder:  vf,
+		client:      c,
+		initialised: vf != nil,
+	}
+}
+
+// Composite passes on copies of the frames with the viewfinder drawn on top, re-encoding their Data as JPEG. Only the
+// widgets of which the client has the property value in its cache are drawn, see ip.Client.CachedProperty(). The
+// returned channel is closed when the frames channel is closed.
+func (cp *Compositor) Composite(frames <-chan *ip.LiveViewFrame) <-chan *ip.LiveViewFrame {
+	quality := cp.Quality
+	if quality == 0 {
+		quality = jpeg.DefaultQuality
+	}
+
+	out := make(chan *ip.LiveViewFrame)
+	go func() {
+		defer close(out)
+		for f := range frames {
+			if f.Image != nil {
+				f = cp.composite(f, quality)
+			}
+			out <- f
+		}
+	}()
+
+	return out
+}
+
+func (cp *Compositor) composite(f *ip.LiveViewFrame, quality int) *ip.LiveViewFrame {
+	img := image.NewRGBA(f.Image.Bounds())
+	draw.Draw(img, img.Rect, f.Image, img.Rect.Min, draw.Src)
+
+	if !cp.initialised {
+		cp.Viewfinder = NewViewfinder(img, cp.client.ResponderVendor())
+		cp.initialised = true
+	}
+
+	if cp.Overlay != nil {
+		cp.Overlay(img)
+	}
+	if vf := cp.Viewfinder; vf != nil {
+		cp.drawWidgets(vf, img, f)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		// Better to pass on the original frame than none at all.
+		return f
+	}
+
+	cf := *f
+	cf.Image = img
+	cf.Data = buf.Bytes()
+
+	return &cf
+}
+
+// drawWidgets draws the widgets using an Overlay, so only the widgets of which the value changed since the previous
+// frame are drawn again. The overlay is replaced when the Viewfinder is replaced.
+func (cp *Compositor) drawWidgets(vf *Viewfinder, img *image.RGBA, f *ip.LiveViewFrame) {
+	vf.Tick(f.Time)
+
+	if cp.layer == nil || cp.layer.vf != vf || cp.layer.layer.Rect != img.Rect {
+		cp.layer = NewOverlay(vf)
+	}
+
+	values := make(map[ptp.DevicePropCode]int64)
+	for _, code := range vf.codes() {
+		val, ok := cp.client.CachedProperty(code)
+		if !ok {
+			continue
+		}
+		if v, ok := valueAsInt64(val); ok {
+			values[code] = v
+		}
+	}
+	cp.layer.Update(values)
+	cp.layer.Draw(img)
+
+	DrawMetadata(vf, img, f.Metadata)
+	DrawSelfTimer(vf, img)
+	if vf.Histogram != nil && f.Histogram != nil {
+		vf.Histogram.Draw(img, f.Histogram)
+	}
+}
+
+// valueAsInt64 converts a decoded device property value to an int64. The second return value is false for values that
+// are not an integer, such as strings and arrays.
+func valueAsInt64(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case int8:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
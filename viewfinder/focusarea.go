@@ -0,0 +1,99 @@
+package viewfinder
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// FocusArea describes the focus area of the camera on a FocusGrid.
+type FocusArea struct {
+	// X and Y hold the position of the focus point in the centre of the area, counting from 1 at the top left.
+	X, Y int
+	// Size is the amount of focus points along each side of a zone, e.g. 3 for a 3x3 zone as used for zone AF. A
+	// Size of 0 or 1 selects a single focus point.
+	Size int
+}
+
+// FocusGrid describes the grid of focus points a camera lays over the image.
+type FocusGrid struct {
+	Cols, Rows int
+	// Rect is the part of the image covered by the focus points.
+	Rect image.Rectangle
+}
+
+// NewFocusGrid returns a grid of focus points centred on the image, covering the given fraction of its width and height.
+func NewFocusGrid(img *image.RGBA, cols, rows int, coverage float64) FocusGrid {
+	b := img.Bounds()
+	w, h := int(float64(b.Dx())*coverage), int(float64(b.Dy())*coverage)
+	min := image.Pt(b.Min.X+(b.Dx()-w)/2, b.Min.Y+(b.Dy()-h)/2)
+
+	return FocusGrid{Cols: cols, Rows: rows, Rect: image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}}
+}
+
+// cell returns the part of the image covered by the focus point at the given position, counting from 1.
+func (g FocusGrid) cell(x, y int) image.Rectangle {
+	cw, ch := g.Rect.Dx()/g.Cols, g.Rect.Dy()/g.Rows
+	min := g.Rect.Min.Add(image.Pt((x-1)*cw, (y-1)*ch))
+
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(cw, ch))}
+}
+
+// PointAt returns the focus point at the given position of the image, e.g. the position the user clicked to select the
+// focus point for touch AF. The second return value is false when the position is outside of the grid.
+func (g FocusGrid) PointAt(p image.Point) (FocusArea, bool) {
+	if g.Cols <= 0 || g.Rows <= 0 || !p.In(g.Rect) {
+		return FocusArea{}, false
+	}
+
+	x := (p.X-g.Rect.Min.X)*g.Cols/g.Rect.Dx() + 1
+	y := (p.Y-g.Rect.Min.Y)*g.Rows/g.Rect.Dy() + 1
+
+	return FocusArea{X: x, Y: y}, true
+}
+
+// DrawFocusArea draws the outline of the focus area on the image. A single focus point is drawn as one rectangle, a zone
+// is drawn as the grid of all focus points it is made of. Zones at the edge of the grid are moved inwards.
+func DrawFocusArea(img *image.RGBA, g FocusGrid, a FocusArea, c color.Color) {
+	if g.Cols <= 0 || g.Rows <= 0 || a.X < 1 || a.Y < 1 || a.X > g.Cols || a.Y > g.Rows {
+		return
+	}
+
+	size := a.Size
+	if size < 1 {
+		size = 1
+	}
+	x0, y0 := clamp(a.X-size/2, 1, g.Cols-size+1), clamp(a.Y-size/2, 1, g.Rows-size+1)
+
+	lw := scaled(img, 2)
+	gap := scaled(img, 2)
+	src := image.NewUniform(c)
+	for y := y0; y < y0+size && y <= g.Rows; y++ {
+		for x := x0; x < x0+size && x <= g.Cols; x++ {
+			drawOutline(img, g.cell(x, y).Inset(gap), lw, src)
+		}
+	}
+}
+
+// drawOutline draws the outline of the rectangle on the image using the given line width.
+func drawOutline(img *image.RGBA, r image.Rectangle, width int, src image.Image) {
+	for _, l := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width),
+		image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y+width, r.Min.X+width, r.Max.Y-width),
+		image.Rect(r.Max.X-width, r.Min.Y+width, r.Max.X, r.Max.Y-width),
+	} {
+		draw.Draw(img, l, src, image.Point{}, draw.Over)
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v > max {
+		v = max
+	}
+	if v < min {
+		v = min
+	}
+
+	return v
+}
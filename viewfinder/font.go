@@ -0,0 +1,50 @@
+package viewfinder
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+	"image"
+)
+
+const (
+	// textSize is the size of the text in pixels at the reference height, matching the 13 pixel high glyphs.
+	textSize = 13
+	// minTextSize is the smallest size in pixels to render text in to keep it legible.
+	minTextSize = 8
+)
+
+// textFont is the embedded TrueType font used to render text.
+var textFont = mustParseFont(gomono.TTF)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// NewTextFace returns a face of the embedded Go Mono TrueType font scaled to the height of the given image. A face must
+// not be used from multiple go routines at once, so each Widget gets its own.
+func NewTextFace(img *image.RGBA) font.Face {
+	return newTextFace(img, textSize)
+}
+
+// newTextFace returns a face of the embedded TrueType font of the given size in pixels at the reference height, scaled
+// to the height of the given image.
+func newTextFace(img *image.RGBA, points float64) font.Face {
+	size := points * scaleFactor(img)
+	if size < minTextSize {
+		size = minTextSize
+	}
+
+	face, err := opentype.NewFace(textFont, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return basicfont.Face7x13
+	}
+
+	return face
+}
@@ -4,9 +4,11 @@ import (
 	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
 	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
-	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 	"image"
+	"image/color"
+	"image/draw"
 	"math"
 	"strconv"
 	"strings"
@@ -15,27 +17,65 @@ import (
 // NewFujiXT1Viewfinder returns a new Fuji X-T1 viewfinder containing a Widget list mimicking the real viewfinder.
 // The image is needed for the widgets to calibrate their origin so they can render in their own designated place.
 func NewFujiXT1Viewfinder(img *image.RGBA) *Viewfinder {
+	wb := NewFujiWhiteBalanceWidget(img)
+	fi := NewFujiFocusIndicatorWidget(img)
+	cr := NewFujiCapturesRemainingWidget(img)
+	epm := NewFujiExposureProgramModeWidget(img)
+
 	return &Viewfinder{
 		Widgets: map[ptp.DevicePropCode]*Widget{
 			ptp.DPC_BatteryLevel:             NewFujiBatteryLevelWidget(img),
 			ptp.DPC_CaptureDelay:             NewFujiCaptureDelayWidget(img),
-			ip.DPC_Fuji_CapturesRemaining:    NewFujiCapturesRemainingWidget(img),
+			ip.DPC_Fuji_CapturesRemaining:    cr,
 			ptp.DPC_ExposureBiasCompensation: NewFujiExposureBiasCompensationWidget(img),
-			ptp.DPC_ExposureProgramMode:      NewFujiExposureProgramModeWidget(img),
+			ptp.DPC_ExposureMeteringMode:     NewFujiMeteringModeWidget(img),
+			ptp.DPC_ExposureProgramMode:      epm,
+			ptp.DPC_ExposureTime:             NewFujiShutterSpeedWidget(img),
 			ip.DPC_Fuji_ExposureIndex:        NewFujiISOWidget(img),
 			ip.DPC_Fuji_FilmSimulation:       NewFujiFilmSimulationWidget(img),
+			ptp.DPC_FlashMode:                NewFujiFlashModeWidget(img),
 			ptp.DPC_FNumber:                  NewFujiFNumberWidget(img),
+			ip.DPC_Fuji_FocusLock:            fi,
+			ip.DPC_Fuji_FocusMeteringMode:    NewFujiFocusAreaWidget(img),
+			ptp.DPC_FocusMode:                NewFujiFocusModeWidget(img),
 			ip.DPC_Fuji_ImageAspectRatio:     NewFujiImageSizeWidget(img),
 			ip.DPC_Fuji_ImageQuality:         NewFujiImageQualityWidget(img),
-			ptp.DPC_WhiteBalance:             NewFujiWhiteBalanceWidget(img),
+			ptp.DPC_WhiteBalance:             wb,
+			ip.DPC_Fuji_ColorTemp:            NewFujiColourTemperatureWidget(img, wb),
+		},
+		Names: map[string]ptp.DevicePropCode{
+			"battery":          ptp.DPC_BatteryLevel,
+			"delay":            ptp.DPC_CaptureDelay,
+			"captures":         ip.DPC_Fuji_CapturesRemaining,
+			"exposure-bias":    ptp.DPC_ExposureBiasCompensation,
+			"metering":         ptp.DPC_ExposureMeteringMode,
+			"exposure-program": ptp.DPC_ExposureProgramMode,
+			"shutter-speed":    ptp.DPC_ExposureTime,
+			"iso":              ip.DPC_Fuji_ExposureIndex,
+			"film-simulation":  ip.DPC_Fuji_FilmSimulation,
+			"flash-mode":       ptp.DPC_FlashMode,
+			"aperture":         ptp.DPC_FNumber,
+			"focus-indicator":  ip.DPC_Fuji_FocusLock,
+			"focus-area":       ip.DPC_Fuji_FocusMeteringMode,
+			"focus-mode":       ptp.DPC_FocusMode,
+			"image-size":       ip.DPC_Fuji_ImageAspectRatio,
+			"image-quality":    ip.DPC_Fuji_ImageQuality,
+			"white-balance":    ptp.DPC_WhiteBalance,
+			"colour-temp":      ip.DPC_Fuji_ColorTemp,
 		},
+		FocusIndicator:    fi,
+		CapturesRemaining: cr,
+		LightMeter:        NewFujiLightMeterWidget(img, epm),
+		SelfTimer:         NewSelfTimerWidget(img),
+		Histogram:         NewHistogramWidget(img, AnchorTopRight),
+		bounds:            img.Bounds(),
 	}
 }
 
 func NewFujiBatteryLevelWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.1)
-	y := img.Bounds().Max.Y - 8
+	y := img.Bounds().Max.Y - scaled(img, 8)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiBattery3Bars
@@ -45,25 +85,38 @@ func NewFujiBatteryLevelWidget(img *image.RGBA) *Widget {
 
 func drawFujiBattery3Bars(w *Widget, val int64) {
 	w.ResetToOrigin()
+	w.ResetColour()
 
-	var lvl string
+	var bars int
 	switch ip.FujiBatteryLevel(val) {
 	case ip.BAT_Fuji_3bOne:
-		w.SetColour(255, 0, 0) // red
-		lvl = "baU"
+		// Blink when the battery is running low.
+		if !w.Blink() {
+			return
+		}
+		w.UseWarningColour()
+		bars = 1
 	case ip.BAT_Fuji_3bTwo:
-		lvl = "bCT"
+		bars = 2
 	case ip.BAT_Fuji_3bFull:
-		lvl = "BAT"
+		bars = 3
+	default:
+		return
 	}
 
-	w.DrawString(lvl)
+	// The terminal is on the left and the bars fill the battery from the right, like on the camera.
+	x, y := w.origin.X.Round(), w.origin.Y.Round()
+	w.fill(image.Rect(x, y-w.px(7), x+w.px(2), y-w.px(2)))
+	w.outline(image.Rect(x+w.px(2), y-w.px(9), x+w.px(18), y), w.px(1))
+	for i := 0; i < bars; i++ {
+		w.fill(image.Rect(x+w.px(13-4*i), y-w.px(7), x+w.px(16-4*i), y-w.px(2)))
+	}
 }
 
 func NewFujiCaptureDelayWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.2)
-	y := 18
+	y := scaled(img, 18)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiCaptureDelay
@@ -89,7 +142,7 @@ func drawFujiCaptureDelay(w *Widget, val int64) {
 func NewFujiCapturesRemainingWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.25)
-	y := 18
+	y := scaled(img, 18)
 
 	w := NewWhiteFontWidget(img, int(x), y)
 	w.Draw = drawFujiCapturesRemaining
@@ -97,18 +150,27 @@ func NewFujiCapturesRemainingWidget(img *image.RGBA) *Widget {
 	return w
 }
 
+// drawFujiCapturesRemaining draws the amount of remaining captures, blinking in red when running low.
 func drawFujiCapturesRemaining(w *Widget, val int64) {
 	w.ResetToOrigin()
+	w.ResetColour()
+
+	if val < LowCapturesRemaining {
+		if !w.Blink() {
+			return
+		}
+		w.UseWarningColour()
+	}
 
 	w.DrawString(strconv.FormatInt(val, 10))
 }
 
 func NewFujiExposureBiasCompensationWidget(img *image.RGBA) *Widget {
 	// Make sure the center point of our bias widget is in the center of the image.
-	offset := VFGlyphs6x13.Width * len(getBias()) / 2
+	offset := VFGlyphs6x13.Width * glyphScale(scaleFactor(img)) * len(getBias()) / 2
 
 	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.5) - float64(offset)
-	y := img.Bounds().Max.Y - 10
+	y := img.Bounds().Max.Y - scaled(img, 10)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiExposureBiasCompensation
@@ -131,7 +193,7 @@ func drawFujiExposureBiasCompensation(w *Widget, val int64) {
 	marker := []rune("                   ")
 
 	// Draw the leading +/- icon
-	w.Dot.X -= fixed.Int26_6(VFGlyphs6x13.Width * 3 * 64) // offset icon 3 glyphs to the left
+	w.Dot.X -= fixed.I(VFGlyphs6x13.Width * 3 * glyphScale(w.scale)) // offset icon 3 glyphs to the left
 	w.DrawString("+-")
 	w.ResetToOrigin()
 
@@ -156,7 +218,7 @@ func drawFujiExposureBiasCompensation(w *Widget, val int64) {
 
 	// When the marker is on 0, the widget must be drawn in grey.
 	if onZero {
-		w.SetColour(100, 100, 100) // grey
+		w.UseInactiveColour()
 	}
 
 	// Now draw the basic exposure bias compensation widget.
@@ -164,7 +226,7 @@ func drawFujiExposureBiasCompensation(w *Widget, val int64) {
 
 	// When the marker is on 0, the the marker and '0' position must be drawn in white.
 	if onZero {
-		w.SetColour(255, 255, 255) // white
+		w.ResetColour()
 		for _, r := range []rune{'"', '!'} {
 			w.ResetToOrigin()
 			marker[pos] = r
@@ -176,7 +238,7 @@ func drawFujiExposureBiasCompensation(w *Widget, val int64) {
 
 	// Draw the marker on the the calculated position in yellow!
 	marker[pos] = '!'
-	w.SetColour(255, 185, 10) // yellow
+	w.UseHighlightColour()
 	w.ResetToOrigin()
 	w.DrawString(string(marker))
 }
@@ -184,7 +246,7 @@ func drawFujiExposureBiasCompensation(w *Widget, val int64) {
 func NewFujiExposureProgramModeWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.1)
-	y := img.Bounds().Max.Y - 10
+	y := img.Bounds().Max.Y - scaled(img, 10)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiExposureProgramMode
@@ -210,10 +272,51 @@ func drawFujiExposureProgramMode(w *Widget, val int64) {
 	w.DrawString(icon)
 }
 
+// NewFujiMeteringModeWidget draws an icon for the exposure metering mode in the top left corner. The X-T1 glyphs do
+// not hold the metering icons, so they are drawn as a frame with a mark for the metered area.
+func NewFujiMeteringModeWidget(img *image.RGBA) *Widget {
+	// Calculate starting position.
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.04)
+	y := scaled(img, 18)
+
+	w := NewWhiteFontWidget(img, int(x), y)
+	w.Draw = drawFujiMeteringMode
+
+	return w
+}
+
+func drawFujiMeteringMode(w *Widget, val int64) {
+	dst, ok := w.Dst.(*image.RGBA)
+	if !ok {
+		return
+	}
+
+	x, y := w.origin.X.Round(), w.origin.Y.Round()
+	frame := image.Rect(x, y-scaled(dst, 11), x+scaled(dst, 16), y-scaled(dst, 1))
+	centre := image.Pt(x+scaled(dst, 8), y-scaled(dst, 6))
+	lw := scaled(dst, 1)
+	spot := image.Rectangle{Min: centre, Max: centre}.Inset(-scaled(dst, 1) - lw)
+
+	switch ptp.ExposureMeteringMode(val) {
+	case ptp.EMM_Avarage:
+		drawOutline(dst, frame, lw, w.colour)
+	case ptp.EMM_CenterWeightedAvarage:
+		drawOutline(dst, frame, lw, w.colour)
+		drawOutline(dst, frame.Inset(scaled(dst, 3)), lw, w.colour)
+	case ptp.EMM_MultiSpot:
+		drawOutline(dst, frame, lw, w.colour)
+		drawOutline(dst, frame.Inset(scaled(dst, 3)), lw, w.colour)
+		draw.Draw(dst, spot, w.colour, image.Point{}, draw.Over)
+	case ptp.EMM_CenterSpot:
+		drawOutline(dst, frame, lw, w.colour)
+		draw.Draw(dst, spot, w.colour, image.Point{}, draw.Over)
+	}
+}
+
 func NewFujiISOWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.2)
-	y := img.Bounds().Max.Y - 10
+	y := img.Bounds().Max.Y - scaled(img, 10)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiISO
@@ -230,24 +333,92 @@ func drawFujiISO(w *Widget, val int64) {
 	w.DrawString("is") // iso icon
 
 	if strings.HasPrefix(iso, "S") {
-		w.Dot.X -= fixed.Int26_6(18 * 64) // offset to the left
-		w.Dot.Y -= fixed.Int26_6(8 * 64)
-		w.DrawString("ISO")              // auto icon
-		w.Dot.Y += fixed.Int26_6(8 * 64) // reset Y axis
-		iso = string([]rune(iso)[1:])    // drop the leading S
+		w.Dot.X -= w.scaled(18) // offset to the left
+		w.Dot.Y -= w.scaled(8)
+		w.DrawString("ISO")           // auto icon
+		w.Dot.Y += w.scaled(8)        // reset Y axis
+		iso = string([]rune(iso)[1:]) // drop the leading S
 	}
 
-	w.Face = basicfont.Face7x13
-	w.Dot.X += fixed.Int26_6(6 * 64)
-	w.Dot.Y += fixed.Int26_6(2 * 64)
+	w.UseTextFace()
+	w.Dot.X += w.scaled(6)
+	w.Dot.Y += w.scaled(2)
 
 	w.DrawString(iso) // actual value
 }
 
+// lightMeterStops is the amount of stops the light meter scale of the X-T1 shows on either side of the metered
+// exposure, in steps of one third of a stop.
+const lightMeterStops = 3
+
+// NewFujiLightMeterWidget draws the vertical light meter scale along the left edge of the X-T1 viewfinder, marking the
+// deviation from the metered exposure. The scale is only drawn when the exposure program mode widget was last drawn in
+// manual mode, like the X-T1 does.
+func NewFujiLightMeterWidget(img *image.RGBA, epm *Widget) *Widget {
+	// Calculate starting position: the baseline is the zero point of the scale.
+	x := img.Bounds().Min.X + scaled(img, 12)
+	y := img.Bounds().Min.Y + img.Bounds().Dy()/2
+
+	w := NewWhiteFontWidget(img, x, y)
+	w.dependencies = []*Widget{epm}
+	w.Draw = func(w *Widget, val int64) {
+		if ptp.ExposureProgramMode(epm.value) != ptp.EPM_Manual {
+			return
+		}
+		drawFujiLightMeter(w, val)
+	}
+
+	return w
+}
+
+// drawFujiLightMeter draws the light meter scale with a tick per third of a stop, plus at the top and minus at the
+// bottom. The deviation is given in thousandths of a stop, like the exposure bias compensation. Deviations beyond the
+// scale are marked in red at the end of the scale.
+func drawFujiLightMeter(w *Widget, val int64) {
+	dst, ok := w.Dst.(*image.RGBA)
+	if !ok {
+		return
+	}
+
+	x, y := w.origin.X.Round(), w.origin.Y.Round()
+	step := scaled(dst, 8)
+	lw := scaled(dst, 1)
+
+	inactive := w.theme.colour(themeInactive)
+	for i := -lightMeterStops * 3; i <= lightMeterStops*3; i++ {
+		length := scaled(dst, 4)
+		src := inactive
+		if i%3 == 0 {
+			length = scaled(dst, 8)
+			src = w.colour
+		}
+		ty := y - i*step
+		draw.Draw(dst, image.Rect(x, ty, x+length, ty+lw), src, image.Point{}, draw.Over)
+	}
+
+	w.ResetColour()
+	w.Dot = fixed.P(x, y-(lightMeterStops*3+1)*step)
+	w.DrawString("+")
+	w.Dot = fixed.P(x, y+(lightMeterStops*3+2)*step)
+	w.DrawString("-")
+
+	// Round the deviation to the nearest third of a stop.
+	pos := int(math.Round(float64(int16(val)) * 3 / 1000))
+	marker := w.theme.colour(themeHighlight)
+	if pos > lightMeterStops*3 || pos < -lightMeterStops*3 {
+		pos = clamp(pos, -lightMeterStops*3, lightMeterStops*3)
+		marker = w.theme.colour(themeWarning)
+	}
+	my := y - pos*step
+	half := scaled(dst, 2)
+	mx := x + scaled(dst, 10)
+	draw.Draw(dst, image.Rect(mx, my-half, mx+scaled(dst, 5), my+half+lw), marker, image.Point{}, draw.Over)
+}
+
 func NewFujiFilmSimulationWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.3)
-	y := 18
+	y := scaled(img, 18)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiFilmSimulation
@@ -257,6 +428,7 @@ func NewFujiFilmSimulationWidget(img *image.RGBA) *Widget {
 
 func drawFujiFilmSimulation(w *Widget, val int64) {
 	w.ResetToOrigin()
+	w.ResetFace()
 
 	var flm string
 
@@ -283,15 +455,69 @@ func drawFujiFilmSimulation(w *Widget, val int64) {
 		flm = "'?8"
 	case ip.FS_Fuji_ClassicChrome:
 		flm = ":,/"
+	default:
+		// Film simulations introduced after the X-T1, such as ACROS and ETERNA, have no glyph: draw their name instead.
+		w.UseTextFace()
+		flm = ptpfmt.FujiFilmSimulationAsString(ip.FujiFilmSimulation(val))
 	}
 
 	w.DrawString(flm)
 }
 
+// NewFujiFlashModeWidget draws the flash icon followed by the flash mode. Nothing is drawn when the flash is disabled,
+// which is the case when no flash is attached.
+func NewFujiFlashModeWidget(img *image.RGBA) *Widget {
+	// Calculate starting position.
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.4)
+	y := scaled(img, 18)
+
+	w := NewWhiteGlyphWidget(img, int(x), y)
+	w.Draw = drawFujiFlashMode
+
+	return w
+}
+
+func drawFujiFlashMode(w *Widget, val int64) {
+	w.ResetToOrigin()
+	w.ResetColour()
+	w.ResetFace()
+
+	var mode string
+
+	switch ptp.FlashMode(val) {
+	case ip.FM_Fuji_Disabled, ptp.FLM_Undefined:
+		return
+	case ptp.FLM_FlashOff:
+		w.UseInactiveColour()
+		mode = "OFF"
+	case ptp.FLM_AutoFlash:
+		mode = "AUTO"
+	case ptp.FLM_RedEyeAuto, ip.FM_Fuji_RedEye:
+		mode = "AUTO R"
+	case ptp.FLM_RedEyeFill, ip.FM_Fuji_RedEyeOn:
+		mode = "R"
+	case ip.FM_Fuji_RedEyeSync:
+		mode = "R SLOW"
+	case ip.FM_Fuji_RedEyeRear:
+		mode = "R 2nd"
+	case ip.FM_Fuji_SlowSync:
+		mode = "SLOW"
+	case ip.FM_Fuji_RearSync:
+		mode = "2nd"
+	case ip.FM_Fuji_Commander:
+		mode = "C"
+	}
+
+	w.DrawString("xy") // flash icon
+	w.UseTextFace()
+	w.Dot.X += w.scaled(2)
+	w.DrawString(mode)
+}
+
 func NewFujiFNumberWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.25)
-	y := img.Bounds().Max.Y - 10
+	y := img.Bounds().Max.Y - scaled(img, 10)
 
 	w := NewWhiteFontWidget(img, int(x), y)
 	w.Draw = drawFujiFNumber
@@ -305,10 +531,113 @@ func drawFujiFNumber(w *Widget, val int64) {
 	w.DrawString(strings.Replace(ptpfmt.FNumberAsString(uint16(val)), "f/", "F", 1))
 }
 
+// NewFujiShutterSpeedWidget renders the shutter speed set in ptp.DPC_ExposureTime left of the F-number. The encoding of
+// ip.DPC_Fuji_ShutterSpeed is unknown, so it is not drawn.
+func NewFujiShutterSpeedWidget(img *image.RGBA) *Widget {
+	// Calculate starting position.
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.16)
+	y := img.Bounds().Max.Y - scaled(img, 10)
+
+	w := NewWhiteFontWidget(img, int(x), y)
+	w.Draw = drawFujiShutterSpeed
+
+	return w
+}
+
+func drawFujiShutterSpeed(w *Widget, val int64) {
+	w.ResetToOrigin()
+
+	w.DrawString(ptpfmt.ExposureTimeAsString(uint32(val)))
+}
+
+func NewFujiFocusModeWidget(img *image.RGBA) *Widget {
+	// Calculate starting position.
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.1)
+	y := scaled(img, 18)
+
+	w := NewWhiteFontWidget(img, int(x), y)
+	w.Draw = drawFujiFocusMode
+
+	return w
+}
+
+func drawFujiFocusMode(w *Widget, val int64) {
+	w.ResetToOrigin()
+
+	var mode string
+
+	switch ptp.FocusMode(val) {
+	case ptp.FCM_Manual:
+		mode = "MF"
+	case ip.FCM_Fuji_Single_Auto:
+		mode = "AF-S"
+	case ip.FCM_Fuji_Continuous_Auto:
+		mode = "AF-C"
+	case ptp.FCM_Automatic:
+		mode = "AF"
+	}
+
+	w.DrawString(mode)
+}
+
+// NewFujiFocusIndicatorWidget draws a green square right of the focus mode widget when the camera confirms focus.
+func NewFujiFocusIndicatorWidget(img *image.RGBA) *Widget {
+	// Calculate starting position: right of the widest focus mode string.
+	mw := font.MeasureString(NewTextFace(img), "AF-S").Ceil()
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.1) + float64(mw+scaled(img, 4))
+	y := scaled(img, 18)
+
+	w := NewFontWidget(img, 0, 200, 0, int(x), y)
+	w.role = themeConfirmation
+	w.Draw = drawFujiFocusIndicator
+
+	return w
+}
+
+func drawFujiFocusIndicator(w *Widget, val int64) {
+	dst, ok := w.Dst.(*image.RGBA)
+	if !ok {
+		return
+	}
+
+	alpha := uint8(255)
+	switch val {
+	case 0:
+		return
+	case focusSearching:
+		alpha = uint8(255 * w.Pulse())
+	}
+
+	x, y := w.origin.X.Round(), w.origin.Y.Round()
+	r := image.Rect(x, y-w.scaled(9).Round(), x+w.scaled(8).Round(), y-w.scaled(1).Round())
+	mask := image.NewUniform(color.Alpha{A: alpha})
+	draw.DrawMask(dst, r, w.colour, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// NewFujiFocusAreaWidget draws the selected focus point on the grid of 7x7 focus points of the X-T1.
+func NewFujiFocusAreaWidget(img *image.RGBA) *Widget {
+	grid := NewFocusGrid(img, 7, 7, 0.6)
+
+	w := NewWhiteFontWidget(img, 0, 0)
+	w.Draw = func(w *Widget, val int64) {
+		dst, ok := w.Dst.(*image.RGBA)
+		if !ok {
+			return
+		}
+
+		// The focus point is held in the two least significant bytes, see ptpfmt.FujiFocusMeteringModeAsString().
+		a := FocusArea{X: int(val >> 8 & 0xFF), Y: int(val & 0xFF)}
+		DrawFocusArea(dst, grid, a, w.colour)
+	}
+
+	return w
+}
+
 func NewFujiImageSizeWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
-	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.15) + float64(VFGlyphs6x13.Width*3) + 1
-	y := 18
+	gw := VFGlyphs6x13.Width * glyphScale(scaleFactor(img))
+	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.15) + float64(gw*3) + 1
+	y := scaled(img, 18)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiImageSize
@@ -336,7 +665,7 @@ func drawFujiImageSize(w *Widget, val int64) {
 func NewFujiImageQualityWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Max.X) - (float64(img.Bounds().Max.X) * 0.15)
-	y := 18
+	y := scaled(img, 18)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiImageQuality
@@ -365,14 +694,14 @@ func drawFujiImageQuality(w *Widget, val int64) {
 	}
 
 	w.DrawString(icon)
-	w.Face = basicfont.Face7x13
+	w.UseTextFace()
 	w.DrawString("   " + qual)
 }
 
 func NewFujiWhiteBalanceWidget(img *image.RGBA) *Widget {
 	// Calculate starting position.
 	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.26)
-	y := 18
+	y := scaled(img, 18)
 
 	w := NewWhiteGlyphWidget(img, int(x), y)
 	w.Draw = drawFujiWhiteBalance
@@ -382,10 +711,15 @@ func NewFujiWhiteBalanceWidget(img *image.RGBA) *Widget {
 
 func drawFujiWhiteBalance(w *Widget, val int64) {
 	w.ResetToOrigin()
+	w.ResetFace()
 
 	var icon string
 
 	switch ptp.WhiteBalance(val) {
+	case ptp.WB_Automatic:
+		// There is no glyph for auto white balance.
+		w.UseTextFace()
+		icon = "AWB"
 	case ptp.WB_Daylight:
 		icon = "XY"
 	case ptp.WB_Tungsten:
@@ -408,3 +742,25 @@ func drawFujiWhiteBalance(w *Widget, val int64) {
 
 	w.DrawString(icon)
 }
+
+// NewFujiColourTemperatureWidget renders the colour temperature in Kelvin below the white balance widget, but only
+// when the white balance is set to ip.WB_Fuji_Temperature.
+func NewFujiColourTemperatureWidget(img *image.RGBA, wb *Widget) *Widget {
+	// Calculate starting position.
+	x := float64(img.Bounds().Min.X) + (float64(img.Bounds().Max.X) * 0.26)
+	y := scaled(img, 18) + NewTextFace(img).Metrics().Height.Ceil()
+
+	w := NewWhiteFontWidget(img, int(x), y)
+	w.dependencies = []*Widget{wb}
+	w.Draw = func(w *Widget, val int64) {
+		w.ResetToOrigin()
+
+		if ptp.WhiteBalance(wb.value) != ip.WB_Fuji_Temperature {
+			return
+		}
+
+		w.DrawString(strconv.FormatInt(val, 10) + "K")
+	}
+
+	return w
+}
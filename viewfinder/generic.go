@@ -0,0 +1,96 @@
+package viewfinder
+
+import (
+	"fmt"
+	ptpfmt "github.com/malc0mn/ptp-ip/fmt"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"strings"
+)
+
+// New returns a viewfinder for the device described by the DeviceInfo dataset. The vendor specific viewfinder is used
+// when available, otherwise the generic one. Only the widgets for the properties the device supports are kept, unless
+// the device does not list any supported properties at all.
+func New(di *ptp.DeviceInfo, img *image.RGBA) *Viewfinder {
+	vendor := ptp.VendorExtension(di.VendorExtensionID)
+	// Fuji cameras do not always report their own vendor extension ID.
+	if strings.HasPrefix(strings.ToUpper(di.Manufacturer), "FUJI") {
+		vendor = ptp.VE_FujiPhotoFilmCoLtd
+	}
+
+	vf := NewViewfinder(img, vendor)
+	if vf == nil {
+		vf = NewGenericViewfinder(img)
+	}
+
+	if len(di.DevicePropertiesSupported) == 0 {
+		return vf
+	}
+
+	supported := make(map[ptp.DevicePropCode]bool)
+	for _, code := range di.DevicePropertiesSupported {
+		supported[code] = true
+	}
+	for code := range vf.Widgets {
+		if !supported[code] {
+			delete(vf.Widgets, code)
+		}
+	}
+
+	return vf
+}
+
+// NewGenericViewfinder returns a viewfinder for cameras without a vendor specific viewfinder. It draws the standard
+// device properties as text in a bar at the top and the bottom of the image.
+func NewGenericViewfinder(img *image.RGBA) *Viewfinder {
+	top := scaled(img, 18)
+	bottom := img.Bounds().Max.Y - scaled(img, 10)
+
+	return &Viewfinder{
+		Widgets: map[ptp.DevicePropCode]*Widget{
+			ptp.DPC_FocusMode:                NewGenericPropertyWidget(img, ptp.DPC_FocusMode, 0.02, top),
+			ptp.DPC_WhiteBalance:             NewGenericPropertyWidget(img, ptp.DPC_WhiteBalance, 0.3, top),
+			ptp.DPC_FlashMode:                NewGenericPropertyWidget(img, ptp.DPC_FlashMode, 0.6, top),
+			ptp.DPC_BatteryLevel:             NewGenericPropertyWidget(img, ptp.DPC_BatteryLevel, 0.88, top),
+			ptp.DPC_ExposureProgramMode:      NewGenericPropertyWidget(img, ptp.DPC_ExposureProgramMode, 0.02, bottom),
+			ptp.DPC_ExposureTime:             NewGenericPropertyWidget(img, ptp.DPC_ExposureTime, 0.3, bottom),
+			ptp.DPC_FNumber:                  NewGenericPropertyWidget(img, ptp.DPC_FNumber, 0.45, bottom),
+			ptp.DPC_ExposureBiasCompensation: NewGenericPropertyWidget(img, ptp.DPC_ExposureBiasCompensation, 0.6, bottom),
+			ptp.DPC_ExposureIndex:            NewGenericPropertyWidget(img, ptp.DPC_ExposureIndex, 0.8, bottom),
+		},
+		Names: map[string]ptp.DevicePropCode{
+			"focus-mode":       ptp.DPC_FocusMode,
+			"white-balance":    ptp.DPC_WhiteBalance,
+			"flash-mode":       ptp.DPC_FlashMode,
+			"battery":          ptp.DPC_BatteryLevel,
+			"exposure-program": ptp.DPC_ExposureProgramMode,
+			"shutter-speed":    ptp.DPC_ExposureTime,
+			"aperture":         ptp.DPC_FNumber,
+			"exposure-bias":    ptp.DPC_ExposureBiasCompensation,
+			"iso":              ptp.DPC_ExposureIndex,
+		},
+		SelfTimer: NewSelfTimerWidget(img),
+		Histogram: NewHistogramWidget(img, AnchorTopRight),
+		bounds:    img.Bounds(),
+	}
+}
+
+// NewGenericPropertyWidget returns a widget drawing the value of the device property as text. The x position is given
+// as a fraction of the image width.
+func NewGenericPropertyWidget(img *image.RGBA, code ptp.DevicePropCode, x float64, y int) *Widget {
+	w := NewWhiteFontWidget(img, int(float64(img.Bounds().Min.X)+float64(img.Bounds().Max.X)*x), y)
+	w.Draw = func(w *Widget, val int64) {
+		w.ResetToOrigin()
+
+		switch code {
+		case ptp.DPC_BatteryLevel:
+			w.DrawString(fmt.Sprintf("%d%%", val))
+		case ptp.DPC_ExposureIndex:
+			w.DrawString(fmt.Sprintf("ISO %d", val))
+		default:
+			w.DrawString(ptpfmt.DevicePropValueAsString(code, val))
+		}
+	}
+
+	return w
+}
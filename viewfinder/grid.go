@@ -0,0 +1,90 @@
+package viewfinder
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Grid defines a framing grid that can be drawn over the live view.
+type Grid int
+
+const (
+	GridNone Grid = iota
+	// GridThirds divides the image in three equal rows and columns.
+	GridThirds
+	// GridSquare marks the largest square in the centre of the image.
+	GridSquare
+	// GridCrop169 marks the largest 16:9 area in the centre of the image.
+	GridCrop169
+)
+
+// GridColour is the colour used to draw the framing grids.
+var GridColour color.Color = color.NRGBA{R: 255, G: 255, B: 255, A: 128}
+
+// gridNames maps the grids to the names used on the command line and in configuration files.
+var gridNames = map[Grid]string{
+	GridNone:    "none",
+	GridThirds:  "thirds",
+	GridSquare:  "square",
+	GridCrop169: "16:9",
+}
+
+func (g Grid) String() string {
+	if n, ok := gridNames[g]; ok {
+		return n
+	}
+
+	return "unknown"
+}
+
+// ParseGrid returns the Grid for the given name, e.g. 'thirds' or '16:9'.
+func ParseGrid(name string) (Grid, error) {
+	for g, n := range gridNames {
+		if n == name {
+			return g, nil
+		}
+	}
+
+	return GridNone, fmt.Errorf("unknown grid '%s'", name)
+}
+
+// DrawGrid draws the framing grid on the image. Draw the grid before the viewfinder widgets so they remain legible.
+func DrawGrid(img *image.RGBA, g Grid) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	lw := scaled(img, 1)
+
+	switch g {
+	case GridThirds:
+		for i := 1; i < 3; i++ {
+			drawVerticalLine(img, b.Min.X+w*i/3, b.Min.Y, b.Max.Y, lw)
+			drawHorizontalLine(img, b.Min.Y+h*i/3, b.Min.X, b.Max.X, lw)
+		}
+	case GridSquare:
+		if w > h {
+			drawVerticalLine(img, b.Min.X+(w-h)/2, b.Min.Y, b.Max.Y, lw)
+			drawVerticalLine(img, b.Max.X-(w-h)/2-lw, b.Min.Y, b.Max.Y, lw)
+		} else {
+			drawHorizontalLine(img, b.Min.Y+(h-w)/2, b.Min.X, b.Max.X, lw)
+			drawHorizontalLine(img, b.Max.Y-(h-w)/2-lw, b.Min.X, b.Max.X, lw)
+		}
+	case GridCrop169:
+		if ch := w * 9 / 16; ch < h {
+			drawHorizontalLine(img, b.Min.Y+(h-ch)/2, b.Min.X, b.Max.X, lw)
+			drawHorizontalLine(img, b.Max.Y-(h-ch)/2-lw, b.Min.X, b.Max.X, lw)
+		} else if cw := h * 16 / 9; cw < w {
+			drawVerticalLine(img, b.Min.X+(w-cw)/2, b.Min.Y, b.Max.Y, lw)
+			drawVerticalLine(img, b.Max.X-(w-cw)/2-lw, b.Min.Y, b.Max.Y, lw)
+		}
+	}
+}
+
+func drawVerticalLine(img *image.RGBA, x, y0, y1, width int) {
+	draw.Draw(img, image.Rect(x, y0, x+width, y1), image.NewUniform(GridColour), image.Point{}, draw.Over)
+}
+
+func drawHorizontalLine(img *image.RGBA, y, x0, x1, width int) {
+	draw.Draw(img, image.Rect(x0, y, x1, y+width), image.NewUniform(GridColour), image.Point{}, draw.Over)
+}
@@ -0,0 +1,83 @@
+package viewfinder
+
+import (
+	"github.com/malc0mn/ptp-ip/ip"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const (
+	histogramWidth  = 128
+	histogramHeight = 64
+	// The margins keep the histogram clear of the top and bottom bar of the viewfinder.
+	histogramMarginX = 10
+	histogramMarginY = 40
+)
+
+// histogramChannels defines the colours used to draw the channels of a histogram.
+var histogramChannels = []struct {
+	levels func(h *ip.Histogram) *[256]uint32
+	colour *image.Uniform
+}{
+	{func(h *ip.Histogram) *[256]uint32 { return &h.Red }, image.NewUniform(color.NRGBA{R: 255, A: 96})},
+	{func(h *ip.Histogram) *[256]uint32 { return &h.Green }, image.NewUniform(color.NRGBA{G: 255, A: 96})},
+	{func(h *ip.Histogram) *[256]uint32 { return &h.Blue }, image.NewUniform(color.NRGBA{B: 255, A: 96})},
+	{
+		func(h *ip.Histogram) *[256]uint32 { return &h.Luminance },
+		image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: 128}),
+	},
+}
+
+// DrawHistogram draws the histogram in the given rectangle of the image on top of a translucent black background. The
+// RGB channels are drawn in their own colour, the luminance is drawn in white. The histogram is scaled so the highest
+// level fills the rectangle.
+func DrawHistogram(img *image.RGBA, h *ip.Histogram, r image.Rectangle) {
+	r = r.Intersect(img.Bounds())
+	if h == nil || r.Empty() {
+		return
+	}
+
+	draw.Draw(img, r, image.NewUniform(color.NRGBA{A: 128}), image.Point{}, draw.Over)
+
+	max := uint64(h.Max())
+	if max == 0 {
+		return
+	}
+
+	for x := 0; x < r.Dx(); x++ {
+		level := x * 256 / r.Dx()
+		for _, ch := range histogramChannels {
+			height := int(uint64(ch.levels(h)[level]) * uint64(r.Dy()) / max)
+			bar := image.Rect(r.Min.X+x, r.Max.Y-height, r.Min.X+x+1, r.Max.Y)
+			draw.Draw(img, bar, ch.colour, image.Point{}, draw.Over)
+		}
+	}
+}
+
+// HistogramWidget draws the live histogram as a small graph in one of the corners of the viewfinder.
+type HistogramWidget struct {
+	// Enabled toggles the histogram on or off.
+	Enabled bool
+	rect    image.Rectangle
+}
+
+// NewHistogramWidget returns an enabled histogram widget positioned in the given corner of the image.
+func NewHistogramWidget(img *image.RGBA, a Anchor) *HistogramWidget {
+	w, h := scaled(img, histogramWidth), scaled(img, histogramHeight)
+	p := anchorPoint(img.Bounds(), a, w, h, scaled(img, histogramMarginX), scaled(img, histogramMarginY))
+
+	return &HistogramWidget{
+		Enabled: true,
+		rect:    image.Rect(p.X, p.Y, p.X+w, p.Y+h),
+	}
+}
+
+// Draw draws the histogram on the image when the widget is enabled.
+func (w *HistogramWidget) Draw(img *image.RGBA, h *ip.Histogram) {
+	if !w.Enabled {
+		return
+	}
+
+	DrawHistogram(img, h, w.rect)
+}
@@ -0,0 +1,121 @@
+package viewfinder
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WidgetLayout overrides the position and colour of a widget as defined by the viewfinder.
+type WidgetLayout struct {
+	// Hidden removes the widget from the viewfinder.
+	Hidden bool
+	// Anchored indicates the widget must be moved to the Anchor using the offsets.
+	Anchored bool
+	Anchor   Anchor
+	// OffsetX and OffsetY move the start of the baseline of the widget away from the anchor.
+	OffsetX int
+	OffsetY int
+	// Colour overrides the colour of the widget when not nil.
+	Colour color.Color
+}
+
+// ParseWidgetLayout parses a layout definition consisting of space separated fields in any order: an anchor such as
+// 'bottom-right', the offsets as 'x,y' and a hexadecimal RGB colour as 'rrggbb'. The definition 'off' hides the widget.
+func ParseWidgetLayout(def string) (*WidgetLayout, error) {
+	l := &WidgetLayout{}
+
+	for _, f := range strings.Fields(def) {
+		switch {
+		case f == "off":
+			l.Hidden = true
+		case strings.Contains(f, ","):
+			if _, err := fmt.Sscanf(f, "%d,%d", &l.OffsetX, &l.OffsetY); err != nil {
+				return nil, fmt.Errorf("invalid offset '%s'", f)
+			}
+		default:
+			if a, err := ParseAnchor(f); err == nil {
+				l.Anchor = a
+				l.Anchored = true
+			} else if c, err := ParseColour(f); err == nil {
+				l.Colour = c
+			} else {
+				return nil, fmt.Errorf("invalid layout field '%s'", f)
+			}
+		}
+	}
+
+	return l, nil
+}
+
+// ParseColour parses a hexadecimal RGB colour such as '#00ff00'. The leading hash sign is optional. A translucent
+// colour is given by appending the alpha value, e.g. '00000080' for half transparent black.
+func ParseColour(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid colour '%s'", s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour '%s'", s)
+	}
+	c := color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+
+	return color.RGBAModel.Convert(c).(color.RGBA), nil
+}
+
+// ApplyLayout applies the layouts to the widgets mapped to the given names in the Names list of the viewfinder. The
+// image is needed to calculate the position of the anchored widgets. Unknown names are reported in the returned error
+// after applying all other layouts.
+func (vf *Viewfinder) ApplyLayout(img *image.RGBA, layouts map[string]*WidgetLayout) error {
+	var unknown []string
+	for name, l := range layouts {
+		code, ok := vf.Names[name]
+		w := vf.Widgets[code]
+		if !ok || w == nil {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		if l.Hidden {
+			vf.hide(w)
+			continue
+		}
+		if l.Anchored {
+			w.Place(img, l.Anchor, l.OffsetX, l.OffsetY)
+		}
+		if l.Colour != nil {
+			w.colour = image.NewUniform(l.Colour)
+			w.ResetColour()
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown viewfinder widget(s): %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// hide removes all references to the widget from the viewfinder.
+func (vf *Viewfinder) hide(w *Widget) {
+	for code, vw := range vf.Widgets {
+		if vw == w {
+			delete(vf.Widgets, code)
+		}
+	}
+	if vf.FocusIndicator == w {
+		vf.FocusIndicator = nil
+	}
+	if vf.CapturesRemaining == w {
+		vf.CapturesRemaining = nil
+	}
+}
@@ -0,0 +1,172 @@
+package viewfinder
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"image/draw"
+)
+
+// Overlay caches the rendered widgets of a viewfinder on a transparent layer, so only the widgets of which the value
+// changed since the previous frame have to be drawn again. Blinking and pulsing widgets are drawn for every frame.
+// Widgets that are not mapped to a device property, such as the FocusIndicator, are not cached: draw them using the
+// DrawXxx() functions after drawing the overlay. An Overlay must not be used from multiple go routines at once.
+type Overlay struct {
+	vf *Viewfinder
+	// layer holds all cached widgets drawn on top of each other.
+	layer *image.RGBA
+	// scratch is a transparent image the widgets are drawn on to find the area they cover.
+	scratch *image.RGBA
+	cache   map[ptp.DevicePropCode]*cachedWidget
+}
+
+// cachedWidget holds the part of the image covered by a widget as it was drawn for the given value.
+type cachedWidget struct {
+	value    int64
+	animated bool
+	// deps holds the values of the widgets the widget depends on at the time it was drawn.
+	deps []int64
+	img  *image.RGBA
+}
+
+// stale returns true when the widget must be drawn again for the given value.
+func (cw *cachedWidget) stale(w *Widget, val int64) bool {
+	if cw.value != val || cw.animated {
+		return true
+	}
+	for i, d := range w.dependencies {
+		if cw.deps[i] != d.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewOverlay returns an overlay for the viewfinder. Call Invalidate() after changing the layout or theme of the
+// viewfinder.
+func NewOverlay(vf *Viewfinder) *Overlay {
+	return &Overlay{
+		vf:      vf,
+		layer:   image.NewRGBA(vf.bounds),
+		scratch: image.NewRGBA(vf.bounds),
+		cache:   make(map[ptp.DevicePropCode]*cachedWidget),
+	}
+}
+
+// Invalidate drops all cached widgets so they are drawn again on the next Update().
+func (o *Overlay) Invalidate() {
+	o.cache = make(map[ptp.DevicePropCode]*cachedWidget)
+	draw.Draw(o.layer, o.layer.Rect, image.Transparent, image.Point{}, draw.Src)
+}
+
+// Update draws the widgets of which the value changed since the previous update as well as the animated widgets, see
+// Widget.Blink() and Widget.Pulse(). Widgets without a value are removed from the overlay. Call Viewfinder.Tick()
+// first to animate the widgets using the time of the frame.
+func (o *Overlay) Update(values map[ptp.DevicePropCode]int64) {
+	var dirty []image.Rectangle
+
+	for _, code := range o.vf.codes() {
+		old := o.cache[code]
+		v, ok := values[code]
+		if !ok {
+			if old != nil {
+				dirty = append(dirty, old.img.Rect)
+				delete(o.cache, code)
+			}
+			continue
+		}
+		w := o.vf.Widgets[code]
+		if old != nil && !old.stale(w, v) {
+			continue
+		}
+
+		cw := o.render(w, v)
+		if old != nil {
+			dirty = append(dirty, old.img.Rect)
+		}
+		dirty = append(dirty, cw.img.Rect)
+		o.cache[code] = cw
+	}
+
+	for _, r := range dirty {
+		o.redraw(r)
+	}
+}
+
+// render draws the widget for the given value on the scratch image and moves the part it covers to a new cachedWidget.
+func (o *Overlay) render(w *Widget, val int64) *cachedWidget {
+	w.animated = false
+	o.vf.draw(w, o.scratch, val)
+
+	r := opaqueBounds(o.scratch)
+	img := image.NewRGBA(r)
+	draw.Draw(img, r, o.scratch, r.Min, draw.Src)
+	draw.Draw(o.scratch, r, image.Transparent, image.Point{}, draw.Src)
+
+	cw := &cachedWidget{value: val, animated: w.animated, img: img}
+	for _, d := range w.dependencies {
+		cw.deps = append(cw.deps, d.value)
+	}
+
+	return cw
+}
+
+// redraw clears the rectangle of the layer and draws the cached widgets covering it again, in the same order as
+// Update() draws them.
+func (o *Overlay) redraw(r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+
+	draw.Draw(o.layer, r, image.Transparent, image.Point{}, draw.Src)
+	for _, code := range o.vf.codes() {
+		cw, ok := o.cache[code]
+		if !ok {
+			continue
+		}
+		if ir := r.Intersect(cw.img.Rect); !ir.Empty() {
+			draw.Draw(o.layer, ir, cw.img, ir.Min, draw.Over)
+		}
+	}
+}
+
+// Draw draws the overlay on the image. Only the part of the layer covered by the cached widgets is drawn.
+func (o *Overlay) Draw(img *image.RGBA) {
+	var r image.Rectangle
+	for _, cw := range o.cache {
+		r = r.Union(cw.img.Rect)
+	}
+
+	r = r.Intersect(img.Rect)
+	draw.Draw(img, r, o.layer, r.Min, draw.Over)
+}
+
+// opaqueBounds returns the smallest rectangle holding all pixels of the image that are not fully transparent.
+func opaqueBounds(img *image.RGBA) image.Rectangle {
+	b := img.Rect
+	r := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i := 3; i < len(row); i += 4 {
+			if row[i] == 0 {
+				continue
+			}
+			x := b.Min.X + i/4
+			if x < r.Min.X {
+				r.Min.X = x
+			}
+			if x >= r.Max.X {
+				r.Max.X = x + 1
+			}
+			if y < r.Min.Y {
+				r.Min.Y = y
+			}
+			r.Max.Y = y + 1
+		}
+	}
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+
+	return r
+}
@@ -0,0 +1,62 @@
+package viewfinder
+
+import (
+	"bytes"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestOverlay_Update(t *testing.T) {
+	values := map[ptp.DevicePropCode]int64{
+		ptp.DPC_BatteryLevel:        int64(ip.BAT_Fuji_3bOne),
+		ptp.DPC_ExposureProgramMode: int64(ptp.EPM_Manual),
+		ptp.DPC_FNumber:             560,
+		ptp.DPC_ExposureTime:        40,
+		ptp.DPC_WhiteBalance:        int64(ptp.WB_Daylight),
+	}
+
+	vf := NewFujiXT1Viewfinder(image.NewRGBA(image.Rect(0, 0, 640, 480)))
+	o := NewOverlay(vf)
+
+	check := func(frame time.Time) {
+		t.Helper()
+		vf.Tick(frame)
+		o.Update(values)
+		got := image.NewRGBA(vf.bounds)
+		o.Draw(got)
+
+		want := vf.Render(values)
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("overlay differs from the rendered viewfinder at %s", frame)
+		}
+	}
+
+	check(time.Unix(0, 0))
+	fnumber := o.cache[ptp.DPC_FNumber]
+	battery := o.cache[ptp.DPC_BatteryLevel]
+
+	values[ptp.DPC_FNumber] = 800
+	values[ptp.DPC_WhiteBalance] = int64(ptp.WB_Automatic)
+	delete(values, ptp.DPC_ExposureTime)
+	check(time.Unix(0, int64(600*time.Millisecond)))
+
+	if o.cache[ptp.DPC_FNumber] == fnumber {
+		t.Errorf("widget for %#x not drawn again after its value changed", ptp.DPC_FNumber)
+	}
+	if _, ok := o.cache[ptp.DPC_ExposureTime]; ok {
+		t.Errorf("widget for %#x not removed after its value was dropped", ptp.DPC_ExposureTime)
+	}
+	// The one bar battery warning blinks, so it must be drawn for every frame.
+	if o.cache[ptp.DPC_BatteryLevel] == battery {
+		t.Errorf("blinking widget for %#x not drawn again", ptp.DPC_BatteryLevel)
+	}
+
+	exposure := o.cache[ptp.DPC_ExposureProgramMode]
+	check(time.Unix(0, int64(600*time.Millisecond)))
+	if o.cache[ptp.DPC_ExposureProgramMode] != exposure {
+		t.Errorf("widget for %#x drawn again while its value did not change", ptp.DPC_ExposureProgramMode)
+	}
+}
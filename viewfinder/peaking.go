@@ -0,0 +1,56 @@
+package viewfinder
+
+import (
+	"image"
+	"image/color"
+)
+
+// DefaultFocusPeakingThreshold is the edge strength above which a pixel is considered to be in focus. Lower values
+// highlight more of the image.
+const DefaultFocusPeakingThreshold = 200
+
+// FocusPeakingColour is the colour used to highlight the in-focus areas when no colour is given.
+var FocusPeakingColour = color.RGBA{R: 255, A: 255}
+
+// DrawFocusPeaking highlights the in-focus areas of the image in the given colour to aid manual focusing. The edges in
+// the image are detected using a Sobel operator on the luminance: sharp edges, which are only found in the areas that
+// are in focus, have an edge strength above the given threshold. Draw the focus peaking before drawing the viewfinder
+// widgets so the widgets remain readable.
+func DrawFocusPeaking(img *image.RGBA, c color.Color, threshold int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return
+	}
+
+	// The luminance is computed up front since the image is modified while detecting the edges.
+	lum := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):]
+			lum[y*w+x] = (299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000
+		}
+	}
+
+	r, g, bl, _ := c.RGBA()
+	col := [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8)}
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			gx := lum[i-w+1] + 2*lum[i+1] + lum[i+w+1] - lum[i-w-1] - 2*lum[i-1] - lum[i+w-1]
+			gy := lum[i+w-1] + 2*lum[i+w] + lum[i+w+1] - lum[i-w-1] - 2*lum[i-w] - lum[i-w+1]
+			if abs(gx)+abs(gy) > threshold {
+				p := img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):]
+				p[0], p[1], p[2], p[3] = col[0], col[1], col[2], 255
+			}
+		}
+	}
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
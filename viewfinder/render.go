@@ -0,0 +1,51 @@
+package viewfinder
+
+import (
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+)
+
+// Render draws the widgets for the given device property values on a transparent image the size of the image the
+// viewfinder was made for. This allows previewing the overlay without a camera or compositing it onto an image later
+// on. The widgets are drawn in the order of their property codes, like the Compositor does. Call Tick() first to
+// render blinking and pulsing widgets at a fixed point in time.
+func (vf *Viewfinder) Render(values map[ptp.DevicePropCode]int64) *image.RGBA {
+	img := image.NewRGBA(vf.bounds)
+	for _, code := range vf.codes() {
+		if v, ok := values[code]; ok {
+			vf.DrawWidget(img, code, v)
+		}
+	}
+
+	return img
+}
+
+// codes returns the device property codes of the widgets in ascending order.
+func (vf *Viewfinder) codes() []ptp.DevicePropCode {
+	codes := make([]ptp.DevicePropCode, 0, len(vf.Widgets))
+	for code := range vf.Widgets {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	return codes
+}
+
+// WritePNG writes the image to the given file in PNG format, e.g. to save a snapshot of a rendered viewfinder. An
+// existing file is overwritten.
+func WritePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,132 @@
+package viewfinder
+
+import (
+	"bytes"
+	"flag"
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// update rewrites the golden images in testdata using the current rendering, run 'go test ./viewfinder -update' after
+// changing the looks of a widget and check the new images before committing them.
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+// checkGolden compares the image to the golden image of the given name in testdata.
+func checkGolden(t *testing.T, name string, got *image.RGBA) {
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := WritePNG(path, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%s: %s; run the tests with -update to create the golden image", name, err)
+	}
+	defer f.Close()
+
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewRGBA(golden.Bounds())
+	draw.Draw(want, want.Rect, golden, want.Rect.Min, draw.Src)
+
+	if got.Rect != want.Rect {
+		t.Fatalf("%s: bounds = %s; want %s", name, got.Rect, want.Rect)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Errorf("%s: rendered image differs from %s; run the tests with -update to accept the changes", name, path)
+	}
+}
+
+func TestViewfinder_Render(t *testing.T) {
+	// Render at a fixed time to show the blinking widgets.
+	frame := time.Unix(0, 0)
+
+	check := []struct {
+		name   string
+		vf     func(img *image.RGBA) *Viewfinder
+		width  int
+		height int
+		values map[ptp.DevicePropCode]int64
+	}{
+		{
+			name:   "fuji-xt1",
+			vf:     NewFujiXT1Viewfinder,
+			width:  640,
+			height: 480,
+			values: map[ptp.DevicePropCode]int64{
+				ptp.DPC_BatteryLevel:             int64(ip.BAT_Fuji_3bTwo),
+				ptp.DPC_CaptureDelay:             int64(ip.ST_Fuji_2Sec),
+				ip.DPC_Fuji_CapturesRemaining:    734,
+				ptp.DPC_ExposureBiasCompensation: 0xfeb3, // -1/3
+				ptp.DPC_ExposureMeteringMode:     int64(ptp.EMM_MultiSpot),
+				ptp.DPC_ExposureTime:             40,
+				ptp.DPC_ExposureProgramMode:      int64(ptp.EPM_AperturePriority),
+				ip.DPC_Fuji_ExposureIndex:        0x80001900, // S6400
+				ip.DPC_Fuji_FilmSimulation:       int64(ip.FS_Fuji_Velvia),
+				ptp.DPC_FlashMode:                int64(ip.FM_Fuji_SlowSync),
+				ptp.DPC_FNumber:                  560,
+				ip.DPC_Fuji_FocusMeteringMode:    0x03020404,
+				ptp.DPC_FocusMode:                int64(ip.FCM_Fuji_Single_Auto),
+				ip.DPC_Fuji_ImageAspectRatio:     int64(ip.IS_Fuji_Large_3x2),
+				ip.DPC_Fuji_ImageQuality:         int64(ip.IQ_Fuji_FineAndRAW),
+				ptp.DPC_WhiteBalance:             int64(ptp.WB_Daylight),
+			},
+		},
+		{
+			name:   "generic",
+			vf:     NewGenericViewfinder,
+			width:  320,
+			height: 240,
+			values: map[ptp.DevicePropCode]int64{
+				ptp.DPC_BatteryLevel:        80,
+				ptp.DPC_ExposureIndex:       400,
+				ptp.DPC_ExposureProgramMode: int64(ptp.EPM_Manual),
+				ptp.DPC_ExposureTime:        40,
+				ptp.DPC_FNumber:             800,
+				ptp.DPC_FocusMode:           int64(ptp.FCM_Manual),
+				ptp.DPC_WhiteBalance:        int64(ptp.WB_Automatic),
+			},
+		},
+	}
+
+	for _, c := range check {
+		vf := c.vf(image.NewRGBA(image.Rect(0, 0, c.width, c.height)))
+		vf.Tick(frame)
+		checkGolden(t, c.name, vf.Render(c.values))
+	}
+}
+
+func TestFujiShutterSpeedWidget(t *testing.T) {
+	vf := NewFujiXT1Viewfinder(image.NewRGBA(image.Rect(0, 0, 640, 480)))
+
+	check := map[ptp.DevicePropCode]bool{
+		ptp.DPC_ExposureTime: true,
+		// The encoding of the Fuji shutter speed is unknown, so it must not be drawn as an exposure time.
+		ip.DPC_Fuji_ShutterSpeed: false,
+	}
+	for code, want := range check {
+		img := vf.Render(map[ptp.DevicePropCode]int64{code: 40})
+		var got bool
+		for i := 3; i < len(img.Pix); i += 4 {
+			if img.Pix[i] != 0 {
+				got = true
+				break
+			}
+		}
+		if got != want {
+			t.Errorf("Render() of %#x drawn = %t; want %t", code, got, want)
+		}
+	}
+}
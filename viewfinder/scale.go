@@ -0,0 +1,111 @@
+package viewfinder
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"math"
+)
+
+// referenceHeight is the image height all widget positions and sizes are designed for, being the height of the live
+// view images of the Fuji X-T1. They are scaled to the actual image height.
+const referenceHeight = 480
+
+// scaleFactor returns the factor to scale positions and sizes designed for the reference height with.
+func scaleFactor(img *image.RGBA) float64 {
+	if img.Bounds().Dy() <= 0 {
+		return 1
+	}
+
+	return float64(img.Bounds().Dy()) / referenceHeight
+}
+
+// scaled returns the position or size designed for the reference height scaled to the image, but never less than 1
+// when v is positive so that lines remain visible.
+func scaled(img *image.RGBA, v int) int {
+	s := int(math.Round(float64(v) * scaleFactor(img)))
+	if s < 1 && v > 0 {
+		return 1
+	}
+
+	return s
+}
+
+// glyphScale returns the integer factor the bitmap glyphs are enlarged with for the given scale factor. Bitmap glyphs
+// are never shrunk since they would become illegible.
+func glyphScale(f float64) int {
+	if n := int(math.Round(f)); n > 1 {
+		return n
+	}
+
+	return 1
+}
+
+// scaledFace enlarges the glyphs of a bitmap font face by an integer factor using nearest neighbour scaling, keeping
+// the glyphs crisp.
+type scaledFace struct {
+	font.Face
+	n int
+}
+
+// newScaledFace returns the face enlarged by the given factor or the face itself when the factor is 1.
+func newScaledFace(f font.Face, n int) font.Face {
+	if n <= 1 {
+		return f
+	}
+
+	return &scaledFace{Face: f, n: n}
+}
+
+func (f *scaledFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, advance, ok := f.Face.Glyph(fixed.Point26_6{}, r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	dst := image.NewAlpha(image.Rect(0, 0, dr.Dx()*f.n, dr.Dy()*f.n))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			_, _, _, a := mask.At(maskp.X+x/f.n, maskp.Y+y/f.n).RGBA()
+			dst.Pix[dst.PixOffset(x, y)] = uint8(a >> 8)
+		}
+	}
+
+	min := image.Pt(dot.X.Round()+dr.Min.X*f.n, dot.Y.Round()+dr.Min.Y*f.n)
+
+	return dst.Rect.Add(min), dst, image.Point{}, advance * fixed.Int26_6(f.n), true
+}
+
+func (f *scaledFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	b, advance, ok := f.Face.GlyphBounds(r)
+	n := fixed.Int26_6(f.n)
+
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: b.Min.X * n, Y: b.Min.Y * n},
+		Max: fixed.Point26_6{X: b.Max.X * n, Y: b.Max.Y * n},
+	}, advance * n, ok
+}
+
+func (f *scaledFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	advance, ok := f.Face.GlyphAdvance(r)
+
+	return advance * fixed.Int26_6(f.n), ok
+}
+
+func (f *scaledFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return f.Face.Kern(r0, r1) * fixed.Int26_6(f.n)
+}
+
+func (f *scaledFace) Metrics() font.Metrics {
+	m := f.Face.Metrics()
+	n := fixed.Int26_6(f.n)
+
+	return font.Metrics{
+		Height:     m.Height * n,
+		Ascent:     m.Ascent * n,
+		Descent:    m.Descent * n,
+		XHeight:    m.XHeight * n,
+		CapHeight:  m.CapHeight * n,
+		CaretSlope: m.CaretSlope,
+	}
+}
@@ -0,0 +1,119 @@
+package viewfinder
+
+import (
+	"github.com/malc0mn/ptp-ip/ip"
+	"github.com/malc0mn/ptp-ip/ptp"
+	"golang.org/x/image/font"
+	"image"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// selfTimerTextSize is the size of the countdown digits in pixels at the reference height.
+	selfTimerTextSize = 4 * textSize
+	// selfTimerGrace is the time the countdown keeps waiting for the capture complete event after the delay passed.
+	selfTimerGrace = 5 * time.Second
+)
+
+// selfTimer holds the end of the running self-timer countdown. It is started from another goroutine than the one
+// drawing the frames, hence the mutex.
+type selfTimer struct {
+	mu  sync.Mutex
+	end time.Time
+}
+
+// StartSelfTimer starts the countdown of the SelfTimer widget, see DrawSelfTimer(). Call it right before releasing the
+// shutter with the capture delay set on the camera, see ip.Client.GetCaptureDelay(). The countdown stops as soon as
+// the client receives the ptp.EC_CaptureComplete event or when the event did not arrive shortly after the delay
+// passed. The client can be nil, in which case the countdown stops when the delay passed.
+func (vf *Viewfinder) StartSelfTimer(c *ip.Client, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+
+	end := time.Now().Add(delay)
+	vf.timer.mu.Lock()
+	vf.timer.end = end
+	vf.timer.mu.Unlock()
+
+	if c == nil {
+		return
+	}
+
+	// Subscribe before returning: the shutter is released after this call.
+	events := c.Subscribe(ptp.EC_CaptureComplete)
+	go func() {
+		defer c.Unsubscribe(events)
+
+		select {
+		case <-events:
+		case <-time.After(delay + selfTimerGrace):
+		}
+		vf.stopSelfTimer(end)
+	}()
+}
+
+// StopSelfTimer stops the running countdown, e.g. when the capture failed.
+func (vf *Viewfinder) StopSelfTimer() {
+	vf.timer.mu.Lock()
+	vf.timer.end = time.Time{}
+	vf.timer.mu.Unlock()
+}
+
+// stopSelfTimer stops the countdown ending at the given time, leaving a countdown started later on running.
+func (vf *Viewfinder) stopSelfTimer(end time.Time) {
+	vf.timer.mu.Lock()
+	if vf.timer.end.Equal(end) {
+		vf.timer.end = time.Time{}
+	}
+	vf.timer.mu.Unlock()
+}
+
+// DrawSelfTimer draws the seconds left before the self-timer releases the shutter using the SelfTimer widget. Nothing
+// is drawn when no countdown is running, see StartSelfTimer().
+func DrawSelfTimer(vf *Viewfinder, img *image.RGBA) {
+	if vf.SelfTimer == nil {
+		return
+	}
+
+	vf.timer.mu.Lock()
+	end := vf.timer.end
+	vf.timer.mu.Unlock()
+	if end.IsZero() {
+		return
+	}
+
+	now := vf.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	left := end.Sub(now)
+	if left <= 0 {
+		return
+	}
+
+	vf.draw(vf.SelfTimer, img, int64((left+time.Second-1)/time.Second))
+}
+
+// NewSelfTimerWidget returns a widget drawing the self-timer countdown in large digits in the centre of the image.
+func NewSelfTimerWidget(img *image.RGBA) *Widget {
+	face := newTextFace(img, selfTimerTextSize)
+	// The baseline is positioned so the digits are centred vertically.
+	x := img.Bounds().Min.X + img.Bounds().Dx()/2
+	y := img.Bounds().Min.Y + (img.Bounds().Dy()+face.Metrics().CapHeight.Ceil())/2
+
+	w := NewWidget(img, 255, 255, 255, face, x, y)
+	w.Draw = drawSelfTimer
+
+	return w
+}
+
+func drawSelfTimer(w *Widget, val int64) {
+	w.ResetToOrigin()
+
+	s := strconv.FormatInt(val, 10)
+	w.Dot.X -= font.MeasureString(w.Face, s) / 2
+	w.DrawString(s)
+}
@@ -0,0 +1,174 @@
+package viewfinder
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// platePadding is the space in pixels at the reference height between the text of a widget and the edge of its plate.
+const platePadding = 2
+
+// Theme defines the colours the widgets are drawn with.
+type Theme struct {
+	// Foreground is the colour of the text and icons.
+	Foreground color.Color
+	// Warning is the colour drawing attention to a problem, such as a low battery.
+	Warning color.Color
+	// Highlight is the colour marking the current value on a scale, such as the exposure bias compensation.
+	Highlight color.Color
+	// Inactive is the colour of disabled or neutral values.
+	Inactive color.Color
+	// Confirmation is the colour of the focus indicator.
+	Confirmation color.Color
+	// Opacity ranging from 0 to 1 is applied to all of the above colours. An Opacity of 0 is treated as 1 so the
+	// widgets remain visible.
+	Opacity float64
+	// Plate is the colour of the box drawn behind the text of each widget, keeping it readable on bright scenes. Use a
+	// translucent colour to keep the scene visible. No plates are drawn when nil.
+	Plate color.Color
+}
+
+// DefaultTheme holds the colours of the X-T1 viewfinder.
+var DefaultTheme = Theme{
+	Foreground:   color.RGBA{R: 255, G: 255, B: 255, A: 255},
+	Warning:      color.RGBA{R: 255, A: 255},
+	Highlight:    color.RGBA{R: 255, G: 185, B: 10, A: 255},
+	Inactive:     color.RGBA{R: 100, G: 100, B: 100, A: 255},
+	Confirmation: color.RGBA{G: 200, A: 255},
+	Opacity:      1,
+}
+
+// themeColour selects one of the colours of a Theme.
+type themeColour int
+
+const (
+	themeForeground themeColour = iota
+	themeWarning
+	themeHighlight
+	themeInactive
+	themeConfirmation
+)
+
+// colour returns the selected colour with the opacity of the theme applied. The colours of the DefaultTheme are used
+// for the colours that are not set.
+func (t Theme) colour(tc themeColour) *image.Uniform {
+	var c, def color.Color
+	switch tc {
+	case themeWarning:
+		c, def = t.Warning, DefaultTheme.Warning
+	case themeHighlight:
+		c, def = t.Highlight, DefaultTheme.Highlight
+	case themeInactive:
+		c, def = t.Inactive, DefaultTheme.Inactive
+	case themeConfirmation:
+		c, def = t.Confirmation, DefaultTheme.Confirmation
+	default:
+		c, def = t.Foreground, DefaultTheme.Foreground
+	}
+	if c == nil {
+		c = def
+	}
+
+	if t.Opacity <= 0 || t.Opacity >= 1 {
+		return image.NewUniform(c)
+	}
+
+	r, g, b, a := c.RGBA()
+	o := t.Opacity
+
+	return image.NewUniform(color.RGBA64{
+		R: uint16(float64(r) * o),
+		G: uint16(float64(g) * o),
+		B: uint16(float64(b) * o),
+		A: uint16(float64(a) * o),
+	})
+}
+
+// ApplyTheme draws all widgets of the viewfinder using the colours of the theme. Colours set by a layout are replaced,
+// so apply the theme before applying a layout, see ApplyLayout().
+func (vf *Viewfinder) ApplyTheme(t Theme) {
+	for _, w := range vf.Widgets {
+		w.SetTheme(t)
+	}
+	for _, w := range []*Widget{vf.FocusIndicator, vf.CapturesRemaining, vf.LightMeter, vf.SelfTimer} {
+		if w != nil {
+			w.SetTheme(t)
+		}
+	}
+}
+
+// SetTheme draws the widget using the colours of the theme.
+func (w *Widget) SetTheme(t Theme) {
+	w.theme = t
+	w.colour = t.colour(w.role)
+	w.ResetColour()
+}
+
+// UseWarningColour sets the drawing colour to the warning colour of the theme until ResetColour() is called.
+func (w *Widget) UseWarningColour() {
+	w.Src = w.theme.colour(themeWarning)
+}
+
+// UseHighlightColour sets the drawing colour to the highlight colour of the theme until ResetColour() is called.
+func (w *Widget) UseHighlightColour() {
+	w.Src = w.theme.colour(themeHighlight)
+}
+
+// UseInactiveColour sets the drawing colour to the inactive colour of the theme until ResetColour() is called.
+func (w *Widget) UseInactiveColour() {
+	w.Src = w.theme.colour(themeInactive)
+}
+
+// DrawString draws the string at the dot and advances the dot, like font.Drawer.DrawString. While measuring, nothing
+// is drawn and the bounds of the string are added to the area covered by the widget instead.
+func (w *Widget) DrawString(s string) {
+	if !w.measuring {
+		w.Drawer.DrawString(s)
+		return
+	}
+
+	b, advance := w.BoundString(s)
+	w.bounds = w.bounds.Union(image.Rect(b.Min.X.Floor(), b.Min.Y.Floor(), b.Max.X.Ceil(), b.Max.Y.Ceil()))
+	w.Dot.X += advance
+}
+
+// fill fills the rectangle with the drawing colour. While measuring, nothing is drawn and the rectangle is added to the
+// area covered by the widget instead.
+func (w *Widget) fill(r image.Rectangle) {
+	if w.measuring {
+		w.bounds = w.bounds.Union(r)
+		return
+	}
+
+	if dst, ok := w.Dst.(draw.Image); ok {
+		draw.Draw(dst, r, w.Src, image.Point{}, draw.Over)
+	}
+}
+
+// outline draws the outline of the rectangle with the given line width in the drawing colour, see fill().
+func (w *Widget) outline(r image.Rectangle, width int) {
+	w.fill(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width))
+	w.fill(image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y))
+	w.fill(image.Rect(r.Min.X, r.Min.Y+width, r.Min.X+width, r.Max.Y-width))
+	w.fill(image.Rect(r.Max.X-width, r.Min.Y+width, r.Max.X, r.Max.Y-width))
+}
+
+// drawPlate draws the plate of the theme behind the text the widget draws for the given value. The widget is drawn
+// once without a destination image to measure the area its text covers: the drawers skip drawing shapes when the
+// destination is not an *image.RGBA.
+func (w *Widget) drawPlate(img *image.RGBA, val int64) {
+	w.Dst = nil
+	w.measuring = true
+	w.bounds = image.Rectangle{}
+	w.Draw(w, val)
+	w.measuring = false
+	w.Dst = img
+
+	if w.bounds.Empty() {
+		return
+	}
+
+	r := w.bounds.Inset(-scaled(img, platePadding))
+	draw.Draw(img, r, image.NewUniform(w.theme.Plate), image.Point{}, draw.Over)
+}
@@ -13,7 +13,7 @@ import (
 //
 // Supported icons
 //
-// - 3 bars battery indicator:
+// - 3 bars battery indicator (the Fuji X-T1 viewfinder draws the battery as shapes instead):
 //   3/3 = "BAT"
 //   2/3 = "bCT"
 //   1/3 = "baU"
@@ -68,6 +68,9 @@ import (
 //   underwater (fish bubbles)        = "VW"
 //   custom                           = "Z["
 //   colour temperature               = "]^"
+//
+// - Flash:
+//   flash (lightning bolt) = "xy"
 var VFGlyphs6x13 = &basicfont.Face{
 	Advance: 6,
 	Width:   6,
@@ -1406,35 +1409,35 @@ var mask6x13 = &image.Alpha{
 		0x00, 0xff, 0x00, 0xff, 0x00, 0xff,
 		0xff, 0xff, 0xff, 0x00, 0xff, 0x00,
 
-		// 0x78 'x'
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// 0x78 'x' -- flash 'left'
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0xff,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0xff, 0xff, 0xff,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0x00, 0xff, 0x00, 0x00, 0xff, 0x00,
-		0x00, 0x00, 0xff, 0xff, 0x00, 0x00,
-		0x00, 0x00, 0xff, 0xff, 0x00, 0x00,
-		0x00, 0xff, 0x00, 0x00, 0xff, 0x00,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0xff,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0xff, 0xff, 0x00,
+		0x00, 0x00, 0x00, 0xff, 0x00, 0x00,
+		0x00, 0x00, 0xff, 0x00, 0x00, 0x00,
+		0x00, 0xff, 0x00, 0x00, 0x00, 0x00,
 
-		// 0x79 'y'
+		// 0x79 'y' -- flash 'right'
+		0x00, 0xff, 0xff, 0xff, 0xff, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0x00, 0x00,
+		0xff, 0xff, 0xff, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0xff, 0x00, 0x00, 0x00, 0xff, 0xff,
-		0x00, 0xff, 0xff, 0xff, 0x00, 0xff,
-		0x00, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0xff, 0x00, 0x00, 0x00, 0x00, 0xff,
-		0x00, 0xff, 0xff, 0xff, 0xff, 0x00,
 
 		// 0x7a 'z'
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
@@ -1,28 +1,147 @@
 package viewfinder
 
 import (
+	"fmt"
+	"github.com/malc0mn/ptp-ip/ip"
 	"github.com/malc0mn/ptp-ip/ptp"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
 	"image"
 	"image/color"
+	"math"
+	"time"
 )
 
+// LowCapturesRemaining is the amount of remaining captures below which the captures remaining widget starts blinking.
+var LowCapturesRemaining int64 = 10
+
+const (
+	// blinkInterval is the time a blinking widget stays visible or hidden.
+	blinkInterval = 500 * time.Millisecond
+	// pulsePeriod is the time a pulsing widget takes to fade in and out again.
+	pulsePeriod = time.Second
+)
+
+const (
+	// focusConfirmed and focusSearching are the values DrawMetadata() draws the FocusIndicator with.
+	focusConfirmed = 1
+	focusSearching = 2
+)
+
+// Anchor defines the corner or edge of the image a widget is positioned in.
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTopRight
+	AnchorBottomLeft
+	AnchorBottomRight
+	AnchorTop
+	AnchorBottom
+	AnchorLeft
+	AnchorRight
+)
+
+// anchors maps the names used in configuration files to their Anchor.
+var anchors = map[string]Anchor{
+	"top-left":     AnchorTopLeft,
+	"top-right":    AnchorTopRight,
+	"bottom-left":  AnchorBottomLeft,
+	"bottom-right": AnchorBottomRight,
+	"top":          AnchorTop,
+	"bottom":       AnchorBottom,
+	"left":         AnchorLeft,
+	"right":        AnchorRight,
+}
+
+// ParseAnchor returns the Anchor for the given name, e.g. 'top-left' or 'bottom-right'.
+func ParseAnchor(name string) (Anchor, error) {
+	if a, ok := anchors[name]; ok {
+		return a, nil
+	}
+
+	return AnchorTopLeft, fmt.Errorf("unknown anchor '%s'", name)
+}
+
+// anchorPoint returns the top left point of an area of the given size positioned at the anchor of the rectangle. The
+// offsets move the area away from the anchor towards the centre of the rectangle.
+func anchorPoint(r image.Rectangle, a Anchor, width, height, dx, dy int) image.Point {
+	p := image.Pt(r.Min.X+dx, r.Min.Y+dy)
+
+	switch a {
+	case AnchorTopRight, AnchorBottomRight, AnchorRight:
+		p.X = r.Max.X - dx - width
+	case AnchorTop, AnchorBottom:
+		p.X = r.Min.X + (r.Dx()-width)/2 + dx
+	}
+
+	switch a {
+	case AnchorBottomLeft, AnchorBottomRight, AnchorBottom:
+		p.Y = r.Max.Y - dy - height
+	case AnchorLeft, AnchorRight:
+		p.Y = r.Min.Y + (r.Dy()-height)/2 + dy
+	}
+
+	return p
+}
+
 // WidgetDrawer defines the signature of the drawer function of a widget.
 type WidgetDrawer func(*Widget, int64)
 
 // Viewfinder holds a list of pointers to Widgets mapped to their ptp.DevicePropCode.
 type Viewfinder struct {
 	Widgets map[ptp.DevicePropCode]*Widget
+	// Names maps the names used in configuration files to the ptp.DevicePropCode of the widgets.
+	Names map[string]ptp.DevicePropCode
+	// FocusIndicator confirms focus when drawn with a value of 1 and pulses while the camera is searching for focus
+	// when drawn with a value of 2. It is drawn by DrawMetadata() and can be mapped to a device property as well.
+	FocusIndicator *Widget
+	// CapturesRemaining displays the amount of captures that still fit on the store. It is drawn by DrawStorageInfo()
+	// and can be mapped to a device property as well.
+	CapturesRemaining *Widget
+	// LightMeter displays the deviation from the metered exposure. It is drawn by DrawLightMeter() since not all
+	// cameras report the metered deviation as a device property.
+	LightMeter *Widget
+	// SelfTimer displays the seconds left before the self-timer releases the shutter. It is drawn by DrawSelfTimer()
+	// once the countdown is started using StartSelfTimer().
+	SelfTimer *Widget
+	// Histogram displays the live histogram. It is drawn separately using HistogramWidget.Draw() since the histogram
+	// has to be computed from the live view frame.
+	Histogram *HistogramWidget
+	// bounds holds the bounds of the image the viewfinder was made for.
+	bounds image.Rectangle
+	// now holds the time of the frame being drawn, see Tick().
+	now time.Time
+	// timer holds the state of the self-timer countdown.
+	timer selfTimer
+}
+
+// Tick sets the time of the frame being drawn which drives the animation of blinking and pulsing widgets. Call it once
+// for each frame before drawing the widgets. When Tick() is never called, the current time is used instead.
+func (vf *Viewfinder) Tick(t time.Time) {
+	vf.now = t
 }
 
 // DrawWidget draws the widget mapped to the given device property code on the given image with the given value.
 func (vf *Viewfinder) DrawWidget(img *image.RGBA, code ptp.DevicePropCode, val int64) {
 	if w, ok := vf.Widgets[code]; ok {
-		w.Dst = img
-		w.Draw(w, val)
+		vf.draw(w, img, val)
+	}
+}
+
+// draw draws the widget on the image with the given value at the time of the current frame.
+func (vf *Viewfinder) draw(w *Widget, img *image.RGBA, val int64) {
+	w.Dst = img
+	w.value = val
+	w.now = vf.now
+	if w.now.IsZero() {
+		w.now = time.Now()
+	}
+	if w.theme.Plate != nil {
+		w.drawPlate(img, val)
 	}
+	w.Draw(w, val)
 }
 
 // NewViewfinder creates a vendor specific viewfinder using the image passed in to allow each Widget to calibrate its
@@ -44,13 +163,99 @@ func DrawViewfinder(vf *Viewfinder, img *image.RGBA, s []*ptp.DevicePropDesc) {
 	}
 }
 
+// PropertyValues returns the current values of the device properties, as used by Render() and Overlay.Update().
+func PropertyValues(s []*ptp.DevicePropDesc) map[ptp.DevicePropCode]int64 {
+	values := make(map[ptp.DevicePropCode]int64, len(s))
+	for _, p := range s {
+		values[p.DevicePropertyCode] = p.CurrentValueAsInt64()
+	}
+
+	return values
+}
+
+// DrawMetadata draws the widgets depending on the camera state sent along with the live view frames, such as the focus
+// indicator. Nothing is drawn when the metadata is nil.
+func DrawMetadata(vf *Viewfinder, img *image.RGBA, m *ip.LiveViewMetadata) {
+	if m == nil || vf.FocusIndicator == nil {
+		return
+	}
+
+	var focus int64
+	switch m.FocusState {
+	case ip.FS_Focused:
+		focus = focusConfirmed
+	case ip.FS_Searching:
+		focus = focusSearching
+	}
+	vf.draw(vf.FocusIndicator, img, focus)
+}
+
+// DrawStorageInfo draws the amount of captures that still fit on the given store using the CapturesRemaining widget.
+// When the store does not report the amount of images, the free space in bytes is drawn instead.
+func DrawStorageInfo(vf *Viewfinder, img *image.RGBA, si *ptp.StorageInfo) {
+	w := vf.CapturesRemaining
+	if si == nil || w == nil {
+		return
+	}
+
+	if si.FreeSpaceInImages != 0xFFFFFFFF {
+		vf.draw(w, img, int64(si.FreeSpaceInImages))
+		return
+	}
+
+	if si.FreeSpaceInBytes == 0xFFFFFFFF {
+		return
+	}
+	w.Dst = img
+	w.ResetToOrigin()
+	w.ResetColour()
+	w.DrawString(formatFreeSpace(si.FreeSpaceInBytes))
+}
+
+// DrawLightMeter draws the deviation from the metered exposure, given in thousandths of a stop, using the LightMeter
+// widget. Nothing is drawn when the viewfinder has no light meter.
+func DrawLightMeter(vf *Viewfinder, img *image.RGBA, deviation int64) {
+	if vf.LightMeter == nil {
+		return
+	}
+
+	vf.draw(vf.LightMeter, img, deviation)
+}
+
+// formatFreeSpace formats the given amount of bytes as gibibytes or, when less than one gibibyte is left, mebibytes.
+func formatFreeSpace(b uint64) string {
+	const gib = 1 << 30
+	if b >= gib {
+		return fmt.Sprintf("%.1fG", float64(b)/gib)
+	}
+
+	return fmt.Sprintf("%dM", b>>20)
+}
+
 // Widget defines a viewfinder widget.
 type Widget struct {
 	*font.Drawer
 	origin fixed.Point26_6
 	face   font.Face
+	text   font.Face
 	colour *image.Uniform
-	Draw   WidgetDrawer
+	// now holds the time of the frame being drawn, used to animate the widget.
+	now time.Time
+	// scale holds the factor to scale positions and sizes designed for the reference height with.
+	scale float64
+	// value holds the value the widget was last drawn with, allowing widgets to depend on each other.
+	value int64
+	// theme holds the colours the widget is drawn with and role selects the colour of the theme used by default.
+	theme Theme
+	role  themeColour
+	// measuring is set while measuring the area covered by the text of the widget, which is held in bounds.
+	measuring bool
+	bounds    image.Rectangle
+	// animated is set when the widget blinked or pulsed while it was drawn, see Overlay.
+	animated bool
+	// dependencies holds the widgets of which the value changes the way this widget is drawn, see Overlay.
+	dependencies []*Widget
+	Draw         WidgetDrawer
 }
 
 // SetColour sets the font colour to the given red, green and blue values.
@@ -63,6 +268,43 @@ func (w *Widget) ResetColour() {
 	w.Src = w.colour
 }
 
+// Blink returns true when a blinking widget must be drawn in the current frame.
+func (w *Widget) Blink() bool {
+	w.animated = true
+
+	return w.now.UnixNano()/int64(blinkInterval)%2 == 0
+}
+
+// Pulse returns the intensity of a pulsing widget in the current frame, fading from 0 to 1 and back to 0 again within
+// the pulse period.
+func (w *Widget) Pulse() float64 {
+	w.animated = true
+	phase := float64(w.now.UnixNano()%int64(pulsePeriod)) / float64(pulsePeriod)
+
+	return 1 - math.Abs(2*phase-1)
+}
+
+// scaled returns the position or size designed for the reference height scaled to the image of the widget.
+func (w *Widget) scaled(v int) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(float64(v) * w.scale * 64))
+}
+
+// px returns the position or size designed for the reference height scaled to the image in whole pixels, but never
+// less than 1 when v is positive so that lines remain visible.
+func (w *Widget) px(v int) int {
+	if p := w.scaled(v).Round(); p > 0 || v <= 0 {
+		return p
+	}
+
+	return 1
+}
+
+// UseTextFace switches to the TrueType face of the widget, allowing glyph widgets to draw text for values without an
+// icon. Use ResetFace() to switch back.
+func (w *Widget) UseTextFace() {
+	w.Face = w.text
+}
+
 // ResetFace resets the font face to the original one when the widget was first made.
 func (w *Widget) ResetFace() {
 	w.Face = w.face
@@ -73,11 +315,26 @@ func (w *Widget) ResetToOrigin() {
 	w.Dot = w.origin
 }
 
+// Place moves the origin of the widget to the given anchor of the image. The offsets move the start of the baseline of
+// the widget away from the anchor towards the centre of the image. They are scaled to the image like all other positions.
+func (w *Widget) Place(img *image.RGBA, a Anchor, dx, dy int) {
+	p := anchorPoint(img.Bounds(), a, 0, 0, scaled(img, dx), scaled(img, dy))
+	w.origin = fixed.P(p.X, p.Y)
+	w.ResetToOrigin()
+}
+
 // NewWidget needs a colour to draw in and x/y coordinates to start drawing from.
 // Important: the destination image is NOT set but can be set later using Widget.SetImage()!
-func NewWidget(img *image.RGBA, r, g, b uint8, f *basicfont.Face, x, y int) *Widget {
+// Widgets using a basicfont.Face get a TrueType face scaled to the image for drawing text as well.
+func NewWidget(img *image.RGBA, r, g, b uint8, f font.Face, x, y int) *Widget {
 	point := fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
 	col := image.NewUniform(color.RGBA{R: r, G: g, B: b, A: 255})
+	scale := scaleFactor(img)
+	text := f
+	if _, ok := f.(*basicfont.Face); ok {
+		text = NewTextFace(img)
+		f = newScaledFace(f, glyphScale(scale))
+	}
 
 	return &Widget{
 		Drawer: &font.Drawer{
@@ -88,17 +345,20 @@ func NewWidget(img *image.RGBA, r, g, b uint8, f *basicfont.Face, x, y int) *Wid
 		},
 		origin: point,
 		face:   f,
+		text:   text,
 		colour: col,
+		scale:  scale,
+		theme:  DefaultTheme,
 	}
 }
 
-// NewFontWidget returns a new Widget using basicfont.Face7x13 for its basicfont.Face.
+// NewFontWidget returns a new Widget using a TrueType face scaled to the image, see NewTextFace().
 func NewFontWidget(img *image.RGBA, r, g, b uint8, x, y int) *Widget {
-	return NewWidget(img, r, g, b, basicfont.Face7x13, x, y)
+	return NewWidget(img, r, g, b, NewTextFace(img), x, y)
 }
 
-// NewWhiteFontWidget returns a new Widget using basicfont.Face7x13 for its basicfont.Face and white (255, 255, 255) for
-// its drawing colour.
+// NewWhiteFontWidget returns a new Widget using a TrueType face scaled to the image and white (255, 255, 255) for its
+// drawing colour.
 func NewWhiteFontWidget(img *image.RGBA, x, y int) *Widget {
 	return NewFontWidget(img, 255, 255, 255, x, y)
 }
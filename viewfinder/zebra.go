@@ -0,0 +1,29 @@
+package viewfinder
+
+import "image"
+
+const (
+	// DefaultZebraThreshold is the brightness in IRE above which pixels are marked as over-exposed.
+	DefaultZebraThreshold = 95
+	// zebraStripeWidth is the width in pixels of the stripes of the zebra pattern at the reference height.
+	zebraStripeWidth = 4
+)
+
+// DrawZebra marks the pixels of the image with a brightness above the given threshold with a diagonal zebra pattern to
+// warn about over-exposure. The threshold is expressed in IRE, ranging from 0 for black to 100 for white.
+func DrawZebra(img *image.RGBA, ire int) {
+	limit := ire * 255 / 100
+	stripe := scaled(img, zebraStripeWidth)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if ((x+y)/stripe)%2 != 0 {
+				continue
+			}
+			p := img.Pix[img.PixOffset(x, y):]
+			if (299*int(p[0])+587*int(p[1])+114*int(p[2]))/1000 >= limit {
+				p[0], p[1], p[2], p[3] = 0, 0, 0, 255
+			}
+		}
+	}
+}